@@ -20,6 +20,7 @@ type Config struct {
 	Zencoder               *Zencoder
 	Bitmovin               *Bitmovin
 	MediaConvert           *MediaConvert
+	Verification           *Verification
 	Log                    *logging.Config
 }
 
@@ -91,6 +92,17 @@ type MediaConvert struct {
 	Destination     string `envconfig:"MEDIACONVERT_DESTINATION"`
 }
 
+// Verification represents the set of configurations for the verification of
+// job outputs. Outputs stored in S3-compatible storage (s3:// locations) are
+// fetched from the given endpoint, using path-style URLs, and requests are
+// signed when credentials are given.
+type Verification struct {
+	S3Endpoint      string `envconfig:"VERIFICATION_S3_ENDPOINT" default:"https://s3.amazonaws.com"`
+	S3Region        string `envconfig:"VERIFICATION_S3_REGION" default:"us-east-1"`
+	AccessKeyID     string `envconfig:"VERIFICATION_AWS_ACCESS_KEY_ID"`
+	SecretAccessKey string `envconfig:"VERIFICATION_AWS_SECRET_ACCESS_KEY"`
+}
+
 // LoadConfig loads the configuration of the API using environment variables.
 func LoadConfig() *Config {
 	var cfg Config
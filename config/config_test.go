@@ -52,6 +52,10 @@ func TestLoadConfigFromEnv(t *testing.T) {
 		"MEDIACONVERT_QUEUE_ARN":                   "arn:aws:mediaconvert:us-east-1:some-queue:queues/Default",
 		"MEDIACONVERT_ROLE_ARN":                    "arn:aws:iam::some-account:role/some-role",
 		"MEDIACONVERT_DESTINATION":                 "s3://mc-destination/",
+		"VERIFICATION_S3_ENDPOINT":                 "http://minio:9000",
+		"VERIFICATION_S3_REGION":                   "sa-east-1",
+		"VERIFICATION_AWS_ACCESS_KEY_ID":           "verification-access-key-id",
+		"VERIFICATION_AWS_SECRET_ACCESS_KEY":       "verification-secret-access-key",
 		"SWAGGER_MANIFEST_PATH":                    "/opt/video-transcoding-api-swagger.json",
 		"HTTP_ACCESS_LOG":                          accessLog,
 		"HTTP_PORT":                                "8080",
@@ -111,6 +115,12 @@ func TestLoadConfigFromEnv(t *testing.T) {
 			Role:            "arn:aws:iam::some-account:role/some-role",
 			Destination:     "s3://mc-destination/",
 		},
+		Verification: &Verification{
+			S3Endpoint:      "http://minio:9000",
+			S3Region:        "sa-east-1",
+			AccessKeyID:     "verification-access-key-id",
+			SecretAccessKey: "verification-secret-access-key",
+		},
 		Server: &server.Config{
 			HTTPPort:      8080,
 			HTTPAccessLog: &accessLog,
@@ -205,6 +215,10 @@ func TestLoadConfigFromEnvWithDefaults(t *testing.T) {
 			EncodingVersion:  "STABLE",
 		},
 		MediaConvert: &MediaConvert{},
+		Verification: &Verification{
+			S3Endpoint: "https://s3.amazonaws.com",
+			S3Region:   "us-east-1",
+		},
 		Server: &server.Config{
 			HTTPPort:      8080,
 			HTTPAccessLog: &accessLog,
@@ -10,7 +10,9 @@ import (
 type fakeRepository struct {
 	triggerError bool
 	presetmaps   map[string]*db.PresetMap
+	versions     map[string][]db.PresetMap
 	localpresets map[string]*db.LocalPreset
+	presetgroups map[string]*db.PresetGroup
 	jobs         []*db.Job
 }
 
@@ -21,7 +23,9 @@ func NewFakeRepository(triggerError bool) db.Repository {
 	return &fakeRepository{
 		triggerError: triggerError,
 		presetmaps:   make(map[string]*db.PresetMap),
+		versions:     make(map[string][]db.PresetMap),
 		localpresets: make(map[string]*db.LocalPreset),
+		presetgroups: make(map[string]*db.PresetGroup),
 	}
 }
 
@@ -36,6 +40,18 @@ func (d *fakeRepository) CreateJob(job *db.Job) error {
 	return nil
 }
 
+func (d *fakeRepository) UpdateJob(job *db.Job) error {
+	if d.triggerError {
+		return errors.New("database error")
+	}
+	index, err := d.findJob(job.ID)
+	if err != nil {
+		return err
+	}
+	d.jobs[index] = job
+	return nil
+}
+
 func (d *fakeRepository) DeleteJob(job *db.Job) error {
 	if d.triggerError {
 		return errors.New("database error")
@@ -105,7 +121,9 @@ func (d *fakeRepository) CreatePresetMap(presetmap *db.PresetMap) error {
 	if _, ok := d.presetmaps[presetmap.Name]; ok {
 		return db.ErrPresetMapAlreadyExists
 	}
+	presetmap.Version = 1
 	d.presetmaps[presetmap.Name] = presetmap
+	d.versions[presetmap.Name] = []db.PresetMap{*presetmap}
 	return nil
 }
 
@@ -113,10 +131,13 @@ func (d *fakeRepository) UpdatePresetMap(presetmap *db.PresetMap) error {
 	if d.triggerError {
 		return errors.New("database error")
 	}
-	if _, ok := d.presetmaps[presetmap.Name]; !ok {
+	current, ok := d.presetmaps[presetmap.Name]
+	if !ok {
 		return db.ErrPresetMapNotFound
 	}
+	presetmap.Version = current.Version + 1
 	d.presetmaps[presetmap.Name] = presetmap
+	d.versions[presetmap.Name] = append(d.versions[presetmap.Name], *presetmap)
 	return nil
 }
 
@@ -138,6 +159,7 @@ func (d *fakeRepository) DeletePresetMap(presetmap *db.PresetMap) error {
 		return db.ErrPresetMapNotFound
 	}
 	delete(d.presetmaps, presetmap.Name)
+	delete(d.versions, presetmap.Name)
 	return nil
 }
 
@@ -152,6 +174,42 @@ func (d *fakeRepository) ListPresetMaps() ([]db.PresetMap, error) {
 	return presetmaps, nil
 }
 
+func (d *fakeRepository) GetPresetMapVersion(name string, version uint) (*db.PresetMap, error) {
+	if d.triggerError {
+		return nil, errors.New("database error")
+	}
+	for _, presetmap := range d.versions[name] {
+		if presetmap.Version == version {
+			return &presetmap, nil
+		}
+	}
+	return nil, db.ErrPresetMapVersionNotFound
+}
+
+func (d *fakeRepository) UpdatePresetMapVersion(presetmap *db.PresetMap) error {
+	if d.triggerError {
+		return errors.New("database error")
+	}
+	for i, version := range d.versions[presetmap.Name] {
+		if version.Version == presetmap.Version {
+			d.versions[presetmap.Name][i] = *presetmap
+			return nil
+		}
+	}
+	return db.ErrPresetMapVersionNotFound
+}
+
+func (d *fakeRepository) ListPresetMapVersions(name string) ([]db.PresetMap, error) {
+	if d.triggerError {
+		return nil, errors.New("database error")
+	}
+	versions, ok := d.versions[name]
+	if !ok {
+		return nil, db.ErrPresetMapNotFound
+	}
+	return append([]db.PresetMap(nil), versions...), nil
+}
+
 func (d *fakeRepository) CreateLocalPreset(preset *db.LocalPreset) error {
 	if d.triggerError {
 		return errors.New("database error")
@@ -198,3 +256,60 @@ func (d *fakeRepository) DeleteLocalPreset(preset *db.LocalPreset) error {
 	delete(d.localpresets, preset.Name)
 	return nil
 }
+
+func (d *fakeRepository) CreatePresetGroup(group *db.PresetGroup) error {
+	if d.triggerError {
+		return errors.New("database error")
+	}
+	if group.Name == "" {
+		return errors.New("preset group name missing")
+	}
+	if _, ok := d.presetgroups[group.Name]; ok {
+		return db.ErrPresetGroupAlreadyExists
+	}
+	d.presetgroups[group.Name] = group
+	return nil
+}
+
+func (d *fakeRepository) UpdatePresetGroup(group *db.PresetGroup) error {
+	if d.triggerError {
+		return errors.New("database error")
+	}
+	if _, ok := d.presetgroups[group.Name]; !ok {
+		return db.ErrPresetGroupNotFound
+	}
+	d.presetgroups[group.Name] = group
+	return nil
+}
+
+func (d *fakeRepository) GetPresetGroup(name string) (*db.PresetGroup, error) {
+	if d.triggerError {
+		return nil, errors.New("database error")
+	}
+	if group, ok := d.presetgroups[name]; ok {
+		return group, nil
+	}
+	return nil, db.ErrPresetGroupNotFound
+}
+
+func (d *fakeRepository) DeletePresetGroup(group *db.PresetGroup) error {
+	if d.triggerError {
+		return errors.New("database error")
+	}
+	if _, ok := d.presetgroups[group.Name]; !ok {
+		return db.ErrPresetGroupNotFound
+	}
+	delete(d.presetgroups, group.Name)
+	return nil
+}
+
+func (d *fakeRepository) ListPresetGroups() ([]db.PresetGroup, error) {
+	if d.triggerError {
+		return nil, errors.New("database error")
+	}
+	groups := make([]db.PresetGroup, 0, len(d.presetgroups))
+	for _, group := range d.presetgroups {
+		groups = append(groups, *group)
+	}
+	return groups, nil
+}
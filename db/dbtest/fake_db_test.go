@@ -91,6 +91,36 @@ func TestGetJobDBError(t *testing.T) {
 	}
 }
 
+func TestUpdateJob(t *testing.T) {
+	repo := NewFakeRepository(false)
+	job := db.Job{ID: "j-123", ProviderName: "myprovider"}
+	err := repo.CreateJob(&job)
+	if err != nil {
+		t.Fatal(err)
+	}
+	updatedJob := job
+	updatedJob.Verification = &db.VerificationReport{Status: db.VerificationPassed}
+	err = repo.UpdateJob(&updatedJob)
+	if err != nil {
+		t.Fatal(err)
+	}
+	gotJob, err := repo.GetJob(job.ID)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !reflect.DeepEqual(*gotJob, updatedJob) {
+		t.Errorf("Wrong job returned. Want %#v. Got %#v", updatedJob, *gotJob)
+	}
+}
+
+func TestUpdateJobNotFound(t *testing.T) {
+	repo := NewFakeRepository(false)
+	err := repo.UpdateJob(&db.Job{ID: "j-123"})
+	if err != db.ErrJobNotFound {
+		t.Errorf("Wrong error returned. Want %#v. Got %#v", db.ErrJobNotFound, err)
+	}
+}
+
 func TestDeleteJob(t *testing.T) {
 	repo := NewFakeRepository(false)
 	job := db.Job{ID: "j-123", ProviderName: "myprovider"}
@@ -419,6 +449,54 @@ func TestListPresetMapsDBError(t *testing.T) {
 	}
 }
 
+func TestPresetMapVersions(t *testing.T) {
+	repo := NewFakeRepository(false)
+	preset := db.PresetMap{Name: "mypreset", ProviderMapping: map[string]string{"some": "preset-1"}}
+	err := repo.CreatePresetMap(&preset)
+	if err != nil {
+		t.Fatal(err)
+	}
+	newPresetMap := db.PresetMap{Name: "mypreset", ProviderMapping: map[string]string{"some": "preset-2"}}
+	err = repo.UpdatePresetMap(&newPresetMap)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if newPresetMap.Version != 2 {
+		t.Errorf("UpdatePresetMap: wrong version. Want 2. Got %d", newPresetMap.Version)
+	}
+	versions, err := repo.ListPresetMapVersions("mypreset")
+	if err != nil {
+		t.Fatal(err)
+	}
+	expectedVersions := []db.PresetMap{preset, newPresetMap}
+	if !reflect.DeepEqual(versions, expectedVersions) {
+		t.Errorf("ListPresetMapVersions: wrong versions. Want %#v. Got %#v", expectedVersions, versions)
+	}
+	version, err := repo.GetPresetMapVersion("mypreset", 1)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !reflect.DeepEqual(*version, preset) {
+		t.Errorf("GetPresetMapVersion: wrong version. Want %#v. Got %#v", preset, *version)
+	}
+	_, err = repo.GetPresetMapVersion("mypreset", 3)
+	if err != db.ErrPresetMapVersionNotFound {
+		t.Errorf("GetPresetMapVersion: wrong error. Want %#v. Got %#v", db.ErrPresetMapVersionNotFound, err)
+	}
+	err = repo.UpdatePresetMapVersion(&db.PresetMap{Name: "mypreset", Version: 3})
+	if err != db.ErrPresetMapVersionNotFound {
+		t.Errorf("UpdatePresetMapVersion: wrong error. Want %#v. Got %#v", db.ErrPresetMapVersionNotFound, err)
+	}
+	err = repo.DeletePresetMap(&preset)
+	if err != nil {
+		t.Fatal(err)
+	}
+	_, err = repo.ListPresetMapVersions("mypreset")
+	if err != db.ErrPresetMapNotFound {
+		t.Errorf("ListPresetMapVersions: wrong error. Want %#v. Got %#v", db.ErrPresetMapNotFound, err)
+	}
+}
+
 func TestCreateLocalPreset(t *testing.T) {
 	repo := NewFakeRepository(false)
 	preset := db.LocalPreset{Name: "mypreset"}
@@ -573,3 +651,97 @@ func TestDeleteLocalPresetDBError(t *testing.T) {
 		t.Errorf("DeleteLocalPreset: wrong error message. Want %q. Got %q", dbErrorMsg, err.Error())
 	}
 }
+
+func TestCreatePresetGroup(t *testing.T) {
+	repo := NewFakeRepository(false)
+	group := db.PresetGroup{Name: "standard", Presets: []string{"hls_360p"}}
+	err := repo.CreatePresetGroup(&group)
+	if err != nil {
+		t.Fatal(err)
+	}
+	expectedGroups := map[string]*db.PresetGroup{"standard": &group}
+	groups := repo.(*fakeRepository).presetgroups
+	if !reflect.DeepEqual(groups, expectedGroups) {
+		t.Errorf("Wrong internal preset group registry. Want %#v. Got %#v", expectedGroups, groups)
+	}
+	err = repo.CreatePresetGroup(&group)
+	if err != db.ErrPresetGroupAlreadyExists {
+		t.Errorf("CreatePresetGroup: wrong error. Want %#v. Got %#v", db.ErrPresetGroupAlreadyExists, err)
+	}
+}
+
+func TestUpdatePresetGroup(t *testing.T) {
+	repo := NewFakeRepository(false)
+	group := db.PresetGroup{Name: "standard", Presets: []string{"hls_360p"}}
+	err := repo.CreatePresetGroup(&group)
+	if err != nil {
+		t.Fatal(err)
+	}
+	updated := db.PresetGroup{Name: "standard", Presets: []string{"hls_360p", "hls_720p"}}
+	err = repo.UpdatePresetGroup(&updated)
+	if err != nil {
+		t.Fatal(err)
+	}
+	gotGroup, err := repo.GetPresetGroup(group.Name)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !reflect.DeepEqual(*gotGroup, updated) {
+		t.Errorf("UpdatePresetGroup: wrong preset group. Want %#v. Got %#v", updated, *gotGroup)
+	}
+	err = repo.UpdatePresetGroup(&db.PresetGroup{Name: "unknown"})
+	if err != db.ErrPresetGroupNotFound {
+		t.Errorf("UpdatePresetGroup: wrong error. Want %#v. Got %#v", db.ErrPresetGroupNotFound, err)
+	}
+}
+
+func TestDeletePresetGroup(t *testing.T) {
+	repo := NewFakeRepository(false)
+	group := db.PresetGroup{Name: "standard", Presets: []string{"hls_360p"}}
+	err := repo.CreatePresetGroup(&group)
+	if err != nil {
+		t.Fatal(err)
+	}
+	err = repo.DeletePresetGroup(&group)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if groups := repo.(*fakeRepository).presetgroups; len(groups) != 0 {
+		t.Errorf("DeletePresetGroup: didn't delete the preset group. Got %#v", groups)
+	}
+	err = repo.DeletePresetGroup(&group)
+	if err != db.ErrPresetGroupNotFound {
+		t.Errorf("DeletePresetGroup: wrong error. Want %#v. Got %#v", db.ErrPresetGroupNotFound, err)
+	}
+}
+
+func TestListPresetGroups(t *testing.T) {
+	repo := NewFakeRepository(false)
+	group := db.PresetGroup{Name: "standard", Presets: []string{"hls_360p"}}
+	err := repo.CreatePresetGroup(&group)
+	if err != nil {
+		t.Fatal(err)
+	}
+	groups, err := repo.ListPresetGroups()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if expected := []db.PresetGroup{group}; !reflect.DeepEqual(groups, expected) {
+		t.Errorf("ListPresetGroups: wrong preset groups. Want %#v. Got %#v", expected, groups)
+	}
+}
+
+func TestPresetGroupDBError(t *testing.T) {
+	repo := NewFakeRepository(true)
+	group := db.PresetGroup{Name: "standard"}
+	errs := []error{repo.CreatePresetGroup(&group), repo.UpdatePresetGroup(&group), repo.DeletePresetGroup(&group)}
+	_, err := repo.GetPresetGroup(group.Name)
+	errs = append(errs, err)
+	_, err = repo.ListPresetGroups()
+	errs = append(errs, err)
+	for i, err := range errs {
+		if err == nil || err.Error() != dbErrorMsg {
+			t.Errorf("operation %d: wrong error. Want %q. Got %v", i, dbErrorMsg, err)
+		}
+	}
+}
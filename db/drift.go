@@ -0,0 +1,59 @@
+package db
+
+import (
+	"strconv"
+	"strings"
+)
+
+// PresetDifference describes a field that differs between the canonical
+// preset and the one found in a provider. Field is the JSON path of the
+// field, like "video.bitrate".
+type PresetDifference struct {
+	Field    string `json:"field"`
+	Expected string `json:"expected"`
+	Actual   string `json:"actual"`
+}
+
+// Drift compares the preset with actual, usually a preset normalized back
+// from the provider-native preset, and returns the fields that differ.
+//
+// Only the fields reported in actual are compared, as providers don't
+// expose every setting. Codecs and containers are compared ignoring case,
+// while bitrates and GOP sizes are compared by their numeric values.
+func (p Preset) Drift(actual Preset) []PresetDifference {
+	var diffs []PresetDifference
+	compare := func(field, expected, actual string, equal func(string, string) bool) {
+		if actual == "" || equal(expected, actual) {
+			return
+		}
+		diffs = append(diffs, PresetDifference{Field: field, Expected: expected, Actual: actual})
+	}
+	gopFrames := func(gopSize string) (int, error) {
+		video := p.Video
+		video.GopSize = gopSize
+		return video.GopFrames()
+	}
+	compare("container", p.Container, actual.Container, strings.EqualFold)
+	compare("video.codec", p.Video.Codec, actual.Video.Codec, strings.EqualFold)
+	compare("video.width", p.Video.Width, actual.Video.Width, equalNumbers(strconv.Atoi))
+	compare("video.height", p.Video.Height, actual.Video.Height, equalNumbers(strconv.Atoi))
+	compare("video.bitrate", p.Video.Bitrate, actual.Video.Bitrate, equalNumbers(ParseBitrate))
+	compare("video.gopSize", p.Video.GopSize, actual.Video.GopSize, equalNumbers(gopFrames))
+	compare("audio.codec", p.Audio.Codec, actual.Audio.Codec, strings.EqualFold)
+	compare("audio.bitrate", p.Audio.Bitrate, actual.Audio.Bitrate, equalNumbers(ParseBitrate))
+	return diffs
+}
+
+// equalNumbers returns a function that compares two values by their parsed
+// numeric values, falling back to comparing the raw values when any of them
+// can't be parsed.
+func equalNumbers(parse func(string) (int, error)) func(string, string) bool {
+	return func(a, b string) bool {
+		x, errA := parse(a)
+		y, errB := parse(b)
+		if errA != nil || errB != nil {
+			return strings.EqualFold(a, b)
+		}
+		return x == y
+	}
+}
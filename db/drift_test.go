@@ -0,0 +1,67 @@
+package db
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestPresetDrift(t *testing.T) {
+	canonical := Preset{
+		Container: "mp4",
+		Video: VideoPreset{
+			Codec:     "h264",
+			Width:     "1280",
+			Height:    "720",
+			Bitrate:   "2500k",
+			GopSize:   "2s",
+			FrameRate: "30",
+		},
+		Audio: AudioPreset{Codec: "aac", Bitrate: "128000"},
+	}
+	tests := []struct {
+		name   string
+		actual Preset
+		diffs  []PresetDifference
+	}{
+		{
+			"in sync",
+			Preset{
+				Container: "MP4",
+				Video:     VideoPreset{Codec: "H264", Width: "1280", Height: "720", Bitrate: "2500000", GopSize: "60"},
+				Audio:     AudioPreset{Codec: "AAC", Bitrate: "128k"},
+			},
+			nil,
+		},
+		{
+			"unreported fields",
+			Preset{Video: VideoPreset{Codec: "h264"}},
+			nil,
+		},
+		{
+			"drifted",
+			Preset{
+				Container: "webm",
+				Video:     VideoPreset{Codec: "h264", Width: "1920", Height: "720", Bitrate: "3000000", GopSize: "90"},
+				Audio:     AudioPreset{Codec: "aac", Bitrate: "96000"},
+			},
+			[]PresetDifference{
+				{Field: "container", Expected: "mp4", Actual: "webm"},
+				{Field: "video.width", Expected: "1280", Actual: "1920"},
+				{Field: "video.bitrate", Expected: "2500k", Actual: "3000000"},
+				{Field: "video.gopSize", Expected: "2s", Actual: "90"},
+				{Field: "audio.bitrate", Expected: "128000", Actual: "96000"},
+			},
+		},
+		{
+			"set only on the provider",
+			Preset{Audio: AudioPreset{Codec: "aac", Bitrate: "128000", SampleRate: "44100"}, Video: VideoPreset{Codec: "vp9"}},
+			[]PresetDifference{{Field: "video.codec", Expected: "h264", Actual: "vp9"}},
+		},
+	}
+	for _, test := range tests {
+		diffs := canonical.Drift(test.actual)
+		if !reflect.DeepEqual(diffs, test.diffs) {
+			t.Errorf("%s: wrong differences\nwant %#v\ngot  %#v", test.name, test.diffs, diffs)
+		}
+	}
+}
@@ -0,0 +1,97 @@
+package db
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+)
+
+// Playlist types of HLS media playlists.
+const (
+	HLSPlaylistTypeVOD   = "VOD"
+	HLSPlaylistTypeEvent = "EVENT"
+)
+
+// Containers of HLS segments.
+const (
+	HLSSegmentContainerTS   = "ts"
+	HLSSegmentContainerFMP4 = "fmp4"
+)
+
+// HLSOptions tunes the HLS playlists and segments generated by hls and cmaf
+// jobs. Providers that can't honor one of the options reject the job.
+//
+// swagger:model
+type HLSOptions struct {
+	// generate I-frame only playlists for each video rendition, used by
+	// players for trick play
+	IFramePlaylist bool `redis-hash:"iframeplaylist" json:"iFramePlaylist,omitempty"`
+
+	// write each rendition to a single file, with the media playlists
+	// addressing segments through byte ranges
+	SingleFile bool `redis-hash:"singlefile" json:"singleFile,omitempty"`
+
+	// type of the media playlists, either VOD or EVENT
+	PlaylistType string `redis-hash:"playlisttype,omitempty" json:"playlistType,omitempty"`
+
+	// add EXT-X-PROGRAM-DATE-TIME tags to the media playlists
+	ProgramDateTime bool `redis-hash:"programdatetime" json:"programDateTime,omitempty"`
+
+	// HLS protocol version of the playlists (EXT-X-VERSION). It must be
+	// at least the version required by the other options (see
+	// MinVersion)
+	TargetVersion uint `redis-hash:"targetversion" json:"targetVersion,omitempty"`
+
+	// container of the segments, either ts or fmp4. Defaults to ts in hls
+	// jobs, cmaf jobs only support fmp4
+	SegmentContainer string `redis-hash:"segmentcontainer,omitempty" json:"segmentContainer,omitempty"`
+}
+
+// FMP4 returns whether the options require fragmented MP4 segments.
+func (o HLSOptions) FMP4() bool {
+	return strings.EqualFold(o.SegmentContainer, HLSSegmentContainerFMP4)
+}
+
+// MinVersion returns the lowest HLS protocol version that supports the
+// options.
+func (o HLSOptions) MinVersion() uint {
+	switch {
+	case o.FMP4():
+		return 7
+	case o.IFramePlaylist || o.SingleFile:
+		return 4
+	default:
+		return 3
+	}
+}
+
+// Validate checks that the options are valid for the given protocol.
+func (o HLSOptions) Validate(protocol string) error {
+	if protocol != "hls" && protocol != "cmaf" {
+		return errors.New("hls options are only supported with the hls and cmaf protocols")
+	}
+	switch strings.ToUpper(o.PlaylistType) {
+	case "", HLSPlaylistTypeVOD, HLSPlaylistTypeEvent:
+	default:
+		return fmt.Errorf("invalid playlistType %q, valid options are VOD and EVENT", o.PlaylistType)
+	}
+	switch strings.ToLower(o.SegmentContainer) {
+	case "", HLSSegmentContainerFMP4:
+	case HLSSegmentContainerTS:
+		if protocol == "cmaf" {
+			return errors.New("cmaf jobs only support fmp4 segments")
+		}
+	default:
+		return fmt.Errorf("invalid segmentContainer %q, valid options are ts and fmp4", o.SegmentContainer)
+	}
+	if o.TargetVersion != 0 {
+		minVersion := o.MinVersion()
+		if protocol == "cmaf" {
+			minVersion = 7
+		}
+		if o.TargetVersion < minVersion {
+			return fmt.Errorf("targetVersion must be at least %d with the given options", minVersion)
+		}
+	}
+	return nil
+}
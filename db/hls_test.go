@@ -0,0 +1,74 @@
+package db
+
+import "testing"
+
+func TestHLSOptionsValidate(t *testing.T) {
+	tests := []struct {
+		name     string
+		protocol string
+		options  HLSOptions
+		errMsg   string
+	}{
+		{
+			name:     "valid hls options",
+			protocol: "hls",
+			options:  HLSOptions{IFramePlaylist: true, PlaylistType: "vod", TargetVersion: 4},
+		},
+		{
+			name:     "valid cmaf options",
+			protocol: "cmaf",
+			options:  HLSOptions{SegmentContainer: "fmp4", TargetVersion: 7},
+		},
+		{
+			name:     "non-hls protocol",
+			protocol: "dash",
+			options:  HLSOptions{IFramePlaylist: true},
+			errMsg:   "hls options are only supported with the hls and cmaf protocols",
+		},
+		{
+			name:     "invalid playlist type",
+			protocol: "hls",
+			options:  HLSOptions{PlaylistType: "live"},
+			errMsg:   `invalid playlistType "live", valid options are VOD and EVENT`,
+		},
+		{
+			name:     "invalid segment container",
+			protocol: "hls",
+			options:  HLSOptions{SegmentContainer: "mp4"},
+			errMsg:   `invalid segmentContainer "mp4", valid options are ts and fmp4`,
+		},
+		{
+			name:     "ts segments on cmaf",
+			protocol: "cmaf",
+			options:  HLSOptions{SegmentContainer: "ts"},
+			errMsg:   "cmaf jobs only support fmp4 segments",
+		},
+		{
+			name:     "target version too low for fmp4",
+			protocol: "hls",
+			options:  HLSOptions{SegmentContainer: "fmp4", TargetVersion: 6},
+			errMsg:   "targetVersion must be at least 7 with the given options",
+		},
+		{
+			name:     "target version too low for i-frame playlists",
+			protocol: "hls",
+			options:  HLSOptions{IFramePlaylist: true, TargetVersion: 3},
+			errMsg:   "targetVersion must be at least 4 with the given options",
+		},
+	}
+	for _, test := range tests {
+		test := test
+		t.Run(test.name, func(t *testing.T) {
+			err := test.options.Validate(test.protocol)
+			if test.errMsg == "" {
+				if err != nil {
+					t.Errorf("unexpected error: %s", err)
+				}
+				return
+			}
+			if err == nil || err.Error() != test.errMsg {
+				t.Errorf("wrong error returned. Want %q. Got %v", test.errMsg, err)
+			}
+		})
+	}
+}
@@ -0,0 +1,117 @@
+package db
+
+import (
+	"fmt"
+	"strings"
+)
+
+// Inherit returns the preset merged into the given base preset: every field
+// set in the preset overrides the same field of the base, while the
+// remaining fields are taken from the base. The name and the base of the
+// result are the ones of the preset.
+//
+// TwoPass is inherited only when the preset leaves it unset, and provider
+// overrides are merged per provider.
+func (p Preset) Inherit(base Preset) Preset {
+	merged := base
+	merged.Name = p.Name
+	merged.Base = p.Base
+	override(&merged.Description, p.Description)
+	override(&merged.Container, p.Container)
+	override(&merged.RateControl, p.RateControl)
+	if p.TwoPass != nil {
+		merged.TwoPass = p.TwoPass
+	}
+	if merged.TwoPass != nil {
+		twoPass := *merged.TwoPass
+		merged.TwoPass = &twoPass
+	}
+	merged.Video = p.Video.inherit(base.Video)
+	merged.Audio = p.Audio.inherit(base.Audio)
+	if len(base.ProviderOverrides) > 0 || len(p.ProviderOverrides) > 0 {
+		merged.ProviderOverrides = make(ProviderOverrides, len(base.ProviderOverrides)+len(p.ProviderOverrides))
+		for name, overrides := range base.ProviderOverrides {
+			merged.ProviderOverrides[name] = overrides
+		}
+		for name, overrides := range p.ProviderOverrides {
+			merged.ProviderOverrides[name] = overrides
+		}
+	}
+	return merged
+}
+
+func (v VideoPreset) inherit(base VideoPreset) VideoPreset {
+	merged := base
+	override(&merged.Profile, v.Profile)
+	override(&merged.ProfileLevel, v.ProfileLevel)
+	override(&merged.Width, v.Width)
+	override(&merged.Height, v.Height)
+	override(&merged.Codec, v.Codec)
+	override(&merged.Bitrate, v.Bitrate)
+	override(&merged.GopSize, v.GopSize)
+	override(&merged.GopMode, v.GopMode)
+	override(&merged.InterlaceMode, v.InterlaceMode)
+	override(&merged.BFrames, v.BFrames)
+	override(&merged.Tier, v.Tier)
+	override(&merged.BitDepth, v.BitDepth)
+	override(&merged.ColorSpace, v.ColorSpace)
+	override(&merged.HDR, v.HDR)
+	override(&merged.FrameRate, v.FrameRate)
+	override(&merged.ScalingBehavior, v.ScalingBehavior)
+	override(&merged.Deinterlacer, v.Deinterlacer)
+	if v.Crop != (CropSettings{}) {
+		merged.Crop = v.Crop
+	}
+	return merged
+}
+
+func (a AudioPreset) inherit(base AudioPreset) AudioPreset {
+	merged := base
+	override(&merged.Codec, a.Codec)
+	override(&merged.Bitrate, a.Bitrate)
+	override(&merged.ChannelLayout, a.ChannelLayout)
+	override(&merged.SampleRate, a.SampleRate)
+	override(&merged.Profile, a.Profile)
+	override(&merged.LoudnessStandard, a.LoudnessStandard)
+	override(&merged.LoudnessTarget, a.LoudnessTarget)
+	return merged
+}
+
+func override(field *string, value string) {
+	if value != "" {
+		*field = value
+	}
+}
+
+// ResolvePreset merges the preset with its chain of base presets, returned
+// by lookup, starting from the preset that has no base. Base presets that
+// can't be found are reported with the error returned by lookup, and chains
+// that loop back into a preset already visited are reported as cycles.
+func ResolvePreset(preset Preset, lookup func(name string) (*Preset, error)) (Preset, error) {
+	chain := []Preset{preset}
+	visited := map[string]bool{preset.Name: true}
+	for current := preset; current.Base != ""; {
+		if visited[current.Base] {
+			names := make([]string, len(chain), len(chain)+1)
+			for i, p := range chain {
+				names[i] = p.Name
+			}
+			names = append(names, current.Base)
+			return preset, fmt.Errorf("preset inheritance cycle: %s", strings.Join(names, " -> "))
+		}
+		name := current.Base
+		visited[name] = true
+		base, err := lookup(name)
+		if err != nil {
+			return preset, fmt.Errorf("base preset %q: %s", name, err)
+		}
+		current = *base
+		current.Name = name
+		chain = append(chain, current)
+	}
+	resolved := chain[len(chain)-1]
+	for i := len(chain) - 2; i >= 0; i-- {
+		resolved = chain[i].Inherit(resolved)
+	}
+	return resolved, nil
+}
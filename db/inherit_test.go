@@ -0,0 +1,173 @@
+package db
+
+import (
+	"encoding/json"
+	"errors"
+	"reflect"
+	"strconv"
+	"testing"
+)
+
+func TestPresetInherit(t *testing.T) {
+	base := Preset{
+		Name:        "mp4_base",
+		Description: "h264 ladder",
+		Container:   "mp4",
+		RateControl: "VBR",
+		Video: VideoPreset{
+			Profile: "high", ProfileLevel: "4.1", Codec: "h264", Width: "1920", Height: "1080",
+			Bitrate: "5000000", GopSize: "2s", Crop: CropSettings{X: "10"},
+		},
+		Audio: AudioPreset{Codec: "aac", Bitrate: "128000"},
+		ProviderOverrides: ProviderOverrides{
+			"mediaconvert": json.RawMessage(`{"a":1}`),
+			"bitmovin":     json.RawMessage(`{"b":1}`),
+		},
+	}
+	preset := Preset{
+		Name:              "mp4_720p",
+		Base:              "mp4_base",
+		TwoPass:           boolPtr(true),
+		Video:             VideoPreset{Width: "1280", Height: "720", Bitrate: "2500000"},
+		Audio:             AudioPreset{Bitrate: "96000"},
+		ProviderOverrides: ProviderOverrides{"bitmovin": json.RawMessage(`{"b":2}`)},
+	}
+	want := Preset{
+		Name:        "mp4_720p",
+		Base:        "mp4_base",
+		Description: "h264 ladder",
+		Container:   "mp4",
+		RateControl: "VBR",
+		TwoPass:     boolPtr(true),
+		Video: VideoPreset{
+			Profile: "high", ProfileLevel: "4.1", Codec: "h264", Width: "1280", Height: "720",
+			Bitrate: "2500000", GopSize: "2s", Crop: CropSettings{X: "10"},
+		},
+		Audio: AudioPreset{Codec: "aac", Bitrate: "96000"},
+		ProviderOverrides: ProviderOverrides{
+			"mediaconvert": json.RawMessage(`{"a":1}`),
+			"bitmovin":     json.RawMessage(`{"b":2}`),
+		},
+	}
+	got := preset.Inherit(base)
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("wrong merged preset\nwant %#v\ngot  %#v", want, got)
+	}
+	if base.ProviderOverrides["bitmovin"] == nil || string(base.ProviderOverrides["bitmovin"]) != `{"b":1}` {
+		t.Errorf("base provider overrides were modified: %#v", base.ProviderOverrides)
+	}
+}
+
+func TestPresetInheritTwoPass(t *testing.T) {
+	tests := []struct {
+		name   string
+		base   *bool
+		preset *bool
+		want   *bool
+	}{
+		{"unset in both", nil, nil, nil},
+		{"inherited from the base", boolPtr(true), nil, boolPtr(true)},
+		{"disabled over the base", boolPtr(true), boolPtr(false), boolPtr(false)},
+		{"enabled over the base", boolPtr(false), boolPtr(true), boolPtr(true)},
+		{"set without a base value", nil, boolPtr(false), boolPtr(false)},
+	}
+	for _, test := range tests {
+		test := test
+		t.Run(test.name, func(t *testing.T) {
+			base := Preset{Name: "base", TwoPass: test.base}
+			preset := Preset{Name: "child", Base: "base", TwoPass: test.preset}
+			got := preset.Inherit(base).TwoPass
+			if !reflect.DeepEqual(got, test.want) {
+				t.Errorf("wrong two pass setting\nwant %v\ngot  %v", describeBool(test.want), describeBool(got))
+			}
+			if got != nil && (got == test.base || got == test.preset) {
+				t.Error("merged preset shares the two pass setting with its source presets")
+			}
+		})
+	}
+}
+
+func boolPtr(b bool) *bool {
+	return &b
+}
+
+func describeBool(b *bool) string {
+	if b == nil {
+		return "<unset>"
+	}
+	return strconv.FormatBool(*b)
+}
+
+func TestResolvePreset(t *testing.T) {
+	stored := map[string]*Preset{
+		"root":   {Name: "root", Container: "mp4", Video: VideoPreset{Codec: "h264", GopSize: "2s"}, Audio: AudioPreset{Codec: "aac"}},
+		"hd":     {Name: "hd", Base: "root", Video: VideoPreset{Width: "1920", Height: "1080"}},
+		"loop_a": {Name: "loop_a", Base: "loop_b"},
+		"loop_b": {Name: "loop_b", Base: "loop_a"},
+	}
+	lookup := func(name string) (*Preset, error) {
+		if preset, ok := stored[name]; ok {
+			return preset, nil
+		}
+		return nil, errors.New("not found")
+	}
+	tests := []struct {
+		name    string
+		preset  Preset
+		want    Preset
+		wantErr string
+	}{
+		{
+			"no base",
+			Preset{Name: "standalone", Container: "webm"},
+			Preset{Name: "standalone", Container: "webm"},
+			"",
+		},
+		{
+			"chain of bases",
+			Preset{Name: "hd_low", Base: "hd", Video: VideoPreset{Bitrate: "3000000"}},
+			Preset{
+				Name:      "hd_low",
+				Base:      "hd",
+				Container: "mp4",
+				Video:     VideoPreset{Codec: "h264", GopSize: "2s", Width: "1920", Height: "1080", Bitrate: "3000000"},
+				Audio:     AudioPreset{Codec: "aac"},
+			},
+			"",
+		},
+		{
+			"missing base",
+			Preset{Name: "orphan", Base: "unknown"},
+			Preset{},
+			`base preset "unknown": not found`,
+		},
+		{
+			"cycle",
+			Preset{Name: "loop_c", Base: "loop_a"},
+			Preset{},
+			"preset inheritance cycle: loop_c -> loop_a -> loop_b -> loop_a",
+		},
+		{
+			"self reference",
+			Preset{Name: "root", Base: "hd"},
+			Preset{},
+			"preset inheritance cycle: root -> hd -> root",
+		},
+	}
+	for _, test := range tests {
+		got, err := ResolvePreset(test.preset, lookup)
+		if test.wantErr != "" {
+			if err == nil || err.Error() != test.wantErr {
+				t.Errorf("%s: wrong error. Want %q. Got %v", test.name, test.wantErr, err)
+			}
+			continue
+		}
+		if err != nil {
+			t.Errorf("%s: unexpected error: %s", test.name, err)
+			continue
+		}
+		if !reflect.DeepEqual(got, test.want) {
+			t.Errorf("%s: wrong resolved preset\nwant %#v\ngot  %#v", test.name, test.want, got)
+		}
+	}
+}
@@ -0,0 +1,213 @@
+package db
+
+import (
+	"errors"
+	"fmt"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+// LadderSpec describes an adaptive bitrate ladder, either by listing its
+// rungs or by referencing one of the built-in ladders (see BuiltinLadders).
+// The presets of the ladder are stored as a PresetGroup.
+//
+// swagger:model
+type LadderSpec struct {
+	// name of the ladder, also used as prefix for the name of its presets
+	//
+	// required: true
+	Name string `json:"name"`
+
+	// name of a built-in ladder to use instead of the rungs
+	Builtin string `json:"builtin,omitempty"`
+
+	// video codec of the ladder. Defaults to h264
+	Codec string `json:"codec,omitempty"`
+
+	// container of the ladder, either m3u8 or cmaf. Defaults to m3u8
+	Container string `json:"container,omitempty"`
+
+	// rungs taller than maxHeight are left out of the ladder
+	MaxHeight int `json:"maxHeight,omitempty"`
+
+	// GOP size of the rungs, in frames or seconds (like 2s, which requires
+	// a frame rate). Defaults to 60 frames
+	GopSize string `json:"gopSize,omitempty"`
+
+	// frame rate of the rungs. Defaults to the frame rate of the source
+	FrameRate string `json:"frameRate,omitempty"`
+
+	// rungs of the ladder
+	Rungs []LadderRung `json:"rungs,omitempty"`
+
+	// audio settings shared by all rungs. Defaults to AAC at 128kbps
+	Audio AudioPreset `json:"audio"`
+
+	// duration of the segments, in seconds. Defaults to the segment
+	// duration of the API
+	SegmentDuration uint `json:"segmentDuration,omitempty"`
+}
+
+// LadderRung is a rung of an adaptive bitrate ladder. The width of the rung
+// follows the aspect ratio of the source.
+type LadderRung struct {
+	// height of the rung, in pixels
+	//
+	// required: true
+	Height int `json:"height"`
+
+	// video bitrate of the rung, in bits per second, optionally expressed
+	// in kilobits ("2500k") or megabits ("2.5M")
+	//
+	// required: true
+	Bitrate string `json:"bitrate"`
+}
+
+// builtinLadders are the ladders users can reference by name, based on the
+// Apple HLS authoring specification for Apple devices.
+var builtinLadders = map[string]LadderSpec{
+	"apple-hls-h264": {
+		Codec:     VideoCodecH264,
+		Container: "m3u8",
+		Rungs: []LadderRung{
+			{Height: 234, Bitrate: "145k"},
+			{Height: 360, Bitrate: "365k"},
+			{Height: 432, Bitrate: "730k"},
+			{Height: 432, Bitrate: "1100k"},
+			{Height: 540, Bitrate: "2000k"},
+			{Height: 720, Bitrate: "3000k"},
+			{Height: 720, Bitrate: "4500k"},
+			{Height: 1080, Bitrate: "6000k"},
+			{Height: 1080, Bitrate: "7800k"},
+		},
+	},
+	"apple-hls-hevc": {
+		Codec:     VideoCodecHEVC,
+		Container: "cmaf",
+		Rungs: []LadderRung{
+			{Height: 360, Bitrate: "145k"},
+			{Height: 432, Bitrate: "300k"},
+			{Height: 540, Bitrate: "600k"},
+			{Height: 540, Bitrate: "900k"},
+			{Height: 540, Bitrate: "1600k"},
+			{Height: 720, Bitrate: "2400k"},
+			{Height: 720, Bitrate: "3400k"},
+			{Height: 1080, Bitrate: "4500k"},
+			{Height: 1080, Bitrate: "5800k"},
+		},
+	},
+}
+
+// BuiltinLadders returns the names of the built-in ladders, alphabetically
+// ordered.
+func BuiltinLadders() []string {
+	names := make([]string, 0, len(builtinLadders))
+	for name := range builtinLadders {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
+
+// Presets generates the presets of the ladder, from the lowest to the highest
+// rung. Each preset is named after the ladder, the height and the bitrate of
+// its rung, like "myladder_720p_3000k".
+func (s LadderSpec) Presets() ([]Preset, error) {
+	if s.Name == "" {
+		return nil, errors.New("name is required")
+	}
+	if s.Builtin != "" {
+		builtin, ok := builtinLadders[s.Builtin]
+		if !ok {
+			return nil, fmt.Errorf("unknown builtin ladder %q, valid options are %s", s.Builtin, strings.Join(BuiltinLadders(), ", "))
+		}
+		if s.Codec != "" || s.Container != "" || len(s.Rungs) > 0 {
+			return nil, errors.New("codec, container and rungs can't be changed in builtin ladders")
+		}
+		s.Codec = builtin.Codec
+		s.Container = builtin.Container
+		s.Rungs = builtin.Rungs
+	}
+	if s.Codec == "" {
+		s.Codec = VideoCodecH264
+	}
+	switch s.Container {
+	case "":
+		s.Container = "m3u8"
+	case "m3u8", "cmaf":
+	default:
+		return nil, fmt.Errorf("container %q is not supported in ladders, valid options are m3u8 and cmaf", s.Container)
+	}
+	if s.GopSize == "" {
+		s.GopSize = "60"
+	}
+	if s.Audio == (AudioPreset{}) {
+		s.Audio = AudioPreset{Codec: AudioCodecAAC, Bitrate: "128000"}
+	}
+	if len(s.Rungs) == 0 {
+		return nil, errors.New("at least one rung is required")
+	}
+	type parsedRung struct {
+		height  int
+		bitrate int
+	}
+	rungs := make([]parsedRung, 0, len(s.Rungs))
+	for i, rung := range s.Rungs {
+		if rung.Height <= 0 {
+			return nil, fmt.Errorf("rung %d: height must be a positive integer", i)
+		}
+		bitrate, err := ParseBitrate(rung.Bitrate)
+		if err != nil {
+			return nil, fmt.Errorf("rung %d: %s", i, err)
+		}
+		if s.MaxHeight == 0 || rung.Height <= s.MaxHeight {
+			rungs = append(rungs, parsedRung{height: rung.Height, bitrate: bitrate})
+		}
+	}
+	if len(rungs) == 0 {
+		return nil, fmt.Errorf("no rungs are at most %dp tall", s.MaxHeight)
+	}
+	sort.SliceStable(rungs, func(i, j int) bool {
+		if rungs[i].height == rungs[j].height {
+			return rungs[i].bitrate < rungs[j].bitrate
+		}
+		return rungs[i].height < rungs[j].height
+	})
+	presets := make([]Preset, len(rungs))
+	for i, rung := range rungs {
+		name := fmt.Sprintf("%s_%dp_%dk", s.Name, rung.height, rung.bitrate/1000)
+		if i > 0 && name == presets[i-1].Name {
+			return nil, fmt.Errorf("duplicate %dp rung at %dkbps", rung.height, rung.bitrate/1000)
+		}
+		presets[i] = Preset{
+			Name:        name,
+			Description: fmt.Sprintf("%dp rung of the %s ladder", rung.height, s.Name),
+			Container:   s.Container,
+			RateControl: "VBR",
+			Video: VideoPreset{
+				Codec:     s.Codec,
+				Height:    strconv.Itoa(rung.height),
+				Bitrate:   strconv.Itoa(rung.bitrate),
+				GopSize:   s.GopSize,
+				GopMode:   "fixed",
+				FrameRate: s.FrameRate,
+			},
+			Audio: s.Audio,
+		}
+	}
+	return presets, nil
+}
+
+// StreamingParams returns the adaptive streaming parameters of jobs that use
+// the ladder.
+func (s LadderSpec) StreamingParams() StreamingParams {
+	protocol := "hls"
+	if s.Container == "cmaf" {
+		protocol = "cmaf"
+	}
+	if builtin, ok := builtinLadders[s.Builtin]; ok && builtin.Container == "cmaf" {
+		protocol = "cmaf"
+	}
+	return StreamingParams{Protocol: protocol, SegmentDuration: s.SegmentDuration}
+}
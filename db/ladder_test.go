@@ -0,0 +1,108 @@
+package db
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestLadderSpecPresets(t *testing.T) {
+	spec := LadderSpec{
+		Name:      "myladder",
+		MaxHeight: 720,
+		GopSize:   "2s",
+		FrameRate: "30",
+		Rungs: []LadderRung{
+			{Height: 720, Bitrate: "3M"},
+			{Height: 1080, Bitrate: "6M"},
+			{Height: 360, Bitrate: "365k"},
+		},
+	}
+	presets, err := spec.Presets()
+	if err != nil {
+		t.Fatal(err)
+	}
+	expected := []Preset{
+		{
+			Name:        "myladder_360p_365k",
+			Description: "360p rung of the myladder ladder",
+			Container:   "m3u8",
+			RateControl: "VBR",
+			Video: VideoPreset{
+				Codec:     "h264",
+				Height:    "360",
+				Bitrate:   "365000",
+				GopSize:   "2s",
+				GopMode:   "fixed",
+				FrameRate: "30",
+			},
+			Audio: AudioPreset{Codec: "aac", Bitrate: "128000"},
+		},
+		{
+			Name:        "myladder_720p_3000k",
+			Description: "720p rung of the myladder ladder",
+			Container:   "m3u8",
+			RateControl: "VBR",
+			Video: VideoPreset{
+				Codec:     "h264",
+				Height:    "720",
+				Bitrate:   "3000000",
+				GopSize:   "2s",
+				GopMode:   "fixed",
+				FrameRate: "30",
+			},
+			Audio: AudioPreset{Codec: "aac", Bitrate: "128000"},
+		},
+	}
+	if !reflect.DeepEqual(presets, expected) {
+		t.Errorf("wrong presets\nwant %#v\ngot  %#v", expected, presets)
+	}
+	if params := spec.StreamingParams(); params != (StreamingParams{Protocol: "hls"}) {
+		t.Errorf("wrong streaming params: %#v", params)
+	}
+}
+
+func TestLadderSpecBuiltinPresets(t *testing.T) {
+	spec := LadderSpec{Name: "apple", Builtin: "apple-hls-hevc", MaxHeight: 540, SegmentDuration: 6}
+	presets, err := spec.Presets()
+	if err != nil {
+		t.Fatal(err)
+	}
+	names := make([]string, len(presets))
+	for i, preset := range presets {
+		names[i] = preset.Name
+		if preset.Video.Codec != "hevc" || preset.Container != "cmaf" {
+			t.Errorf("%s: wrong codec or container: %#v", preset.Name, preset)
+		}
+	}
+	expectedNames := []string{"apple_360p_145k", "apple_432p_300k", "apple_540p_600k", "apple_540p_900k", "apple_540p_1600k"}
+	if !reflect.DeepEqual(names, expectedNames) {
+		t.Errorf("wrong presets\nwant %#v\ngot  %#v", expectedNames, names)
+	}
+	if params := spec.StreamingParams(); params != (StreamingParams{Protocol: "cmaf", SegmentDuration: 6}) {
+		t.Errorf("wrong streaming params: %#v", params)
+	}
+}
+
+func TestLadderSpecPresetsErrors(t *testing.T) {
+	rungs := []LadderRung{{Height: 720, Bitrate: "3M"}}
+	tests := []struct {
+		spec   LadderSpec
+		errMsg string
+	}{
+		{LadderSpec{Rungs: rungs}, "name is required"},
+		{LadderSpec{Name: "l"}, "at least one rung is required"},
+		{LadderSpec{Name: "l", Builtin: "netflix"}, `unknown builtin ladder "netflix", valid options are apple-hls-h264, apple-hls-hevc`},
+		{LadderSpec{Name: "l", Builtin: "apple-hls-h264", Rungs: rungs}, "codec, container and rungs can't be changed in builtin ladders"},
+		{LadderSpec{Name: "l", Container: "mp4", Rungs: rungs}, `container "mp4" is not supported in ladders, valid options are m3u8 and cmaf`},
+		{LadderSpec{Name: "l", MaxHeight: 480, Rungs: rungs}, "no rungs are at most 480p tall"},
+		{LadderSpec{Name: "l", Rungs: []LadderRung{{Height: 0, Bitrate: "3M"}}}, "rung 0: height must be a positive integer"},
+		{LadderSpec{Name: "l", Rungs: []LadderRung{{Height: 720, Bitrate: "fast"}}}, `rung 0: bitrate "fast" must be a positive number of bits per second, optionally followed by k or M`},
+		{LadderSpec{Name: "l", Rungs: []LadderRung{{Height: 720, Bitrate: "3M"}, {Height: 720, Bitrate: "3000k"}}}, "duplicate 720p rung at 3000kbps"},
+	}
+	for _, test := range tests {
+		_, err := test.spec.Presets()
+		if err == nil || err.Error() != test.errMsg {
+			t.Errorf("%#v: wrong error\nwant %q\ngot  %v", test.spec, test.errMsg, err)
+		}
+	}
+}
@@ -0,0 +1,206 @@
+package db
+
+import (
+	"fmt"
+	"math"
+	"regexp"
+	"strconv"
+	"strings"
+)
+
+// Limits for the numeric fields of presets.
+const (
+	maxDimension = 8192
+	maxBFrames   = 16
+)
+
+var (
+	bitrateRegexp = regexp.MustCompile(`^(\d+(?:\.\d+)?)([kKmM]?)$`)
+	gopRegexp     = regexp.MustCompile(`^(\d+(?:\.\d+)?)(s?)$`)
+)
+
+// FieldError describes an invalid field of a preset. Field is the JSON path
+// of the field, like "video.bitrate".
+type FieldError struct {
+	Field   string `json:"field"`
+	Message string `json:"message"`
+}
+
+// ValidationError is returned by Normalize, listing every invalid field of
+// the preset.
+type ValidationError struct {
+	Fields []FieldError
+}
+
+func (e *ValidationError) add(field string, err error) {
+	e.Fields = append(e.Fields, FieldError{Field: field, Message: err.Error()})
+}
+
+func (e *ValidationError) Error() string {
+	messages := make([]string, len(e.Fields))
+	for i, field := range e.Fields {
+		messages[i] = field.Field + ": " + field.Message
+	}
+	return strings.Join(messages, "; ")
+}
+
+// ParseBitrate parses a bitrate in bits per second, optionally expressed in
+// kilobits ("2500k") or megabits ("2.5M").
+func ParseBitrate(value string) (int, error) {
+	invalid := fmt.Errorf("bitrate %q must be a positive number of bits per second, optionally followed by k or M", value)
+	matches := bitrateRegexp.FindStringSubmatch(value)
+	if matches == nil {
+		return 0, invalid
+	}
+	number, err := strconv.ParseFloat(matches[1], 64)
+	if err != nil {
+		return 0, invalid
+	}
+	switch strings.ToLower(matches[2]) {
+	case "k":
+		number *= 1000
+	case "m":
+		number *= 1000000
+	}
+	if number != math.Trunc(number) || number <= 0 || number > math.MaxInt32 {
+		return 0, invalid
+	}
+	return int(number), nil
+}
+
+// Normalize validates the numeric fields of the preset and returns a copy of
+// it holding their canonical values: bitrates in bits per second, GOP sizes
+// in frames and plain integers for the dimensions and B-frames. Providers
+// can then parse these fields as integers.
+//
+// The returned error is a *ValidationError listing all the invalid fields.
+func (p Preset) Normalize() (Preset, error) {
+	var verr ValidationError
+	if !p.AudioOnly() {
+		p.Video.normalize(&verr)
+	}
+	if p.Audio.Bitrate != "" {
+		bitrate, err := ParseBitrate(p.Audio.Bitrate)
+		if err != nil {
+			verr.add("audio.bitrate", err)
+		} else {
+			p.Audio.Bitrate = strconv.Itoa(bitrate)
+		}
+	}
+	if len(verr.Fields) > 0 {
+		return p, &verr
+	}
+	return p, nil
+}
+
+func (v *VideoPreset) normalize(verr *ValidationError) {
+	if v.Bitrate != "" {
+		bitrate, err := ParseBitrate(v.Bitrate)
+		if err != nil {
+			verr.add("video.bitrate", err)
+		} else {
+			v.Bitrate = strconv.Itoa(bitrate)
+		}
+	}
+	if v.GopSize != "" {
+		gopSize, err := v.GopFrames()
+		if err != nil {
+			verr.add("video.gopSize", err)
+		} else {
+			v.GopSize = strconv.Itoa(gopSize)
+		}
+	}
+	dimensions := []struct {
+		field string
+		value *string
+	}{{"video.width", &v.Width}, {"video.height", &v.Height}}
+	for _, dimension := range dimensions {
+		if *dimension.value == "" {
+			continue
+		}
+		n, err := parseDimension(*dimension.value)
+		switch {
+		case err != nil:
+			verr.add(dimension.field, err)
+		case n > maxDimension:
+			verr.add(dimension.field, fmt.Errorf("%q must not be greater than %d", *dimension.value, maxDimension))
+		case n%2 != 0:
+			verr.add(dimension.field, fmt.Errorf("%q must be an even number", *dimension.value))
+		default:
+			*dimension.value = strconv.Itoa(n)
+		}
+	}
+	if v.BFrames != "" {
+		bframes, err := v.BFrameCount()
+		if err != nil {
+			verr.add("video.bframes", err)
+		} else {
+			v.BFrames = strconv.Itoa(bframes)
+		}
+	}
+}
+
+// WidthPixels returns the width of the video, in pixels.
+func (v *VideoPreset) WidthPixels() (int, error) {
+	return parseDimension(v.Width)
+}
+
+// HeightPixels returns the height of the video, in pixels.
+func (v *VideoPreset) HeightPixels() (int, error) {
+	return parseDimension(v.Height)
+}
+
+func parseDimension(value string) (int, error) {
+	n, err := strconv.Atoi(value)
+	if err != nil || n <= 0 {
+		return 0, fmt.Errorf("%q must be a positive integer", value)
+	}
+	return n, nil
+}
+
+// BFrameCount returns the number of B-frames between reference frames.
+func (v *VideoPreset) BFrameCount() (int, error) {
+	n, err := strconv.Atoi(v.BFrames)
+	if err != nil || n < 0 || n > maxBFrames {
+		return 0, fmt.Errorf("%q must be an integer between 0 and %d", v.BFrames, maxBFrames)
+	}
+	return n, nil
+}
+
+// BitrateBps returns the video bitrate in bits per second.
+func (v *VideoPreset) BitrateBps() (int, error) {
+	return ParseBitrate(v.Bitrate)
+}
+
+// GopFrames returns the GOP size in frames. GOP sizes can also be given in
+// seconds ("2s"), which are converted using the frame rate of the preset.
+func (v *VideoPreset) GopFrames() (int, error) {
+	invalid := fmt.Errorf("gop size %q must be a positive number of frames or seconds (like 2s)", v.GopSize)
+	matches := gopRegexp.FindStringSubmatch(v.GopSize)
+	if matches == nil {
+		return 0, invalid
+	}
+	size, err := strconv.ParseFloat(matches[1], 64)
+	if err != nil || size <= 0 {
+		return 0, invalid
+	}
+	if matches[2] == "s" {
+		if v.FrameRate == "" {
+			return 0, fmt.Errorf("gop size %q in seconds requires a frame rate", v.GopSize)
+		}
+		num, den, err := v.FrameRateFraction()
+		if err != nil {
+			return 0, err
+		}
+		size = math.Round(size * float64(num) / float64(den))
+	}
+	if size != math.Trunc(size) || size < 1 {
+		return 0, invalid
+	}
+	return int(size), nil
+}
+
+// BitrateBps returns the audio bitrate in bits per second.
+func (a *AudioPreset) BitrateBps() (int, error) {
+	return ParseBitrate(a.Bitrate)
+}
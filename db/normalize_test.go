@@ -0,0 +1,192 @@
+package db
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestParseBitrate(t *testing.T) {
+	tests := []struct {
+		value   string
+		bitrate int
+		errMsg  string
+	}{
+		{"2500000", 2500000, ""},
+		{"2500k", 2500000, ""},
+		{"2500K", 2500000, ""},
+		{"2.5M", 2500000, ""},
+		{"0.5m", 500000, ""},
+		{"128.5k", 128500, ""},
+		{"0", 0, `bitrate "0" must be a positive number of bits per second, optionally followed by k or M`},
+		{"2.5", 0, `bitrate "2.5" must be a positive number of bits per second, optionally followed by k or M`},
+		{"2500kbps", 0, `bitrate "2500kbps" must be a positive number of bits per second, optionally followed by k or M`},
+		{"-1k", 0, `bitrate "-1k" must be a positive number of bits per second, optionally followed by k or M`},
+		{"5000M", 0, `bitrate "5000M" must be a positive number of bits per second, optionally followed by k or M`},
+	}
+	for _, test := range tests {
+		bitrate, err := ParseBitrate(test.value)
+		if test.errMsg != "" {
+			if err == nil || err.Error() != test.errMsg {
+				t.Errorf("%s: wrong error\nwant %q\ngot  %v", test.value, test.errMsg, err)
+			}
+			continue
+		}
+		if err != nil {
+			t.Errorf("%s: unexpected error: %v", test.value, err)
+			continue
+		}
+		if bitrate != test.bitrate {
+			t.Errorf("%s: wrong bitrate. Want %d. Got %d", test.value, test.bitrate, bitrate)
+		}
+	}
+}
+
+func TestVideoPresetGopFrames(t *testing.T) {
+	tests := []struct {
+		video  VideoPreset
+		frames int
+		errMsg string
+	}{
+		{VideoPreset{GopSize: "90"}, 90, ""},
+		{VideoPreset{GopSize: "90.0"}, 90, ""},
+		{VideoPreset{GopSize: "2s", FrameRate: "30"}, 60, ""},
+		{VideoPreset{GopSize: "2s", FrameRate: "30000/1001"}, 60, ""},
+		{VideoPreset{GopSize: "0.5s", FrameRate: "25"}, 13, ""},
+		{VideoPreset{GopSize: "2s"}, 0, `gop size "2s" in seconds requires a frame rate`},
+		{VideoPreset{GopSize: "2.5"}, 0, `gop size "2.5" must be a positive number of frames or seconds (like 2s)`},
+		{VideoPreset{GopSize: "0"}, 0, `gop size "0" must be a positive number of frames or seconds (like 2s)`},
+		{VideoPreset{GopSize: "2m"}, 0, `gop size "2m" must be a positive number of frames or seconds (like 2s)`},
+	}
+	for _, test := range tests {
+		frames, err := test.video.GopFrames()
+		if test.errMsg != "" {
+			if err == nil || err.Error() != test.errMsg {
+				t.Errorf("%s: wrong error\nwant %q\ngot  %v", test.video.GopSize, test.errMsg, err)
+			}
+			continue
+		}
+		if err != nil {
+			t.Errorf("%s: unexpected error: %v", test.video.GopSize, err)
+			continue
+		}
+		if frames != test.frames {
+			t.Errorf("%s: wrong number of frames. Want %d. Got %d", test.video.GopSize, test.frames, frames)
+		}
+	}
+}
+
+func TestVideoPresetAccessors(t *testing.T) {
+	video := VideoPreset{Width: "1280", Height: "0720", Bitrate: "2.5M", BFrames: "3"}
+	width, err := video.WidthPixels()
+	if err != nil || width != 1280 {
+		t.Errorf("wrong width. Want 1280. Got %d (%v)", width, err)
+	}
+	height, err := video.HeightPixels()
+	if err != nil || height != 720 {
+		t.Errorf("wrong height. Want 720. Got %d (%v)", height, err)
+	}
+	bitrate, err := video.BitrateBps()
+	if err != nil || bitrate != 2500000 {
+		t.Errorf("wrong bitrate. Want 2500000. Got %d (%v)", bitrate, err)
+	}
+	bframes, err := video.BFrameCount()
+	if err != nil || bframes != 3 {
+		t.Errorf("wrong bframes. Want 3. Got %d (%v)", bframes, err)
+	}
+
+	invalid := VideoPreset{Width: "wide", Height: "-2", BFrames: "17"}
+	if _, err = invalid.WidthPixels(); err == nil || err.Error() != `"wide" must be a positive integer` {
+		t.Errorf("wrong width error: %v", err)
+	}
+	if _, err = invalid.HeightPixels(); err == nil || err.Error() != `"-2" must be a positive integer` {
+		t.Errorf("wrong height error: %v", err)
+	}
+	if _, err = invalid.BFrameCount(); err == nil || err.Error() != `"17" must be an integer between 0 and 16` {
+		t.Errorf("wrong bframes error: %v", err)
+	}
+}
+
+func TestPresetNormalize(t *testing.T) {
+	preset := Preset{
+		Name:      "hls_720p",
+		Container: "m3u8",
+		Video: VideoPreset{
+			Codec:     "h264",
+			Bitrate:   "2.5M",
+			GopSize:   "2s",
+			FrameRate: "30",
+			Width:     "1280",
+			Height:    "0720",
+			BFrames:   "3",
+		},
+		Audio: AudioPreset{Codec: "aac", Bitrate: "128k"},
+	}
+	normalized, err := preset.Normalize()
+	if err != nil {
+		t.Fatal(err)
+	}
+	expected := preset
+	expected.Video.Bitrate = "2500000"
+	expected.Video.GopSize = "60"
+	expected.Video.Height = "720"
+	expected.Audio.Bitrate = "128000"
+	if !reflect.DeepEqual(normalized, expected) {
+		t.Errorf("wrong normalized preset\nwant %#v\ngot  %#v", expected, normalized)
+	}
+	if preset.Video.Bitrate != "2.5M" {
+		t.Errorf("Normalize modified the original preset: %#v", preset)
+	}
+}
+
+func TestPresetNormalizeAudioOnly(t *testing.T) {
+	preset := Preset{Container: "m3u8", Audio: AudioPreset{Codec: "aac", Bitrate: "64k"}}
+	normalized, err := preset.Normalize()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !normalized.AudioOnly() {
+		t.Errorf("normalized preset is no longer audio-only: %#v", normalized)
+	}
+	if normalized.Audio.Bitrate != "64000" {
+		t.Errorf("wrong audio bitrate. Want %q. Got %q", "64000", normalized.Audio.Bitrate)
+	}
+}
+
+func TestPresetNormalizeFieldErrors(t *testing.T) {
+	preset := Preset{
+		Video: VideoPreset{
+			Codec:   "h264",
+			Bitrate: "fast",
+			GopSize: "2s",
+			Width:   "1921",
+			Height:  "10000",
+			BFrames: "17",
+		},
+		Audio: AudioPreset{Codec: "aac", Bitrate: "-64k"},
+	}
+	_, err := preset.Normalize()
+	verr, ok := err.(*ValidationError)
+	if !ok {
+		t.Fatalf("wrong error type: %#v", err)
+	}
+	expected := []FieldError{
+		{Field: "video.bitrate", Message: `bitrate "fast" must be a positive number of bits per second, optionally followed by k or M`},
+		{Field: "video.gopSize", Message: `gop size "2s" in seconds requires a frame rate`},
+		{Field: "video.width", Message: `"1921" must be an even number`},
+		{Field: "video.height", Message: `"10000" must not be greater than 8192`},
+		{Field: "video.bframes", Message: `"17" must be an integer between 0 and 16`},
+		{Field: "audio.bitrate", Message: `bitrate "-64k" must be a positive number of bits per second, optionally followed by k or M`},
+	}
+	if !reflect.DeepEqual(verr.Fields, expected) {
+		t.Errorf("wrong field errors\nwant %#v\ngot  %#v", expected, verr.Fields)
+	}
+	expectedMsg := `video.bitrate: bitrate "fast" must be a positive number of bits per second, optionally followed by k or M; ` +
+		`video.gopSize: gop size "2s" in seconds requires a frame rate; ` +
+		`video.width: "1921" must be an even number; ` +
+		`video.height: "10000" must not be greater than 8192; ` +
+		`video.bframes: "17" must be an integer between 0 and 16; ` +
+		`audio.bitrate: bitrate "-64k" must be a positive number of bits per second, optionally followed by k or M`
+	if err.Error() != expectedMsg {
+		t.Errorf("wrong error message\nwant %q\ngot  %q", expectedMsg, err.Error())
+	}
+}
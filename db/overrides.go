@@ -0,0 +1,44 @@
+package db
+
+import (
+	"encoding/json"
+	"fmt"
+	"sort"
+)
+
+// ProviderOverrides holds provider-specific settings for presets and jobs,
+// keyed by provider name. Each value is a JSON object that is deep-merged
+// into the native payload the provider builds (like the MediaConvert
+// CreateJobInput or the Zencoder output settings), allowing users to set
+// options that the API doesn't abstract.
+type ProviderOverrides map[string]json.RawMessage
+
+// For returns the overrides for the given provider, or nil if there aren't
+// any.
+func (o ProviderOverrides) For(providerName string) json.RawMessage {
+	return o[providerName]
+}
+
+// Validate checks that the overrides are keyed by one of the given providers
+// and that each of them is a JSON object.
+func (o ProviderOverrides) Validate(providerNames []string) error {
+	known := make(map[string]bool, len(providerNames))
+	for _, name := range providerNames {
+		known[name] = true
+	}
+	names := make([]string, 0, len(o))
+	for name := range o {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	for _, name := range names {
+		if !known[name] {
+			return fmt.Errorf("providerOverrides: unexpected provider %q", name)
+		}
+		var object map[string]json.RawMessage
+		if err := json.Unmarshal(o[name], &object); err != nil || object == nil {
+			return fmt.Errorf("providerOverrides: overrides for %q must be a JSON object", name)
+		}
+	}
+	return nil
+}
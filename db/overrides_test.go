@@ -0,0 +1,60 @@
+package db
+
+import (
+	"encoding/json"
+	"reflect"
+	"testing"
+)
+
+func TestProviderOverridesJSON(t *testing.T) {
+	var preset Preset
+	err := json.Unmarshal([]byte(`{"name":"mp4_720p","providerOverrides":{"mediaconvert":{"priority":10}}}`), &preset)
+	if err != nil {
+		t.Fatal(err)
+	}
+	expected := ProviderOverrides{"mediaconvert": json.RawMessage(`{"priority":10}`)}
+	if !reflect.DeepEqual(preset.ProviderOverrides, expected) {
+		t.Errorf("wrong overrides\nwant %#v\ngot  %#v", expected, preset.ProviderOverrides)
+	}
+	data, err := json.Marshal(preset.ProviderOverrides)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if expectedJSON := `{"mediaconvert":{"priority":10}}`; string(data) != expectedJSON {
+		t.Errorf("wrong JSON\nwant %s\ngot  %s", expectedJSON, data)
+	}
+	data, err = json.Marshal(Preset{Name: "mp4_720p"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	var fields map[string]interface{}
+	json.Unmarshal(data, &fields)
+	if _, ok := fields["providerOverrides"]; ok {
+		t.Errorf("empty overrides should be omitted: %s", data)
+	}
+}
+
+func TestProviderOverridesValidate(t *testing.T) {
+	tests := []struct {
+		overrides ProviderOverrides
+		errMsg    string
+	}{
+		{nil, ""},
+		{ProviderOverrides{"zencoder": json.RawMessage(`{"speed":2}`)}, ""},
+		{ProviderOverrides{"hybrik": json.RawMessage(`{}`)}, `providerOverrides: unexpected provider "hybrik"`},
+		{ProviderOverrides{"zencoder": json.RawMessage(`[{"speed":2}]`)}, `providerOverrides: overrides for "zencoder" must be a JSON object`},
+		{ProviderOverrides{"zencoder": json.RawMessage(`null`)}, `providerOverrides: overrides for "zencoder" must be a JSON object`},
+	}
+	for _, test := range tests {
+		err := test.overrides.Validate([]string{"mediaconvert", "zencoder"})
+		if test.errMsg == "" {
+			if err != nil {
+				t.Errorf("%#v: unexpected error: %v", test.overrides, err)
+			}
+			continue
+		}
+		if err == nil || err.Error() != test.errMsg {
+			t.Errorf("%#v: wrong error\nwant %q\ngot  %v", test.overrides, test.errMsg, err)
+		}
+	}
+}
@@ -0,0 +1,69 @@
+package db
+
+import (
+	"errors"
+	"fmt"
+)
+
+// PresetGroup is a named list of presets that jobs can reference instead of
+// listing each of their outputs. Adaptive bitrate ladders are stored as
+// preset groups too (see LadderSpec).
+//
+// swagger:model
+type PresetGroup struct {
+	// name of the preset group
+	//
+	// unique: true
+	// required: true
+	Name string `redis-hash:"-" json:"name"`
+
+	// names of the presets of the group, in the order of the job outputs
+	//
+	// required: true
+	Presets []string `redis-hash:"presets" json:"presets"`
+
+	// file names of the outputs, keyed by preset name. Outputs without a
+	// file name are named after the source and the preset
+	FileNames PresetFileNames `redis-hash:"filenames,json,omitempty" json:"fileNames,omitempty"`
+
+	// adaptive streaming parameters used by jobs that reference the group
+	// and don't define their own
+	StreamingParams StreamingParams `redis-hash:"streamingparams,expand" json:"streamingParams"`
+
+	// whether the presets make up an adaptive bitrate ladder, from the
+	// lowest to the highest rung. Rungs taller than the source are left out
+	// of jobs that probe their source. It's set by the server: only groups
+	// created through the ladders API are ladders
+	Ladder bool `redis-hash:"ladder" json:"ladder,omitempty"`
+}
+
+// Validate checks that the group has a name and lists each of its presets
+// once, and that file names are only given for presets of the group.
+func (g PresetGroup) Validate() error {
+	if g.Name == "" {
+		return errors.New("missing preset group name")
+	}
+	if len(g.Presets) == 0 {
+		return errors.New("missing presets from preset group")
+	}
+	presets := make(map[string]bool, len(g.Presets))
+	for _, preset := range g.Presets {
+		if preset == "" {
+			return errors.New("preset names can't be empty")
+		}
+		if presets[preset] {
+			return fmt.Errorf("preset %q is listed more than once", preset)
+		}
+		presets[preset] = true
+	}
+	for preset := range g.FileNames {
+		if !presets[preset] {
+			return fmt.Errorf("file name given for preset %q, which isn't part of the group", preset)
+		}
+	}
+	return g.StreamingParams.Validate()
+}
+
+// PresetFileNames maps the name of each preset of a group to the file name
+// of its output.
+type PresetFileNames map[string]string
@@ -0,0 +1,59 @@
+package db
+
+import "testing"
+
+func TestPresetGroupValidate(t *testing.T) {
+	tests := []struct {
+		name    string
+		group   PresetGroup
+		wantErr string
+	}{
+		{
+			"valid group",
+			PresetGroup{
+				Name:            "standard",
+				Presets:         []string{"hls_360p", "hls_720p"},
+				FileNames:       PresetFileNames{"hls_720p": "hls/720p.m3u8"},
+				StreamingParams: StreamingParams{Protocol: "hls", SegmentDuration: 6},
+			},
+			"",
+		},
+		{
+			"missing name",
+			PresetGroup{Presets: []string{"hls_360p"}},
+			"missing preset group name",
+		},
+		{
+			"missing presets",
+			PresetGroup{Name: "standard"},
+			"missing presets from preset group",
+		},
+		{
+			"empty preset name",
+			PresetGroup{Name: "standard", Presets: []string{"hls_360p", ""}},
+			"preset names can't be empty",
+		},
+		{
+			"duplicate preset",
+			PresetGroup{Name: "standard", Presets: []string{"hls_360p", "hls_360p"}},
+			`preset "hls_360p" is listed more than once`,
+		},
+		{
+			"file name for unknown preset",
+			PresetGroup{Name: "standard", Presets: []string{"hls_360p"}, FileNames: PresetFileNames{"hls_720p": "720p.m3u8"}},
+			`file name given for preset "hls_720p", which isn't part of the group`,
+		},
+	}
+	for _, test := range tests {
+		err := test.group.Validate()
+		if test.wantErr == "" {
+			if err != nil {
+				t.Errorf("%s: unexpected error: %s", test.name, err)
+			}
+			continue
+		}
+		if err == nil || err.Error() != test.wantErr {
+			t.Errorf("%s: wrong error. Want %q. Got %v", test.name, test.wantErr, err)
+		}
+	}
+}
@@ -0,0 +1,30 @@
+package db
+
+import "sort"
+
+// PresetSnapshots maps the name of each preset used in a job to the
+// canonical preset the job was submitted with, so the job doesn't depend on
+// the preset being available afterwards.
+type PresetSnapshots map[string]Preset
+
+// PresetNames returns the names of the presets used in the job,
+// alphabetically ordered. Outputs aren't always stored along with the job,
+// so the names are also taken from the preset versions and snapshots.
+func (j Job) PresetNames() []string {
+	seen := make(map[string]bool)
+	for _, output := range j.Outputs {
+		seen[output.Preset.Name] = true
+	}
+	for name := range j.PresetVersions {
+		seen[name] = true
+	}
+	for name := range j.PresetSnapshots {
+		seen[name] = true
+	}
+	names := make([]string, 0, len(seen))
+	for name := range seen {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
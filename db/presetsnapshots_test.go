@@ -0,0 +1,45 @@
+package db
+
+import (
+	"encoding/json"
+	"reflect"
+	"testing"
+)
+
+func TestPresetSnapshotsJSON(t *testing.T) {
+	snapshots := PresetSnapshots{
+		"mp4_720p": {Name: "mp4_720p", Container: "mp4", Video: VideoPreset{Codec: "h264", Height: "720"}},
+		"webm_480p": {
+			Name:              "webm_480p",
+			Container:         "webm",
+			ProviderOverrides: ProviderOverrides{"zencoder": []byte(`{"speed":2}`)},
+		},
+	}
+	data, err := json.Marshal(snapshots)
+	if err != nil {
+		t.Fatal(err)
+	}
+	var got PresetSnapshots
+	err = json.Unmarshal(data, &got)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !reflect.DeepEqual(got, snapshots) {
+		t.Errorf("wrong snapshots\nwant %#v\ngot  %#v", snapshots, got)
+	}
+}
+
+func TestJobPresetNames(t *testing.T) {
+	job := Job{
+		Outputs: []TranscodeOutput{
+			{Preset: PresetMap{Name: "webm_480p"}},
+			{Preset: PresetMap{Name: "mp4_720p"}},
+		},
+		PresetVersions:  PresetVersions{"mp4_720p": 2, "mp4_1080p": 1},
+		PresetSnapshots: PresetSnapshots{"hls_360p": {Name: "hls_360p"}},
+	}
+	want := []string{"hls_360p", "mp4_1080p", "mp4_720p", "webm_480p"}
+	if got := job.PresetNames(); !reflect.DeepEqual(got, want) {
+		t.Errorf("wrong preset names\nwant %#v\ngot  %#v", want, got)
+	}
+}
@@ -0,0 +1,5 @@
+package db
+
+// PresetVersions maps the name of each preset used in a job to the version
+// of the preset the job was submitted with.
+type PresetVersions map[string]uint
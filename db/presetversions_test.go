@@ -0,0 +1,29 @@
+package db
+
+import (
+	"encoding/json"
+	"reflect"
+	"testing"
+)
+
+func TestPresetVersionsJSON(t *testing.T) {
+	job := Job{ID: "job-123", PresetVersions: PresetVersions{"mp4_720p": 3}}
+	data, err := json.Marshal(job)
+	if err != nil {
+		t.Fatal(err)
+	}
+	var fields map[string]interface{}
+	json.Unmarshal(data, &fields)
+	expected := map[string]interface{}{"mp4_720p": float64(3)}
+	if !reflect.DeepEqual(fields["presetVersions"], expected) {
+		t.Errorf("wrong JSON\nwant %#v\ngot  %#v", expected, fields["presetVersions"])
+	}
+	var got Job
+	err = json.Unmarshal(data, &got)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !reflect.DeepEqual(got.PresetVersions, job.PresetVersions) {
+		t.Errorf("wrong versions\nwant %#v\ngot  %#v", job.PresetVersions, got.PresetVersions)
+	}
+}
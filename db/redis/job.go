@@ -20,6 +20,13 @@ func (r *redisRepository) CreateJob(job *db.Job) error {
 	return r.saveJob(job)
 }
 
+func (r *redisRepository) UpdateJob(job *db.Job) error {
+	if _, err := r.GetJob(job.ID); err == db.ErrJobNotFound {
+		return err
+	}
+	return r.saveJob(job)
+}
+
 func (r *redisRepository) saveJob(job *db.Job) error {
 	fields, err := r.storage.FieldMap(job)
 	if err != nil {
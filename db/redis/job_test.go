@@ -1,6 +1,7 @@
 package redis
 
 import (
+	"encoding/json"
 	"math"
 	"os"
 	"reflect"
@@ -53,11 +54,18 @@ func TestCreateJob(t *testing.T) {
 	if err != nil {
 		t.Fatal(err)
 	}
+	outputs, err := json.Marshal(job.Outputs)
+	if err != nil {
+		t.Fatal(err)
+	}
 	expected := map[string]string{
+		"outputs":                          string(outputs),
 		"source":                           "http://nyt.net/source_here.mp4",
 		"jobID":                            "job1",
 		"providerName":                     "encoding.com",
 		"providerJobID":                    "",
+		"contentaware":                     "false",
+		"verifyoutputs":                    "false",
 		"streamingparams_segmentDuration":  "10",
 		"streamingparams_protocol":         "hls",
 		"streamingparams_playlistFileName": "hls/playlist.m3u8",
@@ -78,6 +86,54 @@ func TestCreateJob(t *testing.T) {
 	}
 }
 
+func TestUpdateJob(t *testing.T) {
+	err := cleanRedis()
+	if err != nil {
+		t.Fatal(err)
+	}
+	repo, err := NewRepository(&config.Config{Redis: new(storage.Config)})
+	if err != nil {
+		t.Fatal(err)
+	}
+	job := db.Job{ID: "job1", ProviderName: "encoding.com", VerifyOutputs: true}
+	err = repo.CreateJob(&job)
+	if err != nil {
+		t.Fatal(err)
+	}
+	job.Verification = &db.VerificationReport{
+		Status:     db.VerificationFailed,
+		VerifiedAt: time.Now().UTC().Truncate(time.Millisecond),
+		Outputs:    []db.OutputVerification{{Path: "s3://bucket/job1/output.mp4", Errors: []string{"output not found"}}},
+	}
+	err = repo.UpdateJob(&job)
+	if err != nil {
+		t.Fatal(err)
+	}
+	gotJob, err := repo.GetJob(job.ID)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !reflect.DeepEqual(*gotJob, job) {
+		pretty.Fdiff(os.Stderr, job, *gotJob)
+		t.Errorf("wrong job returned. Want %#v. Got %#v", job, *gotJob)
+	}
+}
+
+func TestUpdateJobNotFound(t *testing.T) {
+	err := cleanRedis()
+	if err != nil {
+		t.Fatal(err)
+	}
+	repo, err := NewRepository(&config.Config{Redis: new(storage.Config)})
+	if err != nil {
+		t.Fatal(err)
+	}
+	err = repo.UpdateJob(&db.Job{ID: "job1"})
+	if err != db.ErrJobNotFound {
+		t.Errorf("wrong error returned. Want %#v. Got %#v", db.ErrJobNotFound, err)
+	}
+}
+
 func TestCreateJobIsSafe(t *testing.T) {
 	err := cleanRedis()
 	if err != nil {
@@ -179,7 +235,26 @@ func TestGetJob(t *testing.T) {
 	if err != nil {
 		t.Fatal(err)
 	}
-	job := db.Job{ID: "myjob"}
+	job := db.Job{
+		ID: "myjob",
+		Outputs: []db.TranscodeOutput{{
+			Preset: db.PresetMap{
+				Name:            "mp4_1080p",
+				ProviderMapping: map[string]string{"encodingcom": "preset-1"},
+				OutputOpts:      db.OutputOptions{Extension: "mp4"},
+				Preset:          &db.Preset{Name: "mp4_1080p", Container: "mp4"},
+				Version:         2,
+			},
+			FileName: "output_1080p.mp4",
+		}},
+		SourceInfo: &db.SourceInfo{
+			Duration:    time.Minute,
+			Width:       1920,
+			Height:      1080,
+			VideoCodec:  "h264",
+			AudioTracks: []db.SourceAudioTrack{{Codec: "aac", Channels: 2, Language: "eng"}},
+		},
+	}
 	err = repo.CreateJob(&job)
 	if err != nil {
 		t.Fatal(err)
@@ -0,0 +1,90 @@
+package redis
+
+import (
+	"errors"
+
+	"github.com/go-redis/redis"
+	"github.com/video-dev/video-transcoding-api/v2/db"
+	"github.com/video-dev/video-transcoding-api/v2/db/redis/storage"
+)
+
+const presetGroupsSetKey = "presetgroups"
+
+func (r *redisRepository) CreatePresetGroup(group *db.PresetGroup) error {
+	if group.Name == "" {
+		return errors.New("preset group name missing")
+	}
+	if _, err := r.GetPresetGroup(group.Name); err == nil {
+		return db.ErrPresetGroupAlreadyExists
+	}
+	return r.savePresetGroup(group)
+}
+
+func (r *redisRepository) UpdatePresetGroup(group *db.PresetGroup) error {
+	if _, err := r.GetPresetGroup(group.Name); err == db.ErrPresetGroupNotFound {
+		return err
+	}
+	return r.savePresetGroup(group)
+}
+
+// savePresetGroup replaces the stored hash of the group, so fields left out
+// of the new version of the group aren't kept.
+func (r *redisRepository) savePresetGroup(group *db.PresetGroup) error {
+	fields, err := r.storage.FieldMap(group)
+	if err != nil {
+		return err
+	}
+	groupKey := r.presetGroupKey(group.Name)
+	return r.storage.RedisClient().Watch(func(tx *redis.Tx) error {
+		_, err := tx.Pipelined(func(pipe redis.Pipeliner) error {
+			pipe.Del(groupKey)
+			pipe.HMSet(groupKey, fields)
+			pipe.SAdd(presetGroupsSetKey, group.Name)
+			return nil
+		})
+		return err
+	}, groupKey)
+}
+
+func (r *redisRepository) DeletePresetGroup(group *db.PresetGroup) error {
+	err := r.storage.Delete(r.presetGroupKey(group.Name))
+	if err != nil {
+		if err == storage.ErrNotFound {
+			return db.ErrPresetGroupNotFound
+		}
+		return err
+	}
+	r.storage.RedisClient().SRem(presetGroupsSetKey, group.Name)
+	return nil
+}
+
+func (r *redisRepository) GetPresetGroup(name string) (*db.PresetGroup, error) {
+	group := db.PresetGroup{Name: name}
+	err := r.storage.Load(r.presetGroupKey(name), &group)
+	if err == storage.ErrNotFound {
+		return nil, db.ErrPresetGroupNotFound
+	}
+	return &group, err
+}
+
+func (r *redisRepository) ListPresetGroups() ([]db.PresetGroup, error) {
+	names, err := r.storage.RedisClient().SMembers(presetGroupsSetKey).Result()
+	if err != nil {
+		return nil, err
+	}
+	groups := make([]db.PresetGroup, 0, len(names))
+	for _, name := range names {
+		group, err := r.GetPresetGroup(name)
+		if err != nil && err != db.ErrPresetGroupNotFound {
+			return nil, err
+		}
+		if group != nil {
+			groups = append(groups, *group)
+		}
+	}
+	return groups, nil
+}
+
+func (r *redisRepository) presetGroupKey(name string) string {
+	return "presetgroup:" + name
+}
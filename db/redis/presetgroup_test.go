@@ -0,0 +1,178 @@
+package redis
+
+import (
+	"reflect"
+	"sort"
+	"testing"
+
+	"github.com/video-dev/video-transcoding-api/v2/config"
+	"github.com/video-dev/video-transcoding-api/v2/db"
+	"github.com/video-dev/video-transcoding-api/v2/db/redis/storage"
+)
+
+func TestCreatePresetGroup(t *testing.T) {
+	err := cleanRedis()
+	if err != nil {
+		t.Fatal(err)
+	}
+	repo, err := NewRepository(&config.Config{Redis: new(storage.Config)})
+	if err != nil {
+		t.Fatal(err)
+	}
+	group := db.PresetGroup{
+		Name:            "standard",
+		Presets:         []string{"hls_360p", "hls_720p"},
+		FileNames:       db.PresetFileNames{"hls_720p": "hls/720p.m3u8"},
+		StreamingParams: db.StreamingParams{Protocol: "hls", SegmentDuration: 6},
+	}
+	err = repo.CreatePresetGroup(&group)
+	if err != nil {
+		t.Fatal(err)
+	}
+	client := repo.(*redisRepository).storage.RedisClient()
+	defer client.Close()
+	items, err := client.HGetAll("presetgroup:standard").Result()
+	if err != nil {
+		t.Fatal(err)
+	}
+	expectedItems := map[string]string{
+		"presets":                          "hls_360p%%%hls_720p",
+		"filenames":                        `{"hls_720p":"hls/720p.m3u8"}`,
+		"streamingparams_protocol":         "hls",
+		"streamingparams_segmentDuration":  "6",
+		"streamingparams_playlistFileName": "",
+		"ladder":                           "false",
+	}
+	if !reflect.DeepEqual(items, expectedItems) {
+		t.Errorf("Wrong preset group hash returned from Redis. Want %#v. Got %#v", expectedItems, items)
+	}
+	err = repo.CreatePresetGroup(&group)
+	if err != db.ErrPresetGroupAlreadyExists {
+		t.Errorf("Got wrong error. Want %#v. Got %#v", db.ErrPresetGroupAlreadyExists, err)
+	}
+}
+
+func TestUpdatePresetGroup(t *testing.T) {
+	err := cleanRedis()
+	if err != nil {
+		t.Fatal(err)
+	}
+	repo, err := NewRepository(&config.Config{Redis: new(storage.Config)})
+	if err != nil {
+		t.Fatal(err)
+	}
+	group := db.PresetGroup{
+		Name:      "standard",
+		Presets:   []string{"hls_360p", "hls_720p"},
+		FileNames: db.PresetFileNames{"hls_720p": "hls/720p.m3u8"},
+	}
+	err = repo.CreatePresetGroup(&group)
+	if err != nil {
+		t.Fatal(err)
+	}
+	group = db.PresetGroup{Name: "standard", Presets: []string{"hls_1080p"}}
+	err = repo.UpdatePresetGroup(&group)
+	if err != nil {
+		t.Fatal(err)
+	}
+	gotGroup, err := repo.GetPresetGroup(group.Name)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !reflect.DeepEqual(*gotGroup, group) {
+		t.Errorf("Wrong preset group. Want %#v. Got %#v", group, *gotGroup)
+	}
+	err = repo.UpdatePresetGroup(&db.PresetGroup{Name: "unknown"})
+	if err != db.ErrPresetGroupNotFound {
+		t.Errorf("Got wrong error. Want %#v. Got %#v", db.ErrPresetGroupNotFound, err)
+	}
+}
+
+func TestGetPresetGroup(t *testing.T) {
+	err := cleanRedis()
+	if err != nil {
+		t.Fatal(err)
+	}
+	repo, err := NewRepository(&config.Config{Redis: new(storage.Config)})
+	if err != nil {
+		t.Fatal(err)
+	}
+	group := db.PresetGroup{
+		Name:            "standard",
+		Presets:         []string{"hls_360p", "hls_720p"},
+		FileNames:       db.PresetFileNames{"hls_360p": "hls/360p.m3u8"},
+		StreamingParams: db.StreamingParams{Protocol: "cmaf", SegmentDuration: 4},
+	}
+	err = repo.CreatePresetGroup(&group)
+	if err != nil {
+		t.Fatal(err)
+	}
+	gotGroup, err := repo.GetPresetGroup(group.Name)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !reflect.DeepEqual(*gotGroup, group) {
+		t.Errorf("Wrong preset group. Want %#v. Got %#v", group, *gotGroup)
+	}
+	_, err = repo.GetPresetGroup("unknown")
+	if err != db.ErrPresetGroupNotFound {
+		t.Errorf("Got wrong error. Want %#v. Got %#v", db.ErrPresetGroupNotFound, err)
+	}
+}
+
+func TestDeletePresetGroup(t *testing.T) {
+	err := cleanRedis()
+	if err != nil {
+		t.Fatal(err)
+	}
+	repo, err := NewRepository(&config.Config{Redis: new(storage.Config)})
+	if err != nil {
+		t.Fatal(err)
+	}
+	group := db.PresetGroup{Name: "standard", Presets: []string{"hls_360p"}}
+	err = repo.CreatePresetGroup(&group)
+	if err != nil {
+		t.Fatal(err)
+	}
+	err = repo.DeletePresetGroup(&db.PresetGroup{Name: group.Name})
+	if err != nil {
+		t.Fatal(err)
+	}
+	_, err = repo.GetPresetGroup(group.Name)
+	if err != db.ErrPresetGroupNotFound {
+		t.Errorf("Got wrong error. Want %#v. Got %#v", db.ErrPresetGroupNotFound, err)
+	}
+	err = repo.DeletePresetGroup(&db.PresetGroup{Name: group.Name})
+	if err != db.ErrPresetGroupNotFound {
+		t.Errorf("Got wrong error. Want %#v. Got %#v", db.ErrPresetGroupNotFound, err)
+	}
+}
+
+func TestListPresetGroups(t *testing.T) {
+	err := cleanRedis()
+	if err != nil {
+		t.Fatal(err)
+	}
+	repo, err := NewRepository(&config.Config{Redis: new(storage.Config)})
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, name := range []string{"group-1", "group-2"} {
+		err = repo.CreatePresetGroup(&db.PresetGroup{Name: name, Presets: []string{name + "_360p"}})
+		if err != nil {
+			t.Fatal(err)
+		}
+	}
+	groups, err := repo.ListPresetGroups()
+	if err != nil {
+		t.Fatal(err)
+	}
+	names := make([]string, len(groups))
+	for i, group := range groups {
+		names[i] = group.Name
+	}
+	sort.Strings(names)
+	if expected := []string{"group-1", "group-2"}; !reflect.DeepEqual(names, expected) {
+		t.Errorf("Wrong preset groups. Want %#v. Got %#v", expected, names)
+	}
+}
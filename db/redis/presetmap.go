@@ -1,6 +1,10 @@
 package redis
 
 import (
+	"fmt"
+	"sort"
+	"strconv"
+
 	"github.com/go-redis/redis"
 	"github.com/video-dev/video-transcoding-api/v2/db"
 	"github.com/video-dev/video-transcoding-api/v2/db/redis/storage"
@@ -22,18 +26,30 @@ func (r *redisRepository) UpdatePresetMap(presetMap *db.PresetMap) error {
 	return r.savePresetMap(presetMap)
 }
 
+// savePresetMap stores the presetmap with the next version number, keeping a
+// copy of it under the new version. The previous hash is replaced, so fields
+// and provider mappings that were removed don't linger.
 func (r *redisRepository) savePresetMap(presetMap *db.PresetMap) error {
-	fields, err := r.storage.FieldMap(presetMap)
-	if err != nil {
-		return err
-	}
 	presetMapKey := r.presetMapKey(presetMap.Name)
 	return r.storage.RedisClient().Watch(func(tx *redis.Tx) error {
-		err := tx.HMSet(presetMapKey, fields).Err()
+		version, err := tx.HGet(presetMapKey, "version").Uint64()
+		if err != nil && err != redis.Nil {
+			return err
+		}
+		presetMap.Version = uint(version) + 1
+		fields, err := r.storage.FieldMap(presetMap)
 		if err != nil {
 			return err
 		}
-		return tx.SAdd(presetmapsSetKey, presetMap.Name).Err()
+		_, err = tx.Pipelined(func(pipe redis.Pipeliner) error {
+			pipe.Del(presetMapKey)
+			pipe.HMSet(presetMapKey, fields)
+			pipe.SAdd(presetmapsSetKey, presetMap.Name)
+			pipe.HMSet(r.presetMapVersionKey(presetMap.Name, presetMap.Version), fields)
+			pipe.SAdd(r.presetMapVersionsKey(presetMap.Name), presetMap.Version)
+			return nil
+		})
+		return err
 	}, presetMapKey)
 }
 
@@ -46,7 +62,85 @@ func (r *redisRepository) DeletePresetMap(presetMap *db.PresetMap) error {
 		return err
 	}
 	r.storage.RedisClient().SRem(presetmapsSetKey, presetMap.Name)
-	return nil
+	return r.deletePresetMapVersions(presetMap.Name)
+}
+
+func (r *redisRepository) deletePresetMapVersions(name string) error {
+	versions, err := r.presetMapVersions(name)
+	if err != nil {
+		return err
+	}
+	keys := []string{r.presetMapVersionsKey(name)}
+	for _, version := range versions {
+		keys = append(keys, r.presetMapVersionKey(name, version))
+	}
+	return r.storage.RedisClient().Del(keys...).Err()
+}
+
+// presetMapVersions returns the sorted list of versions stored for the given
+// presetmap.
+func (r *redisRepository) presetMapVersions(name string) ([]uint, error) {
+	members, err := r.storage.RedisClient().SMembers(r.presetMapVersionsKey(name)).Result()
+	if err != nil {
+		return nil, err
+	}
+	versions := make([]uint, 0, len(members))
+	for _, member := range members {
+		version, err := strconv.ParseUint(member, 10, 64)
+		if err != nil {
+			return nil, err
+		}
+		versions = append(versions, uint(version))
+	}
+	sort.Slice(versions, func(i, j int) bool { return versions[i] < versions[j] })
+	return versions, nil
+}
+
+func (r *redisRepository) GetPresetMapVersion(name string, version uint) (*db.PresetMap, error) {
+	presetMap := db.PresetMap{Name: name, ProviderMapping: make(map[string]string)}
+	err := r.storage.Load(r.presetMapVersionKey(name, version), &presetMap)
+	if err == storage.ErrNotFound {
+		return nil, db.ErrPresetMapVersionNotFound
+	}
+	return &presetMap, err
+}
+
+func (r *redisRepository) UpdatePresetMapVersion(presetMap *db.PresetMap) error {
+	if _, err := r.GetPresetMapVersion(presetMap.Name, presetMap.Version); err == db.ErrPresetMapVersionNotFound {
+		return err
+	}
+	fields, err := r.storage.FieldMap(presetMap)
+	if err != nil {
+		return err
+	}
+	versionKey := r.presetMapVersionKey(presetMap.Name, presetMap.Version)
+	_, err = r.storage.RedisClient().TxPipelined(func(pipe redis.Pipeliner) error {
+		pipe.Del(versionKey)
+		pipe.HMSet(versionKey, fields)
+		return nil
+	})
+	return err
+}
+
+func (r *redisRepository) ListPresetMapVersions(name string) ([]db.PresetMap, error) {
+	versions, err := r.presetMapVersions(name)
+	if err != nil {
+		return nil, err
+	}
+	if len(versions) == 0 {
+		return nil, db.ErrPresetMapNotFound
+	}
+	presetMaps := make([]db.PresetMap, 0, len(versions))
+	for _, version := range versions {
+		presetMap, err := r.GetPresetMapVersion(name, version)
+		if err != nil && err != db.ErrPresetMapVersionNotFound {
+			return nil, err
+		}
+		if presetMap != nil {
+			presetMaps = append(presetMaps, *presetMap)
+		}
+	}
+	return presetMaps, nil
 }
 
 func (r *redisRepository) GetPresetMap(name string) (*db.PresetMap, error) {
@@ -79,3 +173,11 @@ func (r *redisRepository) ListPresetMaps() ([]db.PresetMap, error) {
 func (r *redisRepository) presetMapKey(name string) string {
 	return "presetmap:" + name
 }
+
+func (r *redisRepository) presetMapVersionsKey(name string) string {
+	return "presetmapversions:" + name
+}
+
+func (r *redisRepository) presetMapVersionKey(name string, version uint) string {
+	return fmt.Sprintf("presetmapversion:%s:%d", name, version)
+}
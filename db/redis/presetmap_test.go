@@ -43,6 +43,7 @@ func TestCreatePresetMap(t *testing.T) {
 		"pmapping_elastictranscoder":  "1281742-93939",
 		"output_extension":            "ts",
 		"presetmap_name":              "mypreset",
+		"version":                     "1",
 	}
 	if !reflect.DeepEqual(items, expectedItems) {
 		t.Errorf("Wrong presetmap hash returned from Redis. Want %#v. Got %#v", expectedItems, items)
@@ -106,10 +107,29 @@ func TestUpdatePresetMap(t *testing.T) {
 		"pmapping_elastictranscoder": "def123",
 		"output_extension":           "mp4",
 		"presetmap_name":             "mypresetmap",
+		"version":                    "2",
 	}
 	if !reflect.DeepEqual(items, expectedItems) {
 		t.Errorf("Wrong presetmap hash returned from Redis. Want %#v. Got %#v", expectedItems, items)
 	}
+	presetmap.ProviderMapping = map[string]string{"elemental": "abc12345"}
+	err = repo.UpdatePresetMap(&presetmap)
+	if err != nil {
+		t.Fatal(err)
+	}
+	items, err = client.HGetAll("presetmap:" + presetmap.Name).Result()
+	if err != nil {
+		t.Fatal(err)
+	}
+	expectedItems = map[string]string{
+		"pmapping_elemental": "abc12345",
+		"output_extension":   "mp4",
+		"presetmap_name":     "mypresetmap",
+		"version":            "3",
+	}
+	if !reflect.DeepEqual(items, expectedItems) {
+		t.Errorf("Stale fields left in the presetmap hash. Want %#v. Got %#v", expectedItems, items)
+	}
 }
 
 func TestUpdatePresetMapNotFound(t *testing.T) {
@@ -216,6 +236,68 @@ func TestGetPresetMapNotFound(t *testing.T) {
 	}
 }
 
+func TestPresetMapVersions(t *testing.T) {
+	err := cleanRedis()
+	if err != nil {
+		t.Fatal(err)
+	}
+	repo, err := NewRepository(&config.Config{Redis: new(storage.Config)})
+	if err != nil {
+		t.Fatal(err)
+	}
+	presetmap := db.PresetMap{
+		Name:            "mypresetmap",
+		ProviderMapping: map[string]string{"elemental": "abc123"},
+		OutputOpts:      db.OutputOptions{Extension: "mp4"},
+	}
+	err = repo.CreatePresetMap(&presetmap)
+	if err != nil {
+		t.Fatal(err)
+	}
+	firstVersion := presetmap
+	firstVersion.ProviderMapping = map[string]string{"elemental": "abc123"}
+	presetmap.ProviderMapping = map[string]string{"elemental": "abc1234"}
+	err = repo.UpdatePresetMap(&presetmap)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if presetmap.Version != 2 {
+		t.Errorf("wrong version after update. Want 2. Got %d", presetmap.Version)
+	}
+	versions, err := repo.ListPresetMapVersions(presetmap.Name)
+	if err != nil {
+		t.Fatal(err)
+	}
+	expected := []db.PresetMap{firstVersion, presetmap}
+	if !reflect.DeepEqual(versions, expected) {
+		t.Errorf("wrong versions. Want %#v. Got %#v", expected, versions)
+	}
+	firstVersion.ProviderMapping["elemental"] = "abc12345"
+	err = repo.UpdatePresetMapVersion(&firstVersion)
+	if err != nil {
+		t.Fatal(err)
+	}
+	gotVersion, err := repo.GetPresetMapVersion(presetmap.Name, 1)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !reflect.DeepEqual(*gotVersion, firstVersion) {
+		t.Errorf("wrong version. Want %#v. Got %#v", firstVersion, *gotVersion)
+	}
+	_, err = repo.GetPresetMapVersion(presetmap.Name, 3)
+	if err != db.ErrPresetMapVersionNotFound {
+		t.Errorf("wrong error returned by GetPresetMapVersion. Want ErrPresetMapVersionNotFound. Got %#v", err)
+	}
+	err = repo.DeletePresetMap(&db.PresetMap{Name: presetmap.Name})
+	if err != nil {
+		t.Fatal(err)
+	}
+	_, err = repo.ListPresetMapVersions(presetmap.Name)
+	if err != db.ErrPresetMapNotFound {
+		t.Errorf("wrong error returned by ListPresetMapVersions after delete. Want ErrPresetMapNotFound. Got %#v", err)
+	}
+}
+
 func TestListPresetMaps(t *testing.T) {
 	err := cleanRedis()
 	if err != nil {
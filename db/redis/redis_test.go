@@ -13,10 +13,18 @@ func cleanRedis() error {
 	if err != nil {
 		return err
 	}
+	err = deleteKeys("presetmapversion*", client)
+	if err != nil {
+		return err
+	}
 	err = deleteKeys("localpreset:*", client)
 	if err != nil {
 		return err
 	}
+	err = deleteKeys("presetgroup:*", client)
+	if err != nil {
+		return err
+	}
 	err = deleteKeys(presetmapsSetKey, client)
 	if err != nil {
 		return err
@@ -25,6 +33,10 @@ func cleanRedis() error {
 	if err != nil {
 		return err
 	}
+	err = deleteKeys(presetGroupsSetKey, client)
+	if err != nil {
+		return err
+	}
 
 	return deleteKeys(jobsSetKey, client)
 }
@@ -2,6 +2,7 @@
 package storage
 
 import (
+	"encoding/json"
 	"errors"
 	"fmt"
 	"reflect"
@@ -135,6 +136,9 @@ func (s *Storage) structToFieldList(value reflect.Value, prefixes ...string) (ma
 		}
 		parts := strings.Split(fieldName, ",")
 		fieldValue := value.Field(i)
+		if fieldValue.Kind() == reflect.Ptr && fieldValue.IsNil() {
+			continue
+		}
 		if len(parts) > 1 && parts[len(parts)-1] == "expand" {
 			if fieldValue.Kind() == reflect.Ptr {
 				fieldValue = fieldValue.Elem()
@@ -163,14 +167,21 @@ func (s *Storage) structToFieldList(value reflect.Value, prefixes ...string) (ma
 		} else if parts[0] != "" {
 			key := strings.Join(append(prefixes, parts[0]), "_")
 			var strValue string
-			iface := fieldValue.Interface()
-			switch v := iface.(type) {
-			case time.Time:
-				strValue = v.Format(time.RFC3339Nano)
-			case []string:
-				strValue = strings.Join(v, "%%%")
-			default:
-				strValue = fmt.Sprintf("%v", v)
+			if hasOption(parts, "json") {
+				text, err := marshalJSON(fieldValue)
+				if err != nil {
+					return nil, err
+				}
+				strValue = text
+			} else {
+				switch v := fieldValue.Interface().(type) {
+				case time.Time:
+					strValue = v.Format(time.RFC3339Nano)
+				case []string:
+					strValue = strings.Join(v, "%%%")
+				default:
+					strValue = fmt.Sprintf("%v", v)
+				}
 			}
 			if parts[len(parts)-1] == "omitempty" && strValue == "" {
 				continue
@@ -239,6 +250,12 @@ func (s *Storage) loadStruct(in map[string]string, out reflect.Value, prefixes .
 		if len(parts) > 1 && parts[len(parts)-1] == "expand" {
 			myPrefixes := append(prefixes, parts[0])
 			if fieldValue.Kind() == reflect.Ptr {
+				if fieldValue.IsNil() {
+					if !hasPrefix(in, strings.Join(myPrefixes, "_")+"_") {
+						continue
+					}
+					fieldValue.Set(reflect.New(fieldValue.Type().Elem()))
+				}
 				fieldValue = fieldValue.Elem()
 			}
 			switch fieldValue.Kind() {
@@ -258,6 +275,16 @@ func (s *Storage) loadStruct(in map[string]string, out reflect.Value, prefixes .
 		} else {
 			key := strings.Join(append(prefixes, parts[0]), "_")
 			if value, ok := in[key]; ok {
+				if hasOption(parts, "json") {
+					if value == "" {
+						continue
+					}
+					err := json.Unmarshal([]byte(value), fieldValue.Addr().Interface())
+					if err != nil {
+						return err
+					}
+					continue
+				}
 				switch fieldValue.Kind() {
 				case reflect.Slice:
 					values := strings.Split(value, "%%%")
@@ -305,6 +332,42 @@ func (s *Storage) loadStruct(in map[string]string, out reflect.Value, prefixes .
 	return nil
 }
 
+// hasOption returns whether the given option is present in the parts of a
+// redis-hash tag, after the name of the field.
+func hasOption(parts []string, option string) bool {
+	for _, part := range parts[1:] {
+		if part == option {
+			return true
+		}
+	}
+	return false
+}
+
+// marshalJSON encodes the value of a field tagged with the json option.
+// Empty maps and slices are encoded as an empty string.
+func marshalJSON(value reflect.Value) (string, error) {
+	switch value.Kind() {
+	case reflect.Map, reflect.Slice:
+		if value.Len() == 0 {
+			return "", nil
+		}
+	}
+	data, err := json.Marshal(value.Interface())
+	if err != nil {
+		return "", err
+	}
+	return string(data), nil
+}
+
+func hasPrefix(in map[string]string, prefix string) bool {
+	for k := range in {
+		if strings.HasPrefix(k, prefix) {
+			return true
+		}
+	}
+	return false
+}
+
 // Delete deletes the given key from redis, returning ErrNotFound when it
 // doesn't exist.
 func (s *Storage) Delete(key string) error {
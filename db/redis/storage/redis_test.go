@@ -315,6 +315,42 @@ func TestFieldMap(t *testing.T) {
 				"preset_audio_codec":         "aac",
 			},
 		},
+		{
+			"json",
+			Playlist{
+				Name:   "playlist",
+				Plays:  map[string]int{"intro": 3},
+				Tracks: []Track{{Title: "intro", Length: 90}},
+				City:   &City{Name: "New York"},
+			},
+			map[string]interface{}{
+				"name":   "playlist",
+				"plays":  `{"intro":3}`,
+				"tracks": `[{"title":"intro","length":90}]`,
+				"city":   `{"Name":"New York"}`,
+			},
+		},
+		{
+			"empty json",
+			Playlist{Name: "playlist", Plays: map[string]int{}},
+			map[string]interface{}{
+				"name": "playlist",
+			},
+		},
+		{
+			"nil pointers",
+			Person{Name: "Gopher", Address: Address{Data: map[string]string{"floor": "3"}, Number: 2}},
+			map[string]interface{}{
+				"name":               "Gopher",
+				"age":                "0",
+				"weight":             "0",
+				"birth":              "0001-01-01T00:00:00Z",
+				"colors":             "",
+				"address_data_floor": "3",
+				"address_number":     "2",
+				"address_main":       "false",
+			},
+		},
 	}
 
 	for _, test := range tests {
@@ -373,6 +409,80 @@ func TestLoadStruct(t *testing.T) {
 	}
 }
 
+func TestLoadJSON(t *testing.T) {
+	storage, err := NewStorage(&Config{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	client := storage.RedisClient()
+	defer client.Close()
+	expected := Playlist{
+		Name:   "playlist",
+		Plays:  map[string]int{"intro": 3},
+		Tracks: []Track{{Title: "intro", Length: 90}},
+		City:   &City{Name: "New York"},
+	}
+	err = storage.Save("test-key", expected)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer client.Del("test-key")
+	var playlist Playlist
+	err = storage.Load("test-key", &playlist)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !reflect.DeepEqual(playlist, expected) {
+		t.Errorf("Didn't load data to struct\nwant %#v\ngot  %#v", expected, playlist)
+	}
+	client.Del("test-key")
+	err = storage.Save("test-key", map[string]string{"name": "other", "plays": ""})
+	if err != nil {
+		t.Fatal(err)
+	}
+	playlist = Playlist{}
+	err = storage.Load("test-key", &playlist)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !reflect.DeepEqual(playlist, Playlist{Name: "other"}) {
+		t.Errorf("Didn't load empty json fields as zero values: %#v", playlist)
+	}
+}
+
+func TestLoadPointers(t *testing.T) {
+	storage, err := NewStorage(&Config{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	client := storage.RedisClient()
+	defer client.Close()
+	err = storage.Save("test-key", map[string]string{
+		"name":              "Gopher",
+		"address_city_name": "New York",
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer client.Del("test-key")
+	var person Person
+	err = storage.Load("test-key", &person)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if person.Address.City == nil || person.Address.City.Name != "New York" {
+		t.Errorf("Didn't allocate expanded pointer: %#v", person.Address.City)
+	}
+	var address Address
+	err = storage.Load("test-key", &address)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if address.City != nil {
+		t.Errorf("Unexpected allocation of expanded pointer: %#v", address.City)
+	}
+}
+
 func TestLoadMap(t *testing.T) {
 	storage, err := NewStorage(&Config{})
 	if err != nil {
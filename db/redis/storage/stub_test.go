@@ -26,6 +26,18 @@ type City struct {
 	Name string `redis-hash:"name"`
 }
 
+type Playlist struct {
+	Name   string         `redis-hash:"name"`
+	Plays  map[string]int `redis-hash:"plays,json,omitempty"`
+	Tracks []Track        `redis-hash:"tracks,json,omitempty"`
+	City   *City          `redis-hash:"city,json"`
+}
+
+type Track struct {
+	Title  string `json:"title"`
+	Length int    `json:"length"`
+}
+
 type InvalidStruct struct {
 	Name string `redis-hash:"name,expand"`
 }
@@ -18,6 +18,11 @@ var (
 	// exists.
 	ErrPresetMapAlreadyExists = errors.New("presetmap already exists")
 
+	// ErrPresetMapVersionNotFound is the error returned when the given
+	// version of a presetmap is not found on GetPresetMapVersion or
+	// UpdatePresetMapVersion.
+	ErrPresetMapVersionNotFound = errors.New("presetmap version not found")
+
 	// ErrLocalPresetNotFound is the error returned when the local preset is not found
 	// on GetPresetMap, UpdatePresetMap or DeletePresetMap.
 	ErrLocalPresetNotFound = errors.New("local preset not found")
@@ -25,6 +30,14 @@ var (
 	// ErrLocalPresetAlreadyExists is the error returned when the local preset already
 	// exists.
 	ErrLocalPresetAlreadyExists = errors.New("local preset already exists")
+
+	// ErrPresetGroupNotFound is the error returned when the preset group is
+	// not found on GetPresetGroup, UpdatePresetGroup or DeletePresetGroup.
+	ErrPresetGroupNotFound = errors.New("preset group not found")
+
+	// ErrPresetGroupAlreadyExists is the error returned when the preset group
+	// already exists.
+	ErrPresetGroupAlreadyExists = errors.New("preset group already exists")
 )
 
 // Repository represents the repository for persisting types of the API.
@@ -32,12 +45,14 @@ type Repository interface {
 	JobRepository
 	PresetMapRepository
 	LocalPresetRepository
+	PresetGroupRepository
 }
 
 // JobRepository is the interface that defines the set of methods for managing Job
 // persistence.
 type JobRepository interface {
 	CreateJob(*Job) error
+	UpdateJob(*Job) error
 	DeleteJob(*Job) error
 	GetJob(id string) (*Job, error)
 	ListJobs(JobFilter) ([]Job, error)
@@ -55,12 +70,19 @@ type JobFilter struct {
 
 // PresetMapRepository is the interface that defines the set of methods for
 // managing PresetMap persistence.
+//
+// CreatePresetMap and UpdatePresetMap assign the version of the presetmap and
+// keep a copy of each version, available through GetPresetMapVersion and
+// ListPresetMapVersions. Versions are removed along with the presetmap.
 type PresetMapRepository interface {
 	CreatePresetMap(*PresetMap) error
 	UpdatePresetMap(*PresetMap) error
 	DeletePresetMap(*PresetMap) error
 	GetPresetMap(name string) (*PresetMap, error)
 	ListPresetMaps() ([]PresetMap, error)
+	GetPresetMapVersion(name string, version uint) (*PresetMap, error)
+	UpdatePresetMapVersion(*PresetMap) error
+	ListPresetMapVersions(name string) ([]PresetMap, error)
 }
 
 // LocalPresetRepository provides an interface that defines the set of methods for
@@ -71,3 +93,13 @@ type LocalPresetRepository interface {
 	DeleteLocalPreset(*LocalPreset) error
 	GetLocalPreset(name string) (*LocalPreset, error)
 }
+
+// PresetGroupRepository is the interface that defines the set of methods for
+// managing PresetGroup persistence.
+type PresetGroupRepository interface {
+	CreatePresetGroup(*PresetGroup) error
+	UpdatePresetGroup(*PresetGroup) error
+	DeletePresetGroup(*PresetGroup) error
+	GetPresetGroup(name string) (*PresetGroup, error)
+	ListPresetGroups() ([]PresetGroup, error)
+}
@@ -0,0 +1,48 @@
+package db
+
+import "time"
+
+// SourceInfo contains information about media transcoded using the Transcoding
+// API.
+type SourceInfo struct {
+	// Duration of the media
+	Duration time.Duration `json:"duration,omitempty"`
+
+	// Dimension of the media, in pixels
+	Height int64 `json:"height,omitempty"`
+	Width  int64 `json:"width,omitempty"`
+
+	// Codec used for video medias
+	VideoCodec string `json:"videoCodec,omitempty"`
+
+	// Container of the media, like mp4 or mov
+	Container string `json:"container,omitempty"`
+
+	// Frame rate of the video, in frames per second
+	FrameRate float64 `json:"frameRate,omitempty"`
+
+	// Audio tracks of the media, in the order they appear in the container
+	AudioTracks []SourceAudioTrack `json:"audioTracks,omitempty"`
+}
+
+// SourceAudioTrack describes an audio track of the source media.
+type SourceAudioTrack struct {
+	// Codec of the track, like aac or ac3
+	Codec string `json:"codec,omitempty"`
+
+	// Number of channels of the track
+	Channels int `json:"channels,omitempty"`
+
+	// ISO-639-2 language code of the track, when the container defines one
+	Language string `json:"language,omitempty"`
+}
+
+// HasVideo returns whether the source has a video track.
+func (s SourceInfo) HasVideo() bool {
+	return s.VideoCodec != "" || s.Height > 0 || s.Width > 0
+}
+
+// HasAudio returns whether the source has at least one audio track.
+func (s SourceInfo) HasAudio() bool {
+	return len(s.AudioTracks) > 0
+}
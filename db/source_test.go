@@ -0,0 +1,42 @@
+package db
+
+import (
+	"encoding/json"
+	"reflect"
+	"testing"
+	"time"
+)
+
+func TestSourceInfoJSON(t *testing.T) {
+	info := SourceInfo{
+		Duration:    time.Minute,
+		Width:       1920,
+		Height:      1080,
+		VideoCodec:  "h264",
+		AudioTracks: []SourceAudioTrack{{Codec: "aac", Channels: 2, Language: "eng"}},
+	}
+	data, err := json.Marshal(Job{ID: "job-1", SourceInfo: &info})
+	if err != nil {
+		t.Fatal(err)
+	}
+	var fields map[string]interface{}
+	json.Unmarshal(data, &fields)
+	expected := map[string]interface{}{
+		"duration":    float64(time.Minute),
+		"width":       float64(1920),
+		"height":      float64(1080),
+		"videoCodec":  "h264",
+		"audioTracks": []interface{}{map[string]interface{}{"codec": "aac", "channels": float64(2), "language": "eng"}},
+	}
+	if !reflect.DeepEqual(fields["sourceInfo"], expected) {
+		t.Errorf("wrong JSON\nwant %#v\ngot  %#v", expected, fields["sourceInfo"])
+	}
+	var job Job
+	err = json.Unmarshal(data, &job)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if job.SourceInfo == nil || !reflect.DeepEqual(*job.SourceInfo, info) {
+		t.Errorf("wrong source info\nwant %#v\ngot  %#v", info, job.SourceInfo)
+	}
+}
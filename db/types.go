@@ -2,6 +2,10 @@ package db
 
 import (
 	"errors"
+	"fmt"
+	"path"
+	"strconv"
+	"strings"
 	"time"
 )
 
@@ -45,7 +49,105 @@ type Job struct {
 	// Output list of the given job
 	//
 	// required: true
-	Outputs []TranscodeOutput `redis-hash:"-" json:"outputs"`
+	Outputs []TranscodeOutput `redis-hash:"outputs,json,omitempty" json:"outputs"`
+
+	// Alternate audio tracks of the given job, added as alternate audio
+	// renditions to the adaptive streaming outputs (EXT-X-MEDIA entries in
+	// HLS and adaptation sets in DASH)
+	//
+	// required: false
+	AudioTracks []AudioTrack `redis-hash:"-" json:"audioTracks,omitempty"`
+
+	// Provider-specific settings of the job, keyed by provider name and
+	// merged into the native job payload of the provider
+	//
+	// required: false
+	ProviderOverrides ProviderOverrides `redis-hash:"-" json:"providerOverrides,omitempty"`
+
+	// Whether the renditions of the job should be adapted to the
+	// complexity of the source. Providers with native support pick the
+	// rungs and bitrates of the adaptive streaming outputs, others
+	// transcode the outputs as a fixed ladder
+	//
+	// required: false
+	ContentAware bool `redis-hash:"contentaware" json:"contentAware,omitempty"`
+
+	// Information about the source media, available when it was probed
+	// before the job was submitted to the provider
+	//
+	// required: false
+	SourceInfo *SourceInfo `redis-hash:"sourceinfo,json" json:"sourceInfo,omitempty"`
+
+	// Whether the outputs should be fetched and verified against their
+	// presets once the job finishes
+	//
+	// required: false
+	VerifyOutputs bool `redis-hash:"verifyoutputs" json:"verifyOutputs,omitempty"`
+
+	// Result of the verification of the outputs, available once they were
+	// verified
+	//
+	// required: false
+	Verification *VerificationReport `redis-hash:"verification,json" json:"verification,omitempty"`
+
+	// Version of each preset used in the job, keyed by preset name
+	//
+	// required: false
+	PresetVersions PresetVersions `redis-hash:"presetversions,json,omitempty" json:"presetVersions,omitempty"`
+
+	// Snapshot of each preset used in the job, keyed by preset name
+	//
+	// required: false
+	PresetSnapshots PresetSnapshots `redis-hash:"presetsnapshots,json,omitempty" json:"presetSnapshots,omitempty"`
+}
+
+// AudioTrack represents an alternate audio rendition of a job, taken either
+// from a track of the source media or from a separate audio file.
+//
+// swagger:model
+type AudioTrack struct {
+	// index of the audio track in the source media, starting at 1
+	TrackIndex uint `json:"trackIndex,omitempty"`
+
+	// URL of a separate audio file, used instead of a track of the source
+	// media
+	SourceMedia string `json:"source,omitempty"`
+
+	// language code of the track, as defined by RFC 5646 (e.g. "en" or
+	// "pt-BR")
+	//
+	// required: true
+	Language string `json:"language"`
+
+	// name of the track, displayed by players. Defaults to the language
+	// code
+	Name string `json:"name,omitempty"`
+
+	// whether this track should be selected by default
+	Default bool `json:"default,omitempty"`
+}
+
+// Validate checks that the track is taken from exactly one place and has a
+// language.
+func (t AudioTrack) Validate() error {
+	if t.TrackIndex == 0 && t.SourceMedia == "" {
+		return errors.New("either trackIndex or source is required")
+	}
+	if t.TrackIndex != 0 && t.SourceMedia != "" {
+		return errors.New("trackIndex and source are mutually exclusive")
+	}
+	if t.Language == "" {
+		return errors.New("language is required")
+	}
+	return nil
+}
+
+// DisplayName returns the name of the track, falling back to its language.
+func (t AudioTrack) DisplayName() string {
+	if t.Name != "" {
+		return t.Name
+	}
+	return t.Language
 }
 
 // TranscodeOutput represents a transcoding output. It's a combination of the
@@ -71,7 +173,10 @@ type StreamingParams struct {
 	// required: true
 	SegmentDuration uint `redis-hash:"segmentDuration" json:"segmentDuration"`
 
-	// the protocol name (hls or dash)
+	// the protocol name (hls, dash or cmaf)
+	//
+	// When using cmaf, providers generate both an HLS master playlist and a
+	// DASH manifest referencing the same set of fragmented MP4 segments.
 	//
 	// required: true
 	Protocol string `redis-hash:"protocol" json:"protocol"`
@@ -79,6 +184,42 @@ type StreamingParams struct {
 	// the playlist file name
 	// required: true
 	PlaylistFileName string `redis-hash:"playlistFileName" json:"playlistFileName,omitempty"`
+
+	// options of the HLS playlists and segments of hls and cmaf jobs
+	//
+	// required: false
+	HLS *HLSOptions `redis-hash:"hls,expand" json:"hls,omitempty"`
+}
+
+// Validate checks the HLS options of the streaming params, if any.
+func (p StreamingParams) Validate() error {
+	if p.HLS == nil {
+		return nil
+	}
+	if err := p.HLS.Validate(p.Protocol); err != nil {
+		return fmt.Errorf("invalid hls options: %s", err)
+	}
+	return nil
+}
+
+// HLSOptions returns the HLS options of the streaming params, or the zero
+// value when they're not set.
+func (p StreamingParams) HLSOptions() HLSOptions {
+	if p.HLS == nil {
+		return HLSOptions{}
+	}
+	return *p.HLS
+}
+
+// DASHManifestFileName returns the name of the DASH manifest generated
+// alongside the HLS master playlist in cmaf jobs. It's the PlaylistFileName
+// with the extension replaced by "mpd".
+func (p StreamingParams) DASHManifestFileName() string {
+	if p.PlaylistFileName == "" {
+		return ""
+	}
+	ext := path.Ext(p.PlaylistFileName)
+	return strings.TrimSuffix(p.PlaylistFileName, ext) + ".mpd"
 }
 
 // LocalPreset is a struct to persist encoding configurations. Some providers don't have
@@ -97,15 +238,86 @@ type LocalPreset struct {
 	Preset Preset `redis-hash:"preset,expand" json:"preset"`
 }
 
-// Preset defines the set of parameters of a given preset
+// Preset defines the set of parameters of a given preset.
+//
+// The Video section is optional: presets without video settings generate
+// audio-only renditions (see AudioOnly).
 type Preset struct {
-	Name        string      `json:"name,omitempty" redis-hash:"name"`
+	Name string `json:"name,omitempty" redis-hash:"name"`
+
+	// Base is the name of the preset this preset inherits from. Fields
+	// that are set in the preset override the ones in the base preset (see
+	// ResolvePreset).
+	Base string `json:"base,omitempty" redis-hash:"base,omitempty"`
+
 	Description string      `json:"description,omitempty" redis-hash:"description,omitempty"`
 	Container   string      `json:"container,omitempty" redis-hash:"container,omitempty"`
 	RateControl string      `json:"rateControl,omitempty" redis-hash:"ratecontrol,omitempty"`
-	TwoPass     bool        `json:"twoPass" redis-hash:"twopass"`
+	TwoPass     *bool       `json:"twoPass,omitempty" redis-hash:"twopass,json,omitempty"`
 	Video       VideoPreset `json:"video" redis-hash:"video,expand"`
 	Audio       AudioPreset `json:"audio" redis-hash:"audio,expand"`
+
+	// ProviderOverrides holds provider-specific settings, keyed by
+	// provider name, that are merged into the native preset payload of
+	// each provider.
+	ProviderOverrides ProviderOverrides `json:"providerOverrides,omitempty" redis-hash:"provideroverrides,json,omitempty"`
+}
+
+// containerCodecs lists the video and audio codecs each container can carry.
+// Codecs and containers that aren't listed are left up to the providers.
+var containerCodecs = map[string]map[string]bool{
+	"mp4": {
+		VideoCodecH264: true, VideoCodecHEVC: true, VideoCodecVP9: true, VideoCodecAV1: true,
+		AudioCodecAAC: true, AudioCodecOpus: true,
+	},
+	"m3u8": {
+		VideoCodecH264: true, VideoCodecHEVC: true,
+		AudioCodecAAC: true,
+	},
+	"cmaf": {
+		VideoCodecH264: true, VideoCodecHEVC: true, VideoCodecAV1: true,
+		AudioCodecAAC: true, AudioCodecOpus: true,
+	},
+	"webm": {
+		VideoCodecVP8: true, VideoCodecVP9: true, VideoCodecAV1: true,
+		AudioCodecVorbis: true, AudioCodecOpus: true,
+	},
+}
+
+var knownCodecs = map[string]bool{
+	VideoCodecH264: true, VideoCodecHEVC: true, VideoCodecVP8: true, VideoCodecVP9: true, VideoCodecAV1: true,
+	AudioCodecAAC: true, AudioCodecVorbis: true, AudioCodecOpus: true,
+}
+
+// ValidateContainer checks that the container of the preset can carry its
+// video and audio codecs.
+func (p Preset) ValidateContainer() error {
+	codecs, ok := containerCodecs[strings.ToLower(p.Container)]
+	if !ok {
+		return nil
+	}
+	if !p.AudioOnly() {
+		codec := strings.ToLower(p.Video.Codec)
+		if knownCodecs[codec] && !codecs[codec] {
+			return fmt.Errorf("video codec %q is not supported with the %s container", p.Video.Codec, p.Container)
+		}
+	}
+	codec := strings.ToLower(p.Audio.Codec)
+	if knownCodecs[codec] && !codecs[codec] {
+		return fmt.Errorf("audio codec %q is not supported with the %s container", p.Audio.Codec, p.Container)
+	}
+	return nil
+}
+
+// TwoPassEnabled returns whether the preset asks for two-pass encoding.
+func (p Preset) TwoPassEnabled() bool {
+	return p.TwoPass != nil && *p.TwoPass
+}
+
+// AudioOnly returns whether the preset describes an audio-only rendition,
+// i.e. it doesn't define any video settings.
+func (p Preset) AudioOnly() bool {
+	return p.Video == VideoPreset{}
 }
 
 // VideoPreset defines the set of parameters for video on a given preset
@@ -120,12 +332,474 @@ type VideoPreset struct {
 	GopMode       string `json:"gopMode,omitempty" redis-hash:"gopmode,omitempty"`
 	InterlaceMode string `json:"interlaceMode,omitempty" redis-hash:"interlacemode,omitempty"`
 	BFrames       string `json:"bframes,omitempty" redis-hash:"bframes,omitempty"`
+
+	// Tier is the HEVC tier: main or high. Only valid with the hevc codec,
+	// defaults to main.
+	Tier string `json:"tier,omitempty" redis-hash:"tier,omitempty"`
+
+	// BitDepth is the bit depth of the output video: 8 or 10. When it's
+	// not set, it's derived from the codec profile.
+	BitDepth string `json:"bitDepth,omitempty" redis-hash:"bitdepth,omitempty"`
+
+	// ColorSpace is the color space the output is converted to: rec601,
+	// rec709 or rec2020. The source color space is kept when it's not set.
+	ColorSpace string `json:"colorSpace,omitempty" redis-hash:"colorspace,omitempty"`
+
+	// HDR defines how HDR metadata is handled: passthrough keeps the
+	// metadata of the source, hdr10 and hlg convert the output to the
+	// given format and sdr tone-maps HDR sources to SDR.
+	HDR string `json:"hdr,omitempty" redis-hash:"hdr,omitempty"`
+
+	// FrameRate is the output frame rate, either as a decimal number
+	// (29.97) or as a fraction (30000/1001). The source frame rate is kept
+	// when it's not set.
+	FrameRate string `json:"frameRate,omitempty" redis-hash:"framerate,omitempty"`
+
+	// ScalingBehavior defines how the source is scaled to the output
+	// dimensions when their aspect ratios differ: fit, fill, stretch or
+	// letterbox. Providers use their own default when it's not set.
+	ScalingBehavior string `json:"scalingBehavior,omitempty" redis-hash:"scalingbehavior,omitempty"`
+
+	// Crop is the region of the source that is kept in the output.
+	Crop CropSettings `json:"crop" redis-hash:"crop,expand"`
+
+	// Deinterlacer is the deinterlacing filter applied to the source: off,
+	// deinterlace, inverse-telecine or adaptive. Providers use their own
+	// default when it's not set.
+	Deinterlacer string `json:"deinterlacer,omitempty" redis-hash:"deinterlacer,omitempty"`
+}
+
+// CropSettings defines a rectangle of the source video, in pixels, with its
+// top left corner at the X and Y offsets.
+type CropSettings struct {
+	X      string `json:"x,omitempty" redis-hash:"x,omitempty"`
+	Y      string `json:"y,omitempty" redis-hash:"y,omitempty"`
+	Width  string `json:"width,omitempty" redis-hash:"width,omitempty"`
+	Height string `json:"height,omitempty" redis-hash:"height,omitempty"`
+}
+
+// IsZero returns whether the crop settings are empty, meaning that the
+// source isn't cropped.
+func (c CropSettings) IsZero() bool {
+	return c == CropSettings{}
+}
+
+func (c CropSettings) validate() error {
+	offsets := []struct{ name, value string }{{"x", c.X}, {"y", c.Y}}
+	for _, offset := range offsets {
+		if n, err := strconv.Atoi(offset.value); offset.value != "" && (err != nil || n < 0) {
+			return fmt.Errorf("crop %s %q must be a non-negative integer", offset.name, offset.value)
+		}
+	}
+	dimensions := []struct{ name, value string }{{"width", c.Width}, {"height", c.Height}}
+	for _, dimension := range dimensions {
+		if n, err := strconv.Atoi(dimension.value); err != nil || n <= 0 {
+			return fmt.Errorf("crop %s %q must be a positive integer", dimension.name, dimension.value)
+		}
+	}
+	return nil
+}
+
+// Supported scaling behaviors.
+const (
+	ScalingBehaviorFit       = "fit"
+	ScalingBehaviorFill      = "fill"
+	ScalingBehaviorStretch   = "stretch"
+	ScalingBehaviorLetterbox = "letterbox"
+)
+
+// Supported deinterlacers.
+const (
+	DeinterlacerOff             = "off"
+	DeinterlacerDeinterlace     = "deinterlace"
+	DeinterlacerInverseTelecine = "inverse-telecine"
+	DeinterlacerAdaptive        = "adaptive"
+)
+
+// maxFrameRate is the highest frame rate accepted in presets.
+const maxFrameRate = 120
+
+// Supported video codecs.
+const (
+	VideoCodecH264 = "h264"
+	VideoCodecHEVC = "hevc"
+	VideoCodecVP8  = "vp8"
+	VideoCodecVP9  = "vp9"
+	VideoCodecAV1  = "av1"
+)
+
+// Supported audio codecs.
+const (
+	AudioCodecAAC    = "aac"
+	AudioCodecVorbis = "vorbis"
+	AudioCodecOpus   = "opus"
+)
+
+// Supported HEVC profiles.
+const (
+	HEVCProfileMain   = "main"
+	HEVCProfileMain10 = "main10"
+)
+
+// Supported HEVC tiers.
+const (
+	HEVCTierMain = "main"
+	HEVCTierHigh = "high"
+)
+
+// Supported color spaces.
+const (
+	ColorSpaceRec601  = "rec601"
+	ColorSpaceRec709  = "rec709"
+	ColorSpaceRec2020 = "rec2020"
+)
+
+// Supported HDR metadata handling modes.
+const (
+	HDRPassthrough = "passthrough"
+	HDR10          = "hdr10"
+	HDRHLG         = "hlg"
+	HDRToneMapSDR  = "sdr"
+)
+
+// Supported VP9 profiles.
+var vp9Profiles = map[string]bool{"0": true, "1": true, "2": true, "3": true}
+
+// Supported AV1 profiles.
+const (
+	AV1ProfileMain         = "main"
+	AV1ProfileHigh         = "high"
+	AV1ProfileProfessional = "professional"
+)
+
+var hevcLevels = map[string]bool{
+	"1": true, "2": true, "2.1": true, "3": true, "3.1": true, "4": true, "4.1": true,
+	"5": true, "5.1": true, "5.2": true, "6": true, "6.1": true, "6.2": true,
+}
+
+// IsHEVC returns whether the preset uses the HEVC (H.265) codec.
+func (v *VideoPreset) IsHEVC() bool {
+	return strings.ToLower(v.Codec) == VideoCodecHEVC
+}
+
+// Validate checks that the codec specific video settings hold supported
+// values. H.264 and VP8 settings are left up to the providers.
+func (v *VideoPreset) Validate() error {
+	if !v.IsHEVC() && v.Tier != "" {
+		return errors.New("tier is only supported with the hevc codec")
+	}
+	switch strings.ToLower(v.Codec) {
+	case VideoCodecHEVC:
+		if err := v.validateHEVC(); err != nil {
+			return err
+		}
+	case VideoCodecVP9:
+		if v.Profile != "" && !vp9Profiles[v.Profile] {
+			return fmt.Errorf("vp9 profile %q is not supported", v.Profile)
+		}
+	case VideoCodecAV1:
+		switch strings.ToLower(v.Profile) {
+		case "", AV1ProfileMain, AV1ProfileHigh, AV1ProfileProfessional:
+		default:
+			return fmt.Errorf("av1 profile %q is not supported", v.Profile)
+		}
+	}
+	if err := v.validateColor(); err != nil {
+		return err
+	}
+	return v.validateProcessing()
+}
+
+// validateProcessing checks the frame rate, scaling, cropping and
+// deinterlacing settings.
+func (v *VideoPreset) validateProcessing() error {
+	if v.FrameRate != "" {
+		num, den, err := v.FrameRateFraction()
+		if err != nil {
+			return err
+		}
+		if float64(num)/float64(den) > maxFrameRate {
+			return fmt.Errorf("frame rate %q must not be greater than %d", v.FrameRate, maxFrameRate)
+		}
+	}
+	switch strings.ToLower(v.ScalingBehavior) {
+	case "", ScalingBehaviorFit, ScalingBehaviorFill, ScalingBehaviorStretch, ScalingBehaviorLetterbox:
+	default:
+		return fmt.Errorf("scaling behavior %q is not supported", v.ScalingBehavior)
+	}
+	if !v.Crop.IsZero() {
+		if err := v.Crop.validate(); err != nil {
+			return err
+		}
+	}
+	switch strings.ToLower(v.Deinterlacer) {
+	case "", DeinterlacerOff, DeinterlacerDeinterlace, DeinterlacerInverseTelecine, DeinterlacerAdaptive:
+	default:
+		return fmt.Errorf("deinterlacer %q is not supported", v.Deinterlacer)
+	}
+	return nil
+}
+
+// FrameRateFraction returns the frame rate of the preset as a fraction,
+// converting decimal frame rates like "29.97" to 2997/100.
+func (v *VideoPreset) FrameRateFraction() (num int, den int, err error) {
+	invalid := fmt.Errorf("frame rate %q must be a positive number or fraction", v.FrameRate)
+	if parts := strings.SplitN(v.FrameRate, "/", 2); len(parts) == 2 {
+		num, err = strconv.Atoi(parts[0])
+		if err != nil || num <= 0 {
+			return 0, 0, invalid
+		}
+		den, err = strconv.Atoi(parts[1])
+		if err != nil || den <= 0 {
+			return 0, 0, invalid
+		}
+		return num, den, nil
+	}
+	den = 1
+	integer, decimals := v.FrameRate, ""
+	if i := strings.Index(v.FrameRate, "."); i > -1 {
+		integer, decimals = v.FrameRate[:i], v.FrameRate[i+1:]
+		for range decimals {
+			den *= 10
+		}
+	}
+	num, err = strconv.Atoi(integer + decimals)
+	if err != nil || num <= 0 {
+		return 0, 0, invalid
+	}
+	return num, den, nil
+}
+
+// IntegerFrameRate returns the frame rate of the preset when it's a whole
+// number, for providers that don't take fractional frame rates.
+func (v *VideoPreset) IntegerFrameRate() (int, bool) {
+	num, den, err := v.FrameRateFraction()
+	if err != nil || num%den != 0 {
+		return 0, false
+	}
+	return num / den, true
+}
+
+// validateColor checks that the bit depth, color space and HDR settings are
+// supported by the codec and consistent with each other.
+func (v *VideoPreset) validateColor() error {
+	codec := strings.ToLower(v.Codec)
+	switch v.BitDepth {
+	case "", "8":
+	case "10":
+		switch {
+		case codec == VideoCodecVP8:
+			return errors.New("10-bit output is not supported with the vp8 codec")
+		case codec == VideoCodecHEVC && strings.ToLower(v.Profile) == HEVCProfileMain:
+			return errors.New("10-bit hevc output requires the main10 profile")
+		case codec == VideoCodecVP9 && (v.Profile == "0" || v.Profile == "1"):
+			return errors.New("10-bit vp9 output requires profile 2 or 3")
+		}
+	default:
+		return fmt.Errorf("bit depth %q is not supported", v.BitDepth)
+	}
+	colorSpace := strings.ToLower(v.ColorSpace)
+	switch colorSpace {
+	case "", ColorSpaceRec601, ColorSpaceRec709, ColorSpaceRec2020:
+	default:
+		return fmt.Errorf("color space %q is not supported", v.ColorSpace)
+	}
+	switch hdr := strings.ToLower(v.HDR); hdr {
+	case "":
+	case HDRPassthrough, HDR10, HDRHLG:
+		switch codec {
+		case VideoCodecHEVC, VideoCodecVP9, VideoCodecAV1:
+		default:
+			return fmt.Errorf("hdr %q requires the hevc, vp9 or av1 codec", v.HDR)
+		}
+		if !v.Is10Bit() {
+			return fmt.Errorf("hdr %q requires 10-bit output", v.HDR)
+		}
+		if hdr == HDRPassthrough && colorSpace != "" {
+			return errors.New("color space can't be set when passing hdr metadata through")
+		}
+		if colorSpace != "" && colorSpace != ColorSpaceRec2020 {
+			return fmt.Errorf("hdr %q requires the rec2020 color space", v.HDR)
+		}
+	case HDRToneMapSDR:
+		if colorSpace == ColorSpaceRec2020 {
+			return errors.New("tone-mapping to sdr requires the rec601 or rec709 color space")
+		}
+	default:
+		return fmt.Errorf("hdr %q is not supported", v.HDR)
+	}
+	return nil
+}
+
+// Is10Bit returns whether the preset outputs 10-bit video, either through
+// an explicit bit depth or through a 10-bit codec profile.
+func (v *VideoPreset) Is10Bit() bool {
+	if v.BitDepth != "" {
+		return v.BitDepth == "10"
+	}
+	switch strings.ToLower(v.Codec) {
+	case VideoCodecHEVC:
+		return strings.ToLower(v.Profile) == HEVCProfileMain10
+	case VideoCodecVP9:
+		return v.Profile == "2" || v.Profile == "3"
+	}
+	return false
+}
+
+// HasColorSettings returns whether the preset defines any bit depth, color
+// space or HDR setting, which not every provider supports.
+func (v *VideoPreset) HasColorSettings() bool {
+	return v.BitDepth != "" || v.ColorSpace != "" || v.HDR != ""
+}
+
+func (v *VideoPreset) validateHEVC() error {
+	switch strings.ToLower(v.Profile) {
+	case "", HEVCProfileMain, HEVCProfileMain10:
+	default:
+		return fmt.Errorf("hevc profile %q is not supported", v.Profile)
+	}
+	switch strings.ToLower(v.Tier) {
+	case "", HEVCTierMain, HEVCTierHigh:
+	default:
+		return fmt.Errorf("hevc tier %q is not supported", v.Tier)
+	}
+	if v.ProfileLevel != "" && !hevcLevels[v.HEVCLevel()] {
+		return fmt.Errorf("hevc level %q is not supported", v.ProfileLevel)
+	}
+	return nil
+}
+
+// HEVCLevel returns the HEVC level of the preset, normalizing levels like
+// "4.0" to "4".
+func (v *VideoPreset) HEVCLevel() string {
+	return strings.TrimSuffix(v.ProfileLevel, ".0")
 }
 
 // AudioPreset defines the set of parameters for audio on a given preset
 type AudioPreset struct {
 	Codec   string `json:"codec,omitempty" redis-hash:"codec,omitempty"`
 	Bitrate string `json:"bitrate,omitempty" redis-hash:"bitrate,omitempty"`
+
+	// ChannelLayout is the layout of the output audio channels: mono,
+	// stereo or 5.1. Providers default to stereo when it's not set.
+	ChannelLayout string `json:"channelLayout,omitempty" redis-hash:"channellayout,omitempty"`
+
+	// SampleRate is the output sample rate, in Hz. Providers default to
+	// 48000 when it's not set.
+	SampleRate string `json:"sampleRate,omitempty" redis-hash:"samplerate,omitempty"`
+
+	// Profile is the AAC profile: lc, he or hev2. Only valid with the aac
+	// codec, defaults to lc.
+	Profile string `json:"profile,omitempty" redis-hash:"profile,omitempty"`
+
+	// LoudnessStandard is the loudness normalization standard to apply to
+	// the output audio: ebu-r128 or atsc-a85.
+	LoudnessStandard string `json:"loudnessStandard,omitempty" redis-hash:"loudnessstandard,omitempty"`
+
+	// LoudnessTarget is the integrated loudness target, in LKFS. When not
+	// set, the target defined by LoudnessStandard is used (-23 for EBU R128
+	// and -24 for ATSC A/85).
+	LoudnessTarget string `json:"loudnessTarget,omitempty" redis-hash:"loudnesstarget,omitempty"`
+}
+
+// Supported values for the audio channel layout.
+const (
+	AudioChannelLayoutMono     = "mono"
+	AudioChannelLayoutStereo   = "stereo"
+	AudioChannelLayoutSurround = "5.1"
+)
+
+// Supported AAC profiles.
+const (
+	AudioProfileLC   = "lc"
+	AudioProfileHE   = "he"
+	AudioProfileHEv2 = "hev2"
+)
+
+// Supported loudness normalization standards.
+const (
+	LoudnessStandardEBUR128 = "ebu-r128"
+	LoudnessStandardATSCA85 = "atsc-a85"
+)
+
+var audioSampleRates = map[string]bool{
+	"8000": true, "16000": true, "22050": true, "24000": true,
+	"32000": true, "44100": true, "48000": true, "88200": true, "96000": true,
+}
+
+// Opus only encodes at a handful of sample rates, with bitrates between 6
+// and 510 kbps.
+var opusSampleRates = map[string]bool{"8000": true, "16000": true, "24000": true, "48000": true}
+
+const (
+	opusMinBitrate = 6000
+	opusMaxBitrate = 510000
+)
+
+// Validate checks that the advanced audio settings hold supported values
+// and are consistent with each other.
+func (a *AudioPreset) Validate() error {
+	switch strings.ToLower(a.ChannelLayout) {
+	case "", AudioChannelLayoutMono, AudioChannelLayoutStereo, AudioChannelLayoutSurround:
+	default:
+		return fmt.Errorf("channel layout %q is not supported", a.ChannelLayout)
+	}
+	if a.SampleRate != "" && !audioSampleRates[a.SampleRate] {
+		return fmt.Errorf("sample rate %q is not supported", a.SampleRate)
+	}
+	if strings.ToLower(a.Codec) == AudioCodecOpus {
+		if a.SampleRate != "" && !opusSampleRates[a.SampleRate] {
+			return fmt.Errorf("sample rate %q is not supported with the opus codec", a.SampleRate)
+		}
+		if a.Bitrate != "" {
+			bitrate, err := a.BitrateBps()
+			if err != nil || bitrate < opusMinBitrate || bitrate > opusMaxBitrate {
+				return fmt.Errorf("opus bitrate %q must be between %d and %d", a.Bitrate, opusMinBitrate, opusMaxBitrate)
+			}
+		}
+	}
+	switch strings.ToLower(a.Profile) {
+	case "":
+	case AudioProfileLC, AudioProfileHE, AudioProfileHEv2:
+		if strings.ToLower(a.Codec) != "aac" {
+			return fmt.Errorf("audio profile %q is only supported with the aac codec", a.Profile)
+		}
+		if strings.ToLower(a.Profile) == AudioProfileHEv2 && a.ChannelLayout != "" &&
+			strings.ToLower(a.ChannelLayout) != AudioChannelLayoutStereo {
+			return errors.New("audio profile hev2 requires the stereo channel layout")
+		}
+	default:
+		return fmt.Errorf("audio profile %q is not supported", a.Profile)
+	}
+	switch strings.ToLower(a.LoudnessStandard) {
+	case "":
+		if a.LoudnessTarget != "" {
+			return errors.New("loudness target requires a loudness standard")
+		}
+	case LoudnessStandardEBUR128, LoudnessStandardATSCA85:
+	default:
+		return fmt.Errorf("loudness standard %q is not supported", a.LoudnessStandard)
+	}
+	if a.LoudnessTarget != "" {
+		target, err := strconv.ParseFloat(a.LoudnessTarget, 64)
+		if err != nil || target < -59 || target > 0 {
+			return fmt.Errorf("loudness target %q must be a number between -59 and 0", a.LoudnessTarget)
+		}
+	}
+	return nil
+}
+
+// LoudnessTargetLKFS returns the integrated loudness target, falling back to
+// the reference level of the configured standard.
+func (a *AudioPreset) LoudnessTargetLKFS() float64 {
+	if a.LoudnessTarget != "" {
+		target, _ := strconv.ParseFloat(a.LoudnessTarget, 64)
+		return target
+	}
+	if strings.ToLower(a.LoudnessStandard) == LoudnessStandardATSCA85 {
+		return -24
+	}
+	return -23
 }
 
 // PresetMap represents the preset that is persisted in the repository of the
@@ -151,6 +825,18 @@ type PresetMap struct {
 	//
 	// required: true
 	OutputOpts OutputOptions `redis-hash:"output,expand" json:"output"`
+
+	// preset the presetmap was created from, available when it was
+	// created through the presets endpoint.
+	//
+	// required: false
+	Preset *Preset `redis-hash:"preset,expand" json:"preset,omitempty"`
+
+	// version of the presetmap. It's automatically assigned by the API,
+	// starting at 1 and increasing on every update
+	//
+	// required: false
+	Version uint `redis-hash:"version" json:"version,omitempty"`
 }
 
 // OutputOptions is the set of options for the output file.
@@ -32,3 +32,493 @@ func TestOutputOptionsValidation(t *testing.T) {
 		}
 	}
 }
+
+func TestStreamingParamsDASHManifestFileName(t *testing.T) {
+	tests := []struct {
+		testCase string
+		params   StreamingParams
+		expected string
+	}{
+		{
+			"playlist with extension",
+			StreamingParams{Protocol: "cmaf", PlaylistFileName: "cmaf/index.m3u8"},
+			"cmaf/index.mpd",
+		},
+		{
+			"playlist without extension",
+			StreamingParams{Protocol: "cmaf", PlaylistFileName: "master"},
+			"master.mpd",
+		},
+		{
+			"no playlist",
+			StreamingParams{Protocol: "cmaf"},
+			"",
+		},
+	}
+	for _, test := range tests {
+		if got := test.params.DASHManifestFileName(); got != test.expected {
+			t.Errorf("%s: wrong manifest name\nWant %q\nGot  %q", test.testCase, test.expected, got)
+		}
+	}
+}
+
+func TestPresetAudioOnly(t *testing.T) {
+	tests := []struct {
+		testCase string
+		preset   Preset
+		expected bool
+	}{
+		{
+			"audio and video",
+			Preset{
+				Video: VideoPreset{Codec: "h264", Bitrate: "1000000"},
+				Audio: AudioPreset{Codec: "aac", Bitrate: "64000"},
+			},
+			false,
+		},
+		{
+			"audio only",
+			Preset{Audio: AudioPreset{Codec: "aac", Bitrate: "64000"}},
+			true,
+		},
+	}
+	for _, test := range tests {
+		if got := test.preset.AudioOnly(); got != test.expected {
+			t.Errorf("%s: wrong AudioOnly result\nWant %v\nGot  %v", test.testCase, test.expected, got)
+		}
+	}
+}
+
+func TestAudioPresetValidation(t *testing.T) {
+	tests := []struct {
+		testCase string
+		audio    AudioPreset
+		errMsg   string
+	}{
+		{
+			"no advanced settings",
+			AudioPreset{Codec: "aac", Bitrate: "128000"},
+			"",
+		},
+		{
+			"all settings",
+			AudioPreset{
+				Codec:            "aac",
+				ChannelLayout:    "5.1",
+				SampleRate:       "44100",
+				Profile:          "he",
+				LoudnessStandard: "ebu-r128",
+				LoudnessTarget:   "-16",
+			},
+			"",
+		},
+		{
+			"opus settings",
+			AudioPreset{Codec: "opus", Bitrate: "96000", SampleRate: "48000", ChannelLayout: "5.1"},
+			"",
+		},
+		{
+			"invalid opus sample rate",
+			AudioPreset{Codec: "opus", Bitrate: "96000", SampleRate: "44100"},
+			`sample rate "44100" is not supported with the opus codec`,
+		},
+		{
+			"out of range opus bitrate",
+			AudioPreset{Codec: "opus", Bitrate: "640000"},
+			`opus bitrate "640000" must be between 6000 and 510000`,
+		},
+		{
+			"invalid channel layout",
+			AudioPreset{Codec: "aac", ChannelLayout: "7.1"},
+			`channel layout "7.1" is not supported`,
+		},
+		{
+			"invalid sample rate",
+			AudioPreset{Codec: "aac", SampleRate: "12345"},
+			`sample rate "12345" is not supported`,
+		},
+		{
+			"invalid profile",
+			AudioPreset{Codec: "aac", Profile: "ld"},
+			`audio profile "ld" is not supported`,
+		},
+		{
+			"profile with non-aac codec",
+			AudioPreset{Codec: "opus", Profile: "he"},
+			`audio profile "he" is only supported with the aac codec`,
+		},
+		{
+			"hev2 with mono",
+			AudioPreset{Codec: "aac", Profile: "hev2", ChannelLayout: "mono"},
+			"audio profile hev2 requires the stereo channel layout",
+		},
+		{
+			"invalid loudness standard",
+			AudioPreset{Codec: "aac", LoudnessStandard: "replaygain"},
+			`loudness standard "replaygain" is not supported`,
+		},
+		{
+			"loudness target without standard",
+			AudioPreset{Codec: "aac", LoudnessTarget: "-23"},
+			"loudness target requires a loudness standard",
+		},
+		{
+			"out of range loudness target",
+			AudioPreset{Codec: "aac", LoudnessStandard: "atsc-a85", LoudnessTarget: "3"},
+			`loudness target "3" must be a number between -59 and 0`,
+		},
+	}
+	for _, test := range tests {
+		err := test.audio.Validate()
+		if err == nil {
+			err = errors.New("")
+		}
+		if err.Error() != test.errMsg {
+			t.Errorf("%s: wrong error message\nWant %q\nGot  %q", test.testCase, test.errMsg, err.Error())
+		}
+	}
+}
+
+func TestAudioPresetLoudnessTargetLKFS(t *testing.T) {
+	tests := []struct {
+		audio AudioPreset
+		want  float64
+	}{
+		{AudioPreset{LoudnessStandard: "ebu-r128"}, -23},
+		{AudioPreset{LoudnessStandard: "atsc-a85"}, -24},
+		{AudioPreset{LoudnessStandard: "atsc-a85", LoudnessTarget: "-16"}, -16},
+	}
+	for _, test := range tests {
+		if got := test.audio.LoudnessTargetLKFS(); got != test.want {
+			t.Errorf("LoudnessTargetLKFS(%#v): want %v, got %v", test.audio, test.want, got)
+		}
+	}
+}
+
+func TestVideoPresetValidation(t *testing.T) {
+	tests := []struct {
+		testCase string
+		video    VideoPreset
+		errMsg   string
+	}{
+		{
+			"h264 settings are left to providers",
+			VideoPreset{Codec: "h264", Profile: "high", ProfileLevel: "4.1"},
+			"",
+		},
+		{
+			"hevc main10 high tier",
+			VideoPreset{Codec: "hevc", Profile: "main10", Tier: "high", ProfileLevel: "5.1"},
+			"",
+		},
+		{
+			"hevc level with trailing zero",
+			VideoPreset{Codec: "HEVC", Profile: "main", ProfileLevel: "4.0"},
+			"",
+		},
+		{
+			"invalid hevc profile",
+			VideoPreset{Codec: "hevc", Profile: "high"},
+			`hevc profile "high" is not supported`,
+		},
+		{
+			"invalid hevc tier",
+			VideoPreset{Codec: "hevc", Tier: "low"},
+			`hevc tier "low" is not supported`,
+		},
+		{
+			"invalid hevc level",
+			VideoPreset{Codec: "hevc", ProfileLevel: "5.3"},
+			`hevc level "5.3" is not supported`,
+		},
+		{
+			"tier with non-hevc codec",
+			VideoPreset{Codec: "h264", Tier: "high"},
+			"tier is only supported with the hevc codec",
+		},
+		{
+			"vp9 profile 2",
+			VideoPreset{Codec: "vp9", Profile: "2"},
+			"",
+		},
+		{
+			"invalid vp9 profile",
+			VideoPreset{Codec: "vp9", Profile: "main"},
+			`vp9 profile "main" is not supported`,
+		},
+		{
+			"av1 main profile",
+			VideoPreset{Codec: "av1", Profile: "Main"},
+			"",
+		},
+		{
+			"invalid av1 profile",
+			VideoPreset{Codec: "av1", Profile: "0"},
+			`av1 profile "0" is not supported`,
+		},
+		{
+			"hdr10 with hevc main10",
+			VideoPreset{Codec: "hevc", Profile: "main10", HDR: "hdr10", ColorSpace: "rec2020"},
+			"",
+		},
+		{
+			"hlg with 10-bit av1",
+			VideoPreset{Codec: "av1", BitDepth: "10", HDR: "hlg"},
+			"",
+		},
+		{
+			"tone-mapping to sdr",
+			VideoPreset{Codec: "h264", HDR: "sdr", ColorSpace: "rec709"},
+			"",
+		},
+		{
+			"invalid bit depth",
+			VideoPreset{Codec: "h264", BitDepth: "12"},
+			`bit depth "12" is not supported`,
+		},
+		{
+			"10-bit hevc main profile",
+			VideoPreset{Codec: "hevc", Profile: "main", BitDepth: "10"},
+			"10-bit hevc output requires the main10 profile",
+		},
+		{
+			"10-bit vp8",
+			VideoPreset{Codec: "vp8", BitDepth: "10"},
+			"10-bit output is not supported with the vp8 codec",
+		},
+		{
+			"invalid color space",
+			VideoPreset{Codec: "h264", ColorSpace: "p3"},
+			`color space "p3" is not supported`,
+		},
+		{
+			"hdr with h264",
+			VideoPreset{Codec: "h264", BitDepth: "10", HDR: "hdr10"},
+			`hdr "hdr10" requires the hevc, vp9 or av1 codec`,
+		},
+		{
+			"hdr with 8-bit output",
+			VideoPreset{Codec: "vp9", HDR: "hlg"},
+			`hdr "hlg" requires 10-bit output`,
+		},
+		{
+			"hdr with sdr color space",
+			VideoPreset{Codec: "hevc", Profile: "main10", HDR: "hdr10", ColorSpace: "rec709"},
+			`hdr "hdr10" requires the rec2020 color space`,
+		},
+		{
+			"hdr passthrough with color space",
+			VideoPreset{Codec: "vp9", Profile: "2", HDR: "passthrough", ColorSpace: "rec2020"},
+			"color space can't be set when passing hdr metadata through",
+		},
+		{
+			"tone-mapping to rec2020",
+			VideoPreset{Codec: "hevc", HDR: "sdr", ColorSpace: "rec2020"},
+			"tone-mapping to sdr requires the rec601 or rec709 color space",
+		},
+		{
+			"invalid hdr mode",
+			VideoPreset{Codec: "hevc", Profile: "main10", HDR: "dolbyvision"},
+			`hdr "dolbyvision" is not supported`,
+		},
+		{
+			"processing settings",
+			VideoPreset{
+				Codec:           "h264",
+				FrameRate:       "30000/1001",
+				ScalingBehavior: "letterbox",
+				Crop:            CropSettings{Y: "140", Width: "1920", Height: "800"},
+				Deinterlacer:    "adaptive",
+			},
+			"",
+		},
+		{
+			"invalid frame rate",
+			VideoPreset{Codec: "h264", FrameRate: "thirty"},
+			`frame rate "thirty" must be a positive number or fraction`,
+		},
+		{
+			"zero frame rate denominator",
+			VideoPreset{Codec: "h264", FrameRate: "30/0"},
+			`frame rate "30/0" must be a positive number or fraction`,
+		},
+		{
+			"frame rate too high",
+			VideoPreset{Codec: "h264", FrameRate: "240"},
+			`frame rate "240" must not be greater than 120`,
+		},
+		{
+			"invalid scaling behavior",
+			VideoPreset{Codec: "h264", ScalingBehavior: "zoom"},
+			`scaling behavior "zoom" is not supported`,
+		},
+		{
+			"negative crop offset",
+			VideoPreset{Codec: "h264", Crop: CropSettings{X: "-1", Width: "1280", Height: "720"}},
+			`crop x "-1" must be a non-negative integer`,
+		},
+		{
+			"crop without dimensions",
+			VideoPreset{Codec: "h264", Crop: CropSettings{X: "10"}},
+			`crop width "" must be a positive integer`,
+		},
+		{
+			"invalid deinterlacer",
+			VideoPreset{Codec: "h264", Deinterlacer: "yadif"},
+			`deinterlacer "yadif" is not supported`,
+		},
+	}
+	for _, test := range tests {
+		err := test.video.Validate()
+		if err == nil {
+			err = errors.New("")
+		}
+		if err.Error() != test.errMsg {
+			t.Errorf("%s: wrong error message\nWant %q\nGot  %q", test.testCase, test.errMsg, err.Error())
+		}
+	}
+}
+
+func TestVideoPresetFrameRateFraction(t *testing.T) {
+	tests := []struct {
+		frameRate string
+		num       int
+		den       int
+		integer   bool
+	}{
+		{"30", 30, 1, true},
+		{"29.97", 2997, 100, false},
+		{"30000/1001", 30000, 1001, false},
+		{"50/2", 50, 2, true},
+		{"23.976", 23976, 1000, false},
+	}
+	for _, test := range tests {
+		video := VideoPreset{FrameRate: test.frameRate}
+		num, den, err := video.FrameRateFraction()
+		if err != nil {
+			t.Errorf("%s: unexpected error: %v", test.frameRate, err)
+			continue
+		}
+		if num != test.num || den != test.den {
+			t.Errorf("%s: wrong fraction. Want %d/%d. Got %d/%d", test.frameRate, test.num, test.den, num, den)
+		}
+		if _, integer := video.IntegerFrameRate(); integer != test.integer {
+			t.Errorf("%s: wrong integer frame rate flag. Want %v. Got %v", test.frameRate, test.integer, integer)
+		}
+	}
+}
+
+func TestPresetValidateContainer(t *testing.T) {
+	tests := []struct {
+		testCase string
+		preset   Preset
+		errMsg   string
+	}{
+		{
+			"h264 and aac in mp4",
+			Preset{Container: "mp4", Video: VideoPreset{Codec: "h264"}, Audio: AudioPreset{Codec: "aac"}},
+			"",
+		},
+		{
+			"vp9 and opus in webm",
+			Preset{Container: "webm", Video: VideoPreset{Codec: "vp9"}, Audio: AudioPreset{Codec: "opus"}},
+			"",
+		},
+		{
+			"av1 and opus in mp4",
+			Preset{Container: "mp4", Video: VideoPreset{Codec: "AV1"}, Audio: AudioPreset{Codec: "opus"}},
+			"",
+		},
+		{
+			"audio-only opus in webm",
+			Preset{Container: "webm", Audio: AudioPreset{Codec: "opus"}},
+			"",
+		},
+		{
+			"unknown containers are left to providers",
+			Preset{Container: "mov", Video: VideoPreset{Codec: "vp9"}, Audio: AudioPreset{Codec: "vorbis"}},
+			"",
+		},
+		{
+			"unknown codecs are left to providers",
+			Preset{Container: "mp4", Video: VideoPreset{Codec: "prores"}, Audio: AudioPreset{Codec: "pcm"}},
+			"",
+		},
+		{
+			"h264 in webm",
+			Preset{Container: "webm", Video: VideoPreset{Codec: "h264"}, Audio: AudioPreset{Codec: "opus"}},
+			`video codec "h264" is not supported with the webm container`,
+		},
+		{
+			"vp9 in hls",
+			Preset{Container: "m3u8", Video: VideoPreset{Codec: "vp9"}, Audio: AudioPreset{Codec: "aac"}},
+			`video codec "vp9" is not supported with the m3u8 container`,
+		},
+		{
+			"vorbis in mp4",
+			Preset{Container: "mp4", Video: VideoPreset{Codec: "vp9"}, Audio: AudioPreset{Codec: "vorbis"}},
+			`audio codec "vorbis" is not supported with the mp4 container`,
+		},
+	}
+	for _, test := range tests {
+		err := test.preset.ValidateContainer()
+		if err == nil {
+			err = errors.New("")
+		}
+		if err.Error() != test.errMsg {
+			t.Errorf("%s: wrong error message\nWant %q\nGot  %q", test.testCase, test.errMsg, err.Error())
+		}
+	}
+}
+
+func TestAudioTrackValidation(t *testing.T) {
+	tests := []struct {
+		testCase string
+		track    AudioTrack
+		errMsg   string
+	}{
+		{
+			"track from the source media",
+			AudioTrack{TrackIndex: 2, Language: "es"},
+			"",
+		},
+		{
+			"track from a separate file",
+			AudioTrack{SourceMedia: "s3://bucket/audio/fr.wav", Language: "fr", Name: "Français"},
+			"",
+		},
+		{
+			"missing track index and source",
+			AudioTrack{Language: "es"},
+			"either trackIndex or source is required",
+		},
+		{
+			"both track index and source",
+			AudioTrack{TrackIndex: 2, SourceMedia: "s3://bucket/audio/fr.wav", Language: "fr"},
+			"trackIndex and source are mutually exclusive",
+		},
+		{
+			"missing language",
+			AudioTrack{TrackIndex: 2},
+			"language is required",
+		},
+	}
+	for _, test := range tests {
+		err := test.track.Validate()
+		if err == nil {
+			err = errors.New("")
+		}
+		if err.Error() != test.errMsg {
+			t.Errorf("%s: wrong error message\nWant %q\nGot  %q", test.testCase, test.errMsg, err.Error())
+		}
+	}
+}
+
+func TestAudioTrackDisplayName(t *testing.T) {
+	if name := (AudioTrack{Language: "es"}).DisplayName(); name != "es" {
+		t.Errorf("wrong display name. Want %q. Got %q", "es", name)
+	}
+	if name := (AudioTrack{Language: "es", Name: "Español"}).DisplayName(); name != "Español" {
+		t.Errorf("wrong display name. Want %q. Got %q", "Español", name)
+	}
+}
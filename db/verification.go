@@ -0,0 +1,84 @@
+package db
+
+import "time"
+
+// Statuses of verification reports.
+const (
+	VerificationPassed = "passed"
+	VerificationFailed = "failed"
+)
+
+// VerificationReport is the result of verifying the outputs of a finished
+// job: the files are fetched and probed, and the HLS and DASH manifests are
+// parsed to confirm that every variant resolves.
+//
+// swagger:model
+type VerificationReport struct {
+	// either passed or failed
+	Status string `json:"status"`
+
+	// time of the verification
+	VerifiedAt time.Time `json:"verifiedAt"`
+
+	// verification of each output file of the job
+	Outputs []OutputVerification `json:"outputs,omitempty"`
+
+	// verification of each adaptive streaming manifest of the job
+	Manifests []ManifestVerification `json:"manifests,omitempty"`
+}
+
+// OutputVerification is the verification of a single output file, with the
+// properties measured from the file itself.
+type OutputVerification struct {
+	// location of the file
+	Path string `json:"path"`
+
+	// name of the preset of the output
+	Preset string `json:"preset,omitempty"`
+
+	// properties measured from the file. Only MP4 and QuickTime files are
+	// probed, other files are only checked for existence
+	Container  string        `json:"container,omitempty"`
+	VideoCodec string        `json:"videoCodec,omitempty"`
+	Width      int64         `json:"width,omitempty"`
+	Height     int64         `json:"height,omitempty"`
+	Duration   time.Duration `json:"duration,omitempty"`
+	Bitrate    int64         `json:"bitrate,omitempty"`
+	FileSize   int64         `json:"fileSize,omitempty"`
+
+	// problems found in the file
+	Errors []string `json:"errors,omitempty"`
+}
+
+// ManifestVerification is the verification of an HLS playlist or DASH
+// manifest.
+type ManifestVerification struct {
+	// location of the manifest
+	Path string `json:"path"`
+
+	// either hls or dash
+	Protocol string `json:"protocol"`
+
+	// number of variants (media playlists or representations) listed in
+	// the manifest
+	Variants int `json:"variants"`
+
+	// problems found in the manifest or in its variants
+	Errors []string `json:"errors,omitempty"`
+}
+
+// Passed returns whether no problems were found in the outputs and
+// manifests of the report.
+func (r VerificationReport) Passed() bool {
+	for _, output := range r.Outputs {
+		if len(output.Errors) > 0 {
+			return false
+		}
+	}
+	for _, manifest := range r.Manifests {
+		if len(manifest.Errors) > 0 {
+			return false
+		}
+	}
+	return true
+}
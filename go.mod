@@ -24,6 +24,7 @@ require (
 	github.com/video-dev/go-elementalconductor v1.1.0
 	github.com/video-dev/go-encodingcom v1.0.0
 	github.com/video-dev/zencoder v0.0.0-20161215190743-745874544382
+	sigs.k8s.io/yaml v1.2.0
 )
 
 go 1.14
@@ -0,0 +1,402 @@
+// Package probe inspects media sources served over HTTP before they're
+// transcoded. It reads only the container header of the source, using range
+// requests, so probing large files is cheap.
+package probe
+
+import (
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/video-dev/video-transcoding-api/v2/db"
+)
+
+const (
+	// maxBoxes is the maximum number of top-level boxes inspected while
+	// looking for the moov box.
+	maxBoxes = 64
+
+	// maxMoovSize is the maximum size of the moov box that is downloaded.
+	maxMoovSize = 64 << 20
+
+	// maxFtypSize is the maximum size of the ftyp box that is downloaded.
+	maxFtypSize = 4 << 10
+)
+
+var (
+	// ErrUnsupportedContainer is returned when the source isn't an MP4 or
+	// QuickTime file.
+	ErrUnsupportedContainer = errors.New("unsupported container, only mp4 and mov sources can be probed")
+
+	// ErrRangeNotSupported is returned when the server hosting the source
+	// ignores range requests.
+	ErrRangeNotSupported = errors.New("the server doesn't support range requests")
+
+	errMoovNotFound = errors.New("moov box not found")
+	errMoovTooLarge = fmt.Errorf("moov box is larger than %d bytes", maxMoovSize)
+)
+
+var videoCodecs = map[string]string{
+	"avc1": db.VideoCodecH264,
+	"avc3": db.VideoCodecH264,
+	"hvc1": db.VideoCodecHEVC,
+	"hev1": db.VideoCodecHEVC,
+	"vp09": "vp9",
+	"av01": "av1",
+	"mp4v": "mpeg4",
+	"apch": "prores",
+	"apcn": "prores",
+	"apcs": "prores",
+	"apco": "prores",
+	"ap4h": "prores",
+}
+
+var audioCodecs = map[string]string{
+	"mp4a": "aac",
+	"ac-3": "ac3",
+	"ec-3": "eac3",
+	"Opus": "opus",
+	"fLaC": "flac",
+	".mp3": "mp3",
+	"lpcm": "pcm",
+	"sowt": "pcm",
+	"twos": "pcm",
+}
+
+// Prober probes media sources served over HTTP.
+type Prober struct {
+	// Client is the HTTP client used for fetching the source. When nil,
+	// http.DefaultClient is used.
+	Client *http.Client
+}
+
+// Probe reads the container header of the given source and returns
+// information about its duration, video and audio tracks. Only MP4 and
+// QuickTime sources are supported.
+func (p *Prober) Probe(sourceURL string) (db.SourceInfo, error) {
+	var info db.SourceInfo
+	container := "mov"
+	var offset int64
+	for i := 0; i < maxBoxes; i++ {
+		header, err := p.readRange(sourceURL, offset, 16)
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			return info, err
+		}
+		size, headerSize, boxType, err := parseBoxHeader(header)
+		if err != nil {
+			if offset == 0 {
+				return info, ErrUnsupportedContainer
+			}
+			return info, err
+		}
+		if offset == 0 && !isTopLevelBox(boxType) {
+			return info, ErrUnsupportedContainer
+		}
+		switch boxType {
+		case "ftyp":
+			length := int64(maxFtypSize)
+			if size != 0 {
+				length = min64(size-headerSize, maxFtypSize)
+			}
+			if length < 4 {
+				return info, errors.New("invalid ftyp box")
+			}
+			data, err := p.readRange(sourceURL, offset+headerSize, length)
+			if err != nil {
+				return info, err
+			}
+			if len(data) >= 4 && string(data[:4]) != "qt  " {
+				container = "mp4"
+			}
+		case "moov":
+			if size == 0 {
+				return info, errors.New("moov boxes extending to the end of the file are not supported")
+			}
+			if size > maxMoovSize {
+				return info, errMoovTooLarge
+			}
+			data, err := p.readRange(sourceURL, offset+headerSize, size-headerSize)
+			if err != nil {
+				return info, err
+			}
+			info, err = parseMoov(data)
+			if err != nil {
+				return info, err
+			}
+			info.Container = container
+			return info, nil
+		}
+		if size == 0 {
+			break
+		}
+		offset += size
+	}
+	return info, errMoovNotFound
+}
+
+func (p *Prober) readRange(sourceURL string, offset, length int64) ([]byte, error) {
+	req, err := http.NewRequest(http.MethodGet, sourceURL, nil)
+	if err != nil {
+		return nil, err
+	}
+	req.Header.Set("Range", fmt.Sprintf("bytes=%d-%d", offset, offset+length-1))
+	client := p.Client
+	if client == nil {
+		client = http.DefaultClient
+	}
+	resp, err := client.Do(req)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+	switch resp.StatusCode {
+	case http.StatusPartialContent:
+	case http.StatusOK:
+		if offset > 0 {
+			return nil, ErrRangeNotSupported
+		}
+	case http.StatusRequestedRangeNotSatisfiable:
+		return nil, io.EOF
+	default:
+		return nil, fmt.Errorf("unexpected status fetching the source: %s", resp.Status)
+	}
+	data, err := ioutil.ReadAll(io.LimitReader(resp.Body, length))
+	if err != nil {
+		return nil, err
+	}
+	if len(data) == 0 {
+		return nil, io.EOF
+	}
+	return data, nil
+}
+
+// parseBoxHeader parses the header of an ISO BMFF box, returning the size of
+// the box (0 meaning that the box extends to the end of the file), the size
+// of its header and its type.
+func parseBoxHeader(data []byte) (int64, int64, string, error) {
+	if len(data) < 8 {
+		return 0, 0, "", errors.New("truncated box header")
+	}
+	size := int64(binary.BigEndian.Uint32(data))
+	boxType := string(data[4:8])
+	headerSize := int64(8)
+	if size == 1 {
+		if len(data) < 16 {
+			return 0, 0, "", errors.New("truncated box header")
+		}
+		size = int64(binary.BigEndian.Uint64(data[8:]))
+		headerSize = 16
+	}
+	if size != 0 && size < headerSize {
+		return 0, 0, "", fmt.Errorf("invalid size for box %q", boxType)
+	}
+	return size, headerSize, boxType, nil
+}
+
+func isTopLevelBox(boxType string) bool {
+	switch boxType {
+	case "ftyp", "moov", "mdat", "free", "skip", "wide", "pnot", "uuid":
+		return true
+	}
+	return false
+}
+
+type box struct {
+	boxType string
+	data    []byte
+}
+
+// children returns the boxes contained in the given data.
+func children(data []byte) []box {
+	var boxes []box
+	for len(data) >= 8 {
+		size, headerSize, boxType, err := parseBoxHeader(data)
+		if err != nil {
+			break
+		}
+		if size == 0 || size > int64(len(data)) {
+			size = int64(len(data))
+		}
+		boxes = append(boxes, box{boxType: boxType, data: data[headerSize:size]})
+		data = data[size:]
+	}
+	return boxes
+}
+
+func child(data []byte, path ...string) []byte {
+	for _, boxType := range path {
+		var found bool
+		for _, b := range children(data) {
+			if b.boxType == boxType {
+				data = b.data
+				found = true
+				break
+			}
+		}
+		if !found {
+			return nil
+		}
+	}
+	return data
+}
+
+func parseMoov(moov []byte) (db.SourceInfo, error) {
+	var info db.SourceInfo
+	mvhd := child(moov, "mvhd")
+	if mvhd == nil {
+		return info, errors.New("mvhd box not found")
+	}
+	timescale, duration := parseTimes(mvhd)
+	if timescale > 0 {
+		info.Duration = time.Duration(float64(duration) / float64(timescale) * float64(time.Second))
+	}
+	for _, b := range children(moov) {
+		if b.boxType != "trak" {
+			continue
+		}
+		handler := child(b.data, "mdia", "hdlr")
+		if len(handler) < 12 {
+			continue
+		}
+		mdhd := child(b.data, "mdia", "mdhd")
+		stbl := child(b.data, "mdia", "minf", "stbl")
+		format, entry := sampleEntry(child(stbl, "stsd"))
+		switch string(handler[8:12]) {
+		case "vide":
+			if info.HasVideo() {
+				continue
+			}
+			info.VideoCodec = codecName(videoCodecs, format)
+			info.Width, info.Height = trackDimensions(child(b.data, "tkhd"))
+			if (info.Width == 0 || info.Height == 0) && len(entry) >= 36 {
+				info.Width = int64(binary.BigEndian.Uint16(entry[32:]))
+				info.Height = int64(binary.BigEndian.Uint16(entry[34:]))
+			}
+			trackTimescale, _ := parseTimes(mdhd)
+			info.FrameRate = frameRate(child(stbl, "stts"), trackTimescale)
+		case "soun":
+			track := db.SourceAudioTrack{
+				Codec:    codecName(audioCodecs, format),
+				Language: trackLanguage(mdhd),
+			}
+			if len(entry) >= 26 {
+				track.Channels = int(binary.BigEndian.Uint16(entry[24:]))
+			}
+			info.AudioTracks = append(info.AudioTracks, track)
+		}
+	}
+	return info, nil
+}
+
+// parseTimes returns the timescale and the duration of mvhd and mdhd boxes.
+func parseTimes(data []byte) (uint32, uint64) {
+	if len(data) < 4 {
+		return 0, 0
+	}
+	if data[0] == 1 {
+		if len(data) < 32 {
+			return 0, 0
+		}
+		return binary.BigEndian.Uint32(data[20:]), binary.BigEndian.Uint64(data[24:])
+	}
+	if len(data) < 20 {
+		return 0, 0
+	}
+	return binary.BigEndian.Uint32(data[12:]), uint64(binary.BigEndian.Uint32(data[16:]))
+}
+
+// trackDimensions returns the display dimensions of a track from its tkhd
+// box.
+func trackDimensions(tkhd []byte) (int64, int64) {
+	offset := 76
+	if len(tkhd) > 0 && tkhd[0] == 1 {
+		offset = 88
+	}
+	if len(tkhd) < offset+8 {
+		return 0, 0
+	}
+	width := binary.BigEndian.Uint32(tkhd[offset:]) >> 16
+	height := binary.BigEndian.Uint32(tkhd[offset+4:]) >> 16
+	return int64(width), int64(height)
+}
+
+// trackLanguage returns the ISO-639-2 language of a track from its mdhd box,
+// or an empty string when it's undetermined.
+func trackLanguage(mdhd []byte) string {
+	offset := 20
+	if len(mdhd) > 0 && mdhd[0] == 1 {
+		offset = 32
+	}
+	if len(mdhd) < offset+2 {
+		return ""
+	}
+	packed := binary.BigEndian.Uint16(mdhd[offset:])
+	if packed == 0 {
+		return ""
+	}
+	language := string([]byte{
+		byte(packed>>10&0x1f) + 0x60,
+		byte(packed>>5&0x1f) + 0x60,
+		byte(packed&0x1f) + 0x60,
+	})
+	if language == "und" {
+		return ""
+	}
+	return language
+}
+
+// sampleEntry returns the format and the data of the first entry of a stsd
+// box. The data includes the header of the entry.
+func sampleEntry(stsd []byte) (string, []byte) {
+	if len(stsd) < 16 {
+		return "", nil
+	}
+	entry := stsd[8:]
+	size := binary.BigEndian.Uint32(entry)
+	if size < 8 || int(size) > len(entry) {
+		return "", nil
+	}
+	return string(entry[4:8]), entry[:size]
+}
+
+// frameRate calculates the average frame rate of a track from its stts box.
+func frameRate(stts []byte, timescale uint32) float64 {
+	if len(stts) < 8 || timescale == 0 {
+		return 0
+	}
+	count := binary.BigEndian.Uint32(stts[4:])
+	var samples, duration uint64
+	for i := 0; i < int(count) && 8+i*8+8 <= len(stts); i++ {
+		entry := stts[8+i*8:]
+		sampleCount := uint64(binary.BigEndian.Uint32(entry))
+		samples += sampleCount
+		duration += sampleCount * uint64(binary.BigEndian.Uint32(entry[4:]))
+	}
+	if duration == 0 {
+		return 0
+	}
+	rate := float64(samples) * float64(timescale) / float64(duration)
+	return float64(int64(rate*1000+0.5)) / 1000
+}
+
+func codecName(codecs map[string]string, format string) string {
+	if codec, ok := codecs[format]; ok {
+		return codec
+	}
+	return strings.TrimSpace(format)
+}
+
+func min64(a, b int64) int64 {
+	if a < b {
+		return a
+	}
+	return b
+}
@@ -0,0 +1,245 @@
+package probe
+
+import (
+	"bytes"
+	"encoding/binary"
+	"net/http"
+	"net/http/httptest"
+	"reflect"
+	"strings"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/video-dev/video-transcoding-api/v2/db"
+)
+
+func mkbox(boxType string, payload ...[]byte) []byte {
+	data := bytes.Join(payload, nil)
+	header := make([]byte, 8)
+	binary.BigEndian.PutUint32(header, uint32(len(data)+8))
+	copy(header[4:], boxType)
+	return append(header, data...)
+}
+
+func u16(v uint16) []byte {
+	data := make([]byte, 2)
+	binary.BigEndian.PutUint16(data, v)
+	return data
+}
+
+func u32(v uint32) []byte {
+	data := make([]byte, 4)
+	binary.BigEndian.PutUint32(data, v)
+	return data
+}
+
+func zeros(n int) []byte {
+	return make([]byte, n)
+}
+
+func mvhd(timescale, duration uint32) []byte {
+	return mkbox("mvhd", zeros(12), u32(timescale), u32(duration), zeros(80))
+}
+
+func mdhd(timescale, duration uint32, language string) []byte {
+	var packed uint16
+	for _, c := range []byte(language) {
+		packed = packed<<5 | uint16(c-0x60)
+	}
+	return mkbox("mdhd", zeros(12), u32(timescale), u32(duration), u16(packed), zeros(2))
+}
+
+func hdlr(handler string) []byte {
+	return mkbox("hdlr", zeros(8), []byte(handler), zeros(13))
+}
+
+func tkhd(width, height uint32) []byte {
+	return mkbox("tkhd", zeros(76), u32(width<<16), u32(height<<16))
+}
+
+func videoTrak(format string, width, height, timescale, frames, frameDuration uint32) []byte {
+	entry := mkbox(format, zeros(6), u16(1), zeros(16), u16(uint16(width)), u16(uint16(height)), zeros(50))
+	return mkbox("trak",
+		tkhd(width, height),
+		mkbox("mdia",
+			mdhd(timescale, frames*frameDuration, "und"),
+			hdlr("vide"),
+			mkbox("minf", mkbox("stbl",
+				mkbox("stsd", zeros(4), u32(1), entry),
+				mkbox("stts", zeros(4), u32(1), u32(frames), u32(frameDuration)),
+			)),
+		),
+	)
+}
+
+func audioTrak(format string, channels uint16, language string) []byte {
+	entry := mkbox(format, zeros(6), u16(1), zeros(8), u16(channels), u16(16), zeros(4), u32(48000<<16))
+	return mkbox("trak",
+		tkhd(0, 0),
+		mkbox("mdia",
+			mdhd(48000, 48000*60, language),
+			hdlr("soun"),
+			mkbox("minf", mkbox("stbl", mkbox("stsd", zeros(4), u32(1), entry))),
+		),
+	)
+}
+
+func serveFile(t *testing.T, data []byte, ignoreRange bool) (*httptest.Server, *int64) {
+	t.Helper()
+	var requests int64
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		atomic.AddInt64(&requests, 1)
+		if ignoreRange {
+			r.Header.Del("Range")
+		}
+		http.ServeContent(w, r, "source", time.Time{}, bytes.NewReader(data))
+	}))
+	t.Cleanup(server.Close)
+	return server, &requests
+}
+
+func TestProbe(t *testing.T) {
+	mdat := mkbox("mdat", zeros(1<<20))
+	tests := []struct {
+		name     string
+		file     []byte
+		expected db.SourceInfo
+	}{
+		{
+			"mp4 with moov before mdat",
+			bytes.Join([][]byte{
+				mkbox("ftyp", []byte("isom"), u32(512), []byte("isomiso2avc1mp41")),
+				mkbox("moov",
+					mvhd(1000, 60000),
+					videoTrak("avc1", 1920, 1080, 30000, 1800, 1001),
+					audioTrak("mp4a", 2, "eng"),
+					audioTrak("ac-3", 6, "spa"),
+				),
+				mdat,
+			}, nil),
+			db.SourceInfo{
+				Duration:   time.Minute,
+				Width:      1920,
+				Height:     1080,
+				VideoCodec: "h264",
+				Container:  "mp4",
+				FrameRate:  29.97,
+				AudioTracks: []db.SourceAudioTrack{
+					{Codec: "aac", Channels: 2, Language: "eng"},
+					{Codec: "ac3", Channels: 6, Language: "spa"},
+				},
+			},
+		},
+		{
+			"mov with moov after mdat",
+			bytes.Join([][]byte{
+				mkbox("ftyp", []byte("qt  "), u32(0), []byte("qt  ")),
+				mkbox("wide"),
+				mdat,
+				mkbox("moov",
+					mvhd(600, 6000),
+					videoTrak("apch", 3840, 2160, 2500, 250, 100),
+				),
+			}, nil),
+			db.SourceInfo{
+				Duration:   10 * time.Second,
+				Width:      3840,
+				Height:     2160,
+				VideoCodec: "prores",
+				Container:  "mov",
+				FrameRate:  25,
+			},
+		},
+		{
+			"audio only",
+			bytes.Join([][]byte{
+				mkbox("ftyp", []byte("M4A "), u32(0)),
+				mkbox("moov", mvhd(1000, 30000), audioTrak("mp4a", 2, "und")),
+				mdat,
+			}, nil),
+			db.SourceInfo{
+				Duration:    30 * time.Second,
+				Container:   "mp4",
+				AudioTracks: []db.SourceAudioTrack{{Codec: "aac", Channels: 2}},
+			},
+		},
+	}
+	for _, test := range tests {
+		test := test
+		t.Run(test.name, func(t *testing.T) {
+			server, requests := serveFile(t, test.file, false)
+			var prober Prober
+			info, err := prober.Probe(server.URL)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if !reflect.DeepEqual(info, test.expected) {
+				t.Errorf("wrong source info\nwant %#v\ngot  %#v", test.expected, info)
+			}
+			if *requests > 6 {
+				t.Errorf("too many requests to probe the source: %d", *requests)
+			}
+		})
+	}
+}
+
+func TestProbeErrors(t *testing.T) {
+	mov := bytes.Join([][]byte{
+		mkbox("ftyp", []byte("qt  "), u32(0)),
+		mkbox("mdat", zeros(1024)),
+		mkbox("moov", mvhd(600, 6000)),
+	}, nil)
+	tests := []struct {
+		name        string
+		file        []byte
+		ignoreRange bool
+		errMsg      string
+	}{
+		{
+			"MPEG-TS source",
+			append([]byte{0x47, 0x40, 0x00, 0x10}, zeros(184)...),
+			false,
+			"unsupported container, only mp4 and mov sources can be probed",
+		},
+		{
+			"range requests not supported",
+			mov,
+			true,
+			"the server doesn't support range requests",
+		},
+		{
+			"missing moov",
+			bytes.Join([][]byte{mkbox("ftyp", []byte("isom"), u32(0)), mkbox("mdat", zeros(1024))}, nil),
+			false,
+			"moov box not found",
+		},
+		{
+			"missing mvhd",
+			bytes.Join([][]byte{mkbox("ftyp", []byte("isom"), u32(0)), mkbox("moov", mkbox("trak"))}, nil),
+			false,
+			"mvhd box not found",
+		},
+	}
+	for _, test := range tests {
+		test := test
+		t.Run(test.name, func(t *testing.T) {
+			server, _ := serveFile(t, test.file, test.ignoreRange)
+			var prober Prober
+			_, err := prober.Probe(server.URL)
+			if err == nil || err.Error() != test.errMsg {
+				t.Errorf("wrong error\nwant %q\ngot  %v", test.errMsg, err)
+			}
+		})
+	}
+}
+
+func TestProbeHTTPError(t *testing.T) {
+	server := httptest.NewServer(http.NotFoundHandler())
+	defer server.Close()
+	var prober Prober
+	_, err := prober.Probe(server.URL)
+	if err == nil || !strings.Contains(err.Error(), "404 Not Found") {
+		t.Errorf("unexpected error: %v", err)
+	}
+}
@@ -101,74 +101,212 @@ type bitmovinH264Preset struct {
 	Audio models.AACCodecConfiguration
 }
 
+type bitmovinH265Preset struct {
+	Video models.H265CodecConfiguration
+	Audio models.AACCodecConfiguration
+}
+
 type bitmovinVP8Preset struct {
 	Video models.VP8CodecConfiguration
 	Audio models.VorbisCodecConfiguration
 }
 
+type bitmovinVP9Preset struct {
+	Video models.VP9CodecConfiguration
+	Audio models.VorbisCodecConfiguration
+}
+
+type bitmovinAudioOnlyPreset struct {
+	Audio models.AACCodecConfiguration
+}
+
+// presetConfig is the representation of a preset in Bitmovin. Audio-only
+// presets are stored as dedicated AAC configurations, while video presets
+// are stored as video configurations whose custom data references an audio
+// configuration shared by the presets with the same audio settings.
+type presetConfig struct {
+	// codec configuration the preset is stored as
+	codec interface{}
+
+	// settings of the shared audio configuration of video presets
+	audioCodec          string
+	audioBitrate        int
+	samplingRate        float64
+	aacChannelLayout    bitmovintypes.AACChannelLayout
+	vorbisChannelLayout bitmovintypes.ChannelLayout
+}
+
+// presetConfigFrom maps the preset to its Bitmovin configurations. Bitmovin
+// only takes h264 or hevc video along with aac audio and vp8 or vp9 video
+// along with vorbis audio, and audio-only presets must use aac.
+func presetConfigFrom(preset db.Preset) (presetConfig, error) {
+	if err := checkAudioSettings(preset.Audio); err != nil {
+		return presetConfig{}, err
+	}
+	samplingRate, err := samplingRateFrom(preset.Audio)
+	if err != nil {
+		return presetConfig{}, err
+	}
+	config := presetConfig{audioCodec: strings.ToLower(preset.Audio.Codec), samplingRate: samplingRate}
+	customData := map[string]interface{}{"container": preset.Container}
+	overrides := preset.ProviderOverrides.For(Name)
+	if preset.AudioOnly() {
+		if config.audioCodec != "aac" {
+			return presetConfig{}, fmt.Errorf("unsupported audio codec for audio-only presets: %v", preset.Audio.Codec)
+		}
+		if config.audioBitrate, err = preset.Audio.BitrateBps(); err != nil {
+			return presetConfig{}, err
+		}
+		if config.aacChannelLayout, err = aacChannelLayoutFrom(preset.Audio.ChannelLayout); err != nil {
+			return presetConfig{}, err
+		}
+		config.codec = &models.AACCodecConfiguration{
+			Name:          stringToPtr(preset.Name),
+			Bitrate:       intToPtr(int64(config.audioBitrate)),
+			SamplingRate:  floatToPtr(samplingRate),
+			ChannelLayout: config.aacChannelLayout,
+			CustomData:    customData,
+		}
+		return config, provider.ApplyOverrides(config.codec, overrides, true)
+	}
+	videoCodec := strings.ToLower(preset.Video.Codec)
+	if videoCodec == db.VideoCodecAV1 {
+		return presetConfig{}, fmt.Errorf("unsupported video codec: %v", preset.Video.Codec)
+	}
+	if err = checkVideoProcessingSettings(preset.Video); err != nil {
+		return presetConfig{}, err
+	}
+	if config.audioCodec != "aac" && config.audioCodec != "vorbis" {
+		return presetConfig{}, fmt.Errorf("unsupported audio codec: %v", preset.Audio.Codec)
+	}
+	if config.audioBitrate, err = preset.Audio.BitrateBps(); err != nil {
+		return presetConfig{}, err
+	}
+	if strings.ToLower(preset.Video.Deinterlacer) == db.DeinterlacerDeinterlace {
+		customData["deinterlacer"] = db.DeinterlacerDeinterlace
+	}
+	switch {
+	case config.audioCodec == "aac" && videoCodec == db.VideoCodecHEVC:
+		// HLS only carries HEVC in fMP4 segments
+		if preset.Container == "m3u8" {
+			customData["container"] = "cmaf"
+		}
+		config.codec, err = h265ConfigFrom(preset, customData)
+	case config.audioCodec == "aac" && videoCodec == db.VideoCodecH264:
+		config.codec, err = h264ConfigFrom(preset, customData)
+	case config.audioCodec == "vorbis" && videoCodec == db.VideoCodecVP9:
+		config.codec, err = vp9ConfigFrom(preset, customData)
+	case config.audioCodec == "vorbis" && videoCodec == db.VideoCodecVP8:
+		config.codec, err = vp8ConfigFrom(preset, customData)
+	default:
+		return presetConfig{}, fmt.Errorf("unsupported codec pair: %s video with %s audio", preset.Video.Codec, preset.Audio.Codec)
+	}
+	if err != nil {
+		return presetConfig{}, err
+	}
+	if config.audioCodec == "aac" {
+		config.aacChannelLayout, err = aacChannelLayoutFrom(preset.Audio.ChannelLayout)
+	} else {
+		config.vorbisChannelLayout, err = vorbisChannelLayoutFrom(preset.Audio.ChannelLayout)
+	}
+	if err != nil {
+		return presetConfig{}, err
+	}
+	return config, provider.ApplyOverrides(config.codec, overrides, true)
+}
+
+// ValidatePreset checks whether the preset can be created in Bitmovin,
+// building the same configurations CreatePreset stores.
+func (p *bitmovinProvider) ValidatePreset(preset db.Preset) error {
+	_, err := presetConfigFrom(preset)
+	return err
+}
+
 func (p *bitmovinProvider) CreatePreset(preset db.Preset) (string, error) {
-	if strings.ToLower(preset.Audio.Codec) == "aac" && strings.ToLower(preset.Video.Codec) == "h264" {
+	config, err := presetConfigFrom(preset)
+	if err != nil {
+		return "", err
+	}
+	if preset.AudioOnly() {
+		return p.createAudioOnlyPreset(config.codec.(*models.AACCodecConfiguration))
+	}
+	var audioConfigID string
+	if config.audioCodec == "aac" {
 		aac := services.NewAACCodecConfigurationService(p.client)
-		bitrate, err := strconv.Atoi(preset.Audio.Bitrate)
+		audioConfigID, err = getAACConfig(aac, config.audioBitrate, config.samplingRate, config.aacChannelLayout)
+	} else {
+		vorbis := services.NewVorbisCodecConfigurationService(p.client)
+		audioConfigID, err = getVorbisConfig(vorbis, config.audioBitrate, config.samplingRate, config.vorbisChannelLayout)
+	}
+	if err != nil {
+		return "", err
+	}
+	var (
+		status   bitmovintypes.ResponseStatus
+		presetID *string
+	)
+	switch codec := config.codec.(type) {
+	case *models.H264CodecConfiguration:
+		codec.CustomData = withAudioConfig(codec.CustomData, audioConfigID)
+		resp, err := services.NewH264CodecConfigurationService(p.client).Create(codec)
 		if err != nil {
 			return "", err
 		}
-		audioConfigID, err := getAACConfig(aac, bitrate, 48000)
+		status, presetID = resp.Status, resp.Data.Result.ID
+	case *models.H265CodecConfiguration:
+		codec.CustomData = withAudioConfig(codec.CustomData, audioConfigID)
+		resp, err := services.NewH265CodecConfigurationService(p.client).Create(codec)
 		if err != nil {
 			return "", err
 		}
-
-		customData := make(map[string]interface{})
-		customData["audio"] = audioConfigID
-		customData["container"] = preset.Container
-		h264Config, err := p.createH264VideoPreset(preset, customData)
+		status, presetID = resp.Status, resp.Data.Result.ID
+	case *models.VP8CodecConfiguration:
+		codec.CustomData = withAudioConfig(codec.CustomData, audioConfigID)
+		resp, err := services.NewVP8CodecConfigurationService(p.client).Create(codec)
 		if err != nil {
 			return "", err
 		}
-
-		h264 := services.NewH264CodecConfigurationService(p.client)
-		videoResp, err := h264.Create(h264Config)
+		status, presetID = resp.Status, resp.Data.Result.ID
+	case *models.VP9CodecConfiguration:
+		codec.CustomData = withAudioConfig(codec.CustomData, audioConfigID)
+		resp, err := services.NewVP9CodecConfigurationService(p.client).Create(codec)
 		if err != nil {
 			return "", err
 		}
-		if videoResp.Status == bitmovinAPIErrorMsg {
-			return "", errors.New("error in creating video portion of Preset")
-		}
-		return *videoResp.Data.Result.ID, nil
+		status, presetID = resp.Status, resp.Data.Result.ID
 	}
-	if strings.ToLower(preset.Audio.Codec) == "vorbis" && strings.ToLower(preset.Video.Codec) == "vp8" {
-		vorbis := services.NewVorbisCodecConfigurationService(p.client)
-		bitrate, err := strconv.Atoi(preset.Audio.Bitrate)
-		if err != nil {
-			return "", err
-		}
-		audioConfigID, err := getVorbisConfig(vorbis, bitrate, 48000)
-		if err != nil {
-			return "", err
-		}
+	if status == bitmovinAPIErrorMsg {
+		return "", errors.New("error in creating video portion of Preset")
+	}
+	return *presetID, nil
+}
 
-		customData := make(map[string]interface{})
-		customData["audio"] = audioConfigID
-		customData["container"] = preset.Container
-		vp8Config, err := p.createVP8VideoPreset(preset, customData)
-		if err != nil {
-			return "", err
-		}
+// withAudioConfig references the audio configuration in the custom data of
+// a video configuration.
+func withAudioConfig(customData map[string]interface{}, audioConfigID string) map[string]interface{} {
+	if customData == nil {
+		customData = make(map[string]interface{})
+	}
+	customData["audio"] = audioConfigID
+	return customData
+}
 
-		vp8 := services.NewVP8CodecConfigurationService(p.client)
-		videoResp, err := vp8.Create(vp8Config)
-		if err != nil {
-			return "", err
-		}
-		if videoResp.Status == bitmovinAPIErrorMsg {
-			return "", errors.New("error in creating video portion of Preset")
-		}
-		return *videoResp.Data.Result.ID, nil
+// createAudioOnlyPreset stores audio-only presets as dedicated AAC
+// configurations, holding the container in their custom data. Unlike the
+// audio portion of video presets, these configurations are never shared.
+func (p *bitmovinProvider) createAudioOnlyPreset(audioConfig *models.AACCodecConfiguration) (string, error) {
+	aac := services.NewAACCodecConfigurationService(p.client)
+	audioResp, err := aac.Create(audioConfig)
+	if err != nil {
+		return "", err
 	}
-	return "", fmt.Errorf("unsupported audio codec: %v", preset.Audio.Codec)
+	if audioResp.Status == bitmovinAPIErrorMsg {
+		return "", errors.New("error in creating audio-only preset")
+	}
+	return *audioResp.Data.Result.ID, nil
 }
 
-func (p *bitmovinProvider) createH264VideoPreset(preset db.Preset, customData map[string]interface{}) (*models.H264CodecConfiguration, error) {
+func h264ConfigFrom(preset db.Preset, customData map[string]interface{}) (*models.H264CodecConfiguration, error) {
 	h264 := &models.H264CodecConfiguration{
 		CustomData: customData,
 	}
@@ -186,6 +324,18 @@ func (p *bitmovinProvider) createH264VideoPreset(preset db.Preset, customData ma
 	default:
 		return nil, fmt.Errorf("unrecognized h264 profile: %v", preset.Video.Profile)
 	}
+	if preset.Video.Is10Bit() {
+		return nil, fmt.Errorf("unsupported bit depth for h264: %v", preset.Video.BitDepth)
+	}
+	colorConfig, err := colorConfigFrom(preset.Video)
+	if err != nil {
+		return nil, err
+	}
+	h264.ColorConfig = colorConfig
+	h264.FrameRate, err = frameRateFrom(preset.Video)
+	if err != nil {
+		return nil, err
+	}
 	foundLevel := false
 	for _, l := range h264Levels {
 		if l == bitmovintypes.H264Level(preset.Video.ProfileLevel) {
@@ -199,14 +349,14 @@ func (p *bitmovinProvider) createH264VideoPreset(preset db.Preset, customData ma
 		h264.Level = bitmovintypes.H264Level5_2
 	}
 	if preset.Video.Width != "" {
-		width, err := strconv.Atoi(preset.Video.Width)
+		width, err := preset.Video.WidthPixels()
 		if err != nil {
 			return nil, err
 		}
 		h264.Width = intToPtr(int64(width))
 	}
 	if preset.Video.Height != "" {
-		height, err := strconv.Atoi(preset.Video.Height)
+		height, err := preset.Video.HeightPixels()
 		if err != nil {
 			return nil, err
 		}
@@ -216,13 +366,13 @@ func (p *bitmovinProvider) createH264VideoPreset(preset db.Preset, customData ma
 	if preset.Video.Bitrate == "" {
 		return nil, errors.New("video bitrate must be set")
 	}
-	bitrate, err := strconv.Atoi(preset.Video.Bitrate)
+	bitrate, err := preset.Video.BitrateBps()
 	if err != nil {
 		return nil, err
 	}
 	h264.Bitrate = intToPtr(int64(bitrate))
 	if preset.Video.GopSize != "" {
-		gopSize, err := strconv.Atoi(preset.Video.GopSize)
+		gopSize, err := preset.Video.GopFrames()
 		if err != nil {
 			return nil, err
 		}
@@ -233,7 +383,7 @@ func (p *bitmovinProvider) createH264VideoPreset(preset db.Preset, customData ma
 		}
 	}
 	if preset.Video.BFrames != "" {
-		bFrames, err := strconv.Atoi(preset.Video.BFrames)
+		bFrames, err := preset.Video.BFrameCount()
 		if err != nil {
 			return nil, err
 		}
@@ -243,21 +393,110 @@ func (p *bitmovinProvider) createH264VideoPreset(preset db.Preset, customData ma
 	return h264, nil
 }
 
-func (p *bitmovinProvider) createVP8VideoPreset(preset db.Preset, customData map[string]interface{}) (*models.VP8CodecConfiguration, error) {
-	vp8 := &models.VP8CodecConfiguration{
+func h265ConfigFrom(preset db.Preset, customData map[string]interface{}) (*models.H265CodecConfiguration, error) {
+	h265 := &models.H265CodecConfiguration{
+		Name:       stringToPtr(preset.Name),
 		CustomData: customData,
 	}
+	switch strings.ToLower(preset.Video.Profile) {
+	case "", db.HEVCProfileMain:
+		h265.Profile = bitmovintypes.H265ProfileMain
+	case db.HEVCProfileMain10:
+		h265.Profile = bitmovintypes.H265ProfileMain10
+	default:
+		return nil, fmt.Errorf("unrecognized h265 profile: %v", preset.Video.Profile)
+	}
+	if preset.Video.Is10Bit() {
+		h265.Profile = bitmovintypes.H265ProfileMain10
+		h265.PixelFormat = bitmovintypes.PixelFormatYUV420P10LE
+	}
+	colorConfig, err := colorConfigFrom(preset.Video)
+	if err != nil {
+		return nil, err
+	}
+	h265.ColorConfig = colorConfig
+	h265.FrameRate, err = frameRateFrom(preset.Video)
+	if err != nil {
+		return nil, err
+	}
+	if strings.ToLower(preset.Video.HDR) == db.HDR10 {
+		h265.HDR = boolToPtr(true)
+	}
+	if strings.ToLower(preset.Video.Tier) == db.HEVCTierHigh {
+		return nil, errors.New("the high hevc tier is not supported with bitmovin")
+	}
+	if level := preset.Video.HEVCLevel(); level != "" {
+		h265.Level = bitmovintypes.H265Level(level)
+	}
+	if preset.Video.Width != "" {
+		width, err := preset.Video.WidthPixels()
+		if err != nil {
+			return nil, err
+		}
+		h265.Width = intToPtr(int64(width))
+	}
+	if preset.Video.Height != "" {
+		height, err := preset.Video.HeightPixels()
+		if err != nil {
+			return nil, err
+		}
+		h265.Height = intToPtr(int64(height))
+	}
+
+	if preset.Video.Bitrate == "" {
+		return nil, errors.New("video bitrate must be set")
+	}
+	bitrate, err := preset.Video.BitrateBps()
+	if err != nil {
+		return nil, err
+	}
+	h265.Bitrate = intToPtr(int64(bitrate))
+	if preset.Video.GopSize != "" {
+		gopSize, err := preset.Video.GopFrames()
+		if err != nil {
+			return nil, err
+		}
+		h265.MaxGOP = intToPtr(int64(gopSize))
+		if preset.Video.GopMode == "fixed" {
+			h265.MinGOP = intToPtr(int64(gopSize))
+			h265.SceneCutThreshold = intToPtr(int64(0))
+		}
+	}
+	if preset.Video.BFrames != "" {
+		bFrames, err := preset.Video.BFrameCount()
+		if err != nil {
+			return nil, err
+		}
+		h265.BFrames = intToPtr(int64(bFrames))
+	}
+
+	return h265, nil
+}
+
+func vp8ConfigFrom(preset db.Preset, customData map[string]interface{}) (*models.VP8CodecConfiguration, error) {
+	colorConfig, err := colorConfigFrom(preset.Video)
+	if err != nil {
+		return nil, err
+	}
+	vp8 := &models.VP8CodecConfiguration{
+		CustomData:  customData,
+		ColorConfig: colorConfig,
+	}
 	vp8.Name = stringToPtr(preset.Name)
+	vp8.FrameRate, err = frameRateFrom(preset.Video)
+	if err != nil {
+		return nil, err
+	}
 
 	if preset.Video.Width != "" {
-		width, err := strconv.Atoi(preset.Video.Width)
+		width, err := preset.Video.WidthPixels()
 		if err != nil {
 			return nil, err
 		}
 		vp8.Width = intToPtr(int64(width))
 	}
 	if preset.Video.Height != "" {
-		height, err := strconv.Atoi(preset.Video.Height)
+		height, err := preset.Video.HeightPixels()
 		if err != nil {
 			return nil, err
 		}
@@ -267,7 +506,7 @@ func (p *bitmovinProvider) createVP8VideoPreset(preset db.Preset, customData map
 	if preset.Video.Bitrate == "" {
 		return nil, errors.New("video bitrate must be set")
 	}
-	bitrate, err := strconv.Atoi(preset.Video.Bitrate)
+	bitrate, err := preset.Video.BitrateBps()
 	if err != nil {
 		return nil, err
 	}
@@ -276,6 +515,144 @@ func (p *bitmovinProvider) createVP8VideoPreset(preset db.Preset, customData map
 	return vp8, nil
 }
 
+// vp9ConfigFrom builds the VP9 configuration of a preset. Bitmovin
+// picks the VP9 profile from the input, so only the default profile 0 is
+// accepted.
+func vp9ConfigFrom(preset db.Preset, customData map[string]interface{}) (*models.VP9CodecConfiguration, error) {
+	if preset.Video.Profile != "" && preset.Video.Profile != "0" {
+		return nil, fmt.Errorf("unsupported vp9 profile: %v", preset.Video.Profile)
+	}
+	if preset.Video.Is10Bit() {
+		return nil, fmt.Errorf("unsupported bit depth for vp9: %v", preset.Video.BitDepth)
+	}
+	colorConfig, err := colorConfigFrom(preset.Video)
+	if err != nil {
+		return nil, err
+	}
+	vp9 := &models.VP9CodecConfiguration{
+		CustomData:  customData,
+		ColorConfig: colorConfig,
+	}
+	vp9.Name = stringToPtr(preset.Name)
+	vp9.FrameRate, err = frameRateFrom(preset.Video)
+	if err != nil {
+		return nil, err
+	}
+
+	if preset.Video.Width != "" {
+		width, err := preset.Video.WidthPixels()
+		if err != nil {
+			return nil, err
+		}
+		vp9.Width = intToPtr(int64(width))
+	}
+	if preset.Video.Height != "" {
+		height, err := preset.Video.HeightPixels()
+		if err != nil {
+			return nil, err
+		}
+		vp9.Height = intToPtr(int64(height))
+	}
+
+	if preset.Video.Bitrate == "" {
+		return nil, errors.New("video bitrate must be set")
+	}
+	bitrate, err := preset.Video.BitrateBps()
+	if err != nil {
+		return nil, err
+	}
+	vp9.Bitrate = intToPtr(int64(bitrate))
+
+	return vp9, nil
+}
+
+// colorConfigFrom maps the color space and HDR settings of the preset to the
+// color configuration of the codec. Bitmovin converts the source to the
+// configured color space, which tone-maps HDR sources when SDR is requested.
+func colorConfigFrom(video db.VideoPreset) (models.ColorConfig, error) {
+	colorSpace := strings.ToLower(video.ColorSpace)
+	switch strings.ToLower(video.HDR) {
+	case db.HDRPassthrough:
+		return models.ColorConfig{
+			CopyColorPrimariesFlag: boolToPtr(true),
+			CopyColorTransferFlag:  boolToPtr(true),
+			CopyColorSpaceFlag:     boolToPtr(true),
+		}, nil
+	case db.HDR10:
+		return models.ColorConfig{
+			ColorPrimaries: bitmovintypes.ColorPrimariesBT2020,
+			ColorTransfer:  bitmovintypes.ColorTransferSMPTE2084,
+			ColorSpace:     bitmovintypes.ColorSpaceBT2020_NCL,
+		}, nil
+	case db.HDRHLG:
+		return models.ColorConfig{
+			ColorPrimaries: bitmovintypes.ColorPrimariesBT2020,
+			ColorTransfer:  bitmovintypes.ColorTransferARIB_STD_B67,
+			ColorSpace:     bitmovintypes.ColorSpaceBT2020_NCL,
+		}, nil
+	case db.HDRToneMapSDR:
+		if colorSpace == "" {
+			colorSpace = db.ColorSpaceRec709
+		}
+	}
+	switch colorSpace {
+	case "":
+		return models.ColorConfig{}, nil
+	case db.ColorSpaceRec601:
+		return models.ColorConfig{
+			ColorPrimaries: bitmovintypes.ColorPrimariesSMPTE170M,
+			ColorTransfer:  bitmovintypes.ColorTransferSMPTE170M,
+			ColorSpace:     bitmovintypes.ColorSpaceSMPTE170M,
+		}, nil
+	case db.ColorSpaceRec709:
+		return models.ColorConfig{
+			ColorPrimaries: bitmovintypes.ColorPrimariesBT709,
+			ColorTransfer:  bitmovintypes.ColorTransferBT709,
+			ColorSpace:     bitmovintypes.ColorSpaceBT709,
+		}, nil
+	case db.ColorSpaceRec2020:
+		return models.ColorConfig{
+			ColorPrimaries: bitmovintypes.ColorPrimariesBT2020,
+			ColorTransfer:  bitmovintypes.ColorTransferBT2020_10,
+			ColorSpace:     bitmovintypes.ColorSpaceBT2020_NCL,
+		}, nil
+	default:
+		return models.ColorConfig{}, fmt.Errorf("unsupported color space: %v", video.ColorSpace)
+	}
+}
+
+// checkVideoProcessingSettings rejects the processing settings of the preset
+// that can't be expressed with bitmovin codec configurations. Bitmovin always
+// scales the input to the configured dimensions, and deinterlacing is applied
+// as a stream filter when transcoding.
+func checkVideoProcessingSettings(video db.VideoPreset) error {
+	if video.ScalingBehavior != "" {
+		return fmt.Errorf("unsupported scaling behavior: %v", video.ScalingBehavior)
+	}
+	if !video.Crop.IsZero() {
+		return errors.New("cropping is not supported with bitmovin")
+	}
+	switch strings.ToLower(video.Deinterlacer) {
+	case "", db.DeinterlacerOff, db.DeinterlacerDeinterlace:
+		return nil
+	default:
+		return fmt.Errorf("unsupported deinterlacer: %v", video.Deinterlacer)
+	}
+}
+
+// frameRateFrom returns the output frame rate of the preset, or nil when the
+// frame rate of the input should be kept.
+func frameRateFrom(video db.VideoPreset) (*float64, error) {
+	if video.FrameRate == "" {
+		return nil, nil
+	}
+	num, den, err := video.FrameRateFraction()
+	if err != nil {
+		return nil, err
+	}
+	return floatToPtr(float64(num) / float64(den)), nil
+}
+
 func (p *bitmovinProvider) DeletePreset(presetID string) error {
 	// Delete both the audio and video preset
 	h264 := services.NewH264CodecConfigurationService(p.client)
@@ -325,6 +702,42 @@ func (p *bitmovinProvider) DeletePreset(presetID string) error {
 		}
 		return nil
 	}
+	h265 := services.NewH265CodecConfigurationService(p.client)
+	h265Response, err := h265.Retrieve(presetID)
+	if err == nil {
+		if h265Response.Status == bitmovinAPIErrorMsg {
+			return errors.New("api error")
+		}
+		cdResp, err := h265.RetrieveCustomData(presetID)
+		if err != nil {
+			return err
+		}
+		if cdResp.Status == bitmovinAPIErrorMsg {
+			return errors.New("video preset must contain custom data to hold audio and container information")
+		}
+		audioPresetID, err := audioConfigurationIDFrom(cdResp.Data.Result.CustomData)
+		if err != nil {
+			return err
+		}
+
+		aac := services.NewAACCodecConfigurationService(p.client)
+		audioDeleteResp, err := aac.Delete(audioPresetID)
+		if err != nil {
+			return err
+		}
+		if audioDeleteResp.Status == bitmovinAPIErrorMsg {
+			return errors.New("error in deleting audio portion of preset")
+		}
+
+		videoDeleteResp, err := h265.Delete(presetID)
+		if err != nil {
+			return err
+		}
+		if videoDeleteResp.Status == bitmovinAPIErrorMsg {
+			return errors.New("error in deleting video portion of preset")
+		}
+		return nil
+	}
 	vp8 := services.NewVP8CodecConfigurationService(p.client)
 	vp8Response, err := vp8.Retrieve(presetID)
 	if err == nil {
@@ -372,7 +785,132 @@ func (p *bitmovinProvider) DeletePreset(presetID string) error {
 		}
 		return nil
 	}
-	return errors.New("could not find h.264 or vp8 configuration to delete")
+	vp9 := services.NewVP9CodecConfigurationService(p.client)
+	vp9Response, err := vp9.Retrieve(presetID)
+	if err == nil {
+		if vp9Response.Status == bitmovinAPIErrorMsg {
+			return errors.New("api error")
+		}
+		cdResp, err := vp9.RetrieveCustomData(presetID)
+		if err != nil {
+			return err
+		}
+		if cdResp.Status == bitmovinAPIErrorMsg {
+			return errors.New("video preset must contain custom data to hold audio and container information")
+		}
+		audioPresetID, err := audioConfigurationIDFrom(cdResp.Data.Result.CustomData)
+		if err != nil {
+			return err
+		}
+
+		vorbis := services.NewVorbisCodecConfigurationService(p.client)
+		audioDeleteResp, err := vorbis.Delete(audioPresetID)
+		if err != nil {
+			return err
+		}
+		if audioDeleteResp.Status == bitmovinAPIErrorMsg {
+			return errors.New("error in deleting audio portion of preset")
+		}
+
+		videoDeleteResp, err := vp9.Delete(presetID)
+		if err != nil {
+			return err
+		}
+		if videoDeleteResp.Status == bitmovinAPIErrorMsg {
+			return errors.New("error in deleting video portion of preset")
+		}
+		return nil
+	}
+	aac := services.NewAACCodecConfigurationService(p.client)
+	if _, err := p.retrieveAudioOnlyContainer(aac, presetID); err == nil {
+		audioDeleteResp, err := aac.Delete(presetID)
+		if err != nil {
+			return err
+		}
+		if audioDeleteResp.Status == bitmovinAPIErrorMsg {
+			return errors.New("error in deleting audio-only preset")
+		}
+		return nil
+	}
+	return errors.New("could not find h.264, h.265, vp8, vp9 or audio-only configuration to delete")
+}
+
+// customDataRetriever is implemented by the codec configuration services
+// storing the audio configuration and the container of video presets in
+// their custom data.
+type customDataRetriever interface {
+	RetrieveCustomData(id string) (*models.CustomDataResponse, error)
+}
+
+// retrieveH26xConfiguration looks the given preset up among the H.264 and
+// H.265 codec configurations, which share the same muxings and only differ
+// in the service holding them. It returns the service that found the preset
+// along with the status of the response.
+func retrieveH26xConfiguration(h264S *services.H264CodecConfigurationService, h265S *services.H265CodecConfigurationService, presetID string) (customDataRetriever, bitmovintypes.ResponseStatus, error) {
+	h264Resp, err := h264S.Retrieve(presetID)
+	if err == nil {
+		return h264S, h264Resp.Status, nil
+	}
+	h265Resp, err := h265S.Retrieve(presetID)
+	if err != nil {
+		return nil, "", err
+	}
+	return h265S, h265Resp.Status, nil
+}
+
+// retrieveVPxConfiguration looks the given preset up among the VP8 and VP9
+// codec configurations, which are both muxed to WebM along with Vorbis audio.
+func retrieveVPxConfiguration(vp8S *services.VP8CodecConfigurationService, vp9S *services.VP9CodecConfigurationService, presetID string) (customDataRetriever, bitmovintypes.ResponseStatus, error) {
+	vp8Resp, err := vp8S.Retrieve(presetID)
+	if err == nil {
+		return vp8S, vp8Resp.Status, nil
+	}
+	vp9Resp, err := vp9S.Retrieve(presetID)
+	if err != nil {
+		return nil, "", err
+	}
+	return vp9S, vp9Resp.Status, nil
+}
+
+// audioConfigurationIDFrom returns the ID of the audio configuration stored
+// in the custom data of a video preset.
+func audioConfigurationIDFrom(customData map[string]interface{}) (string, error) {
+	i, ok := customData["audio"]
+	if !ok {
+		return "", errors.New("no audio configuration found for video preset")
+	}
+	audioPresetID, ok := i.(string)
+	if !ok {
+		return "", errors.New("audio configuration somehow not a string")
+	}
+	return audioPresetID, nil
+}
+
+// retrieveAudioOnlyContainer returns the container of the audio-only preset
+// identified by the given AAC configuration ID. AAC configurations without a
+// container in their custom data belong to video presets and are rejected.
+func (p *bitmovinProvider) retrieveAudioOnlyContainer(aac *services.AACCodecConfigurationService, presetID string) (string, error) {
+	cdResp, err := aac.RetrieveCustomData(presetID)
+	if err != nil {
+		return "", err
+	}
+	if cdResp.Status == bitmovinAPIErrorMsg {
+		// the custom data call doesn't check the response status, so the
+		// error response is decoded as custom data
+		return "", models.BitmovinError{DataEnvelope: models.DataEnvelope{
+			Status: string(cdResp.Status),
+			Data:   models.DataEnvelopeData{Message: "error in retrieving custom data of audio-only preset"},
+		}}
+	}
+	containerInterface, ok := cdResp.Data.Result.CustomData["container"]
+	if !ok {
+		return "", errors.New("aac configuration is not an audio-only preset")
+	}
+	container, ok := containerInterface.(string)
+	if !ok {
+		return "", errors.New("container in custom data could not be converted into a string")
+	}
+	return container, nil
 }
 
 func (p *bitmovinProvider) GetPreset(presetID string) (interface{}, error) {
@@ -418,6 +956,42 @@ func (p *bitmovinProvider) GetPreset(presetID string) (interface{}, error) {
 		}
 		return nil, errors.New("no audio configuration found for video preset")
 	}
+	notFound := isAPIError(err)
+
+	h265 := services.NewH265CodecConfigurationService(p.client)
+	h265Response, err := h265.Retrieve(presetID)
+	if err == nil {
+		// It is H.265 and AAC
+		if h265Response.Status == bitmovinAPIErrorMsg {
+			return nil, errors.New("error in retrieving video portion of preset")
+		}
+		h265Config := h265Response.Data.Result
+		cd, err := h265.RetrieveCustomData(presetID)
+		if err != nil {
+			return nil, err
+		}
+		if cd.Status == bitmovinAPIErrorMsg {
+			return nil, errors.New("api error")
+		}
+		h265Config.CustomData = cd.Data.Result.CustomData
+		audioPresetID, err := audioConfigurationIDFrom(h265Config.CustomData)
+		if err != nil {
+			return nil, err
+		}
+		aac := services.NewAACCodecConfigurationService(p.client)
+		audioResponse, err := aac.Retrieve(audioPresetID)
+		if err != nil {
+			return nil, err
+		}
+		if audioResponse.Status == bitmovinAPIErrorMsg {
+			return nil, errors.New("error in retrieving audio portion of preset")
+		}
+		return bitmovinH265Preset{
+			Video: h265Config,
+			Audio: audioResponse.Data.Result,
+		}, nil
+	}
+	notFound = notFound && isAPIError(err)
 
 	vp8 := services.NewVP8CodecConfigurationService(p.client)
 	vp8Response, err := vp8.Retrieve(presetID)
@@ -460,16 +1034,150 @@ func (p *bitmovinProvider) GetPreset(presetID string) (interface{}, error) {
 			return preset, nil
 		}
 	}
+	notFound = notFound && isAPIError(err)
+
+	vp9 := services.NewVP9CodecConfigurationService(p.client)
+	vp9Response, err := vp9.Retrieve(presetID)
+	if err == nil {
+		// It is VP9 and Vorbis
+		if vp9Response.Status == bitmovinAPIErrorMsg {
+			return nil, errors.New("error in retrieving video portion of preset")
+		}
+		vp9Config := vp9Response.Data.Result
+		cd, err := vp9.RetrieveCustomData(presetID)
+		if err != nil {
+			return nil, err
+		}
+		if cd.Status == bitmovinAPIErrorMsg {
+			return nil, errors.New("api error")
+		}
+		vp9Config.CustomData = cd.Data.Result.CustomData
+		audioPresetID, err := audioConfigurationIDFrom(vp9Config.CustomData)
+		if err != nil {
+			return nil, err
+		}
+		vorbis := services.NewVorbisCodecConfigurationService(p.client)
+		audioResponse, err := vorbis.Retrieve(audioPresetID)
+		if err != nil {
+			return nil, err
+		}
+		if audioResponse.Status == bitmovinAPIErrorMsg {
+			return nil, errors.New("error in retrieving audio portion of preset")
+		}
+		return bitmovinVP9Preset{
+			Video: vp9Config,
+			Audio: audioResponse.Data.Result,
+		}, nil
+	}
+	notFound = notFound && isAPIError(err)
+
+	aac := services.NewAACCodecConfigurationService(p.client)
+	container, err := p.retrieveAudioOnlyContainer(aac, presetID)
+	if err == nil {
+		audioResponse, err := aac.Retrieve(presetID)
+		if err != nil {
+			return nil, err
+		}
+		if audioResponse.Status == bitmovinAPIErrorMsg {
+			return nil, errors.New("error in retrieving audio-only preset")
+		}
+		aacConfig := audioResponse.Data.Result
+		aacConfig.CustomData = map[string]interface{}{"container": container}
+		return bitmovinAudioOnlyPreset{Audio: aacConfig}, nil
+	}
+	if notFound && isAPIError(err) {
+		return nil, provider.PresetNotFoundError{ID: presetID}
+	}
 
 	return nil, errors.New("no audio configuration found for video preset")
 }
 
+// isAPIError tells whether the error is an error response of the Bitmovin
+// API, which is how lookups of IDs that don't belong to a configuration of
+// the given codec are answered. Network errors and malformed responses are
+// reported with other error types.
+func isAPIError(err error) bool {
+	var apiErr models.BitmovinError
+	return errors.As(err, &apiErr)
+}
+
+// NormalizePreset maps the codec configurations returned by GetPreset back
+// into the canonical preset fields. The container is read from the custom
+// data of the configurations.
+func (p *bitmovinProvider) NormalizePreset(native interface{}) (db.Preset, error) {
+	var preset db.Preset
+	switch native := native.(type) {
+	case bitmovinH264Preset:
+		preset = normalizedPreset(stringValue(native.Video.Name), native.Video.CustomData, db.VideoPreset{
+			Codec:   db.VideoCodecH264,
+			Width:   formatInt64(native.Video.Width),
+			Height:  formatInt64(native.Video.Height),
+			Bitrate: formatInt64(native.Video.Bitrate),
+			GopSize: formatInt64(native.Video.MaxGOP),
+		})
+		preset.Audio = db.AudioPreset{Codec: db.AudioCodecAAC, Bitrate: formatInt64(native.Audio.Bitrate)}
+	case bitmovinH265Preset:
+		preset = normalizedPreset(stringValue(native.Video.Name), native.Video.CustomData, db.VideoPreset{
+			Codec:   db.VideoCodecHEVC,
+			Width:   formatInt64(native.Video.Width),
+			Height:  formatInt64(native.Video.Height),
+			Bitrate: formatInt64(native.Video.Bitrate),
+			GopSize: formatInt64(native.Video.MaxGOP),
+		})
+		// HEVC HLS presets are stored with the cmaf container, so the
+		// container can't tell them apart
+		if preset.Container == "cmaf" {
+			preset.Container = ""
+		}
+		preset.Audio = db.AudioPreset{Codec: db.AudioCodecAAC, Bitrate: formatInt64(native.Audio.Bitrate)}
+	case bitmovinVP8Preset:
+		preset = normalizedPreset(stringValue(native.Video.Name), native.Video.CustomData, db.VideoPreset{
+			Codec:   db.VideoCodecVP8,
+			Width:   formatInt64(native.Video.Width),
+			Height:  formatInt64(native.Video.Height),
+			Bitrate: formatInt64(native.Video.Bitrate),
+		})
+		preset.Audio = db.AudioPreset{Codec: db.AudioCodecVorbis, Bitrate: formatInt64(native.Audio.Bitrate)}
+	case bitmovinVP9Preset:
+		preset = normalizedPreset(stringValue(native.Video.Name), native.Video.CustomData, db.VideoPreset{
+			Codec:   db.VideoCodecVP9,
+			Width:   formatInt64(native.Video.Width),
+			Height:  formatInt64(native.Video.Height),
+			Bitrate: formatInt64(native.Video.Bitrate),
+		})
+		preset.Audio = db.AudioPreset{Codec: db.AudioCodecVorbis, Bitrate: formatInt64(native.Audio.Bitrate)}
+	case bitmovinAudioOnlyPreset:
+		preset = normalizedPreset(stringValue(native.Audio.Name), native.Audio.CustomData, db.VideoPreset{})
+		preset.Audio = db.AudioPreset{Codec: db.AudioCodecAAC, Bitrate: formatInt64(native.Audio.Bitrate)}
+	default:
+		return db.Preset{}, fmt.Errorf("unexpected bitmovin preset type %T", native)
+	}
+	return preset, nil
+}
+
+func normalizedPreset(name string, customData map[string]interface{}, video db.VideoPreset) db.Preset {
+	container, _ := customData["container"].(string)
+	return db.Preset{Name: name, Container: container, Video: video}
+}
+
+func formatInt64(i *int64) string {
+	if i == nil {
+		return ""
+	}
+	return strconv.FormatInt(*i, 10)
+}
+
 func (p *bitmovinProvider) Transcode(job *db.Job) (*provider.JobStatus, error) {
 	aclEntry := models.ACLItem{
 		Permission: bitmovintypes.ACLPermissionPublicRead,
 	}
 	acl := []models.ACLItem{aclEntry}
 
+	hlsOptions := job.StreamingParams.HLSOptions()
+	if err := checkHLSOptions(hlsOptions); err != nil {
+		return nil, err
+	}
+
 	cloudRegion := bitmovintypes.AWSCloudRegion(p.config.AWSStorageRegion)
 	outputBucketName, prefix, err := parseS3URL(p.config.Destination)
 	if err != nil {
@@ -512,12 +1220,21 @@ func (p *bitmovinProvider) Transcode(job *db.Job) (*provider.JobStatus, error) {
 	viss := []models.InputStream{videoInputStream}
 	aiss := []models.InputStream{audioInputStream}
 
+	audioTrackInputStreams, err := audioTrackInputStreamsFrom(p, job.AudioTracks, inputID, inputFullPath)
+	if err != nil {
+		return nil, err
+	}
+
 	h264S := services.NewH264CodecConfigurationService(p.client)
+	h265S := services.NewH265CodecConfigurationService(p.client)
 	vp8S := services.NewVP8CodecConfigurationService(p.client)
+	vp9S := services.NewVP9CodecConfigurationService(p.client)
+	aacS := services.NewAACCodecConfigurationService(p.client)
 
 	var masterManifestPath string
 	var masterManifestFile string
 	outputtingHLS := false
+	outputtingCMAF := false
 	manifestID := ""
 
 	// create the master manifest if needed so we can add it to the customData of the encoding response
@@ -525,7 +1242,13 @@ func (p *bitmovinProvider) Transcode(job *db.Job) (*provider.JobStatus, error) {
 		if output.Preset.OutputOpts.Extension != "webm" {
 			videoPresetID := output.Preset.ProviderMapping[Name]
 			customDataResp, cdErr := h264S.RetrieveCustomData(videoPresetID)
-
+			if cdErr != nil {
+				customDataResp, cdErr = h265S.RetrieveCustomData(videoPresetID)
+			}
+			if cdErr != nil {
+				// audio-only presets are stored as AAC configurations
+				customDataResp, cdErr = aacS.RetrieveCustomData(videoPresetID)
+			}
 			if cdErr != nil {
 				return nil, cdErr
 			}
@@ -540,9 +1263,11 @@ func (p *bitmovinProvider) Transcode(job *db.Job) (*provider.JobStatus, error) {
 			if !ok {
 				return nil, errors.New("container in custom data could not be converted into a string")
 			}
-			if container == "m3u8" {
+			if container == "m3u8" || container == "cmaf" {
 				outputtingHLS = true
-				break
+			}
+			if container == "cmaf" {
+				outputtingCMAF = true
 			}
 		}
 	}
@@ -561,6 +1286,10 @@ func (p *bitmovinProvider) Transcode(job *db.Job) (*provider.JobStatus, error) {
 			ManifestName: stringToPtr(masterManifestFile),
 			Outputs:      []models.Output{manifestOutput},
 		}
+		if hlsOptions.TargetVersion != 0 {
+			hlsMasterManifest.HlsMasterPlaylistVersion = bitmovintypes.HlsMasterPlaylistVersion(hlsOptions.TargetVersion)
+			hlsMasterManifest.HlsMediaPlaylistVersion = bitmovintypes.HlsMediaPlaylistVersion(hlsOptions.TargetVersion)
+		}
 		hlsMasterManifestResp, manErr := hlsService.Create(hlsMasterManifest)
 		if manErr != nil {
 			return nil, manErr
@@ -570,11 +1299,56 @@ func (p *bitmovinProvider) Transcode(job *db.Job) (*provider.JobStatus, error) {
 		manifestID = *hlsMasterManifestResp.Data.Result.ID
 	}
 
+	dashService := services.NewDashManifestService(p.client)
+
+	// cmaf outputs share their fMP4 segments between the HLS master
+	// playlist and a DASH manifest written next to it
+	var dashManifestID, dashPeriodID, dashVideoAdaptationSetID string
+	dashAudioAdaptationSetIDs := make(map[string]string)
+	if outputtingCMAF {
+		manifestOutput := models.Output{
+			OutputID:   s3OSResponse.Data.Result.ID,
+			OutputPath: stringToPtr(masterManifestPath),
+			ACL:        acl,
+		}
+		dashManifest := &models.DashManifest{
+			ManifestName: stringToPtr(path.Base(job.StreamingParams.DASHManifestFileName())),
+			Outputs:      []models.Output{manifestOutput},
+			Profile:      bitmovintypes.DASHManifestProfileOnDemand,
+		}
+		dashManifestResp, manErr := dashService.Create(dashManifest)
+		if manErr != nil {
+			return nil, manErr
+		} else if dashManifestResp.Status == bitmovinAPIErrorMsg {
+			return nil, errors.New("error in DASH manifest creation")
+		}
+		dashManifestID = *dashManifestResp.Data.Result.ID
+
+		periodResp, periodErr := dashService.AddPeriod(dashManifestID, &models.Period{})
+		if periodErr != nil {
+			return nil, periodErr
+		} else if periodResp.Status == bitmovinAPIErrorMsg {
+			return nil, errors.New("error in adding DASH period")
+		}
+		dashPeriodID = *periodResp.Data.Result.ID
+
+		videoAdaptationSetResp, asErr := dashService.AddVideoAdaptationSet(dashManifestID, dashPeriodID, &models.VideoAdaptationSet{})
+		if asErr != nil {
+			return nil, asErr
+		} else if videoAdaptationSetResp.Status == bitmovinAPIErrorMsg {
+			return nil, errors.New("error in adding DASH video adaptation set")
+		}
+		dashVideoAdaptationSetID = *videoAdaptationSetResp.Data.Result.ID
+	}
+
 	encodingS := services.NewEncodingService(p.client)
 	customData := make(map[string]interface{})
 	if outputtingHLS {
 		customData["manifest"] = manifestID
 	}
+	if outputtingCMAF {
+		customData["dashManifest"] = dashManifestID
+	}
 	encodingRegion := bitmovintypes.CloudRegion(p.config.EncodingRegion)
 	encodingVersion := bitmovintypes.EncoderVersion(p.config.EncodingVersion)
 	encoding := &models.Encoding{
@@ -583,6 +1357,10 @@ func (p *bitmovinProvider) Transcode(job *db.Job) (*provider.JobStatus, error) {
 		CloudRegion:    encodingRegion,
 		EncoderVersion: encodingVersion,
 	}
+	err = provider.ApplyOverrides(encoding, job.ProviderOverrides.For(Name), true)
+	if err != nil {
+		return nil, err
+	}
 
 	encodingResp, err := encodingS.Create(encoding)
 	if err != nil {
@@ -595,14 +1373,18 @@ func (p *bitmovinProvider) Transcode(job *db.Job) (*provider.JobStatus, error) {
 	uniqueAudioMuxingStreams := make(map[string]models.StreamItem)
 	uniqueAudioStreamResps := make(map[string]*models.StreamResponse)
 
+	// per-title settings of content-aware jobs, set for each codec of the
+	// adaptive streaming outputs
+	var perTitle *models.PerTitle
+
 	for _, output := range job.Outputs {
 		videoPresetID := output.Preset.ProviderMapping[Name]
-		h264VideoResponse, h264Err := h264S.Retrieve(videoPresetID)
-		if h264Err == nil {
-			if h264VideoResponse.Status == bitmovinAPIErrorMsg {
+		videoCodecS, videoStatus, h26xErr := retrieveH26xConfiguration(h264S, h265S, videoPresetID)
+		if h26xErr == nil {
+			if videoStatus == bitmovinAPIErrorMsg {
 				return nil, errors.New("error in retrieving video portion of preset")
 			}
-			customDataResp, h264CDErr := h264S.RetrieveCustomData(videoPresetID)
+			customDataResp, h264CDErr := videoCodecS.RetrieveCustomData(videoPresetID)
 			if h264CDErr != nil {
 				return nil, h264CDErr
 			}
@@ -637,84 +1419,197 @@ func (p *bitmovinProvider) Transcode(job *db.Job) (*provider.JobStatus, error) {
 				if audioStreamResp.Status == bitmovinAPIErrorMsg {
 					return nil, errors.New("error in adding audio stream to encoding")
 				}
-				audioStreamID = *audioStreamResp.Data.Result.ID
+				audioStreamID = *audioStreamResp.Data.Result.ID
+
+				audioMuxingStream = models.StreamItem{
+					StreamID: &audioStreamID,
+				}
+
+				uniqueAudioMuxingStreams[audioPresetID] = audioMuxingStream
+				uniqueAudioStreamResps[audioPresetID] = audioStreamResp
+			}
+
+			videoStream := &models.Stream{
+				CodecConfigurationID: &videoPresetID,
+				InputStreams:         viss,
+			}
+			if c := customDataResp.Data.Result.CustomData["container"]; job.ContentAware && (c == "m3u8" || c == "cmaf") {
+				// per-title picks the bitrate of each rung and keeps its
+				// resolution, so muxings and manifests still reference
+				// a single stream per output
+				videoStream.Mode = bitmovintypes.StreamModePerTitleTemplateFixedResolution
+				if perTitle == nil {
+					perTitle = &models.PerTitle{}
+				}
+				if _, ok := videoCodecS.(*services.H265CodecConfigurationService); ok {
+					perTitle.H265Configuration = &models.H265PerTitleConfiguration{}
+				} else {
+					perTitle.H264Configuration = &models.H264PerTitleConfiguration{}
+				}
+			}
+			videoStreamResp, vsErr := encodingS.AddStream(*encodingResp.Data.Result.ID, videoStream)
+			if vsErr != nil {
+				return nil, vsErr
+			}
+			if videoStreamResp.Status == bitmovinAPIErrorMsg {
+				return nil, errors.New("error in adding video stream to encoding")
+			}
+			videoStreamID = *videoStreamResp.Data.Result.ID
+			if err = p.addDeinterlacingFilter(*encodingResp.Data.Result.ID, videoStreamID, customDataResp.Data.Result.CustomData); err != nil {
+				return nil, err
+			}
+
+			videoMuxingStream := models.StreamItem{
+				StreamID: &videoStreamID,
+			}
+
+			containerInterface, ok := customDataResp.Data.Result.CustomData["container"]
+			if !ok {
+				return nil, errors.New("container type not found in video custom data")
+			}
+			container, ok := containerInterface.(string)
+			if !ok {
+				return nil, errors.New("container type somehow not a string")
+			}
+			switch container {
+			case "m3u8":
+				if !isRepeatedAudio {
+					audioMuxingOutput := models.Output{
+						OutputID:   s3OSResponse.Data.Result.ID,
+						OutputPath: stringToPtr(path.Join(masterManifestPath, audioPresetID)),
+						ACL:        acl,
+					}
+					audioMuxingID, muxErr := addHLSMuxing(encodingS, *encodingResp.Data.Result.ID, job, hlsMuxing{
+						streams:        []models.StreamItem{uniqueAudioMuxingStreams[audioPresetID]},
+						output:         audioMuxingOutput,
+						conditionsMode: bitmovintypes.ConditionModeDropStream,
+						description:    "audio",
+					})
+					if muxErr != nil {
+						return nil, muxErr
+					}
+
+					// create the MediaInfo
+					audioMediaInfo := &models.MediaInfo{
+						Type:            bitmovintypes.MediaTypeAudio,
+						URI:             stringToPtr(audioPresetID + ".m3u8"),
+						GroupID:         stringToPtr(audioPresetID),
+						Language:        stringToPtr("en"),
+						Name:            stringToPtr(audioPresetID),
+						IsDefault:       boolToPtr(false),
+						Autoselect:      boolToPtr(false),
+						Forced:          boolToPtr(false),
+						SegmentPath:     stringToPtr(audioPresetID),
+						Characteristics: []string{"public.accessibility.describes-video"},
+						EncodingID:      encodingResp.Data.Result.ID,
+						StreamID:        uniqueAudioStreamResps[audioPresetID].Data.Result.ID,
+						MuxingID:        audioMuxingID,
+					}
+
+					// Add to Master manifest, we will set the m3u8 and segments relative to the master
+
+					audioMediaInfoResp, miErr := hlsService.AddMediaInfo(manifestID, audioMediaInfo)
+					if miErr != nil {
+						return nil, miErr
+					}
+					if audioMediaInfoResp.Status == bitmovinAPIErrorMsg {
+						return nil, errors.New("error in adding EXT-X-MEDIA")
+					}
+
+					altErr := p.addAlternateAudioTracks(job, audioTrackInputStreams, audioPresetID, container, alternateAudioTarget{
+						encodingID:    encodingResp.Data.Result.ID,
+						outputID:      s3OSResponse.Data.Result.ID,
+						acl:           acl,
+						manifestPath:  masterManifestPath,
+						hlsManifestID: manifestID,
+					})
+					if altErr != nil {
+						return nil, altErr
+					}
+				}
+
+				videoMuxingOutput := models.Output{
+					OutputID:   s3OSResponse.Data.Result.ID,
+					OutputPath: stringToPtr(path.Join(masterManifestPath, videoPresetID)),
+					ACL:        acl,
+				}
+				videoMuxingID, vmuxErr := addHLSMuxing(encodingS, *encodingResp.Data.Result.ID, job, hlsMuxing{
+					streams:     []models.StreamItem{videoMuxingStream},
+					output:      videoMuxingOutput,
+					description: "video",
+				})
+				if vmuxErr != nil {
+					return nil, vmuxErr
+				}
+
+				videoManifestURI, err := filepath.Rel(masterManifestPath, path.Join(prefix, output.FileName))
+				if err != nil {
+					return nil, err
+				}
+				videoSegmentPath, err := filepath.Rel(path.Dir(path.Join(prefix, output.FileName)), path.Join(masterManifestPath, videoPresetID))
+				if err != nil {
+					return nil, err
+				}
+
+				videoStreamInfo := &models.StreamInfo{
+					Audio:       stringToPtr(audioPresetID),
+					SegmentPath: stringToPtr(videoSegmentPath),
+					URI:         stringToPtr(videoManifestURI),
+					EncodingID:  encodingResp.Data.Result.ID,
+					StreamID:    videoStreamResp.Data.Result.ID,
+					MuxingID:    videoMuxingID,
+				}
 
-				audioMuxingStream = models.StreamItem{
-					StreamID: &audioStreamID,
+				videoStreamInfoResp, vsiErr := hlsService.AddStreamInfo(manifestID, videoStreamInfo)
+				if vsiErr != nil {
+					return nil, vsiErr
 				}
-
-				uniqueAudioMuxingStreams[audioPresetID] = audioMuxingStream
-				uniqueAudioStreamResps[audioPresetID] = audioStreamResp
-			}
-
-			videoStream := &models.Stream{
-				CodecConfigurationID: &videoPresetID,
-				InputStreams:         viss,
-			}
-			videoStreamResp, vsErr := encodingS.AddStream(*encodingResp.Data.Result.ID, videoStream)
-			if vsErr != nil {
-				return nil, vsErr
-			}
-			if videoStreamResp.Status == bitmovinAPIErrorMsg {
-				return nil, errors.New("error in adding video stream to encoding")
-			}
-			videoStreamID = *videoStreamResp.Data.Result.ID
-
-			videoMuxingStream := models.StreamItem{
-				StreamID: &videoStreamID,
-			}
-
-			containerInterface, ok := customDataResp.Data.Result.CustomData["container"]
-			if !ok {
-				return nil, errors.New("container type not found in video custom data")
-			}
-			container, ok := containerInterface.(string)
-			if !ok {
-				return nil, errors.New("container type somehow not a string")
-			}
-			switch container {
-			case "m3u8":
+				if videoStreamInfoResp.Status == bitmovinAPIErrorMsg {
+					return nil, errors.New("error in adding EXT-X-STREAM-INF")
+				}
+				if hlsOptions.IFramePlaylist {
+					ifErr := addIFramePlaylist(hlsService, manifestID, videoStreamInfoResp, videoManifestURI)
+					if ifErr != nil {
+						return nil, ifErr
+					}
+				}
+			case "cmaf":
 				if !isRepeatedAudio {
 					audioMuxingOutput := models.Output{
 						OutputID:   s3OSResponse.Data.Result.ID,
 						OutputPath: stringToPtr(path.Join(masterManifestPath, audioPresetID)),
 						ACL:        acl,
 					}
-					audioMuxing := &models.TSMuxing{
+					audioMuxing := &models.FMP4Muxing{
 						SegmentLength:        floatToPtr(float64(job.StreamingParams.SegmentDuration)),
-						SegmentNaming:        stringToPtr("seg_%number%.ts"),
+						SegmentNaming:        stringToPtr("seg_%number%.m4s"),
+						InitSegmentName:      stringToPtr("init.mp4"),
 						Streams:              []models.StreamItem{uniqueAudioMuxingStreams[audioPresetID]},
 						Outputs:              []models.Output{audioMuxingOutput},
 						StreamConditionsMode: bitmovintypes.ConditionModeDropStream,
 					}
-
-					audioMuxingResp, muxErr := encodingS.AddTSMuxing(*encodingResp.Data.Result.ID, audioMuxing)
+					audioMuxingResp, muxErr := encodingS.AddFMP4Muxing(*encodingResp.Data.Result.ID, audioMuxing)
 					if muxErr != nil {
 						return nil, muxErr
 					}
 					if audioMuxingResp.Status == bitmovinAPIErrorMsg {
-						return nil, errors.New("error in adding ts muxing for audio")
+						return nil, errors.New("error in adding fmp4 muxing for audio")
 					}
 
-					// create the MediaInfo
 					audioMediaInfo := &models.MediaInfo{
-						Type:            bitmovintypes.MediaTypeAudio,
-						URI:             stringToPtr(audioPresetID + ".m3u8"),
-						GroupID:         stringToPtr(audioPresetID),
-						Language:        stringToPtr("en"),
-						Name:            stringToPtr(audioPresetID),
-						IsDefault:       boolToPtr(false),
-						Autoselect:      boolToPtr(false),
-						Forced:          boolToPtr(false),
-						SegmentPath:     stringToPtr(audioPresetID),
-						Characteristics: []string{"public.accessibility.describes-video"},
-						EncodingID:      encodingResp.Data.Result.ID,
-						StreamID:        uniqueAudioStreamResps[audioPresetID].Data.Result.ID,
-						MuxingID:        audioMuxingResp.Data.Result.ID,
+						Type:        bitmovintypes.MediaTypeAudio,
+						URI:         stringToPtr(audioPresetID + ".m3u8"),
+						GroupID:     stringToPtr(audioPresetID),
+						Language:    stringToPtr("en"),
+						Name:        stringToPtr(audioPresetID),
+						IsDefault:   boolToPtr(false),
+						Autoselect:  boolToPtr(false),
+						Forced:      boolToPtr(false),
+						SegmentPath: stringToPtr(audioPresetID),
+						EncodingID:  encodingResp.Data.Result.ID,
+						StreamID:    uniqueAudioStreamResps[audioPresetID].Data.Result.ID,
+						MuxingID:    audioMuxingResp.Data.Result.ID,
 					}
-
-					// Add to Master manifest, we will set the m3u8 and segments relative to the master
-
 					audioMediaInfoResp, miErr := hlsService.AddMediaInfo(manifestID, audioMediaInfo)
 					if miErr != nil {
 						return nil, miErr
@@ -722,6 +1617,43 @@ func (p *bitmovinProvider) Transcode(job *db.Job) (*provider.JobStatus, error) {
 					if audioMediaInfoResp.Status == bitmovinAPIErrorMsg {
 						return nil, errors.New("error in adding EXT-X-MEDIA")
 					}
+
+					audioAdaptationSetResp, asErr := dashService.AddAudioAdaptationSet(dashManifestID, dashPeriodID, &models.AudioAdaptationSet{
+						Language: stringToPtr("en"),
+					})
+					if asErr != nil {
+						return nil, asErr
+					}
+					if audioAdaptationSetResp.Status == bitmovinAPIErrorMsg {
+						return nil, errors.New("error in adding DASH audio adaptation set")
+					}
+					dashAudioAdaptationSetIDs[audioPresetID] = *audioAdaptationSetResp.Data.Result.ID
+
+					audioRepresentationResp, repErr := dashService.AddFMP4Representation(dashManifestID, dashPeriodID, dashAudioAdaptationSetIDs[audioPresetID], &models.FMP4Representation{
+						Type:        bitmovintypes.FMP4RepresentationTypeTemplate,
+						MuxingID:    audioMuxingResp.Data.Result.ID,
+						EncodingID:  encodingResp.Data.Result.ID,
+						SegmentPath: stringToPtr(audioPresetID),
+					})
+					if repErr != nil {
+						return nil, repErr
+					}
+					if audioRepresentationResp.Status == bitmovinAPIErrorMsg {
+						return nil, errors.New("error in adding DASH audio representation")
+					}
+
+					altErr := p.addAlternateAudioTracks(job, audioTrackInputStreams, audioPresetID, container, alternateAudioTarget{
+						encodingID:     encodingResp.Data.Result.ID,
+						outputID:       s3OSResponse.Data.Result.ID,
+						acl:            acl,
+						manifestPath:   masterManifestPath,
+						hlsManifestID:  manifestID,
+						dashManifestID: dashManifestID,
+						dashPeriodID:   dashPeriodID,
+					})
+					if altErr != nil {
+						return nil, altErr
+					}
 				}
 
 				videoMuxingOutput := models.Output{
@@ -729,25 +1661,27 @@ func (p *bitmovinProvider) Transcode(job *db.Job) (*provider.JobStatus, error) {
 					OutputPath: stringToPtr(path.Join(masterManifestPath, videoPresetID)),
 					ACL:        acl,
 				}
-				videoMuxing := &models.TSMuxing{
-					SegmentLength: floatToPtr(float64(job.StreamingParams.SegmentDuration)),
-					SegmentNaming: stringToPtr("seg_%number%.ts"),
-					Streams:       []models.StreamItem{videoMuxingStream},
-					Outputs:       []models.Output{videoMuxingOutput},
+				videoMuxing := &models.FMP4Muxing{
+					SegmentLength:   floatToPtr(float64(job.StreamingParams.SegmentDuration)),
+					SegmentNaming:   stringToPtr("seg_%number%.m4s"),
+					InitSegmentName: stringToPtr("init.mp4"),
+					Streams:         []models.StreamItem{videoMuxingStream},
+					Outputs:         []models.Output{videoMuxingOutput},
 				}
-				videoMuxingResp, vmuxErr := encodingS.AddTSMuxing(*encodingResp.Data.Result.ID, videoMuxing)
+				videoMuxingResp, vmuxErr := encodingS.AddFMP4Muxing(*encodingResp.Data.Result.ID, videoMuxing)
 				if vmuxErr != nil {
 					return nil, vmuxErr
 				}
 				if videoMuxingResp.Status == bitmovinAPIErrorMsg {
-					return nil, errors.New("error in adding ts muxing for video")
+					return nil, errors.New("error in adding fmp4 muxing for video")
 				}
 
-				videoManifestURI, err := filepath.Rel(masterManifestPath, path.Join(prefix, output.FileName))
+				videoPlaylistName := strings.TrimSuffix(output.FileName, path.Ext(output.FileName)) + ".m3u8"
+				videoManifestURI, err := filepath.Rel(masterManifestPath, path.Join(prefix, videoPlaylistName))
 				if err != nil {
 					return nil, err
 				}
-				videoSegmentPath, err := filepath.Rel(path.Dir(path.Join(prefix, output.FileName)), path.Join(masterManifestPath, videoPresetID))
+				videoSegmentPath, err := filepath.Rel(path.Dir(path.Join(prefix, videoPlaylistName)), path.Join(masterManifestPath, videoPresetID))
 				if err != nil {
 					return nil, err
 				}
@@ -760,7 +1694,6 @@ func (p *bitmovinProvider) Transcode(job *db.Job) (*provider.JobStatus, error) {
 					StreamID:    videoStreamResp.Data.Result.ID,
 					MuxingID:    videoMuxingResp.Data.Result.ID,
 				}
-
 				videoStreamInfoResp, vsiErr := hlsService.AddStreamInfo(manifestID, videoStreamInfo)
 				if vsiErr != nil {
 					return nil, vsiErr
@@ -768,6 +1701,25 @@ func (p *bitmovinProvider) Transcode(job *db.Job) (*provider.JobStatus, error) {
 				if videoStreamInfoResp.Status == bitmovinAPIErrorMsg {
 					return nil, errors.New("error in adding EXT-X-STREAM-INF")
 				}
+				if hlsOptions.IFramePlaylist {
+					ifErr := addIFramePlaylist(hlsService, manifestID, videoStreamInfoResp, videoManifestURI)
+					if ifErr != nil {
+						return nil, ifErr
+					}
+				}
+
+				videoRepresentationResp, repErr := dashService.AddFMP4Representation(dashManifestID, dashPeriodID, dashVideoAdaptationSetID, &models.FMP4Representation{
+					Type:        bitmovintypes.FMP4RepresentationTypeTemplate,
+					MuxingID:    videoMuxingResp.Data.Result.ID,
+					EncodingID:  encodingResp.Data.Result.ID,
+					SegmentPath: stringToPtr(videoPresetID),
+				})
+				if repErr != nil {
+					return nil, repErr
+				}
+				if videoRepresentationResp.Status == bitmovinAPIErrorMsg {
+					return nil, errors.New("error in adding DASH video representation")
+				}
 			case "mp4":
 				videoMuxingOutput := models.Output{
 					OutputID:   s3OSResponse.Data.Result.ID,
@@ -810,14 +1762,14 @@ func (p *bitmovinProvider) Transcode(job *db.Job) (*provider.JobStatus, error) {
 				return nil, errors.New("unknown container format")
 			}
 		} else {
-			vp8VideoResponse, vp8Err := vp8S.Retrieve(videoPresetID)
-			if vp8Err == nil {
-				if vp8VideoResponse.Status == bitmovinAPIErrorMsg {
+			vpxCodecS, vpxStatus, vpxErr := retrieveVPxConfiguration(vp8S, vp9S, videoPresetID)
+			if vpxErr == nil {
+				if vpxStatus == bitmovinAPIErrorMsg {
 					return nil, errors.New("error in retrieving video portion of preset")
 				}
-				customDataResp, vp8CDErr := vp8S.RetrieveCustomData(videoPresetID)
-				if vp8CDErr != nil {
-					return nil, vp8CDErr
+				customDataResp, vpxCDErr := vpxCodecS.RetrieveCustomData(videoPresetID)
+				if vpxCDErr != nil {
+					return nil, vpxCDErr
 				}
 				if customDataResp.Status == bitmovinAPIErrorMsg {
 					return nil, errors.New("error in retrieving video custom data where the audio id and container type is stored")
@@ -869,6 +1821,9 @@ func (p *bitmovinProvider) Transcode(job *db.Job) (*provider.JobStatus, error) {
 					return nil, errors.New("error in adding video stream to encoding")
 				}
 				videoStreamID = *videoStreamResp.Data.Result.ID
+				if err = p.addDeinterlacingFilter(*encodingResp.Data.Result.ID, videoStreamID, customDataResp.Data.Result.CustomData); err != nil {
+					return nil, err
+				}
 
 				videoMuxingStream := models.StreamItem{
 					StreamID: &videoStreamID,
@@ -882,7 +1837,7 @@ func (p *bitmovinProvider) Transcode(job *db.Job) (*provider.JobStatus, error) {
 					return nil, errors.New("container type somehow not a string")
 				}
 				if container != "webm" {
-					return nil, errors.New("unknown container for vp8 encoding")
+					return nil, errors.New("unknown container for vp8/vp9 encoding")
 				}
 				videoMuxingOutput := models.Output{
 					OutputID:   s3OSResponse.Data.Result.ID,
@@ -902,8 +1857,130 @@ func (p *bitmovinProvider) Transcode(job *db.Job) (*provider.JobStatus, error) {
 				if videoMuxingResp.Status == bitmovinAPIErrorMsg {
 					return nil, errors.New("error in adding mp4 muxing")
 				}
+			} else if container, aacErr := p.retrieveAudioOnlyContainer(aacS, videoPresetID); aacErr == nil {
+				audioOnlyStream := &models.Stream{
+					CodecConfigurationID: &videoPresetID,
+					InputStreams:         aiss,
+				}
+				audioOnlyStreamResp, asErr := encodingS.AddStream(*encodingResp.Data.Result.ID, audioOnlyStream)
+				if asErr != nil {
+					return nil, asErr
+				}
+				if audioOnlyStreamResp.Status == bitmovinAPIErrorMsg {
+					return nil, errors.New("error in adding audio stream to encoding")
+				}
+				audioOnlyMuxingStream := models.StreamItem{
+					StreamID: audioOnlyStreamResp.Data.Result.ID,
+				}
+
+				switch container {
+				case "m3u8", "cmaf":
+					audioOnlyMuxingOutput := models.Output{
+						OutputID:   s3OSResponse.Data.Result.ID,
+						OutputPath: stringToPtr(path.Join(masterManifestPath, videoPresetID)),
+						ACL:        acl,
+					}
+					var audioOnlyMuxingID *string
+					playlistName := output.FileName
+					if container == "m3u8" {
+						var muxErr error
+						audioOnlyMuxingID, muxErr = addHLSMuxing(encodingS, *encodingResp.Data.Result.ID, job, hlsMuxing{
+							streams:     []models.StreamItem{audioOnlyMuxingStream},
+							output:      audioOnlyMuxingOutput,
+							description: "audio-only output",
+						})
+						if muxErr != nil {
+							return nil, muxErr
+						}
+					} else {
+						audioOnlyMuxing := &models.FMP4Muxing{
+							SegmentLength:   floatToPtr(float64(job.StreamingParams.SegmentDuration)),
+							SegmentNaming:   stringToPtr("seg_%number%.m4s"),
+							InitSegmentName: stringToPtr("init.mp4"),
+							Streams:         []models.StreamItem{audioOnlyMuxingStream},
+							Outputs:         []models.Output{audioOnlyMuxingOutput},
+						}
+						audioOnlyMuxingResp, muxErr := encodingS.AddFMP4Muxing(*encodingResp.Data.Result.ID, audioOnlyMuxing)
+						if muxErr != nil {
+							return nil, muxErr
+						}
+						if audioOnlyMuxingResp.Status == bitmovinAPIErrorMsg {
+							return nil, errors.New("error in adding fmp4 muxing for audio-only output")
+						}
+						audioOnlyMuxingID = audioOnlyMuxingResp.Data.Result.ID
+						playlistName = strings.TrimSuffix(output.FileName, path.Ext(output.FileName)) + ".m3u8"
+
+						audioAdaptationSetResp, adErr := dashService.AddAudioAdaptationSet(dashManifestID, dashPeriodID, &models.AudioAdaptationSet{
+							Language: stringToPtr("en"),
+						})
+						if adErr != nil {
+							return nil, adErr
+						}
+						if audioAdaptationSetResp.Status == bitmovinAPIErrorMsg {
+							return nil, errors.New("error in adding DASH audio adaptation set")
+						}
+						audioRepresentationResp, repErr := dashService.AddFMP4Representation(dashManifestID, dashPeriodID, *audioAdaptationSetResp.Data.Result.ID, &models.FMP4Representation{
+							Type:        bitmovintypes.FMP4RepresentationTypeTemplate,
+							MuxingID:    audioOnlyMuxingID,
+							EncodingID:  encodingResp.Data.Result.ID,
+							SegmentPath: stringToPtr(videoPresetID),
+						})
+						if repErr != nil {
+							return nil, repErr
+						}
+						if audioRepresentationResp.Status == bitmovinAPIErrorMsg {
+							return nil, errors.New("error in adding DASH audio representation")
+						}
+					}
+
+					audioOnlyManifestURI, err := filepath.Rel(masterManifestPath, path.Join(prefix, playlistName))
+					if err != nil {
+						return nil, err
+					}
+					audioOnlySegmentPath, err := filepath.Rel(path.Dir(path.Join(prefix, playlistName)), path.Join(masterManifestPath, videoPresetID))
+					if err != nil {
+						return nil, err
+					}
+
+					// audio-only renditions are listed as variants in
+					// the master playlist, without an audio group
+					audioOnlyStreamInfo := &models.StreamInfo{
+						SegmentPath: stringToPtr(audioOnlySegmentPath),
+						URI:         stringToPtr(audioOnlyManifestURI),
+						EncodingID:  encodingResp.Data.Result.ID,
+						StreamID:    audioOnlyStreamResp.Data.Result.ID,
+						MuxingID:    audioOnlyMuxingID,
+					}
+					audioOnlyStreamInfoResp, asiErr := hlsService.AddStreamInfo(manifestID, audioOnlyStreamInfo)
+					if asiErr != nil {
+						return nil, asiErr
+					}
+					if audioOnlyStreamInfoResp.Status == bitmovinAPIErrorMsg {
+						return nil, errors.New("error in adding EXT-X-STREAM-INF")
+					}
+				case "mp4":
+					audioOnlyMuxingOutput := models.Output{
+						OutputID:   s3OSResponse.Data.Result.ID,
+						ACL:        acl,
+						OutputPath: stringToPtr(path.Dir(path.Join(prefix, output.FileName))),
+					}
+					audioOnlyMuxing := &models.MP4Muxing{
+						Filename: stringToPtr(path.Base(output.FileName)),
+						Outputs:  []models.Output{audioOnlyMuxingOutput},
+						Streams:  []models.StreamItem{audioOnlyMuxingStream},
+					}
+					audioOnlyMuxingResp, amErr := encodingS.AddMP4Muxing(*encodingResp.Data.Result.ID, audioOnlyMuxing)
+					if amErr != nil {
+						return nil, amErr
+					}
+					if audioOnlyMuxingResp.Status == bitmovinAPIErrorMsg {
+						return nil, errors.New("error in adding mp4 muxing")
+					}
+				default:
+					return nil, errors.New("unknown container for audio-only encoding")
+				}
 			} else {
-				return nil, errors.New("no h264 or vp8 codec configuration found")
+				return nil, errors.New("no h264, h265, vp8, vp9 or aac codec configuration found")
 			}
 		}
 	}
@@ -914,6 +1991,10 @@ func (p *bitmovinProvider) Transcode(job *db.Job) (*provider.JobStatus, error) {
 		}
 		startOptions := &models.StartOptions{
 			VodHlsManifests: []models.VodHlsManifest{vodHLSManifest},
+			PerTitle:        perTitle,
+		}
+		if outputtingCMAF {
+			startOptions.VodDashManifests = []models.VodDashManifest{{ManifestID: dashManifestID}}
 		}
 		startResp, err = encodingS.StartWithOptions(*encodingResp.Data.Result.ID, startOptions)
 		if err != nil {
@@ -939,6 +2020,253 @@ func (p *bitmovinProvider) Transcode(job *db.Job) (*provider.JobStatus, error) {
 	return jobStatus, nil
 }
 
+// audioTrackInputStreamsFrom returns the input streams of each alternate
+// audio track, creating the inputs of tracks taken from separate files.
+func audioTrackInputStreamsFrom(p *bitmovinProvider, tracks []db.AudioTrack, sourceInputID, sourceInputPath string) ([][]models.InputStream, error) {
+	inputStreams := make([][]models.InputStream, len(tracks))
+	for i, track := range tracks {
+		if track.SourceMedia != "" {
+			inputID, inputPath, err := createInput(p, track.SourceMedia)
+			if err != nil {
+				return nil, err
+			}
+			inputStreams[i] = []models.InputStream{{
+				InputID:       stringToPtr(inputID),
+				InputPath:     stringToPtr(inputPath),
+				SelectionMode: bitmovintypes.SelectionModeAuto,
+			}}
+			continue
+		}
+		inputStreams[i] = []models.InputStream{{
+			InputID:       stringToPtr(sourceInputID),
+			InputPath:     stringToPtr(sourceInputPath),
+			SelectionMode: bitmovintypes.SelectionModeAudioRelative,
+			Position:      intToPtr(int64(track.TrackIndex) - 1),
+		}}
+	}
+	return inputStreams, nil
+}
+
+// alternateAudioTarget describes where the alternate audio tracks of an
+// audio group are written and listed.
+type alternateAudioTarget struct {
+	encodingID     *string
+	outputID       *string
+	acl            []models.ACLItem
+	manifestPath   string
+	hlsManifestID  string
+	dashManifestID string
+	dashPeriodID   string
+}
+
+// checkHLSOptions rejects the HLS options that can't be honored by Bitmovin
+// VOD manifests.
+func checkHLSOptions(options db.HLSOptions) error {
+	switch {
+	case options.SingleFile:
+		return provider.HLSOptionNotSupportedError{Option: "singleFile"}
+	case options.ProgramDateTime:
+		return provider.HLSOptionNotSupportedError{Option: "programDateTime"}
+	case strings.EqualFold(options.PlaylistType, db.HLSPlaylistTypeEvent):
+		return provider.HLSOptionNotSupportedError{Option: "playlistType=" + db.HLSPlaylistTypeEvent}
+	case options.TargetVersion > uint(bitmovintypes.HlsMasterPlaylistVersion8):
+		return provider.HLSOptionNotSupportedError{Option: fmt.Sprintf("targetVersion=%d", options.TargetVersion)}
+	}
+	return nil
+}
+
+type hlsMuxing struct {
+	streams        []models.StreamItem
+	output         models.Output
+	conditionsMode bitmovintypes.ConditionMode
+	description    string
+}
+
+// addHLSMuxing adds the muxing of the segments of a rendition of a hls
+// output, using fMP4 segments when the HLS options of the job ask for them
+// and MPEG-TS segments otherwise. It returns the ID of the muxing.
+func addHLSMuxing(encodingS *services.EncodingService, encodingID string, job *db.Job, muxing hlsMuxing) (*string, error) {
+	segmentLength := floatToPtr(float64(job.StreamingParams.SegmentDuration))
+	if job.StreamingParams.HLSOptions().FMP4() {
+		muxingResp, err := encodingS.AddFMP4Muxing(encodingID, &models.FMP4Muxing{
+			SegmentLength:        segmentLength,
+			SegmentNaming:        stringToPtr("seg_%number%.m4s"),
+			InitSegmentName:      stringToPtr("init.mp4"),
+			Streams:              muxing.streams,
+			Outputs:              []models.Output{muxing.output},
+			StreamConditionsMode: muxing.conditionsMode,
+		})
+		if err != nil {
+			return nil, err
+		}
+		if muxingResp.Status == bitmovinAPIErrorMsg {
+			return nil, errors.New("error in adding fmp4 muxing for " + muxing.description)
+		}
+		return muxingResp.Data.Result.ID, nil
+	}
+	muxingResp, err := encodingS.AddTSMuxing(encodingID, &models.TSMuxing{
+		SegmentLength:        segmentLength,
+		SegmentNaming:        stringToPtr("seg_%number%.ts"),
+		Streams:              muxing.streams,
+		Outputs:              []models.Output{muxing.output},
+		StreamConditionsMode: muxing.conditionsMode,
+	})
+	if err != nil {
+		return nil, err
+	}
+	if muxingResp.Status == bitmovinAPIErrorMsg {
+		return nil, errors.New("error in adding ts muxing for " + muxing.description)
+	}
+	return muxingResp.Data.Result.ID, nil
+}
+
+// addIFramePlaylist adds an I-frame only playlist to the given
+// EXT-X-STREAM-INF, written next to the media playlist of the rendition.
+func addIFramePlaylist(hlsService *services.HLSManifestService, manifestID string, streamInfoResp *models.StreamInfoResponse, playlistURI string) error {
+	playlistName := path.Base(playlistURI)
+	iframeResp, err := hlsService.AddIFramePlaylistToStreamInfo(manifestID, stringValue(streamInfoResp.Data.Result.ID), &models.IFramePlaylist{
+		Filename: stringToPtr(strings.TrimSuffix(playlistName, path.Ext(playlistName)) + "_iframes.m3u8"),
+	})
+	if err != nil {
+		return err
+	}
+	if iframeResp.Status == bitmovinAPIErrorMsg {
+		return errors.New("error in adding EXT-X-I-FRAME-STREAM-INF")
+	}
+	return nil
+}
+
+// addAlternateAudioTracks encodes each alternate audio track of the job with
+// the given audio configuration, listing them as EXT-X-MEDIA entries of the
+// audio group and, for cmaf, as DASH audio adaptation sets.
+func (p *bitmovinProvider) addAlternateAudioTracks(job *db.Job, inputStreams [][]models.InputStream, audioPresetID string, container string, target alternateAudioTarget) error {
+	encodingS := services.NewEncodingService(p.client)
+	hlsService := services.NewHLSManifestService(p.client)
+	dashService := services.NewDashManifestService(p.client)
+	for i, track := range job.AudioTracks {
+		streamResp, err := encodingS.AddStream(*target.encodingID, &models.Stream{
+			CodecConfigurationID: stringToPtr(audioPresetID),
+			InputStreams:         inputStreams[i],
+		})
+		if err != nil {
+			return err
+		}
+		if streamResp.Status == bitmovinAPIErrorMsg {
+			return errors.New("error in adding alternate audio stream to encoding")
+		}
+
+		segmentPath := fmt.Sprintf("%s_%s_%d", audioPresetID, track.Language, i+1)
+		muxingOutput := models.Output{
+			OutputID:   target.outputID,
+			OutputPath: stringToPtr(path.Join(target.manifestPath, segmentPath)),
+			ACL:        target.acl,
+		}
+		streams := []models.StreamItem{{StreamID: streamResp.Data.Result.ID}}
+		var muxingID *string
+		if container == "cmaf" {
+			muxingResp, muxErr := encodingS.AddFMP4Muxing(*target.encodingID, &models.FMP4Muxing{
+				SegmentLength:   floatToPtr(float64(job.StreamingParams.SegmentDuration)),
+				SegmentNaming:   stringToPtr("seg_%number%.m4s"),
+				InitSegmentName: stringToPtr("init.mp4"),
+				Streams:         streams,
+				Outputs:         []models.Output{muxingOutput},
+			})
+			if muxErr != nil {
+				return muxErr
+			}
+			if muxingResp.Status == bitmovinAPIErrorMsg {
+				return errors.New("error in adding fmp4 muxing for alternate audio")
+			}
+			muxingID = muxingResp.Data.Result.ID
+		} else {
+			var muxErr error
+			muxingID, muxErr = addHLSMuxing(encodingS, *target.encodingID, job, hlsMuxing{
+				streams:     streams,
+				output:      muxingOutput,
+				description: "alternate audio",
+			})
+			if muxErr != nil {
+				return muxErr
+			}
+		}
+
+		mediaInfoResp, err := hlsService.AddMediaInfo(target.hlsManifestID, &models.MediaInfo{
+			Type:        bitmovintypes.MediaTypeAudio,
+			URI:         stringToPtr(segmentPath + ".m3u8"),
+			GroupID:     stringToPtr(audioPresetID),
+			Language:    stringToPtr(track.Language),
+			Name:        stringToPtr(track.DisplayName()),
+			IsDefault:   boolToPtr(track.Default),
+			Autoselect:  boolToPtr(true),
+			Forced:      boolToPtr(false),
+			SegmentPath: stringToPtr(segmentPath),
+			EncodingID:  target.encodingID,
+			StreamID:    streamResp.Data.Result.ID,
+			MuxingID:    muxingID,
+		})
+		if err != nil {
+			return err
+		}
+		if mediaInfoResp.Status == bitmovinAPIErrorMsg {
+			return errors.New("error in adding EXT-X-MEDIA for alternate audio")
+		}
+
+		if container != "cmaf" {
+			continue
+		}
+		adaptationSetResp, err := dashService.AddAudioAdaptationSet(target.dashManifestID, target.dashPeriodID, &models.AudioAdaptationSet{
+			Language: stringToPtr(track.Language),
+		})
+		if err != nil {
+			return err
+		}
+		if adaptationSetResp.Status == bitmovinAPIErrorMsg {
+			return errors.New("error in adding DASH audio adaptation set for alternate audio")
+		}
+		representationResp, err := dashService.AddFMP4Representation(target.dashManifestID, target.dashPeriodID, *adaptationSetResp.Data.Result.ID, &models.FMP4Representation{
+			Type:        bitmovintypes.FMP4RepresentationTypeTemplate,
+			MuxingID:    muxingID,
+			EncodingID:  target.encodingID,
+			SegmentPath: stringToPtr(segmentPath),
+		})
+		if err != nil {
+			return err
+		}
+		if representationResp.Status == bitmovinAPIErrorMsg {
+			return errors.New("error in adding DASH representation for alternate audio")
+		}
+	}
+	return nil
+}
+
+// addDeinterlacingFilter deinterlaces the given video stream when the preset
+// asked for it, as recorded in the custom data of the video configuration.
+func (p *bitmovinProvider) addDeinterlacingFilter(encodingID, streamID string, customData map[string]interface{}) error {
+	if deinterlacer, _ := customData["deinterlacer"].(string); deinterlacer != db.DeinterlacerDeinterlace {
+		return nil
+	}
+	filterS := services.NewFilterService(p.client)
+	filterResp, err := filterS.CreateDeinterlacingFilter(&models.DeinterlacingFilter{
+		Mode:   bitmovintypes.DeinterlacingModeFrame,
+		Parity: bitmovintypes.PictureFieldParityAuto,
+	})
+	if err != nil {
+		return err
+	}
+	if filterResp.Status == bitmovinAPIErrorMsg {
+		return errors.New("error in creating deinterlacing filter")
+	}
+	encodingS := services.NewEncodingService(p.client)
+	addResp, err := encodingS.AddFilter(encodingID, streamID, *filterResp.Data.Result.ID, 0)
+	if err != nil {
+		return err
+	}
+	if addResp.Status == bitmovinAPIErrorMsg {
+		return errors.New("error in adding deinterlacing filter to video stream")
+	}
+	return nil
+}
+
 func (p *bitmovinProvider) JobStatus(job *db.Job) (*provider.JobStatus, error) {
 	encodingS := services.NewEncodingService(p.client)
 	statusResp, err := encodingS.RetrieveStatus(job.ProviderJobID)
@@ -963,8 +2291,9 @@ func (p *bitmovinProvider) JobStatus(job *db.Job) (*provider.JobStatus, error) {
 		},
 	}
 
+	var customData map[string]interface{}
 	if jobStatus.Status == provider.StatusFinished {
-		err = p.addManifestStatusInfo(&jobStatus)
+		customData, err = p.addManifestStatusInfo(&jobStatus)
 		if err != nil {
 			return nil, err
 		}
@@ -976,38 +2305,92 @@ func (p *bitmovinProvider) JobStatus(job *db.Job) (*provider.JobStatus, error) {
 			return nil, err
 		}
 
-		err = p.addOutputFilesInfo(job, &jobStatus)
+		err = p.addOutputFilesInfo(job, &jobStatus, customData)
 		if err != nil {
 			return nil, err
 		}
+
+		if job.ContentAware {
+			err = p.addPerTitleOutputFilesInfo(job, &jobStatus)
+			if err != nil {
+				return nil, err
+			}
+		}
 	}
 
 	return &jobStatus, nil
 }
 
-func (p *bitmovinProvider) addManifestStatusInfo(status *provider.JobStatus) error {
+// addPerTitleOutputFilesInfo reports the rungs produced by per-title, along
+// with the bitrate picked for each of them.
+func (p *bitmovinProvider) addPerTitleOutputFilesInfo(job *db.Job, status *provider.JobStatus) error {
+	encodingS := services.NewEncodingService(p.client)
+	h264S := services.NewH264CodecConfigurationService(p.client)
+	h265S := services.NewH265CodecConfigurationService(p.client)
+
+	var totalCount int64 = 1
+	var streams []models.Stream
+	for int64(len(streams)) < totalCount {
+		resp, err := encodingS.ListStream(job.ProviderJobID, int64(len(streams)), 100)
+		if err != nil {
+			return err
+		}
+		totalCount = int64Value(resp.Data.Result.TotalCount)
+		streams = append(streams, resp.Data.Result.Items...)
+	}
+
+	for _, stream := range streams {
+		if stream.Mode != bitmovintypes.StreamModePerTitleResult {
+			continue
+		}
+		codecConfigID := stringValue(stream.CodecConfigurationID)
+		var file provider.OutputFile
+		if h264Resp, err := h264S.Retrieve(codecConfigID); err == nil {
+			file.VideoCodec = "h264"
+			file.Width = int64Value(h264Resp.Data.Result.Width)
+			file.Height = int64Value(h264Resp.Data.Result.Height)
+			file.Bitrate = int64Value(h264Resp.Data.Result.Bitrate)
+		} else {
+			h265Resp, err := h265S.Retrieve(codecConfigID)
+			if err != nil {
+				return err
+			}
+			file.VideoCodec = "hevc"
+			file.Width = int64Value(h265Resp.Data.Result.Width)
+			file.Height = int64Value(h265Resp.Data.Result.Height)
+			file.Bitrate = int64Value(h265Resp.Data.Result.Bitrate)
+		}
+		status.Output.Files = append(status.Output.Files, file)
+	}
+	return nil
+}
+
+// addManifestStatusInfo reports the status of the manifests of the encoding
+// and returns its custom data, which records the manifests that were
+// requested when the job was submitted.
+func (p *bitmovinProvider) addManifestStatusInfo(status *provider.JobStatus) (map[string]interface{}, error) {
 	encodingS := services.NewEncodingService(p.client)
 	cdResp, err := encodingS.RetrieveCustomData(status.ProviderJobID)
 	if err != nil {
-		return err
+		return nil, err
 	}
 	if cdResp.Status == bitmovinAPIErrorMsg {
-		return errors.New("no custom data on encoding, there should at least be container information here")
+		return nil, errors.New("no custom data on encoding, there should at least be container information here")
 	}
 	cd := cdResp.Data.Result.CustomData
 	_, ok := cd["manifest"]
 	if !ok {
 		// no manifest requested, no-op
-		return nil
+		return cd, nil
 	}
 
 	status.Status = p.mapStatus("FINISHED")
 	status.ProviderStatus["manifestStatus"] = "FINISHED"
 
-	return nil
+	return cd, nil
 }
 
-func (p *bitmovinProvider) addOutputFilesInfo(job *db.Job, status *provider.JobStatus) error {
+func (p *bitmovinProvider) addOutputFilesInfo(job *db.Job, status *provider.JobStatus, customData map[string]interface{}) error {
 	err := p.addMP4OutputFilesInfo(job, status)
 	if err != nil {
 		return err
@@ -1016,7 +2399,31 @@ func (p *bitmovinProvider) addOutputFilesInfo(job *db.Job, status *provider.JobS
 	if err != nil {
 		return err
 	}
-	return p.addMOVOutputFilesInfo(job, status)
+	err = p.addMOVOutputFilesInfo(job, status)
+	if err != nil {
+		return err
+	}
+	p.addCMAFOutputFilesInfo(job, status, customData)
+	return nil
+}
+
+// addCMAFOutputFilesInfo reports the manifests written for cmaf outputs. The
+// DASH manifest is only created when the presets of the job emit cmaf, and it
+// is then written next to the HLS master playlist.
+func (p *bitmovinProvider) addCMAFOutputFilesInfo(job *db.Job, status *provider.JobStatus, customData map[string]interface{}) {
+	if _, ok := customData["dashManifest"]; !ok {
+		return
+	}
+	status.Output.Files = append(status.Output.Files,
+		provider.OutputFile{
+			Path:      status.Output.Destination + job.StreamingParams.PlaylistFileName,
+			Container: "m3u8",
+		},
+		provider.OutputFile{
+			Path:      status.Output.Destination + job.StreamingParams.DASHManifestFileName(),
+			Container: "mpd",
+		},
+	)
 }
 
 func (p *bitmovinProvider) addMP4OutputFilesInfo(job *db.Job, status *provider.JobStatus) error {
@@ -1034,18 +2441,21 @@ func (p *bitmovinProvider) addMP4OutputFilesInfo(job *db.Job, status *provider.J
 		}
 
 		info := resp.Data.Result
-		if len(info.VideoTracks) == 0 {
-			return fmt.Errorf("no video track found for encodingID %s muxingID %s", job.ProviderJobID, stringValue(muxing.ID))
-		}
-
 		files[i] = provider.OutputFile{
-			Path:       status.Output.Destination + stringValue(muxing.Filename),
-			Container:  stringValue(info.ContainerFormat),
-			FileSize:   int64Value(info.FileSize),
-			VideoCodec: stringValue(info.VideoTracks[0].Codec),
-			Width:      int64Value(info.VideoTracks[0].FrameWidth),
-			Height:     int64Value(info.VideoTracks[0].FrameHeight),
+			Path:      status.Output.Destination + stringValue(muxing.Filename),
+			Container: stringValue(info.ContainerFormat),
+			FileSize:  int64Value(info.FileSize),
+		}
+		if len(info.VideoTracks) == 0 {
+			// audio-only renditions don't carry any video track
+			if len(info.AudioTracks) == 0 {
+				return fmt.Errorf("no video track found for encodingID %s muxingID %s", job.ProviderJobID, stringValue(muxing.ID))
+			}
+			continue
 		}
+		files[i].VideoCodec = stringValue(info.VideoTracks[0].Codec)
+		files[i].Width = int64Value(info.VideoTracks[0].FrameWidth)
+		files[i].Height = int64Value(info.VideoTracks[0].FrameHeight)
 	}
 
 	status.Output.Files = append(status.Output.Files, files...)
@@ -1236,8 +2646,12 @@ func (p *bitmovinProvider) Healthcheck() error {
 func (p *bitmovinProvider) Capabilities() provider.Capabilities {
 	return provider.Capabilities{
 		InputFormats:  []string{"prores", "h264"},
-		OutputFormats: []string{"mp4", "mov", "hls", "webm"},
+		OutputFormats: []string{"mp4", "mov", "hls", "webm", "cmaf"},
 		Destinations:  []string{"s3"},
+		VideoCodecs:   []string{db.VideoCodecH264, db.VideoCodecHEVC, db.VideoCodecVP8, db.VideoCodecVP9},
+		AudioCodecs:   []string{db.AudioCodecAAC, db.AudioCodecVorbis},
+		HDR:           true,
+		ContentAware:  true,
 	}
 }
 
@@ -1336,22 +2750,78 @@ func createHTTPSInput(provider *bitmovinProvider, input string) (inputID string,
 	return *httpsISResponse.Data.Result.ID, u.Path, nil
 }
 
-func getAACConfig(s *services.AACCodecConfigurationService, bitrate int, samplingRate float64) (string, error) {
+// checkAudioSettings rejects the audio settings that can't be expressed with
+// Bitmovin codec configurations.
+func checkAudioSettings(audio db.AudioPreset) error {
+	switch strings.ToLower(audio.Profile) {
+	case "", db.AudioProfileLC:
+	default:
+		return fmt.Errorf("unsupported audio profile: %v", audio.Profile)
+	}
+	if audio.LoudnessStandard != "" {
+		return fmt.Errorf("unsupported loudness standard: %v", audio.LoudnessStandard)
+	}
+	return nil
+}
+
+func samplingRateFrom(audio db.AudioPreset) (float64, error) {
+	if audio.SampleRate == "" {
+		return 48000, nil
+	}
+	return strconv.ParseFloat(audio.SampleRate, 64)
+}
+
+func aacChannelLayoutFrom(layout string) (bitmovintypes.AACChannelLayout, error) {
+	switch strings.ToLower(layout) {
+	case "":
+		return "", nil
+	case db.AudioChannelLayoutMono:
+		return bitmovintypes.AACChannelLayoutMono, nil
+	case db.AudioChannelLayoutStereo:
+		return bitmovintypes.AACChannelLayoutStereo, nil
+	case db.AudioChannelLayoutSurround:
+		return bitmovintypes.AACChannelLayout51, nil
+	default:
+		return "", fmt.Errorf("unsupported channel layout: %v", layout)
+	}
+}
+
+func vorbisChannelLayoutFrom(layout string) (bitmovintypes.ChannelLayout, error) {
+	switch strings.ToLower(layout) {
+	case "":
+		return "", nil
+	case db.AudioChannelLayoutMono:
+		return bitmovintypes.ChannelLayoutMono, nil
+	case db.AudioChannelLayoutStereo:
+		return bitmovintypes.ChannelLayoutStereo, nil
+	case db.AudioChannelLayoutSurround:
+		return bitmovintypes.ChannelLayout51, nil
+	default:
+		return "", fmt.Errorf("unsupported channel layout: %v", layout)
+	}
+}
+
+func getAACConfig(s *services.AACCodecConfigurationService, bitrate int, samplingRate float64, channelLayout bitmovintypes.AACChannelLayout) (string, error) {
 	l, err := s.List(0, 25)
 	if err != nil {
 		return "", err
 	}
 
 	for _, i := range l.Data.Result.Items {
-		if *i.Bitrate == int64(bitrate) && *i.SamplingRate == samplingRate {
+		// configurations backing audio-only presets are not shared
+		if i.Name != nil && *i.Name != "unused" {
+			continue
+		}
+		if *i.Bitrate == int64(bitrate) && *i.SamplingRate == samplingRate && i.ChannelLayout == channelLayout {
 			return *i.ID, nil
 		}
 	}
 
 	audioConfig := &models.AACCodecConfiguration{
-		Name:         stringToPtr("unused"),
-		Bitrate:      intToPtr(int64(bitrate)),
-		SamplingRate: floatToPtr(samplingRate),
+		Name:          stringToPtr("unused"),
+		Bitrate:       intToPtr(int64(bitrate)),
+		SamplingRate:  floatToPtr(samplingRate),
+		ChannelLayout: channelLayout,
 	}
 
 	audioResp, err := s.Create(audioConfig)
@@ -1364,21 +2834,22 @@ func getAACConfig(s *services.AACCodecConfigurationService, bitrate int, samplin
 	return *audioResp.Data.Result.ID, nil
 }
 
-func getVorbisConfig(s *services.VorbisCodecConfigurationService, bitrate int, samplingRate float64) (string, error) {
+func getVorbisConfig(s *services.VorbisCodecConfigurationService, bitrate int, samplingRate float64, channelLayout bitmovintypes.ChannelLayout) (string, error) {
 	l, err := s.List(0, 25)
 	if err != nil {
 		return "", err
 	}
 
 	for _, i := range l.Data.Result.Items {
-		if *i.Bitrate == int64(bitrate) && *i.SamplingRate == samplingRate {
+		if *i.Bitrate == int64(bitrate) && *i.SamplingRate == samplingRate && i.ChannelLayout == channelLayout {
 			return *i.ID, nil
 		}
 	}
 	audioConfig := &models.VorbisCodecConfiguration{
-		Name:         stringToPtr("unused"),
-		Bitrate:      intToPtr(int64(bitrate)),
-		SamplingRate: floatToPtr(samplingRate),
+		Name:          stringToPtr("unused"),
+		Bitrate:       intToPtr(int64(bitrate)),
+		SamplingRate:  floatToPtr(samplingRate),
+		ChannelLayout: channelLayout,
 	}
 
 	audioResp, err := s.Create(audioConfig)
@@ -98,33 +98,39 @@ func TestCreateH264Preset(t *testing.T) {
 	}
 }
 
-func TestCreateVP8Preset(t *testing.T) {
-	testPresetName := "this_is_an_audio_config_uuid"
-	preset := getVP8Preset()
+func TestCreateH265Preset(t *testing.T) {
+	testPresetName := "this_is_a_video_config_uuid"
+	preset := getH264Preset()
+	preset.Container = "m3u8"
+	preset.Video.Codec = "hevc"
+	preset.Video.Profile = "main10"
+	preset.Video.ProfileLevel = "5.1"
+	var h265Config models.H265CodecConfiguration
 	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
 		switch r.URL.Path {
-		case "/encoding/configurations/audio/vorbis":
-			resp := models.VorbisCodecConfigurationResponse{
+		case "/encoding/configurations/audio/aac":
+			resp := models.AACCodecConfigurationResponse{
 				Status: bitmovintypes.ResponseStatusSuccess,
-				Data: models.VorbisCodecConfigurationData{
-					Result: models.VorbisCodecConfiguration{
+				Data: models.AACCodecConfigurationData{
+					Result: models.AACCodecConfiguration{
 						ID: stringToPtr("this_is_an_audio_config_uuid"),
 					},
 				},
 			}
 			json.NewEncoder(w).Encode(resp)
-		case "/encoding/configurations/video/vp8":
-			resp := models.VP8CodecConfigurationResponse{
+		case "/encoding/configurations/video/h265":
+			json.NewDecoder(r.Body).Decode(&h265Config)
+			resp := models.H265CodecConfigurationResponse{
 				Status: bitmovintypes.ResponseStatusSuccess,
-				Data: models.VP8CodecConfigurationData{
-					Result: models.VP8CodecConfiguration{
+				Data: models.H265CodecConfigurationData{
+					Result: models.H265CodecConfiguration{
 						ID: stringToPtr(testPresetName),
 					},
 				},
 			}
 			json.NewEncoder(w).Encode(resp)
 		default:
-			t.Fatal(errors.New("unexpected path hit"))
+			t.Fatal(errors.New("unexpected path hit " + r.URL.Path))
 		}
 	}))
 	defer ts.Close()
@@ -136,72 +142,181 @@ func TestCreateVP8Preset(t *testing.T) {
 	if presetName != testPresetName {
 		t.Error("expected ", testPresetName, "got ", presetName)
 	}
+	if h265Config.Profile != bitmovintypes.H265ProfileMain10 {
+		t.Errorf("wrong profile: want %q. Got %q", bitmovintypes.H265ProfileMain10, h265Config.Profile)
+	}
+	if h265Config.Level != bitmovintypes.H265Level5_1 {
+		t.Errorf("wrong level: want %q. Got %q", bitmovintypes.H265Level5_1, h265Config.Level)
+	}
+	if g, e := h265Config.CustomData["container"], "cmaf"; g != e {
+		t.Errorf("wrong container: want %q. Got %q", e, g)
+	}
 }
 
-func TestCreatePresetFailsOnAPIError(t *testing.T) {
+func TestCreateH265PresetHDR10(t *testing.T) {
 	preset := getH264Preset()
+	preset.Video.Codec = "hevc"
+	preset.Video.Profile = ""
+	preset.Video.ProfileLevel = ""
+	preset.Video.BitDepth = "10"
+	preset.Video.HDR = "hdr10"
+	var h265Config models.H265CodecConfiguration
 	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
 		switch r.URL.Path {
 		case "/encoding/configurations/audio/aac":
 			resp := models.AACCodecConfigurationResponse{
-				Status: bitmovintypes.ResponseStatusError,
+				Status: bitmovintypes.ResponseStatusSuccess,
+				Data: models.AACCodecConfigurationData{
+					Result: models.AACCodecConfiguration{
+						ID: stringToPtr("this_is_an_audio_config_uuid"),
+					},
+				},
+			}
+			json.NewEncoder(w).Encode(resp)
+		case "/encoding/configurations/video/h265":
+			json.NewDecoder(r.Body).Decode(&h265Config)
+			resp := models.H265CodecConfigurationResponse{
+				Status: bitmovintypes.ResponseStatusSuccess,
+				Data: models.H265CodecConfigurationData{
+					Result: models.H265CodecConfiguration{
+						ID: stringToPtr("this_is_a_video_config_uuid"),
+					},
+				},
 			}
 			json.NewEncoder(w).Encode(resp)
 		default:
-			t.Fatal(errors.New("unexpected path hit"))
+			t.Fatal(errors.New("unexpected path hit " + r.URL.Path))
 		}
 	}))
 	defer ts.Close()
 	prov := getBitmovinProvider(ts.URL)
-	_, err := prov.CreatePreset(preset)
-	if err == nil {
-		t.Fatal("unexpected <nil> error")
+	if _, err := prov.CreatePreset(preset); err != nil {
+		t.Fatal(err)
+	}
+	if h265Config.Profile != bitmovintypes.H265ProfileMain10 {
+		t.Errorf("wrong profile: want %q. Got %q", bitmovintypes.H265ProfileMain10, h265Config.Profile)
+	}
+	if h265Config.PixelFormat != bitmovintypes.PixelFormatYUV420P10LE {
+		t.Errorf("wrong pixel format: want %q. Got %q", bitmovintypes.PixelFormatYUV420P10LE, h265Config.PixelFormat)
+	}
+	if h265Config.HDR == nil || !*h265Config.HDR {
+		t.Error("hdr flag not set")
+	}
+	expectedColorConfig := models.ColorConfig{
+		ColorPrimaries: bitmovintypes.ColorPrimariesBT2020,
+		ColorTransfer:  bitmovintypes.ColorTransferSMPTE2084,
+		ColorSpace:     bitmovintypes.ColorSpaceBT2020_NCL,
+	}
+	if !reflect.DeepEqual(h265Config.ColorConfig, expectedColorConfig) {
+		t.Errorf("wrong color config\nwant %#v\ngot  %#v", expectedColorConfig, h265Config.ColorConfig)
 	}
 }
 
-func TestCreatePresetFailsOnGenericError(t *testing.T) {
+func TestColorConfigFrom(t *testing.T) {
+	tests := []struct {
+		video    db.VideoPreset
+		expected models.ColorConfig
+	}{
+		{
+			db.VideoPreset{},
+			models.ColorConfig{},
+		},
+		{
+			db.VideoPreset{HDR: "passthrough"},
+			models.ColorConfig{
+				CopyColorPrimariesFlag: boolToPtr(true),
+				CopyColorTransferFlag:  boolToPtr(true),
+				CopyColorSpaceFlag:     boolToPtr(true),
+			},
+		},
+		{
+			db.VideoPreset{HDR: "hlg"},
+			models.ColorConfig{
+				ColorPrimaries: bitmovintypes.ColorPrimariesBT2020,
+				ColorTransfer:  bitmovintypes.ColorTransferARIB_STD_B67,
+				ColorSpace:     bitmovintypes.ColorSpaceBT2020_NCL,
+			},
+		},
+		{
+			db.VideoPreset{HDR: "sdr"},
+			models.ColorConfig{
+				ColorPrimaries: bitmovintypes.ColorPrimariesBT709,
+				ColorTransfer:  bitmovintypes.ColorTransferBT709,
+				ColorSpace:     bitmovintypes.ColorSpaceBT709,
+			},
+		},
+		{
+			db.VideoPreset{ColorSpace: "rec601"},
+			models.ColorConfig{
+				ColorPrimaries: bitmovintypes.ColorPrimariesSMPTE170M,
+				ColorTransfer:  bitmovintypes.ColorTransferSMPTE170M,
+				ColorSpace:     bitmovintypes.ColorSpaceSMPTE170M,
+			},
+		},
+	}
+	for _, test := range tests {
+		colorConfig, err := colorConfigFrom(test.video)
+		if err != nil {
+			t.Errorf("%#v: unexpected error: %v", test.video, err)
+			continue
+		}
+		if !reflect.DeepEqual(colorConfig, test.expected) {
+			t.Errorf("%#v: wrong color config\nwant %#v\ngot  %#v", test.video, test.expected, colorConfig)
+		}
+	}
+}
+
+func TestCreateH265PresetHighTier(t *testing.T) {
 	preset := getH264Preset()
+	preset.Video.Codec = "hevc"
+	preset.Video.Tier = "high"
 	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
 		switch r.URL.Path {
 		case "/encoding/configurations/audio/aac":
-			fmt.Fprintln(w, "Not proper json")
+			resp := models.AACCodecConfigurationResponse{
+				Status: bitmovintypes.ResponseStatusSuccess,
+				Data: models.AACCodecConfigurationData{
+					Result: models.AACCodecConfiguration{
+						ID: stringToPtr("this_is_an_audio_config_uuid"),
+					},
+				},
+			}
+			json.NewEncoder(w).Encode(resp)
 		default:
-			t.Fatal(errors.New("unexpected path hit"))
+			t.Fatal(errors.New("unexpected path hit " + r.URL.Path))
 		}
 	}))
 	defer ts.Close()
 	prov := getBitmovinProvider(ts.URL)
 	_, err := prov.CreatePreset(preset)
-	if err == nil {
-		t.Fatal("unexpected <nil> error")
+	if err == nil || err.Error() != "the high hevc tier is not supported with bitmovin" {
+		t.Errorf("wrong error returned: %v", err)
 	}
 }
 
-func TestDeletePresetH264(t *testing.T) {
-	testPresetID := "i_want_to_delete_this"
-	audioPresetID := "embedded_audio_id"
-	customData := make(map[string]interface{})
-	customData["audio"] = audioPresetID
+func TestCreateVP8Preset(t *testing.T) {
+	testPresetName := "this_is_an_audio_config_uuid"
+	preset := getVP8Preset()
 	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
 		switch r.URL.Path {
-		case "/encoding/configurations/video/h264/" + testPresetID + "/customData":
-			resp := models.H264CodecConfigurationResponse{
+		case "/encoding/configurations/audio/vorbis":
+			resp := models.VorbisCodecConfigurationResponse{
 				Status: bitmovintypes.ResponseStatusSuccess,
-				Data: models.H264CodecConfigurationData{
-					Result: models.H264CodecConfiguration{
-						CustomData: customData,
+				Data: models.VorbisCodecConfigurationData{
+					Result: models.VorbisCodecConfiguration{
+						ID: stringToPtr("this_is_an_audio_config_uuid"),
 					},
 				},
 			}
 			json.NewEncoder(w).Encode(resp)
-		case "/encoding/configurations/audio/aac/" + audioPresetID:
-			resp := models.AACCodecConfigurationResponse{
-				Status: bitmovintypes.ResponseStatusSuccess,
-			}
-			json.NewEncoder(w).Encode(resp)
-		case "/encoding/configurations/video/h264/" + testPresetID:
-			resp := models.H264CodecConfigurationResponse{
+		case "/encoding/configurations/video/vp8":
+			resp := models.VP8CodecConfigurationResponse{
 				Status: bitmovintypes.ResponseStatusSuccess,
+				Data: models.VP8CodecConfigurationData{
+					Result: models.VP8CodecConfiguration{
+						ID: stringToPtr(testPresetName),
+					},
+				},
 			}
 			json.NewEncoder(w).Encode(resp)
 		default:
@@ -210,196 +325,395 @@ func TestDeletePresetH264(t *testing.T) {
 	}))
 	defer ts.Close()
 	prov := getBitmovinProvider(ts.URL)
-	err := prov.DeletePreset(testPresetID)
+	presetName, err := prov.CreatePreset(preset)
 	if err != nil {
 		t.Fatal(err)
 	}
+	if presetName != testPresetName {
+		t.Error("expected ", testPresetName, "got ", presetName)
+	}
 }
 
-func TestDeletePresetVP8(t *testing.T) {
-	testPresetID := "i_want_to_delete_this"
-	audioPresetID := "embedded_audio_id"
-	customData := make(map[string]interface{})
-	customData["audio"] = audioPresetID
+func TestCreateVP9Preset(t *testing.T) {
+	testPresetID := "this_is_a_vp9_config_uuid"
+	preset := getVP8Preset()
+	preset.Container = "webm"
+	preset.Video.Codec = "vp9"
+	preset.Video.Profile = "0"
+	var vp9Config models.VP9CodecConfiguration
 	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
 		switch r.URL.Path {
-		case "/encoding/configurations/video/vp8/" + testPresetID + "/customData":
-			resp := models.VP8CodecConfigurationResponse{
+		case "/encoding/configurations/audio/vorbis":
+			resp := models.VorbisCodecConfigurationResponse{
 				Status: bitmovintypes.ResponseStatusSuccess,
-				Data: models.VP8CodecConfigurationData{
-					Result: models.VP8CodecConfiguration{
-						CustomData: customData,
+				Data: models.VorbisCodecConfigurationData{
+					Result: models.VorbisCodecConfiguration{
+						ID: stringToPtr("this_is_an_audio_config_uuid"),
 					},
 				},
 			}
 			json.NewEncoder(w).Encode(resp)
-		case "/encoding/configurations/audio/vorbis/" + audioPresetID:
-			resp := models.VorbisCodecConfigurationResponse{
-				Status: bitmovintypes.ResponseStatusSuccess,
-			}
-			json.NewEncoder(w).Encode(resp)
-		case "/encoding/configurations/video/h264/" + testPresetID:
-			w.WriteHeader(http.StatusNotFound)
-			w.Write([]byte("404 - no API found with those values"))
-		case "/encoding/configurations/video/vp8/" + testPresetID:
-			resp := models.VP8CodecConfigurationResponse{
+		case "/encoding/configurations/video/vp9":
+			json.NewDecoder(r.Body).Decode(&vp9Config)
+			resp := models.VP9CodecConfigurationResponse{
 				Status: bitmovintypes.ResponseStatusSuccess,
+				Data: models.VP9CodecConfigurationData{
+					Result: models.VP9CodecConfiguration{
+						ID: stringToPtr(testPresetID),
+					},
+				},
 			}
 			json.NewEncoder(w).Encode(resp)
 		default:
-			t.Fatal(errors.New("unexpected path hit"))
+			t.Fatal(errors.New("unexpected path hit " + r.URL.Path))
 		}
 	}))
 	defer ts.Close()
 	prov := getBitmovinProvider(ts.URL)
-	err := prov.DeletePreset(testPresetID)
+	presetID, err := prov.CreatePreset(preset)
 	if err != nil {
 		t.Fatal(err)
 	}
+	if presetID != testPresetID {
+		t.Errorf("CreatePreset: want %q. Got %q", testPresetID, presetID)
+	}
+	expectedConfig := models.VP9CodecConfiguration{
+		Name:    stringToPtr("mp4_1080p"),
+		Bitrate: intToPtr(3500000),
+		Height:  intToPtr(1080),
+		CustomData: map[string]interface{}{
+			"audio":     "this_is_an_audio_config_uuid",
+			"container": "webm",
+		},
+	}
+	if !reflect.DeepEqual(vp9Config, expectedConfig) {
+		t.Errorf("wrong vp9 configuration\nwant %#v\ngot  %#v", expectedConfig, vp9Config)
+	}
 }
 
-func TestDeletePresetFailsOnAPIError(t *testing.T) {
-	testPresetID := "i_want_to_delete_this"
+func TestCreatePresetUnsupportedCodecs(t *testing.T) {
+	av1Preset := getVP8Preset()
+	av1Preset.Video.Codec = "av1"
+	opusPreset := getVP8Preset()
+	opusPreset.Video.Codec = "vp9"
+	opusPreset.Audio.Codec = "opus"
+	tests := []struct {
+		preset db.Preset
+		errMsg string
+	}{
+		{av1Preset, "unsupported video codec: av1"},
+		{opusPreset, "unsupported audio codec: opus"},
+	}
 	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
-		switch r.URL.Path {
-		case "/encoding/configurations/video/h264/" + testPresetID:
-			resp := models.H264CodecConfigurationResponse{
-				Status: bitmovintypes.ResponseStatusError,
-			}
-			json.NewEncoder(w).Encode(resp)
-		default:
-			t.Fatal(errors.New("unexpected path hit"))
-		}
+		t.Fatal(errors.New("unexpected path hit " + r.URL.Path))
 	}))
 	defer ts.Close()
 	prov := getBitmovinProvider(ts.URL)
-	err := prov.DeletePreset(testPresetID)
-	if err == nil {
-		t.Fatal("unexpected <nil> error")
+	for _, test := range tests {
+		_, err := prov.CreatePreset(test.preset)
+		if err == nil || err.Error() != test.errMsg {
+			t.Errorf("CreatePreset(%s/%s): want error %q. Got %v", test.preset.Video.Codec, test.preset.Audio.Codec, test.errMsg, err)
+		}
 	}
 }
 
-func TestDeletePresetFailsOnGenericErrors(t *testing.T) {
-	testPresetID := "i_want_to_delete_this"
+func TestValidatePreset(t *testing.T) {
+	vp8AACPreset := getVP8Preset()
+	vp8AACPreset.Audio.Codec = "aac"
+	h264VorbisPreset := getH264Preset()
+	h264VorbisPreset.Audio.Codec = "vorbis"
+	av1Preset := getVP8Preset()
+	av1Preset.Video.Codec = "av1"
+	audioOnlyPreset := db.Preset{Container: "webm", Audio: db.AudioPreset{Codec: "vorbis", Bitrate: "64000"}}
+	overridesPreset := getH264Preset()
+	overridesPreset.ProviderOverrides = db.ProviderOverrides{"bitmovin": json.RawMessage(`{"lagInFramez":25}`)}
+	tests := []struct {
+		name   string
+		preset db.Preset
+		errMsg string
+	}{
+		{"h264/aac", getH264Preset(), ""},
+		{"vp8/vorbis", getVP8Preset(), ""},
+		{"vp8/aac", vp8AACPreset, "unsupported codec pair: vp8 video with aac audio"},
+		{"h264/vorbis", h264VorbisPreset, "unsupported codec pair: h264 video with vorbis audio"},
+		{"av1", av1Preset, "unsupported video codec: av1"},
+		{"audio-only vorbis", audioOnlyPreset, "unsupported audio codec for audio-only presets: vorbis"},
+		{"invalid overrides", overridesPreset, `invalid provider overrides: json: unknown field "lagInFramez"`},
+	}
 	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
-		switch r.URL.Path {
-		case "/encoding/configurations/video/h264/" + testPresetID:
-			fmt.Fprintln(w, "Not proper json")
-		case "/encoding/configurations/video/vp8/" + testPresetID:
-			fmt.Fprintln(w, "Not proper json")
-		default:
-			t.Fatal(errors.New("unexpected path hit"))
-		}
+		t.Fatal(errors.New("unexpected path hit " + r.URL.Path))
 	}))
 	defer ts.Close()
 	prov := getBitmovinProvider(ts.URL)
-	err := prov.DeletePreset(testPresetID)
-	if err == nil {
-		t.Fatal("unexpected <nil> error")
+	for _, test := range tests {
+		err := prov.ValidatePreset(test.preset)
+		if test.errMsg == "" {
+			if err != nil {
+				t.Errorf("%s: unexpected error: %s", test.name, err)
+			}
+			continue
+		}
+		if err == nil || err.Error() != test.errMsg {
+			t.Errorf("%s: want error %q. Got %v", test.name, test.errMsg, err)
+		}
 	}
 }
 
-func TestGetPresetH264(t *testing.T) {
-	testPresetID := "this_is_a_video_preset_id"
-	audioPresetID := "this_is_a_audio_preset_id"
-	customData := make(map[string]interface{})
-	customData["audio"] = audioPresetID
+func TestCreateVP9PresetFrameRateAndDeinterlacer(t *testing.T) {
+	preset := getVP8Preset()
+	preset.Container = "webm"
+	preset.Video.Codec = "vp9"
+	preset.Video.Profile = "0"
+	preset.Video.FrameRate = "30000/1001"
+	preset.Video.Deinterlacer = "deinterlace"
+	var vp9Config models.VP9CodecConfiguration
 	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
 		switch r.URL.Path {
-		case "/encoding/configurations/video/h264/" + testPresetID + "/customData":
-			resp := models.H264CodecConfigurationResponse{
+		case "/encoding/configurations/audio/vorbis":
+			resp := models.VorbisCodecConfigurationResponse{
 				Status: bitmovintypes.ResponseStatusSuccess,
-				Data: models.H264CodecConfigurationData{
-					Result: models.H264CodecConfiguration{
-						CustomData: customData,
+				Data: models.VorbisCodecConfigurationData{
+					Result: models.VorbisCodecConfiguration{
+						ID: stringToPtr("this_is_an_audio_config_uuid"),
 					},
 				},
 			}
 			json.NewEncoder(w).Encode(resp)
-		case "/encoding/configurations/audio/aac/" + audioPresetID:
-			resp := models.AACCodecConfigurationResponse{
-				Status: bitmovintypes.ResponseStatusSuccess,
-			}
-			json.NewEncoder(w).Encode(resp)
-		case "/encoding/configurations/video/h264/" + testPresetID:
-			resp := models.H264CodecConfigurationResponse{
+		case "/encoding/configurations/video/vp9":
+			json.NewDecoder(r.Body).Decode(&vp9Config)
+			resp := models.VP9CodecConfigurationResponse{
 				Status: bitmovintypes.ResponseStatusSuccess,
+				Data: models.VP9CodecConfigurationData{
+					Result: models.VP9CodecConfiguration{
+						ID: stringToPtr("this_is_a_vp9_config_uuid"),
+					},
+				},
 			}
 			json.NewEncoder(w).Encode(resp)
 		default:
-			t.Fatal(errors.New("unexpected path hit"))
+			t.Fatal(errors.New("unexpected path hit " + r.URL.Path))
 		}
 	}))
 	defer ts.Close()
 	prov := getBitmovinProvider(ts.URL)
-	i, err := prov.GetPreset(testPresetID)
-	if err != nil {
+	if _, err := prov.CreatePreset(preset); err != nil {
 		t.Fatal(err)
 	}
-	expected := bitmovinH264Preset{
-		Video: models.H264CodecConfiguration{CustomData: customData},
-		Audio: models.AACCodecConfiguration{},
+	if g, e := floatValue(vp9Config.FrameRate), 30000.0/1001; g != e {
+		t.Errorf("wrong frame rate: want %v. Got %v", e, g)
 	}
-	if !reflect.DeepEqual(i, expected) {
-		t.Errorf("GetPreset: want %#v. Got %#v", expected, i)
+	if g, e := vp9Config.CustomData["deinterlacer"], "deinterlace"; g != e {
+		t.Errorf("wrong deinterlacer in custom data: want %q. Got %v", e, g)
 	}
 }
 
-func TestGetPresetVP8(t *testing.T) {
-	testPresetID := "this_is_a_video_preset_id"
-	audioPresetID := "this_is_a_audio_preset_id"
-	customData := make(map[string]interface{})
-	customData["audio"] = audioPresetID
+func TestCreateVP9PresetProviderOverrides(t *testing.T) {
+	preset := getVP8Preset()
+	preset.Container = "webm"
+	preset.Video.Codec = "vp9"
+	preset.Video.Profile = "0"
+	preset.ProviderOverrides = db.ProviderOverrides{
+		"bitmovin": json.RawMessage(`{"lagInFrames":25,"tileColumns":2,"customData":{"team":"video"}}`),
+	}
+	var vp9Config models.VP9CodecConfiguration
 	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
 		switch r.URL.Path {
-		case "/encoding/configurations/video/h264/" + testPresetID:
-			w.WriteHeader(http.StatusNotFound)
-			w.Write([]byte("404 - no API found with those values"))
-		case "/encoding/configurations/video/vp8/" + testPresetID:
-			resp := models.VP8CodecConfigurationResponse{
-				Status: bitmovintypes.ResponseStatusSuccess,
-			}
-			json.NewEncoder(w).Encode(resp)
-		case "/encoding/configurations/video/vp8/" + testPresetID + "/customData":
-			resp := models.VP8CodecConfigurationResponse{
+		case "/encoding/configurations/audio/vorbis":
+			resp := models.VorbisCodecConfigurationResponse{
 				Status: bitmovintypes.ResponseStatusSuccess,
-				Data: models.VP8CodecConfigurationData{
-					Result: models.VP8CodecConfiguration{
-						CustomData: customData,
+				Data: models.VorbisCodecConfigurationData{
+					Result: models.VorbisCodecConfiguration{
+						ID: stringToPtr("this_is_an_audio_config_uuid"),
 					},
 				},
 			}
 			json.NewEncoder(w).Encode(resp)
-		case "/encoding/configurations/audio/vorbis/" + audioPresetID:
-			resp := models.VorbisCodecConfigurationResponse{
+		case "/encoding/configurations/video/vp9":
+			json.NewDecoder(r.Body).Decode(&vp9Config)
+			resp := models.VP9CodecConfigurationResponse{
 				Status: bitmovintypes.ResponseStatusSuccess,
+				Data: models.VP9CodecConfigurationData{
+					Result: models.VP9CodecConfiguration{
+						ID: stringToPtr("this_is_a_vp9_config_uuid"),
+					},
+				},
 			}
 			json.NewEncoder(w).Encode(resp)
 		default:
-			t.Fatal(errors.New("unexpected path hit"))
+			t.Fatal(errors.New("unexpected path hit " + r.URL.Path))
 		}
 	}))
 	defer ts.Close()
 	prov := getBitmovinProvider(ts.URL)
-	i, err := prov.GetPreset(testPresetID)
-	if err != nil {
+	if _, err := prov.CreatePreset(preset); err != nil {
 		t.Fatal(err)
 	}
-	expected := bitmovinVP8Preset{
-		Video: models.VP8CodecConfiguration{CustomData: customData},
-		Audio: models.VorbisCodecConfiguration{},
+	if g, e := int64Value(vp9Config.LagInFrames), int64(25); g != e {
+		t.Errorf("wrong lag in frames: want %d. Got %d", e, g)
 	}
-	if !reflect.DeepEqual(i, expected) {
-		t.Errorf("GetPreset: want %#v. Got %#v", expected, i)
+	if g, e := int64Value(vp9Config.TileColumns), int64(2); g != e {
+		t.Errorf("wrong tile columns: want %d. Got %d", e, g)
+	}
+	if g, e := vp9Config.CustomData["team"], "video"; g != e {
+		t.Errorf("wrong team in custom data: want %q. Got %v", e, g)
+	}
+	if g, e := vp9Config.CustomData["audio"], "this_is_an_audio_config_uuid"; g != e {
+		t.Errorf("overrides dropped the audio config from custom data: want %q. Got %v", e, g)
+	}
+
+	preset.ProviderOverrides = db.ProviderOverrides{"bitmovin": json.RawMessage(`{"lagInFramez":25}`)}
+	_, err := prov.CreatePreset(preset)
+	if expectedMsg := `invalid provider overrides: json: unknown field "lagInFramez"`; err == nil || err.Error() != expectedMsg {
+		t.Errorf("wrong error returned\nwant %q\ngot  %v", expectedMsg, err)
 	}
 }
 
-func TestGetPresetFailsOnAPIError(t *testing.T) {
-	testPresetID := "this_is_a_video_preset_id"
+func TestCreatePresetUnsupportedProcessingSettings(t *testing.T) {
+	fitPreset := getVP8Preset()
+	fitPreset.Video.ScalingBehavior = "fit"
+	cropPreset := getVP8Preset()
+	cropPreset.Video.Crop = db.CropSettings{Width: "1280", Height: "720"}
+	telecinePreset := getVP8Preset()
+	telecinePreset.Video.Deinterlacer = "inverse-telecine"
+	tests := []struct {
+		preset db.Preset
+		errMsg string
+	}{
+		{fitPreset, "unsupported scaling behavior: fit"},
+		{cropPreset, "cropping is not supported with bitmovin"},
+		{telecinePreset, "unsupported deinterlacer: inverse-telecine"},
+	}
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		t.Fatal(errors.New("unexpected path hit " + r.URL.Path))
+	}))
+	defer ts.Close()
+	prov := getBitmovinProvider(ts.URL)
+	for _, test := range tests {
+		_, err := prov.CreatePreset(test.preset)
+		if err == nil || err.Error() != test.errMsg {
+			t.Errorf("CreatePreset: want error %q. Got %v", test.errMsg, err)
+		}
+	}
+}
+
+func TestCreateAudioOnlyPreset(t *testing.T) {
+	testPresetID := "this_is_an_audio_only_config_uuid"
+	preset := db.Preset{
+		Name:      "hls_audio_64k",
+		Container: "m3u8",
+		Audio:     db.AudioPreset{Codec: "aac", Bitrate: "64000"},
+	}
+	var audioConfig models.AACCodecConfiguration
 	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
 		switch r.URL.Path {
-		case "/encoding/configurations/video/h264/" + testPresetID:
-			resp := models.H264CodecConfigurationResponse{
+		case "/encoding/configurations/audio/aac":
+			json.NewDecoder(r.Body).Decode(&audioConfig)
+			resp := models.AACCodecConfigurationResponse{
+				Status: bitmovintypes.ResponseStatusSuccess,
+				Data: models.AACCodecConfigurationData{
+					Result: models.AACCodecConfiguration{ID: stringToPtr(testPresetID)},
+				},
+			}
+			json.NewEncoder(w).Encode(resp)
+		default:
+			t.Fatal(errors.New("unexpected path hit " + r.URL.Path))
+		}
+	}))
+	defer ts.Close()
+	prov := getBitmovinProvider(ts.URL)
+	presetID, err := prov.CreatePreset(preset)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if presetID != testPresetID {
+		t.Errorf("CreatePreset: want %q. Got %q", testPresetID, presetID)
+	}
+	expectedConfig := models.AACCodecConfiguration{
+		Name:         stringToPtr("hls_audio_64k"),
+		Bitrate:      intToPtr(64000),
+		SamplingRate: floatToPtr(48000),
+		CustomData:   map[string]interface{}{"container": "m3u8"},
+	}
+	if !reflect.DeepEqual(audioConfig, expectedConfig) {
+		t.Errorf("CreatePreset: wrong audio config sent\nWant %#v\nGot  %#v", expectedConfig, audioConfig)
+	}
+}
+
+func TestCreateAudioOnlyPresetAdvancedSettings(t *testing.T) {
+	preset := db.Preset{
+		Name:      "hls_audio_surround",
+		Container: "m3u8",
+		Audio: db.AudioPreset{
+			Codec:         "aac",
+			Bitrate:       "384000",
+			ChannelLayout: "5.1",
+			SampleRate:    "44100",
+		},
+	}
+	var audioConfig models.AACCodecConfiguration
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch r.URL.Path {
+		case "/encoding/configurations/audio/aac":
+			json.NewDecoder(r.Body).Decode(&audioConfig)
+			resp := models.AACCodecConfigurationResponse{
+				Status: bitmovintypes.ResponseStatusSuccess,
+				Data: models.AACCodecConfigurationData{
+					Result: models.AACCodecConfiguration{ID: stringToPtr("some-id")},
+				},
+			}
+			json.NewEncoder(w).Encode(resp)
+		default:
+			t.Fatal(errors.New("unexpected path hit " + r.URL.Path))
+		}
+	}))
+	defer ts.Close()
+	prov := getBitmovinProvider(ts.URL)
+	if _, err := prov.CreatePreset(preset); err != nil {
+		t.Fatal(err)
+	}
+	if audioConfig.ChannelLayout != bitmovintypes.AACChannelLayout51 {
+		t.Errorf("CreatePreset: wrong channel layout. Want %q. Got %q", bitmovintypes.AACChannelLayout51, audioConfig.ChannelLayout)
+	}
+	if floatValue(audioConfig.SamplingRate) != 44100 {
+		t.Errorf("CreatePreset: wrong sampling rate. Want 44100. Got %v", floatValue(audioConfig.SamplingRate))
+	}
+}
+
+func TestCreatePresetUnsupportedAudioSettings(t *testing.T) {
+	tests := []struct {
+		name  string
+		audio db.AudioPreset
+	}{
+		{"he-aac profile", db.AudioPreset{Codec: "aac", Bitrate: "64000", Profile: "he"}},
+		{"loudness normalization", db.AudioPreset{Codec: "aac", Bitrate: "64000", LoudnessStandard: "ebu-r128"}},
+	}
+	prov := getBitmovinProvider("http://bitmovin.example.com")
+	for _, test := range tests {
+		preset := getH264Preset()
+		preset.Audio = test.audio
+		if _, err := prov.CreatePreset(preset); err == nil {
+			t.Errorf("%s: unexpected <nil> error", test.name)
+		}
+	}
+}
+
+func TestCreateAudioOnlyPresetUnsupportedCodec(t *testing.T) {
+	prov := getBitmovinProvider("http://bitmovin.example.com")
+	_, err := prov.CreatePreset(db.Preset{
+		Container: "webm",
+		Audio:     db.AudioPreset{Codec: "vorbis", Bitrate: "64000"},
+	})
+	if err == nil {
+		t.Fatal("unexpected <nil> error")
+	}
+}
+
+func TestCreatePresetFailsOnAPIError(t *testing.T) {
+	preset := getH264Preset()
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch r.URL.Path {
+		case "/encoding/configurations/audio/aac":
+			resp := models.AACCodecConfigurationResponse{
 				Status: bitmovintypes.ResponseStatusError,
 			}
 			json.NewEncoder(w).Encode(resp)
@@ -409,22 +723,17 @@ func TestGetPresetFailsOnAPIError(t *testing.T) {
 	}))
 	defer ts.Close()
 	prov := getBitmovinProvider(ts.URL)
-	i, err := prov.GetPreset(testPresetID)
+	_, err := prov.CreatePreset(preset)
 	if err == nil {
 		t.Fatal("unexpected <nil> error")
 	}
-	if i != nil {
-		t.Errorf("GetPreset: got unexpected non-nil result: %#v", i)
-	}
 }
 
-func TestGetPresetFailsOnGenericErrors(t *testing.T) {
-	testPresetID := "this_is_a_video_preset_id"
+func TestCreatePresetFailsOnGenericError(t *testing.T) {
+	preset := getH264Preset()
 	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
 		switch r.URL.Path {
-		case "/encoding/configurations/video/h264/" + testPresetID:
-			fmt.Fprintln(w, "Not proper json")
-		case "/encoding/configurations/video/vp8/" + testPresetID:
+		case "/encoding/configurations/audio/aac":
 			fmt.Fprintln(w, "Not proper json")
 		default:
 			t.Fatal(errors.New("unexpected path hit"))
@@ -432,176 +741,1367 @@ func TestGetPresetFailsOnGenericErrors(t *testing.T) {
 	}))
 	defer ts.Close()
 	prov := getBitmovinProvider(ts.URL)
-	i, err := prov.GetPreset(testPresetID)
+	_, err := prov.CreatePreset(preset)
 	if err == nil {
 		t.Fatal("unexpected <nil> error")
 	}
-	if i != nil {
-		t.Errorf("GetPreset: got unexpected non-nil result: %#v", i)
-	}
 }
 
-func TestTranscodeWithS3Input(t *testing.T) {
-	s3InputID := "this_is_the_s3_input_id"
-	s3OutputID := "this_is_the_s3_output_id"
-	encodingID := "this_is_the_master_encoding_id"
-	manifestID := "this_is_the_master_manifest_id"
+func TestDeletePresetH264(t *testing.T) {
+	testPresetID := "i_want_to_delete_this"
+	audioPresetID := "embedded_audio_id"
+	customData := make(map[string]interface{})
+	customData["audio"] = audioPresetID
 	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
 		switch r.URL.Path {
-		case "/encoding/inputs/s3":
-			resp := models.S3InputResponse{
+		case "/encoding/configurations/video/h264/" + testPresetID + "/customData":
+			resp := models.H264CodecConfigurationResponse{
 				Status: bitmovintypes.ResponseStatusSuccess,
-				Data: models.S3InputData{
-					Result: models.S3InputItem{
-						ID: stringToPtr(s3InputID),
+				Data: models.H264CodecConfigurationData{
+					Result: models.H264CodecConfiguration{
+						CustomData: customData,
 					},
 				},
 			}
 			json.NewEncoder(w).Encode(resp)
-		case "/encoding/outputs/s3":
-			resp := models.S3OutputResponse{
+		case "/encoding/configurations/audio/aac/" + audioPresetID:
+			resp := models.AACCodecConfigurationResponse{
 				Status: bitmovintypes.ResponseStatusSuccess,
-				Data: models.S3OutputData{
-					Result: models.S3OutputItem{
-						ID: stringToPtr(s3OutputID),
-					},
-				},
 			}
 			json.NewEncoder(w).Encode(resp)
-		case "/encoding/configurations/video/h264/videoID1/customData":
-			customData := make(map[string]interface{})
-			customData["audio"] = "audioID1"
-			customData["container"] = "mp4"
+		case "/encoding/configurations/video/h264/" + testPresetID:
 			resp := models.H264CodecConfigurationResponse{
 				Status: bitmovintypes.ResponseStatusSuccess,
-				Data: models.H264CodecConfigurationData{
-					Result: models.H264CodecConfiguration{
+			}
+			json.NewEncoder(w).Encode(resp)
+		default:
+			t.Fatal(errors.New("unexpected path hit"))
+		}
+	}))
+	defer ts.Close()
+	prov := getBitmovinProvider(ts.URL)
+	err := prov.DeletePreset(testPresetID)
+	if err != nil {
+		t.Fatal(err)
+	}
+}
+
+func TestDeletePresetVP8(t *testing.T) {
+	testPresetID := "i_want_to_delete_this"
+	audioPresetID := "embedded_audio_id"
+	customData := make(map[string]interface{})
+	customData["audio"] = audioPresetID
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch r.URL.Path {
+		case "/encoding/configurations/video/vp8/" + testPresetID + "/customData":
+			resp := models.VP8CodecConfigurationResponse{
+				Status: bitmovintypes.ResponseStatusSuccess,
+				Data: models.VP8CodecConfigurationData{
+					Result: models.VP8CodecConfiguration{
 						CustomData: customData,
 					},
 				},
 			}
 			json.NewEncoder(w).Encode(resp)
-		case "/encoding/configurations/video/h264/videoID2/customData":
-			customData := make(map[string]interface{})
-			customData["audio"] = "audioID2"
-			customData["container"] = "m3u8"
+		case "/encoding/configurations/audio/vorbis/" + audioPresetID:
+			resp := models.VorbisCodecConfigurationResponse{
+				Status: bitmovintypes.ResponseStatusSuccess,
+			}
+			json.NewEncoder(w).Encode(resp)
+		case "/encoding/configurations/video/h264/" + testPresetID,
+			"/encoding/configurations/video/h265/" + testPresetID:
+			w.WriteHeader(http.StatusNotFound)
+			w.Write([]byte("404 - no API found with those values"))
+		case "/encoding/configurations/video/vp8/" + testPresetID:
+			resp := models.VP8CodecConfigurationResponse{
+				Status: bitmovintypes.ResponseStatusSuccess,
+			}
+			json.NewEncoder(w).Encode(resp)
+		default:
+			t.Fatal(errors.New("unexpected path hit"))
+		}
+	}))
+	defer ts.Close()
+	prov := getBitmovinProvider(ts.URL)
+	err := prov.DeletePreset(testPresetID)
+	if err != nil {
+		t.Fatal(err)
+	}
+}
+
+func TestDeletePresetAudioOnly(t *testing.T) {
+	testPresetID := "i_want_to_delete_this"
+	var deleted bool
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch r.URL.Path {
+		case "/encoding/configurations/video/h264/" + testPresetID,
+			"/encoding/configurations/video/h265/" + testPresetID,
+			"/encoding/configurations/video/vp8/" + testPresetID,
+			"/encoding/configurations/video/vp9/" + testPresetID:
+			w.WriteHeader(http.StatusNotFound)
+			w.Write([]byte("404 - no API found with those values"))
+		case "/encoding/configurations/audio/aac/" + testPresetID + "/customData":
+			resp := models.CustomDataResponse{
+				Status: bitmovintypes.ResponseStatusSuccess,
+				Data: models.Data{
+					Result: models.Result{
+						CustomData: map[string]interface{}{"container": "mp4"},
+					},
+				},
+			}
+			json.NewEncoder(w).Encode(resp)
+		case "/encoding/configurations/audio/aac/" + testPresetID:
+			deleted = r.Method == http.MethodDelete
+			resp := models.AACCodecConfigurationResponse{
+				Status: bitmovintypes.ResponseStatusSuccess,
+			}
+			json.NewEncoder(w).Encode(resp)
+		default:
+			t.Fatal(errors.New("unexpected path hit " + r.URL.Path))
+		}
+	}))
+	defer ts.Close()
+	prov := getBitmovinProvider(ts.URL)
+	err := prov.DeletePreset(testPresetID)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !deleted {
+		t.Error("DeletePreset: audio-only configuration was not deleted")
+	}
+}
+
+func TestDeletePresetFailsOnAPIError(t *testing.T) {
+	testPresetID := "i_want_to_delete_this"
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch r.URL.Path {
+		case "/encoding/configurations/video/h264/" + testPresetID:
 			resp := models.H264CodecConfigurationResponse{
+				Status: bitmovintypes.ResponseStatusError,
+			}
+			json.NewEncoder(w).Encode(resp)
+		default:
+			t.Fatal(errors.New("unexpected path hit"))
+		}
+	}))
+	defer ts.Close()
+	prov := getBitmovinProvider(ts.URL)
+	err := prov.DeletePreset(testPresetID)
+	if err == nil {
+		t.Fatal("unexpected <nil> error")
+	}
+}
+
+func TestDeletePresetFailsOnGenericErrors(t *testing.T) {
+	testPresetID := "i_want_to_delete_this"
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch r.URL.Path {
+		case "/encoding/configurations/video/h264/" + testPresetID,
+			"/encoding/configurations/video/h265/" + testPresetID:
+			fmt.Fprintln(w, "Not proper json")
+		case "/encoding/configurations/video/vp8/" + testPresetID,
+			"/encoding/configurations/video/vp9/" + testPresetID,
+			"/encoding/configurations/audio/aac/" + testPresetID + "/customData":
+			fmt.Fprintln(w, "Not proper json")
+		default:
+			t.Fatal(errors.New("unexpected path hit"))
+		}
+	}))
+	defer ts.Close()
+	prov := getBitmovinProvider(ts.URL)
+	err := prov.DeletePreset(testPresetID)
+	if err == nil {
+		t.Fatal("unexpected <nil> error")
+	}
+}
+
+func TestGetPresetH264(t *testing.T) {
+	testPresetID := "this_is_a_video_preset_id"
+	audioPresetID := "this_is_a_audio_preset_id"
+	customData := make(map[string]interface{})
+	customData["audio"] = audioPresetID
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch r.URL.Path {
+		case "/encoding/configurations/video/h264/" + testPresetID + "/customData":
+			resp := models.H264CodecConfigurationResponse{
+				Status: bitmovintypes.ResponseStatusSuccess,
+				Data: models.H264CodecConfigurationData{
+					Result: models.H264CodecConfiguration{
+						CustomData: customData,
+					},
+				},
+			}
+			json.NewEncoder(w).Encode(resp)
+		case "/encoding/configurations/audio/aac/" + audioPresetID:
+			resp := models.AACCodecConfigurationResponse{
+				Status: bitmovintypes.ResponseStatusSuccess,
+			}
+			json.NewEncoder(w).Encode(resp)
+		case "/encoding/configurations/video/h264/" + testPresetID:
+			resp := models.H264CodecConfigurationResponse{
+				Status: bitmovintypes.ResponseStatusSuccess,
+			}
+			json.NewEncoder(w).Encode(resp)
+		default:
+			t.Fatal(errors.New("unexpected path hit"))
+		}
+	}))
+	defer ts.Close()
+	prov := getBitmovinProvider(ts.URL)
+	i, err := prov.GetPreset(testPresetID)
+	if err != nil {
+		t.Fatal(err)
+	}
+	expected := bitmovinH264Preset{
+		Video: models.H264CodecConfiguration{CustomData: customData},
+		Audio: models.AACCodecConfiguration{},
+	}
+	if !reflect.DeepEqual(i, expected) {
+		t.Errorf("GetPreset: want %#v. Got %#v", expected, i)
+	}
+}
+
+func TestGetPresetVP8(t *testing.T) {
+	testPresetID := "this_is_a_video_preset_id"
+	audioPresetID := "this_is_a_audio_preset_id"
+	customData := make(map[string]interface{})
+	customData["audio"] = audioPresetID
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch r.URL.Path {
+		case "/encoding/configurations/video/h264/" + testPresetID,
+			"/encoding/configurations/video/h265/" + testPresetID:
+			w.WriteHeader(http.StatusNotFound)
+			w.Write([]byte("404 - no API found with those values"))
+		case "/encoding/configurations/video/vp8/" + testPresetID:
+			resp := models.VP8CodecConfigurationResponse{
+				Status: bitmovintypes.ResponseStatusSuccess,
+			}
+			json.NewEncoder(w).Encode(resp)
+		case "/encoding/configurations/video/vp8/" + testPresetID + "/customData":
+			resp := models.VP8CodecConfigurationResponse{
+				Status: bitmovintypes.ResponseStatusSuccess,
+				Data: models.VP8CodecConfigurationData{
+					Result: models.VP8CodecConfiguration{
+						CustomData: customData,
+					},
+				},
+			}
+			json.NewEncoder(w).Encode(resp)
+		case "/encoding/configurations/audio/vorbis/" + audioPresetID:
+			resp := models.VorbisCodecConfigurationResponse{
+				Status: bitmovintypes.ResponseStatusSuccess,
+			}
+			json.NewEncoder(w).Encode(resp)
+		default:
+			t.Fatal(errors.New("unexpected path hit"))
+		}
+	}))
+	defer ts.Close()
+	prov := getBitmovinProvider(ts.URL)
+	i, err := prov.GetPreset(testPresetID)
+	if err != nil {
+		t.Fatal(err)
+	}
+	expected := bitmovinVP8Preset{
+		Video: models.VP8CodecConfiguration{CustomData: customData},
+		Audio: models.VorbisCodecConfiguration{},
+	}
+	if !reflect.DeepEqual(i, expected) {
+		t.Errorf("GetPreset: want %#v. Got %#v", expected, i)
+	}
+}
+
+func TestGetPresetAudioOnly(t *testing.T) {
+	testPresetID := "this_is_an_audio_only_preset_id"
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch r.URL.Path {
+		case "/encoding/configurations/video/h264/" + testPresetID,
+			"/encoding/configurations/video/h265/" + testPresetID,
+			"/encoding/configurations/video/vp8/" + testPresetID,
+			"/encoding/configurations/video/vp9/" + testPresetID:
+			w.WriteHeader(http.StatusNotFound)
+			w.Write([]byte("404 - no API found with those values"))
+		case "/encoding/configurations/audio/aac/" + testPresetID + "/customData":
+			resp := models.CustomDataResponse{
+				Status: bitmovintypes.ResponseStatusSuccess,
+				Data: models.Data{
+					Result: models.Result{
+						CustomData: map[string]interface{}{"container": "m3u8"},
+					},
+				},
+			}
+			json.NewEncoder(w).Encode(resp)
+		case "/encoding/configurations/audio/aac/" + testPresetID:
+			resp := models.AACCodecConfigurationResponse{
+				Status: bitmovintypes.ResponseStatusSuccess,
+				Data: models.AACCodecConfigurationData{
+					Result: models.AACCodecConfiguration{Bitrate: intToPtr(64000)},
+				},
+			}
+			json.NewEncoder(w).Encode(resp)
+		default:
+			t.Fatal(errors.New("unexpected path hit " + r.URL.Path))
+		}
+	}))
+	defer ts.Close()
+	prov := getBitmovinProvider(ts.URL)
+	i, err := prov.GetPreset(testPresetID)
+	if err != nil {
+		t.Fatal(err)
+	}
+	expected := bitmovinAudioOnlyPreset{
+		Audio: models.AACCodecConfiguration{
+			Bitrate:    intToPtr(64000),
+			CustomData: map[string]interface{}{"container": "m3u8"},
+		},
+	}
+	if !reflect.DeepEqual(i, expected) {
+		t.Errorf("GetPreset: want %#v. Got %#v", expected, i)
+	}
+}
+
+func TestNormalizePreset(t *testing.T) {
+	var prov bitmovinProvider
+	tests := []struct {
+		name     string
+		native   interface{}
+		expected db.Preset
+	}{
+		{
+			"h264",
+			bitmovinH264Preset{
+				Video: models.H264CodecConfiguration{
+					Name:       stringToPtr("preset-1"),
+					CustomData: map[string]interface{}{"audio": "audio-1", "container": "mp4"},
+					Width:      intToPtr(1280),
+					Height:     intToPtr(720),
+					Bitrate:    intToPtr(2500000),
+					MaxGOP:     intToPtr(90),
+				},
+				Audio: models.AACCodecConfiguration{Bitrate: intToPtr(128000)},
+			},
+			db.Preset{
+				Name:      "preset-1",
+				Container: "mp4",
+				Video:     db.VideoPreset{Codec: "h264", Width: "1280", Height: "720", Bitrate: "2500000", GopSize: "90"},
+				Audio:     db.AudioPreset{Codec: "aac", Bitrate: "128000"},
+			},
+		},
+		{
+			"hevc hls",
+			bitmovinH265Preset{
+				Video: models.H265CodecConfiguration{
+					CustomData: map[string]interface{}{"audio": "audio-1", "container": "cmaf"},
+					Bitrate:    intToPtr(4000000),
+				},
+				Audio: models.AACCodecConfiguration{Bitrate: intToPtr(128000)},
+			},
+			db.Preset{
+				Video: db.VideoPreset{Codec: "hevc", Bitrate: "4000000"},
+				Audio: db.AudioPreset{Codec: "aac", Bitrate: "128000"},
+			},
+		},
+		{
+			"vp9",
+			bitmovinVP9Preset{
+				Video: models.VP9CodecConfiguration{
+					CustomData: map[string]interface{}{"audio": "audio-1", "container": "webm"},
+					Height:     intToPtr(1080),
+					Bitrate:    intToPtr(3000000),
+				},
+				Audio: models.VorbisCodecConfiguration{Bitrate: intToPtr(96000)},
+			},
+			db.Preset{
+				Container: "webm",
+				Video:     db.VideoPreset{Codec: "vp9", Height: "1080", Bitrate: "3000000"},
+				Audio:     db.AudioPreset{Codec: "vorbis", Bitrate: "96000"},
+			},
+		},
+		{
+			"audio-only",
+			bitmovinAudioOnlyPreset{
+				Audio: models.AACCodecConfiguration{
+					Bitrate:    intToPtr(64000),
+					CustomData: map[string]interface{}{"container": "m3u8"},
+				},
+			},
+			db.Preset{
+				Container: "m3u8",
+				Audio:     db.AudioPreset{Codec: "aac", Bitrate: "64000"},
+			},
+		},
+	}
+	for _, test := range tests {
+		preset, err := prov.NormalizePreset(test.native)
+		if err != nil {
+			t.Errorf("%s: unexpected error: %v", test.name, err)
+			continue
+		}
+		if !reflect.DeepEqual(preset, test.expected) {
+			t.Errorf("%s: NormalizePreset: want %#v. Got %#v", test.name, test.expected, preset)
+		}
+	}
+	if _, err := prov.NormalizePreset("preset-1"); err == nil {
+		t.Error("NormalizePreset: unexpected <nil> error for a non-preset value")
+	}
+}
+
+func TestGetPresetFailsOnAPIError(t *testing.T) {
+	testPresetID := "this_is_a_video_preset_id"
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch r.URL.Path {
+		case "/encoding/configurations/video/h264/" + testPresetID:
+			resp := models.H264CodecConfigurationResponse{
+				Status: bitmovintypes.ResponseStatusError,
+			}
+			json.NewEncoder(w).Encode(resp)
+		default:
+			t.Fatal(errors.New("unexpected path hit"))
+		}
+	}))
+	defer ts.Close()
+	prov := getBitmovinProvider(ts.URL)
+	i, err := prov.GetPreset(testPresetID)
+	if err == nil {
+		t.Fatal("unexpected <nil> error")
+	}
+	if i != nil {
+		t.Errorf("GetPreset: got unexpected non-nil result: %#v", i)
+	}
+}
+
+func TestGetPresetNotFound(t *testing.T) {
+	testPresetID := "this_is_a_video_preset_id"
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch r.URL.Path {
+		case "/encoding/configurations/video/h264/" + testPresetID,
+			"/encoding/configurations/video/h265/" + testPresetID,
+			"/encoding/configurations/video/vp8/" + testPresetID,
+			"/encoding/configurations/video/vp9/" + testPresetID,
+			"/encoding/configurations/audio/aac/" + testPresetID + "/customData":
+			w.WriteHeader(http.StatusNotFound)
+			json.NewEncoder(w).Encode(models.DataEnvelope{
+				Status: bitmovinAPIErrorMsg,
+				Data:   models.DataEnvelopeData{Code: 1001, Message: "Resource not found"},
+			})
+		default:
+			t.Fatal(errors.New("unexpected path hit"))
+		}
+	}))
+	defer ts.Close()
+	prov := getBitmovinProvider(ts.URL)
+	i, err := prov.GetPreset(testPresetID)
+	if e := (provider.PresetNotFoundError{ID: testPresetID}); err != e {
+		t.Fatalf("wrong error returned\nwant %#v\ngot  %#v", e, err)
+	}
+	if i != nil {
+		t.Errorf("GetPreset: got unexpected non-nil result: %#v", i)
+	}
+}
+
+func TestGetPresetFailsOnGenericErrors(t *testing.T) {
+	testPresetID := "this_is_a_video_preset_id"
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch r.URL.Path {
+		case "/encoding/configurations/video/h264/" + testPresetID,
+			"/encoding/configurations/video/h265/" + testPresetID:
+			fmt.Fprintln(w, "Not proper json")
+		case "/encoding/configurations/video/vp8/" + testPresetID,
+			"/encoding/configurations/video/vp9/" + testPresetID,
+			"/encoding/configurations/audio/aac/" + testPresetID + "/customData":
+			fmt.Fprintln(w, "Not proper json")
+		default:
+			t.Fatal(errors.New("unexpected path hit"))
+		}
+	}))
+	defer ts.Close()
+	prov := getBitmovinProvider(ts.URL)
+	i, err := prov.GetPreset(testPresetID)
+	if err == nil {
+		t.Fatal("unexpected <nil> error")
+	}
+	if _, ok := err.(provider.PresetNotFoundError); ok {
+		t.Errorf("GetPreset: generic errors reported as a missing preset: %v", err)
+	}
+	if i != nil {
+		t.Errorf("GetPreset: got unexpected non-nil result: %#v", i)
+	}
+}
+
+func TestTranscodeWithS3Input(t *testing.T) {
+	s3InputID := "this_is_the_s3_input_id"
+	s3OutputID := "this_is_the_s3_output_id"
+	encodingID := "this_is_the_master_encoding_id"
+	manifestID := "this_is_the_master_manifest_id"
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch r.URL.Path {
+		case "/encoding/inputs/s3":
+			resp := models.S3InputResponse{
+				Status: bitmovintypes.ResponseStatusSuccess,
+				Data: models.S3InputData{
+					Result: models.S3InputItem{
+						ID: stringToPtr(s3InputID),
+					},
+				},
+			}
+			json.NewEncoder(w).Encode(resp)
+		case "/encoding/outputs/s3":
+			resp := models.S3OutputResponse{
+				Status: bitmovintypes.ResponseStatusSuccess,
+				Data: models.S3OutputData{
+					Result: models.S3OutputItem{
+						ID: stringToPtr(s3OutputID),
+					},
+				},
+			}
+			json.NewEncoder(w).Encode(resp)
+		case "/encoding/configurations/video/h264/videoID1/customData":
+			customData := make(map[string]interface{})
+			customData["audio"] = "audioID1"
+			customData["container"] = "mp4"
+			resp := models.H264CodecConfigurationResponse{
+				Status: bitmovintypes.ResponseStatusSuccess,
+				Data: models.H264CodecConfigurationData{
+					Result: models.H264CodecConfiguration{
+						CustomData: customData,
+					},
+				},
+			}
+			json.NewEncoder(w).Encode(resp)
+		case "/encoding/configurations/video/h264/videoID2/customData":
+			customData := make(map[string]interface{})
+			customData["audio"] = "audioID2"
+			customData["container"] = "m3u8"
+			resp := models.H264CodecConfigurationResponse{
+				Status: bitmovintypes.ResponseStatusSuccess,
+				Data: models.H264CodecConfigurationData{
+					Result: models.H264CodecConfiguration{
+						CustomData: customData,
+					},
+				},
+			}
+			json.NewEncoder(w).Encode(resp)
+		case "/encoding/configurations/video/h264/videoID3/customData":
+			customData := make(map[string]interface{})
+			customData["audio"] = "audioID3"
+			customData["container"] = "m3u8"
+			resp := models.H264CodecConfigurationResponse{
+				Status: bitmovintypes.ResponseStatusSuccess,
+				Data: models.H264CodecConfigurationData{
+					Result: models.H264CodecConfiguration{
+						CustomData: customData,
+					},
+				},
+			}
+			json.NewEncoder(w).Encode(resp)
+		case "/encoding/configurations/video/vp8/videoID4/customData":
+			customData := make(map[string]interface{})
+			customData["audio"] = "audioID4"
+			customData["container"] = "webm"
+			resp := models.VP8CodecConfigurationResponse{
+				Status: bitmovintypes.ResponseStatusSuccess,
+				Data: models.VP8CodecConfigurationData{
+					Result: models.VP8CodecConfiguration{
+						CustomData: customData,
+					},
+				},
+			}
+			json.NewEncoder(w).Encode(resp)
+		case "/encoding/configurations/video/h264/videoID5/customData":
+			customData := make(map[string]interface{})
+			customData["audio"] = "audioID5"
+			customData["container"] = "mov"
+			resp := models.H264CodecConfigurationResponse{
+				Status: bitmovintypes.ResponseStatusSuccess,
+				Data: models.H264CodecConfigurationData{
+					Result: models.H264CodecConfiguration{
+						CustomData: customData,
+					},
+				},
+			}
+			json.NewEncoder(w).Encode(resp)
+		case "/encoding/manifests/hls":
+			resp := models.HLSManifestResponse{
+				Status: bitmovintypes.ResponseStatusSuccess,
+				Data: models.HLSManifestData{
+					Result: models.HLSManifest{
+						ID: stringToPtr(manifestID),
+					},
+				},
+			}
+			json.NewEncoder(w).Encode(resp)
+		case "/encoding/encodings":
+			resp := models.EncodingResponse{
+				Status: bitmovintypes.ResponseStatusSuccess,
+				Data: models.EncodingData{
+					Result: models.Encoding{
+						ID: stringToPtr(encodingID),
+					},
+				},
+			}
+			json.NewEncoder(w).Encode(resp)
+		case "/encoding/configurations/video/h264/videoID1",
+			"/encoding/configurations/video/h264/videoID2",
+			"/encoding/configurations/video/h264/videoID3",
+			"/encoding/configurations/video/h264/videoID5":
+			resp := models.H264CodecConfigurationResponse{
+				Status: bitmovintypes.ResponseStatusSuccess,
+			}
+			json.NewEncoder(w).Encode(resp)
+		case "/encoding/configurations/video/h264/videoID4",
+			"/encoding/configurations/video/h265/videoID4":
+			w.WriteHeader(http.StatusNotFound)
+			w.Write([]byte("404 - no API found with those values"))
+		case "/encoding/configurations/video/vp8/videoID4":
+			resp := models.VP8CodecConfigurationResponse{
+				Status: bitmovintypes.ResponseStatusSuccess,
+			}
+			json.NewEncoder(w).Encode(resp)
+		case "/encoding/encodings/" + encodingID + "/streams":
+			resp := models.StreamResponse{
+				Status: bitmovintypes.ResponseStatusSuccess,
+				Data: models.StreamData{
+					Result: models.Stream{
+						ID: stringToPtr("this_is_a_stream_id"),
+					},
+				},
+			}
+			json.NewEncoder(w).Encode(resp)
+		case "/encoding/encodings/" + encodingID + "/muxings/mp4":
+			resp := models.MP4MuxingResponse{
+				Status: bitmovintypes.ResponseStatusSuccess,
+			}
+			json.NewEncoder(w).Encode(resp)
+		case "/encoding/encodings/" + encodingID + "/muxings/ts":
+			resp := models.TSMuxingResponse{
+				Status: bitmovintypes.ResponseStatusSuccess,
+				Data: models.TSMuxingData{
+					Result: models.TSMuxing{
+						ID: stringToPtr("this_is_a_ts_muxing_id"),
+					},
+				},
+			}
+			json.NewEncoder(w).Encode(resp)
+		case "/encoding/encodings/" + encodingID + "/muxings/progressive-webm":
+			resp := models.MP4MuxingResponse{
+				Status: bitmovintypes.ResponseStatusSuccess,
+			}
+			json.NewEncoder(w).Encode(resp)
+		case "/encoding/encodings/" + encodingID + "/muxings/progressive-mov":
+			resp := models.ProgressiveMOVMuxingResponse{
+				Status: bitmovintypes.ResponseStatusSuccess,
+			}
+			json.NewEncoder(w).Encode(resp)
+		case "/encoding/manifests/hls/" + manifestID + "/media":
+			resp := models.MediaInfoResponse{
+				Status: bitmovintypes.ResponseStatusSuccess,
+			}
+			json.NewEncoder(w).Encode(resp)
+		case "/encoding/manifests/hls/" + manifestID + "/streams":
+			resp := models.StreamInfoResponse{
+				Status: bitmovintypes.ResponseStatusSuccess,
+			}
+			json.NewEncoder(w).Encode(resp)
+		case "/encoding/encodings/" + encodingID + "/start":
+			resp := models.StartStopResponse{
+				Status: bitmovintypes.ResponseStatusSuccess,
+			}
+			json.NewEncoder(w).Encode(resp)
+		default:
+			t.Fatal(errors.New("unexpected path hit " + r.URL.Path))
+		}
+	}))
+	defer ts.Close()
+	prov := getBitmovinProvider(ts.URL)
+	jobStatus, err := prov.Transcode(getJob("s3://bucket/folder/filename.mp4"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	expectedJobStatus := &provider.JobStatus{
+		ProviderName:  Name,
+		ProviderJobID: encodingID,
+		Status:        provider.StatusQueued,
+	}
+	if !reflect.DeepEqual(jobStatus, expectedJobStatus) {
+		t.Errorf("Job Status: want %#v. Got %#v", expectedJobStatus, jobStatus)
+	}
+}
+
+func TestTranscodeWithHTTPInput(t *testing.T) {
+	httpInputID := "this_is_the_s3_input_id"
+	s3OutputID := "this_is_the_s3_output_id"
+	encodingID := "this_is_the_master_encoding_id"
+	manifestID := "this_is_the_master_manifest_id"
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch r.URL.Path {
+		case "/encoding/inputs/http":
+			resp := models.HTTPInputResponse{
+				Status: bitmovintypes.ResponseStatusSuccess,
+				Data: models.HTTPInputData{
+					Result: models.HTTPInputItem{
+						ID: stringToPtr(httpInputID),
+					},
+				},
+			}
+			json.NewEncoder(w).Encode(resp)
+		case "/encoding/outputs/s3":
+			resp := models.S3OutputResponse{
+				Status: bitmovintypes.ResponseStatusSuccess,
+				Data: models.S3OutputData{
+					Result: models.S3OutputItem{
+						ID: stringToPtr(s3OutputID),
+					},
+				},
+			}
+			json.NewEncoder(w).Encode(resp)
+		case "/encoding/configurations/video/h264/videoID1/customData":
+			customData := make(map[string]interface{})
+			customData["audio"] = "audioID1"
+			customData["container"] = "mp4"
+			resp := models.H264CodecConfigurationResponse{
+				Status: bitmovintypes.ResponseStatusSuccess,
+				Data: models.H264CodecConfigurationData{
+					Result: models.H264CodecConfiguration{
+						CustomData: customData,
+					},
+				},
+			}
+			json.NewEncoder(w).Encode(resp)
+		case "/encoding/configurations/video/h264/videoID2/customData":
+			customData := make(map[string]interface{})
+			customData["audio"] = "audioID2"
+			customData["container"] = "m3u8"
+			resp := models.H264CodecConfigurationResponse{
+				Status: bitmovintypes.ResponseStatusSuccess,
+				Data: models.H264CodecConfigurationData{
+					Result: models.H264CodecConfiguration{
+						CustomData: customData,
+					},
+				},
+			}
+			json.NewEncoder(w).Encode(resp)
+		case "/encoding/configurations/video/h264/videoID3/customData":
+			customData := make(map[string]interface{})
+			customData["audio"] = "audioID3"
+			customData["container"] = "m3u8"
+			resp := models.H264CodecConfigurationResponse{
+				Status: bitmovintypes.ResponseStatusSuccess,
+				Data: models.H264CodecConfigurationData{
+					Result: models.H264CodecConfiguration{
+						CustomData: customData,
+					},
+				},
+			}
+			json.NewEncoder(w).Encode(resp)
+		case "/encoding/configurations/video/vp8/videoID4/customData":
+			customData := make(map[string]interface{})
+			customData["audio"] = "audioID4"
+			customData["container"] = "webm"
+			resp := models.VP8CodecConfigurationResponse{
+				Status: bitmovintypes.ResponseStatusSuccess,
+				Data: models.VP8CodecConfigurationData{
+					Result: models.VP8CodecConfiguration{
+						CustomData: customData,
+					},
+				},
+			}
+			json.NewEncoder(w).Encode(resp)
+		case "/encoding/configurations/video/h264/videoID5/customData":
+			customData := make(map[string]interface{})
+			customData["audio"] = "audioID5"
+			customData["container"] = "mov"
+			resp := models.H264CodecConfigurationResponse{
+				Status: bitmovintypes.ResponseStatusSuccess,
+				Data: models.H264CodecConfigurationData{
+					Result: models.H264CodecConfiguration{
+						CustomData: customData,
+					},
+				},
+			}
+			json.NewEncoder(w).Encode(resp)
+		case "/encoding/manifests/hls":
+			resp := models.HLSManifestResponse{
+				Status: bitmovintypes.ResponseStatusSuccess,
+				Data: models.HLSManifestData{
+					Result: models.HLSManifest{
+						ID: stringToPtr(manifestID),
+					},
+				},
+			}
+			json.NewEncoder(w).Encode(resp)
+		case "/encoding/encodings":
+			resp := models.EncodingResponse{
+				Status: bitmovintypes.ResponseStatusSuccess,
+				Data: models.EncodingData{
+					Result: models.Encoding{
+						ID: stringToPtr(encodingID),
+					},
+				},
+			}
+			json.NewEncoder(w).Encode(resp)
+		case "/encoding/configurations/video/h264/videoID1",
+			"/encoding/configurations/video/h264/videoID2",
+			"/encoding/configurations/video/h264/videoID3",
+			"/encoding/configurations/video/h264/videoID5":
+			resp := models.H264CodecConfigurationResponse{
+				Status: bitmovintypes.ResponseStatusSuccess,
+			}
+			json.NewEncoder(w).Encode(resp)
+		case "/encoding/configurations/video/h264/videoID4",
+			"/encoding/configurations/video/h265/videoID4":
+			w.WriteHeader(http.StatusNotFound)
+			w.Write([]byte("404 - no API found with those values"))
+		case "/encoding/configurations/video/vp8/videoID4":
+			resp := models.VP8CodecConfigurationResponse{
+				Status: bitmovintypes.ResponseStatusSuccess,
+			}
+			json.NewEncoder(w).Encode(resp)
+		case "/encoding/encodings/" + encodingID + "/streams":
+			resp := models.StreamResponse{
+				Status: bitmovintypes.ResponseStatusSuccess,
+				Data: models.StreamData{
+					Result: models.Stream{
+						ID: stringToPtr("this_is_a_stream_id"),
+					},
+				},
+			}
+			json.NewEncoder(w).Encode(resp)
+		case "/encoding/encodings/" + encodingID + "/muxings/mp4":
+			resp := models.MP4MuxingResponse{
+				Status: bitmovintypes.ResponseStatusSuccess,
+			}
+			json.NewEncoder(w).Encode(resp)
+		case "/encoding/encodings/" + encodingID + "/muxings/ts":
+			resp := models.TSMuxingResponse{
+				Status: bitmovintypes.ResponseStatusSuccess,
+				Data: models.TSMuxingData{
+					Result: models.TSMuxing{
+						ID: stringToPtr("this_is_a_ts_muxing_id"),
+					},
+				},
+			}
+			json.NewEncoder(w).Encode(resp)
+		case "/encoding/encodings/" + encodingID + "/muxings/progressive-webm":
+			resp := models.MP4MuxingResponse{
+				Status: bitmovintypes.ResponseStatusSuccess,
+			}
+			json.NewEncoder(w).Encode(resp)
+		case "/encoding/encodings/" + encodingID + "/muxings/progressive-mov":
+			resp := models.ProgressiveMOVMuxingResponse{
+				Status: bitmovintypes.ResponseStatusSuccess,
+			}
+			json.NewEncoder(w).Encode(resp)
+		case "/encoding/manifests/hls/" + manifestID + "/media":
+			resp := models.MediaInfoResponse{
+				Status: bitmovintypes.ResponseStatusSuccess,
+			}
+			json.NewEncoder(w).Encode(resp)
+		case "/encoding/manifests/hls/" + manifestID + "/streams":
+			resp := models.StreamInfoResponse{
+				Status: bitmovintypes.ResponseStatusSuccess,
+			}
+			json.NewEncoder(w).Encode(resp)
+		case "/encoding/encodings/" + encodingID + "/start":
+			resp := models.StartStopResponse{
+				Status: bitmovintypes.ResponseStatusSuccess,
+			}
+			json.NewEncoder(w).Encode(resp)
+		default:
+			t.Fatal(errors.New("unexpected path hit " + r.URL.Path))
+		}
+	}))
+	defer ts.Close()
+	prov := getBitmovinProvider(ts.URL)
+	jobStatus, err := prov.Transcode(getJob("http://bucket.com/folder/filename.mp4"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	expectedJobStatus := &provider.JobStatus{
+		ProviderName:  Name,
+		ProviderJobID: encodingID,
+		Status:        provider.StatusQueued,
+	}
+	if !reflect.DeepEqual(jobStatus, expectedJobStatus) {
+		t.Errorf("Job Status: want %#v. Got %#v", expectedJobStatus, jobStatus)
+	}
+}
+
+func TestTranscodeWithHTTPSInput(t *testing.T) {
+	httpsInputID := "this_is_the_s3_input_id"
+	s3OutputID := "this_is_the_s3_output_id"
+	encodingID := "this_is_the_master_encoding_id"
+	manifestID := "this_is_the_master_manifest_id"
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch r.URL.Path {
+		case "/encoding/inputs/https":
+			resp := models.HTTPSInputResponse{
+				Status: bitmovintypes.ResponseStatusSuccess,
+				Data: models.HTTPSInputData{
+					Result: models.HTTPSInputItem{
+						ID: stringToPtr(httpsInputID),
+					},
+				},
+			}
+			json.NewEncoder(w).Encode(resp)
+		case "/encoding/outputs/s3":
+			resp := models.S3OutputResponse{
+				Status: bitmovintypes.ResponseStatusSuccess,
+				Data: models.S3OutputData{
+					Result: models.S3OutputItem{
+						ID: stringToPtr(s3OutputID),
+					},
+				},
+			}
+			json.NewEncoder(w).Encode(resp)
+		case "/encoding/configurations/video/h264/videoID1/customData":
+			customData := make(map[string]interface{})
+			customData["audio"] = "audioID1"
+			customData["container"] = "mp4"
+			resp := models.H264CodecConfigurationResponse{
+				Status: bitmovintypes.ResponseStatusSuccess,
+				Data: models.H264CodecConfigurationData{
+					Result: models.H264CodecConfiguration{
+						CustomData: customData,
+					},
+				},
+			}
+			json.NewEncoder(w).Encode(resp)
+		case "/encoding/configurations/video/h264/videoID2/customData":
+			customData := make(map[string]interface{})
+			customData["audio"] = "audioID2"
+			customData["container"] = "m3u8"
+			resp := models.H264CodecConfigurationResponse{
+				Status: bitmovintypes.ResponseStatusSuccess,
+				Data: models.H264CodecConfigurationData{
+					Result: models.H264CodecConfiguration{
+						CustomData: customData,
+					},
+				},
+			}
+			json.NewEncoder(w).Encode(resp)
+		case "/encoding/configurations/video/h264/videoID3/customData":
+			customData := make(map[string]interface{})
+			customData["audio"] = "audioID3"
+			customData["container"] = "m3u8"
+			resp := models.H264CodecConfigurationResponse{
+				Status: bitmovintypes.ResponseStatusSuccess,
+				Data: models.H264CodecConfigurationData{
+					Result: models.H264CodecConfiguration{
+						CustomData: customData,
+					},
+				},
+			}
+			json.NewEncoder(w).Encode(resp)
+		case "/encoding/configurations/video/vp8/videoID4/customData":
+			customData := make(map[string]interface{})
+			customData["audio"] = "audioID4"
+			customData["container"] = "webm"
+			resp := models.VP8CodecConfigurationResponse{
+				Status: bitmovintypes.ResponseStatusSuccess,
+				Data: models.VP8CodecConfigurationData{
+					Result: models.VP8CodecConfiguration{
+						CustomData: customData,
+					},
+				},
+			}
+			json.NewEncoder(w).Encode(resp)
+		case "/encoding/configurations/video/h264/videoID5/customData":
+			customData := make(map[string]interface{})
+			customData["audio"] = "audioID5"
+			customData["container"] = "mov"
+			resp := models.H264CodecConfigurationResponse{
+				Status: bitmovintypes.ResponseStatusSuccess,
+				Data: models.H264CodecConfigurationData{
+					Result: models.H264CodecConfiguration{
+						CustomData: customData,
+					},
+				},
+			}
+			json.NewEncoder(w).Encode(resp)
+		case "/encoding/manifests/hls":
+			resp := models.HLSManifestResponse{
+				Status: bitmovintypes.ResponseStatusSuccess,
+				Data: models.HLSManifestData{
+					Result: models.HLSManifest{
+						ID: stringToPtr(manifestID),
+					},
+				},
+			}
+			json.NewEncoder(w).Encode(resp)
+		case "/encoding/encodings":
+			resp := models.EncodingResponse{
+				Status: bitmovintypes.ResponseStatusSuccess,
+				Data: models.EncodingData{
+					Result: models.Encoding{
+						ID: stringToPtr(encodingID),
+					},
+				},
+			}
+			json.NewEncoder(w).Encode(resp)
+		case "/encoding/configurations/video/h264/videoID1",
+			"/encoding/configurations/video/h264/videoID2",
+			"/encoding/configurations/video/h264/videoID3",
+			"/encoding/configurations/video/h264/videoID5":
+			resp := models.H264CodecConfigurationResponse{
+				Status: bitmovintypes.ResponseStatusSuccess,
+			}
+			json.NewEncoder(w).Encode(resp)
+		case "/encoding/configurations/video/h264/videoID4",
+			"/encoding/configurations/video/h265/videoID4":
+			w.WriteHeader(http.StatusNotFound)
+			w.Write([]byte("404 - no API found with those values"))
+		case "/encoding/configurations/video/vp8/videoID4":
+			resp := models.VP8CodecConfigurationResponse{
+				Status: bitmovintypes.ResponseStatusSuccess,
+			}
+			json.NewEncoder(w).Encode(resp)
+		case "/encoding/encodings/" + encodingID + "/streams":
+			resp := models.StreamResponse{
+				Status: bitmovintypes.ResponseStatusSuccess,
+				Data: models.StreamData{
+					Result: models.Stream{
+						ID: stringToPtr("this_is_a_stream_id"),
+					},
+				},
+			}
+			json.NewEncoder(w).Encode(resp)
+		case "/encoding/encodings/" + encodingID + "/muxings/mp4":
+			resp := models.MP4MuxingResponse{
+				Status: bitmovintypes.ResponseStatusSuccess,
+			}
+			json.NewEncoder(w).Encode(resp)
+		case "/encoding/encodings/" + encodingID + "/muxings/ts":
+			resp := models.TSMuxingResponse{
+				Status: bitmovintypes.ResponseStatusSuccess,
+				Data: models.TSMuxingData{
+					Result: models.TSMuxing{
+						ID: stringToPtr("this_is_a_ts_muxing_id"),
+					},
+				},
+			}
+			json.NewEncoder(w).Encode(resp)
+		case "/encoding/encodings/" + encodingID + "/muxings/progressive-webm":
+			resp := models.MP4MuxingResponse{
+				Status: bitmovintypes.ResponseStatusSuccess,
+			}
+			json.NewEncoder(w).Encode(resp)
+		case "/encoding/encodings/" + encodingID + "/muxings/progressive-mov":
+			resp := models.ProgressiveMOVMuxingResponse{
+				Status: bitmovintypes.ResponseStatusSuccess,
+			}
+			json.NewEncoder(w).Encode(resp)
+		case "/encoding/manifests/hls/" + manifestID + "/media":
+			resp := models.MediaInfoResponse{
+				Status: bitmovintypes.ResponseStatusSuccess,
+			}
+			json.NewEncoder(w).Encode(resp)
+		case "/encoding/manifests/hls/" + manifestID + "/streams":
+			resp := models.StreamInfoResponse{
+				Status: bitmovintypes.ResponseStatusSuccess,
+			}
+			json.NewEncoder(w).Encode(resp)
+		case "/encoding/encodings/" + encodingID + "/start":
+			resp := models.StartStopResponse{
+				Status: bitmovintypes.ResponseStatusSuccess,
+			}
+			json.NewEncoder(w).Encode(resp)
+		default:
+			t.Fatal(errors.New("unexpected path hit " + r.URL.Path))
+		}
+	}))
+	defer ts.Close()
+	prov := getBitmovinProvider(ts.URL)
+	jobStatus, err := prov.Transcode(getJob("https://bucket.com/folder/filename.mp4"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	expectedJobStatus := &provider.JobStatus{
+		ProviderName:  Name,
+		ProviderJobID: encodingID,
+		Status:        provider.StatusQueued,
+	}
+	if !reflect.DeepEqual(jobStatus, expectedJobStatus) {
+		t.Errorf("Job Status: want %#v. Got %#v", expectedJobStatus, jobStatus)
+	}
+}
+
+func TestTranscodeWithCMAF(t *testing.T) {
+	s3InputID := "this_is_the_s3_input_id"
+	s3OutputID := "this_is_the_s3_output_id"
+	encodingID := "this_is_the_master_encoding_id"
+	manifestID := "this_is_the_master_manifest_id"
+	dashManifestID := "this_is_the_dash_manifest_id"
+	periodID := "this_is_the_period_id"
+	videoAdaptationSetID := "this_is_the_video_adaptation_set_id"
+	audioAdaptationSetID := "this_is_the_audio_adaptation_set_id"
+	var fmp4Muxings int
+	var representations []string
+	var startOptions models.StartOptions
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch r.URL.Path {
+		case "/encoding/inputs/s3":
+			resp := models.S3InputResponse{
+				Status: bitmovintypes.ResponseStatusSuccess,
+				Data:   models.S3InputData{Result: models.S3InputItem{ID: stringToPtr(s3InputID)}},
+			}
+			json.NewEncoder(w).Encode(resp)
+		case "/encoding/outputs/s3":
+			resp := models.S3OutputResponse{
+				Status: bitmovintypes.ResponseStatusSuccess,
+				Data:   models.S3OutputData{Result: models.S3OutputItem{ID: stringToPtr(s3OutputID)}},
+			}
+			json.NewEncoder(w).Encode(resp)
+		case "/encoding/configurations/video/h264/videoID1/customData",
+			"/encoding/configurations/video/h264/videoID2/customData":
+			customData := make(map[string]interface{})
+			customData["audio"] = "audioID1"
+			customData["container"] = "cmaf"
+			resp := models.H264CodecConfigurationResponse{
+				Status: bitmovintypes.ResponseStatusSuccess,
+				Data: models.H264CodecConfigurationData{
+					Result: models.H264CodecConfiguration{CustomData: customData},
+				},
+			}
+			json.NewEncoder(w).Encode(resp)
+		case "/encoding/configurations/video/h264/videoID1",
+			"/encoding/configurations/video/h264/videoID2":
+			resp := models.H264CodecConfigurationResponse{
+				Status: bitmovintypes.ResponseStatusSuccess,
+			}
+			json.NewEncoder(w).Encode(resp)
+		case "/encoding/manifests/hls":
+			resp := models.HLSManifestResponse{
+				Status: bitmovintypes.ResponseStatusSuccess,
+				Data:   models.HLSManifestData{Result: models.HLSManifest{ID: stringToPtr(manifestID)}},
+			}
+			json.NewEncoder(w).Encode(resp)
+		case "/encoding/manifests/dash":
+			resp := models.DashManifestResponse{
+				Status: bitmovintypes.ResponseStatusSuccess,
+				Data:   models.DashManifestData{Result: models.DashManifest{ID: stringToPtr(dashManifestID)}},
+			}
+			json.NewEncoder(w).Encode(resp)
+		case "/encoding/manifests/dash/" + dashManifestID + "/periods":
+			resp := models.PeriodResponse{
+				Status: bitmovintypes.ResponseStatusSuccess,
+				Data:   models.PeriodData{Result: models.Period{ID: stringToPtr(periodID)}},
+			}
+			json.NewEncoder(w).Encode(resp)
+		case "/encoding/manifests/dash/" + dashManifestID + "/periods/" + periodID + "/adaptationsets/video":
+			resp := models.VideoAdaptationSetResponse{
+				Status: bitmovintypes.ResponseStatusSuccess,
+				Data:   models.VideoAdaptationSetData{Result: models.VideoAdaptationSet{ID: stringToPtr(videoAdaptationSetID)}},
+			}
+			json.NewEncoder(w).Encode(resp)
+		case "/encoding/manifests/dash/" + dashManifestID + "/periods/" + periodID + "/adaptationsets/audio":
+			resp := models.AudioAdaptationSetResponse{
+				Status: bitmovintypes.ResponseStatusSuccess,
+				Data:   models.AudioAdaptationSetData{Result: models.AudioAdaptationSet{ID: stringToPtr(audioAdaptationSetID)}},
+			}
+			json.NewEncoder(w).Encode(resp)
+		case "/encoding/manifests/dash/" + dashManifestID + "/periods/" + periodID + "/adaptationsets/" + videoAdaptationSetID + "/representations/fmp4",
+			"/encoding/manifests/dash/" + dashManifestID + "/periods/" + periodID + "/adaptationsets/" + audioAdaptationSetID + "/representations/fmp4":
+			var representation models.FMP4Representation
+			json.NewDecoder(r.Body).Decode(&representation)
+			representations = append(representations, stringValue(representation.SegmentPath))
+			resp := models.FMP4RepresentationResponse{
+				Status: bitmovintypes.ResponseStatusSuccess,
+			}
+			json.NewEncoder(w).Encode(resp)
+		case "/encoding/encodings":
+			resp := models.EncodingResponse{
+				Status: bitmovintypes.ResponseStatusSuccess,
+				Data:   models.EncodingData{Result: models.Encoding{ID: stringToPtr(encodingID)}},
+			}
+			json.NewEncoder(w).Encode(resp)
+		case "/encoding/encodings/" + encodingID + "/streams":
+			resp := models.StreamResponse{
+				Status: bitmovintypes.ResponseStatusSuccess,
+				Data:   models.StreamData{Result: models.Stream{ID: stringToPtr("this_is_a_stream_id")}},
+			}
+			json.NewEncoder(w).Encode(resp)
+		case "/encoding/encodings/" + encodingID + "/muxings/fmp4":
+			fmp4Muxings++
+			resp := models.FMP4MuxingResponse{
+				Status: bitmovintypes.ResponseStatusSuccess,
+				Data:   models.FMP4MuxingData{Result: models.FMP4Muxing{ID: stringToPtr("this_is_a_fmp4_muxing_id")}},
+			}
+			json.NewEncoder(w).Encode(resp)
+		case "/encoding/manifests/hls/" + manifestID + "/media":
+			resp := models.MediaInfoResponse{
+				Status: bitmovintypes.ResponseStatusSuccess,
+			}
+			json.NewEncoder(w).Encode(resp)
+		case "/encoding/manifests/hls/" + manifestID + "/streams":
+			resp := models.StreamInfoResponse{
+				Status: bitmovintypes.ResponseStatusSuccess,
+			}
+			json.NewEncoder(w).Encode(resp)
+		case "/encoding/encodings/" + encodingID + "/start":
+			json.NewDecoder(r.Body).Decode(&startOptions)
+			resp := models.StartStopResponse{
+				Status: bitmovintypes.ResponseStatusSuccess,
+			}
+			json.NewEncoder(w).Encode(resp)
+		default:
+			t.Fatal(errors.New("unexpected path hit " + r.URL.Path))
+		}
+	}))
+	defer ts.Close()
+	prov := getBitmovinProvider(ts.URL)
+	job := &db.Job{
+		ProviderName: Name,
+		SourceMedia:  "s3://bucket/folder/filename.mp4",
+		StreamingParams: db.StreamingParams{
+			Protocol:         "cmaf",
+			SegmentDuration:  uint(4),
+			PlaylistFileName: "cmaf/index.m3u8",
+		},
+		Outputs: []db.TranscodeOutput{
+			{
+				Preset: db.PresetMap{
+					Name:            "cmaf_1080p",
+					ProviderMapping: map[string]string{Name: "videoID1"},
+					OutputOpts:      db.OutputOptions{Extension: "cmaf"},
+				},
+				FileName: "cmaf/output-cmaf_1080p.cmaf",
+			},
+			{
+				Preset: db.PresetMap{
+					Name:            "cmaf_720p",
+					ProviderMapping: map[string]string{Name: "videoID2"},
+					OutputOpts:      db.OutputOptions{Extension: "cmaf"},
+				},
+				FileName: "cmaf/output-cmaf_720p.cmaf",
+			},
+		},
+	}
+	jobStatus, err := prov.Transcode(job)
+	if err != nil {
+		t.Fatal(err)
+	}
+	expectedJobStatus := &provider.JobStatus{
+		ProviderName:  Name,
+		ProviderJobID: encodingID,
+		Status:        provider.StatusQueued,
+	}
+	if !reflect.DeepEqual(jobStatus, expectedJobStatus) {
+		t.Errorf("Job Status: want %#v. Got %#v", expectedJobStatus, jobStatus)
+	}
+	if fmp4Muxings != 3 {
+		t.Errorf("wrong number of fmp4 muxings: want 3. Got %d", fmp4Muxings)
+	}
+	expectedRepresentations := []string{"audioID1", "videoID1", "videoID2"}
+	if !reflect.DeepEqual(representations, expectedRepresentations) {
+		t.Errorf("DASH representations: want %#v. Got %#v", expectedRepresentations, representations)
+	}
+	expectedStartOptions := models.StartOptions{
+		VodHlsManifests:  []models.VodHlsManifest{{ManifestID: manifestID}},
+		VodDashManifests: []models.VodDashManifest{{ManifestID: dashManifestID}},
+	}
+	if !reflect.DeepEqual(startOptions, expectedStartOptions) {
+		t.Errorf("Start options: want %#v. Got %#v", expectedStartOptions, startOptions)
+	}
+}
+
+func TestTranscodeWithCMAFContentAware(t *testing.T) {
+	s3InputID := "this_is_the_s3_input_id"
+	s3OutputID := "this_is_the_s3_output_id"
+	encodingID := "this_is_the_master_encoding_id"
+	manifestID := "this_is_the_master_manifest_id"
+	dashManifestID := "this_is_the_dash_manifest_id"
+	periodID := "this_is_the_period_id"
+	videoAdaptationSetID := "this_is_the_video_adaptation_set_id"
+	audioAdaptationSetID := "this_is_the_audio_adaptation_set_id"
+	streamModes := make(map[string]bitmovintypes.StreamMode)
+	var startOptions models.StartOptions
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch r.URL.Path {
+		case "/encoding/inputs/s3":
+			resp := models.S3InputResponse{
 				Status: bitmovintypes.ResponseStatusSuccess,
-				Data: models.H264CodecConfigurationData{
-					Result: models.H264CodecConfiguration{
-						CustomData: customData,
-					},
-				},
+				Data:   models.S3InputData{Result: models.S3InputItem{ID: stringToPtr(s3InputID)}},
 			}
 			json.NewEncoder(w).Encode(resp)
-		case "/encoding/configurations/video/h264/videoID3/customData":
-			customData := make(map[string]interface{})
-			customData["audio"] = "audioID3"
-			customData["container"] = "m3u8"
-			resp := models.H264CodecConfigurationResponse{
+		case "/encoding/outputs/s3":
+			resp := models.S3OutputResponse{
 				Status: bitmovintypes.ResponseStatusSuccess,
-				Data: models.H264CodecConfigurationData{
-					Result: models.H264CodecConfiguration{
-						CustomData: customData,
-					},
-				},
+				Data:   models.S3OutputData{Result: models.S3OutputItem{ID: stringToPtr(s3OutputID)}},
 			}
 			json.NewEncoder(w).Encode(resp)
-		case "/encoding/configurations/video/vp8/videoID4/customData":
+		case "/encoding/configurations/video/h264/videoID1/customData",
+			"/encoding/configurations/video/h264/videoID2/customData":
 			customData := make(map[string]interface{})
-			customData["audio"] = "audioID4"
-			customData["container"] = "webm"
-			resp := models.VP8CodecConfigurationResponse{
+			customData["audio"] = "audioID1"
+			customData["container"] = "cmaf"
+			resp := models.H264CodecConfigurationResponse{
 				Status: bitmovintypes.ResponseStatusSuccess,
-				Data: models.VP8CodecConfigurationData{
-					Result: models.VP8CodecConfiguration{
-						CustomData: customData,
-					},
+				Data: models.H264CodecConfigurationData{
+					Result: models.H264CodecConfiguration{CustomData: customData},
 				},
 			}
 			json.NewEncoder(w).Encode(resp)
-		case "/encoding/configurations/video/h264/videoID5/customData":
-			customData := make(map[string]interface{})
-			customData["audio"] = "audioID5"
-			customData["container"] = "mov"
+		case "/encoding/configurations/video/h264/videoID1",
+			"/encoding/configurations/video/h264/videoID2":
 			resp := models.H264CodecConfigurationResponse{
 				Status: bitmovintypes.ResponseStatusSuccess,
-				Data: models.H264CodecConfigurationData{
-					Result: models.H264CodecConfiguration{
-						CustomData: customData,
-					},
-				},
 			}
 			json.NewEncoder(w).Encode(resp)
 		case "/encoding/manifests/hls":
 			resp := models.HLSManifestResponse{
 				Status: bitmovintypes.ResponseStatusSuccess,
-				Data: models.HLSManifestData{
-					Result: models.HLSManifest{
-						ID: stringToPtr(manifestID),
-					},
-				},
+				Data:   models.HLSManifestData{Result: models.HLSManifest{ID: stringToPtr(manifestID)}},
 			}
 			json.NewEncoder(w).Encode(resp)
-		case "/encoding/encodings":
-			resp := models.EncodingResponse{
+		case "/encoding/manifests/dash":
+			resp := models.DashManifestResponse{
 				Status: bitmovintypes.ResponseStatusSuccess,
-				Data: models.EncodingData{
-					Result: models.Encoding{
-						ID: stringToPtr(encodingID),
-					},
-				},
+				Data:   models.DashManifestData{Result: models.DashManifest{ID: stringToPtr(dashManifestID)}},
 			}
 			json.NewEncoder(w).Encode(resp)
-		case "/encoding/configurations/video/h264/videoID1",
-			"/encoding/configurations/video/h264/videoID2",
-			"/encoding/configurations/video/h264/videoID3",
-			"/encoding/configurations/video/h264/videoID5":
-			resp := models.H264CodecConfigurationResponse{
+		case "/encoding/manifests/dash/" + dashManifestID + "/periods":
+			resp := models.PeriodResponse{
 				Status: bitmovintypes.ResponseStatusSuccess,
+				Data:   models.PeriodData{Result: models.Period{ID: stringToPtr(periodID)}},
 			}
 			json.NewEncoder(w).Encode(resp)
-		case "/encoding/configurations/video/h264/videoID4":
-			w.WriteHeader(http.StatusNotFound)
-			w.Write([]byte("404 - no API found with those values"))
-		case "/encoding/configurations/video/vp8/videoID4":
-			resp := models.VP8CodecConfigurationResponse{
+		case "/encoding/manifests/dash/" + dashManifestID + "/periods/" + periodID + "/adaptationsets/video":
+			resp := models.VideoAdaptationSetResponse{
 				Status: bitmovintypes.ResponseStatusSuccess,
+				Data:   models.VideoAdaptationSetData{Result: models.VideoAdaptationSet{ID: stringToPtr(videoAdaptationSetID)}},
 			}
 			json.NewEncoder(w).Encode(resp)
-		case "/encoding/encodings/" + encodingID + "/streams":
-			resp := models.StreamResponse{
+		case "/encoding/manifests/dash/" + dashManifestID + "/periods/" + periodID + "/adaptationsets/audio":
+			resp := models.AudioAdaptationSetResponse{
 				Status: bitmovintypes.ResponseStatusSuccess,
-				Data: models.StreamData{
-					Result: models.Stream{
-						ID: stringToPtr("this_is_a_stream_id"),
-					},
-				},
+				Data:   models.AudioAdaptationSetData{Result: models.AudioAdaptationSet{ID: stringToPtr(audioAdaptationSetID)}},
 			}
 			json.NewEncoder(w).Encode(resp)
-		case "/encoding/encodings/" + encodingID + "/muxings/mp4":
-			resp := models.MP4MuxingResponse{
+		case "/encoding/manifests/dash/" + dashManifestID + "/periods/" + periodID + "/adaptationsets/" + videoAdaptationSetID + "/representations/fmp4",
+			"/encoding/manifests/dash/" + dashManifestID + "/periods/" + periodID + "/adaptationsets/" + audioAdaptationSetID + "/representations/fmp4":
+			resp := models.FMP4RepresentationResponse{
 				Status: bitmovintypes.ResponseStatusSuccess,
 			}
 			json.NewEncoder(w).Encode(resp)
-		case "/encoding/encodings/" + encodingID + "/muxings/ts":
-			resp := models.TSMuxingResponse{
+		case "/encoding/encodings":
+			resp := models.EncodingResponse{
 				Status: bitmovintypes.ResponseStatusSuccess,
-				Data: models.TSMuxingData{
-					Result: models.TSMuxing{
-						ID: stringToPtr("this_is_a_ts_muxing_id"),
-					},
-				},
+				Data:   models.EncodingData{Result: models.Encoding{ID: stringToPtr(encodingID)}},
 			}
 			json.NewEncoder(w).Encode(resp)
-		case "/encoding/encodings/" + encodingID + "/muxings/progressive-webm":
-			resp := models.MP4MuxingResponse{
+		case "/encoding/encodings/" + encodingID + "/streams":
+			var stream models.Stream
+			json.NewDecoder(r.Body).Decode(&stream)
+			streamModes[stringValue(stream.CodecConfigurationID)] = stream.Mode
+			resp := models.StreamResponse{
 				Status: bitmovintypes.ResponseStatusSuccess,
+				Data:   models.StreamData{Result: models.Stream{ID: stringToPtr("this_is_a_stream_id")}},
 			}
 			json.NewEncoder(w).Encode(resp)
-		case "/encoding/encodings/" + encodingID + "/muxings/progressive-mov":
-			resp := models.ProgressiveMOVMuxingResponse{
+		case "/encoding/encodings/" + encodingID + "/muxings/fmp4":
+			resp := models.FMP4MuxingResponse{
 				Status: bitmovintypes.ResponseStatusSuccess,
+				Data:   models.FMP4MuxingData{Result: models.FMP4Muxing{ID: stringToPtr("this_is_a_fmp4_muxing_id")}},
 			}
 			json.NewEncoder(w).Encode(resp)
 		case "/encoding/manifests/hls/" + manifestID + "/media":
@@ -615,6 +2115,7 @@ func TestTranscodeWithS3Input(t *testing.T) {
 			}
 			json.NewEncoder(w).Encode(resp)
 		case "/encoding/encodings/" + encodingID + "/start":
+			json.NewDecoder(r.Body).Decode(&startOptions)
 			resp := models.StartStopResponse{
 				Status: bitmovintypes.ResponseStatusSuccess,
 			}
@@ -625,184 +2126,275 @@ func TestTranscodeWithS3Input(t *testing.T) {
 	}))
 	defer ts.Close()
 	prov := getBitmovinProvider(ts.URL)
-	jobStatus, err := prov.Transcode(getJob("s3://bucket/folder/filename.mp4"))
+	job := &db.Job{
+		ProviderName: Name,
+		SourceMedia:  "s3://bucket/folder/filename.mp4",
+		ContentAware: true,
+		StreamingParams: db.StreamingParams{
+			Protocol:         "cmaf",
+			SegmentDuration:  uint(4),
+			PlaylistFileName: "cmaf/index.m3u8",
+		},
+		Outputs: []db.TranscodeOutput{
+			{
+				Preset: db.PresetMap{
+					Name:            "cmaf_1080p",
+					ProviderMapping: map[string]string{Name: "videoID1"},
+					OutputOpts:      db.OutputOptions{Extension: "cmaf"},
+				},
+				FileName: "cmaf/output-cmaf_1080p.cmaf",
+			},
+			{
+				Preset: db.PresetMap{
+					Name:            "cmaf_720p",
+					ProviderMapping: map[string]string{Name: "videoID2"},
+					OutputOpts:      db.OutputOptions{Extension: "cmaf"},
+				},
+				FileName: "cmaf/output-cmaf_720p.cmaf",
+			},
+		},
+	}
+	_, err := prov.Transcode(job)
 	if err != nil {
 		t.Fatal(err)
 	}
-	expectedJobStatus := &provider.JobStatus{
-		ProviderName:  Name,
-		ProviderJobID: encodingID,
-		Status:        provider.StatusQueued,
+	expectedStreamModes := map[string]bitmovintypes.StreamMode{
+		"audioID1": "",
+		"videoID1": bitmovintypes.StreamModePerTitleTemplateFixedResolution,
+		"videoID2": bitmovintypes.StreamModePerTitleTemplateFixedResolution,
 	}
-	if !reflect.DeepEqual(jobStatus, expectedJobStatus) {
-		t.Errorf("Job Status: want %#v. Got %#v", expectedJobStatus, jobStatus)
+	if !reflect.DeepEqual(streamModes, expectedStreamModes) {
+		t.Errorf("Stream modes: want %#v. Got %#v", expectedStreamModes, streamModes)
+	}
+	expectedStartOptions := models.StartOptions{
+		VodHlsManifests:  []models.VodHlsManifest{{ManifestID: manifestID}},
+		VodDashManifests: []models.VodDashManifest{{ManifestID: dashManifestID}},
+		PerTitle:         &models.PerTitle{H264Configuration: &models.H264PerTitleConfiguration{}},
+	}
+	if !reflect.DeepEqual(startOptions, expectedStartOptions) {
+		t.Errorf("Start options: want %#v. Got %#v", expectedStartOptions, startOptions)
 	}
 }
 
-func TestTranscodeWithHTTPInput(t *testing.T) {
-	httpInputID := "this_is_the_s3_input_id"
+func TestTranscodeWithAudioOnlyHLS(t *testing.T) {
+	s3InputID := "this_is_the_s3_input_id"
 	s3OutputID := "this_is_the_s3_output_id"
 	encodingID := "this_is_the_master_encoding_id"
 	manifestID := "this_is_the_master_manifest_id"
+	var streamInfos []models.StreamInfo
 	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
 		switch r.URL.Path {
-		case "/encoding/inputs/http":
-			resp := models.HTTPInputResponse{
+		case "/encoding/inputs/s3":
+			resp := models.S3InputResponse{
 				Status: bitmovintypes.ResponseStatusSuccess,
-				Data: models.HTTPInputData{
-					Result: models.HTTPInputItem{
-						ID: stringToPtr(httpInputID),
-					},
-				},
+				Data:   models.S3InputData{Result: models.S3InputItem{ID: stringToPtr(s3InputID)}},
 			}
 			json.NewEncoder(w).Encode(resp)
 		case "/encoding/outputs/s3":
 			resp := models.S3OutputResponse{
 				Status: bitmovintypes.ResponseStatusSuccess,
-				Data: models.S3OutputData{
-					Result: models.S3OutputItem{
-						ID: stringToPtr(s3OutputID),
-					},
-				},
+				Data:   models.S3OutputData{Result: models.S3OutputItem{ID: stringToPtr(s3OutputID)}},
 			}
 			json.NewEncoder(w).Encode(resp)
 		case "/encoding/configurations/video/h264/videoID1/customData":
 			customData := make(map[string]interface{})
 			customData["audio"] = "audioID1"
-			customData["container"] = "mp4"
+			customData["container"] = "m3u8"
 			resp := models.H264CodecConfigurationResponse{
 				Status: bitmovintypes.ResponseStatusSuccess,
 				Data: models.H264CodecConfigurationData{
-					Result: models.H264CodecConfiguration{
-						CustomData: customData,
-					},
+					Result: models.H264CodecConfiguration{CustomData: customData},
 				},
 			}
 			json.NewEncoder(w).Encode(resp)
-		case "/encoding/configurations/video/h264/videoID2/customData":
-			customData := make(map[string]interface{})
-			customData["audio"] = "audioID2"
-			customData["container"] = "m3u8"
+		case "/encoding/configurations/video/h264/videoID1":
 			resp := models.H264CodecConfigurationResponse{
 				Status: bitmovintypes.ResponseStatusSuccess,
-				Data: models.H264CodecConfigurationData{
-					Result: models.H264CodecConfiguration{
-						CustomData: customData,
-					},
-				},
 			}
 			json.NewEncoder(w).Encode(resp)
-		case "/encoding/configurations/video/h264/videoID3/customData":
-			customData := make(map[string]interface{})
-			customData["audio"] = "audioID3"
-			customData["container"] = "m3u8"
-			resp := models.H264CodecConfigurationResponse{
+		case "/encoding/configurations/video/h264/audioOnlyID/customData",
+			"/encoding/configurations/video/h264/audioOnlyID",
+			"/encoding/configurations/video/h265/audioOnlyID/customData",
+			"/encoding/configurations/video/h265/audioOnlyID",
+			"/encoding/configurations/video/vp8/audioOnlyID",
+			"/encoding/configurations/video/vp9/audioOnlyID":
+			w.WriteHeader(http.StatusNotFound)
+			w.Write([]byte("404 - no API found with those values"))
+		case "/encoding/configurations/audio/aac/audioOnlyID/customData":
+			resp := models.CustomDataResponse{
 				Status: bitmovintypes.ResponseStatusSuccess,
-				Data: models.H264CodecConfigurationData{
-					Result: models.H264CodecConfiguration{
-						CustomData: customData,
+				Data: models.Data{
+					Result: models.Result{
+						CustomData: map[string]interface{}{"container": "m3u8"},
 					},
 				},
 			}
 			json.NewEncoder(w).Encode(resp)
-		case "/encoding/configurations/video/vp8/videoID4/customData":
-			customData := make(map[string]interface{})
-			customData["audio"] = "audioID4"
-			customData["container"] = "webm"
-			resp := models.VP8CodecConfigurationResponse{
+		case "/encoding/manifests/hls":
+			resp := models.HLSManifestResponse{
 				Status: bitmovintypes.ResponseStatusSuccess,
-				Data: models.VP8CodecConfigurationData{
-					Result: models.VP8CodecConfiguration{
-						CustomData: customData,
-					},
-				},
+				Data:   models.HLSManifestData{Result: models.HLSManifest{ID: stringToPtr(manifestID)}},
 			}
 			json.NewEncoder(w).Encode(resp)
-		case "/encoding/configurations/video/h264/videoID5/customData":
-			customData := make(map[string]interface{})
-			customData["audio"] = "audioID5"
-			customData["container"] = "mov"
-			resp := models.H264CodecConfigurationResponse{
+		case "/encoding/encodings":
+			resp := models.EncodingResponse{
 				Status: bitmovintypes.ResponseStatusSuccess,
-				Data: models.H264CodecConfigurationData{
-					Result: models.H264CodecConfiguration{
-						CustomData: customData,
-					},
-				},
+				Data:   models.EncodingData{Result: models.Encoding{ID: stringToPtr(encodingID)}},
 			}
 			json.NewEncoder(w).Encode(resp)
-		case "/encoding/manifests/hls":
-			resp := models.HLSManifestResponse{
+		case "/encoding/encodings/" + encodingID + "/streams":
+			resp := models.StreamResponse{
 				Status: bitmovintypes.ResponseStatusSuccess,
-				Data: models.HLSManifestData{
-					Result: models.HLSManifest{
-						ID: stringToPtr(manifestID),
-					},
-				},
+				Data:   models.StreamData{Result: models.Stream{ID: stringToPtr("this_is_a_stream_id")}},
 			}
 			json.NewEncoder(w).Encode(resp)
-		case "/encoding/encodings":
-			resp := models.EncodingResponse{
+		case "/encoding/encodings/" + encodingID + "/muxings/ts":
+			resp := models.TSMuxingResponse{
 				Status: bitmovintypes.ResponseStatusSuccess,
-				Data: models.EncodingData{
-					Result: models.Encoding{
-						ID: stringToPtr(encodingID),
-					},
+				Data:   models.TSMuxingData{Result: models.TSMuxing{ID: stringToPtr("this_is_a_ts_muxing_id")}},
+			}
+			json.NewEncoder(w).Encode(resp)
+		case "/encoding/manifests/hls/" + manifestID + "/media":
+			resp := models.MediaInfoResponse{
+				Status: bitmovintypes.ResponseStatusSuccess,
+			}
+			json.NewEncoder(w).Encode(resp)
+		case "/encoding/manifests/hls/" + manifestID + "/streams":
+			var streamInfo models.StreamInfo
+			json.NewDecoder(r.Body).Decode(&streamInfo)
+			streamInfos = append(streamInfos, streamInfo)
+			resp := models.StreamInfoResponse{
+				Status: bitmovintypes.ResponseStatusSuccess,
+			}
+			json.NewEncoder(w).Encode(resp)
+		case "/encoding/encodings/" + encodingID + "/start":
+			resp := models.StartStopResponse{
+				Status: bitmovintypes.ResponseStatusSuccess,
+			}
+			json.NewEncoder(w).Encode(resp)
+		default:
+			t.Fatal(errors.New("unexpected path hit " + r.URL.Path))
+		}
+	}))
+	defer ts.Close()
+	prov := getBitmovinProvider(ts.URL)
+	job := &db.Job{
+		ProviderName: Name,
+		SourceMedia:  "s3://bucket/folder/filename.mp4",
+		StreamingParams: db.StreamingParams{
+			Protocol:         "hls",
+			SegmentDuration:  uint(4),
+			PlaylistFileName: "hls/index.m3u8",
+		},
+		Outputs: []db.TranscodeOutput{
+			{
+				Preset: db.PresetMap{
+					Name:            "hls_1080p",
+					ProviderMapping: map[string]string{Name: "videoID1"},
+					OutputOpts:      db.OutputOptions{Extension: "m3u8"},
+				},
+				FileName: "hls/output-hls_1080p.m3u8",
+			},
+			{
+				Preset: db.PresetMap{
+					Name:            "hls_audio_64k",
+					ProviderMapping: map[string]string{Name: "audioOnlyID"},
+					OutputOpts:      db.OutputOptions{Extension: "m3u8"},
 				},
+				FileName: "hls/output-hls_audio_64k.m3u8",
+			},
+		},
+	}
+	_, err := prov.Transcode(job)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(streamInfos) != 2 {
+		t.Fatalf("wrong number of EXT-X-STREAM-INF entries: want 2. Got %d", len(streamInfos))
+	}
+	if g, e := stringValue(streamInfos[0].Audio), "audioID1"; g != e {
+		t.Errorf("video variant: wrong audio group: want %q. Got %q", e, g)
+	}
+	audioOnly := streamInfos[1]
+	if audioOnly.Audio != nil {
+		t.Errorf("audio-only variant: unexpected audio group %q", *audioOnly.Audio)
+	}
+	if g, e := stringValue(audioOnly.URI), "output-hls_audio_64k.m3u8"; g != e {
+		t.Errorf("audio-only variant: wrong URI: want %q. Got %q", e, g)
+	}
+	if g, e := stringValue(audioOnly.SegmentPath), "audioOnlyID"; g != e {
+		t.Errorf("audio-only variant: wrong segment path: want %q. Got %q", e, g)
+	}
+}
+
+func TestTranscodeWithAudioTracks(t *testing.T) {
+	s3InputID := "this_is_the_s3_input_id"
+	s3OutputID := "this_is_the_s3_output_id"
+	encodingID := "this_is_the_master_encoding_id"
+	manifestID := "this_is_the_master_manifest_id"
+	var mediaInfos []models.MediaInfo
+	var streams []models.Stream
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch r.URL.Path {
+		case "/encoding/inputs/s3":
+			resp := models.S3InputResponse{
+				Status: bitmovintypes.ResponseStatusSuccess,
+				Data:   models.S3InputData{Result: models.S3InputItem{ID: stringToPtr(s3InputID)}},
 			}
 			json.NewEncoder(w).Encode(resp)
-		case "/encoding/configurations/video/h264/videoID1",
-			"/encoding/configurations/video/h264/videoID2",
-			"/encoding/configurations/video/h264/videoID3",
-			"/encoding/configurations/video/h264/videoID5":
-			resp := models.H264CodecConfigurationResponse{
+		case "/encoding/outputs/s3":
+			resp := models.S3OutputResponse{
 				Status: bitmovintypes.ResponseStatusSuccess,
+				Data:   models.S3OutputData{Result: models.S3OutputItem{ID: stringToPtr(s3OutputID)}},
 			}
 			json.NewEncoder(w).Encode(resp)
-		case "/encoding/configurations/video/h264/videoID4":
-			w.WriteHeader(http.StatusNotFound)
-			w.Write([]byte("404 - no API found with those values"))
-		case "/encoding/configurations/video/vp8/videoID4":
-			resp := models.VP8CodecConfigurationResponse{
+		case "/encoding/configurations/video/h264/videoID1/customData":
+			customData := make(map[string]interface{})
+			customData["audio"] = "audioID1"
+			customData["container"] = "m3u8"
+			resp := models.H264CodecConfigurationResponse{
 				Status: bitmovintypes.ResponseStatusSuccess,
+				Data: models.H264CodecConfigurationData{
+					Result: models.H264CodecConfiguration{CustomData: customData},
+				},
 			}
 			json.NewEncoder(w).Encode(resp)
-		case "/encoding/encodings/" + encodingID + "/streams":
-			resp := models.StreamResponse{
+		case "/encoding/configurations/video/h264/videoID1":
+			resp := models.H264CodecConfigurationResponse{
 				Status: bitmovintypes.ResponseStatusSuccess,
-				Data: models.StreamData{
-					Result: models.Stream{
-						ID: stringToPtr("this_is_a_stream_id"),
-					},
-				},
 			}
 			json.NewEncoder(w).Encode(resp)
-		case "/encoding/encodings/" + encodingID + "/muxings/mp4":
-			resp := models.MP4MuxingResponse{
+		case "/encoding/manifests/hls":
+			resp := models.HLSManifestResponse{
 				Status: bitmovintypes.ResponseStatusSuccess,
+				Data:   models.HLSManifestData{Result: models.HLSManifest{ID: stringToPtr(manifestID)}},
 			}
 			json.NewEncoder(w).Encode(resp)
-		case "/encoding/encodings/" + encodingID + "/muxings/ts":
-			resp := models.TSMuxingResponse{
+		case "/encoding/encodings":
+			resp := models.EncodingResponse{
 				Status: bitmovintypes.ResponseStatusSuccess,
-				Data: models.TSMuxingData{
-					Result: models.TSMuxing{
-						ID: stringToPtr("this_is_a_ts_muxing_id"),
-					},
-				},
+				Data:   models.EncodingData{Result: models.Encoding{ID: stringToPtr(encodingID)}},
 			}
 			json.NewEncoder(w).Encode(resp)
-		case "/encoding/encodings/" + encodingID + "/muxings/progressive-webm":
-			resp := models.MP4MuxingResponse{
+		case "/encoding/encodings/" + encodingID + "/streams":
+			var stream models.Stream
+			json.NewDecoder(r.Body).Decode(&stream)
+			streams = append(streams, stream)
+			resp := models.StreamResponse{
 				Status: bitmovintypes.ResponseStatusSuccess,
+				Data:   models.StreamData{Result: models.Stream{ID: stringToPtr("this_is_a_stream_id")}},
 			}
 			json.NewEncoder(w).Encode(resp)
-		case "/encoding/encodings/" + encodingID + "/muxings/progressive-mov":
-			resp := models.ProgressiveMOVMuxingResponse{
+		case "/encoding/encodings/" + encodingID + "/muxings/ts":
+			resp := models.TSMuxingResponse{
 				Status: bitmovintypes.ResponseStatusSuccess,
+				Data:   models.TSMuxingData{Result: models.TSMuxing{ID: stringToPtr("this_is_a_ts_muxing_id")}},
 			}
 			json.NewEncoder(w).Encode(resp)
 		case "/encoding/manifests/hls/" + manifestID + "/media":
+			var mediaInfo models.MediaInfo
+			json.NewDecoder(r.Body).Decode(&mediaInfo)
+			mediaInfos = append(mediaInfos, mediaInfo)
 			resp := models.MediaInfoResponse{
 				Status: bitmovintypes.ResponseStatusSuccess,
 			}
@@ -823,181 +2415,142 @@ func TestTranscodeWithHTTPInput(t *testing.T) {
 	}))
 	defer ts.Close()
 	prov := getBitmovinProvider(ts.URL)
-	jobStatus, err := prov.Transcode(getJob("http://bucket.com/folder/filename.mp4"))
+	job := &db.Job{
+		ProviderName: Name,
+		SourceMedia:  "s3://bucket/folder/filename.mp4",
+		StreamingParams: db.StreamingParams{
+			Protocol:         "hls",
+			SegmentDuration:  uint(4),
+			PlaylistFileName: "hls/index.m3u8",
+		},
+		Outputs: []db.TranscodeOutput{
+			{
+				Preset: db.PresetMap{
+					Name:            "hls_1080p",
+					ProviderMapping: map[string]string{Name: "videoID1"},
+					OutputOpts:      db.OutputOptions{Extension: "m3u8"},
+				},
+				FileName: "hls/output-hls_1080p.m3u8",
+			},
+		},
+		AudioTracks: []db.AudioTrack{
+			{TrackIndex: 2, Language: "es", Name: "Español"},
+			{TrackIndex: 3, Language: "fr", Default: true},
+		},
+	}
+	_, err := prov.Transcode(job)
 	if err != nil {
 		t.Fatal(err)
 	}
-	expectedJobStatus := &provider.JobStatus{
-		ProviderName:  Name,
-		ProviderJobID: encodingID,
-		Status:        provider.StatusQueued,
-	}
-	if !reflect.DeepEqual(jobStatus, expectedJobStatus) {
-		t.Errorf("Job Status: want %#v. Got %#v", expectedJobStatus, jobStatus)
+	if len(mediaInfos) != 3 {
+		t.Fatalf("wrong number of EXT-X-MEDIA entries: want 3. Got %d", len(mediaInfos))
+	}
+	var tests = []struct {
+		language  string
+		name      string
+		uri       string
+		isDefault bool
+		position  int64
+	}{
+		{"es", "Español", "audioID1_es_1.m3u8", false, 1},
+		{"fr", "fr", "audioID1_fr_2.m3u8", true, 2},
+	}
+	for i, test := range tests {
+		mediaInfo := mediaInfos[i+1]
+		if g := stringValue(mediaInfo.GroupID); g != "audioID1" {
+			t.Errorf("track %d: wrong group id: want %q. Got %q", i, "audioID1", g)
+		}
+		if g := stringValue(mediaInfo.Language); g != test.language {
+			t.Errorf("track %d: wrong language: want %q. Got %q", i, test.language, g)
+		}
+		if g := stringValue(mediaInfo.Name); g != test.name {
+			t.Errorf("track %d: wrong name: want %q. Got %q", i, test.name, g)
+		}
+		if g := stringValue(mediaInfo.URI); g != test.uri {
+			t.Errorf("track %d: wrong URI: want %q. Got %q", i, test.uri, g)
+		}
+		if mediaInfo.IsDefault == nil || *mediaInfo.IsDefault != test.isDefault {
+			t.Errorf("track %d: wrong default flag: want %v. Got %v", i, test.isDefault, mediaInfo.IsDefault)
+		}
+		stream := streams[i+2]
+		if len(stream.InputStreams) != 1 {
+			t.Fatalf("track %d: wrong number of input streams: want 1. Got %d", i, len(stream.InputStreams))
+		}
+		inputStream := stream.InputStreams[0]
+		if inputStream.SelectionMode != bitmovintypes.SelectionModeAudioRelative {
+			t.Errorf("track %d: wrong selection mode: want %q. Got %q", i, bitmovintypes.SelectionModeAudioRelative, inputStream.SelectionMode)
+		}
+		if inputStream.Position == nil || *inputStream.Position != test.position {
+			t.Errorf("track %d: wrong position: want %d. Got %v", i, test.position, inputStream.Position)
+		}
 	}
 }
 
-func TestTranscodeWithHTTPSInput(t *testing.T) {
-	httpsInputID := "this_is_the_s3_input_id"
+func TestTranscodeWithHLSOptions(t *testing.T) {
+	s3InputID := "this_is_the_s3_input_id"
 	s3OutputID := "this_is_the_s3_output_id"
 	encodingID := "this_is_the_master_encoding_id"
 	manifestID := "this_is_the_master_manifest_id"
+	streamInfoID := "this_is_the_stream_info_id"
+	var manifest models.HLSManifest
+	var fmp4Muxings []models.FMP4Muxing
+	var iframePlaylists []models.IFramePlaylist
 	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
 		switch r.URL.Path {
-		case "/encoding/inputs/https":
-			resp := models.HTTPSInputResponse{
+		case "/encoding/inputs/s3":
+			resp := models.S3InputResponse{
 				Status: bitmovintypes.ResponseStatusSuccess,
-				Data: models.HTTPSInputData{
-					Result: models.HTTPSInputItem{
-						ID: stringToPtr(httpsInputID),
-					},
-				},
+				Data:   models.S3InputData{Result: models.S3InputItem{ID: stringToPtr(s3InputID)}},
 			}
 			json.NewEncoder(w).Encode(resp)
 		case "/encoding/outputs/s3":
 			resp := models.S3OutputResponse{
 				Status: bitmovintypes.ResponseStatusSuccess,
-				Data: models.S3OutputData{
-					Result: models.S3OutputItem{
-						ID: stringToPtr(s3OutputID),
-					},
-				},
+				Data:   models.S3OutputData{Result: models.S3OutputItem{ID: stringToPtr(s3OutputID)}},
 			}
 			json.NewEncoder(w).Encode(resp)
 		case "/encoding/configurations/video/h264/videoID1/customData":
 			customData := make(map[string]interface{})
 			customData["audio"] = "audioID1"
-			customData["container"] = "mp4"
-			resp := models.H264CodecConfigurationResponse{
-				Status: bitmovintypes.ResponseStatusSuccess,
-				Data: models.H264CodecConfigurationData{
-					Result: models.H264CodecConfiguration{
-						CustomData: customData,
-					},
-				},
-			}
-			json.NewEncoder(w).Encode(resp)
-		case "/encoding/configurations/video/h264/videoID2/customData":
-			customData := make(map[string]interface{})
-			customData["audio"] = "audioID2"
-			customData["container"] = "m3u8"
-			resp := models.H264CodecConfigurationResponse{
-				Status: bitmovintypes.ResponseStatusSuccess,
-				Data: models.H264CodecConfigurationData{
-					Result: models.H264CodecConfiguration{
-						CustomData: customData,
-					},
-				},
-			}
-			json.NewEncoder(w).Encode(resp)
-		case "/encoding/configurations/video/h264/videoID3/customData":
-			customData := make(map[string]interface{})
-			customData["audio"] = "audioID3"
 			customData["container"] = "m3u8"
 			resp := models.H264CodecConfigurationResponse{
 				Status: bitmovintypes.ResponseStatusSuccess,
 				Data: models.H264CodecConfigurationData{
-					Result: models.H264CodecConfiguration{
-						CustomData: customData,
-					},
-				},
-			}
-			json.NewEncoder(w).Encode(resp)
-		case "/encoding/configurations/video/vp8/videoID4/customData":
-			customData := make(map[string]interface{})
-			customData["audio"] = "audioID4"
-			customData["container"] = "webm"
-			resp := models.VP8CodecConfigurationResponse{
-				Status: bitmovintypes.ResponseStatusSuccess,
-				Data: models.VP8CodecConfigurationData{
-					Result: models.VP8CodecConfiguration{
-						CustomData: customData,
-					},
+					Result: models.H264CodecConfiguration{CustomData: customData},
 				},
 			}
 			json.NewEncoder(w).Encode(resp)
-		case "/encoding/configurations/video/h264/videoID5/customData":
-			customData := make(map[string]interface{})
-			customData["audio"] = "audioID5"
-			customData["container"] = "mov"
+		case "/encoding/configurations/video/h264/videoID1":
 			resp := models.H264CodecConfigurationResponse{
 				Status: bitmovintypes.ResponseStatusSuccess,
-				Data: models.H264CodecConfigurationData{
-					Result: models.H264CodecConfiguration{
-						CustomData: customData,
-					},
-				},
 			}
 			json.NewEncoder(w).Encode(resp)
 		case "/encoding/manifests/hls":
+			json.NewDecoder(r.Body).Decode(&manifest)
 			resp := models.HLSManifestResponse{
 				Status: bitmovintypes.ResponseStatusSuccess,
-				Data: models.HLSManifestData{
-					Result: models.HLSManifest{
-						ID: stringToPtr(manifestID),
-					},
-				},
+				Data:   models.HLSManifestData{Result: models.HLSManifest{ID: stringToPtr(manifestID)}},
 			}
 			json.NewEncoder(w).Encode(resp)
 		case "/encoding/encodings":
 			resp := models.EncodingResponse{
 				Status: bitmovintypes.ResponseStatusSuccess,
-				Data: models.EncodingData{
-					Result: models.Encoding{
-						ID: stringToPtr(encodingID),
-					},
-				},
-			}
-			json.NewEncoder(w).Encode(resp)
-		case "/encoding/configurations/video/h264/videoID1",
-			"/encoding/configurations/video/h264/videoID2",
-			"/encoding/configurations/video/h264/videoID3",
-			"/encoding/configurations/video/h264/videoID5":
-			resp := models.H264CodecConfigurationResponse{
-				Status: bitmovintypes.ResponseStatusSuccess,
-			}
-			json.NewEncoder(w).Encode(resp)
-		case "/encoding/configurations/video/h264/videoID4":
-			w.WriteHeader(http.StatusNotFound)
-			w.Write([]byte("404 - no API found with those values"))
-		case "/encoding/configurations/video/vp8/videoID4":
-			resp := models.VP8CodecConfigurationResponse{
-				Status: bitmovintypes.ResponseStatusSuccess,
+				Data:   models.EncodingData{Result: models.Encoding{ID: stringToPtr(encodingID)}},
 			}
 			json.NewEncoder(w).Encode(resp)
 		case "/encoding/encodings/" + encodingID + "/streams":
 			resp := models.StreamResponse{
 				Status: bitmovintypes.ResponseStatusSuccess,
-				Data: models.StreamData{
-					Result: models.Stream{
-						ID: stringToPtr("this_is_a_stream_id"),
-					},
-				},
-			}
-			json.NewEncoder(w).Encode(resp)
-		case "/encoding/encodings/" + encodingID + "/muxings/mp4":
-			resp := models.MP4MuxingResponse{
-				Status: bitmovintypes.ResponseStatusSuccess,
-			}
-			json.NewEncoder(w).Encode(resp)
-		case "/encoding/encodings/" + encodingID + "/muxings/ts":
-			resp := models.TSMuxingResponse{
-				Status: bitmovintypes.ResponseStatusSuccess,
-				Data: models.TSMuxingData{
-					Result: models.TSMuxing{
-						ID: stringToPtr("this_is_a_ts_muxing_id"),
-					},
-				},
-			}
-			json.NewEncoder(w).Encode(resp)
-		case "/encoding/encodings/" + encodingID + "/muxings/progressive-webm":
-			resp := models.MP4MuxingResponse{
-				Status: bitmovintypes.ResponseStatusSuccess,
+				Data:   models.StreamData{Result: models.Stream{ID: stringToPtr("this_is_a_stream_id")}},
 			}
 			json.NewEncoder(w).Encode(resp)
-		case "/encoding/encodings/" + encodingID + "/muxings/progressive-mov":
-			resp := models.ProgressiveMOVMuxingResponse{
+		case "/encoding/encodings/" + encodingID + "/muxings/fmp4":
+			var muxing models.FMP4Muxing
+			json.NewDecoder(r.Body).Decode(&muxing)
+			fmp4Muxings = append(fmp4Muxings, muxing)
+			resp := models.FMP4MuxingResponse{
 				Status: bitmovintypes.ResponseStatusSuccess,
+				Data:   models.FMP4MuxingData{Result: models.FMP4Muxing{ID: stringToPtr("this_is_a_fmp4_muxing_id")}},
 			}
 			json.NewEncoder(w).Encode(resp)
 		case "/encoding/manifests/hls/" + manifestID + "/media":
@@ -1008,6 +2561,15 @@ func TestTranscodeWithHTTPSInput(t *testing.T) {
 		case "/encoding/manifests/hls/" + manifestID + "/streams":
 			resp := models.StreamInfoResponse{
 				Status: bitmovintypes.ResponseStatusSuccess,
+				Data:   models.StreamInfoData{Result: models.StreamInfo{ID: stringToPtr(streamInfoID)}},
+			}
+			json.NewEncoder(w).Encode(resp)
+		case "/encoding/manifests/hls/" + manifestID + "/streams/" + streamInfoID + "/iframe":
+			var iframePlaylist models.IFramePlaylist
+			json.NewDecoder(r.Body).Decode(&iframePlaylist)
+			iframePlaylists = append(iframePlaylists, iframePlaylist)
+			resp := models.IFramePlaylistResponse{
+				Status: bitmovintypes.ResponseStatusSuccess,
 			}
 			json.NewEncoder(w).Encode(resp)
 		case "/encoding/encodings/" + encodingID + "/start":
@@ -1021,17 +2583,80 @@ func TestTranscodeWithHTTPSInput(t *testing.T) {
 	}))
 	defer ts.Close()
 	prov := getBitmovinProvider(ts.URL)
-	jobStatus, err := prov.Transcode(getJob("https://bucket.com/folder/filename.mp4"))
+	job := &db.Job{
+		ProviderName: Name,
+		SourceMedia:  "s3://bucket/folder/filename.mp4",
+		StreamingParams: db.StreamingParams{
+			Protocol:         "hls",
+			SegmentDuration:  uint(4),
+			PlaylistFileName: "hls/index.m3u8",
+			HLS: &db.HLSOptions{
+				IFramePlaylist:   true,
+				TargetVersion:    7,
+				SegmentContainer: "fmp4",
+			},
+		},
+		Outputs: []db.TranscodeOutput{
+			{
+				Preset: db.PresetMap{
+					Name:            "hls_1080p",
+					ProviderMapping: map[string]string{Name: "videoID1"},
+					OutputOpts:      db.OutputOptions{Extension: "m3u8"},
+				},
+				FileName: "hls/output-hls_1080p.m3u8",
+			},
+		},
+	}
+	_, err := prov.Transcode(job)
 	if err != nil {
 		t.Fatal(err)
 	}
-	expectedJobStatus := &provider.JobStatus{
-		ProviderName:  Name,
-		ProviderJobID: encodingID,
-		Status:        provider.StatusQueued,
+	if manifest.HlsMasterPlaylistVersion != bitmovintypes.HlsMasterPlaylistVersion7 {
+		t.Errorf("wrong master playlist version: want 7. Got %d", manifest.HlsMasterPlaylistVersion)
 	}
-	if !reflect.DeepEqual(jobStatus, expectedJobStatus) {
-		t.Errorf("Job Status: want %#v. Got %#v", expectedJobStatus, jobStatus)
+	if manifest.HlsMediaPlaylistVersion != bitmovintypes.HlsMediaPlaylistVersion(7) {
+		t.Errorf("wrong media playlist version: want 7. Got %d", manifest.HlsMediaPlaylistVersion)
+	}
+	if len(fmp4Muxings) != 2 {
+		t.Fatalf("wrong number of fmp4 muxings: want 2. Got %d", len(fmp4Muxings))
+	}
+	for _, muxing := range fmp4Muxings {
+		if naming := stringValue(muxing.SegmentNaming); naming != "seg_%number%.m4s" {
+			t.Errorf("wrong segment naming: want %q. Got %q", "seg_%number%.m4s", naming)
+		}
+		if initSegment := stringValue(muxing.InitSegmentName); initSegment != "init.mp4" {
+			t.Errorf("wrong init segment name: want %q. Got %q", "init.mp4", initSegment)
+		}
+	}
+	expectedIFramePlaylists := []models.IFramePlaylist{{Filename: stringToPtr("output-hls_1080p_iframes.m3u8")}}
+	if !reflect.DeepEqual(iframePlaylists, expectedIFramePlaylists) {
+		t.Errorf("wrong I-frame playlists\nwant %#v\ngot  %#v", expectedIFramePlaylists, iframePlaylists)
+	}
+}
+
+func TestTranscodeUnsupportedHLSOptions(t *testing.T) {
+	var tests = []struct {
+		options db.HLSOptions
+		errMsg  string
+	}{
+		{db.HLSOptions{SingleFile: true}, "hls option singleFile is not supported by the provider"},
+		{db.HLSOptions{ProgramDateTime: true}, "hls option programDateTime is not supported by the provider"},
+		{db.HLSOptions{PlaylistType: "event"}, "hls option playlistType=EVENT is not supported by the provider"},
+		{db.HLSOptions{TargetVersion: 9}, "hls option targetVersion=9 is not supported by the provider"},
+	}
+	prov := getBitmovinProvider("http://bitmovin.example.com")
+	for _, test := range tests {
+		job := getJob("s3://bucket/folder/filename.mp4")
+		options := test.options
+		job.StreamingParams.HLS = &options
+		_, err := prov.Transcode(job)
+		if _, ok := err.(provider.HLSOptionNotSupportedError); !ok {
+			t.Errorf("%#v: wrong error type: want HLSOptionNotSupportedError. Got %#v", test.options, err)
+			continue
+		}
+		if err.Error() != test.errMsg {
+			t.Errorf("%#v: wrong error message\nwant %q\ngot  %q", test.options, test.errMsg, err.Error())
+		}
 	}
 }
 
@@ -1506,6 +3131,84 @@ func TestJobStatusReturnsFinishedIfEncodeIsFinishedAndNoManifestGenerationIsNeed
 	}
 }
 
+func TestJobStatusReportsEmittedCMAFManifests(t *testing.T) {
+	const testJobID = "this_is_a_job_id"
+	tests := []struct {
+		name       string
+		customData map[string]interface{}
+		wantFiles  []provider.OutputFile
+	}{
+		{
+			name:       "cmaf outputs",
+			customData: map[string]interface{}{"manifest": "hls-manifest", "dashManifest": "dash-manifest"},
+			wantFiles: []provider.OutputFile{
+				{Path: "s3://some-output-bucket/job-123/cmaf/index.m3u8", Container: "m3u8"},
+				{Path: "s3://some-output-bucket/job-123/cmaf/index.mpd", Container: "mpd"},
+			},
+		},
+		{
+			name:       "no cmaf outputs",
+			customData: map[string]interface{}{"manifest": "hls-manifest"},
+		},
+	}
+	for _, test := range tests {
+		test := test
+		t.Run(test.name, func(t *testing.T) {
+			ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				switch r.URL.Path {
+				case "/encoding/encodings/" + testJobID + "/status":
+					json.NewEncoder(w).Encode(models.StatusResponse{
+						Status: bitmovintypes.ResponseStatusSuccess,
+						Data: models.StatusData{
+							Result: models.StatusResult{Status: stringToPtr("FINISHED"), Progress: floatToPtr(100)},
+						},
+					})
+				case "/encoding/encodings/" + testJobID + "/customData":
+					json.NewEncoder(w).Encode(models.CustomDataResponse{
+						Data: models.Data{Result: models.Result{CustomData: test.customData}},
+					})
+				case "/encoding/encodings/" + testJobID + "/streams":
+					json.NewEncoder(w).Encode(models.StreamListResponse{
+						Data: models.StreamListData{
+							Result: models.StreamListResult{Items: []models.Stream{{ID: stringToPtr("new_stream")}}},
+						},
+					})
+				case "/encoding/encodings/" + testJobID + "/streams/new_stream/input":
+					json.NewEncoder(w).Encode(models.StreamInputResponse{
+						Data: models.StreamInputData{
+							Result: models.StreamInputResult{
+								VideoStreams: []models.StreamInputVideo{{Codec: stringToPtr("h264")}},
+							},
+						},
+					})
+				case "/encoding/encodings/" + testJobID + "/muxings/mp4",
+					"/encoding/encodings/" + testJobID + "/muxings/progressive-webm",
+					"/encoding/encodings/" + testJobID + "/muxings/progressive-mov":
+					w.Write([]byte("{}"))
+				default:
+					t.Fatalf("unexpected path hit: %v", r.URL.Path)
+				}
+			}))
+			defer ts.Close()
+			prov := getBitmovinProvider(ts.URL)
+			jobStatus, err := prov.JobStatus(&db.Job{
+				ID:            "job-123",
+				ProviderJobID: testJobID,
+				StreamingParams: db.StreamingParams{
+					Protocol:         "cmaf",
+					PlaylistFileName: "cmaf/index.m3u8",
+				},
+			})
+			if err != nil {
+				t.Fatal(err)
+			}
+			if !reflect.DeepEqual(jobStatus.Output.Files, test.wantFiles) {
+				t.Errorf("wrong output files\nWant %#v\nGot  %#v", test.wantFiles, jobStatus.Output.Files)
+			}
+		})
+	}
+}
+
 func TestJobStatusReturnsQueuedIfEncodeIsCreated(t *testing.T) {
 	testJobID := "this_is_a_job_id"
 	manifestID := "this_is_the_underlying_manifest_id"
@@ -1821,6 +3524,70 @@ func TestJobStatusReturnsErrorOnGenericError(t *testing.T) {
 	}
 }
 
+func TestAddPerTitleOutputFilesInfo(t *testing.T) {
+	encodingID := "this_is_the_master_encoding_id"
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch r.URL.Path {
+		case "/encoding/encodings/" + encodingID + "/streams":
+			resp := models.StreamListResponse{
+				Status: bitmovintypes.ResponseStatusSuccess,
+				Data: models.StreamListData{
+					Result: models.StreamListResult{
+						TotalCount: intToPtr(4),
+						Items: []models.Stream{
+							{CodecConfigurationID: stringToPtr("audioID"), Mode: bitmovintypes.StreamModeStandard},
+							{CodecConfigurationID: stringToPtr("templateID"), Mode: bitmovintypes.StreamModePerTitleTemplateFixedResolution},
+							{CodecConfigurationID: stringToPtr("h264ResultID"), Mode: bitmovintypes.StreamModePerTitleResult},
+							{CodecConfigurationID: stringToPtr("h265ResultID"), Mode: bitmovintypes.StreamModePerTitleResult},
+						},
+					},
+				},
+			}
+			json.NewEncoder(w).Encode(resp)
+		case "/encoding/configurations/video/h264/h264ResultID":
+			resp := models.H264CodecConfigurationResponse{
+				Status: bitmovintypes.ResponseStatusSuccess,
+				Data: models.H264CodecConfigurationData{
+					Result: models.H264CodecConfiguration{Width: intToPtr(640), Height: intToPtr(360), Bitrate: intToPtr(620000)},
+				},
+			}
+			json.NewEncoder(w).Encode(resp)
+		case "/encoding/configurations/video/h264/h265ResultID":
+			w.WriteHeader(http.StatusNotFound)
+			w.Write([]byte("404 - no API found with those values"))
+		case "/encoding/configurations/video/h265/h265ResultID":
+			resp := models.H265CodecConfigurationResponse{
+				Status: bitmovintypes.ResponseStatusSuccess,
+				Data: models.H265CodecConfigurationData{
+					Result: models.H265CodecConfiguration{Width: intToPtr(1920), Height: intToPtr(1080), Bitrate: intToPtr(3100000)},
+				},
+			}
+			json.NewEncoder(w).Encode(resp)
+		default:
+			t.Fatal(errors.New("unexpected path hit " + r.URL.Path))
+		}
+	}))
+	defer ts.Close()
+	prov := getBitmovinProvider(ts.URL)
+	job := &db.Job{
+		ProviderJobID:   encodingID,
+		ContentAware:    true,
+		StreamingParams: db.StreamingParams{Protocol: "hls"},
+	}
+	var status provider.JobStatus
+	err := prov.addPerTitleOutputFilesInfo(job, &status)
+	if err != nil {
+		t.Fatal(err)
+	}
+	expectedFiles := []provider.OutputFile{
+		{VideoCodec: "h264", Width: 640, Height: 360, Bitrate: 620000},
+		{VideoCodec: "hevc", Width: 1920, Height: 1080, Bitrate: 3100000},
+	}
+	if !reflect.DeepEqual(status.Output.Files, expectedFiles) {
+		t.Errorf("Output files: want %#v. Got %#v", expectedFiles, status.Output.Files)
+	}
+}
+
 func TestCancelJob(t *testing.T) {
 	testJobID := "this_is_a_job_id"
 	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
@@ -1942,8 +3709,12 @@ func TestCapabilities(t *testing.T) {
 	var prov bitmovinProvider
 	expected := provider.Capabilities{
 		InputFormats:  []string{"prores", "h264"},
-		OutputFormats: []string{"mp4", "mov", "hls", "webm"},
+		OutputFormats: []string{"mp4", "mov", "hls", "webm", "cmaf"},
 		Destinations:  []string{"s3"},
+		VideoCodecs:   []string{"h264", "hevc", "vp8", "vp9"},
+		AudioCodecs:   []string{"aac", "vorbis"},
+		HDR:           true,
+		ContentAware:  true,
 	}
 	cap := prov.Capabilities()
 	if !reflect.DeepEqual(cap, expected) {
@@ -1,5 +1,7 @@
 package provider
 
+import "strings"
+
 // Description fully describes a provider.
 //
 // It contains the name of the provider, along with its current heath status
@@ -13,11 +15,44 @@ type Description struct {
 
 // Capabilities describes the available features in the provider. It specificie
 // which input and output formats the provider supports, along with
-// supported destinations.
+// supported destinations, whether it handles the bit depth, color space
+// and HDR settings of video presets and whether it natively supports
+// content-aware encoding.
+//
+// VideoCodecs and AudioCodecs list the preset codecs the provider is able to
+// encode. Providers that leave them empty don't restrict the codecs.
 type Capabilities struct {
 	InputFormats  []string `json:"input"`
 	OutputFormats []string `json:"output"`
 	Destinations  []string `json:"destinations"`
+	VideoCodecs   []string `json:"videoCodecs,omitempty"`
+	AudioCodecs   []string `json:"audioCodecs,omitempty"`
+	HDR           bool     `json:"hdr"`
+	ContentAware  bool     `json:"contentAware"`
+}
+
+// SupportsVideoCodec returns whether the provider is able to encode video
+// presets with the given codec.
+func (c Capabilities) SupportsVideoCodec(codec string) bool {
+	return supportsCodec(c.VideoCodecs, codec)
+}
+
+// SupportsAudioCodec returns whether the provider is able to encode audio
+// presets with the given codec.
+func (c Capabilities) SupportsAudioCodec(codec string) bool {
+	return supportsCodec(c.AudioCodecs, codec)
+}
+
+func supportsCodec(codecs []string, codec string) bool {
+	if len(codecs) == 0 || codec == "" {
+		return true
+	}
+	for _, c := range codecs {
+		if strings.EqualFold(c, codec) {
+			return true
+		}
+	}
+	return false
 }
 
 // Health describes the current health status of the provider. If indicates
@@ -17,7 +17,9 @@ package elementalconductor
 
 import (
 	"encoding/xml"
+	"errors"
 	"fmt"
+	"net/http"
 	"path/filepath"
 	"strconv"
 	"strings"
@@ -50,7 +52,32 @@ func (p *elementalConductorProvider) DeletePreset(presetID string) error {
 	return p.client.DeletePreset(presetID)
 }
 
+// ValidatePreset checks whether the preset only uses settings that can be
+// stored in Elemental Conductor presets.
+func (p *elementalConductorProvider) ValidatePreset(preset db.Preset) error {
+	if preset.ProviderOverrides.For(Name) != nil {
+		return provider.ErrProviderOverridesNotSupported
+	}
+	// Elemental presets only carry the audio codec and bitrate
+	if (preset.Audio != db.AudioPreset{Codec: preset.Audio.Codec, Bitrate: preset.Audio.Bitrate}) {
+		return errors.New("channel layout, sample rate, profile and loudness audio settings are not supported with elementalconductor")
+	}
+	if preset.Video.FrameRate != "" || preset.Video.ScalingBehavior != "" || !preset.Video.Crop.IsZero() || preset.Video.Deinterlacer != "" {
+		return errors.New("frame rate, scaling, cropping and deinterlacing video settings are not supported with elementalconductor")
+	}
+	return nil
+}
+
 func (p *elementalConductorProvider) CreatePreset(preset db.Preset) (string, error) {
+	if err := p.ValidatePreset(preset); err != nil {
+		return "", err
+	}
+	// Elemental Conductor presets take plain integers for bitrates, GOP
+	// sizes and dimensions
+	preset, err := preset.Normalize()
+	if err != nil {
+		return "", err
+	}
 	elementalConductorPreset := elementalconductor.Preset{
 		XMLName: xml.Name{Local: "preset"},
 	}
@@ -59,7 +86,6 @@ func (p *elementalConductorProvider) CreatePreset(preset db.Preset) (string, err
 	elementalConductorPreset.Container = preset.Container
 	elementalConductorPreset.Profile = preset.Video.Profile
 	elementalConductorPreset.ProfileLevel = preset.Video.ProfileLevel
-	elementalConductorPreset.RateControl = preset.RateControl
 	elementalConductorPreset.Width = preset.Video.Width
 	elementalConductorPreset.Height = preset.Video.Height
 	elementalConductorPreset.VideoCodec = preset.Video.Codec
@@ -70,6 +96,12 @@ func (p *elementalConductorProvider) CreatePreset(preset db.Preset) (string, err
 	elementalConductorPreset.AudioCodec = preset.Audio.Codec
 	elementalConductorPreset.AudioBitrate = preset.Audio.Bitrate
 
+	// the rate control mode is part of the video description, which must be
+	// left out of audio-only presets
+	if !preset.AudioOnly() {
+		elementalConductorPreset.RateControl = preset.RateControl
+	}
+
 	if preset.Video.BFrames != "" {
 		elementalConductorPreset.GopNumBFrames = preset.Video.BFrames
 	}
@@ -84,13 +116,51 @@ func (p *elementalConductorProvider) CreatePreset(preset db.Preset) (string, err
 
 func (p *elementalConductorProvider) GetPreset(presetID string) (interface{}, error) {
 	preset, err := p.client.GetPreset(presetID)
+	if apiErr, ok := err.(*elementalconductor.APIError); ok && apiErr.Status == http.StatusNotFound {
+		return nil, provider.PresetNotFoundError{ID: presetID}
+	}
 	if err != nil {
 		return nil, err
 	}
 	return preset, err
 }
 
+func (p *elementalConductorProvider) NormalizePreset(native interface{}) (db.Preset, error) {
+	preset, ok := native.(*elementalconductor.Preset)
+	if !ok {
+		return db.Preset{}, fmt.Errorf("unexpected elementalconductor preset type %T", native)
+	}
+	return db.Preset{
+		Name:        preset.Name,
+		Description: preset.Description,
+		Container:   preset.Container,
+		RateControl: preset.RateControl,
+		Video: db.VideoPreset{
+			Profile:       preset.Profile,
+			ProfileLevel:  preset.ProfileLevel,
+			Width:         preset.Width,
+			Height:        preset.Height,
+			Codec:         preset.VideoCodec,
+			Bitrate:       preset.VideoBitrate,
+			GopSize:       preset.GopSize,
+			GopMode:       preset.GopMode,
+			InterlaceMode: preset.InterlaceMode,
+			BFrames:       preset.GopNumBFrames,
+		},
+		Audio: db.AudioPreset{
+			Codec:   preset.AudioCodec,
+			Bitrate: preset.AudioBitrate,
+		},
+	}, nil
+}
+
 func (p *elementalConductorProvider) Transcode(job *db.Job) (*provider.JobStatus, error) {
+	if len(job.AudioTracks) > 0 {
+		return nil, provider.ErrAudioTracksNotSupported
+	}
+	if job.ProviderOverrides.For(Name) != nil {
+		return nil, provider.ErrProviderOverridesNotSupported
+	}
 	newJob, err := p.newJob(job)
 	if err != nil {
 		return nil, err
@@ -174,9 +244,12 @@ func (p *elementalConductorProvider) getOutputFiles(job *elementalconductor.Job)
 	}
 	for _, stream := range job.StreamAssembly {
 		if file, ok := streamFiles[stream.Name]; ok {
-			file.VideoCodec = stream.VideoDescription.Codec
-			file.Width = stream.VideoDescription.GetWidth()
-			file.Height = stream.VideoDescription.GetHeight()
+			// audio-only streams don't have a video description
+			if stream.VideoDescription != nil {
+				file.VideoCodec = stream.VideoDescription.Codec
+				file.Width = stream.VideoDescription.GetWidth()
+				file.Height = stream.VideoDescription.GetHeight()
+			}
 			files = append(files, file)
 		}
 	}
@@ -1,6 +1,7 @@
 package elementalconductor
 
 import (
+	"net/http"
 	"strings"
 
 	"github.com/video-dev/go-elementalconductor"
@@ -10,8 +11,9 @@ import (
 
 type fakeElementalConductorClient struct {
 	*elementalconductor.Client
-	jobs         map[string]elementalconductor.Job
-	canceledJobs []string
+	jobs          map[string]elementalconductor.Job
+	canceledJobs  []string
+	createdPreset *elementalconductor.Preset
 }
 
 func newFakeElementalConductorClient(cfg *config.ElementalConductor) *fakeElementalConductorClient {
@@ -30,6 +32,9 @@ func newFakeElementalConductorClient(cfg *config.ElementalConductor) *fakeElemen
 }
 
 func (c *fakeElementalConductorClient) GetPreset(presetID string) (*elementalconductor.Preset, error) {
+	if strings.HasPrefix(presetID, "missing") {
+		return nil, &elementalconductor.APIError{Status: http.StatusNotFound, Errors: "not found"}
+	}
 	container := elementalconductor.MPEG4
 	if strings.Contains(presetID, "hls") {
 		container = elementalconductor.AppleHTTPLiveStreaming
@@ -41,6 +46,7 @@ func (c *fakeElementalConductorClient) GetPreset(presetID string) (*elementalcon
 }
 
 func (c *fakeElementalConductorClient) CreatePreset(preset *elementalconductor.Preset) (*elementalconductor.Preset, error) {
+	c.createdPreset = preset
 	return &elementalconductor.Preset{
 		Name: preset.Name,
 	}, nil
@@ -1,6 +1,7 @@
 package elementalconductor
 
 import (
+	"encoding/json"
 	"encoding/xml"
 	"reflect"
 	"testing"
@@ -646,6 +647,25 @@ func TestElementalNewJobPresetNotFound(t *testing.T) {
 	}
 }
 
+func TestElementalGetPresetNotFound(t *testing.T) {
+	elementalConductorConfig := config.Config{
+		ElementalConductor: &config.ElementalConductor{
+			Host:        "https://mybucket.s3.amazonaws.com/destination-dir/",
+			UserLogin:   "myuser",
+			APIKey:      "elemental-api-key",
+			AuthExpires: 30,
+		},
+	}
+	prov, err := fakeElementalConductorFactory(&elementalConductorConfig)
+	if err != nil {
+		t.Fatal(err)
+	}
+	_, err = prov.GetPreset("missing-preset")
+	if e := (provider.PresetNotFoundError{ID: "missing-preset"}); err != e {
+		t.Errorf("Wrong error returned. Want %#v. Got %#v", e, err)
+	}
+}
+
 func TestJobStatusOutputDestination(t *testing.T) {
 	tests := []struct {
 		job            db.Job
@@ -1196,3 +1216,132 @@ func TestCapabilities(t *testing.T) {
 		t.Errorf("Capabilities: want %#v. Got %#v", expected, cap)
 	}
 }
+
+func TestCreatePresetUnsupportedAudioSettings(t *testing.T) {
+	var prov elementalConductorProvider
+	_, err := prov.CreatePreset(db.Preset{
+		Name:      "audio",
+		Container: "mp4",
+		Audio:     db.AudioPreset{Codec: "aac", Bitrate: "64000", SampleRate: "44100"},
+	})
+	if err == nil {
+		t.Fatal("unexpected <nil> error")
+	}
+}
+
+func TestElementalTranscodeAudioTracksNotSupported(t *testing.T) {
+	var prov elementalConductorProvider
+	_, err := prov.Transcode(&db.Job{
+		ID:          "job-1",
+		AudioTracks: []db.AudioTrack{{TrackIndex: 2, Language: "es"}},
+	})
+	if err != provider.ErrAudioTracksNotSupported {
+		t.Errorf("wrong error returned. Want %#v. Got %#v", provider.ErrAudioTracksNotSupported, err)
+	}
+}
+
+func TestCreatePresetNumericSettings(t *testing.T) {
+	client := newFakeElementalConductorClient(&config.ElementalConductor{})
+	prov := elementalConductorProvider{client: client}
+	_, err := prov.CreatePreset(db.Preset{
+		Name:      "video",
+		Container: "mp4",
+		Video:     db.VideoPreset{Codec: "h264", Width: "1280", Height: "0720", Bitrate: "2.5M", GopSize: "90.0", BFrames: "03"},
+		Audio:     db.AudioPreset{Codec: "aac", Bitrate: "128k"},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	got := client.createdPreset
+	if got.Width != "1280" || got.Height != "720" || got.VideoBitrate != "2500000" || got.GopSize != "90" || got.GopNumBFrames != "3" || got.AudioBitrate != "128000" {
+		t.Errorf("wrong numeric settings: %#v", got)
+	}
+}
+
+func TestElementalProviderOverridesNotSupported(t *testing.T) {
+	var prov elementalConductorProvider
+	overrides := db.ProviderOverrides{Name: json.RawMessage(`{"priority":"high"}`)}
+	_, err := prov.Transcode(&db.Job{ID: "job-1", ProviderOverrides: overrides})
+	if err != provider.ErrProviderOverridesNotSupported {
+		t.Errorf("wrong error returned. Want %#v. Got %#v", provider.ErrProviderOverridesNotSupported, err)
+	}
+	_, err = prov.CreatePreset(db.Preset{Name: "preset-1", ProviderOverrides: overrides})
+	if err != provider.ErrProviderOverridesNotSupported {
+		t.Errorf("wrong error returned. Want %#v. Got %#v", provider.ErrProviderOverridesNotSupported, err)
+	}
+}
+
+func TestCreatePresetUnsupportedVideoSettings(t *testing.T) {
+	var prov elementalConductorProvider
+	_, err := prov.CreatePreset(db.Preset{
+		Name:      "video",
+		Container: "mp4",
+		Video:     db.VideoPreset{Codec: "h264", Bitrate: "2000000", FrameRate: "30"},
+		Audio:     db.AudioPreset{Codec: "aac", Bitrate: "64000"},
+	})
+	if err == nil {
+		t.Fatal("unexpected <nil> error")
+	}
+}
+
+func TestElementalValidatePreset(t *testing.T) {
+	var prov elementalConductorProvider
+	preset := db.Preset{
+		Name:      "video",
+		Container: "mp4",
+		Video:     db.VideoPreset{Codec: "h264", Bitrate: "2000000"},
+		Audio:     db.AudioPreset{Codec: "aac", Bitrate: "64000"},
+	}
+	if err := prov.ValidatePreset(preset); err != nil {
+		t.Fatal(err)
+	}
+	preset.Video.Deinterlacer = "deinterlace"
+	err := prov.ValidatePreset(preset)
+	if expectedMsg := "frame rate, scaling, cropping and deinterlacing video settings are not supported with elementalconductor"; err == nil || err.Error() != expectedMsg {
+		t.Errorf("wrong error returned\nwant %q\ngot  %v", expectedMsg, err)
+	}
+}
+
+func TestNormalizePreset(t *testing.T) {
+	var prov elementalConductorProvider
+	preset, err := prov.NormalizePreset(&elementalconductor.Preset{
+		Name:          "preset-1",
+		Container:     "mp4",
+		Profile:       "Main",
+		ProfileLevel:  "3.1",
+		Width:         "1280",
+		Height:        "720",
+		VideoCodec:    "h264",
+		VideoBitrate:  "2500000",
+		GopSize:       "90",
+		GopMode:       "frames",
+		InterlaceMode: "progressive",
+		AudioCodec:    "aac",
+		AudioBitrate:  "128000",
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	expected := db.Preset{
+		Name:      "preset-1",
+		Container: "mp4",
+		Video: db.VideoPreset{
+			Profile:       "Main",
+			ProfileLevel:  "3.1",
+			Width:         "1280",
+			Height:        "720",
+			Codec:         "h264",
+			Bitrate:       "2500000",
+			GopSize:       "90",
+			GopMode:       "frames",
+			InterlaceMode: "progressive",
+		},
+		Audio: db.AudioPreset{Codec: "aac", Bitrate: "128000"},
+	}
+	if !reflect.DeepEqual(preset, expected) {
+		t.Errorf("wrong preset\nwant %#v\ngot  %#v", expected, preset)
+	}
+	if _, err = prov.NormalizePreset("preset-1"); err == nil {
+		t.Error("unexpected <nil> error for a non-preset value")
+	}
+}
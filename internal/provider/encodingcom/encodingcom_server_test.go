@@ -9,6 +9,7 @@ import (
 	"log"
 	"net/http"
 	"net/http/httptest"
+	"sort"
 	"strconv"
 	"time"
 
@@ -106,6 +107,8 @@ func (s *encodingComFakeServer) ServeHTTP(w http.ResponseWriter, r *http.Request
 		s.savePreset(w, req)
 	case "DeletePreset":
 		s.deletePreset(w, req)
+	case "GetPresetsList":
+		s.listPresets(w, req)
 	default:
 		s.Error(w, "invalid action")
 	}
@@ -248,6 +251,28 @@ func (s *encodingComFakeServer) getPreset(w io.Writer, req request) {
 	json.NewEncoder(w).Encode(resp)
 }
 
+func (s *encodingComFakeServer) listPresets(w io.Writer, _ request) {
+	names := make([]string, 0, len(s.presets))
+	for name := range s.presets {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	presets := make([]encodingcom.Preset, len(names))
+	for i, name := range names {
+		preset := s.presets[name]
+		presets[i] = encodingcom.Preset{
+			Name:   name,
+			Format: convertFormat(preset.Request.Format[0]),
+			Output: preset.Request.Format[0].Output[0],
+			Type:   encodingcom.UserPresets,
+		}
+	}
+	resp := map[string]*encodingcom.ListPresetsResponse{
+		"response": {UserPresets: presets},
+	}
+	json.NewEncoder(w).Encode(resp)
+}
+
 func (s *encodingComFakeServer) deletePreset(w io.Writer, req request) {
 	if _, ok := s.presets[req.Name]; !ok {
 		s.Error(w, "preset not found")
@@ -0,0 +1,87 @@
+package hybrik
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"net/url"
+	"strings"
+
+	hwrapper "github.com/hybrik/hybrik-sdk-go"
+)
+
+// fakeHybrikClient is an in-memory implementation of the Hybrik client. It
+// keeps the stored presets by name and reports errors the same way the SDK
+// does for non-200 responses.
+type fakeHybrikClient struct {
+	presets    map[string]hwrapper.Preset
+	queuedJobs []string
+
+	// getPresetErr is returned by GetPreset when set.
+	getPresetErr error
+}
+
+func newFakeHybrikClient() *fakeHybrikClient {
+	return &fakeHybrikClient{presets: make(map[string]hwrapper.Preset)}
+}
+
+func (c *fakeHybrikClient) CallAPI(method string, apiPath string, _ url.Values, body io.Reader) (string, error) {
+	presetID := strings.TrimPrefix(apiPath, "/presets/")
+	if method != "PUT" || presetID == apiPath {
+		return "", fmt.Errorf("405 - %s %s: unsupported call", method, apiPath)
+	}
+	if _, ok := c.presets[presetID]; !ok {
+		return "", fmt.Errorf(`404 - %s %s: {"success":"false","message":"preset not found"}`, method, apiPath)
+	}
+	data, err := ioutil.ReadAll(body)
+	if err != nil {
+		return "", err
+	}
+	var preset hwrapper.Preset
+	if err = json.Unmarshal(data, &preset); err != nil {
+		return "", err
+	}
+	c.presets[presetID] = preset
+	return string(data), nil
+}
+
+func (c *fakeHybrikClient) QueueJob(job string) (string, error) {
+	c.queuedJobs = append(c.queuedJobs, job)
+	return fmt.Sprintf("job-%d", len(c.queuedJobs)), nil
+}
+
+func (c *fakeHybrikClient) GetJobInfo(id string) (hwrapper.JobInfo, error) {
+	return hwrapper.JobInfo{ID: id, Status: completed}, nil
+}
+
+func (c *fakeHybrikClient) StopJob(string) error {
+	return nil
+}
+
+func (c *fakeHybrikClient) GetPreset(presetID string) (hwrapper.Preset, error) {
+	if c.getPresetErr != nil {
+		return hwrapper.Preset{}, c.getPresetErr
+	}
+	preset, ok := c.presets[presetID]
+	if !ok {
+		return hwrapper.Preset{}, fmt.Errorf(`404 - GET /presets/%s: {"success":"false","message":"preset not found"}`, presetID)
+	}
+	return preset, nil
+}
+
+func (c *fakeHybrikClient) CreatePreset(preset hwrapper.Preset) (hwrapper.Preset, error) {
+	if _, ok := c.presets[preset.Name]; ok {
+		return hwrapper.Preset{}, hwrapper.ErrCreatePreset{Msg: "preset already exists"}
+	}
+	c.presets[preset.Name] = preset
+	return preset, nil
+}
+
+func (c *fakeHybrikClient) DeletePreset(presetID string) error {
+	if _, ok := c.presets[presetID]; !ok {
+		return fmt.Errorf(`404 - DELETE /presets/%s: {"success":"false","message":"preset not found"}`, presetID)
+	}
+	delete(c.presets, presetID)
+	return nil
+}
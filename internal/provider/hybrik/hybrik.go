@@ -1,6 +1,7 @@
 package hybrik
 
 import (
+	"bytes"
 	"encoding/json"
 	"errors"
 	"fmt"
@@ -40,6 +41,17 @@ var (
 	// ErrVideoHeightNan is an error returned when the preset video height of db.Preset is not a valid number
 	ErrVideoHeightNan = errors.New("preset video height not a number")
 
+	// ErrAudioSampleRateNan is an error returned when the preset audio sample rate of db.Preset is not a valid number
+	ErrAudioSampleRateNan = errors.New("preset audio sample rate not a number")
+
+	// ErrAudioTrackSourceMedia is returned when an alternate audio track
+	// references a separate audio file
+	ErrAudioTrackSourceMedia = provider.InvalidAudioTracksError("alternate audio tracks from separate files are not supported with hybrik")
+
+	// ErrAudioTracksWithoutHLS is returned when a job with alternate audio
+	// tracks doesn't have any HLS output carrying audio
+	ErrAudioTracksWithoutHLS = provider.InvalidAudioTracksError("alternate audio tracks require an HLS output with audio")
+
 	// ErrUnsupportedContainer is returned when the container format is not present in the provider's capabilities list
 	ErrUnsupportedContainer = errors.New("container format unsupported. Hybrik provider capabilities may need to be updated")
 )
@@ -77,6 +89,9 @@ func hybrikTranscoderFactory(cfg *config.Config) (provider.TranscodingProvider,
 }
 
 func (hp *hybrikProvider) Transcode(job *db.Job) (*provider.JobStatus, error) {
+	if err := checkHLSOptions(job.StreamingParams.HLSOptions()); err != nil {
+		return &provider.JobStatus{}, err
+	}
 	cj, err := hp.presetsToTranscodeJob(job)
 	if err != nil {
 		return &provider.JobStatus{}, err
@@ -94,6 +109,27 @@ func (hp *hybrikProvider) Transcode(job *db.Job) (*provider.JobStatus, error) {
 	}, nil
 }
 
+// checkHLSOptions rejects the HLS options that can't be honored through the
+// hybrik wrapper, whose HLS targets and manifest creator only take the segment
+// duration. Hybrik-native HLS settings can still be set with job overrides.
+func checkHLSOptions(options db.HLSOptions) error {
+	switch {
+	case options.IFramePlaylist:
+		return provider.HLSOptionNotSupportedError{Option: "iFramePlaylist"}
+	case options.SingleFile:
+		return provider.HLSOptionNotSupportedError{Option: "singleFile"}
+	case options.ProgramDateTime:
+		return provider.HLSOptionNotSupportedError{Option: "programDateTime"}
+	case strings.EqualFold(options.PlaylistType, db.HLSPlaylistTypeEvent):
+		return provider.HLSOptionNotSupportedError{Option: "playlistType=" + db.HLSPlaylistTypeEvent}
+	case options.FMP4():
+		return provider.HLSOptionNotSupportedError{Option: "segmentContainer=" + db.HLSSegmentContainerFMP4}
+	case options.TargetVersion != 0:
+		return provider.HLSOptionNotSupportedError{Option: "targetVersion"}
+	}
+	return nil
+}
+
 func (hp *hybrikProvider) mountTranscodeElement(elementID, id, outputFilename, destination string, duration uint, preset hwrapper.Preset) hwrapper.Element {
 	var e hwrapper.Element
 	var subLocation *hwrapper.TranscodeLocation
@@ -139,6 +175,79 @@ func (hp *hybrikProvider) mountTranscodeElement(elementID, id, outputFilename, d
 	return e
 }
 
+// audioTrackLocationPayload is the payload of the transcode elements of
+// alternate audio tracks, which the hybrik wrapper doesn't model.
+type audioTrackLocationPayload struct {
+	Location hwrapper.TranscodeLocation `json:"location"`
+	Targets  []audioTrackLocationTarget `json:"targets"`
+}
+
+type audioTrackLocationTarget struct {
+	FilePattern string                      `json:"file_pattern"`
+	Container   audioTrackContainer         `json:"container"`
+	Audio       []audioTrackAudioTarget     `json:"audio"`
+	Location    *hwrapper.TranscodeLocation `json:"location,omitempty"`
+}
+
+type audioTrackContainer struct {
+	Kind            string `json:"kind"`
+	SegmentDuration uint   `json:"segment_duration,omitempty"`
+}
+
+type audioTrackAudioTarget struct {
+	hwrapper.AudioTarget
+	Source   []audioTrackSource `json:"source"`
+	Language string             `json:"language,omitempty"`
+}
+
+type audioTrackSource struct {
+	Track uint `json:"track"`
+}
+
+func (hp *hybrikProvider) mountAudioTrackElement(elementID, id, outputFilename, destination string, duration uint, audio hwrapper.AudioTarget, track db.AudioTrack) hwrapper.Element {
+	var subLocation *hwrapper.TranscodeLocation
+
+	subPath := path.Dir(outputFilename)
+	if subPath != "." && subPath != "/" {
+		subLocation = &hwrapper.TranscodeLocation{
+			StorageProvider: "relative",
+			Path:            subPath,
+		}
+	}
+
+	return hwrapper.Element{
+		UID:  fmt.Sprintf(transcodeElementIDTemplate, elementID),
+		Kind: "transcode",
+		Task: &hwrapper.ElementTaskOptions{
+			Name: "Transcode - audio " + track.DisplayName(),
+		},
+		Payload: audioTrackLocationPayload{
+			Location: hwrapper.TranscodeLocation{
+				StorageProvider: "s3",
+				Path:            fmt.Sprintf("%s/j%s", destination, id),
+			},
+			Targets: []audioTrackLocationTarget{
+				{
+					Location:    subLocation,
+					FilePattern: path.Base(outputFilename),
+					Container: audioTrackContainer{
+						Kind:            hls,
+						SegmentDuration: duration,
+					},
+					Audio: []audioTrackAudioTarget{
+						{
+							AudioTarget: audio,
+							// hybrik track numbers are 0-based
+							Source:   []audioTrackSource{{Track: track.TrackIndex - 1}},
+							Language: track.Language,
+						},
+					},
+				},
+			},
+		},
+	}
+}
+
 type presetResult struct {
 	presetID string
 	preset   interface{}
@@ -205,6 +314,10 @@ func (hp *hybrikProvider) presetsToTranscodeJob(job *db.Job) (string, error) {
 		presets[res.presetID] = res.preset
 	}
 
+	// alternate audio tracks are encoded with the audio settings of the
+	// first HLS output carrying audio
+	var audioTrackTarget *hwrapper.AudioTarget
+
 	// create transcode elements for each target
 	for _, output := range job.Outputs {
 		presetID, ok := output.Preset.ProviderMapping[Name]
@@ -227,6 +340,9 @@ func (hp *hybrikProvider) presetsToTranscodeJob(job *db.Job) (string, error) {
 		if len(preset.Payload.Targets) > 0 && preset.Payload.Targets[0].Container.Kind == hls {
 			hlsElementIds = append(hlsElementIds, len(transcodeElementIds))
 			segmentDur = job.StreamingParams.SegmentDuration
+			if audioTrackTarget == nil && len(preset.Payload.Targets[0].Audio) > 0 {
+				audioTrackTarget = &preset.Payload.Targets[0].Audio[0]
+			}
 		}
 
 		taskIndex := strconv.Itoa(len(transcodeElementIds))
@@ -236,6 +352,25 @@ func (hp *hybrikProvider) presetsToTranscodeJob(job *db.Job) (string, error) {
 		elements = append(elements, e)
 	}
 
+	if len(job.AudioTracks) > 0 {
+		if job.StreamingParams.Protocol != hls || audioTrackTarget == nil {
+			return "", ErrAudioTracksWithoutHLS
+		}
+		playlistDir := path.Dir(job.StreamingParams.PlaylistFileName)
+		for i, track := range job.AudioTracks {
+			if track.SourceMedia != "" {
+				return "", ErrAudioTrackSourceMedia
+			}
+			fileName := path.Join(playlistDir, fmt.Sprintf("audio_%s_%d.m3u8", track.Language, i+1))
+			hlsElementIds = append(hlsElementIds, len(transcodeElementIds))
+			taskIndex := strconv.Itoa(len(transcodeElementIds))
+			e := hp.mountAudioTrackElement(taskIndex, job.ID, fileName, hp.config.Destination, job.StreamingParams.SegmentDuration, *audioTrackTarget, track)
+
+			transcodeElementIds = append(transcodeElementIds, e.UID)
+			elements = append(elements, e)
+		}
+	}
+
 	// connect the source element to each of the transcode elements
 	transcodeSuccessConnections := make([]hwrapper.ToSuccess, len(transcodeElementIds))
 	for i, id := range transcodeElementIds {
@@ -304,6 +439,13 @@ func (hp *hybrikProvider) presetsToTranscodeJob(job *db.Job) (string, error) {
 		)
 	}
 
+	// job overrides are checked against the job and element fields of the
+	// wrapper, element payloads are left as given since their schema depends
+	// on the element kind
+	if err := provider.ApplyOverrides(&cj, job.ProviderOverrides.For(Name), true); err != nil {
+		return "", err
+	}
+
 	resp, err := json.Marshal(cj)
 	if err != nil {
 		return "", err
@@ -346,21 +488,62 @@ func (hp *hybrikProvider) CancelJob(id string) error {
 	return hp.c.StopJob(id)
 }
 
+// ValidatePreset checks whether the preset can be created in Hybrik. The
+// container must be one of the output formats listed in Capabilities.
+func (hp *hybrikProvider) ValidatePreset(preset db.Preset) error {
+	_, err := hp.hybrikPresetFrom(preset)
+	return err
+}
+
 func (hp *hybrikProvider) CreatePreset(preset db.Preset) (string, error) {
-	var minGOPFrames, maxGOPFrames, gopSize int
+	p, err := hp.hybrikPresetFrom(preset)
+	if err != nil {
+		return "", err
+	}
 
-	gopSize, err := strconv.Atoi(preset.Video.GopSize)
+	resultPreset, err := hp.c.CreatePreset(p)
 	if err != nil {
 		return "", err
 	}
 
-	if preset.Video.GopMode == "fixed" {
-		minGOPFrames = gopSize
-		maxGOPFrames = gopSize
-	} else {
-		maxGOPFrames = gopSize
+	return resultPreset.Name, nil
+}
+
+// UpdatePreset replaces the preset with the given ID. Hybrik presets are
+// keyed by name, so the replacement keeps the ID of the previous preset.
+// The SDK has no call for it, so the request goes through CallAPI.
+func (hp *hybrikProvider) UpdatePreset(presetID string, preset db.Preset) (string, error) {
+	p, err := hp.hybrikPresetFrom(preset)
+	if err != nil {
+		return "", err
+	}
+	p.Key = presetID
+	p.Name = presetID
+
+	body, err := json.Marshal(p)
+	if err != nil {
+		return "", err
+	}
+	resp, err := hp.c.CallAPI("PUT", "/presets/"+presetID, nil, bytes.NewReader(body))
+	if err != nil {
+		return "", presetError(presetID, err)
+	}
+
+	var result struct {
+		Success string `json:"success"`
+		Message string `json:"message"`
+	}
+	if err = json.Unmarshal([]byte(resp), &result); err != nil {
+		return "", err
 	}
+	if result.Success == "false" {
+		return "", fmt.Errorf("unable to update preset, error: %s", result.Message)
+	}
+
+	return presetID, nil
+}
 
+func (hp *hybrikProvider) hybrikPresetFrom(preset db.Preset) (hwrapper.Preset, error) {
 	container := ""
 	for _, c := range hp.Capabilities().OutputFormats {
 		if preset.Container == c || (preset.Container == "m3u8" && c == hls) {
@@ -369,17 +552,69 @@ func (hp *hybrikProvider) CreatePreset(preset db.Preset) (string, error) {
 	}
 
 	if container == "" {
-		return "", ErrUnsupportedContainer
+		return hwrapper.Preset{}, ErrUnsupportedContainer
+	}
+
+	audioTarget, err := hybrikAudioTargetFrom(preset)
+	if err != nil {
+		return hwrapper.Preset{}, err
+	}
+
+	// audio-only presets don't carry any video settings
+	var videoTarget hwrapper.VideoTarget
+	if !preset.AudioOnly() {
+		videoTarget, err = hybrikVideoTargetFrom(preset)
+		if err != nil {
+			return hwrapper.Preset{}, err
+		}
+	}
+
+	p := hwrapper.Preset{
+		Key:         preset.Name,
+		Name:        preset.Name,
+		Description: preset.Description,
+		Kind:        "transcode",
+		Path:        hp.config.PresetPath,
+		Payload: hwrapper.PresetPayload{
+			Targets: []hwrapper.PresetTarget{
+				{
+					FilePattern:   "",
+					Container:     hwrapper.TranscodeContainer{Kind: container},
+					Video:         videoTarget,
+					Audio:         []hwrapper.AudioTarget{audioTarget},
+					ExistingFiles: "replace",
+					UID:           "target",
+				},
+			},
+		},
+	}
+
+	err = provider.ApplyOverrides(&p, preset.ProviderOverrides.For(Name), true)
+	if err != nil {
+		return hwrapper.Preset{}, err
 	}
 
-	bitrate, err := strconv.Atoi(preset.Video.Bitrate)
+	return p, nil
+}
+
+func hybrikVideoTargetFrom(preset db.Preset) (hwrapper.VideoTarget, error) {
+	var minGOPFrames, maxGOPFrames, gopSize int
+
+	gopSize, err := preset.Video.GopFrames()
 	if err != nil {
-		return "", ErrBitrateNan
+		return hwrapper.VideoTarget{}, err
 	}
 
-	audioBitrate, err := strconv.Atoi(preset.Audio.Bitrate)
+	if preset.Video.GopMode == "fixed" {
+		minGOPFrames = gopSize
+		maxGOPFrames = gopSize
+	} else {
+		maxGOPFrames = gopSize
+	}
+
+	bitrate, err := preset.Video.BitrateBps()
 	if err != nil {
-		return "", ErrBitrateNan
+		return hwrapper.VideoTarget{}, ErrBitrateNan
 	}
 
 	var videoWidth *int
@@ -387,71 +622,128 @@ func (hp *hybrikProvider) CreatePreset(preset db.Preset) (string, error) {
 
 	if preset.Video.Width != "" {
 		var presetWidth int
-		presetWidth, err = strconv.Atoi(preset.Video.Width)
+		presetWidth, err = preset.Video.WidthPixels()
 		if err != nil {
-			return "", ErrVideoWidthNan
+			return hwrapper.VideoTarget{}, ErrVideoWidthNan
 		}
 		videoWidth = &presetWidth
 	}
 
 	if preset.Video.Height != "" {
 		var presetHeight int
-		presetHeight, err = strconv.Atoi(preset.Video.Height)
+		presetHeight, err = preset.Video.HeightPixels()
 		if err != nil {
-			return "", ErrVideoHeightNan
+			return hwrapper.VideoTarget{}, ErrVideoHeightNan
 		}
 		videoHeight = &presetHeight
 	}
 
+	videoCodec := preset.Video.Codec
 	videoProfile := strings.ToLower(preset.Video.Profile)
 	videoLevel := preset.Video.ProfileLevel
 
-	if preset.Video.Codec == "vp8" {
+	// the hybrik wrapper has no AV1 settings
+	switch strings.ToLower(preset.Video.Codec) {
+	case db.VideoCodecAV1:
+		return hwrapper.VideoTarget{}, fmt.Errorf("video codec %q is not supported with hybrik", preset.Video.Codec)
+	case db.VideoCodecVP8, db.VideoCodecVP9:
 		videoProfile = ""
 		videoLevel = ""
 	}
 
-	p := hwrapper.Preset{
-		Key:         preset.Name,
-		Name:        preset.Name,
-		Description: preset.Description,
-		Kind:        "transcode",
-		Path:        hp.config.PresetPath,
-		Payload: hwrapper.PresetPayload{
-			Targets: []hwrapper.PresetTarget{
-				{
-					FilePattern: "",
-					Container:   hwrapper.TranscodeContainer{Kind: container},
-					Video: hwrapper.VideoTarget{
-						Width:         videoWidth,
-						Height:        videoHeight,
-						Codec:         preset.Video.Codec,
-						BitrateKb:     bitrate / 1000,
-						MinGOPFrames:  minGOPFrames,
-						MaxGOPFrames:  maxGOPFrames,
-						Profile:       videoProfile,
-						Level:         videoLevel,
-						InterlaceMode: preset.Video.InterlaceMode,
-					},
-					Audio: []hwrapper.AudioTarget{
-						{
-							Codec:     preset.Audio.Codec,
-							BitrateKb: audioBitrate / 1000,
-						},
-					},
-					ExistingFiles: "replace",
-					UID:           "target",
-				},
-			},
-		},
+	if preset.Video.IsHEVC() {
+		if tier := strings.ToLower(preset.Video.Tier); tier != "" && tier != db.HEVCTierMain {
+			return hwrapper.VideoTarget{}, fmt.Errorf("hevc tier %q is not supported with hybrik", preset.Video.Tier)
+		}
+		// the hybrik wrapper can't request fMP4 segments, which HLS
+		// requires for HEVC
+		if preset.Container == "m3u8" {
+			return hwrapper.VideoTarget{}, errors.New("hevc is not supported with HLS outputs on hybrik")
+		}
+		videoCodec = "h265"
+		videoLevel = preset.Video.HEVCLevel()
 	}
 
-	resultPreset, err := hp.c.CreatePreset(p)
+	// the hybrik wrapper only takes integer frame rates and has no scaling,
+	// cropping or deinterlacing settings
+	var frameRate int
+	if preset.Video.FrameRate != "" {
+		var ok bool
+		if frameRate, ok = preset.Video.IntegerFrameRate(); !ok {
+			return hwrapper.VideoTarget{}, fmt.Errorf("frame rate %q is not supported with hybrik", preset.Video.FrameRate)
+		}
+	}
+	if preset.Video.ScalingBehavior != "" {
+		return hwrapper.VideoTarget{}, fmt.Errorf("scaling behavior %q is not supported with hybrik", preset.Video.ScalingBehavior)
+	}
+	if !preset.Video.Crop.IsZero() {
+		return hwrapper.VideoTarget{}, errors.New("cropping is not supported with hybrik")
+	}
+	if preset.Video.Deinterlacer != "" {
+		return hwrapper.VideoTarget{}, fmt.Errorf("deinterlacer %q is not supported with hybrik", preset.Video.Deinterlacer)
+	}
+
+	return hwrapper.VideoTarget{
+		Width:         videoWidth,
+		Height:        videoHeight,
+		Codec:         videoCodec,
+		BitrateKb:     bitrate / 1000,
+		MinGOPFrames:  minGOPFrames,
+		MaxGOPFrames:  maxGOPFrames,
+		Profile:       videoProfile,
+		Level:         videoLevel,
+		FrameRate:     frameRate,
+		InterlaceMode: preset.Video.InterlaceMode,
+	}, nil
+}
+
+func hybrikAudioTargetFrom(preset db.Preset) (hwrapper.AudioTarget, error) {
+	bitrate, err := preset.Audio.BitrateBps()
 	if err != nil {
-		return "", err
+		return hwrapper.AudioTarget{}, ErrBitrateNan
 	}
 
-	return resultPreset.Name, nil
+	if strings.ToLower(preset.Audio.Codec) == db.AudioCodecOpus {
+		return hwrapper.AudioTarget{}, fmt.Errorf("audio codec %q is not supported with hybrik", preset.Audio.Codec)
+	}
+
+	switch strings.ToLower(preset.Audio.Profile) {
+	case "", db.AudioProfileLC:
+	default:
+		return hwrapper.AudioTarget{}, fmt.Errorf("audio profile %q is not supported with hybrik", preset.Audio.Profile)
+	}
+
+	if preset.Audio.LoudnessStandard != "" {
+		return hwrapper.AudioTarget{}, fmt.Errorf("loudness standard %q is not supported with hybrik", preset.Audio.LoudnessStandard)
+	}
+
+	var channels int
+	switch strings.ToLower(preset.Audio.ChannelLayout) {
+	case "":
+	case db.AudioChannelLayoutMono:
+		channels = 1
+	case db.AudioChannelLayoutStereo:
+		channels = 2
+	case db.AudioChannelLayoutSurround:
+		channels = 6
+	default:
+		return hwrapper.AudioTarget{}, fmt.Errorf("channel layout %q is not supported with hybrik", preset.Audio.ChannelLayout)
+	}
+
+	var sampleRate int
+	if preset.Audio.SampleRate != "" {
+		sampleRate, err = strconv.Atoi(preset.Audio.SampleRate)
+		if err != nil {
+			return hwrapper.AudioTarget{}, ErrAudioSampleRateNan
+		}
+	}
+
+	return hwrapper.AudioTarget{
+		Codec:      preset.Audio.Codec,
+		Channels:   channels,
+		SampleRate: sampleRate,
+		BitrateKb:  bitrate / 1000,
+	}, nil
 }
 
 func (hp *hybrikProvider) DeletePreset(presetID string) error {
@@ -461,12 +753,69 @@ func (hp *hybrikProvider) DeletePreset(presetID string) error {
 func (hp *hybrikProvider) GetPreset(presetID string) (interface{}, error) {
 	preset, err := hp.c.GetPreset(presetID)
 	if err != nil {
-		return nil, err
+		return nil, presetError(presetID, err)
 	}
 
 	return preset, nil
 }
 
+// presetError maps errors of calls on the preset with the given ID,
+// reporting 404 responses as provider.PresetNotFoundError. The SDK returns
+// non-200 responses as errors formatted as "<status> - <method> <path>:
+// <body>".
+func presetError(presetID string, err error) error {
+	if strings.HasPrefix(err.Error(), "404 - ") {
+		return provider.PresetNotFoundError{ID: presetID}
+	}
+	return err
+}
+
+// NormalizePreset maps the Hybrik preset back into the canonical preset
+// fields, reading the settings of its first target.
+func (hp *hybrikProvider) NormalizePreset(native interface{}) (db.Preset, error) {
+	preset, ok := native.(hwrapper.Preset)
+	if !ok {
+		return db.Preset{}, fmt.Errorf("unexpected hybrik preset type %T", native)
+	}
+	if len(preset.Payload.Targets) == 0 {
+		return db.Preset{}, errors.New("hybrik preset without targets")
+	}
+	target := preset.Payload.Targets[0]
+	normalized := db.Preset{
+		Name:        preset.Name,
+		Description: preset.Description,
+		Container:   target.Container.Kind,
+	}
+	if normalized.Container == hls {
+		normalized.Container = "m3u8"
+	}
+	if video := target.Video; video.Codec != "" {
+		normalized.Video.Codec = video.Codec
+		if video.Codec == "h265" {
+			normalized.Video.Codec = db.VideoCodecHEVC
+		}
+		if video.Width != nil {
+			normalized.Video.Width = strconv.Itoa(*video.Width)
+		}
+		if video.Height != nil {
+			normalized.Video.Height = strconv.Itoa(*video.Height)
+		}
+		if video.BitrateKb > 0 {
+			normalized.Video.Bitrate = strconv.Itoa(video.BitrateKb * 1000)
+		}
+		if video.MaxGOPFrames > 0 {
+			normalized.Video.GopSize = strconv.Itoa(video.MaxGOPFrames)
+		}
+	}
+	if len(target.Audio) > 0 {
+		normalized.Audio.Codec = target.Audio[0].Codec
+		if target.Audio[0].BitrateKb > 0 {
+			normalized.Audio.Bitrate = strconv.Itoa(target.Audio[0].BitrateKb * 1000)
+		}
+	}
+	return normalized, nil
+}
+
 // Healthcheck should return nil if the provider is currently available
 // for transcoding videos, otherwise it should return an error
 // explaining what's going on.
@@ -483,5 +832,7 @@ func (hp *hybrikProvider) Capabilities() provider.Capabilities {
 		InputFormats:  []string{"prores", "h264"},
 		OutputFormats: []string{"mp4", "hls", "webm", "mov"},
 		Destinations:  []string{"s3"},
+		VideoCodecs:   []string{db.VideoCodecH264, db.VideoCodecHEVC, db.VideoCodecVP8, db.VideoCodecVP9},
+		AudioCodecs:   []string{db.AudioCodecAAC, db.AudioCodecVorbis},
 	}
 }
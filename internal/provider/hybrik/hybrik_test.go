@@ -0,0 +1,481 @@
+package hybrik
+
+import (
+	"encoding/json"
+	"errors"
+	"reflect"
+	"strings"
+	"testing"
+
+	hwrapper "github.com/hybrik/hybrik-sdk-go"
+	"github.com/video-dev/video-transcoding-api/v2/config"
+	"github.com/video-dev/video-transcoding-api/v2/db"
+	"github.com/video-dev/video-transcoding-api/v2/internal/provider"
+)
+
+var defaultPreset = db.Preset{
+	Name:        "preset_name",
+	Description: "test_desc",
+	Container:   "mp4",
+	RateControl: "VBR",
+	Video: db.VideoPreset{
+		Width:   "1280",
+		Height:  "720",
+		Codec:   "h264",
+		Bitrate: "2500000",
+		GopSize: "60",
+		GopMode: "fixed",
+	},
+	Audio: db.AudioPreset{
+		Codec:   "aac",
+		Bitrate: "128000",
+	},
+}
+
+func newTestProvider() (*hybrikProvider, *fakeHybrikClient) {
+	client := newFakeHybrikClient()
+	return &hybrikProvider{
+		c: client,
+		config: &config.Hybrik{
+			Destination: "s3://some-bucket/encodes",
+			PresetPath:  "transcoding-api-presets",
+		},
+	}, client
+}
+
+func TestUpdatePreset(t *testing.T) {
+	prov, client := newTestProvider()
+	presetID, err := prov.CreatePreset(defaultPreset)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	preset := defaultPreset
+	preset.Name = "some_other_name"
+	preset.Video.Bitrate = "3000000"
+	updatedID, err := prov.UpdatePreset(presetID, preset)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if updatedID != presetID {
+		t.Errorf("wrong preset id returned\nwant %q\ngot  %q", presetID, updatedID)
+	}
+	if len(client.presets) != 1 {
+		t.Fatalf("wrong number of presets stored\nwant 1\ngot  %d", len(client.presets))
+	}
+	stored := client.presets[presetID]
+	if stored.Name != presetID || stored.Key != presetID {
+		t.Errorf("preset renamed by the update: key=%q name=%q", stored.Key, stored.Name)
+	}
+	if bitrate := stored.Payload.Targets[0].Video.BitrateKb; bitrate != 3000 {
+		t.Errorf("preset not updated\nwant bitrate 3000\ngot  %d", bitrate)
+	}
+}
+
+func TestUpdatePresetNotFound(t *testing.T) {
+	prov, _ := newTestProvider()
+	_, err := prov.UpdatePreset("missing", defaultPreset)
+	if e := (provider.PresetNotFoundError{ID: "missing"}); err != e {
+		t.Fatalf("wrong error returned\nwant %#v\ngot  %#v", e, err)
+	}
+}
+
+func TestGetPreset(t *testing.T) {
+	prov, _ := newTestProvider()
+	presetID, err := prov.CreatePreset(defaultPreset)
+	if err != nil {
+		t.Fatal(err)
+	}
+	preset, err := prov.GetPreset(presetID)
+	if err != nil {
+		t.Fatal(err)
+	}
+	normalized, err := prov.NormalizePreset(preset)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if drift := defaultPreset.Drift(normalized); len(drift) > 0 {
+		t.Errorf("unexpected drift from the original preset: %+v", drift)
+	}
+}
+
+func TestGetPresetNotFound(t *testing.T) {
+	prov, _ := newTestProvider()
+	preset, err := prov.GetPreset("missing")
+	if e := (provider.PresetNotFoundError{ID: "missing"}); err != e {
+		t.Fatalf("wrong error returned\nwant %#v\ngot  %#v", e, err)
+	}
+	if preset != nil {
+		t.Errorf("unexpected non-nil preset: %#v", preset)
+	}
+}
+
+func TestGetPresetFailure(t *testing.T) {
+	prov, client := newTestProvider()
+	client.getPresetErr = errors.New("500 - GET /presets/some-preset: internal error")
+	_, err := prov.GetPreset("some-preset")
+	if err != client.getPresetErr {
+		t.Fatalf("wrong error returned\nwant %#v\ngot  %#v", client.getPresetErr, err)
+	}
+}
+
+func TestValidatePresetUnsupportedCodecs(t *testing.T) {
+	av1Preset := defaultPreset
+	av1Preset.Container = "webm"
+	av1Preset.Video.Codec = "av1"
+	opusPreset := defaultPreset
+	opusPreset.Container = "webm"
+	opusPreset.Video.Codec = "vp9"
+	opusPreset.Audio.Codec = "opus"
+	tests := []struct {
+		name    string
+		preset  db.Preset
+		wantErr string
+	}{
+		{"av1", av1Preset, `video codec "av1" is not supported with hybrik`},
+		{"opus", opusPreset, `audio codec "opus" is not supported with hybrik`},
+	}
+	prov, _ := newTestProvider()
+	for _, test := range tests {
+		test := test
+		t.Run(test.name, func(t *testing.T) {
+			err := prov.ValidatePreset(test.preset)
+			if err == nil || err.Error() != test.wantErr {
+				t.Errorf("wrong error returned\nwant %q\ngot  %v", test.wantErr, err)
+			}
+		})
+	}
+}
+
+func TestCapabilities(t *testing.T) {
+	var prov hybrikProvider
+	expected := provider.Capabilities{
+		InputFormats:  []string{"prores", "h264"},
+		OutputFormats: []string{"mp4", "hls", "webm", "mov"},
+		Destinations:  []string{"s3"},
+		VideoCodecs:   []string{"h264", "hevc", "vp8", "vp9"},
+		AudioCodecs:   []string{"aac", "vorbis"},
+	}
+	cap := prov.Capabilities()
+	if !reflect.DeepEqual(cap, expected) {
+		t.Errorf("Capabilities: want %#v. Got %#v", expected, cap)
+	}
+}
+
+func TestMountAudioTrackElement(t *testing.T) {
+	audio := hwrapper.AudioTarget{Codec: "aac", Channels: 2, BitrateKb: 128}
+	tests := []struct {
+		name           string
+		outputFilename string
+		duration       uint
+		track          db.AudioTrack
+		wantJSON       string
+	}{
+		{
+			"playlist in a subdirectory",
+			"hls/audio_es_1.m3u8",
+			6,
+			db.AudioTrack{TrackIndex: 2, Language: "es", Name: "Español"},
+			`{
+  "uid": "transcode_task_1",
+  "kind": "transcode",
+  "task": {"name": "Transcode - audio Español"},
+  "payload": {
+    "location": {"storage_provider": "s3", "path": "s3://some-bucket/encodes/jjob-123"},
+    "targets": [{
+      "file_pattern": "audio_es_1.m3u8",
+      "container": {"kind": "hls", "segment_duration": 6},
+      "audio": [{"codec": "aac", "channels": 2, "bitrate_kb": 128, "source": [{"track": 1}], "language": "es"}],
+      "location": {"storage_provider": "relative", "path": "hls"}
+    }]
+  }
+}`,
+		},
+		{
+			"playlist in the root of the destination",
+			"audio_pt-BR_2.m3u8",
+			0,
+			db.AudioTrack{TrackIndex: 1, Language: "pt-BR"},
+			`{
+  "uid": "transcode_task_1",
+  "kind": "transcode",
+  "task": {"name": "Transcode - audio pt-BR"},
+  "payload": {
+    "location": {"storage_provider": "s3", "path": "s3://some-bucket/encodes/jjob-123"},
+    "targets": [{
+      "file_pattern": "audio_pt-BR_2.m3u8",
+      "container": {"kind": "hls"},
+      "audio": [{"codec": "aac", "channels": 2, "bitrate_kb": 128, "source": [{"track": 0}], "language": "pt-BR"}]
+    }]
+  }
+}`,
+		},
+	}
+	for _, test := range tests {
+		test := test
+		t.Run(test.name, func(t *testing.T) {
+			p, _ := newTestProvider()
+			element := p.mountAudioTrackElement("1", "job-123", test.outputFilename, p.config.Destination, test.duration, audio, test.track)
+			data, err := json.Marshal(element)
+			if err != nil {
+				t.Fatal(err)
+			}
+			var got, want interface{}
+			if err = json.Unmarshal(data, &got); err != nil {
+				t.Fatal(err)
+			}
+			if err = json.Unmarshal([]byte(test.wantJSON), &want); err != nil {
+				t.Fatal(err)
+			}
+			if !reflect.DeepEqual(got, want) {
+				t.Errorf("wrong element\nwant %s\ngot  %s", test.wantJSON, data)
+			}
+		})
+	}
+}
+
+func TestTranscodeAudioTracks(t *testing.T) {
+	hlsPreset := hwrapper.Preset{
+		Name: "hls_preset",
+		Payload: hwrapper.PresetPayload{
+			Targets: []hwrapper.PresetTarget{{
+				FilePattern: "{source_basename}",
+				Audio:       []hwrapper.AudioTarget{{Codec: "aac", Channels: 2, BitrateKb: 128}},
+			}},
+		},
+	}
+	hlsPreset.Payload.Targets[0].Container.Kind = hls
+	mp4Preset := hwrapper.Preset{
+		Name: "mp4_preset",
+		Payload: hwrapper.PresetPayload{
+			Targets: []hwrapper.PresetTarget{{
+				FilePattern: "{source_basename}",
+				Audio:       []hwrapper.AudioTarget{{Codec: "aac", Channels: 2, BitrateKb: 128}},
+			}},
+		},
+	}
+	mp4Preset.Payload.Targets[0].Container.Kind = "mp4"
+
+	tests := []struct {
+		name        string
+		presetID    string
+		protocol    string
+		audioTracks []db.AudioTrack
+		wantTasks   []string
+		wantErr     error
+	}{
+		{
+			name:        "hls output",
+			presetID:    "hls_preset",
+			protocol:    "hls",
+			audioTracks: []db.AudioTrack{{TrackIndex: 2, Language: "es"}, {TrackIndex: 3, Language: "fr", Name: "Français"}},
+			wantTasks:   []string{"Transcode - audio es", "Transcode - audio Français"},
+		},
+		{
+			name:        "track from a separate file",
+			presetID:    "hls_preset",
+			protocol:    "hls",
+			audioTracks: []db.AudioTrack{{SourceMedia: "s3://some-bucket/audio/fr.wav", Language: "fr"}},
+			wantErr:     ErrAudioTrackSourceMedia,
+		},
+		{
+			name:        "dash streaming protocol",
+			presetID:    "hls_preset",
+			protocol:    "dash",
+			audioTracks: []db.AudioTrack{{TrackIndex: 2, Language: "es"}},
+			wantErr:     ErrAudioTracksWithoutHLS,
+		},
+		{
+			name:        "no hls output",
+			presetID:    "mp4_preset",
+			protocol:    "hls",
+			audioTracks: []db.AudioTrack{{TrackIndex: 2, Language: "es"}},
+			wantErr:     ErrAudioTracksWithoutHLS,
+		},
+	}
+	for _, test := range tests {
+		test := test
+		t.Run(test.name, func(t *testing.T) {
+			p, client := newTestProvider()
+			client.presets["hls_preset"] = hlsPreset
+			client.presets["mp4_preset"] = mp4Preset
+			job := db.Job{
+				ID:          "job-123",
+				SourceMedia: "s3://some-bucket/source/video.mp4",
+				Outputs: []db.TranscodeOutput{{
+					Preset:   db.PresetMap{Name: "preset", ProviderMapping: map[string]string{Name: test.presetID}},
+					FileName: "hls/video.m3u8",
+				}},
+				StreamingParams: db.StreamingParams{
+					Protocol:         test.protocol,
+					PlaylistFileName: "hls/index.m3u8",
+					SegmentDuration:  6,
+				},
+				AudioTracks: test.audioTracks,
+			}
+			_, err := p.Transcode(&job)
+			if err != test.wantErr {
+				t.Fatalf("wrong error\nwant %v\ngot  %v", test.wantErr, err)
+			}
+			if test.wantErr != nil {
+				if _, ok := err.(provider.InvalidAudioTracksError); !ok {
+					t.Errorf("wrong error type: want InvalidAudioTracksError. Got %#v", err)
+				}
+				if len(client.queuedJobs) != 0 {
+					t.Errorf("unexpected queued jobs: %v", client.queuedJobs)
+				}
+				return
+			}
+			if len(client.queuedJobs) != 1 {
+				t.Fatalf("wrong number of queued jobs: %d", len(client.queuedJobs))
+			}
+			var queued struct {
+				Payload struct {
+					Elements []struct {
+						Task struct {
+							Name string `json:"name"`
+						} `json:"task"`
+					} `json:"elements"`
+				} `json:"payload"`
+			}
+			if err = json.Unmarshal([]byte(client.queuedJobs[0]), &queued); err != nil {
+				t.Fatal(err)
+			}
+			var tasks []string
+			for _, element := range queued.Payload.Elements {
+				if strings.HasPrefix(element.Task.Name, "Transcode - audio ") {
+					tasks = append(tasks, element.Task.Name)
+				}
+			}
+			if !reflect.DeepEqual(tasks, test.wantTasks) {
+				t.Errorf("wrong audio track tasks\nwant %q\ngot  %q\njob: %s", test.wantTasks, tasks, client.queuedJobs[0])
+			}
+		})
+	}
+}
+
+func TestTranscodeProviderOverrides(t *testing.T) {
+	tests := []struct {
+		name         string
+		overrides    string
+		wantPriority int
+		wantUserTag  string
+		wantErr      bool
+	}{
+		{
+			name:         "job fields",
+			overrides:    `{"priority":200,"user_tag":"some-tag"}`,
+			wantPriority: 200,
+			wantUserTag:  "some-tag",
+		},
+		{
+			name:         "keys matched case-insensitively",
+			overrides:    `{"Priority":150}`,
+			wantPriority: 150,
+		},
+		{
+			name:      "unknown job field",
+			overrides: `{"prioirty":200}`,
+			wantErr:   true,
+		},
+		{
+			name:      "unknown element field",
+			overrides: `{"payload":{"elements":[{"uid":"source_file","kind":"source","payload":{},"retries":3}]}}`,
+			wantErr:   true,
+		},
+		{
+			name:      "wrong type",
+			overrides: `{"priority":"high"}`,
+			wantErr:   true,
+		},
+	}
+	for _, test := range tests {
+		test := test
+		t.Run(test.name, func(t *testing.T) {
+			p, client := newTestProvider()
+			presetID, err := p.CreatePreset(defaultPreset)
+			if err != nil {
+				t.Fatal(err)
+			}
+			job := db.Job{
+				ID:          "job-123",
+				SourceMedia: "s3://some-bucket/source/video.mp4",
+				Outputs: []db.TranscodeOutput{{
+					Preset:   db.PresetMap{Name: "preset", ProviderMapping: map[string]string{Name: presetID}},
+					FileName: "video.mp4",
+				}},
+				ProviderOverrides: db.ProviderOverrides{Name: json.RawMessage(test.overrides)},
+			}
+			_, err = p.Transcode(&job)
+			if test.wantErr {
+				if err == nil {
+					t.Fatal("unexpected <nil> error")
+				}
+				if len(client.queuedJobs) != 0 {
+					t.Errorf("unexpected queued jobs: %v", client.queuedJobs)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatal(err)
+			}
+			var queued hwrapper.CreateJob
+			if err = json.Unmarshal([]byte(client.queuedJobs[0]), &queued); err != nil {
+				t.Fatal(err)
+			}
+			if queued.Priority != test.wantPriority {
+				t.Errorf("wrong priority\nwant %d\ngot  %d", test.wantPriority, queued.Priority)
+			}
+			if queued.UserTag != test.wantUserTag {
+				t.Errorf("wrong user tag\nwant %q\ngot  %q", test.wantUserTag, queued.UserTag)
+			}
+			if len(queued.Payload.Elements) == 0 {
+				t.Error("overrides dropped the job elements")
+			}
+		})
+	}
+}
+
+func TestCreatePresetProviderOverrides(t *testing.T) {
+	tests := []struct {
+		name        string
+		overrides   string
+		wantProfile string
+		wantErr     bool
+	}{
+		{
+			name:        "target fields",
+			overrides:   `{"payload":{"targets":[{"container":{"kind":"mp4"},"video":{"codec":"h264","profile":"high"}}]}}`,
+			wantProfile: "high",
+		},
+		{
+			name:      "unknown preset field",
+			overrides: `{"payload":{"targets":[{"container":{"kind":"mp4"},"video":{"codec":"h264","profil":"high"}}]}}`,
+			wantErr:   true,
+		},
+	}
+	for _, test := range tests {
+		test := test
+		t.Run(test.name, func(t *testing.T) {
+			p, client := newTestProvider()
+			preset := defaultPreset
+			preset.ProviderOverrides = db.ProviderOverrides{Name: json.RawMessage(test.overrides)}
+			presetID, err := p.CreatePreset(preset)
+			if test.wantErr {
+				if err == nil {
+					t.Fatal("unexpected <nil> error")
+				}
+				if len(client.presets) != 0 {
+					t.Errorf("unexpected stored presets: %v", client.presets)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatal(err)
+			}
+			if profile := client.presets[presetID].Payload.Targets[0].Video.Profile; profile != test.wantProfile {
+				t.Errorf("wrong profile\nwant %q\ngot  %q", test.wantProfile, profile)
+			}
+		})
+	}
+}
@@ -58,6 +58,11 @@ func Test_mediaconvertFactory(t *testing.T) {
 			cfg:        config.Config{MediaConvert: &config.MediaConvert{}},
 			wantErrMsg: "incomplete MediaConvert config",
 		},
+		{
+			name:       "a missing cfg results in an error returned",
+			cfg:        config.Config{},
+			wantErrMsg: "incomplete MediaConvert config",
+		},
 	}
 
 	for _, tt := range tests {
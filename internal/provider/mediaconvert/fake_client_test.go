@@ -2,6 +2,7 @@ package mediaconvert
 
 import (
 	"context"
+	"strconv"
 	"sync/atomic"
 	"testing"
 	"unsafe"
@@ -17,6 +18,7 @@ type testMediaConvertClient struct {
 	t *testing.T
 
 	createPresetCalledWith *mediaconvert.CreatePresetInput
+	updatePresetCalledWith *mediaconvert.UpdatePresetInput
 	getPresetCalledWith    *string
 	deletePresetCalledWith string
 	createJobCalledWith    mediaconvert.CreateJobInput
@@ -26,6 +28,9 @@ type testMediaConvertClient struct {
 	jobReturnedByGetJob      types.Job
 	jobIDReturnedByCreateJob string
 	getPresetContainerType   types.ContainerType
+	getPresetSettings        *types.PresetSettings
+	presetPages              [][]types.Preset
+	getPresetErr             error
 }
 
 func (c *testMediaConvertClient) CreatePreset(_ context.Context, input *mediaconvert.CreatePresetInput, _ ...func(*mediaconvert.Options)) (*mediaconvert.CreatePresetOutput, error) {
@@ -42,6 +47,13 @@ func (c *testMediaConvertClient) CreatePreset(_ context.Context, input *mediacon
 	}, nil
 }
 
+func (c *testMediaConvertClient) UpdatePreset(_ context.Context, input *mediaconvert.UpdatePresetInput, _ ...func(*mediaconvert.Options)) (*mediaconvert.UpdatePresetOutput, error) {
+	c.updatePresetCalledWith = input
+	return &mediaconvert.UpdatePresetOutput{
+		Preset: &types.Preset{Name: input.Name, Settings: input.Settings},
+	}, nil
+}
+
 func (c *testMediaConvertClient) GetJob(context.Context, *mediaconvert.GetJobInput, ...func(*mediaconvert.Options)) (*mediaconvert.GetJobOutput, error) {
 	return &mediaconvert.GetJobOutput{
 		Job: &c.jobReturnedByGetJob,
@@ -71,15 +83,22 @@ func (c *testMediaConvertClient) GetPreset(_ context.Context, input *mediaconver
 	// atomically set the value of getPresetCalledWith to avoid data races,
 	// should probably take a different approach?
 	atomic.StorePointer((*unsafe.Pointer)(unsafe.Pointer(&c.getPresetCalledWith)), unsafe.Pointer(input.Name))
+	if c.getPresetErr != nil {
+		return nil, c.getPresetErr
+	}
+
+	settings := types.PresetSettings{}
+	if c.getPresetSettings != nil {
+		settings = *c.getPresetSettings
+	}
+	settings.ContainerSettings = &types.ContainerSettings{
+		Container: c.getPresetContainerType,
+	}
 
 	return &mediaconvert.GetPresetOutput{
 		Preset: &types.Preset{
-			Name: input.Name,
-			Settings: &types.PresetSettings{
-				ContainerSettings: &types.ContainerSettings{
-					Container: c.getPresetContainerType,
-				},
-			},
+			Name:     input.Name,
+			Settings: &settings,
 		},
 	}, nil
 }
@@ -88,3 +107,18 @@ func (c *testMediaConvertClient) DeletePreset(_ context.Context, input *mediacon
 	c.deletePresetCalledWith = *input.Name
 	return &mediaconvert.DeletePresetOutput{}, nil
 }
+
+func (c *testMediaConvertClient) ListPresets(_ context.Context, input *mediaconvert.ListPresetsInput, _ ...func(*mediaconvert.Options)) (*mediaconvert.ListPresetsOutput, error) {
+	page := 0
+	if input.NextToken != nil {
+		page, _ = strconv.Atoi(*input.NextToken)
+	}
+	output := mediaconvert.ListPresetsOutput{}
+	if page < len(c.presetPages) {
+		output.Presets = c.presetPages[page]
+	}
+	if page+1 < len(c.presetPages) {
+		output.NextToken = aws.String(strconv.Itoa(page + 1))
+	}
+	return &output, nil
+}
@@ -4,6 +4,7 @@ import (
 	"context"
 	"fmt"
 	"path"
+	"reflect"
 	"strings"
 	"sync"
 
@@ -23,6 +24,19 @@ const (
 	Name = "mediaconvert"
 
 	defaultAudioSampleRate = 48000
+
+	defaultAudioSelector = "Audio Selector 1"
+
+	// alternateAudioGroupID is the audio group of the alternate audio
+	// tracks. It matches the group MediaConvert assigns to audio-only
+	// outputs by default, so the tracks are listed along with the program
+	// audio.
+	alternateAudioGroupID = "program_audio"
+)
+
+var (
+	errAlternateAudioWithoutAudio = errors.New("alternate audio tracks require at least one output with audio")
+	errContentAwareCodec          = errors.New("content-aware encoding requires h264 or hevc presets")
 )
 
 func init() {
@@ -36,7 +50,9 @@ type mediaconvertClient interface {
 	CancelJob(context.Context, *mediaconvert.CancelJobInput, ...func(*mediaconvert.Options)) (*mediaconvert.CancelJobOutput, error)
 	CreatePreset(context.Context, *mediaconvert.CreatePresetInput, ...func(*mediaconvert.Options)) (*mediaconvert.CreatePresetOutput, error)
 	GetPreset(context.Context, *mediaconvert.GetPresetInput, ...func(*mediaconvert.Options)) (*mediaconvert.GetPresetOutput, error)
+	UpdatePreset(context.Context, *mediaconvert.UpdatePresetInput, ...func(*mediaconvert.Options)) (*mediaconvert.UpdatePresetOutput, error)
 	DeletePreset(context.Context, *mediaconvert.DeletePresetInput, ...func(*mediaconvert.Options)) (*mediaconvert.DeletePresetOutput, error)
+	ListPresets(context.Context, *mediaconvert.ListPresetsInput, ...func(*mediaconvert.Options)) (*mediaconvert.ListPresetsOutput, error)
 }
 
 type mcProvider struct {
@@ -61,10 +77,8 @@ func (p *mcProvider) Transcode(job *db.Job) (*provider.JobStatus, error) {
 		Settings: &types.JobSettings{
 			Inputs: []types.Input{
 				{
-					FileInput: aws.String(job.SourceMedia),
-					AudioSelectors: map[string]types.AudioSelector{
-						"Audio Selector 1": {DefaultSelection: types.AudioDefaultSelectionDefault},
-					},
+					FileInput:      aws.String(job.SourceMedia),
+					AudioSelectors: audioSelectorsFrom(job.AudioTracks),
 					VideoSelector: &types.VideoSelector{
 						ColorSpace: types.ColorSpaceFollow,
 					},
@@ -73,6 +87,10 @@ func (p *mcProvider) Transcode(job *db.Job) (*provider.JobStatus, error) {
 			OutputGroups: outputGroups,
 		},
 	}
+	err = provider.ApplyOverrides(&createJobInput, job.ProviderOverrides.For(Name), true)
+	if err != nil {
+		return nil, errors.Wrap(err, "applying MediaConvert overrides")
+	}
 
 	resp, err := p.client.CreateJob(context.Background(), &createJobInput)
 	if err != nil {
@@ -148,11 +166,31 @@ func (p *mcProvider) outputGroupsFrom(job *db.Job, presets map[string]types.Pres
 			filename := strings.Replace(path.Base(output.FileName), rawExtension, "", 1)
 			extension := strings.Replace(rawExtension, ".", "", -1)
 
-			mcOutputs = append(mcOutputs, types.Output{
+			mcOutput := types.Output{
 				Preset:       aws.String(presetID),
 				NameModifier: aws.String(filename),
 				Extension:    aws.String(extension),
-			})
+			}
+
+			// audio-only renditions are listed as audio-only variants
+			// in the master playlist, instead of being used as
+			// alternate audio for the video renditions.
+			if preset := presets[presetID]; container == types.ContainerTypeM3u8 &&
+				preset.Settings != nil && preset.Settings.VideoDescription == nil {
+				mcOutput.OutputSettings = &types.OutputSettings{
+					HlsSettings: &types.HlsSettings{
+						AudioTrackType: types.HlsAudioTrackTypeAudioOnlyVariantStream,
+					},
+				}
+			} else if container == types.ContainerTypeM3u8 && len(job.AudioTracks) > 0 {
+				mcOutput.OutputSettings = &types.OutputSettings{
+					HlsSettings: &types.HlsSettings{
+						AudioRenditionSets: aws.String(alternateAudioGroupID),
+					},
+				}
+			}
+
+			mcOutputs = append(mcOutputs, mcOutput)
 		}
 		mcOutputGroup.Outputs = mcOutputs
 
@@ -160,6 +198,11 @@ func (p *mcProvider) outputGroupsFrom(job *db.Job, presets map[string]types.Pres
 
 		switch container {
 		case types.ContainerTypeM3u8:
+			hlsAudioOutputs, err := hlsAlternateAudioOutputsFrom(job.AudioTracks, outputs, presets)
+			if err != nil {
+				return nil, err
+			}
+			mcOutputGroup.Outputs = append(mcOutputGroup.Outputs, hlsAudioOutputs...)
 			mcOutputGroup.OutputGroupSettings = &types.OutputGroupSettings{
 				Type: types.OutputGroupTypeHlsGroupSettings,
 				HlsGroupSettings: &types.HlsGroupSettings{
@@ -172,7 +215,27 @@ func (p *mcProvider) outputGroupsFrom(job *db.Job, presets map[string]types.Pres
 					SegmentControl:         types.HlsSegmentControlSegmentedFiles,
 				},
 			}
-		case types.ContainerTypeMp4:
+		case types.ContainerTypeCmfc:
+			manifestName := strings.TrimSuffix(job.StreamingParams.PlaylistFileName, path.Ext(job.StreamingParams.PlaylistFileName))
+			mcOutputGroup.OutputGroupSettings = &types.OutputGroupSettings{
+				Type: types.OutputGroupTypeCmafGroupSettings,
+				CmafGroupSettings: &types.CmafGroupSettings{
+					Destination:            aws.String(destination + manifestName),
+					SegmentLength:          int32(job.StreamingParams.SegmentDuration),
+					FragmentLength:         int32(job.StreamingParams.SegmentDuration),
+					ManifestDurationFormat: types.CmafManifestDurationFormatFloatingPoint,
+					SegmentControl:         types.CmafSegmentControlSegmentedFiles,
+					WriteHlsManifest:       types.CmafWriteHLSManifestEnabled,
+					WriteDashManifest:      types.CmafWriteDASHManifestEnabled,
+				},
+			}
+
+			cmafOutputs, err := cmafOutputsFrom(outputs, presets, job.AudioTracks)
+			if err != nil {
+				return nil, err
+			}
+			mcOutputGroup.Outputs = cmafOutputs
+		case types.ContainerTypeMp4, types.ContainerTypeWebm:
 			mcOutputGroup.OutputGroupSettings = &types.OutputGroupSettings{
 				Type: types.OutputGroupTypeFileGroupSettings,
 				FileGroupSettings: &types.FileGroupSettings{
@@ -183,12 +246,401 @@ func (p *mcProvider) outputGroupsFrom(job *db.Job, presets map[string]types.Pres
 			return nil, fmt.Errorf("container %s is not yet supported with mediaconvert", string(container))
 		}
 
+		if job.ContentAware && (container == types.ContainerTypeM3u8 || container == types.ContainerTypeCmfc) {
+			if err := automatedABRFrom(&mcOutputGroup, presets); err != nil {
+				return nil, err
+			}
+		}
+
+		if job.StreamingParams.HLS != nil && (container == types.ContainerTypeM3u8 || container == types.ContainerTypeCmfc) {
+			if err := applyHLSOptions(&mcOutputGroup, *job.StreamingParams.HLS, presets); err != nil {
+				return nil, err
+			}
+		}
+
 		mcOutputGroups = append(mcOutputGroups, mcOutputGroup)
 	}
 
 	return mcOutputGroups, nil
 }
 
+// cmafOutputsFrom builds the outputs of a CMAF output group. CMAF tracks
+// carry a single elementary stream each, so the video description of every
+// preset becomes its own output and the audio descriptions are deduplicated
+// into shared audio-only outputs referenced by all video renditions.
+func cmafOutputsFrom(outputs []db.TranscodeOutput, presets map[string]types.Preset, audioTracks []db.AudioTrack) ([]types.Output, error) {
+	var videoOutputs []types.Output
+	var audioDescriptions []types.AudioDescription
+	for _, output := range outputs {
+		presetID, ok := output.Preset.ProviderMapping[Name]
+		if !ok {
+			return nil, provider.ErrPresetMapNotFound
+		}
+
+		preset, ok := presets[presetID]
+		if !ok {
+			return nil, errors.New("mediaconvert preset not found in preset results")
+		}
+		if preset.Settings == nil {
+			return nil, fmt.Errorf("mediaconvert preset %q has no settings", presetID)
+		}
+
+		if preset.Settings.VideoDescription != nil {
+			rawExtension := path.Ext(output.FileName)
+			videoOutputs = append(videoOutputs, types.Output{
+				NameModifier:      aws.String(strings.Replace(path.Base(output.FileName), rawExtension, "", 1)),
+				ContainerSettings: &types.ContainerSettings{Container: types.ContainerTypeCmfc},
+				VideoDescription:  preset.Settings.VideoDescription,
+			})
+		}
+
+		for _, audioDescription := range preset.Settings.AudioDescriptions {
+			if !containsAudioDescription(audioDescriptions, audioDescription) {
+				audioDescriptions = append(audioDescriptions, audioDescription)
+			}
+		}
+	}
+
+	mcOutputs := videoOutputs
+	for i, audioDescription := range audioDescriptions {
+		mcOutputs = append(mcOutputs, types.Output{
+			NameModifier:      aws.String(fmt.Sprintf("audio_%d", i+1)),
+			ContainerSettings: &types.ContainerSettings{Container: types.ContainerTypeCmfc},
+			AudioDescriptions: []types.AudioDescription{audioDescription},
+		})
+	}
+
+	if len(audioTracks) > 0 {
+		if len(audioDescriptions) == 0 {
+			return nil, errAlternateAudioWithoutAudio
+		}
+		for i, audioDescription := range alternateAudioDescriptionsFrom(audioDescriptions[0], audioTracks) {
+			trackType := types.CmfcAudioTrackTypeAlternateAudioAutoSelect
+			if audioTracks[i].Default {
+				trackType = types.CmfcAudioTrackTypeAlternateAudioAutoSelectDefault
+			}
+			mcOutputs = append(mcOutputs, types.Output{
+				NameModifier: aws.String(alternateAudioNameModifier(i, audioTracks[i])),
+				ContainerSettings: &types.ContainerSettings{
+					Container: types.ContainerTypeCmfc,
+					CmfcSettings: &types.CmfcSettings{
+						AudioGroupId:   aws.String(alternateAudioGroupID),
+						AudioTrackType: trackType,
+					},
+				},
+				AudioDescriptions: []types.AudioDescription{audioDescription},
+			})
+		}
+	}
+
+	return mcOutputs, nil
+}
+
+// applyHLSOptions applies the HLS options of the job to an HLS or CMAF output
+// group. MediaConvert only writes VOD playlists and picks the version of the
+// playlists itself, HLS output groups only support ts segments and CMAF
+// output groups don't support program date time tags.
+func applyHLSOptions(group *types.OutputGroup, options db.HLSOptions, presets map[string]types.Preset) error {
+	if strings.EqualFold(options.PlaylistType, db.HLSPlaylistTypeEvent) {
+		return provider.HLSOptionNotSupportedError{Option: "playlistType=EVENT"}
+	}
+	if options.TargetVersion != 0 {
+		return provider.HLSOptionNotSupportedError{Option: "targetVersion"}
+	}
+	if settings := group.OutputGroupSettings.HlsGroupSettings; settings != nil {
+		if options.FMP4() {
+			return provider.HLSOptionNotSupportedError{Option: "segmentContainer=fmp4"}
+		}
+		if options.SingleFile {
+			settings.SegmentControl = types.HlsSegmentControlSingleFile
+		}
+		if options.ProgramDateTime {
+			settings.ProgramDateTime = types.HlsProgramDateTimeInclude
+		}
+	}
+	if settings := group.OutputGroupSettings.CmafGroupSettings; settings != nil {
+		if options.ProgramDateTime {
+			return provider.HLSOptionNotSupportedError{Option: "programDateTime"}
+		}
+		if options.SingleFile {
+			settings.SegmentControl = types.CmafSegmentControlSingleFile
+		}
+	}
+	if !options.IFramePlaylist {
+		return nil
+	}
+	for i := range group.Outputs {
+		output := &group.Outputs[i]
+		videoDescription := output.VideoDescription
+		if videoDescription == nil && output.Preset != nil {
+			if settings := presets[*output.Preset].Settings; settings != nil {
+				videoDescription = settings.VideoDescription
+			}
+		}
+		if videoDescription == nil {
+			continue
+		}
+		if group.OutputGroupSettings.CmafGroupSettings != nil {
+			if output.ContainerSettings == nil {
+				output.ContainerSettings = &types.ContainerSettings{Container: types.ContainerTypeCmfc}
+			}
+			if output.ContainerSettings.CmfcSettings == nil {
+				output.ContainerSettings.CmfcSettings = &types.CmfcSettings{}
+			}
+			output.ContainerSettings.CmfcSettings.IFrameOnlyManifest = types.CmfcIFrameOnlyManifestInclude
+			continue
+		}
+		if output.OutputSettings == nil {
+			output.OutputSettings = &types.OutputSettings{}
+		}
+		if output.OutputSettings.HlsSettings == nil {
+			output.OutputSettings.HlsSettings = &types.HlsSettings{}
+		}
+		output.OutputSettings.HlsSettings.IFrameOnlyManifest = types.HlsIFrameOnlyManifestInclude
+	}
+	return nil
+}
+
+// automatedABRFrom replaces the video outputs of an HLS or CMAF output group
+// with a single QVBR output based on the video settings of the highest
+// bitrate preset, letting MediaConvert pick the rungs of the ladder (automated
+// ABR). The bitrates of the presets bound the bitrates of the ladder. HLS
+// video outputs carry their audio, so the program audio is moved to an
+// audio-only output referenced by the generated renditions.
+func automatedABRFrom(group *types.OutputGroup, presets map[string]types.Preset) error {
+	var outputs []types.Output
+	var top *types.Output
+	var topVideo, topAudio *types.PresetSettings
+	var minBitrate, maxBitrate, renditions int32
+	for i, output := range group.Outputs {
+		settings := &types.PresetSettings{VideoDescription: output.VideoDescription}
+		if output.Preset != nil {
+			settings = presets[*output.Preset].Settings
+		}
+		if settings == nil || settings.VideoDescription == nil {
+			outputs = append(outputs, output)
+			continue
+		}
+		bitrate, err := videoBitrateFrom(settings.VideoDescription)
+		if err != nil {
+			return err
+		}
+		if renditions == 0 || bitrate < minBitrate {
+			minBitrate = bitrate
+		}
+		if bitrate > maxBitrate || top == nil {
+			maxBitrate = bitrate
+			top = &group.Outputs[i]
+			topVideo = settings
+		}
+		if topAudio == nil && len(settings.AudioDescriptions) > 0 {
+			topAudio = settings
+		}
+		renditions++
+	}
+	if top == nil {
+		return nil
+	}
+
+	videoDescription, err := qvbrVideoDescriptionFrom(*topVideo.VideoDescription)
+	if err != nil {
+		return err
+	}
+	abrOutput := types.Output{
+		NameModifier:      top.NameModifier,
+		Extension:         top.Extension,
+		ContainerSettings: top.ContainerSettings,
+		VideoDescription:  &videoDescription,
+	}
+	if top.Preset != nil {
+		abrOutput.ContainerSettings = &types.ContainerSettings{Container: types.ContainerTypeM3u8}
+		abrOutput.OutputSettings = &types.OutputSettings{
+			HlsSettings: &types.HlsSettings{AudioRenditionSets: aws.String(alternateAudioGroupID)},
+		}
+		if topAudio != nil {
+			trackType := types.HlsAudioTrackTypeAlternateAudioAutoSelectDefault
+			for _, output := range outputs {
+				if output.OutputSettings != nil && output.OutputSettings.HlsSettings != nil &&
+					output.OutputSettings.HlsSettings.AudioTrackType == types.HlsAudioTrackTypeAlternateAudioAutoSelectDefault {
+					trackType = types.HlsAudioTrackTypeAlternateAudioAutoSelect
+				}
+			}
+			outputs = append([]types.Output{{
+				NameModifier:      aws.String("audio"),
+				ContainerSettings: &types.ContainerSettings{Container: types.ContainerTypeM3u8},
+				AudioDescriptions: topAudio.AudioDescriptions[:1],
+				OutputSettings: &types.OutputSettings{
+					HlsSettings: &types.HlsSettings{
+						AudioGroupId:   aws.String(alternateAudioGroupID),
+						AudioTrackType: trackType,
+					},
+				},
+			}}, outputs...)
+		}
+	}
+	group.Outputs = append([]types.Output{abrOutput}, outputs...)
+
+	// MediaConvert generates between 3 and 15 renditions
+	if renditions < 3 {
+		renditions = 3
+	} else if renditions > 15 {
+		renditions = 15
+	}
+	group.AutomatedEncodingSettings = &types.AutomatedEncodingSettings{
+		AbrSettings: &types.AutomatedAbrSettings{
+			MinAbrBitrate: minBitrate,
+			MaxAbrBitrate: maxBitrate,
+			MaxRenditions: renditions,
+		},
+	}
+	return nil
+}
+
+// videoBitrateFrom returns the bitrate of the given H.264 or H.265 video
+// description, the only codecs supported by automated ABR.
+func videoBitrateFrom(description *types.VideoDescription) (int32, error) {
+	if description.CodecSettings != nil {
+		switch description.CodecSettings.Codec {
+		case types.VideoCodecH264:
+			if settings := description.CodecSettings.H264Settings; settings != nil {
+				if settings.MaxBitrate > 0 {
+					return settings.MaxBitrate, nil
+				}
+				return settings.Bitrate, nil
+			}
+		case types.VideoCodecH265:
+			if settings := description.CodecSettings.H265Settings; settings != nil {
+				if settings.MaxBitrate > 0 {
+					return settings.MaxBitrate, nil
+				}
+				return settings.Bitrate, nil
+			}
+		}
+	}
+	return 0, errContentAwareCodec
+}
+
+// qvbrVideoDescriptionFrom returns a copy of the given video description
+// using QVBR and no fixed resolution, as required by automated ABR.
+func qvbrVideoDescriptionFrom(description types.VideoDescription) (types.VideoDescription, error) {
+	description.Width = 0
+	description.Height = 0
+	codecSettings := *description.CodecSettings
+	switch codecSettings.Codec {
+	case types.VideoCodecH264:
+		settings := *codecSettings.H264Settings
+		settings.RateControlMode = types.H264RateControlModeQvbr
+		settings.Bitrate = 0
+		settings.MaxBitrate = 0
+		codecSettings.H264Settings = &settings
+	case types.VideoCodecH265:
+		settings := *codecSettings.H265Settings
+		settings.RateControlMode = types.H265RateControlModeQvbr
+		settings.Bitrate = 0
+		settings.MaxBitrate = 0
+		codecSettings.H265Settings = &settings
+	default:
+		return description, errContentAwareCodec
+	}
+	description.CodecSettings = &codecSettings
+	return description, nil
+}
+
+// hlsAlternateAudioOutputsFrom builds one audio-only output per alternate
+// audio track, encoded with the audio settings of the first preset in the
+// group. The outputs are listed as EXT-X-MEDIA entries referenced by the
+// video renditions.
+func hlsAlternateAudioOutputsFrom(audioTracks []db.AudioTrack, outputs []db.TranscodeOutput, presets map[string]types.Preset) ([]types.Output, error) {
+	if len(audioTracks) == 0 {
+		return nil, nil
+	}
+
+	var reference *types.AudioDescription
+	for _, output := range outputs {
+		preset := presets[output.Preset.ProviderMapping[Name]]
+		if preset.Settings != nil && len(preset.Settings.AudioDescriptions) > 0 {
+			reference = &preset.Settings.AudioDescriptions[0]
+			break
+		}
+	}
+	if reference == nil {
+		return nil, errAlternateAudioWithoutAudio
+	}
+
+	var mcOutputs []types.Output
+	for i, audioDescription := range alternateAudioDescriptionsFrom(*reference, audioTracks) {
+		trackType := types.HlsAudioTrackTypeAlternateAudioAutoSelect
+		if audioTracks[i].Default {
+			trackType = types.HlsAudioTrackTypeAlternateAudioAutoSelectDefault
+		}
+		mcOutputs = append(mcOutputs, types.Output{
+			NameModifier:      aws.String(alternateAudioNameModifier(i, audioTracks[i])),
+			ContainerSettings: &types.ContainerSettings{Container: types.ContainerTypeM3u8},
+			AudioDescriptions: []types.AudioDescription{audioDescription},
+			OutputSettings: &types.OutputSettings{
+				HlsSettings: &types.HlsSettings{
+					AudioGroupId:   aws.String(alternateAudioGroupID),
+					AudioTrackType: trackType,
+				},
+			},
+		})
+	}
+
+	return mcOutputs, nil
+}
+
+// alternateAudioDescriptionsFrom returns one audio description per track,
+// copying the codec settings from the reference description.
+func alternateAudioDescriptionsFrom(reference types.AudioDescription, audioTracks []db.AudioTrack) []types.AudioDescription {
+	descriptions := make([]types.AudioDescription, len(audioTracks))
+	for i, track := range audioTracks {
+		description := reference
+		description.AudioSourceName = aws.String(alternateAudioSelectorName(i))
+		description.LanguageCodeControl = types.AudioLanguageCodeControlUseConfigured
+		description.CustomLanguageCode = aws.String(track.Language)
+		description.StreamName = aws.String(track.DisplayName())
+		descriptions[i] = description
+	}
+	return descriptions
+}
+
+func alternateAudioNameModifier(i int, track db.AudioTrack) string {
+	return fmt.Sprintf("audio_%s_%d", track.Language, i+1)
+}
+
+// audioSelectorsFrom builds the audio selectors of the job input: the
+// default selector for the program audio, plus one selector per alternate
+// audio track.
+func audioSelectorsFrom(audioTracks []db.AudioTrack) map[string]types.AudioSelector {
+	selectors := map[string]types.AudioSelector{
+		defaultAudioSelector: {DefaultSelection: types.AudioDefaultSelectionDefault},
+	}
+	for i, track := range audioTracks {
+		selector := types.AudioSelector{DefaultSelection: types.AudioDefaultSelectionNotDefault}
+		if track.SourceMedia != "" {
+			selector.ExternalAudioFileInput = aws.String(track.SourceMedia)
+		} else {
+			selector.SelectorType = types.AudioSelectorTypeTrack
+			selector.Tracks = []int32{int32(track.TrackIndex)}
+		}
+		selectors[alternateAudioSelectorName(i)] = selector
+	}
+	return selectors
+}
+
+func alternateAudioSelectorName(i int) string {
+	return fmt.Sprintf("Audio Selector %d", i+2)
+}
+
+func containsAudioDescription(descriptions []types.AudioDescription, description types.AudioDescription) bool {
+	for _, d := range descriptions {
+		if reflect.DeepEqual(d, description) {
+			return true
+		}
+	}
+	return false
+}
+
 func destinationPathFrom(destBase string, jobID string) string {
 	return fmt.Sprintf("%s/%s/", strings.TrimRight(destBase, "/"), jobID)
 }
@@ -214,20 +666,79 @@ func (p *mcProvider) makeGetPresetRequest(presetID string, ch chan *presetResult
 	ch <- result
 }
 
+// ValidatePreset checks whether the preset can be mapped to a MediaConvert
+// preset, without calling the MediaConvert API.
+func (p *mcProvider) ValidatePreset(preset db.Preset) error {
+	_, err := presetInputFrom(preset)
+	return err
+}
+
 func (p *mcProvider) CreatePreset(preset db.Preset) (string, error) {
-	container, err := containerFrom(preset.Container)
+	presetInput, err := presetInputFrom(preset)
 	if err != nil {
-		return "", errors.Wrap(err, "mapping preset container to MediaConvert container")
+		return "", err
 	}
 
-	videoPreset, err := videoPresetFrom(preset)
+	resp, err := p.client.CreatePreset(context.Background(), &presetInput)
 	if err != nil {
-		return "", errors.Wrap(err, "generating video preset")
+		return "", err
+	}
+	if resp == nil || resp.Preset == nil || resp.Preset.Name == nil {
+		return "", fmt.Errorf("unexpected response from MediaConvert: %v", resp)
+	}
+
+	return *resp.Preset.Name, nil
+}
+
+// UpdatePreset replaces the settings of the preset with the given ID.
+// MediaConvert presets are keyed by name, so the replacement can't be
+// created next to the previous preset and keeps its ID.
+func (p *mcProvider) UpdatePreset(presetID string, preset db.Preset) (string, error) {
+	presetInput, err := presetInputFrom(preset)
+	if err != nil {
+		return "", err
+	}
+
+	_, err = p.client.UpdatePreset(context.Background(), &mediaconvert.UpdatePresetInput{
+		Name:        aws.String(presetID),
+		Category:    presetInput.Category,
+		Description: presetInput.Description,
+		Settings:    presetInput.Settings,
+	})
+	var notFound *types.NotFoundException
+	if errors.As(err, &notFound) {
+		return "", provider.PresetNotFoundError{ID: presetID}
+	}
+	if err != nil {
+		return "", err
+	}
+
+	return presetID, nil
+}
+
+func presetInputFrom(preset db.Preset) (mediaconvert.CreatePresetInput, error) {
+	container, err := containerFrom(preset.Container)
+	if err != nil {
+		return mediaconvert.CreatePresetInput{}, errors.Wrap(err, "mapping preset container to MediaConvert container")
+	}
+
+	// HLS only carries HEVC in fMP4 segments, which MediaConvert writes
+	// through CMAF output groups.
+	if container == types.ContainerTypeM3u8 && preset.Video.IsHEVC() {
+		container = types.ContainerTypeCmfc
+	}
+
+	var videoPreset *types.VideoDescription
+	if !preset.AudioOnly() {
+		videoPreset, err = videoPresetFrom(preset)
+		if err != nil {
+			return mediaconvert.CreatePresetInput{}, errors.Wrap(err, "generating video preset")
+		}
 	}
 
 	audioPreset, err := audioPresetFrom(preset)
 	if err != nil {
-		return "", errors.Wrap(err, "generating audio preset")
+		return mediaconvert.CreatePresetInput{}, errors.Wrap(err, "generating audio preset")
 	}
 
 	presetInput := mediaconvert.CreatePresetInput{
@@ -241,20 +752,20 @@ func (p *mcProvider) CreatePreset(preset db.Preset) (string, error) {
 			AudioDescriptions: []types.AudioDescription{*audioPreset},
 		},
 	}
-
-	resp, err := p.client.CreatePreset(context.Background(), &presetInput)
+	err = provider.ApplyOverrides(&presetInput, preset.ProviderOverrides.For(Name), true)
 	if err != nil {
-		return "", err
-	}
-	if resp == nil || resp.Preset == nil || resp.Preset.Name == nil {
-		return "", fmt.Errorf("unexpected response from MediaConvert: %v", resp)
+		return mediaconvert.CreatePresetInput{}, errors.Wrap(err, "applying MediaConvert overrides")
 	}
 
-	return *resp.Preset.Name, nil
+	return presetInput, nil
 }
 
 func (p *mcProvider) GetPreset(presetID string) (interface{}, error) {
 	preset, err := p.fetchPreset(presetID)
+	var notFound *types.NotFoundException
+	if errors.As(err, &notFound) {
+		return nil, provider.PresetNotFoundError{ID: presetID}
+	}
 	if err != nil {
 		return nil, err
 	}
@@ -262,6 +773,37 @@ func (p *mcProvider) GetPreset(presetID string) (interface{}, error) {
 	return preset, err
 }
 
+// ListPresets lists the custom presets of the account, leaving out the
+// system presets provided by MediaConvert.
+func (p *mcProvider) ListPresets() ([]provider.StoredPreset, error) {
+	var presets []provider.StoredPreset
+	input := mediaconvert.ListPresetsInput{}
+	for {
+		resp, err := p.client.ListPresets(context.Background(), &input)
+		if err != nil {
+			return nil, err
+		}
+		for _, preset := range resp.Presets {
+			if preset.Type != types.TypeCustom || preset.Name == nil {
+				continue
+			}
+			presets = append(presets, provider.StoredPreset{ID: *preset.Name, Preset: preset})
+		}
+		if resp.NextToken == nil {
+			return presets, nil
+		}
+		input.NextToken = resp.NextToken
+	}
+}
+
+func (p *mcProvider) NormalizePreset(native interface{}) (db.Preset, error) {
+	preset, ok := native.(types.Preset)
+	if !ok {
+		return db.Preset{}, fmt.Errorf("unexpected mediaconvert preset type %T", native)
+	}
+	return canonicalPresetFrom(preset), nil
+}
+
 func (p *mcProvider) fetchPreset(presetID string) (types.Preset, error) {
 	preset, err := p.client.GetPreset(context.Background(), &mediaconvert.GetPresetInput{
 		Name: aws.String(presetID),
@@ -292,17 +834,17 @@ func (p *mcProvider) JobStatus(job *db.Job) (*provider.JobStatus, error) {
 		return &provider.JobStatus{}, errors.Wrap(err, "fetching job info with the mediaconvert API")
 	}
 
-	return p.jobStatusFrom(job.ProviderJobID, job.ID, jobResp.Job), nil
+	return p.jobStatusFrom(job, jobResp.Job), nil
 }
 
-func (p *mcProvider) jobStatusFrom(providerJobID string, jobID string, job *types.Job) *provider.JobStatus {
+func (p *mcProvider) jobStatusFrom(dbJob *db.Job, job *types.Job) *provider.JobStatus {
 	status := &provider.JobStatus{
-		ProviderJobID: providerJobID,
+		ProviderJobID: dbJob.ProviderJobID,
 		ProviderName:  Name,
 		Status:        providerStatusFrom(job.Status),
 		StatusMessage: statusMsgFrom(job),
 		Output: provider.JobOutput{
-			Destination: destinationPathFrom(p.cfg.Destination, jobID),
+			Destination: destinationPathFrom(p.cfg.Destination, dbJob.ID),
 		},
 	}
 
@@ -325,11 +867,37 @@ func (p *mcProvider) jobStatusFrom(providerJobID string, jobID string, job *type
 			})
 		}
 	}
+	if status.Status == provider.StatusFinished {
+		files = append(files, cmafManifestsFrom(job)...)
+	}
 	status.Output.Files = files
 
 	return status
 }
 
+// cmafManifestsFrom lists the manifests written by the CMAF output groups of
+// the job. The manifests are named after the destination of the group.
+func cmafManifestsFrom(job *types.Job) []provider.OutputFile {
+	if job.Settings == nil {
+		return nil
+	}
+	var files []provider.OutputFile
+	for _, group := range job.Settings.OutputGroups {
+		if group.OutputGroupSettings == nil || group.OutputGroupSettings.CmafGroupSettings == nil {
+			continue
+		}
+		settings := group.OutputGroupSettings.CmafGroupSettings
+		destination := aws.ToString(settings.Destination)
+		if settings.WriteHlsManifest == types.CmafWriteHLSManifestEnabled {
+			files = append(files, provider.OutputFile{Path: destination + ".m3u8", Container: "m3u8"})
+		}
+		if settings.WriteDashManifest == types.CmafWriteDASHManifestEnabled {
+			files = append(files, provider.OutputFile{Path: destination + ".mpd", Container: "mpd"})
+		}
+	}
+	return files
+}
+
 func statusMsgFrom(job *types.Job) string {
 	if job.ErrorMessage != nil {
 		return *job.ErrorMessage
@@ -357,13 +925,17 @@ func (p *mcProvider) Healthcheck() error {
 func (p *mcProvider) Capabilities() provider.Capabilities {
 	return provider.Capabilities{
 		InputFormats:  []string{"h264"},
-		OutputFormats: []string{"mp4", "hls"},
+		OutputFormats: []string{"mp4", "hls", "cmaf", "webm"},
 		Destinations:  []string{"s3"},
+		VideoCodecs:   []string{db.VideoCodecH264, db.VideoCodecHEVC, db.VideoCodecVP9, db.VideoCodecAV1},
+		AudioCodecs:   []string{db.AudioCodecAAC, db.AudioCodecOpus},
+		HDR:           true,
+		ContentAware:  true,
 	}
 }
 
 func mediaconvertFactory(cfg *config.Config) (provider.TranscodingProvider, error) {
-	if cfg.MediaConvert.Endpoint == "" || cfg.MediaConvert.Queue == "" || cfg.MediaConvert.Role == "" {
+	if cfg.MediaConvert == nil || cfg.MediaConvert.Endpoint == "" || cfg.MediaConvert.Queue == "" || cfg.MediaConvert.Role == "" {
 		return nil, errors.New("incomplete MediaConvert config")
 	}
 
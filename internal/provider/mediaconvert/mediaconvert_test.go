@@ -1,6 +1,7 @@
 package mediaconvert
 
 import (
+	"encoding/json"
 	"reflect"
 	"testing"
 
@@ -19,7 +20,7 @@ var (
 		Description: "test_desc",
 		Container:   "mp4",
 		RateControl: "VBR",
-		TwoPass:     true,
+		TwoPass:     aws.Bool(true),
 		Video: db.VideoPreset{
 			Profile:       "high",
 			ProfileLevel:  "4.1",
@@ -161,6 +162,273 @@ func Test_mcProvider_CreatePreset_fields(t *testing.T) {
 				}
 			},
 		},
+		{
+			name: "cmaf presets are set correctly",
+			presetModifier: func(p db.Preset) db.Preset {
+				p.Container = "cmaf"
+				return p
+			},
+			assertion: func(input *mediaconvert.CreatePresetInput, t *testing.T) {
+				if g, e := input.Settings.ContainerSettings.Container, types.ContainerTypeCmfc; g != e {
+					t.Fatalf("got %q, expected %q", g, e)
+				}
+			},
+		},
+		{
+			name: "hevc presets are set correctly",
+			presetModifier: func(p db.Preset) db.Preset {
+				p.Video.Codec = "hevc"
+				p.Video.Profile = "main10"
+				p.Video.Tier = "high"
+				p.Video.ProfileLevel = "5.1"
+				return p
+			},
+			assertion: func(input *mediaconvert.CreatePresetInput, t *testing.T) {
+				codecSettings := input.Settings.VideoDescription.CodecSettings
+				if g, e := codecSettings.Codec, types.VideoCodecH265; g != e {
+					t.Fatalf("got %q, expected %q", g, e)
+				}
+				want := types.H265Settings{
+					Bitrate:                             400000,
+					GopSize:                             120,
+					RateControlMode:                     types.H265RateControlModeVbr,
+					CodecProfile:                        types.H265CodecProfileMain10High,
+					CodecLevel:                          types.H265CodecLevelLevel51,
+					InterlaceMode:                       types.H265InterlaceModeProgressive,
+					QualityTuningLevel:                  types.H265QualityTuningLevelMultiPassHq,
+					NumberBFramesBetweenReferenceFrames: 3,
+					WriteMp4PackagingType:               types.H265WriteMp4PackagingTypeHvc1,
+				}
+				if g := *codecSettings.H265Settings; !reflect.DeepEqual(g, want) {
+					t.Fatalf("wrong h265 settings\ngot  %#v\nwant %#v", g, want)
+				}
+			},
+		},
+		{
+			name: "hevc hls presets are packaged as cmaf",
+			presetModifier: func(p db.Preset) db.Preset {
+				p.Container = "m3u8"
+				p.Video.Codec = "hevc"
+				p.Video.Profile = "main"
+				p.Video.ProfileLevel = ""
+				return p
+			},
+			assertion: func(input *mediaconvert.CreatePresetInput, t *testing.T) {
+				if g, e := input.Settings.ContainerSettings.Container, types.ContainerTypeCmfc; g != e {
+					t.Fatalf("got %q, expected %q", g, e)
+				}
+			},
+		},
+		{
+			name: "vp9/opus webm presets are set correctly",
+			presetModifier: func(p db.Preset) db.Preset {
+				p.Container = "webm"
+				p.Video.Codec = "vp9"
+				p.Video.Profile = ""
+				p.Video.ProfileLevel = ""
+				p.Audio.Codec = "opus"
+				p.Audio.Bitrate = "96000"
+				p.Audio.ChannelLayout = "mono"
+				return p
+			},
+			assertion: func(input *mediaconvert.CreatePresetInput, t *testing.T) {
+				if g, e := input.Settings.ContainerSettings.Container, types.ContainerTypeWebm; g != e {
+					t.Fatalf("got %q, expected %q", g, e)
+				}
+				codecSettings := input.Settings.VideoDescription.CodecSettings
+				if g, e := codecSettings.Codec, types.VideoCodecVp9; g != e {
+					t.Fatalf("got %q, expected %q", g, e)
+				}
+				wantVP9 := types.Vp9Settings{
+					Bitrate:            400000,
+					GopSize:            120,
+					RateControlMode:    types.Vp9RateControlModeVbr,
+					QualityTuningLevel: types.Vp9QualityTuningLevelMultiPassHq,
+				}
+				if g := *codecSettings.Vp9Settings; !reflect.DeepEqual(g, wantVP9) {
+					t.Fatalf("wrong vp9 settings\ngot  %#v\nwant %#v", g, wantVP9)
+				}
+				wantOpus := types.OpusSettings{
+					Bitrate:    96000,
+					Channels:   1,
+					SampleRate: defaultAudioSampleRate,
+				}
+				if g := *input.Settings.AudioDescriptions[0].CodecSettings.OpusSettings; !reflect.DeepEqual(g, wantOpus) {
+					t.Fatalf("wrong opus settings\ngot  %#v\nwant %#v", g, wantOpus)
+				}
+			},
+		},
+		{
+			name: "av1 presets are set correctly",
+			presetModifier: func(p db.Preset) db.Preset {
+				p.Video.Codec = "av1"
+				p.Video.Profile = "main"
+				p.Video.ProfileLevel = ""
+				p.RateControl = "qvbr"
+				return p
+			},
+			assertion: func(input *mediaconvert.CreatePresetInput, t *testing.T) {
+				codecSettings := input.Settings.VideoDescription.CodecSettings
+				if g, e := codecSettings.Codec, types.VideoCodecAv1; g != e {
+					t.Fatalf("got %q, expected %q", g, e)
+				}
+				want := types.Av1Settings{
+					MaxBitrate:                          400000,
+					GopSize:                             120,
+					RateControlMode:                     types.Av1RateControlModeQvbr,
+					NumberBFramesBetweenReferenceFrames: 3,
+				}
+				if g := *codecSettings.Av1Settings; !reflect.DeepEqual(g, want) {
+					t.Fatalf("wrong av1 settings\ngot  %#v\nwant %#v", g, want)
+				}
+			},
+		},
+		{
+			name: "10-bit hdr10 hevc presets are set correctly",
+			presetModifier: func(p db.Preset) db.Preset {
+				p.Video.Codec = "hevc"
+				p.Video.Profile = ""
+				p.Video.ProfileLevel = ""
+				p.Video.BitDepth = "10"
+				p.Video.HDR = "hdr10"
+				return p
+			},
+			assertion: func(input *mediaconvert.CreatePresetInput, t *testing.T) {
+				videoDescription := input.Settings.VideoDescription
+				if g, e := videoDescription.CodecSettings.H265Settings.CodecProfile, types.H265CodecProfileMain10Main; g != e {
+					t.Fatalf("got %q, expected %q", g, e)
+				}
+				want := types.ColorCorrector{ColorSpaceConversion: types.ColorSpaceConversionForceHdr10}
+				if g := *videoDescription.VideoPreprocessors.ColorCorrector; !reflect.DeepEqual(g, want) {
+					t.Fatalf("wrong color corrector\ngot  %#v\nwant %#v", g, want)
+				}
+			},
+		},
+		{
+			name: "hdr passthrough keeps the source color space and inserts its metadata",
+			presetModifier: func(p db.Preset) db.Preset {
+				p.Video.Codec = "av1"
+				p.Video.Profile = ""
+				p.Video.ProfileLevel = ""
+				p.Video.BitDepth = "10"
+				p.Video.HDR = "passthrough"
+				p.RateControl = "qvbr"
+				return p
+			},
+			assertion: func(input *mediaconvert.CreatePresetInput, t *testing.T) {
+				videoDescription := input.Settings.VideoDescription
+				if g, e := videoDescription.CodecSettings.Av1Settings.BitDepth, types.Av1BitDepthBit10; g != e {
+					t.Fatalf("got %q, expected %q", g, e)
+				}
+				if videoDescription.VideoPreprocessors != nil {
+					t.Fatalf("unexpected video preprocessors: %#v", videoDescription.VideoPreprocessors)
+				}
+				if g, e := videoDescription.ColorMetadata, types.ColorMetadataInsert; g != e {
+					t.Fatalf("got %q, expected %q", g, e)
+				}
+			},
+		},
+		{
+			name: "tone-mapping to sdr converts to rec709",
+			presetModifier: func(p db.Preset) db.Preset {
+				p.Video.HDR = "sdr"
+				return p
+			},
+			assertion: func(input *mediaconvert.CreatePresetInput, t *testing.T) {
+				want := types.ColorCorrector{ColorSpaceConversion: types.ColorSpaceConversionForce709}
+				if g := *input.Settings.VideoDescription.VideoPreprocessors.ColorCorrector; !reflect.DeepEqual(g, want) {
+					t.Fatalf("wrong color corrector\ngot  %#v\nwant %#v", g, want)
+				}
+			},
+		},
+		{
+			name: "10-bit h264 presets use the high 10-bit profile",
+			presetModifier: func(p db.Preset) db.Preset {
+				p.Video.BitDepth = "10"
+				return p
+			},
+			assertion: func(input *mediaconvert.CreatePresetInput, t *testing.T) {
+				if g, e := input.Settings.VideoDescription.CodecSettings.H264Settings.CodecProfile, types.H264CodecProfileHigh10bit; g != e {
+					t.Fatalf("got %q, expected %q", g, e)
+				}
+			},
+		},
+		{
+			name: "frame rate, scaling, cropping and deinterlacing settings are mapped correctly",
+			presetModifier: func(p db.Preset) db.Preset {
+				p.Video.FrameRate = "29.97"
+				p.Video.ScalingBehavior = "stretch"
+				p.Video.Crop = db.CropSettings{Y: "140", Width: "1920", Height: "800"}
+				p.Video.Deinterlacer = "inverse-telecine"
+				return p
+			},
+			assertion: func(input *mediaconvert.CreatePresetInput, t *testing.T) {
+				videoDescription := input.Settings.VideoDescription
+				if g, e := videoDescription.ScalingBehavior, types.ScalingBehaviorStretchToOutput; g != e {
+					t.Fatalf("got %q, expected %q", g, e)
+				}
+				h264Settings := videoDescription.CodecSettings.H264Settings
+				if g, e := h264Settings.FramerateControl, types.H264FramerateControlSpecified; g != e {
+					t.Fatalf("got %q, expected %q", g, e)
+				}
+				if h264Settings.FramerateNumerator != 2997 || h264Settings.FramerateDenominator != 100 {
+					t.Fatalf("wrong frame rate: got %d/%d", h264Settings.FramerateNumerator, h264Settings.FramerateDenominator)
+				}
+				wantCrop := types.Rectangle{Y: 140, Width: 1920, Height: 800}
+				if g := *videoDescription.Crop; !reflect.DeepEqual(g, wantCrop) {
+					t.Fatalf("wrong crop\ngot  %#v\nwant %#v", g, wantCrop)
+				}
+				wantDeinterlacer := types.Deinterlacer{
+					Algorithm: types.DeinterlaceAlgorithmInterpolate,
+					Control:   types.DeinterlacerControlNormal,
+					Mode:      types.DeinterlacerModeInverseTelecine,
+				}
+				if g := *videoDescription.VideoPreprocessors.Deinterlacer; !reflect.DeepEqual(g, wantDeinterlacer) {
+					t.Fatalf("wrong deinterlacer\ngot  %#v\nwant %#v", g, wantDeinterlacer)
+				}
+			},
+		},
+		{
+			name: "unsupported scaling behavior returns an error",
+			presetModifier: func(p db.Preset) db.Preset {
+				p.Video.ScalingBehavior = "fill"
+				return p
+			},
+			wantErrMsg: `generating video preset: scaling behavior "fill" is not supported with mediaconvert`,
+		},
+		{
+			name: "sdr rec2020 output returns an error",
+			presetModifier: func(p db.Preset) db.Preset {
+				p.Video.ColorSpace = "rec2020"
+				return p
+			},
+			wantErrMsg: `generating video preset: color space "rec2020" is only supported along with hdr10 or hlg on mediaconvert`,
+		},
+		{
+			name: "unsupported av1 rate control mode returns an error",
+			presetModifier: func(p db.Preset) db.Preset {
+				p.Video.Codec = "av1"
+				return p
+			},
+			wantErrMsg: `generating video preset: rate control mode "vbr" is not supported with av1 on mediaconvert`,
+		},
+		{
+			name: "unsupported opus channel layout returns an error",
+			presetModifier: func(p db.Preset) db.Preset {
+				p.Audio.Codec = "opus"
+				p.Audio.ChannelLayout = "5.1"
+				return p
+			},
+			wantErrMsg: `generating audio preset: channel layout "5.1" is not supported with opus on mediaconvert`,
+		},
+		{
+			name: "unrecognized h265 codec profile returns an error",
+			presetModifier: func(p db.Preset) db.Preset {
+				p.Video.Codec = "hevc"
+				return p
+			},
+			wantErrMsg: `generating video preset: h265 profile "high" is not supported with mediaconvert`,
+		},
 		{
 			name: "unrecognized containers return an error",
 			presetModifier: func(p db.Preset) db.Preset {
@@ -199,7 +467,7 @@ func Test_mcProvider_CreatePreset_fields(t *testing.T) {
 				p.Video.Width = "s"
 				return p
 			},
-			wantErrMsg: `generating video preset: parsing video width "s" to int32: strconv.ParseInt: parsing "s": invalid syntax`,
+			wantErrMsg: `generating video preset: parsing video width: "s" must be a positive integer`,
 		},
 		{
 			name: "bad video height returns an error",
@@ -207,7 +475,7 @@ func Test_mcProvider_CreatePreset_fields(t *testing.T) {
 				p.Video.Height = "h"
 				return p
 			},
-			wantErrMsg: `generating video preset: parsing video height "h" to int32: strconv.ParseInt: parsing "h": invalid syntax`,
+			wantErrMsg: `generating video preset: parsing video height: "h" must be a positive integer`,
 		},
 		{
 			name: "bad video bitrate returns an error",
@@ -215,7 +483,7 @@ func Test_mcProvider_CreatePreset_fields(t *testing.T) {
 				p.Video.Bitrate = "bitrate"
 				return p
 			},
-			wantErrMsg: `generating video preset: parsing video bitrate "bitrate" to int32: strconv.ParseInt: parsing "bitrate": invalid syntax`,
+			wantErrMsg: `generating video preset: parsing video bitrate: bitrate "bitrate" must be a positive number of bits per second, optionally followed by k or M`,
 		},
 		{
 			name: "bad video gop size returns an error",
@@ -223,7 +491,21 @@ func Test_mcProvider_CreatePreset_fields(t *testing.T) {
 				p.Video.GopSize = "gop"
 				return p
 			},
-			wantErrMsg: `generating video preset: parsing gop size "gop" to float64: strconv.ParseFloat: parsing "gop": invalid syntax`,
+			wantErrMsg: `generating video preset: parsing gop size: gop size "gop" must be a positive number of frames or seconds (like 2s)`,
+		},
+		{
+			name: "gop size in seconds is converted to frames",
+			presetModifier: func(p db.Preset) db.Preset {
+				p.Video.GopSize = "2s"
+				p.Video.FrameRate = "30000/1001"
+				return p
+			},
+			assertion: func(input *mediaconvert.CreatePresetInput, t *testing.T) {
+				h264Settings := input.Settings.VideoDescription.CodecSettings.H264Settings
+				if g, e := h264Settings.GopSize, float64(60); g != e {
+					t.Fatalf("got %v, expected %v", g, e)
+				}
+			},
 		},
 		{
 			name: "unrecognized rate control mode returns an error",
@@ -247,7 +529,7 @@ func Test_mcProvider_CreatePreset_fields(t *testing.T) {
 				p.Audio.Bitrate = "aud_bitrate"
 				return p
 			},
-			wantErrMsg: `generating audio preset: parsing audio bitrate "aud_bitrate" to int32: strconv.ParseInt: parsing "aud_bitrate": invalid syntax`,
+			wantErrMsg: `generating audio preset: parsing audio bitrate: bitrate "aud_bitrate" must be a positive number of bits per second, optionally followed by k or M`,
 		},
 		{
 			name: "unrecognized audio codec returns an error",
@@ -257,6 +539,93 @@ func Test_mcProvider_CreatePreset_fields(t *testing.T) {
 			},
 			wantErrMsg: `generating audio preset: audio codec "aab" is not yet supported with mediaconvert`,
 		},
+		{
+			name: "audio-only presets don't generate a video description",
+			presetModifier: func(p db.Preset) db.Preset {
+				p.Video = db.VideoPreset{}
+				return p
+			},
+			assertion: func(input *mediaconvert.CreatePresetInput, t *testing.T) {
+				if input.Settings.VideoDescription != nil {
+					t.Fatalf("unexpected video description: %#v", input.Settings.VideoDescription)
+				}
+				if g, e := len(input.Settings.AudioDescriptions), 1; g != e {
+					t.Fatalf("wrong number of audio descriptions: got %d, expected %d", g, e)
+				}
+			},
+		},
+		{
+			name: "advanced audio settings are mapped correctly",
+			presetModifier: func(p db.Preset) db.Preset {
+				p.Audio.ChannelLayout = "5.1"
+				p.Audio.SampleRate = "44100"
+				p.Audio.Profile = "he"
+				p.Audio.LoudnessStandard = "atsc-a85"
+				return p
+			},
+			assertion: func(input *mediaconvert.CreatePresetInput, t *testing.T) {
+				audio := input.Settings.AudioDescriptions[0]
+				wantAAC := types.AacSettings{
+					SampleRate:      44100,
+					Bitrate:         20000,
+					CodecProfile:    types.AacCodecProfileHev1,
+					CodingMode:      types.AacCodingModeCodingMode51,
+					RateControlMode: types.AacRateControlModeCbr,
+				}
+				if g, e := *audio.CodecSettings.AacSettings, wantAAC; !reflect.DeepEqual(g, e) {
+					t.Fatalf("wrong aac settings\ngot  %#v\nwant %#v", g, e)
+				}
+				wantNormalization := types.AudioNormalizationSettings{
+					Algorithm:        types.AudioNormalizationAlgorithmItuBs17703,
+					AlgorithmControl: types.AudioNormalizationAlgorithmControlCorrectAudio,
+					TargetLkfs:       -24,
+				}
+				if g, e := *audio.AudioNormalizationSettings, wantNormalization; !reflect.DeepEqual(g, e) {
+					t.Fatalf("wrong normalization settings\ngot  %#v\nwant %#v", g, e)
+				}
+			},
+		},
+		{
+			name: "bad audio sample rate returns an error",
+			presetModifier: func(p db.Preset) db.Preset {
+				p.Audio.SampleRate = "rate"
+				return p
+			},
+			wantErrMsg: `generating audio preset: parsing audio sample rate "rate" to int32: strconv.ParseInt: parsing "rate": invalid syntax`,
+		},
+		{
+			name: "provider overrides are merged into the preset",
+			presetModifier: func(p db.Preset) db.Preset {
+				p.ProviderOverrides = db.ProviderOverrides{
+					"mediaconvert": json.RawMessage(`{"settings":{"videoDescription":{"sharpness":75,"codecSettings":{"h264Settings":{"syntax":"RP2027"}}}}}`),
+					"zencoder":     json.RawMessage(`{"sharpness":0}`),
+				}
+				return p
+			},
+			assertion: func(input *mediaconvert.CreatePresetInput, t *testing.T) {
+				videoDescription := input.Settings.VideoDescription
+				if g, e := videoDescription.Sharpness, int32(75); g != e {
+					t.Fatalf("got %d, expected %d", g, e)
+				}
+				h264Settings := videoDescription.CodecSettings.H264Settings
+				if g, e := h264Settings.Syntax, types.H264SyntaxRp2027; g != e {
+					t.Fatalf("got %q, expected %q", g, e)
+				}
+				if g, e := h264Settings.Bitrate, int32(400000); g != e {
+					t.Fatalf("overrides changed the bitrate: got %d, expected %d", g, e)
+				}
+			},
+		},
+		{
+			name: "provider overrides that don't match the preset input return an error",
+			presetModifier: func(p db.Preset) db.Preset {
+				p.ProviderOverrides = db.ProviderOverrides{
+					"mediaconvert": json.RawMessage(`{"settings":{"unknownSetting":true}}`),
+				}
+				return p
+			},
+			wantErrMsg: `applying MediaConvert overrides: invalid provider overrides: json: unknown field "unknownSetting"`,
+		},
 	}
 	for _, tt := range tests {
 		tt := tt
@@ -276,6 +645,33 @@ func Test_mcProvider_CreatePreset_fields(t *testing.T) {
 	}
 }
 
+func Test_mcProvider_UpdatePreset(t *testing.T) {
+	client := &testMediaConvertClient{t: t}
+	p := &mcProvider{client: client}
+	if _, err := p.CreatePreset(defaultPreset); err != nil {
+		t.Fatal(err)
+	}
+
+	presetID, err := p.UpdatePreset("preset_name", defaultPreset)
+	if err != nil {
+		t.Fatalf("expected UpdatePreset() not to return an error, got: %v", err)
+	}
+	if presetID != "preset_name" {
+		t.Errorf("wrong preset id returned\nwant %q\ngot  %q", "preset_name", presetID)
+	}
+
+	input := client.updatePresetCalledWith
+	if input == nil {
+		t.Fatal("expected UpdatePreset() to be called on the client")
+	}
+	if g, e := aws.ToString(input.Name), "preset_name"; g != e {
+		t.Errorf("wrong preset name\nwant %q\ngot  %q", e, g)
+	}
+	if !reflect.DeepEqual(input.Settings, client.createPresetCalledWith.Settings) {
+		t.Errorf("UpdatePreset(): settings differ from CreatePreset()\nwant %+v\ngot  %+v", client.createPresetCalledWith.Settings, input.Settings)
+	}
+}
+
 func Test_mcProvider_GetPreset(t *testing.T) {
 	presetID := "some_preset"
 	client := &testMediaConvertClient{t: t}
@@ -290,6 +686,87 @@ func Test_mcProvider_GetPreset(t *testing.T) {
 	}
 }
 
+func Test_mcProvider_GetPresetNotFound(t *testing.T) {
+	client := &testMediaConvertClient{t: t, getPresetErr: &types.NotFoundException{Message: aws.String("not found")}}
+	p := &mcProvider{client: client}
+	_, err := p.GetPreset("some_preset")
+	if e := (provider.PresetNotFoundError{ID: "some_preset"}); err != e {
+		t.Fatalf("wrong error returned\nwant %#v\ngot  %#v", e, err)
+	}
+}
+
+func Test_mcProvider_ListPresets(t *testing.T) {
+	client := &testMediaConvertClient{
+		t: t,
+		presetPages: [][]types.Preset{
+			{
+				{Name: aws.String("custom_1"), Type: types.TypeCustom},
+				{Name: aws.String("System-Generic_Hd_Mp4_Avc_Aac_16x9_1920x1080p_24Hz_6Mbps"), Type: types.TypeSystem},
+			},
+			{
+				{Name: aws.String("custom_2"), Type: types.TypeCustom},
+			},
+		},
+	}
+	p := &mcProvider{client: client}
+	presets, err := p.ListPresets()
+	if err != nil {
+		t.Fatalf("expected ListPresets() not to return an error, got: %v", err)
+	}
+
+	want := []provider.StoredPreset{
+		{ID: "custom_1", Preset: types.Preset{Name: aws.String("custom_1"), Type: types.TypeCustom}},
+		{ID: "custom_2", Preset: types.Preset{Name: aws.String("custom_2"), Type: types.TypeCustom}},
+	}
+	if !reflect.DeepEqual(presets, want) {
+		t.Fatalf("ListPresets(): wrong presets\nWant %+v\nGot %+v", want, presets)
+	}
+}
+
+func Test_mcProvider_NormalizePreset(t *testing.T) {
+	client := &testMediaConvertClient{t: t}
+	p := &mcProvider{client: client}
+	_, err := p.CreatePreset(defaultPreset)
+	if err != nil {
+		t.Fatal(err)
+	}
+	preset, err := p.NormalizePreset(types.Preset{
+		Name:        client.createPresetCalledWith.Name,
+		Description: client.createPresetCalledWith.Description,
+		Settings:    client.createPresetCalledWith.Settings,
+	})
+	if err != nil {
+		t.Fatalf("expected NormalizePreset() not to return an error, got: %v", err)
+	}
+
+	expected := db.Preset{
+		Name:        "preset_name",
+		Description: "test_desc",
+		Container:   "mp4",
+		Video: db.VideoPreset{
+			Width:   "300",
+			Height:  "400",
+			Codec:   "h264",
+			Bitrate: "400000",
+			GopSize: "120",
+		},
+		Audio: db.AudioPreset{
+			Codec:   "aac",
+			Bitrate: "20000",
+		},
+	}
+	if !reflect.DeepEqual(preset, expected) {
+		t.Fatalf("NormalizePreset(): wrong preset\nWant %+v\nGot %+v\nDiff %s", expected, preset, cmp.Diff(expected, preset))
+	}
+	if drift := defaultPreset.Drift(preset); len(drift) > 0 {
+		t.Errorf("unexpected drift from the original preset: %+v", drift)
+	}
+
+	if _, err = p.NormalizePreset("preset_name"); err == nil {
+		t.Error("expected NormalizePreset() to return an error for a non-preset value")
+	}
+}
+
 func Test_mcProvider_DeletePreset(t *testing.T) {
 	presetID := "some_preset_id"
 	client := &testMediaConvertClient{t: t}
@@ -309,6 +786,7 @@ func Test_mcProvider_Transcode(t *testing.T) {
 		name                string
 		job                 *db.Job
 		presetContainerType types.ContainerType
+		presetSettings      *types.PresetSettings
 		destination         string
 		wantJobReq          mediaconvert.CreateJobInput
 		wantErr             bool
@@ -364,7 +842,10 @@ func Test_mcProvider_Transcode(t *testing.T) {
 			name:                "a valid hls transcode job is mapped correctly to a mediaconvert job input",
 			job:                 &defaultJob,
 			presetContainerType: types.ContainerTypeM3u8,
-			destination:         "s3://some/destination",
+			presetSettings: &types.PresetSettings{
+				VideoDescription: &types.VideoDescription{Width: 1920, Height: 1080},
+			},
+			destination: "s3://some/destination",
 			wantJobReq: mediaconvert.CreateJobInput{
 				Role:  aws.String(""),
 				Queue: aws.String(""),
@@ -413,20 +894,392 @@ func Test_mcProvider_Transcode(t *testing.T) {
 				},
 			},
 		},
-	}
-
-	for _, tt := range tests {
-		tt := tt
-		t.Run(tt.name, func(t *testing.T) {
-			client := &testMediaConvertClient{t: t, getPresetContainerType: tt.presetContainerType}
-			p := &mcProvider{client: client, cfg: &config.MediaConvert{
-				Destination: tt.destination,
-			}}
-			_, err := p.Transcode(tt.job)
-			if (err != nil) != tt.wantErr {
-				t.Errorf("mcProvider.Transcode() error = %v, wantErr %v", err, tt.wantErr)
-				return
-			}
+		{
+			name:                "audio-only hls outputs are mapped to audio-only variant streams",
+			job:                 &defaultJob,
+			presetContainerType: types.ContainerTypeM3u8,
+			presetSettings: &types.PresetSettings{
+				AudioDescriptions: []types.AudioDescription{
+					{CodecSettings: &types.AudioCodecSettings{Codec: types.AudioCodecAac}},
+				},
+			},
+			destination: "s3://some/destination",
+			wantJobReq: mediaconvert.CreateJobInput{
+				Role:  aws.String(""),
+				Queue: aws.String(""),
+				Settings: &types.JobSettings{
+					Inputs: []types.Input{
+						{
+							AudioSelectors: map[string]types.AudioSelector{
+								"Audio Selector 1": {
+									DefaultSelection: types.AudioDefaultSelectionDefault,
+								},
+							},
+							FileInput: aws.String("s3://some/path.mp4"),
+							VideoSelector: &types.VideoSelector{
+								ColorSpace: types.ColorSpaceFollow,
+							},
+						},
+					},
+					OutputGroups: []types.OutputGroup{
+						{
+							OutputGroupSettings: &types.OutputGroupSettings{
+								Type: types.OutputGroupTypeHlsGroupSettings,
+								HlsGroupSettings: &types.HlsGroupSettings{
+									Destination:            aws.String("s3://some/destination/jobID/"),
+									SegmentLength:          6,
+									MinSegmentLength:       0,
+									DirectoryStructure:     types.HlsDirectoryStructureSingleDirectory,
+									ManifestDurationFormat: types.HlsManifestDurationFormatFloatingPoint,
+									OutputSelection:        types.HlsOutputSelectionManifestsAndSegments,
+									SegmentControl:         types.HlsSegmentControlSegmentedFiles,
+								},
+							},
+							Outputs: []types.Output{
+								{
+									NameModifier: aws.String("file1"),
+									Preset:       aws.String("preset1"),
+									Extension:    aws.String("mp4"),
+									OutputSettings: &types.OutputSettings{
+										HlsSettings: &types.HlsSettings{
+											AudioTrackType: types.HlsAudioTrackTypeAudioOnlyVariantStream,
+										},
+									},
+								},
+								{
+									NameModifier: aws.String("file2"),
+									Preset:       aws.String("preset2"),
+									Extension:    aws.String("mp4"),
+									OutputSettings: &types.OutputSettings{
+										HlsSettings: &types.HlsSettings{
+											AudioTrackType: types.HlsAudioTrackTypeAudioOnlyVariantStream,
+										},
+									},
+								},
+							},
+						},
+					},
+				},
+			},
+		},
+		{
+			name: "alternate audio tracks are mapped to audio selectors and hls audio renditions",
+			job: &db.Job{
+				ID:              "jobID",
+				SourceMedia:     "s3://some/path.mp4",
+				Outputs:         defaultJob.Outputs[:1],
+				StreamingParams: defaultJob.StreamingParams,
+				AudioTracks: []db.AudioTrack{
+					{TrackIndex: 2, Language: "es", Name: "Español"},
+					{SourceMedia: "s3://some/fr.wav", Language: "fr", Default: true},
+				},
+			},
+			presetContainerType: types.ContainerTypeM3u8,
+			presetSettings: &types.PresetSettings{
+				VideoDescription: &types.VideoDescription{Width: 1920, Height: 1080},
+				AudioDescriptions: []types.AudioDescription{
+					{CodecSettings: &types.AudioCodecSettings{Codec: types.AudioCodecAac}},
+				},
+			},
+			destination: "s3://some/destination",
+			wantJobReq: mediaconvert.CreateJobInput{
+				Role:  aws.String(""),
+				Queue: aws.String(""),
+				Settings: &types.JobSettings{
+					Inputs: []types.Input{
+						{
+							AudioSelectors: map[string]types.AudioSelector{
+								"Audio Selector 1": {
+									DefaultSelection: types.AudioDefaultSelectionDefault,
+								},
+								"Audio Selector 2": {
+									DefaultSelection: types.AudioDefaultSelectionNotDefault,
+									SelectorType:     types.AudioSelectorTypeTrack,
+									Tracks:           []int32{2},
+								},
+								"Audio Selector 3": {
+									DefaultSelection:       types.AudioDefaultSelectionNotDefault,
+									ExternalAudioFileInput: aws.String("s3://some/fr.wav"),
+								},
+							},
+							FileInput: aws.String("s3://some/path.mp4"),
+							VideoSelector: &types.VideoSelector{
+								ColorSpace: types.ColorSpaceFollow,
+							},
+						},
+					},
+					OutputGroups: []types.OutputGroup{
+						{
+							OutputGroupSettings: &types.OutputGroupSettings{
+								Type: types.OutputGroupTypeHlsGroupSettings,
+								HlsGroupSettings: &types.HlsGroupSettings{
+									Destination:            aws.String("s3://some/destination/jobID/"),
+									SegmentLength:          6,
+									MinSegmentLength:       0,
+									DirectoryStructure:     types.HlsDirectoryStructureSingleDirectory,
+									ManifestDurationFormat: types.HlsManifestDurationFormatFloatingPoint,
+									OutputSelection:        types.HlsOutputSelectionManifestsAndSegments,
+									SegmentControl:         types.HlsSegmentControlSegmentedFiles,
+								},
+							},
+							Outputs: []types.Output{
+								{
+									NameModifier: aws.String("file1"),
+									Preset:       aws.String("preset1"),
+									Extension:    aws.String("mp4"),
+									OutputSettings: &types.OutputSettings{
+										HlsSettings: &types.HlsSettings{
+											AudioRenditionSets: aws.String("program_audio"),
+										},
+									},
+								},
+								{
+									NameModifier:      aws.String("audio_es_1"),
+									ContainerSettings: &types.ContainerSettings{Container: types.ContainerTypeM3u8},
+									AudioDescriptions: []types.AudioDescription{
+										{
+											AudioSourceName:     aws.String("Audio Selector 2"),
+											CodecSettings:       &types.AudioCodecSettings{Codec: types.AudioCodecAac},
+											CustomLanguageCode:  aws.String("es"),
+											LanguageCodeControl: types.AudioLanguageCodeControlUseConfigured,
+											StreamName:          aws.String("Español"),
+										},
+									},
+									OutputSettings: &types.OutputSettings{
+										HlsSettings: &types.HlsSettings{
+											AudioGroupId:   aws.String("program_audio"),
+											AudioTrackType: types.HlsAudioTrackTypeAlternateAudioAutoSelect,
+										},
+									},
+								},
+								{
+									NameModifier:      aws.String("audio_fr_2"),
+									ContainerSettings: &types.ContainerSettings{Container: types.ContainerTypeM3u8},
+									AudioDescriptions: []types.AudioDescription{
+										{
+											AudioSourceName:     aws.String("Audio Selector 3"),
+											CodecSettings:       &types.AudioCodecSettings{Codec: types.AudioCodecAac},
+											CustomLanguageCode:  aws.String("fr"),
+											LanguageCodeControl: types.AudioLanguageCodeControlUseConfigured,
+											StreamName:          aws.String("fr"),
+										},
+									},
+									OutputSettings: &types.OutputSettings{
+										HlsSettings: &types.HlsSettings{
+											AudioGroupId:   aws.String("program_audio"),
+											AudioTrackType: types.HlsAudioTrackTypeAlternateAudioAutoSelectDefault,
+										},
+									},
+								},
+							},
+						},
+					},
+				},
+			},
+		},
+		{
+			name: "alternate audio tracks require an output with audio",
+			job: &db.Job{
+				ID:              "jobID",
+				SourceMedia:     "s3://some/path.mp4",
+				Outputs:         defaultJob.Outputs,
+				StreamingParams: defaultJob.StreamingParams,
+				AudioTracks:     []db.AudioTrack{{TrackIndex: 2, Language: "es"}},
+			},
+			presetContainerType: types.ContainerTypeM3u8,
+			presetSettings: &types.PresetSettings{
+				VideoDescription: &types.VideoDescription{Width: 1920, Height: 1080},
+			},
+			wantErr: true,
+		},
+		{
+			name: "a valid cmaf transcode job is mapped to a mediaconvert cmaf group with split tracks",
+			job: &db.Job{
+				ID:          "jobID",
+				SourceMedia: "s3://some/path.mp4",
+				Outputs:     defaultJob.Outputs,
+				StreamingParams: db.StreamingParams{
+					Protocol:         "cmaf",
+					PlaylistFileName: "cmaf/index.m3u8",
+					SegmentDuration:  6,
+				},
+			},
+			presetContainerType: types.ContainerTypeCmfc,
+			presetSettings: &types.PresetSettings{
+				VideoDescription: &types.VideoDescription{Width: 1920, Height: 1080},
+				AudioDescriptions: []types.AudioDescription{
+					{CodecSettings: &types.AudioCodecSettings{Codec: types.AudioCodecAac}},
+				},
+			},
+			destination: "s3://some/destination",
+			wantJobReq: mediaconvert.CreateJobInput{
+				Role:  aws.String(""),
+				Queue: aws.String(""),
+				Settings: &types.JobSettings{
+					Inputs: []types.Input{
+						{
+							AudioSelectors: map[string]types.AudioSelector{
+								"Audio Selector 1": {
+									DefaultSelection: types.AudioDefaultSelectionDefault,
+								},
+							},
+							FileInput: aws.String("s3://some/path.mp4"),
+							VideoSelector: &types.VideoSelector{
+								ColorSpace: types.ColorSpaceFollow,
+							},
+						},
+					},
+					OutputGroups: []types.OutputGroup{
+						{
+							OutputGroupSettings: &types.OutputGroupSettings{
+								Type: types.OutputGroupTypeCmafGroupSettings,
+								CmafGroupSettings: &types.CmafGroupSettings{
+									Destination:            aws.String("s3://some/destination/jobID/cmaf/index"),
+									SegmentLength:          6,
+									FragmentLength:         6,
+									ManifestDurationFormat: types.CmafManifestDurationFormatFloatingPoint,
+									SegmentControl:         types.CmafSegmentControlSegmentedFiles,
+									WriteHlsManifest:       types.CmafWriteHLSManifestEnabled,
+									WriteDashManifest:      types.CmafWriteDASHManifestEnabled,
+								},
+							},
+							Outputs: []types.Output{
+								{
+									NameModifier:      aws.String("file1"),
+									ContainerSettings: &types.ContainerSettings{Container: types.ContainerTypeCmfc},
+									VideoDescription:  &types.VideoDescription{Width: 1920, Height: 1080},
+								},
+								{
+									NameModifier:      aws.String("file2"),
+									ContainerSettings: &types.ContainerSettings{Container: types.ContainerTypeCmfc},
+									VideoDescription:  &types.VideoDescription{Width: 1920, Height: 1080},
+								},
+								{
+									NameModifier:      aws.String("audio_1"),
+									ContainerSettings: &types.ContainerSettings{Container: types.ContainerTypeCmfc},
+									AudioDescriptions: []types.AudioDescription{
+										{CodecSettings: &types.AudioCodecSettings{Codec: types.AudioCodecAac}},
+									},
+								},
+							},
+						},
+					},
+				},
+			},
+		},
+		{
+			name: "alternate audio tracks are added to cmaf groups as alternate audio outputs",
+			job: &db.Job{
+				ID:          "jobID",
+				SourceMedia: "s3://some/path.mp4",
+				Outputs:     defaultJob.Outputs[:1],
+				StreamingParams: db.StreamingParams{
+					Protocol:         "cmaf",
+					PlaylistFileName: "cmaf/index.m3u8",
+					SegmentDuration:  6,
+				},
+				AudioTracks: []db.AudioTrack{{TrackIndex: 3, Language: "pt-BR", Name: "Português"}},
+			},
+			presetContainerType: types.ContainerTypeCmfc,
+			presetSettings: &types.PresetSettings{
+				VideoDescription: &types.VideoDescription{Width: 1920, Height: 1080},
+				AudioDescriptions: []types.AudioDescription{
+					{CodecSettings: &types.AudioCodecSettings{Codec: types.AudioCodecAac}},
+				},
+			},
+			destination: "s3://some/destination",
+			wantJobReq: mediaconvert.CreateJobInput{
+				Role:  aws.String(""),
+				Queue: aws.String(""),
+				Settings: &types.JobSettings{
+					Inputs: []types.Input{
+						{
+							AudioSelectors: map[string]types.AudioSelector{
+								"Audio Selector 1": {
+									DefaultSelection: types.AudioDefaultSelectionDefault,
+								},
+								"Audio Selector 2": {
+									DefaultSelection: types.AudioDefaultSelectionNotDefault,
+									SelectorType:     types.AudioSelectorTypeTrack,
+									Tracks:           []int32{3},
+								},
+							},
+							FileInput: aws.String("s3://some/path.mp4"),
+							VideoSelector: &types.VideoSelector{
+								ColorSpace: types.ColorSpaceFollow,
+							},
+						},
+					},
+					OutputGroups: []types.OutputGroup{
+						{
+							OutputGroupSettings: &types.OutputGroupSettings{
+								Type: types.OutputGroupTypeCmafGroupSettings,
+								CmafGroupSettings: &types.CmafGroupSettings{
+									Destination:            aws.String("s3://some/destination/jobID/cmaf/index"),
+									SegmentLength:          6,
+									FragmentLength:         6,
+									ManifestDurationFormat: types.CmafManifestDurationFormatFloatingPoint,
+									SegmentControl:         types.CmafSegmentControlSegmentedFiles,
+									WriteHlsManifest:       types.CmafWriteHLSManifestEnabled,
+									WriteDashManifest:      types.CmafWriteDASHManifestEnabled,
+								},
+							},
+							Outputs: []types.Output{
+								{
+									NameModifier:      aws.String("file1"),
+									ContainerSettings: &types.ContainerSettings{Container: types.ContainerTypeCmfc},
+									VideoDescription:  &types.VideoDescription{Width: 1920, Height: 1080},
+								},
+								{
+									NameModifier:      aws.String("audio_1"),
+									ContainerSettings: &types.ContainerSettings{Container: types.ContainerTypeCmfc},
+									AudioDescriptions: []types.AudioDescription{
+										{CodecSettings: &types.AudioCodecSettings{Codec: types.AudioCodecAac}},
+									},
+								},
+								{
+									NameModifier: aws.String("audio_pt-BR_1"),
+									ContainerSettings: &types.ContainerSettings{
+										Container: types.ContainerTypeCmfc,
+										CmfcSettings: &types.CmfcSettings{
+											AudioGroupId:   aws.String("program_audio"),
+											AudioTrackType: types.CmfcAudioTrackTypeAlternateAudioAutoSelect,
+										},
+									},
+									AudioDescriptions: []types.AudioDescription{
+										{
+											AudioSourceName:     aws.String("Audio Selector 2"),
+											CodecSettings:       &types.AudioCodecSettings{Codec: types.AudioCodecAac},
+											CustomLanguageCode:  aws.String("pt-BR"),
+											LanguageCodeControl: types.AudioLanguageCodeControlUseConfigured,
+											StreamName:          aws.String("Português"),
+										},
+									},
+								},
+							},
+						},
+					},
+				},
+			},
+		},
+	}
+
+	for _, tt := range tests {
+		tt := tt
+		t.Run(tt.name, func(t *testing.T) {
+			client := &testMediaConvertClient{
+				t:                      t,
+				getPresetContainerType: tt.presetContainerType,
+				getPresetSettings:      tt.presetSettings,
+			}
+			p := &mcProvider{client: client, cfg: &config.MediaConvert{
+				Destination: tt.destination,
+			}}
+			_, err := p.Transcode(tt.job)
+			if (err != nil) != tt.wantErr {
+				t.Errorf("mcProvider.Transcode() error = %v, wantErr %v", err, tt.wantErr)
+				return
+			}
 
 			if g, e := client.createJobCalledWith, tt.wantJobReq; !reflect.DeepEqual(g, e) {
 				t.Fatalf("Transcode(): wrong job request\nWant %+v\nGot %+v\nDiff %s", e,
@@ -436,6 +1289,254 @@ func Test_mcProvider_Transcode(t *testing.T) {
 	}
 }
 
+func Test_mcProvider_Transcode_providerOverrides(t *testing.T) {
+	job := defaultJob
+	job.ProviderOverrides = db.ProviderOverrides{
+		"mediaconvert": json.RawMessage(`{"priority":10,"settings":{"inputs":[{"fileInput":"s3://some/path.mp4","timecodeSource":"ZEROBASED"}],"timecodeConfig":{"source":"ZEROBASED"}}}`),
+	}
+	client := &testMediaConvertClient{t: t, getPresetContainerType: types.ContainerTypeMp4}
+	p := &mcProvider{client: client, cfg: &config.MediaConvert{Destination: "s3://some/destination"}}
+	_, err := p.Transcode(&job)
+	if err != nil {
+		t.Fatal(err)
+	}
+	jobReq := client.createJobCalledWith
+	if g, e := jobReq.Priority, int32(10); g != e {
+		t.Errorf("wrong priority: got %d, expected %d", g, e)
+	}
+	if g, e := jobReq.Settings.TimecodeConfig.Source, types.TimecodeSourceZerobased; g != e {
+		t.Errorf("wrong timecode source: got %q, expected %q", g, e)
+	}
+	if g, e := jobReq.Settings.Inputs[0].TimecodeSource, types.InputTimecodeSourceZerobased; g != e {
+		t.Errorf("wrong input timecode source: got %q, expected %q", g, e)
+	}
+	if g, e := len(jobReq.Settings.OutputGroups), 1; g != e {
+		t.Errorf("overrides changed the output groups: got %d, expected %d", g, e)
+	}
+
+	job.ProviderOverrides = db.ProviderOverrides{"mediaconvert": json.RawMessage(`{"priority":"high"}`)}
+	_, err = p.Transcode(&job)
+	if err == nil {
+		t.Fatal("unexpected <nil> error for overrides with the wrong type")
+	}
+}
+
+func Test_automatedABRFrom(t *testing.T) {
+	h264Preset := func(bitrate int32) types.Preset {
+		return types.Preset{Settings: &types.PresetSettings{
+			VideoDescription: &types.VideoDescription{
+				Width:  1280,
+				Height: 720,
+				CodecSettings: &types.VideoCodecSettings{
+					Codec: types.VideoCodecH264,
+					H264Settings: &types.H264Settings{
+						RateControlMode: types.H264RateControlModeVbr,
+						Bitrate:         bitrate,
+						GopSize:         60,
+					},
+				},
+			},
+			AudioDescriptions: []types.AudioDescription{
+				{CodecSettings: &types.AudioCodecSettings{Codec: types.AudioCodecAac}},
+			},
+		}}
+	}
+	presets := map[string]types.Preset{
+		"preset1": h264Preset(1000000),
+		"preset2": h264Preset(4500000),
+	}
+	group := types.OutputGroup{
+		Outputs: []types.Output{
+			{Preset: aws.String("preset1"), NameModifier: aws.String("file1"), Extension: aws.String("m3u8")},
+			{Preset: aws.String("preset2"), NameModifier: aws.String("file2"), Extension: aws.String("m3u8")},
+		},
+	}
+	err := automatedABRFrom(&group, presets)
+	if err != nil {
+		t.Fatal(err)
+	}
+	expected := types.OutputGroup{
+		AutomatedEncodingSettings: &types.AutomatedEncodingSettings{
+			AbrSettings: &types.AutomatedAbrSettings{
+				MinAbrBitrate: 1000000,
+				MaxAbrBitrate: 4500000,
+				MaxRenditions: 3,
+			},
+		},
+		Outputs: []types.Output{
+			{
+				NameModifier:      aws.String("file2"),
+				Extension:         aws.String("m3u8"),
+				ContainerSettings: &types.ContainerSettings{Container: types.ContainerTypeM3u8},
+				VideoDescription: &types.VideoDescription{
+					CodecSettings: &types.VideoCodecSettings{
+						Codec: types.VideoCodecH264,
+						H264Settings: &types.H264Settings{
+							RateControlMode: types.H264RateControlModeQvbr,
+							GopSize:         60,
+						},
+					},
+				},
+				OutputSettings: &types.OutputSettings{
+					HlsSettings: &types.HlsSettings{AudioRenditionSets: aws.String("program_audio")},
+				},
+			},
+			{
+				NameModifier:      aws.String("audio"),
+				ContainerSettings: &types.ContainerSettings{Container: types.ContainerTypeM3u8},
+				AudioDescriptions: []types.AudioDescription{
+					{CodecSettings: &types.AudioCodecSettings{Codec: types.AudioCodecAac}},
+				},
+				OutputSettings: &types.OutputSettings{
+					HlsSettings: &types.HlsSettings{
+						AudioGroupId:   aws.String("program_audio"),
+						AudioTrackType: types.HlsAudioTrackTypeAlternateAudioAutoSelectDefault,
+					},
+				},
+			},
+		},
+	}
+	if !reflect.DeepEqual(group, expected) {
+		t.Errorf("wrong output group\n%s", cmp.Diff(expected, group))
+	}
+	if g := presets["preset2"].Settings.VideoDescription.CodecSettings.H264Settings.Bitrate; g != 4500000 {
+		t.Errorf("the preset settings shouldn't be changed, got bitrate %d", g)
+	}
+
+	vp9Group := types.OutputGroup{
+		Outputs: []types.Output{{
+			NameModifier: aws.String("file1"),
+			VideoDescription: &types.VideoDescription{
+				CodecSettings: &types.VideoCodecSettings{Codec: types.VideoCodecVp9},
+			},
+		}},
+	}
+	err = automatedABRFrom(&vp9Group, nil)
+	if err != errContentAwareCodec {
+		t.Errorf("wrong error returned\nwant %v\ngot  %v", errContentAwareCodec, err)
+	}
+}
+
+func Test_applyHLSOptions(t *testing.T) {
+	presets := map[string]types.Preset{
+		"video": {Settings: &types.PresetSettings{VideoDescription: &types.VideoDescription{Width: 1280, Height: 720}}},
+		"audio": {Settings: &types.PresetSettings{AudioDescriptions: []types.AudioDescription{{}}}},
+	}
+	hlsGroup := func() types.OutputGroup {
+		return types.OutputGroup{
+			OutputGroupSettings: &types.OutputGroupSettings{
+				Type:             types.OutputGroupTypeHlsGroupSettings,
+				HlsGroupSettings: &types.HlsGroupSettings{SegmentControl: types.HlsSegmentControlSegmentedFiles},
+			},
+			Outputs: []types.Output{
+				{Preset: aws.String("video"), NameModifier: aws.String("video")},
+				{
+					Preset:         aws.String("audio"),
+					NameModifier:   aws.String("audio"),
+					OutputSettings: &types.OutputSettings{HlsSettings: &types.HlsSettings{AudioTrackType: types.HlsAudioTrackTypeAudioOnlyVariantStream}},
+				},
+			},
+		}
+	}
+	cmafGroup := func() types.OutputGroup {
+		return types.OutputGroup{
+			OutputGroupSettings: &types.OutputGroupSettings{
+				Type:              types.OutputGroupTypeCmafGroupSettings,
+				CmafGroupSettings: &types.CmafGroupSettings{SegmentControl: types.CmafSegmentControlSegmentedFiles},
+			},
+			Outputs: []types.Output{
+				{
+					NameModifier:      aws.String("video"),
+					ContainerSettings: &types.ContainerSettings{Container: types.ContainerTypeCmfc},
+					VideoDescription:  presets["video"].Settings.VideoDescription,
+				},
+				{
+					NameModifier:      aws.String("audio_1"),
+					ContainerSettings: &types.ContainerSettings{Container: types.ContainerTypeCmfc},
+					AudioDescriptions: []types.AudioDescription{{}},
+				},
+			},
+		}
+	}
+
+	tests := []struct {
+		name      string
+		group     func() types.OutputGroup
+		options   db.HLSOptions
+		wantGroup func(*types.OutputGroup)
+		wantErr   string
+	}{
+		{
+			name:    "hls group",
+			group:   hlsGroup,
+			options: db.HLSOptions{IFramePlaylist: true, SingleFile: true, ProgramDateTime: true, PlaylistType: "vod"},
+			wantGroup: func(group *types.OutputGroup) {
+				group.OutputGroupSettings.HlsGroupSettings.SegmentControl = types.HlsSegmentControlSingleFile
+				group.OutputGroupSettings.HlsGroupSettings.ProgramDateTime = types.HlsProgramDateTimeInclude
+				group.Outputs[0].OutputSettings = &types.OutputSettings{
+					HlsSettings: &types.HlsSettings{IFrameOnlyManifest: types.HlsIFrameOnlyManifestInclude},
+				}
+			},
+		},
+		{
+			name:    "cmaf group",
+			group:   cmafGroup,
+			options: db.HLSOptions{IFramePlaylist: true, SingleFile: true, SegmentContainer: "fmp4"},
+			wantGroup: func(group *types.OutputGroup) {
+				group.OutputGroupSettings.CmafGroupSettings.SegmentControl = types.CmafSegmentControlSingleFile
+				group.Outputs[0].ContainerSettings.CmfcSettings = &types.CmfcSettings{
+					IFrameOnlyManifest: types.CmfcIFrameOnlyManifestInclude,
+				}
+			},
+		},
+		{
+			name:    "event playlists",
+			group:   hlsGroup,
+			options: db.HLSOptions{PlaylistType: "EVENT"},
+			wantErr: "hls option playlistType=EVENT is not supported by the provider",
+		},
+		{
+			name:    "target version",
+			group:   hlsGroup,
+			options: db.HLSOptions{TargetVersion: 4},
+			wantErr: "hls option targetVersion is not supported by the provider",
+		},
+		{
+			name:    "fmp4 segments in hls groups",
+			group:   hlsGroup,
+			options: db.HLSOptions{SegmentContainer: "fmp4"},
+			wantErr: "hls option segmentContainer=fmp4 is not supported by the provider",
+		},
+		{
+			name:    "program date time in cmaf groups",
+			group:   cmafGroup,
+			options: db.HLSOptions{ProgramDateTime: true},
+			wantErr: "hls option programDateTime is not supported by the provider",
+		},
+	}
+	for _, tt := range tests {
+		tt := tt
+		t.Run(tt.name, func(t *testing.T) {
+			group := tt.group()
+			err := applyHLSOptions(&group, tt.options, presets)
+			if tt.wantErr != "" {
+				if err == nil || err.Error() != tt.wantErr {
+					t.Errorf("wrong error returned\nwant %q\ngot  %v", tt.wantErr, err)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatal(err)
+			}
+			expected := tt.group()
+			tt.wantGroup(&expected)
+			if !reflect.DeepEqual(group, expected) {
+				t.Errorf("wrong output group\n%s", cmp.Diff(expected, group))
+			}
+		})
+	}
+}
+
 func Test_mcProvider_CancelJob(t *testing.T) {
 	jobID := "some_job_id"
 	client := &testMediaConvertClient{t: t}
@@ -468,6 +1569,7 @@ func Test_mcProvider_JobStatus(t *testing.T) {
 	tests := []struct {
 		name        string
 		destination string
+		job         *db.Job
 		mcJob       types.Job
 		wantStatus  provider.JobStatus
 		wantErr     bool
@@ -537,6 +1639,88 @@ func Test_mcProvider_JobStatus(t *testing.T) {
 				},
 			},
 		},
+		{
+			name:        "a finished cmaf job reports the hls and dash manifests",
+			destination: "s3://some/destination",
+			job: &db.Job{
+				ID: "jobID",
+				StreamingParams: db.StreamingParams{
+					Protocol:         "cmaf",
+					PlaylistFileName: "cmaf/index.m3u8",
+				},
+			},
+			mcJob: types.Job{
+				Status: types.JobStatusComplete,
+				Settings: &types.JobSettings{
+					OutputGroups: []types.OutputGroup{{
+						OutputGroupSettings: &types.OutputGroupSettings{
+							Type: types.OutputGroupTypeCmafGroupSettings,
+							CmafGroupSettings: &types.CmafGroupSettings{
+								Destination:       aws.String("s3://some/destination/jobID/cmaf/index"),
+								WriteHlsManifest:  types.CmafWriteHLSManifestEnabled,
+								WriteDashManifest: types.CmafWriteDASHManifestEnabled,
+							},
+						},
+					}},
+				},
+				OutputGroupDetails: []types.OutputGroupDetail{{
+					OutputDetails: []types.OutputDetail{
+						{
+							VideoDetails: &types.VideoDetail{
+								HeightInPx: 1080,
+								WidthInPx:  1920,
+							},
+						},
+						{},
+					},
+				}},
+			},
+			wantStatus: provider.JobStatus{
+				Status:       provider.StatusFinished,
+				ProviderName: Name,
+				Progress:     100,
+				Output: provider.JobOutput{
+					Destination: "s3://some/destination/jobID/",
+					Files: []provider.OutputFile{
+						{Height: 1080, Width: 1920},
+						{Path: "s3://some/destination/jobID/cmaf/index.m3u8", Container: "m3u8"},
+						{Path: "s3://some/destination/jobID/cmaf/index.mpd", Container: "mpd"},
+					},
+				},
+			},
+		},
+		{
+			name:        "manifests are only reported for the cmaf groups of the job",
+			destination: "s3://some/destination",
+			job: &db.Job{
+				ID: "jobID",
+				StreamingParams: db.StreamingParams{
+					Protocol:         "cmaf",
+					PlaylistFileName: "cmaf/index.m3u8",
+				},
+			},
+			mcJob: types.Job{
+				Status: types.JobStatusComplete,
+				Settings: &types.JobSettings{
+					OutputGroups: []types.OutputGroup{{
+						OutputGroupSettings: &types.OutputGroupSettings{
+							Type: types.OutputGroupTypeHlsGroupSettings,
+							HlsGroupSettings: &types.HlsGroupSettings{
+								Destination: aws.String("s3://some/destination/jobID/"),
+							},
+						},
+					}},
+				},
+			},
+			wantStatus: provider.JobStatus{
+				Status:       provider.StatusFinished,
+				ProviderName: Name,
+				Progress:     100,
+				Output: provider.JobOutput{
+					Destination: "s3://some/destination/jobID/",
+				},
+			},
+		},
 	}
 
 	for _, tt := range tests {
@@ -551,7 +1735,12 @@ func Test_mcProvider_JobStatus(t *testing.T) {
 				Destination: tt.destination,
 			}}
 
-			status, err := p.JobStatus(&defaultJob)
+			job := tt.job
+			if job == nil {
+				job = &defaultJob
+			}
+
+			status, err := p.JobStatus(job)
 			if (err != nil) != tt.wantErr {
 				t.Errorf("mcProvider.JobStatus() error = %v, wantErr %v", err, tt.wantErr)
 				return
@@ -564,3 +1753,52 @@ func Test_mcProvider_JobStatus(t *testing.T) {
 		})
 	}
 }
+
+func Test_mcProvider_ValidatePreset(t *testing.T) {
+	vp8Preset := defaultPreset
+	vp8Preset.Video.Codec = "vp8"
+	aviPreset := defaultPreset
+	aviPreset.Container = "avi"
+	tests := []struct {
+		name       string
+		preset     db.Preset
+		wantErrMsg string
+	}{
+		{name: "valid preset", preset: defaultPreset},
+		{name: "unsupported video codec", preset: vp8Preset, wantErrMsg: `generating video preset: video codec "vp8" is not yet supported with mediaconvert`},
+		{name: "unsupported container", preset: aviPreset, wantErrMsg: `mapping preset container to MediaConvert container: container "avi" not supported with mediaconvert`},
+	}
+	for _, tt := range tests {
+		tt := tt
+		t.Run(tt.name, func(t *testing.T) {
+			client := &testMediaConvertClient{t: t}
+			p := &mcProvider{client: client}
+			err := p.ValidatePreset(tt.preset)
+			if tt.wantErrMsg == "" && err != nil {
+				t.Errorf("mcProvider.ValidatePreset() unexpected error = %v", err)
+			}
+			if tt.wantErrMsg != "" && (err == nil || err.Error() != tt.wantErrMsg) {
+				t.Errorf("mcProvider.ValidatePreset() error = %v, want %q", err, tt.wantErrMsg)
+			}
+			if client.createPresetCalledWith != nil {
+				t.Error("mcProvider.ValidatePreset() shouldn't create the preset")
+			}
+		})
+	}
+}
+
+func Test_mcProvider_Capabilities(t *testing.T) {
+	var p mcProvider
+	want := provider.Capabilities{
+		InputFormats:  []string{"h264"},
+		OutputFormats: []string{"mp4", "hls", "cmaf", "webm"},
+		Destinations:  []string{"s3"},
+		VideoCodecs:   []string{"h264", "hevc", "vp9", "av1"},
+		AudioCodecs:   []string{"aac", "opus"},
+		HDR:           true,
+		ContentAware:  true,
+	}
+	if got := p.Capabilities(); !reflect.DeepEqual(got, want) {
+		t.Errorf("wrong capabilities\nwant %#v\ngot  %#v", want, got)
+	}
+}
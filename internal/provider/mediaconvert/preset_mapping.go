@@ -35,11 +35,103 @@ func containerFrom(container string) (types.ContainerType, error) {
 		return types.ContainerTypeM3u8, nil
 	case "mp4":
 		return types.ContainerTypeMp4, nil
+	case "cmaf":
+		return types.ContainerTypeCmfc, nil
+	case "webm":
+		return types.ContainerTypeWebm, nil
 	default:
 		return "", fmt.Errorf("container %q not supported with mediaconvert", container)
 	}
 }
 
+// canonicalContainerFrom is the inverse of containerFrom.
+func canonicalContainerFrom(container types.ContainerType) string {
+	switch container {
+	case types.ContainerTypeM3u8:
+		return "m3u8"
+	case types.ContainerTypeMp4:
+		return "mp4"
+	case types.ContainerTypeCmfc:
+		return "cmaf"
+	case types.ContainerTypeWebm:
+		return "webm"
+	default:
+		return strings.ToLower(string(container))
+	}
+}
+
+// canonicalPresetFrom maps the MediaConvert preset back into the canonical
+// preset fields that can be compared with the preset it was created from:
+// the container, the codecs, the dimensions, the bitrates and the GOP size.
+func canonicalPresetFrom(mcPreset types.Preset) db.Preset {
+	var preset db.Preset
+	if mcPreset.Name != nil {
+		preset.Name = *mcPreset.Name
+	}
+	if mcPreset.Description != nil {
+		preset.Description = *mcPreset.Description
+	}
+	settings := mcPreset.Settings
+	if settings == nil {
+		return preset
+	}
+	if settings.ContainerSettings != nil {
+		preset.Container = canonicalContainerFrom(settings.ContainerSettings.Container)
+	}
+	if video := settings.VideoDescription; video != nil {
+		if video.Width > 0 {
+			preset.Video.Width = strconv.Itoa(int(video.Width))
+		}
+		if video.Height > 0 {
+			preset.Video.Height = strconv.Itoa(int(video.Height))
+		}
+		var bitrate int32
+		var gopSize float64
+		if codec := video.CodecSettings; codec != nil {
+			switch {
+			case codec.H264Settings != nil:
+				preset.Video.Codec = db.VideoCodecH264
+				bitrate, gopSize = codec.H264Settings.Bitrate, codec.H264Settings.GopSize
+			case codec.H265Settings != nil:
+				preset.Video.Codec = db.VideoCodecHEVC
+				bitrate, gopSize = codec.H265Settings.Bitrate, codec.H265Settings.GopSize
+				// HEVC HLS presets are created with the CMAF container,
+				// so the container can't tell them apart
+				if preset.Container == "cmaf" {
+					preset.Container = ""
+				}
+			case codec.Vp9Settings != nil:
+				preset.Video.Codec = db.VideoCodecVP9
+				bitrate, gopSize = codec.Vp9Settings.Bitrate, codec.Vp9Settings.GopSize
+			case codec.Av1Settings != nil:
+				preset.Video.Codec = db.VideoCodecAV1
+				bitrate, gopSize = codec.Av1Settings.MaxBitrate, codec.Av1Settings.GopSize
+			}
+		}
+		if bitrate > 0 {
+			preset.Video.Bitrate = strconv.Itoa(int(bitrate))
+		}
+		if gopSize > 0 {
+			preset.Video.GopSize = strconv.FormatFloat(gopSize, 'f', -1, 64)
+		}
+	}
+	if len(settings.AudioDescriptions) > 0 && settings.AudioDescriptions[0].CodecSettings != nil {
+		var bitrate int32
+		switch codec := settings.AudioDescriptions[0].CodecSettings; {
+		case codec.AacSettings != nil:
+			preset.Audio.Codec = db.AudioCodecAAC
+			bitrate = codec.AacSettings.Bitrate
+		case codec.OpusSettings != nil:
+			preset.Audio.Codec = db.AudioCodecOpus
+			bitrate = codec.OpusSettings.Bitrate
+		}
+		if bitrate > 0 {
+			preset.Audio.Bitrate = strconv.Itoa(int(bitrate))
+		}
+	}
+	return preset
+}
+
 func h264RateControlModeFrom(rateControl string) (types.H264RateControlMode, error) {
 	rateControl = strings.ToLower(rateControl)
 	switch rateControl {
@@ -119,41 +211,150 @@ func h264InterlaceModeFrom(mode string) (types.H264InterlaceMode, error) {
 	}
 }
 
+func h265RateControlModeFrom(rateControl string) (types.H265RateControlMode, error) {
+	rateControl = strings.ToLower(rateControl)
+	switch rateControl {
+	case "vbr":
+		return types.H265RateControlModeVbr, nil
+	case "", "cbr":
+		return types.H265RateControlModeCbr, nil
+	case "qvbr":
+		return types.H265RateControlModeQvbr, nil
+	default:
+		return "", fmt.Errorf("rate control mode %q is not supported with mediaconvert", rateControl)
+	}
+}
+
+// h265CodecProfileFrom maps the HEVC profile and tier to MediaConvert's
+// combined profile/tier values.
+func h265CodecProfileFrom(profile, tier string) (types.H265CodecProfile, error) {
+	profile = strings.ToLower(profile)
+	tier = strings.ToLower(tier)
+	highTier := tier == db.HEVCTierHigh
+	if tier != "" && tier != db.HEVCTierMain && !highTier {
+		return "", fmt.Errorf("h265 tier %q is not supported with mediaconvert", tier)
+	}
+	switch profile {
+	case "", db.HEVCProfileMain:
+		if highTier {
+			return types.H265CodecProfileMainHigh, nil
+		}
+		return types.H265CodecProfileMainMain, nil
+	case db.HEVCProfileMain10:
+		if highTier {
+			return types.H265CodecProfileMain10High, nil
+		}
+		return types.H265CodecProfileMain10Main, nil
+	default:
+		return "", fmt.Errorf("h265 profile %q is not supported with mediaconvert", profile)
+	}
+}
+
+func h265CodecLevelFrom(level string) (types.H265CodecLevel, error) {
+	switch strings.TrimSuffix(level, ".0") {
+	case "":
+		return types.H265CodecLevelAuto, nil
+	case "1":
+		return types.H265CodecLevelLevel1, nil
+	case "2":
+		return types.H265CodecLevelLevel2, nil
+	case "2.1":
+		return types.H265CodecLevelLevel21, nil
+	case "3":
+		return types.H265CodecLevelLevel3, nil
+	case "3.1":
+		return types.H265CodecLevelLevel31, nil
+	case "4":
+		return types.H265CodecLevelLevel4, nil
+	case "4.1":
+		return types.H265CodecLevelLevel41, nil
+	case "5":
+		return types.H265CodecLevelLevel5, nil
+	case "5.1":
+		return types.H265CodecLevelLevel51, nil
+	case "5.2":
+		return types.H265CodecLevelLevel52, nil
+	case "6":
+		return types.H265CodecLevelLevel6, nil
+	case "6.1":
+		return types.H265CodecLevelLevel61, nil
+	case "6.2":
+		return types.H265CodecLevelLevel62, nil
+	default:
+		return "", fmt.Errorf("h265 level %q is not supported with mediaconvert", level)
+	}
+}
+
+func h265InterlaceModeFrom(mode string) (types.H265InterlaceMode, error) {
+	mode = strings.ToLower(mode)
+	switch mode {
+	case "", "progressive":
+		return types.H265InterlaceModeProgressive, nil
+	default:
+		return "", fmt.Errorf("h265 interlace mode %q is not supported with mediaconvert", mode)
+	}
+}
+
+func vp9RateControlModeFrom(rateControl string) (types.Vp9RateControlMode, error) {
+	rateControl = strings.ToLower(rateControl)
+	switch rateControl {
+	case "", "vbr":
+		return types.Vp9RateControlModeVbr, nil
+	default:
+		return "", fmt.Errorf("rate control mode %q is not supported with vp9 on mediaconvert", rateControl)
+	}
+}
+
+func av1RateControlModeFrom(rateControl string) (types.Av1RateControlMode, error) {
+	rateControl = strings.ToLower(rateControl)
+	switch rateControl {
+	case "", "qvbr":
+		return types.Av1RateControlModeQvbr, nil
+	default:
+		return "", fmt.Errorf("rate control mode %q is not supported with av1 on mediaconvert", rateControl)
+	}
+}
+
 func videoPresetFrom(preset db.Preset) (*types.VideoDescription, error) {
+	scalingBehavior, err := scalingBehaviorFrom(preset.Video.ScalingBehavior)
+	if err != nil {
+		return nil, err
+	}
+
 	videoPreset := types.VideoDescription{
-		ScalingBehavior:   types.ScalingBehaviorDefault,
+		ScalingBehavior:   scalingBehavior,
 		TimecodeInsertion: types.VideoTimecodeInsertionDisabled,
 		AntiAlias:         types.AntiAliasEnabled,
 		RespondToAfd:      types.RespondToAfdNone,
 	}
 
 	if preset.Video.Width != "" {
-		width, err := strconv.ParseInt(preset.Video.Width, 10, 32)
+		width, err := preset.Video.WidthPixels()
 		if err != nil {
-			return nil, errors.Wrapf(err, "parsing video width %q to int32", preset.Video.Width)
+			return nil, errors.Wrap(err, "parsing video width")
 		}
 		videoPreset.Width = int32(width)
 	}
 
 	if preset.Video.Height != "" {
-		height, err := strconv.ParseInt(preset.Video.Height, 10, 32)
+		height, err := preset.Video.HeightPixels()
 		if err != nil {
-			return nil, errors.Wrapf(err, "parsing video height %q to int32", preset.Video.Height)
+			return nil, errors.Wrap(err, "parsing video height")
 		}
 		videoPreset.Height = int32(height)
 	}
 
 	codec := strings.ToLower(preset.Video.Codec)
 	switch codec {
-	case "h264":
-		bitrate, err := strconv.ParseInt(preset.Video.Bitrate, 10, 32)
+	case db.VideoCodecH264:
+		bitrate, err := preset.Video.BitrateBps()
 		if err != nil {
-			return nil, errors.Wrapf(err, "parsing video bitrate %q to int32", preset.Video.Bitrate)
+			return nil, errors.Wrap(err, "parsing video bitrate")
 		}
 
-		gopSize, err := strconv.ParseFloat(preset.Video.GopSize, 64)
+		gopSize, err := preset.Video.GopFrames()
 		if err != nil {
-			return nil, errors.Wrapf(err, "parsing gop size %q to float64", preset.Video.GopSize)
+			return nil, errors.Wrap(err, "parsing gop size")
 		}
 
 		rateControl, err := h264RateControlModeFrom(preset.RateControl)
@@ -165,6 +366,12 @@ func videoPresetFrom(preset db.Preset) (*types.VideoDescription, error) {
 		if err != nil {
 			return nil, err
 		}
+		if preset.Video.Is10Bit() {
+			if profile != types.H264CodecProfileHigh {
+				return nil, errors.New("10-bit output requires the high h264 profile with mediaconvert")
+			}
+			profile = types.H264CodecProfileHigh10bit
+		}
 
 		level, err := h264CodecLevelFrom(preset.Video.ProfileLevel)
 		if err != nil {
@@ -177,15 +384,15 @@ func videoPresetFrom(preset db.Preset) (*types.VideoDescription, error) {
 		}
 
 		tuning := types.H264QualityTuningLevelSinglePassHq
-		if preset.TwoPass {
+		if preset.TwoPassEnabled() {
 			tuning = types.H264QualityTuningLevelMultiPassHq
 		}
 
-		var bframes int64
+		var bframes int
 		if preset.Video.BFrames != "" {
-			bframes, err = strconv.ParseInt(preset.Video.BFrames, 10, 32)
+			bframes, err = preset.Video.BFrameCount()
 			if err != nil {
-				return nil, errors.Wrapf(err, "parsing bframes %q to int32", preset.Video.BFrames)
+				return nil, errors.Wrap(err, "parsing bframes")
 			}
 		}
 
@@ -193,7 +400,7 @@ func videoPresetFrom(preset db.Preset) (*types.VideoDescription, error) {
 			Codec: types.VideoCodecH264,
 			H264Settings: &types.H264Settings{
 				Bitrate:                             int32(bitrate),
-				GopSize:                             gopSize,
+				GopSize:                             float64(gopSize),
 				RateControlMode:                     rateControl,
 				CodecProfile:                        profile,
 				CodecLevel:                          level,
@@ -202,37 +409,406 @@ func videoPresetFrom(preset db.Preset) (*types.VideoDescription, error) {
 				NumberBFramesBetweenReferenceFrames: int32(bframes),
 			},
 		}
+	case db.VideoCodecHEVC:
+		bitrate, err := preset.Video.BitrateBps()
+		if err != nil {
+			return nil, errors.Wrap(err, "parsing video bitrate")
+		}
+
+		gopSize, err := preset.Video.GopFrames()
+		if err != nil {
+			return nil, errors.Wrap(err, "parsing gop size")
+		}
+
+		rateControl, err := h265RateControlModeFrom(preset.RateControl)
+		if err != nil {
+			return nil, err
+		}
+
+		hevcProfile := preset.Video.Profile
+		if hevcProfile == "" && preset.Video.Is10Bit() {
+			hevcProfile = db.HEVCProfileMain10
+		}
+		profile, err := h265CodecProfileFrom(hevcProfile, preset.Video.Tier)
+		if err != nil {
+			return nil, err
+		}
+
+		level, err := h265CodecLevelFrom(preset.Video.ProfileLevel)
+		if err != nil {
+			return nil, err
+		}
+
+		interlaceMode, err := h265InterlaceModeFrom(preset.Video.InterlaceMode)
+		if err != nil {
+			return nil, err
+		}
+
+		tuning := types.H265QualityTuningLevelSinglePassHq
+		if preset.TwoPassEnabled() {
+			tuning = types.H265QualityTuningLevelMultiPassHq
+		}
+
+		var bframes int
+		if preset.Video.BFrames != "" {
+			bframes, err = preset.Video.BFrameCount()
+			if err != nil {
+				return nil, errors.Wrap(err, "parsing bframes")
+			}
+		}
+
+		videoPreset.CodecSettings = &types.VideoCodecSettings{
+			Codec: types.VideoCodecH265,
+			H265Settings: &types.H265Settings{
+				Bitrate:                             int32(bitrate),
+				GopSize:                             float64(gopSize),
+				RateControlMode:                     rateControl,
+				CodecProfile:                        profile,
+				CodecLevel:                          level,
+				InterlaceMode:                       interlaceMode,
+				QualityTuningLevel:                  tuning,
+				NumberBFramesBetweenReferenceFrames: int32(bframes),
+				// Apple devices only play HEVC tagged as hvc1
+				WriteMp4PackagingType: types.H265WriteMp4PackagingTypeHvc1,
+			},
+		}
+	case db.VideoCodecVP9:
+		bitrate, err := preset.Video.BitrateBps()
+		if err != nil {
+			return nil, errors.Wrap(err, "parsing video bitrate")
+		}
+
+		gopSize, err := preset.Video.GopFrames()
+		if err != nil {
+			return nil, errors.Wrap(err, "parsing gop size")
+		}
+
+		rateControl, err := vp9RateControlModeFrom(preset.RateControl)
+		if err != nil {
+			return nil, err
+		}
+
+		if preset.Video.Is10Bit() {
+			return nil, errors.New("10-bit vp9 output is not supported with mediaconvert")
+		}
+
+		tuning := types.Vp9QualityTuningLevelMultiPass
+		if preset.TwoPassEnabled() {
+			tuning = types.Vp9QualityTuningLevelMultiPassHq
+		}
+
+		videoPreset.CodecSettings = &types.VideoCodecSettings{
+			Codec: types.VideoCodecVp9,
+			Vp9Settings: &types.Vp9Settings{
+				Bitrate:            int32(bitrate),
+				GopSize:            float64(gopSize),
+				RateControlMode:    rateControl,
+				QualityTuningLevel: tuning,
+			},
+		}
+	case db.VideoCodecAV1:
+		bitrate, err := preset.Video.BitrateBps()
+		if err != nil {
+			return nil, errors.Wrap(err, "parsing video bitrate")
+		}
+
+		gopSize, err := preset.Video.GopFrames()
+		if err != nil {
+			return nil, errors.Wrap(err, "parsing gop size")
+		}
+
+		rateControl, err := av1RateControlModeFrom(preset.RateControl)
+		if err != nil {
+			return nil, err
+		}
+
+		var bframes int
+		if preset.Video.BFrames != "" {
+			bframes, err = preset.Video.BFrameCount()
+			if err != nil {
+				return nil, errors.Wrap(err, "parsing bframes")
+			}
+		}
+
+		var bitDepth types.Av1BitDepth
+		if preset.Video.Is10Bit() {
+			bitDepth = types.Av1BitDepthBit10
+		}
+
+		// MediaConvert only encodes AV1 with QVBR, so the preset bitrate
+		// caps the quality-defined rate.
+		videoPreset.CodecSettings = &types.VideoCodecSettings{
+			Codec: types.VideoCodecAv1,
+			Av1Settings: &types.Av1Settings{
+				MaxBitrate:                          int32(bitrate),
+				GopSize:                             float64(gopSize),
+				RateControlMode:                     rateControl,
+				NumberBFramesBetweenReferenceFrames: int32(bframes),
+				BitDepth:                            bitDepth,
+			},
+		}
 	default:
 		return nil, fmt.Errorf("video codec %q is not yet supported with mediaconvert", codec)
 	}
 
+	if preset.Video.FrameRate != "" {
+		num, den, err := preset.Video.FrameRateFraction()
+		if err != nil {
+			return nil, err
+		}
+		setFramerate(videoPreset.CodecSettings, int32(num), int32(den))
+	}
+
+	if !preset.Video.Crop.IsZero() {
+		crop, err := cropRectangleFrom(preset.Video.Crop)
+		if err != nil {
+			return nil, err
+		}
+		videoPreset.Crop = crop
+	}
+
+	var preprocessor types.VideoPreprocessor
+	conversion, err := colorSpaceConversionFrom(preset.Video)
+	if err != nil {
+		return nil, err
+	}
+	if conversion != "" {
+		preprocessor.ColorCorrector = &types.ColorCorrector{ColorSpaceConversion: conversion}
+	}
+	preprocessor.Deinterlacer, err = deinterlacerFrom(preset.Video.Deinterlacer)
+	if err != nil {
+		return nil, err
+	}
+	if preprocessor.ColorCorrector != nil || preprocessor.Deinterlacer != nil {
+		videoPreset.VideoPreprocessors = &preprocessor
+	}
+	if strings.ToLower(preset.Video.HDR) == db.HDRPassthrough {
+		videoPreset.ColorMetadata = types.ColorMetadataInsert
+	}
+
 	return &videoPreset, nil
 }
 
+// scalingBehaviorFrom maps the scaling behavior of the preset. MediaConvert's
+// default behavior letterboxes the source into the output dimensions.
+func scalingBehaviorFrom(behavior string) (types.ScalingBehavior, error) {
+	behavior = strings.ToLower(behavior)
+	switch behavior {
+	case "", db.ScalingBehaviorLetterbox:
+		return types.ScalingBehaviorDefault, nil
+	case db.ScalingBehaviorStretch:
+		return types.ScalingBehaviorStretchToOutput, nil
+	default:
+		return "", fmt.Errorf("scaling behavior %q is not supported with mediaconvert", behavior)
+	}
+}
+
+// setFramerate sets a fixed output frame rate in the given codec settings.
+func setFramerate(settings *types.VideoCodecSettings, num, den int32) {
+	switch settings.Codec {
+	case types.VideoCodecH264:
+		settings.H264Settings.FramerateControl = types.H264FramerateControlSpecified
+		settings.H264Settings.FramerateNumerator = num
+		settings.H264Settings.FramerateDenominator = den
+	case types.VideoCodecH265:
+		settings.H265Settings.FramerateControl = types.H265FramerateControlSpecified
+		settings.H265Settings.FramerateNumerator = num
+		settings.H265Settings.FramerateDenominator = den
+	case types.VideoCodecVp9:
+		settings.Vp9Settings.FramerateControl = types.Vp9FramerateControlSpecified
+		settings.Vp9Settings.FramerateNumerator = num
+		settings.Vp9Settings.FramerateDenominator = den
+	case types.VideoCodecAv1:
+		settings.Av1Settings.FramerateControl = types.Av1FramerateControlSpecified
+		settings.Av1Settings.FramerateNumerator = num
+		settings.Av1Settings.FramerateDenominator = den
+	}
+}
+
+func cropRectangleFrom(crop db.CropSettings) (*types.Rectangle, error) {
+	values := make([]int32, 4)
+	for i, value := range []string{crop.X, crop.Y, crop.Width, crop.Height} {
+		if value == "" {
+			continue
+		}
+		n, err := strconv.ParseInt(value, 10, 32)
+		if err != nil {
+			return nil, errors.Wrapf(err, "parsing crop value %q to int32", value)
+		}
+		values[i] = int32(n)
+	}
+	return &types.Rectangle{X: values[0], Y: values[1], Width: values[2], Height: values[3]}, nil
+}
+
+func deinterlacerFrom(deinterlacer string) (*types.Deinterlacer, error) {
+	deinterlacer = strings.ToLower(deinterlacer)
+	var mode types.DeinterlacerMode
+	switch deinterlacer {
+	case "", db.DeinterlacerOff:
+		return nil, nil
+	case db.DeinterlacerDeinterlace:
+		mode = types.DeinterlacerModeDeinterlace
+	case db.DeinterlacerInverseTelecine:
+		mode = types.DeinterlacerModeInverseTelecine
+	case db.DeinterlacerAdaptive:
+		mode = types.DeinterlacerModeAdaptive
+	default:
+		return nil, fmt.Errorf("deinterlacer %q is not supported with mediaconvert", deinterlacer)
+	}
+	return &types.Deinterlacer{
+		Algorithm: types.DeinterlaceAlgorithmInterpolate,
+		Control:   types.DeinterlacerControlNormal,
+		Mode:      mode,
+	}, nil
+}
+
+// colorSpaceConversionFrom maps the color space and HDR settings to the
+// conversion applied by MediaConvert's color corrector. An empty conversion
+// keeps the color space of the source, passing its HDR metadata through.
+func colorSpaceConversionFrom(video db.VideoPreset) (types.ColorSpaceConversion, error) {
+	colorSpace := strings.ToLower(video.ColorSpace)
+	switch strings.ToLower(video.HDR) {
+	case db.HDR10:
+		return types.ColorSpaceConversionForceHdr10, nil
+	case db.HDRHLG:
+		return types.ColorSpaceConversionForceHlg2020, nil
+	case db.HDRToneMapSDR:
+		if colorSpace == db.ColorSpaceRec601 {
+			return types.ColorSpaceConversionForce601, nil
+		}
+		return types.ColorSpaceConversionForce709, nil
+	case db.HDRPassthrough:
+		return "", nil
+	}
+	switch colorSpace {
+	case "":
+		return "", nil
+	case db.ColorSpaceRec601:
+		return types.ColorSpaceConversionForce601, nil
+	case db.ColorSpaceRec709:
+		return types.ColorSpaceConversionForce709, nil
+	default:
+		return "", fmt.Errorf("color space %q is only supported along with hdr10 or hlg on mediaconvert", video.ColorSpace)
+	}
+}
+
 func audioPresetFrom(preset db.Preset) (*types.AudioDescription, error) {
 	audioPreset := types.AudioDescription{}
 
 	codec := strings.ToLower(preset.Audio.Codec)
 	switch codec {
-	case "aac":
-		bitrate, err := strconv.ParseInt(preset.Audio.Bitrate, 10, 32)
+	case db.AudioCodecAAC:
+		bitrate, err := preset.Audio.BitrateBps()
 		if err != nil {
-			return nil, errors.Wrapf(err, "parsing audio bitrate %q to int32", preset.Audio.Bitrate)
+			return nil, errors.Wrap(err, "parsing audio bitrate")
+		}
+
+		sampleRate := int64(defaultAudioSampleRate)
+		if preset.Audio.SampleRate != "" {
+			sampleRate, err = strconv.ParseInt(preset.Audio.SampleRate, 10, 32)
+			if err != nil {
+				return nil, errors.Wrapf(err, "parsing audio sample rate %q to int32", preset.Audio.SampleRate)
+			}
+		}
+
+		profile, err := aacCodecProfileFrom(preset.Audio.Profile)
+		if err != nil {
+			return nil, err
+		}
+
+		codingMode, err := aacCodingModeFrom(preset.Audio.ChannelLayout)
+		if err != nil {
+			return nil, err
 		}
 
 		audioPreset.CodecSettings = &types.AudioCodecSettings{
 			Codec: types.AudioCodecAac,
 			AacSettings: &types.AacSettings{
-				SampleRate:      defaultAudioSampleRate,
+				SampleRate:      int32(sampleRate),
 				Bitrate:         int32(bitrate),
-				CodecProfile:    types.AacCodecProfileLc,
-				CodingMode:      types.AacCodingModeCodingMode20,
+				CodecProfile:    profile,
+				CodingMode:      codingMode,
 				RateControlMode: types.AacRateControlModeCbr,
 			},
 		}
+	case db.AudioCodecOpus:
+		bitrate, err := preset.Audio.BitrateBps()
+		if err != nil {
+			return nil, errors.Wrap(err, "parsing audio bitrate")
+		}
+
+		sampleRate := int64(defaultAudioSampleRate)
+		if preset.Audio.SampleRate != "" {
+			sampleRate, err = strconv.ParseInt(preset.Audio.SampleRate, 10, 32)
+			if err != nil {
+				return nil, errors.Wrapf(err, "parsing audio sample rate %q to int32", preset.Audio.SampleRate)
+			}
+		}
+
+		channels, err := opusChannelsFrom(preset.Audio.ChannelLayout)
+		if err != nil {
+			return nil, err
+		}
+
+		audioPreset.CodecSettings = &types.AudioCodecSettings{
+			Codec: types.AudioCodecOpus,
+			OpusSettings: &types.OpusSettings{
+				Bitrate:    int32(bitrate),
+				Channels:   channels,
+				SampleRate: int32(sampleRate),
+			},
+		}
 	default:
 		return nil, fmt.Errorf("audio codec %q is not yet supported with mediaconvert", codec)
 	}
 
+	if preset.Audio.LoudnessStandard != "" {
+		audioPreset.AudioNormalizationSettings = &types.AudioNormalizationSettings{
+			Algorithm:        types.AudioNormalizationAlgorithmItuBs17703,
+			AlgorithmControl: types.AudioNormalizationAlgorithmControlCorrectAudio,
+			TargetLkfs:       preset.Audio.LoudnessTargetLKFS(),
+		}
+	}
+
 	return &audioPreset, nil
 }
+
+func aacCodecProfileFrom(profile string) (types.AacCodecProfile, error) {
+	profile = strings.ToLower(profile)
+	switch profile {
+	case "", db.AudioProfileLC:
+		return types.AacCodecProfileLc, nil
+	case db.AudioProfileHE:
+		return types.AacCodecProfileHev1, nil
+	case db.AudioProfileHEv2:
+		return types.AacCodecProfileHev2, nil
+	default:
+		return "", fmt.Errorf("aac profile %q is not supported with mediaconvert", profile)
+	}
+}
+
+func aacCodingModeFrom(channelLayout string) (types.AacCodingMode, error) {
+	channelLayout = strings.ToLower(channelLayout)
+	switch channelLayout {
+	case db.AudioChannelLayoutMono:
+		return types.AacCodingModeCodingMode10, nil
+	case "", db.AudioChannelLayoutStereo:
+		return types.AacCodingModeCodingMode20, nil
+	case db.AudioChannelLayoutSurround:
+		return types.AacCodingModeCodingMode51, nil
+	default:
+		return "", fmt.Errorf("channel layout %q is not supported with mediaconvert", channelLayout)
+	}
+}
+
+func opusChannelsFrom(channelLayout string) (int32, error) {
+	channelLayout = strings.ToLower(channelLayout)
+	switch channelLayout {
+	case db.AudioChannelLayoutMono:
+		return 1, nil
+	case "", db.AudioChannelLayoutStereo:
+		return 2, nil
+	default:
+		return 0, fmt.Errorf("channel layout %q is not supported with opus on mediaconvert", channelLayout)
+	}
+}
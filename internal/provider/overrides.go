@@ -0,0 +1,97 @@
+package provider
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"reflect"
+	"strings"
+)
+
+// MergeJSON deep-merges the given overrides into the JSON document base,
+// following the JSON Merge Patch semantics (RFC 7386): objects are merged
+// recursively, null values remove keys and any other value replaces the
+// original one. Keys of the overrides that aren't present in base match
+// existing keys case-insensitively, so overrides can use the field names
+// of the provider's documentation.
+func MergeJSON(base []byte, overrides json.RawMessage) ([]byte, error) {
+	if len(overrides) == 0 {
+		return base, nil
+	}
+	var target, patch interface{}
+	if err := decodeJSON(base, &target); err != nil {
+		return nil, err
+	}
+	if err := decodeJSON(overrides, &patch); err != nil {
+		return nil, fmt.Errorf("invalid provider overrides: %s", err)
+	}
+	return json.Marshal(mergePatch(target, patch))
+}
+
+// decodeJSON decodes numbers as json.Number, so integers don't lose precision
+// when going through the merge.
+func decodeJSON(data []byte, v interface{}) error {
+	decoder := json.NewDecoder(bytes.NewReader(data))
+	decoder.UseNumber()
+	return decoder.Decode(v)
+}
+
+func mergePatch(target, patch interface{}) interface{} {
+	patchObject, ok := patch.(map[string]interface{})
+	if !ok {
+		return patch
+	}
+	targetObject, ok := target.(map[string]interface{})
+	if !ok {
+		targetObject = make(map[string]interface{})
+	}
+	for key, value := range patchObject {
+		key = matchKey(targetObject, key)
+		if value == nil {
+			delete(targetObject, key)
+			continue
+		}
+		targetObject[key] = mergePatch(targetObject[key], value)
+	}
+	return targetObject
+}
+
+func matchKey(object map[string]interface{}, key string) string {
+	if _, ok := object[key]; ok {
+		return key
+	}
+	for existing := range object {
+		if strings.EqualFold(existing, key) {
+			return existing
+		}
+	}
+	return key
+}
+
+// ApplyOverrides merges the given overrides into payload, which must be a
+// pointer to the native type the provider sends to its API. When strict is
+// true, overrides that don't match any field of the native type are
+// rejected.
+func ApplyOverrides(payload interface{}, overrides json.RawMessage, strict bool) error {
+	if len(overrides) == 0 {
+		return nil
+	}
+	base, err := json.Marshal(payload)
+	if err != nil {
+		return err
+	}
+	merged, err := MergeJSON(base, overrides)
+	if err != nil {
+		return err
+	}
+	value := reflect.ValueOf(payload).Elem()
+	value.Set(reflect.Zero(value.Type()))
+	decoder := json.NewDecoder(bytes.NewReader(merged))
+	if strict {
+		decoder.DisallowUnknownFields()
+	}
+	if err := decoder.Decode(payload); err != nil {
+		return fmt.Errorf("invalid provider overrides: %s", err)
+	}
+	return nil
+}
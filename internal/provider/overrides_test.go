@@ -0,0 +1,114 @@
+package provider
+
+import (
+	"encoding/json"
+	"reflect"
+	"testing"
+)
+
+func TestMergeJSON(t *testing.T) {
+	tests := []struct {
+		name      string
+		base      string
+		overrides string
+		expected  string
+	}{
+		{
+			"no overrides",
+			`{"a":1}`,
+			``,
+			`{"a":1}`,
+		},
+		{
+			"nested objects are merged",
+			`{"settings":{"bitrate":1000,"codec":"h264"},"queue":"default"}`,
+			`{"settings":{"bitrate":2000,"tuning":"film"}}`,
+			`{"queue":"default","settings":{"bitrate":2000,"codec":"h264","tuning":"film"}}`,
+		},
+		{
+			"null removes keys",
+			`{"settings":{"bitrate":1000,"codec":"h264"}}`,
+			`{"settings":{"bitrate":null}}`,
+			`{"settings":{"codec":"h264"}}`,
+		},
+		{
+			"arrays are replaced",
+			`{"outputs":[{"name":"a"},{"name":"b"}]}`,
+			`{"outputs":[{"name":"c"}]}`,
+			`{"outputs":[{"name":"c"}]}`,
+		},
+		{
+			"keys match case-insensitively",
+			`{"Settings":{"Bitrate":1000}}`,
+			`{"settings":{"bitrate":2000}}`,
+			`{"Settings":{"Bitrate":2000}}`,
+		},
+		{
+			"exact matches take precedence",
+			`{"rate":1,"Rate":2}`,
+			`{"Rate":3}`,
+			`{"Rate":3,"rate":1}`,
+		},
+		{
+			"large integers are kept",
+			`{"id":9007199254740993}`,
+			`{"priority":1}`,
+			`{"id":9007199254740993,"priority":1}`,
+		},
+	}
+	for _, test := range tests {
+		test := test
+		t.Run(test.name, func(t *testing.T) {
+			merged, err := MergeJSON([]byte(test.base), json.RawMessage(test.overrides))
+			if err != nil {
+				t.Fatal(err)
+			}
+			if string(merged) != test.expected {
+				t.Errorf("wrong merged JSON\nwant %s\ngot  %s", test.expected, merged)
+			}
+		})
+	}
+}
+
+func TestMergeJSONInvalidOverrides(t *testing.T) {
+	_, err := MergeJSON([]byte(`{}`), json.RawMessage(`{"a":`))
+	if expectedMsg := "invalid provider overrides: unexpected EOF"; err == nil || err.Error() != expectedMsg {
+		t.Errorf("wrong error returned\nwant %q\ngot  %v", expectedMsg, err)
+	}
+}
+
+func TestApplyOverrides(t *testing.T) {
+	type settings struct {
+		Bitrate int    `json:"bitrate,omitempty"`
+		Codec   string `json:"codec,omitempty"`
+	}
+	type payload struct {
+		Name     string   `json:"name"`
+		Public   bool     `json:"public,omitempty"`
+		Settings settings `json:"settings"`
+	}
+	value := payload{Name: "output", Public: true, Settings: settings{Bitrate: 1000, Codec: "h264"}}
+	err := ApplyOverrides(&value, json.RawMessage(`{"public":null,"settings":{"bitrate":2000}}`), true)
+	if err != nil {
+		t.Fatal(err)
+	}
+	expected := payload{Name: "output", Settings: settings{Bitrate: 2000, Codec: "h264"}}
+	if !reflect.DeepEqual(value, expected) {
+		t.Errorf("wrong payload\nwant %#v\ngot  %#v", expected, value)
+	}
+}
+
+func TestApplyOverridesStrict(t *testing.T) {
+	type payload struct {
+		Name string `json:"name"`
+	}
+	value := payload{Name: "output"}
+	err := ApplyOverrides(&value, json.RawMessage(`{"label":"my output"}`), false)
+	if err != nil {
+		t.Fatal(err)
+	}
+	err = ApplyOverrides(&value, json.RawMessage(`{"label":"my output"}`), true)
+	if expectedMsg := `invalid provider overrides: json: unknown field "label"`; err == nil || err.Error() != expectedMsg {
+		t.Errorf("wrong error returned\nwant %q\ngot  %v", expectedMsg, err)
+	}
+}
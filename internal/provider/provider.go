@@ -4,7 +4,6 @@ import (
 	"errors"
 	"fmt"
 	"sort"
-	"time"
 
 	"github.com/video-dev/video-transcoding-api/v2/config"
 	"github.com/video-dev/video-transcoding-api/v2/db"
@@ -22,6 +21,14 @@ var (
 	// ErrPresetMapNotFound is the error returned when the given preset is not
 	// found in the provider.
 	ErrPresetMapNotFound = errors.New("preset not found in provider")
+
+	// ErrAudioTracksNotSupported is the error returned when the job has
+	// alternate audio tracks but the provider can't transcode them.
+	ErrAudioTracksNotSupported = errors.New("alternate audio tracks are not supported by the provider")
+
+	// ErrProviderOverridesNotSupported is the error returned when the preset
+	// or the job has overrides for a provider that doesn't support them.
+	ErrProviderOverridesNotSupported = errors.New("provider overrides are not supported by the provider")
 )
 
 // TranscodingProvider represents a provider of transcoding.
@@ -46,6 +53,42 @@ type TranscodingProvider interface {
 	Capabilities() Capabilities
 }
 
+// PresetNormalizer is implemented by providers that are able to map their
+// native presets, as returned by GetPreset, back into the canonical preset
+// fields. Fields that can't be read from the native preset are left empty.
+type PresetNormalizer interface {
+	NormalizePreset(native interface{}) (db.Preset, error)
+}
+
+// PresetLister is implemented by providers that are able to list the presets
+// created in them. Presets are listed in the same representation returned by
+// GetPreset.
+type PresetLister interface {
+	ListPresets() ([]StoredPreset, error)
+}
+
+// PresetValidator is implemented by providers that are able to tell whether
+// a preset can be created in them without creating it. ValidatePreset runs
+// the same checks as CreatePreset, but doesn't make any network calls.
+type PresetValidator interface {
+	ValidatePreset(db.Preset) error
+}
+
+// PresetUpdater is implemented by providers that key presets by name, which
+// can't hold a preset and its replacement at the same time. UpdatePreset
+// replaces the preset with the given ID in place, returning the ID of the
+// updated preset.
+type PresetUpdater interface {
+	UpdatePreset(presetID string, preset db.Preset) (string, error)
+}
+
+// StoredPreset is a preset stored in a provider, along with the ID that
+// identifies it in presetmaps.
+type StoredPreset struct {
+	ID     string
+	Preset interface{}
+}
+
 // Factory is the function responsible for creating the instance of a
 // provider.
 type Factory func(cfg *config.Config) (TranscodingProvider, error)
@@ -58,14 +101,42 @@ type JobNotFoundError struct {
 	ID string
 }
 
+// PresetNotFoundError is returned if a preset with a given id could not be
+// found by the provider
+type PresetNotFoundError struct {
+	ID string
+}
+
+// InvalidAudioTracksError is returned if the provider supports alternate
+// audio tracks, but can't transcode the ones requested by the job
+type InvalidAudioTracksError string
+
+// HLSOptionNotSupportedError is returned if the job has an HLS option (see
+// db.HLSOptions) that the provider can't honor
+type HLSOptionNotSupportedError struct {
+	Option string
+}
+
 func (err InvalidConfigError) Error() string {
 	return string(err)
 }
 
+func (err InvalidAudioTracksError) Error() string {
+	return string(err)
+}
+
 func (err JobNotFoundError) Error() string {
 	return fmt.Sprintf("could not found job with id: %s", err.ID)
 }
 
+func (err PresetNotFoundError) Error() string {
+	return fmt.Sprintf("could not find preset with id: %s", err.ID)
+}
+
+func (err HLSOptionNotSupportedError) Error() string {
+	return fmt.Sprintf("hls option %s is not supported by the provider", err.Option)
+}
+
 // JobStatus is the representation of the status as the provide sees it. The
 // provider is able to add customized information in the ProviderStatus field.
 //
@@ -87,7 +158,9 @@ type JobOutput struct {
 	Files       []OutputFile `json:"files,omitempty"`
 }
 
-// OutputFile represents an output file in a given job.
+// OutputFile represents an output file in a given job. Bitrate is the video
+// bitrate of the rendition, in bits per second, reported by providers that
+// expose the bitrate they picked for the renditions of content-aware jobs.
 type OutputFile struct {
 	Path       string `json:"path"`
 	Container  string `json:"container"`
@@ -95,21 +168,12 @@ type OutputFile struct {
 	Height     int64  `json:"height"`
 	Width      int64  `json:"width"`
 	FileSize   int64  `json:"fileSize"`
+	Bitrate    int64  `json:"bitrate,omitempty"`
 }
 
 // SourceInfo contains information about media transcoded using the Transcoding
 // API.
-type SourceInfo struct {
-	// Duration of the media
-	Duration time.Duration `json:"duration,omitempty"`
-
-	// Dimension of the media, in pixels
-	Height int64 `json:"height,omitempty"`
-	Width  int64 `json:"width,omitempty"`
-
-	// Codec used for video medias
-	VideoCodec string `json:"videoCodec,omitempty"`
-}
+type SourceInfo = db.SourceInfo
 
 // Status is the status of a transcoding job.
 type Status string
@@ -159,6 +223,17 @@ func GetProviderFactory(name string) (Factory, error) {
 	return factory, nil
 }
 
+// Names returns the names of all registered providers, alphabetically
+// ordered, regardless of whether they're configured.
+func Names() []string {
+	names := make([]string, 0, len(providers))
+	for name := range providers {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
+
 // ListProviders returns the list of currently registered providers,
 // alphabetically ordered.
 func ListProviders(c *config.Config) []string {
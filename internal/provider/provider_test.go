@@ -169,3 +169,32 @@ func TestDescribeProviderNotFound(t *testing.T) {
 		t.Errorf("Unexpected non-nil description: %#v", description)
 	}
 }
+
+func TestCapabilitiesSupportsCodec(t *testing.T) {
+	restricted := Capabilities{VideoCodecs: []string{"h264", "vp9"}, AudioCodecs: []string{"aac"}}
+	tests := []struct {
+		name         string
+		capabilities Capabilities
+		videoCodec   string
+		audioCodec   string
+		wantVideo    bool
+		wantAudio    bool
+	}{
+		{"listed codecs", restricted, "h264", "aac", true, true},
+		{"codecs are case insensitive", restricted, "VP9", "AAC", true, true},
+		{"codecs not listed", restricted, "av1", "opus", false, false},
+		{"empty codecs", restricted, "", "", true, true},
+		{"provider without codec list", Capabilities{}, "av1", "opus", true, true},
+	}
+	for _, test := range tests {
+		test := test
+		t.Run(test.name, func(t *testing.T) {
+			if got := test.capabilities.SupportsVideoCodec(test.videoCodec); got != test.wantVideo {
+				t.Errorf("SupportsVideoCodec(%q): want %v, got %v", test.videoCodec, test.wantVideo, got)
+			}
+			if got := test.capabilities.SupportsAudioCodec(test.audioCodec); got != test.wantAudio {
+				t.Errorf("SupportsAudioCodec(%q): want %v, got %v", test.audioCodec, test.wantAudio, got)
+			}
+		})
+	}
+}
@@ -16,6 +16,8 @@
 package zencoder
 
 import (
+	"encoding/json"
+	"errors"
 	"fmt"
 	"net/url"
 	"path"
@@ -59,6 +61,12 @@ type zencoderProvider struct {
 }
 
 func (z *zencoderProvider) Transcode(job *db.Job) (*provider.JobStatus, error) {
+	if len(job.AudioTracks) > 0 {
+		return nil, provider.ErrAudioTracksNotSupported
+	}
+	if err := checkHLSOptions(job.StreamingParams.HLSOptions()); err != nil {
+		return nil, err
+	}
 	outputs, err := z.buildOutputs(job)
 	if err != nil {
 		return nil, err
@@ -84,11 +92,10 @@ func (z *zencoderProvider) buildOutputs(job *db.Job) ([]*zencoder.OutputSettings
 	zencoderOutputs := make([]*zencoder.OutputSettings, 0, len(job.Outputs))
 	hlsOutputs := 0
 	for _, output := range job.Outputs {
-		localPresetOutput, err := z.GetPreset(output.Preset.Name)
+		localPresetStruct, err := z.localPreset(job, output.Preset.Name)
 		if err != nil {
 			return nil, fmt.Errorf("error getting localpreset: %s", err)
 		}
-		localPresetStruct := localPresetOutput.(*db.LocalPreset)
 		zencoderOutput, err := z.buildOutput(job, localPresetStruct.Preset, output.FileName)
 		if err != nil {
 			return nil, fmt.Errorf("error building output: %s", err)
@@ -99,7 +106,7 @@ func (z *zencoderProvider) buildOutputs(job *db.Job) ([]*zencoder.OutputSettings
 		zencoderOutputs = append(zencoderOutputs, &zencoderOutput)
 	}
 	if hlsOutputs > 0 {
-		optimizedOutputs, err := z.optimizeOutputsForHLS(zencoderOutputs)
+		optimizedOutputs, err := z.optimizeOutputsForHLS(job, zencoderOutputs)
 		if err != nil {
 			return nil, err
 		}
@@ -115,12 +122,12 @@ func (z *zencoderProvider) buildOutputs(job *db.Job) ([]*zencoder.OutputSettings
 	return zencoderOutputs, nil
 }
 
-func (z *zencoderProvider) optimizeOutputsForHLS(outputs []*zencoder.OutputSettings) ([]*zencoder.OutputSettings, error) {
+func (z *zencoderProvider) optimizeOutputsForHLS(job *db.Job, outputs []*zencoder.OutputSettings) ([]*zencoder.OutputSettings, error) {
 	for i, hlsOutput := range outputs {
 		if hlsOutput.Format == "ts" {
 			for _, mp4Output := range outputs {
 				if mp4Output.Format == "mp4" {
-					isCompatible, err := z.isOutputCompatible(hlsOutput, mp4Output)
+					isCompatible, err := z.isOutputCompatible(job, hlsOutput, mp4Output)
 					if err != nil {
 						return nil, err
 					} else if isCompatible {
@@ -132,7 +139,10 @@ func (z *zencoderProvider) optimizeOutputsForHLS(outputs []*zencoder.OutputSetti
 							Source:    mp4Output.Label,
 							CopyAudio: true,
 							CopyVideo: true,
+							SkipVideo: hlsOutput.SkipVideo,
 							Type:      "segmented",
+
+							MaxHLSProtocolVersion: hlsOutput.MaxHLSProtocolVersion,
 						}
 						outputs[i] = &newHlsOutput
 						mp4Output.PrepareForSegmenting = "hls"
@@ -144,18 +154,16 @@ func (z *zencoderProvider) optimizeOutputsForHLS(outputs []*zencoder.OutputSetti
 	return outputs, nil
 }
 
-func (z *zencoderProvider) isOutputCompatible(hlsOutput, mp4Output *zencoder.OutputSettings) (bool, error) {
-	localHlsPreset, err := z.GetPreset(hlsOutput.Label)
+func (z *zencoderProvider) isOutputCompatible(job *db.Job, hlsOutput, mp4Output *zencoder.OutputSettings) (bool, error) {
+	hls, err := z.localPreset(job, hlsOutput.Label)
 	if err != nil {
 		return false, err
 	}
-	hls := localHlsPreset.(*db.LocalPreset)
 
-	localMp4Preset, err := z.GetPreset(mp4Output.Label)
+	mp4, err := z.localPreset(job, mp4Output.Label)
 	if err != nil {
 		return false, err
 	}
-	mp4 := localMp4Preset.(*db.LocalPreset)
 
 	isCompatible := reflect.DeepEqual(hls.Preset.Video, mp4.Preset.Video) &&
 		reflect.DeepEqual(hls.Preset.Audio, mp4.Preset.Audio) &&
@@ -182,6 +190,11 @@ func (z *zencoderProvider) buildHLSPlaylist(outputs []*zencoder.OutputSettings,
 				Path:   output.Label + "/video.m3u8",
 				Source: output.Label,
 			}
+			if output.SkipVideo {
+				// advertise audio-only variants as such in the
+				// master playlist
+				stream.Codecs = "mp4a.40.2"
+			}
 			streams = append(streams, &stream)
 		}
 	}
@@ -189,16 +202,15 @@ func (z *zencoderProvider) buildHLSPlaylist(outputs []*zencoder.OutputSettings,
 	return output, nil
 }
 
+// kbps converts a bitrate in bits per second to the nearest kilobit.
+func kbps(bitrate int) int {
+	return (bitrate + 500) / 1000
+}
+
 func (z *zencoderProvider) getResolution(preset db.Preset) (int32, int32) {
-	var width, height int64
-	width, err := strconv.ParseInt(preset.Video.Width, 10, 32)
-	if err != nil || preset.Video.Width == "0" || preset.Video.Width == "" {
-		width = 0
-	}
-	height, err = strconv.ParseInt(preset.Video.Height, 10, 32)
-	if err != nil || preset.Video.Height == "0" || preset.Video.Height == "" {
-		height = 0
-	}
+	// unset or invalid dimensions are left for Zencoder to pick
+	width, _ := preset.Video.WidthPixels()
+	height, _ := preset.Video.HeightPixels()
 	return int32(width), int32(height)
 }
 
@@ -209,26 +221,35 @@ func (z *zencoderProvider) buildOutput(job *db.Job, preset db.Preset, filename s
 		AudioCodec: preset.Audio.Codec,
 		Filename:   filename,
 		MakePublic: true,
-		OnePass:    !preset.TwoPass,
-	}
-	zencoderOutput.Width, zencoderOutput.Height = z.getResolution(preset)
-	videoBitrate, err := strconv.ParseInt(preset.Video.Bitrate, 10, 32)
-	if err != nil {
-		return zencoder.OutputSettings{}, fmt.Errorf("error converting preset video bitrate (%q): %s", preset.Video.Bitrate, err)
+		OnePass:    !preset.TwoPassEnabled(),
 	}
-	zencoderOutput.VideoBitrate = int32(videoBitrate) / 1000
+	audioOnly := preset.AudioOnly()
+	if audioOnly {
+		zencoderOutput.SkipVideo = true
+	} else {
+		zencoderOutput.Width, zencoderOutput.Height = z.getResolution(preset)
+		videoBitrate, err := preset.Video.BitrateBps()
+		if err != nil {
+			return zencoder.OutputSettings{}, fmt.Errorf("error converting preset video bitrate (%q): %s", preset.Video.Bitrate, err)
+		}
+		zencoderOutput.VideoBitrate = int32(kbps(videoBitrate))
 
-	keyframeInterval, err := strconv.ParseInt(preset.Video.GopSize, 10, 32)
-	if err != nil {
-		return zencoder.OutputSettings{}, fmt.Errorf("error converting preset keyframe interval (%q): %s", preset.Video.GopSize, err)
+		keyframeInterval, err := preset.Video.GopFrames()
+		if err != nil {
+			return zencoder.OutputSettings{}, fmt.Errorf("error converting preset keyframe interval (%q): %s", preset.Video.GopSize, err)
+		}
+		zencoderOutput.KeyframeInterval = int32(keyframeInterval)
 	}
-	zencoderOutput.KeyframeInterval = int32(keyframeInterval)
 
-	audioBitrate, err := strconv.ParseInt(preset.Audio.Bitrate, 10, 32)
+	audioBitrate, err := preset.Audio.BitrateBps()
 	if err != nil {
 		return zencoder.OutputSettings{}, fmt.Errorf("error converting preset audio bitrate (%q): %s", preset.Audio.Bitrate, err)
 	}
-	zencoderOutput.AudioBitrate = int32(audioBitrate) / 1000
+	zencoderOutput.AudioBitrate = int32(kbps(audioBitrate))
+	zencoderOutput.AudioChannels, zencoderOutput.AudioSampleRate, err = audioSettingsFrom(preset.Audio)
+	if err != nil {
+		return zencoder.OutputSettings{}, err
+	}
 
 	if preset.Video.GopMode == "fixed" {
 		zencoderOutput.FixedKeyframeInterval = true
@@ -238,7 +259,7 @@ func (z *zencoderProvider) buildOutput(job *db.Job, preset db.Preset, filename s
 		zencoderOutput.H264Level = strings.ToLower(preset.Video.ProfileLevel)
 
 		if preset.Video.BFrames != "" {
-			bframes, err := strconv.ParseInt(preset.Video.BFrames, 10, 32)
+			bframes, err := preset.Video.BFrameCount()
 			if err != nil {
 				return zencoder.OutputSettings{}, fmt.Errorf("error converting preset bframes (%q): %s ", preset.Video.BFrames, err)
 			}
@@ -257,6 +278,7 @@ func (z *zencoderProvider) buildOutput(job *db.Job, preset db.Preset, filename s
 		zencoderOutput.Type = "segmented"
 		zencoderOutput.Format = "ts"
 		zencoderOutput.SegmentSeconds = int32(job.StreamingParams.SegmentDuration)
+		zencoderOutput.MaxHLSProtocolVersion = int32(job.StreamingParams.HLSOptions().TargetVersion)
 		parts := strings.Split(filename, "/")
 		finalFilename := parts[0] + "/" + preset.Name + "/video.m3u8"
 		zencoderOutput.Filename = finalFilename
@@ -264,7 +286,18 @@ func (z *zencoderProvider) buildOutput(job *db.Job, preset db.Preset, filename s
 		zencoderOutput.Format = preset.Container
 	}
 	zencoderOutput.BaseUrl = destinationURL.String()
-	zencoderOutput.Deinterlace = "on"
+	if !audioOnly {
+		zencoderOutput.FrameRate, zencoderOutput.AspectMode, zencoderOutput.Deinterlace, err = videoProcessingFrom(preset.Video)
+		if err != nil {
+			return zencoder.OutputSettings{}, err
+		}
+	}
+	for _, overrides := range []json.RawMessage{preset.ProviderOverrides.For(Name), job.ProviderOverrides.For(Name)} {
+		err = provider.ApplyOverrides(&zencoderOutput, overrides, true)
+		if err != nil {
+			return zencoder.OutputSettings{}, err
+		}
+	}
 	return zencoderOutput, nil
 }
 
@@ -373,7 +406,25 @@ func (z *zencoderProvider) Healthcheck() error {
 	return err
 }
 
+// ValidatePreset checks whether the preset can be stored as a local preset
+// and later turned into Zencoder output settings.
+func (z *zencoderProvider) ValidatePreset(preset db.Preset) error {
+	if _, _, err := audioSettingsFrom(preset.Audio); err != nil {
+		return err
+	}
+	if err := checkVideoSettings(preset); err != nil {
+		return err
+	}
+	if _, _, _, err := videoProcessingFrom(preset.Video); err != nil {
+		return err
+	}
+	return provider.ApplyOverrides(&zencoder.OutputSettings{}, preset.ProviderOverrides.For(Name), true)
+}
+
 func (z *zencoderProvider) CreatePreset(preset db.Preset) (string, error) {
+	if err := z.ValidatePreset(preset); err != nil {
+		return "", err
+	}
 	err := z.db.CreateLocalPreset(&db.LocalPreset{
 		Name:   preset.Name,
 		Preset: preset,
@@ -384,8 +435,162 @@ func (z *zencoderProvider) CreatePreset(preset db.Preset) (string, error) {
 	return preset.Name, nil
 }
 
+// UpdatePreset replaces the local preset with the given ID. Local presets are
+// keyed by name, so the replacement keeps the same ID.
+func (z *zencoderProvider) UpdatePreset(presetID string, preset db.Preset) (string, error) {
+	if err := z.ValidatePreset(preset); err != nil {
+		return "", err
+	}
+	err := z.db.UpdateLocalPreset(&db.LocalPreset{
+		Name:   presetID,
+		Preset: preset,
+	})
+	if err != nil {
+		return "", err
+	}
+	return presetID, nil
+}
+
+// checkHLSOptions rejects the HLS options that can't be honored by Zencoder,
+// which only controls the protocol version of the playlists it generates.
+func checkHLSOptions(options db.HLSOptions) error {
+	switch {
+	case options.IFramePlaylist:
+		return provider.HLSOptionNotSupportedError{Option: "iFramePlaylist"}
+	case options.SingleFile:
+		return provider.HLSOptionNotSupportedError{Option: "singleFile"}
+	case options.ProgramDateTime:
+		return provider.HLSOptionNotSupportedError{Option: "programDateTime"}
+	case strings.EqualFold(options.PlaylistType, db.HLSPlaylistTypeEvent):
+		return provider.HLSOptionNotSupportedError{Option: "playlistType=" + db.HLSPlaylistTypeEvent}
+	case options.FMP4():
+		return provider.HLSOptionNotSupportedError{Option: "segmentContainer=" + db.HLSSegmentContainerFMP4}
+	}
+	return nil
+}
+
+// checkVideoSettings rejects the codec settings Zencoder can't handle. It has
+// no AV1 encoder, its HEVC encoder only outputs the main profile and tier, and
+// its HLS outputs are limited to MPEG-TS segments, which can't carry HEVC.
+func checkVideoSettings(preset db.Preset) error {
+	if strings.ToLower(preset.Video.Codec) == db.VideoCodecAV1 {
+		return fmt.Errorf("video codec %q is not supported with zencoder", preset.Video.Codec)
+	}
+	if !preset.Video.IsHEVC() {
+		return nil
+	}
+	if profile := strings.ToLower(preset.Video.Profile); profile != "" && profile != db.HEVCProfileMain {
+		return fmt.Errorf("hevc profile %q is not supported with zencoder", preset.Video.Profile)
+	}
+	if tier := strings.ToLower(preset.Video.Tier); tier != "" && tier != db.HEVCTierMain {
+		return fmt.Errorf("hevc tier %q is not supported with zencoder", preset.Video.Tier)
+	}
+	if preset.Container == "m3u8" {
+		return errors.New("hevc is not supported with HLS outputs on zencoder")
+	}
+	return nil
+}
+
+// videoProcessingFrom returns the frame rate, aspect mode and deinterlace mode
+// for the given video preset. Zencoder only supports integer frame rates and
+// can't crop the source. Presets without a deinterlacer keep deinterlacing on.
+func videoProcessingFrom(video db.VideoPreset) (frameRate int32, aspectMode string, deinterlace string, err error) {
+	if video.FrameRate != "" {
+		rate, ok := video.IntegerFrameRate()
+		if !ok {
+			return 0, "", "", fmt.Errorf("frame rate %q is not supported with zencoder", video.FrameRate)
+		}
+		frameRate = int32(rate)
+	}
+	switch strings.ToLower(video.ScalingBehavior) {
+	case "":
+	case db.ScalingBehaviorFit:
+		aspectMode = "preserve"
+	case db.ScalingBehaviorFill:
+		aspectMode = "crop"
+	case db.ScalingBehaviorStretch:
+		aspectMode = "stretch"
+	case db.ScalingBehaviorLetterbox:
+		aspectMode = "pad"
+	default:
+		return 0, "", "", fmt.Errorf("scaling behavior %q is not supported with zencoder", video.ScalingBehavior)
+	}
+	if !video.Crop.IsZero() {
+		return 0, "", "", errors.New("cropping is not supported with zencoder")
+	}
+	switch strings.ToLower(video.Deinterlacer) {
+	case "", db.DeinterlacerDeinterlace:
+		deinterlace = "on"
+	case db.DeinterlacerOff:
+		deinterlace = "off"
+	case db.DeinterlacerAdaptive:
+		deinterlace = "detect"
+	default:
+		return 0, "", "", fmt.Errorf("deinterlacer %q is not supported with zencoder", video.Deinterlacer)
+	}
+	return frameRate, aspectMode, deinterlace, nil
+}
+
+// audioSettingsFrom returns the number of audio channels and the sample rate
+// for the given audio preset, rejecting the settings Zencoder can't handle.
+func audioSettingsFrom(audio db.AudioPreset) (channels int32, sampleRate int32, err error) {
+	if strings.ToLower(audio.Codec) == db.AudioCodecOpus {
+		return 0, 0, fmt.Errorf("audio codec %q is not supported with zencoder", audio.Codec)
+	}
+	switch strings.ToLower(audio.ChannelLayout) {
+	case "":
+	case db.AudioChannelLayoutMono:
+		channels = 1
+	case db.AudioChannelLayoutStereo:
+		channels = 2
+	default:
+		return 0, 0, fmt.Errorf("channel layout %q is not supported with zencoder", audio.ChannelLayout)
+	}
+	switch strings.ToLower(audio.Profile) {
+	case "", db.AudioProfileLC:
+	default:
+		return 0, 0, fmt.Errorf("audio profile %q is not supported with zencoder", audio.Profile)
+	}
+	if audio.LoudnessStandard != "" {
+		return 0, 0, fmt.Errorf("loudness standard %q is not supported with zencoder", audio.LoudnessStandard)
+	}
+	if audio.SampleRate != "" {
+		rate, err := strconv.ParseInt(audio.SampleRate, 10, 32)
+		if err != nil {
+			return 0, 0, fmt.Errorf("error converting preset audio sample rate (%q): %s", audio.SampleRate, err)
+		}
+		sampleRate = int32(rate)
+	}
+	return channels, sampleRate, nil
+}
+
 func (z *zencoderProvider) GetPreset(presetID string) (interface{}, error) {
-	return z.db.GetLocalPreset(presetID)
+	preset, err := z.db.GetLocalPreset(presetID)
+	if err == db.ErrLocalPresetNotFound {
+		return nil, provider.PresetNotFoundError{ID: presetID}
+	}
+	if err != nil {
+		return nil, err
+	}
+	return preset, nil
+}
+
+// localPreset returns the local preset used by the job, preferring the
+// snapshot stored in the job, so the job doesn't depend on the local preset
+// after it's deleted.
+func (z *zencoderProvider) localPreset(job *db.Job, name string) (*db.LocalPreset, error) {
+	if preset, ok := job.PresetSnapshots[name]; ok {
+		return &db.LocalPreset{Name: name, Preset: preset}, nil
+	}
+	return z.db.GetLocalPreset(name)
+}
+
+func (z *zencoderProvider) NormalizePreset(native interface{}) (db.Preset, error) {
+	localPreset, ok := native.(*db.LocalPreset)
+	if !ok {
+		return db.Preset{}, fmt.Errorf("unexpected zencoder preset type %T", native)
+	}
+	return localPreset.Preset, nil
 }
 
 func (z *zencoderProvider) DeletePreset(presetID string) error {
@@ -401,6 +606,8 @@ func (z *zencoderProvider) Capabilities() provider.Capabilities {
 		InputFormats:  []string{"prores", "h264"},
 		OutputFormats: []string{"mp4", "hls", "webm"},
 		Destinations:  []string{"akamai", "s3"},
+		VideoCodecs:   []string{db.VideoCodecH264, db.VideoCodecHEVC, db.VideoCodecVP8, db.VideoCodecVP9},
+		AudioCodecs:   []string{db.AudioCodecAAC, db.AudioCodecVorbis},
 	}
 }
 
@@ -0,0 +1,574 @@
+// Package verify checks the outputs of finished jobs. Output files are
+// fetched over HTTP or from S3-compatible storage and compared against their
+// presets, and the HLS and DASH manifests are parsed to confirm that every
+// variant resolves.
+package verify
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/xml"
+	"errors"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"net/http"
+	"net/url"
+	"path"
+	"regexp"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	v4 "github.com/aws/aws-sdk-go-v2/aws/signer/v4"
+	"github.com/video-dev/video-transcoding-api/v2/config"
+	"github.com/video-dev/video-transcoding-api/v2/db"
+	"github.com/video-dev/video-transcoding-api/v2/internal/probe"
+	"github.com/video-dev/video-transcoding-api/v2/internal/provider"
+)
+
+const (
+	// dimensionTolerance is the difference, in pixels, accepted between
+	// the dimensions of an output and the dimensions of its preset.
+	// Encoders round dimensions to even numbers.
+	dimensionTolerance = 2
+
+	// bitrateTolerance is the relative difference accepted between the
+	// bitrate of an output and the bitrate of its preset.
+	bitrateTolerance = 0.25
+
+	// minDurationTolerance is the minimum difference accepted between the
+	// duration of an output and the duration of the source.
+	minDurationTolerance = time.Second
+
+	// durationTolerance is the relative difference accepted between the
+	// duration of an output and the duration of the source.
+	durationTolerance = 0.02
+
+	// maxManifestSize is the maximum size of the manifests that are
+	// downloaded.
+	maxManifestSize = 4 << 20
+
+	// emptyPayloadHash is the SHA-256 of an empty payload, used when
+	// signing requests to S3.
+	emptyPayloadHash = "e3b0c44298fc1c149afbf4c8996fb92427ae41e4649b934ca495991b7852b855"
+)
+
+var (
+	uriAttrRegexp        = regexp.MustCompile(`URI="([^"]*)"`)
+	templateIdentifierRe = regexp.MustCompile(`\$(RepresentationID|Number|Bandwidth|Time)(%0(\d+)d)?\$`)
+)
+
+// prober is the interface used for probing output files, implemented by
+// probe.Prober.
+type prober interface {
+	Probe(sourceURL string) (db.SourceInfo, error)
+}
+
+// Verifier verifies the outputs of finished jobs.
+type Verifier struct {
+	client     *http.Client
+	s3Endpoint *url.URL
+	prober     prober
+}
+
+// NewVerifier returns a verifier that fetches outputs using the given client.
+// Requests to the S3 endpoint of the configuration are signed when the
+// configuration has credentials.
+func NewVerifier(cfg *config.Verification, client *http.Client) (*Verifier, error) {
+	if cfg == nil {
+		cfg = &config.Verification{S3Endpoint: "https://s3.amazonaws.com", S3Region: "us-east-1"}
+	}
+	endpoint, err := url.Parse(cfg.S3Endpoint)
+	if err != nil || endpoint.Host == "" {
+		return nil, fmt.Errorf("invalid S3 endpoint %q", cfg.S3Endpoint)
+	}
+	if client == nil {
+		client = http.DefaultClient
+	}
+	if cfg.AccessKeyID != "" && cfg.SecretAccessKey != "" {
+		signingClient := *client
+		base := client.Transport
+		if base == nil {
+			base = http.DefaultTransport
+		}
+		signingClient.Transport = &s3SigningTransport{
+			base:   base,
+			host:   endpoint.Host,
+			region: cfg.S3Region,
+			credentials: aws.Credentials{
+				AccessKeyID:     cfg.AccessKeyID,
+				SecretAccessKey: cfg.SecretAccessKey,
+			},
+			signer: v4.NewSigner(),
+		}
+		client = &signingClient
+	}
+	return &Verifier{
+		client:     client,
+		s3Endpoint: endpoint,
+		prober:     &probe.Prober{Client: client},
+	}, nil
+}
+
+// Verify fetches the outputs of the given finished job and checks them
+// against their canonical presets, keyed by preset name, and checks that every variant listed in the
+// adaptive streaming manifests of the job resolves. Problems are recorded in
+// the report rather than returned as errors.
+func (v *Verifier) Verify(job *db.Job, presets map[string]*db.Preset, status *provider.JobStatus) db.VerificationReport {
+	report := db.VerificationReport{VerifiedAt: time.Now().UTC()}
+	destination := status.Output.Destination
+	for _, output := range job.Outputs {
+		switch strings.ToLower(output.Preset.OutputOpts.Extension) {
+		case "m3u8", "cmaf", "mpd":
+			// segmented outputs are verified through the manifests
+			continue
+		}
+		location := outputLocation(status.Output.Files, destination, output.FileName)
+		report.Outputs = append(report.Outputs, v.verifyOutput(job, output, presets[output.Preset.Name], location))
+	}
+	params := job.StreamingParams
+	switch params.Protocol {
+	case "hls":
+		report.Manifests = append(report.Manifests, v.verifyHLS(joinLocation(destination, params.PlaylistFileName)))
+	case "dash":
+		report.Manifests = append(report.Manifests, v.verifyDASH(joinLocation(destination, params.PlaylistFileName)))
+	case "cmaf":
+		report.Manifests = append(report.Manifests,
+			v.verifyHLS(joinLocation(destination, params.PlaylistFileName)),
+			v.verifyDASH(joinLocation(destination, params.DASHManifestFileName())),
+		)
+	}
+	report.Status = db.VerificationFailed
+	if report.Passed() {
+		report.Status = db.VerificationPassed
+	}
+	return report
+}
+
+// outputLocation returns the location of an output file, preferring the path
+// reported by the provider.
+func outputLocation(files []provider.OutputFile, destination, fileName string) string {
+	for _, file := range files {
+		if file.Path == fileName || strings.HasSuffix(file.Path, "/"+strings.TrimLeft(fileName, "/")) {
+			return file.Path
+		}
+	}
+	return joinLocation(destination, fileName)
+}
+
+func joinLocation(destination, name string) string {
+	if destination == "" {
+		return name
+	}
+	return strings.TrimRight(destination, "/") + "/" + strings.TrimLeft(name, "/")
+}
+
+func (v *Verifier) verifyOutput(job *db.Job, output db.TranscodeOutput, preset *db.Preset, location string) db.OutputVerification {
+	result := db.OutputVerification{Path: location, Preset: output.Preset.Name}
+	fileURL, err := v.resolve(location)
+	if err != nil {
+		result.Errors = append(result.Errors, err.Error())
+		return result
+	}
+	size, err := v.fileSize(fileURL)
+	if err != nil {
+		result.Errors = append(result.Errors, fmt.Sprintf("error fetching the output: %s", err))
+		return result
+	}
+	result.FileSize = size
+	container := strings.ToLower(output.Preset.OutputOpts.Extension)
+	if container != "mp4" && container != "mov" {
+		return result
+	}
+	info, err := v.prober.Probe(fileURL)
+	if err != nil {
+		result.Errors = append(result.Errors, fmt.Sprintf("error probing the output: %s", err))
+		return result
+	}
+	result.Container = info.Container
+	result.VideoCodec = info.VideoCodec
+	result.Width = info.Width
+	result.Height = info.Height
+	result.Duration = info.Duration
+	if info.Duration > 0 {
+		result.Bitrate = int64(float64(size*8) / info.Duration.Seconds())
+	}
+	if result.Container != container {
+		result.Errors = append(result.Errors, fmt.Sprintf("wrong container: want %s, got %s", container, result.Container))
+	}
+	if job.SourceInfo != nil && job.SourceInfo.Duration > 0 {
+		tolerance := time.Duration(float64(job.SourceInfo.Duration) * durationTolerance)
+		if tolerance < minDurationTolerance {
+			tolerance = minDurationTolerance
+		}
+		if diff := result.Duration - job.SourceInfo.Duration; diff > tolerance || -diff > tolerance {
+			result.Errors = append(result.Errors, fmt.Sprintf("wrong duration: want %s, got %s", job.SourceInfo.Duration, result.Duration))
+		}
+	}
+	if preset != nil {
+		result.Errors = append(result.Errors, checkPreset(result, *preset, job.ContentAware)...)
+	}
+	return result
+}
+
+// checkPreset compares the properties measured from an output with the
+// settings of its preset. Bitrates aren't checked in content-aware jobs,
+// where providers pick the bitrate of each rendition.
+func checkPreset(result db.OutputVerification, preset db.Preset, contentAware bool) []string {
+	var errs []string
+	if !preset.AudioOnly() {
+		if result.VideoCodec == "" {
+			errs = append(errs, "the output has no video track")
+		} else if codec := strings.ToLower(preset.Video.Codec); codec != "" && codec != result.VideoCodec {
+			errs = append(errs, fmt.Sprintf("wrong video codec: want %s, got %s", codec, result.VideoCodec))
+		}
+		if width, err := preset.Video.WidthPixels(); err == nil && abs(result.Width-int64(width)) > dimensionTolerance {
+			errs = append(errs, fmt.Sprintf("wrong width: want %d, got %d", width, result.Width))
+		}
+		if height, err := preset.Video.HeightPixels(); err == nil && abs(result.Height-int64(height)) > dimensionTolerance {
+			errs = append(errs, fmt.Sprintf("wrong height: want %d, got %d", height, result.Height))
+		}
+	}
+	if contentAware || result.Bitrate == 0 {
+		return errs
+	}
+	var bitrate int64
+	if preset.Video.Bitrate != "" {
+		if videoBitrate, err := preset.Video.BitrateBps(); err == nil {
+			bitrate += int64(videoBitrate)
+		}
+	}
+	if preset.Audio.Bitrate != "" {
+		if audioBitrate, err := preset.Audio.BitrateBps(); err == nil {
+			bitrate += int64(audioBitrate)
+		}
+	}
+	if bitrate > 0 && float64(abs(result.Bitrate-bitrate)) > float64(bitrate)*bitrateTolerance {
+		errs = append(errs, fmt.Sprintf("wrong bitrate: want %d, got %d", bitrate, result.Bitrate))
+	}
+	return errs
+}
+
+// verifyHLS checks that every variant listed in the given HLS master
+// playlist resolves to a media playlist whose first segment exists.
+func (v *Verifier) verifyHLS(location string) db.ManifestVerification {
+	result := db.ManifestVerification{Path: location, Protocol: "hls"}
+	playlistURL, err := v.resolve(location)
+	if err != nil {
+		result.Errors = append(result.Errors, err.Error())
+		return result
+	}
+	data, err := v.fetch(playlistURL)
+	if err != nil {
+		result.Errors = append(result.Errors, fmt.Sprintf("error fetching the playlist: %s", err))
+		return result
+	}
+	variants, err := parseMasterPlaylist(data)
+	if err != nil {
+		result.Errors = append(result.Errors, err.Error())
+		return result
+	}
+	result.Variants = len(variants)
+	if len(variants) == 0 {
+		result.Errors = append(result.Errors, "the playlist doesn't list any variant")
+	}
+	for _, variant := range variants {
+		if err := v.verifyMediaPlaylist(playlistURL, variant); err != nil {
+			result.Errors = append(result.Errors, fmt.Sprintf("variant %s: %s", variant, err))
+		}
+	}
+	return result
+}
+
+// parseMasterPlaylist returns the URIs of the variant streams, renditions and
+// I-frame playlists of an HLS master playlist.
+func parseMasterPlaylist(data []byte) ([]string, error) {
+	scanner := bufio.NewScanner(bytes.NewReader(data))
+	if !scanner.Scan() || strings.TrimSpace(scanner.Text()) != "#EXTM3U" {
+		return nil, errors.New("the playlist doesn't start with #EXTM3U")
+	}
+	var variants []string
+	var streamInf bool
+	for scanner.Scan() {
+		line := strings.TrimSpace(scanner.Text())
+		switch {
+		case line == "":
+		case strings.HasPrefix(line, "#EXT-X-STREAM-INF:"):
+			streamInf = true
+		case strings.HasPrefix(line, "#EXT-X-MEDIA:"), strings.HasPrefix(line, "#EXT-X-I-FRAME-STREAM-INF:"):
+			if matches := uriAttrRegexp.FindStringSubmatch(line); matches != nil {
+				variants = append(variants, matches[1])
+			}
+		case strings.HasPrefix(line, "#"):
+		case streamInf:
+			variants = append(variants, line)
+			streamInf = false
+		}
+	}
+	return variants, scanner.Err()
+}
+
+func (v *Verifier) verifyMediaPlaylist(masterURL, variant string) error {
+	playlistURL, err := resolveReference(masterURL, variant)
+	if err != nil {
+		return err
+	}
+	data, err := v.fetch(playlistURL)
+	if err != nil {
+		return err
+	}
+	scanner := bufio.NewScanner(bytes.NewReader(data))
+	if !scanner.Scan() || strings.TrimSpace(scanner.Text()) != "#EXTM3U" {
+		return errors.New("the playlist doesn't start with #EXTM3U")
+	}
+	var segments []string
+	for scanner.Scan() && len(segments) < 2 {
+		line := strings.TrimSpace(scanner.Text())
+		switch {
+		case strings.HasPrefix(line, "#EXT-X-MAP:"):
+			if matches := uriAttrRegexp.FindStringSubmatch(line); matches != nil {
+				segments = append(segments, matches[1])
+			}
+		case line == "", strings.HasPrefix(line, "#"):
+		default:
+			segments = append(segments, line)
+		}
+	}
+	if len(segments) == 0 {
+		return errors.New("the playlist has no segments")
+	}
+	for _, segment := range segments {
+		if err := v.checkReference(playlistURL, segment); err != nil {
+			return fmt.Errorf("segment %s: %s", segment, err)
+		}
+	}
+	return nil
+}
+
+type mpd struct {
+	BaseURL string `xml:"BaseURL"`
+	Periods []struct {
+		BaseURL        string `xml:"BaseURL"`
+		AdaptationSets []struct {
+			BaseURL         string           `xml:"BaseURL"`
+			SegmentTemplate *segmentTemplate `xml:"SegmentTemplate"`
+			Representations []struct {
+				ID              string           `xml:"id,attr"`
+				Bandwidth       string           `xml:"bandwidth,attr"`
+				BaseURL         string           `xml:"BaseURL"`
+				SegmentTemplate *segmentTemplate `xml:"SegmentTemplate"`
+			} `xml:"Representation"`
+		} `xml:"AdaptationSet"`
+	} `xml:"Period"`
+}
+
+type segmentTemplate struct {
+	Initialization string `xml:"initialization,attr"`
+	Media          string `xml:"media,attr"`
+	StartNumber    string `xml:"startNumber,attr"`
+	Timeline       []struct {
+		T string `xml:"t,attr"`
+	} `xml:"SegmentTimeline>S"`
+}
+
+// verifyDASH checks that the initialization and first media segment of every
+// representation listed in the given DASH manifest exist.
+func (v *Verifier) verifyDASH(location string) db.ManifestVerification {
+	result := db.ManifestVerification{Path: location, Protocol: "dash"}
+	manifestURL, err := v.resolve(location)
+	if err != nil {
+		result.Errors = append(result.Errors, err.Error())
+		return result
+	}
+	data, err := v.fetch(manifestURL)
+	if err != nil {
+		result.Errors = append(result.Errors, fmt.Sprintf("error fetching the manifest: %s", err))
+		return result
+	}
+	var manifest mpd
+	if err := xml.Unmarshal(data, &manifest); err != nil {
+		result.Errors = append(result.Errors, fmt.Sprintf("invalid manifest: %s", err))
+		return result
+	}
+	mpdBase := joinBase(manifestURL, manifest.BaseURL)
+	for _, period := range manifest.Periods {
+		periodBase := joinBase(mpdBase, period.BaseURL)
+		for _, adaptationSet := range period.AdaptationSets {
+			adaptationSetBase := joinBase(periodBase, adaptationSet.BaseURL)
+			for _, representation := range adaptationSet.Representations {
+				result.Variants++
+				template := representation.SegmentTemplate
+				if template == nil {
+					template = adaptationSet.SegmentTemplate
+				}
+				base := adaptationSetBase
+				var segments []string
+				if template != nil {
+					base = joinBase(adaptationSetBase, representation.BaseURL)
+					for _, pattern := range []string{template.Initialization, template.Media} {
+						if pattern != "" {
+							segments = append(segments, template.expand(pattern, representation.ID, representation.Bandwidth))
+						}
+					}
+				} else if representation.BaseURL != "" {
+					segments = append(segments, representation.BaseURL)
+				}
+				if len(segments) == 0 {
+					result.Errors = append(result.Errors, fmt.Sprintf("representation %s: no segments", representation.ID))
+					continue
+				}
+				for _, segment := range segments {
+					if err := v.checkReference(base, segment); err != nil {
+						result.Errors = append(result.Errors, fmt.Sprintf("representation %s: segment %s: %s", representation.ID, segment, err))
+						break
+					}
+				}
+			}
+		}
+	}
+	if result.Variants == 0 {
+		result.Errors = append(result.Errors, "the manifest doesn't list any representation")
+	}
+	return result
+}
+
+// expand replaces the identifiers of a segment template pattern with the
+// values of the first segment of the given representation.
+func (t *segmentTemplate) expand(pattern, representationID, bandwidth string) string {
+	number := t.StartNumber
+	if number == "" {
+		number = "1"
+	}
+	segmentTime := "0"
+	if len(t.Timeline) > 0 && t.Timeline[0].T != "" {
+		segmentTime = t.Timeline[0].T
+	}
+	values := map[string]string{
+		"RepresentationID": representationID,
+		"Number":           number,
+		"Bandwidth":        bandwidth,
+		"Time":             segmentTime,
+	}
+	expanded := templateIdentifierRe.ReplaceAllStringFunc(pattern, func(identifier string) string {
+		matches := templateIdentifierRe.FindStringSubmatch(identifier)
+		value := values[matches[1]]
+		if width, err := strconv.Atoi(matches[3]); err == nil && len(value) < width {
+			value = strings.Repeat("0", width-len(value)) + value
+		}
+		return value
+	})
+	return strings.Replace(expanded, "$$", "$", -1)
+}
+
+// joinBase resolves a DASH BaseURL element against the base URL of its
+// parent element.
+func joinBase(base, baseURL string) string {
+	if baseURL == "" {
+		return base
+	}
+	resolved, err := resolveReference(base, strings.TrimSpace(baseURL))
+	if err != nil {
+		return base
+	}
+	return resolved
+}
+
+// resolve returns the URL used to fetch the given location. s3:// locations
+// are mapped to path-style URLs of the S3 endpoint.
+func (v *Verifier) resolve(location string) (string, error) {
+	u, err := url.Parse(location)
+	if err != nil {
+		return "", fmt.Errorf("invalid location %q: %s", location, err)
+	}
+	switch u.Scheme {
+	case "http", "https":
+		return location, nil
+	case "s3":
+		endpoint := *v.s3Endpoint
+		endpoint.Path = path.Join("/", endpoint.Path, u.Host, u.Path)
+		return endpoint.String(), nil
+	default:
+		return "", fmt.Errorf("unsupported location %q, outputs can only be verified from http, https and s3 locations", location)
+	}
+}
+
+func resolveReference(base, reference string) (string, error) {
+	baseURL, err := url.Parse(base)
+	if err != nil {
+		return "", err
+	}
+	referenceURL, err := url.Parse(reference)
+	if err != nil {
+		return "", err
+	}
+	return baseURL.ResolveReference(referenceURL).String(), nil
+}
+
+// checkReference checks that the file referenced by a manifest exists.
+func (v *Verifier) checkReference(base, reference string) error {
+	fileURL, err := resolveReference(base, reference)
+	if err != nil {
+		return err
+	}
+	_, err = v.fileSize(fileURL)
+	return err
+}
+
+func (v *Verifier) fetch(fileURL string) ([]byte, error) {
+	resp, err := v.client.Get(fileURL)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("unexpected status: %s", resp.Status)
+	}
+	return ioutil.ReadAll(io.LimitReader(resp.Body, maxManifestSize))
+}
+
+func (v *Verifier) fileSize(fileURL string) (int64, error) {
+	resp, err := v.client.Head(fileURL)
+	if err != nil {
+		return 0, err
+	}
+	resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		return 0, fmt.Errorf("unexpected status: %s", resp.Status)
+	}
+	if resp.ContentLength < 0 {
+		return 0, nil
+	}
+	return resp.ContentLength, nil
+}
+
+// s3SigningTransport signs the requests sent to the S3 endpoint with AWS
+// Signature Version 4.
+type s3SigningTransport struct {
+	base        http.RoundTripper
+	host        string
+	region      string
+	credentials aws.Credentials
+	signer      *v4.Signer
+}
+
+func (t *s3SigningTransport) RoundTrip(req *http.Request) (*http.Response, error) {
+	if req.URL.Host != t.host {
+		return t.base.RoundTrip(req)
+	}
+	req = req.Clone(req.Context())
+	req.Header.Set("X-Amz-Content-Sha256", emptyPayloadHash)
+	err := t.signer.SignHTTP(req.Context(), t.credentials, req, emptyPayloadHash, "s3", t.region, time.Now())
+	if err != nil {
+		return nil, err
+	}
+	return t.base.RoundTrip(req)
+}
+
+func abs(n int64) int64 {
+	if n < 0 {
+		return -n
+	}
+	return n
+}
@@ -0,0 +1,313 @@
+package verify
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/video-dev/video-transcoding-api/v2/config"
+	"github.com/video-dev/video-transcoding-api/v2/db"
+	"github.com/video-dev/video-transcoding-api/v2/internal/provider"
+)
+
+type fakeProber struct {
+	infos map[string]db.SourceInfo
+}
+
+func (p *fakeProber) Probe(sourceURL string) (db.SourceInfo, error) {
+	for suffix, info := range p.infos {
+		if strings.HasSuffix(sourceURL, suffix) {
+			return info, nil
+		}
+	}
+	return db.SourceInfo{}, nil
+}
+
+// newFileServer serves the given files, keyed by path.
+func newFileServer(files map[string]string) *httptest.Server {
+	return httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		content, ok := files[r.URL.Path]
+		if !ok {
+			http.NotFound(w, r)
+			return
+		}
+		http.ServeContent(w, r, r.URL.Path, time.Time{}, strings.NewReader(content))
+	}))
+}
+
+func newTestVerifier(t *testing.T, server *httptest.Server, infos map[string]db.SourceInfo) *Verifier {
+	verifier, err := NewVerifier(nil, server.Client())
+	if err != nil {
+		t.Fatal(err)
+	}
+	verifier.prober = &fakeProber{infos: infos}
+	return verifier
+}
+
+func TestVerifyOutputs(t *testing.T) {
+	// 125000 bytes over 10 seconds: 100 kbps
+	server := newFileServer(map[string]string{
+		"/job-1/720p.mp4":  strings.Repeat("a", 125000),
+		"/job-1/480p.mp4":  strings.Repeat("a", 125000),
+		"/job-1/720p.webm": "webm",
+	})
+	defer server.Close()
+	verifier := newTestVerifier(t, server, map[string]db.SourceInfo{
+		"720p.mp4": {Container: "mp4", VideoCodec: "h264", Width: 1280, Height: 720, Duration: 10 * time.Second},
+		"480p.mp4": {Container: "mov", VideoCodec: "hevc", Width: 640, Height: 360, Duration: 5 * time.Second},
+	})
+	preset := func(width, height, bitrate string) *db.Preset {
+		return &db.Preset{
+			Container: "mp4",
+			Video:     db.VideoPreset{Codec: "h264", Width: width, Height: height, Bitrate: bitrate},
+			Audio:     db.AudioPreset{Codec: "aac", Bitrate: "20000"},
+		}
+	}
+	job := db.Job{
+		ID:         "job-1",
+		SourceInfo: &db.SourceInfo{Duration: 10 * time.Second},
+		Outputs: []db.TranscodeOutput{
+			{
+				FileName: "720p.mp4",
+				Preset:   db.PresetMap{Name: "720p", OutputOpts: db.OutputOptions{Extension: "mp4"}},
+			},
+			{
+				FileName: "480p.mp4",
+				Preset:   db.PresetMap{Name: "480p", OutputOpts: db.OutputOptions{Extension: "mp4"}},
+			},
+			{
+				FileName: "720p.webm",
+				Preset:   db.PresetMap{Name: "720p_webm", OutputOpts: db.OutputOptions{Extension: "webm"}},
+			},
+			{
+				FileName: "1080p.mp4",
+				Preset:   db.PresetMap{Name: "1080p", OutputOpts: db.OutputOptions{Extension: "mp4"}},
+			},
+		},
+	}
+	presets := map[string]*db.Preset{
+		"720p": preset("1280", "", "80000"),
+		"480p": preset("", "480", "1000000"),
+	}
+	report := verifier.Verify(&job, presets, &provider.JobStatus{
+		Status: provider.StatusFinished,
+		Output: provider.JobOutput{
+			Destination: server.URL + "/job-1/",
+			Files:       []provider.OutputFile{{Path: server.URL + "/job-1/720p.mp4"}},
+		},
+	})
+	if report.Status != db.VerificationFailed {
+		t.Errorf("wrong status. Want %q. Got %q", db.VerificationFailed, report.Status)
+	}
+	if report.VerifiedAt.IsZero() {
+		t.Error("unexpected zero VerifiedAt")
+	}
+	expected := []db.OutputVerification{
+		{
+			Path:       server.URL + "/job-1/720p.mp4",
+			Preset:     "720p",
+			Container:  "mp4",
+			VideoCodec: "h264",
+			Width:      1280,
+			Height:     720,
+			Duration:   10 * time.Second,
+			Bitrate:    100000,
+			FileSize:   125000,
+		},
+		{
+			Path:       server.URL + "/job-1/480p.mp4",
+			Preset:     "480p",
+			Container:  "mov",
+			VideoCodec: "hevc",
+			Width:      640,
+			Height:     360,
+			Duration:   5 * time.Second,
+			Bitrate:    200000,
+			FileSize:   125000,
+			Errors: []string{
+				"wrong container: want mp4, got mov",
+				"wrong duration: want 10s, got 5s",
+				"wrong video codec: want h264, got hevc",
+				"wrong height: want 480, got 360",
+				"wrong bitrate: want 1020000, got 200000",
+			},
+		},
+		{
+			Path:     server.URL + "/job-1/720p.webm",
+			Preset:   "720p_webm",
+			FileSize: 4,
+		},
+		{
+			Path:   server.URL + "/job-1/1080p.mp4",
+			Preset: "1080p",
+			Errors: []string{"error fetching the output: unexpected status: 404 Not Found"},
+		},
+	}
+	if !reflect.DeepEqual(report.Outputs, expected) {
+		t.Errorf("wrong outputs\nwant %#v\ngot  %#v", expected, report.Outputs)
+	}
+}
+
+func TestVerifyContentAwareSkipsBitrate(t *testing.T) {
+	server := newFileServer(map[string]string{"/720p.mp4": strings.Repeat("a", 125000)})
+	defer server.Close()
+	verifier := newTestVerifier(t, server, map[string]db.SourceInfo{
+		"720p.mp4": {Container: "mp4", VideoCodec: "h264", Width: 1280, Height: 720, Duration: 10 * time.Second},
+	})
+	job := db.Job{
+		ContentAware: true,
+		Outputs: []db.TranscodeOutput{{
+			FileName: "720p.mp4",
+			Preset:   db.PresetMap{Name: "720p", OutputOpts: db.OutputOptions{Extension: "mp4"}},
+		}},
+	}
+	presets := map[string]*db.Preset{
+		"720p": {Video: db.VideoPreset{Codec: "h264", Height: "720", Bitrate: "3000000"}},
+	}
+	report := verifier.Verify(&job, presets, &provider.JobStatus{Output: provider.JobOutput{Destination: server.URL}})
+	if report.Status != db.VerificationPassed {
+		t.Errorf("wrong status. Want %q. Got %q (%#v)", db.VerificationPassed, report.Status, report.Outputs)
+	}
+}
+
+func TestVerifyHLS(t *testing.T) {
+	server := newFileServer(map[string]string{
+		"/job-1/hls/index.m3u8": `#EXTM3U
+#EXT-X-MEDIA:TYPE=AUDIO,GROUP-ID="audio",NAME="en",URI="audio/en.m3u8"
+#EXT-X-STREAM-INF:BANDWIDTH=1000000,AUDIO="audio"
+720p/video.m3u8
+#EXT-X-STREAM-INF:BANDWIDTH=500000,AUDIO="audio"
+480p/video.m3u8
+#EXT-X-I-FRAME-STREAM-INF:BANDWIDTH=100000,URI="720p/iframes.m3u8"
+`,
+		"/job-1/hls/720p/video.m3u8": `#EXTM3U
+#EXT-X-VERSION:7
+#EXT-X-MAP:URI="init.mp4"
+#EXTINF:6.0,
+seg_0.m4s
+`,
+		"/job-1/hls/720p/init.mp4":     "init",
+		"/job-1/hls/720p/seg_0.m4s":    "segment",
+		"/job-1/hls/720p/iframes.m3u8": "#EXTM3U\n#EXT-X-I-FRAMES-ONLY\n#EXTINF:6.0,\nseg_0.m4s\n",
+		"/job-1/hls/480p/video.m3u8":   "#EXTM3U\n#EXTINF:6.0,\nseg_0.ts\n",
+		"/job-1/hls/audio/en.m3u8":     "#EXTM3U\n#EXT-X-ENDLIST\n",
+	})
+	defer server.Close()
+	verifier := newTestVerifier(t, server, nil)
+	job := db.Job{StreamingParams: db.StreamingParams{Protocol: "hls", PlaylistFileName: "hls/index.m3u8"}}
+	report := verifier.Verify(&job, nil, &provider.JobStatus{Output: provider.JobOutput{Destination: server.URL + "/job-1"}})
+	expected := []db.ManifestVerification{{
+		Path:     server.URL + "/job-1/hls/index.m3u8",
+		Protocol: "hls",
+		Variants: 4,
+		Errors: []string{
+			"variant audio/en.m3u8: the playlist has no segments",
+			"variant 480p/video.m3u8: segment seg_0.ts: unexpected status: 404 Not Found",
+		},
+	}}
+	if !reflect.DeepEqual(report.Manifests, expected) {
+		t.Errorf("wrong manifests\nwant %#v\ngot  %#v", expected, report.Manifests)
+	}
+	if report.Status != db.VerificationFailed {
+		t.Errorf("wrong status. Want %q. Got %q", db.VerificationFailed, report.Status)
+	}
+}
+
+func TestVerifyCMAF(t *testing.T) {
+	server := newFileServer(map[string]string{
+		"/job-1/cmaf/index.m3u8":         "#EXTM3U\n#EXT-X-STREAM-INF:BANDWIDTH=1000000\n720p/video.m3u8\n",
+		"/job-1/cmaf/720p/video.m3u8":    "#EXTM3U\n#EXT-X-MAP:URI=\"init.mp4\"\n#EXTINF:6.0,\nseg_1.m4s\n",
+		"/job-1/cmaf/720p/init.mp4":      "init",
+		"/job-1/cmaf/720p/seg_1.m4s":     "segment",
+		"/job-1/cmaf/720p/seg_00001.m4s": "segment",
+		"/job-1/cmaf/audio/init.mp4":     "init",
+		"/job-1/cmaf/index.mpd": `<?xml version="1.0" encoding="UTF-8"?>
+<MPD xmlns="urn:mpeg:dash:schema:mpd:2011" type="static">
+  <Period>
+    <AdaptationSet mimeType="video/mp4">
+      <SegmentTemplate initialization="$RepresentationID$/init.mp4" media="$RepresentationID$/seg_$Number%05d$.m4s" startNumber="1"/>
+      <Representation id="720p" bandwidth="1000000"/>
+      <Representation id="480p" bandwidth="500000"/>
+    </AdaptationSet>
+    <AdaptationSet mimeType="audio/mp4">
+      <Representation id="audio" bandwidth="128000">
+        <BaseURL>audio/init.mp4</BaseURL>
+      </Representation>
+    </AdaptationSet>
+  </Period>
+</MPD>`,
+	})
+	defer server.Close()
+	verifier := newTestVerifier(t, server, nil)
+	job := db.Job{StreamingParams: db.StreamingParams{Protocol: "cmaf", PlaylistFileName: "cmaf/index.m3u8"}}
+	report := verifier.Verify(&job, nil, &provider.JobStatus{Output: provider.JobOutput{Destination: server.URL + "/job-1/"}})
+	expected := []db.ManifestVerification{
+		{
+			Path:     server.URL + "/job-1/cmaf/index.m3u8",
+			Protocol: "hls",
+			Variants: 1,
+		},
+		{
+			Path:     server.URL + "/job-1/cmaf/index.mpd",
+			Protocol: "dash",
+			Variants: 3,
+			Errors:   []string{"representation 480p: segment 480p/init.mp4: unexpected status: 404 Not Found"},
+		},
+	}
+	if !reflect.DeepEqual(report.Manifests, expected) {
+		t.Errorf("wrong manifests\nwant %#v\ngot  %#v", expected, report.Manifests)
+	}
+}
+
+func TestVerifyS3Locations(t *testing.T) {
+	var authorizations []string
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		authorizations = append(authorizations, r.Header.Get("Authorization"))
+		if r.URL.Path != "/my-bucket/job-1/720p.webm" {
+			http.NotFound(w, r)
+			return
+		}
+		w.Header().Set("Content-Length", "4")
+	}))
+	defer server.Close()
+	verifier, err := NewVerifier(&config.Verification{
+		S3Endpoint:      server.URL,
+		S3Region:        "us-east-1",
+		AccessKeyID:     "access-key",
+		SecretAccessKey: "secret-key",
+	}, server.Client())
+	if err != nil {
+		t.Fatal(err)
+	}
+	job := db.Job{Outputs: []db.TranscodeOutput{{
+		FileName: "720p.webm",
+		Preset:   db.PresetMap{Name: "720p", OutputOpts: db.OutputOptions{Extension: "webm"}},
+	}}}
+	report := verifier.Verify(&job, nil, &provider.JobStatus{Output: provider.JobOutput{Destination: "s3://my-bucket/job-1"}})
+	expected := []db.OutputVerification{{Path: "s3://my-bucket/job-1/720p.webm", Preset: "720p", FileSize: 4}}
+	if !reflect.DeepEqual(report.Outputs, expected) {
+		t.Errorf("wrong outputs\nwant %#v\ngot  %#v", expected, report.Outputs)
+	}
+	if len(authorizations) != 1 || !strings.HasPrefix(authorizations[0], "AWS4-HMAC-SHA256 Credential=access-key/") {
+		t.Errorf("request wasn't signed: %#v", authorizations)
+	}
+}
+
+func TestVerifyUnsupportedLocation(t *testing.T) {
+	verifier, err := NewVerifier(nil, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	job := db.Job{Outputs: []db.TranscodeOutput{{
+		FileName: "720p.mp4",
+		Preset:   db.PresetMap{Name: "720p", OutputOpts: db.OutputOptions{Extension: "mp4"}},
+	}}}
+	report := verifier.Verify(&job, nil, &provider.JobStatus{Output: provider.JobOutput{Destination: "ftp://host/job-1"}})
+	expected := `unsupported location "ftp://host/job-1/720p.mp4", outputs can only be verified from http, https and s3 locations`
+	if len(report.Outputs) != 1 || !reflect.DeepEqual(report.Outputs[0].Errors, []string{expected}) {
+		t.Errorf("wrong outputs: %#v", report.Outputs)
+	}
+}
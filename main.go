@@ -1,8 +1,10 @@
 package main
 
 import (
+	"flag"
 	"io/ioutil"
 	"log"
+	"os"
 
 	"github.com/NYTimes/gizmo/server"
 	"github.com/google/gops/agent"
@@ -17,6 +19,11 @@ import (
 )
 
 func main() {
+	if len(os.Args) > 1 && os.Args[1] == "reconcile" {
+		reconcile(os.Args[2:])
+		return
+	}
+
 	agent.Listen(agent.Options{})
 	defer agent.Close()
 	cfg := config.LoadConfig()
@@ -41,3 +48,26 @@ func main() {
 		logger.Fatal("server encountered a fatal error: ", err)
 	}
 }
+
+// reconcile compares the presets given as arguments, or every preset, with
+// the presets stored in their providers and prints the reports as JSON.
+func reconcile(args []string) {
+	flags := flag.NewFlagSet("reconcile", flag.ExitOnError)
+	recreate := flags.Bool("recreate", false, "recreate drifted and missing presets from the canonical presets")
+	flags.Parse(args)
+
+	cfg := config.LoadConfig()
+	logger, err := cfg.Log.Logger()
+	if err != nil {
+		log.Fatal(err)
+	}
+
+	service, err := service.NewTranscodingService(cfg, logger)
+	if err != nil {
+		logger.Fatal("unable to initialize service: ", err)
+	}
+	err = service.ReconcilePresets(os.Stdout, *recreate, flags.Args()...)
+	if err != nil {
+		logger.Fatal("unable to reconcile presets: ", err)
+	}
+}
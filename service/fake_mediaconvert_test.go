@@ -0,0 +1,118 @@
+package service
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync"
+
+	"github.com/video-dev/video-transcoding-api/v2/config"
+	_ "github.com/video-dev/video-transcoding-api/v2/internal/provider/mediaconvert"
+)
+
+const mediaConvertPresetsPath = "/2017-08-29/presets"
+
+// fakeMediaConvert is an HTTP server implementing the preset calls of the
+// MediaConvert API. Like MediaConvert, it keys presets by name, so creating
+// a preset with a name that's already taken fails with a conflict.
+type fakeMediaConvert struct {
+	*httptest.Server
+	mu      sync.Mutex
+	presets map[string]map[string]interface{}
+	updates []string
+}
+
+func newFakeMediaConvert() *fakeMediaConvert {
+	mc := fakeMediaConvert{presets: make(map[string]map[string]interface{})}
+	mc.Server = httptest.NewServer(&mc)
+	return &mc
+}
+
+// config returns the configuration that points the mediaconvert provider to
+// the fake server.
+func (mc *fakeMediaConvert) config() *config.MediaConvert {
+	return &config.MediaConvert{
+		Endpoint: mc.URL,
+		Region:   "us-east-1",
+		Queue:    "arn:aws:mediaconvert:us-east-1:123456789012:queues/Default",
+		Role:     "arn:aws:iam::123456789012:role/MediaConvert",
+	}
+}
+
+func (mc *fakeMediaConvert) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	mc.mu.Lock()
+	defer mc.mu.Unlock()
+	w.Header().Set("Content-Type", "application/json")
+	if r.URL.Path == mediaConvertPresetsPath && r.Method == http.MethodPost {
+		mc.createPreset(w, r)
+		return
+	}
+	name := strings.TrimPrefix(r.URL.Path, mediaConvertPresetsPath+"/")
+	if name == r.URL.Path {
+		mc.error(w, http.StatusBadRequest, "BadRequestException", "unsupported call")
+		return
+	}
+	stored, ok := mc.presets[name]
+	if !ok {
+		mc.error(w, http.StatusNotFound, "NotFoundException", "preset not found")
+		return
+	}
+	switch r.Method {
+	case http.MethodGet:
+		json.NewEncoder(w).Encode(map[string]interface{}{"preset": stored})
+	case http.MethodPut:
+		var input map[string]interface{}
+		json.NewDecoder(r.Body).Decode(&input)
+		input["name"] = name
+		mc.presets[name] = input
+		mc.updates = append(mc.updates, name)
+		json.NewEncoder(w).Encode(map[string]interface{}{"preset": input})
+	case http.MethodDelete:
+		delete(mc.presets, name)
+		w.WriteHeader(http.StatusAccepted)
+		w.Write([]byte("{}"))
+	default:
+		mc.error(w, http.StatusBadRequest, "BadRequestException", "unsupported call")
+	}
+}
+
+func (mc *fakeMediaConvert) createPreset(w http.ResponseWriter, r *http.Request) {
+	var input map[string]interface{}
+	json.NewDecoder(r.Body).Decode(&input)
+	name, _ := input["name"].(string)
+	if _, ok := mc.presets[name]; ok {
+		mc.error(w, http.StatusConflict, "ConflictException", "a preset with this name already exists")
+		return
+	}
+	mc.presets[name] = input
+	w.WriteHeader(http.StatusCreated)
+	json.NewEncoder(w).Encode(map[string]interface{}{"preset": input})
+}
+
+func (mc *fakeMediaConvert) error(w http.ResponseWriter, code int, errorType, message string) {
+	w.Header().Set("X-Amzn-ErrorType", errorType)
+	w.WriteHeader(code)
+	json.NewEncoder(w).Encode(map[string]string{"message": message})
+}
+
+// bitrate returns the bitrate of the first video output of the stored
+// preset.
+func (mc *fakeMediaConvert) bitrate(name string) float64 {
+	mc.mu.Lock()
+	defer mc.mu.Unlock()
+	var preset struct {
+		Settings struct {
+			VideoDescription struct {
+				CodecSettings struct {
+					H264Settings struct {
+						Bitrate float64 `json:"bitrate"`
+					} `json:"h264Settings"`
+				} `json:"codecSettings"`
+			} `json:"videoDescription"`
+		} `json:"settings"`
+	}
+	data, _ := json.Marshal(mc.presets[name])
+	json.Unmarshal(data, &preset)
+	return preset.Settings.VideoDescription.CodecSettings.H264Settings.Bitrate
+}
@@ -1,6 +1,9 @@
 package service
 
 import (
+	"errors"
+	"sort"
+
 	"github.com/video-dev/video-transcoding-api/v2/config"
 	"github.com/video-dev/video-transcoding-api/v2/db"
 	"github.com/video-dev/video-transcoding-api/v2/internal/provider"
@@ -9,11 +12,21 @@ import (
 func init() {
 	provider.Register("fake", fakeProviderFactory)
 	provider.Register("zencoder", fakeProviderFactory)
+	provider.Register("local", localPresetProviderFactory)
 }
 
 type fakeProvider struct {
-	jobs         []*db.Job
-	canceledJobs []string
+	jobs           []*db.Job
+	canceledJobs   []string
+	deletedPresets []string
+
+	// failedPresets lists the IDs of the presets that GetPreset fails to
+	// fetch for reasons other than the preset not existing.
+	failedPresets []string
+
+	// storedPresets maps the IDs of the presets listed by ListPresets to
+	// their containers.
+	storedPresets map[string]string
 }
 
 var fprovider fakeProvider
@@ -24,6 +37,11 @@ func (p *fakeProvider) Transcode(job *db.Job) (*provider.JobStatus, error) {
 			return nil, provider.ErrPresetMapNotFound
 		}
 	}
+	for _, track := range job.AudioTracks {
+		if track.Language == "unsupported" {
+			return nil, provider.InvalidAudioTracksError("audio tracks in language \"unsupported\" are not supported")
+		}
+	}
 	p.jobs = append(p.jobs, job)
 	return &provider.JobStatus{
 		ProviderJobID: "provider-preset-job-123",
@@ -40,11 +58,50 @@ func (*fakeProvider) CreatePreset(preset db.Preset) (string, error) {
 	return "presetID_here", nil
 }
 
-func (*fakeProvider) GetPreset(presetID string) (interface{}, error) {
-	return struct{ presetID string }{"presetID_here"}, nil
+func (*fakeProvider) ValidatePreset(preset db.Preset) error {
+	if preset.Container == "webm" {
+		return errors.New("unsupported container: webm")
+	}
+	return nil
 }
 
-func (*fakeProvider) DeletePreset(presetID string) error {
+func (p *fakeProvider) GetPreset(presetID string) (interface{}, error) {
+	for _, deleted := range p.deletedPresets {
+		if deleted == presetID {
+			return nil, provider.PresetNotFoundError{ID: presetID}
+		}
+	}
+	for _, failed := range p.failedPresets {
+		if failed == presetID {
+			return nil, errors.New("service unavailable")
+		}
+	}
+	return map[string]interface{}{"id": presetID, "container": "mp4"}, nil
+}
+
+func (p *fakeProvider) NormalizePreset(native interface{}) (db.Preset, error) {
+	preset := native.(map[string]interface{})
+	return db.Preset{Container: preset["container"].(string)}, nil
+}
+
+func (p *fakeProvider) ListPresets() ([]provider.StoredPreset, error) {
+	ids := make([]string, 0, len(p.storedPresets))
+	for id := range p.storedPresets {
+		ids = append(ids, id)
+	}
+	sort.Strings(ids)
+	presets := make([]provider.StoredPreset, len(ids))
+	for i, id := range ids {
+		presets[i] = provider.StoredPreset{
+			ID:     id,
+			Preset: map[string]interface{}{"id": id, "container": p.storedPresets[id]},
+		}
+	}
+	return presets, nil
+}
+
+func (p *fakeProvider) DeletePreset(presetID string) error {
+	p.deletedPresets = append(p.deletedPresets, presetID)
 	return nil
 }
 
@@ -75,6 +132,16 @@ func (p *fakeProvider) JobStatus(job *db.Job) (*provider.JobStatus, error) {
 			},
 		}, nil
 	}
+	if id == "provider-job-unavailable" {
+		return nil, errors.New("service unavailable")
+	}
+	if id == "provider-job-running" {
+		return &provider.JobStatus{
+			ProviderJobID: id,
+			Status:        provider.StatusStarted,
+			Progress:      42,
+		}, nil
+	}
 	return nil, provider.JobNotFoundError{ID: id}
 }
 
@@ -101,3 +168,60 @@ func (p *fakeProvider) Capabilities() provider.Capabilities {
 func fakeProviderFactory(_ *config.Config) (provider.TranscodingProvider, error) {
 	return &fprovider, nil
 }
+
+// localPresetProvider keeps presets in the local preset repository, keyed
+// by name, like Zencoder does.
+type localPresetProvider struct {
+	fakeProvider
+	db db.LocalPresetRepository
+}
+
+// localPresets is the repository used by the local provider, which is only
+// available while tests set it.
+var localPresets db.LocalPresetRepository
+
+func (p *localPresetProvider) CreatePreset(preset db.Preset) (string, error) {
+	err := p.db.CreateLocalPreset(&db.LocalPreset{Name: preset.Name, Preset: preset})
+	if err != nil {
+		return "", err
+	}
+	return preset.Name, nil
+}
+
+func (p *localPresetProvider) UpdatePreset(presetID string, preset db.Preset) (string, error) {
+	err := p.db.UpdateLocalPreset(&db.LocalPreset{Name: presetID, Preset: preset})
+	if err != nil {
+		return "", err
+	}
+	return presetID, nil
+}
+
+func (p *localPresetProvider) GetPreset(presetID string) (interface{}, error) {
+	preset, err := p.db.GetLocalPreset(presetID)
+	if err == db.ErrLocalPresetNotFound {
+		return nil, provider.PresetNotFoundError{ID: presetID}
+	}
+	if err != nil {
+		return nil, err
+	}
+	return preset, nil
+}
+
+func (p *localPresetProvider) NormalizePreset(native interface{}) (db.Preset, error) {
+	return native.(*db.LocalPreset).Preset, nil
+}
+
+func (p *localPresetProvider) DeletePreset(presetID string) error {
+	preset, err := p.db.GetLocalPreset(presetID)
+	if err != nil {
+		return err
+	}
+	return p.db.DeleteLocalPreset(preset)
+}
+
+func localPresetProviderFactory(_ *config.Config) (provider.TranscodingProvider, error) {
+	if localPresets == nil {
+		return nil, errors.New("local presets not configured")
+	}
+	return &localPresetProvider{db: localPresets}, nil
+}
@@ -0,0 +1,202 @@
+package service
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+
+	"github.com/NYTimes/gizmo/server"
+	"github.com/video-dev/video-transcoding-api/v2/db"
+	"github.com/video-dev/video-transcoding-api/v2/swagger"
+)
+
+// swagger:route POST /ladders ladders newLadder
+//
+// Generates the presets of an adaptive bitrate ladder and creates them on the
+// given providers. Jobs can then reference the ladder instead of listing its
+// outputs.
+//
+//     Responses:
+//       200: newLadderOutputs
+//       400: invalidLadder
+//       409: ladderAlreadyExists
+//       500: genericError
+func (s *TranscodingService) newLadder(r *http.Request) swagger.GizmoJSONResponse {
+	defer r.Body.Close()
+	var input newLadderInput
+	err := json.NewDecoder(r.Body).Decode(&input)
+	if err != nil {
+		return newInvalidLadderResponse(err)
+	}
+	if len(input.Providers) == 0 {
+		return newInvalidLadderResponse(errMissingLadderProviders)
+	}
+	presets, err := input.Ladder.Presets()
+	if err != nil {
+		return newInvalidLadderResponse(fmt.Errorf("invalid ladder: %s", err))
+	}
+	_, err = s.db.GetPresetGroup(input.Ladder.Name)
+	if err == nil {
+		return newLadderAlreadyExistsResponse(errLadderAlreadyExists)
+	} else if err != db.ErrPresetGroupNotFound {
+		return swagger.NewErrorResponse(err)
+	}
+
+	// all presets are validated before creating any of them
+	for i := range presets {
+		var errResp swagger.GizmoJSONResponse
+		presets[i], errResp = validateNewPreset(presets[i], input.Providers)
+		if errResp != nil {
+			return errResp
+		}
+	}
+
+	output := newLadderOutputs{Results: make(map[string]newPresetOutputs, len(presets))}
+	ladder := db.PresetGroup{
+		Name:            input.Ladder.Name,
+		StreamingParams: input.Ladder.StreamingParams(),
+		Ladder:          true,
+	}
+	// rungs may reuse presetmaps that already exist, which are restored
+	// instead of deleted when the ladder is rolled back
+	previous := make(map[string]*db.PresetMap, len(presets))
+	status := http.StatusOK
+	for _, preset := range presets {
+		presetMap, err := s.db.GetPresetMap(preset.Name)
+		if err == nil {
+			// createPreset adds the new providers to the mapping
+			saved := *presetMap
+			saved.ProviderMapping = make(map[string]string, len(presetMap.ProviderMapping))
+			for p, presetID := range presetMap.ProviderMapping {
+				saved.ProviderMapping[p] = presetID
+			}
+			previous[preset.Name] = &saved
+		} else if err != db.ErrPresetMapNotFound {
+			s.rollbackLadderPresets(&output, previous)
+			return swagger.NewErrorResponse(err)
+		}
+		presetOutput, errResp := s.createPreset(preset, db.OutputOptions{}, input.Providers)
+		output.Results[preset.Name] = presetOutput
+		if errResp != nil {
+			s.rollbackLadderPresets(&output, previous)
+			return errResp
+		}
+		if !ladderRungCreated(presetOutput) {
+			status = http.StatusInternalServerError
+			break
+		}
+		ladder.Presets = append(ladder.Presets, preset.Name)
+	}
+	if status == http.StatusOK {
+		err = s.db.CreatePresetGroup(&ladder)
+		if err == db.ErrPresetGroupAlreadyExists {
+			s.rollbackLadderPresets(&output, previous)
+			return newLadderAlreadyExistsResponse(errLadderAlreadyExists)
+		} else if err != nil {
+			s.rollbackLadderPresets(&output, previous)
+			return swagger.NewErrorResponse(err)
+		}
+		output.Ladder = ladder.Name
+	} else {
+		s.rollbackLadderPresets(&output, previous)
+	}
+	return &createLadderResponse{
+		baseResponse: baseResponse{
+			payload: output,
+			status:  status,
+		},
+	}
+}
+
+// ladderRungCreated returns whether the preset of a rung was created on every
+// provider of the ladder.
+func ladderRungCreated(output newPresetOutputs) bool {
+	if output.PresetMap == "" {
+		return false
+	}
+	for _, result := range output.Results {
+		if result.Error != "" {
+			return false
+		}
+	}
+	return true
+}
+
+// rollbackLadderPresets undoes the rungs created by newLadder. Presets
+// created for the ladder are deleted from the providers, along with their
+// presetmaps, while the presetmaps that existed before are restored.
+func (s *TranscodingService) rollbackLadderPresets(output *newLadderOutputs, previous map[string]*db.PresetMap) {
+	for name, rung := range output.Results {
+		presetMap := previous[name]
+		for p, result := range rung.Results {
+			if result.PresetID == "" || (presetMap != nil && presetMap.ProviderMapping[p] == result.PresetID) {
+				continue
+			}
+			if result.Error == "" {
+				result.Error = "rolled back after failures on other rungs"
+			}
+			if err := s.deleteProviderPreset(p, result.PresetID); err != nil {
+				result.Error += ", " + err.Error()
+			}
+			result.PresetID = ""
+			rung.Results[p] = result
+		}
+		if rung.PresetMap == "" {
+			continue
+		}
+		var err error
+		if presetMap != nil {
+			err = s.db.UpdatePresetMap(presetMap)
+		} else {
+			err = s.db.DeletePresetMap(&db.PresetMap{Name: name})
+		}
+		rung.PresetMap = ""
+		if err != nil {
+			rung.PresetMap = "couldn't roll back: " + err.Error()
+		}
+		output.Results[name] = rung
+	}
+}
+
+// swagger:route GET /ladders/{name} ladders getLadder
+//
+// Finds a ladder using its name.
+//
+//     Responses:
+//       200: ladder
+//       404: ladderNotFound
+//       500: genericError
+func (s *TranscodingService) getLadder(r *http.Request) swagger.GizmoJSONResponse {
+	var params getLadderInput
+	params.loadParams(server.Vars(r))
+	ladder, err := s.db.GetPresetGroup(params.Name)
+	switch {
+	case err == nil && ladder.Ladder:
+		return newLadderResponse(ladder)
+	case err == nil, err == db.ErrPresetGroupNotFound:
+		return newLadderNotFoundResponse(errLadderNotFound)
+	default:
+		return swagger.NewErrorResponse(err)
+	}
+}
+
+// swagger:route GET /ladders ladders listLadders
+//
+// List available ladders on the API.
+//
+//     Responses:
+//       200: listLadders
+//       500: genericError
+func (s *TranscodingService) listLadders(*http.Request) swagger.GizmoJSONResponse {
+	groups, err := s.db.ListPresetGroups()
+	if err != nil {
+		return swagger.NewErrorResponse(err)
+	}
+	var ladders []db.PresetGroup
+	for _, group := range groups {
+		if group.Ladder {
+			ladders = append(ladders, group)
+		}
+	}
+	return newListLaddersResponse(ladders)
+}
@@ -0,0 +1,146 @@
+package service
+
+import (
+	"errors"
+	"net/http"
+
+	"github.com/video-dev/video-transcoding-api/v2/db"
+	"github.com/video-dev/video-transcoding-api/v2/swagger"
+)
+
+var (
+	errMissingLadderProviders = errors.New("missing providers from request")
+	errLadderNotFound         = errors.New("ladder not found")
+	errLadderAlreadyExists    = errors.New("ladder already exists")
+)
+
+// swagger:parameters newLadder
+type newLadderInput struct {
+	// providers where the presets of the ladder are created
+	//
+	// required: true
+	Providers []string `json:"providers"`
+
+	// specification of the ladder
+	//
+	// required: true
+	Ladder db.LadderSpec `json:"ladder"`
+}
+
+// swagger:parameters getLadder
+type getLadderInput struct {
+	// in: path
+	// required: true
+	Name string `json:"name"`
+}
+
+func (p *getLadderInput) loadParams(paramsMap map[string]string) {
+	p.Name = paramsMap["name"]
+}
+
+// results of the attempt to create each preset of a ladder, keyed by preset
+// name. Ladder is empty when any of the presets couldn't be created.
+//
+// swagger:response newLadderOutputs
+type newLadderOutputs struct {
+	// in: body
+	// required: true
+	Results map[string]newPresetOutputs
+	Ladder  string
+}
+
+type createLadderResponse struct {
+	baseResponse
+}
+
+// JSON-encoded ladder returned on the getLadder operation.
+//
+// swagger:response ladder
+type ladderResponse struct {
+	// in: body
+	Payload *db.PresetGroup
+
+	baseResponse
+}
+
+// response for the listLadders operation. It's a JSON-encoded object in the
+// format `ladderName: ladderObject`
+//
+// swagger:response listLadders
+type listLaddersResponse struct {
+	// in: body
+	Ladders map[string]db.PresetGroup
+
+	baseResponse
+}
+
+// error returned when the given ladder specification is not valid.
+//
+// swagger:response invalidLadder
+type invalidLadderResponse struct {
+	// in: body
+	Error *swagger.ErrorResponse
+}
+
+// error returned when the given ladder name is not found on the API.
+//
+// swagger:response ladderNotFound
+type ladderNotFoundResponse struct {
+	// in: body
+	Error *swagger.ErrorResponse
+}
+
+// error returned when trying to create a new ladder using a name that is
+// already in-use.
+//
+// swagger:response ladderAlreadyExists
+type ladderAlreadyExistsResponse struct {
+	// in: body
+	Error *swagger.ErrorResponse
+}
+
+func newLadderResponse(ladder *db.PresetGroup) *ladderResponse {
+	return &ladderResponse{
+		baseResponse: baseResponse{
+			payload: ladder,
+			status:  http.StatusOK,
+		},
+	}
+}
+
+func newListLaddersResponse(ladders []db.PresetGroup) *listLaddersResponse {
+	ladderMap := make(map[string]db.PresetGroup, len(ladders))
+	for _, ladder := range ladders {
+		ladderMap[ladder.Name] = ladder
+	}
+	return &listLaddersResponse{
+		baseResponse: baseResponse{
+			payload: ladderMap,
+			status:  http.StatusOK,
+		},
+	}
+}
+
+func newInvalidLadderResponse(err error) *invalidLadderResponse {
+	return &invalidLadderResponse{Error: swagger.NewErrorResponse(err).WithStatus(http.StatusBadRequest)}
+}
+
+func (r *invalidLadderResponse) Result() (int, interface{}, error) {
+	return r.Error.Result()
+}
+
+func newLadderNotFoundResponse(err error) *ladderNotFoundResponse {
+	return &ladderNotFoundResponse{Error: swagger.NewErrorResponse(err).WithStatus(http.StatusNotFound)}
+}
+
+func (r *ladderNotFoundResponse) Result() (int, interface{}, error) {
+	return r.Error.Result()
+}
+
+func newLadderAlreadyExistsResponse(err error) *ladderAlreadyExistsResponse {
+	return &ladderAlreadyExistsResponse{Error: swagger.NewErrorResponse(err).WithStatus(http.StatusConflict)}
+}
+
+func (r *ladderAlreadyExistsResponse) Result() (int, interface{}, error) {
+	return r.Error.Result()
+}
@@ -0,0 +1,334 @@
+package service
+
+import (
+	"bytes"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"reflect"
+	"testing"
+
+	"github.com/NYTimes/gizmo/server"
+	"github.com/sirupsen/logrus"
+	"github.com/video-dev/video-transcoding-api/v2/config"
+	"github.com/video-dev/video-transcoding-api/v2/db"
+	"github.com/video-dev/video-transcoding-api/v2/db/dbtest"
+)
+
+func TestNewLadder(t *testing.T) {
+	tests := []struct {
+		givenTestCase    string
+		givenRequestData map[string]interface{}
+		givenLadders     []db.PresetGroup
+		wantBody         map[string]interface{}
+		wantLadder       *db.PresetGroup
+		wantCode         int
+	}{
+		{
+			"Create new ladder",
+			map[string]interface{}{
+				"providers": []string{"fake"},
+				"ladder": map[string]interface{}{
+					"name":      "myladder",
+					"maxHeight": 720,
+					"rungs": []map[string]interface{}{
+						{"height": 1080, "bitrate": "6M"},
+						{"height": 720, "bitrate": "3000k"},
+						{"height": 360, "bitrate": "800000"},
+					},
+				},
+			},
+			nil,
+			map[string]interface{}{
+				"Results": map[string]interface{}{
+					"myladder_360p_800k": map[string]interface{}{
+						"Results":   map[string]interface{}{"fake": map[string]interface{}{"PresetID": "presetID_here", "Error": ""}},
+						"PresetMap": "myladder_360p_800k",
+					},
+					"myladder_720p_3000k": map[string]interface{}{
+						"Results":   map[string]interface{}{"fake": map[string]interface{}{"PresetID": "presetID_here", "Error": ""}},
+						"PresetMap": "myladder_720p_3000k",
+					},
+				},
+				"Ladder": "myladder",
+			},
+			&db.PresetGroup{
+				Name:            "myladder",
+				Presets:         []string{"myladder_360p_800k", "myladder_720p_3000k"},
+				StreamingParams: db.StreamingParams{Protocol: "hls"},
+				Ladder:          true,
+			},
+			http.StatusOK,
+		},
+		{
+			"Error creating presets in all providers",
+			map[string]interface{}{
+				"providers": []string{"encodingcom"},
+				"ladder": map[string]interface{}{
+					"name":  "myladder",
+					"rungs": []map[string]interface{}{{"height": 360, "bitrate": "800k"}},
+				},
+			},
+			nil,
+			map[string]interface{}{
+				"Results": map[string]interface{}{
+					"myladder_360p_800k": map[string]interface{}{
+						"Results":   map[string]interface{}{"encodingcom": map[string]interface{}{"PresetID": "", "Error": "getting factory: provider not found"}},
+						"PresetMap": "",
+					},
+				},
+				"Ladder": "",
+			},
+			nil,
+			http.StatusInternalServerError,
+		},
+		{
+			"Unknown builtin ladder",
+			map[string]interface{}{
+				"providers": []string{"fake"},
+				"ladder":    map[string]interface{}{"name": "myladder", "builtin": "android"},
+			},
+			nil,
+			map[string]interface{}{
+				"error": `invalid ladder: unknown builtin ladder "android", valid options are apple-hls-h264, apple-hls-hevc`,
+			},
+			nil,
+			http.StatusBadRequest,
+		},
+		{
+			"Missing providers",
+			map[string]interface{}{
+				"ladder": map[string]interface{}{"name": "myladder", "builtin": "apple-hls-h264"},
+			},
+			nil,
+			map[string]interface{}{"error": "missing providers from request"},
+			nil,
+			http.StatusBadRequest,
+		},
+		{
+			"Ladder already exists",
+			map[string]interface{}{
+				"providers": []string{"fake"},
+				"ladder":    map[string]interface{}{"name": "myladder", "builtin": "apple-hls-h264"},
+			},
+			[]db.PresetGroup{{Name: "myladder", Presets: []string{"myladder_360p_800k"}, Ladder: true}},
+			map[string]interface{}{"error": "ladder already exists"},
+			&db.PresetGroup{Name: "myladder", Presets: []string{"myladder_360p_800k"}, Ladder: true},
+			http.StatusConflict,
+		},
+	}
+
+	for _, test := range tests {
+		srvr := server.NewSimpleServer(&server.Config{})
+		fakeDB := dbtest.NewFakeRepository(false)
+		for i := range test.givenLadders {
+			fakeDB.CreatePresetGroup(&test.givenLadders[i])
+		}
+		service, err := NewTranscodingService(&config.Config{Server: &server.Config{}}, logrus.New())
+		if err != nil {
+			t.Fatal(err)
+		}
+		service.db = fakeDB
+		srvr.Register(service)
+		body, _ := json.Marshal(test.givenRequestData)
+		r, _ := http.NewRequest("POST", "/ladders", bytes.NewReader(body))
+		r.Header.Set("Content-Type", "application/json")
+		w := httptest.NewRecorder()
+		srvr.ServeHTTP(w, r)
+		if w.Code != test.wantCode {
+			t.Errorf("%s: wrong response code. Want %d. Got %d", test.givenTestCase, test.wantCode, w.Code)
+		}
+		var got map[string]interface{}
+		err = json.NewDecoder(w.Body).Decode(&got)
+		if err != nil {
+			t.Errorf("%s: unable to JSON decode response body: %s", test.givenTestCase, err)
+		}
+		if !reflect.DeepEqual(got, test.wantBody) {
+			t.Errorf("%s: expected response body of\n%#v;\ngot\n%#v", test.givenTestCase, test.wantBody, got)
+		}
+		ladder, _ := fakeDB.GetPresetGroup("myladder")
+		if !reflect.DeepEqual(ladder, test.wantLadder) {
+			t.Errorf("%s: wrong ladder saved\nwant %#v\ngot  %#v", test.givenTestCase, test.wantLadder, ladder)
+		}
+	}
+}
+
+func TestNewLadderRollback(t *testing.T) {
+	mc := newFakeMediaConvert()
+	defer mc.Close()
+	// the preset of the second rung already exists in mediaconvert, so
+	// creating it fails with a conflict
+	mc.presets["myladder_720p_3000k"] = map[string]interface{}{"name": "myladder_720p_3000k"}
+	fprovider = fakeProvider{}
+	defer func() { fprovider = fakeProvider{} }()
+	fakeDB := dbtest.NewFakeRepository(false)
+	fakeDB.CreatePresetMap(&db.PresetMap{
+		Name:            "myladder_360p_800k",
+		ProviderMapping: map[string]string{"fake": "existing_preset"},
+		OutputOpts:      db.OutputOptions{Extension: "m3u8"},
+	})
+	srvr := server.NewSimpleServer(&server.Config{})
+	service, err := NewTranscodingService(&config.Config{Server: &server.Config{}, MediaConvert: mc.config()}, logrus.New())
+	if err != nil {
+		t.Fatal(err)
+	}
+	service.db = fakeDB
+	srvr.Register(service)
+	body, _ := json.Marshal(map[string]interface{}{
+		"providers": []string{"fake", "mediaconvert"},
+		"ladder": map[string]interface{}{
+			"name": "myladder",
+			"rungs": []map[string]interface{}{
+				{"height": 360, "bitrate": "800k"},
+				{"height": 720, "bitrate": "3000k"},
+			},
+		},
+	})
+	r, _ := http.NewRequest("POST", "/ladders", bytes.NewReader(body))
+	r.Header.Set("Content-Type", "application/json")
+	w := httptest.NewRecorder()
+	srvr.ServeHTTP(w, r)
+	if w.Code != http.StatusInternalServerError {
+		t.Errorf("wrong response code. Want %d. Got %d", http.StatusInternalServerError, w.Code)
+	}
+	var got newLadderOutputs
+	if err = json.NewDecoder(w.Body).Decode(&got); err != nil {
+		t.Fatal(err)
+	}
+	rolledBack := got.Results["myladder_360p_800k"]
+	wantRolledBack := newPresetOutputs{
+		Results: map[string]newPresetOutput{
+			"fake":         {PresetID: "existing_preset"},
+			"mediaconvert": {Error: "rolled back after failures on other rungs"},
+		},
+	}
+	if !reflect.DeepEqual(rolledBack, wantRolledBack) {
+		t.Errorf("wrong results for the rolled back rung\nwant %#v\ngot  %#v", wantRolledBack, rolledBack)
+	}
+	failed := got.Results["myladder_720p_3000k"]
+	if failed.PresetMap != "" || failed.Results["fake"].PresetID != "" || failed.Results["mediaconvert"].Error == "" {
+		t.Errorf("wrong results for the failed rung: %#v", failed)
+	}
+	if got.Ladder != "" {
+		t.Errorf("unexpected ladder created: %q", got.Ladder)
+	}
+	if _, ok := mc.presets["myladder_360p_800k"]; ok {
+		t.Error("preset of the rolled back rung wasn't deleted from mediaconvert")
+	}
+	if !reflect.DeepEqual(fprovider.deletedPresets, []string{"presetID_here"}) {
+		t.Errorf("wrong presets deleted from fake\nwant %v\ngot  %v", []string{"presetID_here"}, fprovider.deletedPresets)
+	}
+	presetMap, err := fakeDB.GetPresetMap("myladder_360p_800k")
+	if err != nil {
+		t.Fatal(err)
+	}
+	wantMapping := map[string]string{"fake": "existing_preset"}
+	if !reflect.DeepEqual(presetMap.ProviderMapping, wantMapping) || presetMap.Preset != nil {
+		t.Errorf("existing presetmap wasn't restored\nwant mapping %v without preset\ngot  %#v", wantMapping, *presetMap)
+	}
+	if _, err = fakeDB.GetPresetMap("myladder_720p_3000k"); err != db.ErrPresetMapNotFound {
+		t.Errorf("presetmap of the failed rung wasn't deleted: %v", err)
+	}
+	if _, err = fakeDB.GetPresetGroup("myladder"); err != db.ErrPresetGroupNotFound {
+		t.Errorf("unexpected ladder stored: %v", err)
+	}
+}
+
+func TestGetLadder(t *testing.T) {
+	tests := []struct {
+		givenTestCase string
+		givenName     string
+
+		wantCode int
+		wantBody map[string]interface{}
+	}{
+		{
+			"Get ladder",
+			"myladder",
+			http.StatusOK,
+			map[string]interface{}{
+				"name":            "myladder",
+				"presets":         []interface{}{"myladder_360p_800k", "myladder_720p_3000k"},
+				"streamingParams": map[string]interface{}{"protocol": "hls", "segmentDuration": float64(0)},
+				"ladder":          true,
+			},
+		},
+		{
+			"Ladder not found",
+			"otherladder",
+			http.StatusNotFound,
+			map[string]interface{}{"error": "ladder not found"},
+		},
+		{
+			"Preset group that isn't a ladder",
+			"mygroup",
+			http.StatusNotFound,
+			map[string]interface{}{"error": "ladder not found"},
+		},
+	}
+	for _, test := range tests {
+		srvr := server.NewSimpleServer(&server.Config{})
+		fakeDB := dbtest.NewFakeRepository(false)
+		fakeDB.CreatePresetGroup(&db.PresetGroup{
+			Name:            "myladder",
+			Presets:         []string{"myladder_360p_800k", "myladder_720p_3000k"},
+			StreamingParams: db.StreamingParams{Protocol: "hls"},
+			Ladder:          true,
+		})
+		fakeDB.CreatePresetGroup(&db.PresetGroup{Name: "mygroup", Presets: []string{"myladder_360p_800k"}})
+		service, err := NewTranscodingService(&config.Config{Server: &server.Config{}}, logrus.New())
+		if err != nil {
+			t.Fatal(err)
+		}
+		service.db = fakeDB
+		srvr.Register(service)
+		r, _ := http.NewRequest("GET", "/ladders/"+test.givenName, nil)
+		w := httptest.NewRecorder()
+		srvr.ServeHTTP(w, r)
+		if w.Code != test.wantCode {
+			t.Errorf("%s: wrong response code. Want %d. Got %d", test.givenTestCase, test.wantCode, w.Code)
+		}
+		var got map[string]interface{}
+		err = json.NewDecoder(w.Body).Decode(&got)
+		if err != nil {
+			t.Errorf("%s: unable to JSON decode response body: %s", test.givenTestCase, err)
+		}
+		if !reflect.DeepEqual(got, test.wantBody) {
+			t.Errorf("%s: expected response body of\n%#v;\ngot\n%#v", test.givenTestCase, test.wantBody, got)
+		}
+	}
+}
+
+func TestListLadders(t *testing.T) {
+	srvr := server.NewSimpleServer(&server.Config{})
+	fakeDB := dbtest.NewFakeRepository(false)
+	ladders := []db.PresetGroup{
+		{Name: "ladder-1", Presets: []string{"ladder-1_360p_800k"}, StreamingParams: db.StreamingParams{Protocol: "hls"}, Ladder: true},
+		{Name: "ladder-2", Presets: []string{"ladder-2_720p_3000k"}, StreamingParams: db.StreamingParams{Protocol: "cmaf"}, Ladder: true},
+		{Name: "group", Presets: []string{"ladder-1_360p_800k"}},
+	}
+	for i := range ladders {
+		fakeDB.CreatePresetGroup(&ladders[i])
+	}
+	service, err := NewTranscodingService(&config.Config{Server: &server.Config{}}, logrus.New())
+	if err != nil {
+		t.Fatal(err)
+	}
+	service.db = fakeDB
+	srvr.Register(service)
+	r, _ := http.NewRequest("GET", "/ladders", nil)
+	w := httptest.NewRecorder()
+	srvr.ServeHTTP(w, r)
+	if w.Code != http.StatusOK {
+		t.Errorf("wrong response code. Want %d. Got %d", http.StatusOK, w.Code)
+	}
+	var got map[string]db.PresetGroup
+	err = json.NewDecoder(w.Body).Decode(&got)
+	if err != nil {
+		t.Fatal(err)
+	}
+	expected := map[string]db.PresetGroup{"ladder-1": ladders[0], "ladder-2": ladders[1]}
+	if !reflect.DeepEqual(got, expected) {
+		t.Errorf("wrong response body\nwant %#v\ngot  %#v", expected, got)
+	}
+}
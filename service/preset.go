@@ -2,9 +2,11 @@ package service
 
 import (
 	"encoding/json"
+	"errors"
 	"fmt"
 	"io/ioutil"
 	"net/http"
+	"sync"
 
 	"github.com/NYTimes/gizmo/server"
 	"github.com/video-dev/video-transcoding-api/v2/db"
@@ -12,18 +14,337 @@ import (
 	"github.com/video-dev/video-transcoding-api/v2/swagger"
 )
 
+// swagger:route GET /presets/{name} presets getProviderPresets
+//
+// Finds a preset by name and retrieves it from each provider it's mapped to.
+//
+//     Responses:
+//       200: providerPresets
+//       404: presetNotFound
+//       500: genericError
+func (s *TranscodingService) getPreset(r *http.Request) swagger.GizmoJSONResponse {
+	var params getPresetMapInput
+	params.loadParams(server.Vars(r))
+
+	presetMap, err := s.db.GetPresetMap(params.Name)
+	switch err {
+	case nil:
+	case db.ErrPresetMapNotFound:
+		return newPresetMapNotFoundResponse(err)
+	default:
+		return swagger.NewErrorResponse(err)
+	}
+
+	output := getPresetOutputs{
+		Name:       presetMap.Name,
+		Preset:     s.canonicalPreset(*presetMap),
+		OutputOpts: presetMap.OutputOpts,
+		Version:    presetMap.Version,
+		Results:    make(map[string]getPresetOutput, len(presetMap.ProviderMapping)),
+	}
+
+	type providerResult struct {
+		provider string
+		output   getPresetOutput
+	}
+	resultCh := make(chan providerResult, len(presetMap.ProviderMapping))
+	var wg sync.WaitGroup
+	for p, presetID := range presetMap.ProviderMapping {
+		wg.Add(1)
+		go func(p, presetID string) {
+			defer wg.Done()
+			resultCh <- providerResult{provider: p, output: s.getProviderPreset(p, presetID)}
+		}(p, presetID)
+	}
+	wg.Wait()
+	close(resultCh)
+	for res := range resultCh {
+		output.Results[res.provider] = res.output
+	}
+
+	return &getPresetResponse{
+		baseResponse: baseResponse{
+			payload: output,
+			status:  http.StatusOK,
+		},
+	}
+}
+
+// getProviderPreset retrieves the provider-native representation of the
+// given preset.
+func (s *TranscodingService) getProviderPreset(p, presetID string) getPresetOutput {
+	providerFactory, err := provider.GetProviderFactory(p)
+	if err != nil {
+		return getPresetOutput{PresetID: presetID, Error: "getting factory: " + err.Error()}
+	}
+	providerObj, err := providerFactory(s.config)
+	if err != nil {
+		return getPresetOutput{PresetID: presetID, Error: "initializing provider: " + err.Error()}
+	}
+	preset, err := providerObj.GetPreset(presetID)
+	if err != nil {
+		return getPresetOutput{PresetID: presetID, Error: "getting preset: " + err.Error()}
+	}
+	return getPresetOutput{PresetID: presetID, Preset: preset}
+}
+
+// swagger:route PUT /presets/{name} presets updateProviderPresets
+//
+// Replaces a preset on every provider it's mapped to. The replacement presets
+// are created before the presetmap is updated, and the previous presets are
+// deleted only after that. Providers that key presets by name, like
+// Zencoder, have their presets updated in place instead. If the preset can't
+// be created on any of the providers, the change is rolled back.
+//
+// Presets inheriting from the replaced preset are listed as affected, as
+// they keep the settings merged when they were created or last replaced.
+//
+//     Responses:
+//       200: updatePresetOutputs
+//       400: invalidPreset
+//       404: presetNotFound
+//       500: updatePresetOutputs
+func (s *TranscodingService) updatePreset(r *http.Request) swagger.GizmoJSONResponse {
+	defer r.Body.Close()
+	var params getPresetMapInput
+	params.loadParams(server.Vars(r))
+
+	var input updatePresetInput
+	err := json.NewDecoder(r.Body).Decode(&input)
+	if err != nil {
+		return newInvalidPresetResponse(err)
+	}
+	if input.Preset.Name == "" {
+		input.Preset.Name = params.Name
+	}
+	if input.Preset.Name != params.Name {
+		return newInvalidPresetResponse(fmt.Errorf("invalid preset: name %q doesn't match %q", input.Preset.Name, params.Name))
+	}
+
+	presetMap, err := s.db.GetPresetMap(params.Name)
+	switch err {
+	case nil:
+	case db.ErrPresetMapNotFound:
+		return newPresetMapNotFoundResponse(err)
+	default:
+		return swagger.NewErrorResponse(err)
+	}
+	input.Preset, err = s.resolvePreset(input.Preset)
+	if err != nil {
+		return newInvalidPresetResponse(fmt.Errorf("invalid preset: %s", err))
+	}
+	affectedPresets, err := s.descendantPresets(params.Name)
+	if err != nil {
+		return swagger.NewErrorResponse(err)
+	}
+
+	providers := make([]string, 0, len(presetMap.ProviderMapping))
+	for p := range presetMap.ProviderMapping {
+		providers = append(providers, p)
+	}
+	preset, errResp := validateNewPreset(input.Preset, providers)
+	if errResp != nil {
+		return errResp
+	}
+
+	updatedMap := db.PresetMap{
+		Name:            presetMap.Name,
+		ProviderMapping: make(map[string]string, len(presetMap.ProviderMapping)),
+		OutputOpts:      presetMap.OutputOpts,
+		Preset:          &preset,
+	}
+	if input.OutputOptions != nil {
+		updatedMap.OutputOpts = *input.OutputOptions
+	}
+	updatedMap.OutputOpts.Extension = preset.Container
+	if err = updatedMap.OutputOpts.Validate(); err != nil {
+		return newInvalidPresetResponse(fmt.Errorf("invalid outputOptions: %s", err))
+	}
+
+	previousPreset := s.canonicalPreset(*presetMap)
+	output := updatePresetOutputs{Results: make(map[string]updatePresetOutput, len(providers))}
+	providerObjs := make(map[string]provider.TranscodingProvider, len(providers))
+	var failed bool
+	for _, p := range providers {
+		result := updatePresetOutput{PreviousPresetID: presetMap.ProviderMapping[p]}
+		providerObj, presetID, ierr := s.replaceProviderPreset(p, result.PreviousPresetID, preset)
+		if ierr != nil {
+			result.Error = ierr.Error()
+			failed = true
+		} else {
+			providerObjs[p] = providerObj
+			updatedMap.ProviderMapping[p] = presetID
+			result.PresetID = presetID
+		}
+		output.Results[p] = result
+	}
+
+	if !failed {
+		err = s.db.UpdatePresetMap(&updatedMap)
+		if err != nil {
+			for p, result := range output.Results {
+				result.Error = "updating presetmap: " + err.Error()
+				output.Results[p] = result
+			}
+			failed = true
+		}
+	}
+
+	if failed {
+		for p, providerObj := range providerObjs {
+			result := output.Results[p]
+			if result.Error == "" {
+				result.Error = "rolled back after failures on other providers"
+			}
+			if ierr := rollbackProviderPreset(providerObj, result.PresetID, result.PreviousPresetID, previousPreset); ierr != nil {
+				result.Error += ", " + ierr.Error()
+			}
+			result.PresetID = ""
+			output.Results[p] = result
+		}
+		return &updatePresetResponse{
+			baseResponse: baseResponse{
+				payload: output,
+				status:  http.StatusInternalServerError,
+			},
+		}
+	}
+
+	for p, providerObj := range providerObjs {
+		result := output.Results[p]
+		if result.PresetID == result.PreviousPresetID {
+			continue
+		}
+		if ierr := providerObj.DeletePreset(result.PreviousPresetID); ierr != nil {
+			result.Error = "deleting previous preset: " + ierr.Error()
+			output.Results[p] = result
+		}
+	}
+	output.PresetMap = updatedMap.Name
+	output.AffectedPresets = affectedPresets
+	return &updatePresetResponse{
+		baseResponse: baseResponse{
+			payload: output,
+			status:  http.StatusOK,
+		},
+	}
+}
+
+// createProviderPreset creates the given preset on the provider, returning
+// the provider along with the id of the new preset.
+func (s *TranscodingService) createProviderPreset(p string, preset db.Preset) (provider.TranscodingProvider, string, error) {
+	providerObj, err := s.presetProvider(p, preset)
+	if err != nil {
+		return nil, "", err
+	}
+	presetID, err := providerObj.CreatePreset(preset)
+	if err != nil {
+		return nil, "", fmt.Errorf("creating preset: %s", err)
+	}
+	return providerObj, presetID, nil
+}
+
+// replaceProviderPreset creates the replacement of the preset with the given
+// id on the provider, returning the provider along with the id of the
+// replacement. Providers that key presets by name update the preset in
+// place, so the id of the replacement is the id of the previous preset.
+func (s *TranscodingService) replaceProviderPreset(p, presetID string, preset db.Preset) (provider.TranscodingProvider, string, error) {
+	providerObj, err := s.presetProvider(p, preset)
+	if err != nil {
+		return nil, "", err
+	}
+	if updater, ok := providerObj.(provider.PresetUpdater); ok && presetID != "" {
+		presetID, err = updater.UpdatePreset(presetID, preset)
+		if err != nil {
+			return nil, "", fmt.Errorf("updating preset: %s", err)
+		}
+		return providerObj, presetID, nil
+	}
+	presetID, err = providerObj.CreatePreset(preset)
+	if err != nil {
+		return nil, "", fmt.Errorf("creating preset: %s", err)
+	}
+	return providerObj, presetID, nil
+}
+
+// rollbackProviderPreset undoes replaceProviderPreset. Replacements created
+// next to the previous preset are deleted, and presets updated in place get
+// the previous settings back.
+func rollbackProviderPreset(providerObj provider.TranscodingProvider, presetID, previousPresetID string, previous *db.Preset) error {
+	if presetID != previousPresetID {
+		if err := providerObj.DeletePreset(presetID); err != nil {
+			return fmt.Errorf("deleting preset: %s", err)
+		}
+		return nil
+	}
+	updater, ok := providerObj.(provider.PresetUpdater)
+	if !ok || previous == nil {
+		return errors.New("restoring previous preset: previous settings are unknown")
+	}
+	if _, err := updater.UpdatePreset(presetID, *previous); err != nil {
+		return fmt.Errorf("restoring previous preset: %s", err)
+	}
+	return nil
+}
+
+// presetProvider initializes the given provider for creating the preset,
+// checking that the provider supports the settings of the preset.
+func (s *TranscodingService) presetProvider(p string, preset db.Preset) (provider.TranscodingProvider, error) {
+	providerFactory, err := provider.GetProviderFactory(p)
+	if err != nil {
+		return nil, fmt.Errorf("getting factory: %s", err)
+	}
+	providerObj, err := providerFactory(s.config)
+	if err != nil {
+		return nil, fmt.Errorf("initializing provider: %s", err)
+	}
+	if err = checkPresetCapabilities(providerObj, preset); err != nil {
+		return nil, fmt.Errorf("creating preset: %s", err)
+	}
+	return providerObj, nil
+}
+
+// checkPresetCapabilities checks the preset against the capabilities of the
+// provider, which apply to every provider regardless of its own validation.
+func checkPresetCapabilities(providerObj provider.TranscodingProvider, preset db.Preset) error {
+	capabilities := providerObj.Capabilities()
+	if preset.Video.HasColorSettings() && !capabilities.HDR {
+		return errors.New("bit depth, color space and hdr settings are not supported by the provider")
+	}
+	if !capabilities.SupportsVideoCodec(preset.Video.Codec) {
+		return fmt.Errorf("video codec %q is not supported by the provider", preset.Video.Codec)
+	}
+	if !capabilities.SupportsAudioCodec(preset.Audio.Codec) {
+		return fmt.Errorf("audio codec %q is not supported by the provider", preset.Audio.Codec)
+	}
+	return nil
+}
+
 // swagger:route DELETE /presets/{name} presets deletePreset
 //
-// Deletes a preset by name.
+// Deletes a preset by name. Presets used by jobs that haven't finished yet
+// are only deleted when force is set.
 //
 //     Responses:
 //       200: deletePresetOutputs
+//       400: invalidPreset
 //       404: presetNotFound
+//       409: presetInUse
 //       500: genericError
+//       503: presetUsageUnknown
 func (s *TranscodingService) deletePreset(r *http.Request) swagger.GizmoJSONResponse {
 	var output deletePresetOutputs
 	var params getPresetMapInput
 	params.loadParams(server.Vars(r))
+	var input deletePresetInput
+	if err := input.loadParams(r.URL.Query()); err != nil {
+		return newInvalidPresetResponse(err)
+	}
+	if !input.Force {
+		if errResp := s.checkPresetNotInUse(params.Name); errResp != nil {
+			return errResp
+		}
+	}
 
 	output.Results = make(map[string]deletePresetOutput)
 
@@ -31,6 +352,10 @@ func (s *TranscodingService) deletePreset(r *http.Request) swagger.GizmoJSONResp
 	if err != nil {
 		output.PresetMap = "couldn't retrieve: " + err.Error()
 	} else {
+		output.AffectedPresets, err = s.descendantPresets(params.Name)
+		if err != nil {
+			return swagger.NewErrorResponse(err)
+		}
 		for p, presetID := range presetmap.ProviderMapping {
 			providerFactory, ierr := provider.GetProviderFactory(p)
 			if ierr != nil {
@@ -66,7 +391,8 @@ func (s *TranscodingService) deletePreset(r *http.Request) swagger.GizmoJSONResp
 
 // swagger:route POST /presets presets Output
 //
-// Creates a new preset on given providers.
+// Creates a new preset on given providers. Presets with a base are merged
+// with their base presets before being created.
 //     Responses:
 //       200: newPresetOutputs
 //       400: invalidPreset
@@ -74,10 +400,6 @@ func (s *TranscodingService) deletePreset(r *http.Request) swagger.GizmoJSONResp
 func (s *TranscodingService) newPreset(r *http.Request) swagger.GizmoJSONResponse {
 	defer r.Body.Close()
 	var input newPresetInput
-	var output newPresetOutputs
-	var presetMap *db.PresetMap
-	var providers []string
-	var shouldCreatePresetMap bool
 
 	respData, err := ioutil.ReadAll(r.Body)
 	if err != nil {
@@ -89,27 +411,106 @@ func (s *TranscodingService) newPreset(r *http.Request) swagger.GizmoJSONRespons
 		return swagger.NewErrorResponse(err)
 	}
 
+	input.Preset, err = s.resolvePreset(input.Preset)
+	if err != nil {
+		return newInvalidPresetResponse(fmt.Errorf("invalid preset: %s", err))
+	}
+
+	preset, errResp := validateNewPreset(input.Preset, input.Providers)
+	if errResp != nil {
+		return errResp
+	}
+
+	output, errResp := s.createPreset(preset, input.OutputOptions, input.Providers)
+	if errResp != nil {
+		return errResp
+	}
+
+	status := http.StatusOK
+	if output.PresetMap == "" {
+		status = http.StatusInternalServerError
+	}
+
+	return &newPresetResponse{
+		baseResponse: baseResponse{
+			payload: output,
+			status:  status,
+		},
+	}
+}
+
+// validateNewPreset normalizes the given preset and checks its settings before
+// creating it on the given providers.
+func validateNewPreset(preset db.Preset, providers []string) (db.Preset, swagger.GizmoJSONResponse) {
+	preset, err := normalizePreset(preset, providers)
+	if verr, ok := err.(*db.ValidationError); ok {
+		return preset, newInvalidPresetFieldsResponse(verr)
+	}
+	if err != nil {
+		return preset, newInvalidPresetResponse(err)
+	}
+	return preset, nil
+}
+
+// normalizePreset normalizes the given preset and checks its settings,
+// returning a *db.ValidationError when any of the preset fields is invalid.
+func normalizePreset(preset db.Preset, providers []string) (db.Preset, error) {
+	preset, err := preset.Normalize()
+	if err != nil {
+		return preset, err
+	}
+
+	if err = preset.Video.Validate(); err != nil {
+		return preset, fmt.Errorf("invalid video preset: %s", err)
+	}
+
+	if err = preset.Audio.Validate(); err != nil {
+		return preset, fmt.Errorf("invalid audio preset: %s", err)
+	}
+
+	if err = preset.ValidateContainer(); err != nil {
+		return preset, fmt.Errorf("invalid preset: %s", err)
+	}
+
+	if err = preset.ProviderOverrides.Validate(providers); err != nil {
+		return preset, fmt.Errorf("invalid preset: %s", err)
+	}
+	return preset, nil
+}
+
+// createPreset creates the preset on the given providers and stores the
+// resulting PresetMap. The PresetMap field of the output is empty when the
+// preset couldn't be created on any provider.
+func (s *TranscodingService) createPreset(preset db.Preset, outputOpts db.OutputOptions, inputProviders []string) (newPresetOutputs, swagger.GizmoJSONResponse) {
+	var output newPresetOutputs
+	var providers []string
+	var shouldCreatePresetMap bool
+
 	output.Results = make(map[string]newPresetOutput)
 
 	// Sometimes we try to create a new preset in a new provider but we already
 	// have the PresetMap stored. We want to update the PresetMap in such cases.
-	presetMap, err = s.db.GetPresetMap(input.Preset.Name)
+	presetMap, err := s.db.GetPresetMap(preset.Name)
 	if err == db.ErrPresetMapNotFound {
-		presetMap = &db.PresetMap{Name: input.Preset.Name}
-		presetMap.OutputOpts = input.OutputOptions
-		presetMap.OutputOpts.Extension = input.Preset.Container
+		presetMap = &db.PresetMap{Name: preset.Name}
+		presetMap.OutputOpts = outputOpts
+		presetMap.OutputOpts.Extension = preset.Container
 		presetMap.ProviderMapping = make(map[string]string)
+		presetMap.Preset = &preset
 		if err = presetMap.OutputOpts.Validate(); err != nil {
-			return newInvalidPresetResponse(fmt.Errorf("invalid outputOptions: %s", err))
+			return output, newInvalidPresetResponse(fmt.Errorf("invalid outputOptions: %s", err))
 		}
 		shouldCreatePresetMap = true
-		providers = input.Providers
+		providers = inputProviders
 	} else if err != nil {
-		return swagger.NewErrorResponse(err)
+		return output, swagger.NewErrorResponse(err)
 	} else {
 		// If we already have a PresetMap for this preset, we just need to create the
 		// preset on the providers that are not mapped yet.
-		providers = s.getMissingProviders(input.Providers, presetMap.ProviderMapping)
+		providers = s.getMissingProviders(inputProviders, presetMap.ProviderMapping)
+		if presetMap.Preset == nil {
+			presetMap.Preset = &preset
+		}
 
 		// We also want to add the existent presets on the result.
 		for provider, presetID := range presetMap.ProviderMapping {
@@ -118,26 +519,15 @@ func (s *TranscodingService) newPreset(r *http.Request) swagger.GizmoJSONRespons
 	}
 
 	for _, p := range providers {
-		providerFactory, ierr := provider.GetProviderFactory(p)
-		if ierr != nil {
-			output.Results[p] = newPresetOutput{PresetID: "", Error: "getting factory: " + ierr.Error()}
-			continue
-		}
-		providerObj, ierr := providerFactory(s.config)
-		if ierr != nil {
-			output.Results[p] = newPresetOutput{PresetID: "", Error: "initializing provider: " + ierr.Error()}
-			continue
-		}
-		presetID, ierr := providerObj.CreatePreset(input.Preset)
+		_, presetID, ierr := s.createProviderPreset(p, preset)
 		if ierr != nil {
-			output.Results[p] = newPresetOutput{PresetID: "", Error: "creating preset: " + ierr.Error()}
+			output.Results[p] = newPresetOutput{PresetID: "", Error: ierr.Error()}
 			continue
 		}
 		presetMap.ProviderMapping[p] = presetID
 		output.Results[p] = newPresetOutput{PresetID: presetID, Error: ""}
 	}
 
-	status := http.StatusOK
 	if len(presetMap.ProviderMapping) > 0 {
 		if shouldCreatePresetMap {
 			err = s.db.CreatePresetMap(presetMap)
@@ -145,19 +535,26 @@ func (s *TranscodingService) newPreset(r *http.Request) swagger.GizmoJSONRespons
 			err = s.db.UpdatePresetMap(presetMap)
 		}
 		if err != nil {
-			return newInvalidPresetResponse(fmt.Errorf("failed creating/updating presetmap after creating presets: %s", err))
+			return output, newInvalidPresetResponse(fmt.Errorf("failed creating/updating presetmap after creating presets: %s", err))
 		}
 		output.PresetMap = presetMap.Name
-	} else {
-		status = http.StatusInternalServerError
 	}
+	return output, nil
+}
 
-	return &newPresetResponse{
-		baseResponse: baseResponse{
-			payload: output,
-			status:  status,
-		},
+// canonicalPreset returns the preset the given presetmap was created from.
+// Presetmaps created before presets were stored with them fall back to the
+// providers that store presets locally, so it's nil for presetmaps that
+// aren't mapped to any of them.
+func (s *TranscodingService) canonicalPreset(presetMap db.PresetMap) *db.Preset {
+	if presetMap.Preset != nil {
+		return presetMap.Preset
+	}
+	localPreset, err := s.db.GetLocalPreset(presetMap.Name)
+	if err != nil {
+		return nil
 	}
+	return &localPreset.Preset
 }
 
 // getMissingProviders will check what providers already have a preset associated to it
@@ -1,6 +1,10 @@
 package service
 
 import (
+	"fmt"
+	"net/url"
+	"strconv"
+
 	"github.com/video-dev/video-transcoding-api/v2/db"
 )
 
@@ -26,6 +30,30 @@ type newPresetOutput struct {
 	Error    string
 }
 
+type updatePresetInput struct {
+	Preset        db.Preset         `json:"preset"`
+	OutputOptions *db.OutputOptions `json:"outputOptions"`
+}
+
+// list of the results of the attempt to replace a preset in each provider.
+//
+// swagger:response updatePresetOutputs
+type updatePresetOutputs struct {
+	// in: body
+	// required: true
+	Results   map[string]updatePresetOutput `json:"results"`
+	PresetMap string                        `json:"presetMap"`
+
+	// presets that inherit from the replaced preset
+	AffectedPresets []string `json:"affectedPresets,omitempty"`
+}
+
+type updatePresetOutput struct {
+	PresetID         string `json:"presetId"`
+	PreviousPresetID string `json:"previousPresetId,omitempty"`
+	Error            string `json:"error,omitempty"`
+}
+
 // list of the results of the attempt to delete a preset
 // in each provider.
 //
@@ -35,9 +63,225 @@ type deletePresetOutputs struct {
 	// required: true
 	Results   map[string]deletePresetOutput `json:"results"`
 	PresetMap string                        `json:"presetMap"`
+
+	// presets that inherit from the deleted preset
+	AffectedPresets []string `json:"affectedPresets,omitempty"`
 }
 
 type deletePresetOutput struct {
 	PresetID string `json:"presetId"`
 	Error    string `json:"error,omitempty"`
 }
+
+// canonical preset along with the representation of the preset stored in
+// each provider.
+//
+// swagger:response providerPresets
+type getPresetOutputs struct {
+	// in: body
+	// required: true
+	Name       string                     `json:"name"`
+	Preset     *db.Preset                 `json:"preset,omitempty"`
+	OutputOpts db.OutputOptions           `json:"output"`
+	Version    uint                       `json:"version,omitempty"`
+	Results    map[string]getPresetOutput `json:"results"`
+}
+
+type getPresetOutput struct {
+	PresetID string      `json:"presetId"`
+	Preset   interface{} `json:"preset,omitempty"`
+	Error    string      `json:"error,omitempty"`
+}
+
+type reconcilePresetInput struct {
+	Recreate bool `json:"recreate"`
+}
+
+// comparison of the canonical preset with the preset stored in each
+// provider.
+//
+// swagger:response presetReconciliation
+type presetReconciliation struct {
+	// in: body
+	// required: true
+	Name    string                                  `json:"name"`
+	Results map[string]providerPresetReconciliation `json:"results"`
+}
+
+type providerPresetReconciliation struct {
+	PresetID          string                `json:"presetId"`
+	Status            string                `json:"status"`
+	Differences       []db.PresetDifference `json:"differences,omitempty"`
+	RecreatedPresetID string                `json:"recreatedPresetId,omitempty"`
+	Error             string                `json:"error,omitempty"`
+}
+
+// swagger:parameters importPresets
+type importPresetsInput struct {
+	// in: path
+	// required: true
+	Name string `json:"name"`
+
+	// in: body
+	Payload importPresetsOptions
+}
+
+type importPresetsOptions struct {
+	// DryRun reports the presetmaps that would be created, without
+	// creating them.
+	DryRun bool `json:"dryRun"`
+
+	// Names filters the presets to import by their IDs on the provider,
+	// using shell patterns like "mp4_*".
+	Names []string `json:"names"`
+}
+
+func (p *importPresetsInput) loadParams(paramsMap map[string]string) {
+	p.Name = paramsMap["name"]
+}
+
+// result of the import of each preset listed on the provider, keyed by the ID
+// of the preset on the provider.
+//
+// swagger:response importPresetsOutputs
+type importPresetsOutputs struct {
+	// in: body
+	// required: true
+	Provider string                        `json:"provider"`
+	DryRun   bool                          `json:"dryRun"`
+	Results  map[string]importPresetOutput `json:"results"`
+}
+
+type importPresetOutput struct {
+	PresetMap string     `json:"presetMap,omitempty"`
+	Status    string     `json:"status"`
+	Preset    *db.Preset `json:"preset,omitempty"`
+	Error     string     `json:"error,omitempty"`
+}
+
+// presetmaps along with their canonical presets, as exported and applied by
+// the export and apply endpoints.
+//
+// swagger:response presetManifest
+type presetManifest struct {
+	// in: body
+	// required: true
+	PresetMaps []db.PresetMap `json:"presetMaps"`
+}
+
+// swagger:parameters exportPresets
+type exportPresetsInput struct {
+	// format of the exported file, either json (the default) or yaml
+	//
+	// in: query
+	Format string `json:"format"`
+}
+
+func (p *exportPresetsInput) loadParams(query url.Values) {
+	p.Format = query.Get("format")
+}
+
+// swagger:parameters applyPresets
+type applyPresetsInput struct {
+	// returns the plan without applying it
+	//
+	// in: query
+	DryRun bool `json:"dryRun"`
+
+	// deletes the presetmaps that aren't listed in the file
+	//
+	// in: query
+	Prune bool `json:"prune"`
+
+	// prunes presetmaps even when they're used by jobs that haven't
+	// finished yet
+	//
+	// in: query
+	Force bool `json:"force"`
+
+	// file in the format returned by the export endpoint, either in JSON
+	// or YAML. The IDs in the provider mappings are ignored, only the
+	// provider names are used.
+	//
+	// in: body
+	// required: true
+	Payload presetManifest
+}
+
+func (p *applyPresetsInput) loadParams(query url.Values) error {
+	var err error
+	if p.DryRun, err = boolParam(query, "dryRun"); err != nil {
+		return err
+	}
+	if p.Prune, err = boolParam(query, "prune"); err != nil {
+		return err
+	}
+	p.Force, err = boolParam(query, "force")
+	return err
+}
+
+// boolParam parses the given query string parameter, which is false when
+// it's not set.
+func boolParam(query url.Values, name string) (bool, error) {
+	value := query.Get(name)
+	if value == "" {
+		return false, nil
+	}
+	parsed, err := strconv.ParseBool(value)
+	if err != nil {
+		return false, fmt.Errorf("invalid %s: %q is not a boolean", name, value)
+	}
+	return parsed, nil
+}
+
+// plan of the changes required to converge the stored presetmaps to the
+// applied file, keyed by presetmap name. When not running in dry-run mode,
+// it also includes the results of applying the changes.
+//
+// swagger:response applyPresetsOutputs
+type applyPresetsOutputs struct {
+	// in: body
+	// required: true
+	DryRun  bool                         `json:"dryRun"`
+	Prune   bool                         `json:"prune"`
+	Results map[string]applyPresetOutput `json:"results"`
+}
+
+type applyPresetOutput struct {
+	Action          string                               `json:"action"`
+	Changes         []string                             `json:"changes,omitempty"`
+	Providers       map[string]applyProviderPresetOutput `json:"providers"`
+	AffectedPresets []string                             `json:"affectedPresets,omitempty"`
+	Error           string                               `json:"error,omitempty"`
+}
+
+type applyProviderPresetOutput struct {
+	Action           string `json:"action"`
+	PresetID         string `json:"presetId,omitempty"`
+	PreviousPresetID string `json:"previousPresetId,omitempty"`
+	Error            string `json:"error,omitempty"`
+}
+
+// validatePresetInput is the body of validatePreset requests. Providers
+// default to the configured providers.
+type validatePresetInput struct {
+	Providers []string  `json:"providers"`
+	Preset    db.Preset `json:"preset"`
+}
+
+// results of checking whether a preset can be created on each provider,
+// keyed by provider name. Valid is only set when the preset can be created
+// on all of them.
+//
+// swagger:response validatePresetOutputs
+type validatePresetOutputs struct {
+	// in: body
+	// required: true
+	Valid   bool                            `json:"valid"`
+	Results map[string]validatePresetOutput `json:"results"`
+}
+
+type validatePresetOutput struct {
+	Status string `json:"status"`
+	Error  string `json:"error,omitempty"`
+}
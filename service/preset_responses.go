@@ -1,8 +1,10 @@
 package service
 
 import (
+	"fmt"
 	"net/http"
 
+	"github.com/video-dev/video-transcoding-api/v2/db"
 	"github.com/video-dev/video-transcoding-api/v2/swagger"
 )
 
@@ -14,18 +16,67 @@ type deletePresetResponse struct {
 	baseResponse
 }
 
+type getPresetResponse struct {
+	baseResponse
+}
+
+type updatePresetResponse struct {
+	baseResponse
+}
+
+type reconcilePresetResponse struct {
+	baseResponse
+}
+
+type importPresetsResponse struct {
+	baseResponse
+}
+
+type applyPresetsResponse struct {
+	baseResponse
+}
+
+type validatePresetResponse struct {
+	baseResponse
+}
+
 // error returned when the given preset data is not valid.
 //
 // swagger:response invalidPreset
 type invalidPresetResponse struct {
 	// in: body
 	Error *swagger.ErrorResponse
+
+	fields []db.FieldError
 }
 
 func newInvalidPresetResponse(err error) *invalidPresetResponse {
 	return &invalidPresetResponse{Error: swagger.NewErrorResponse(err).WithStatus(http.StatusBadRequest)}
 }
 
+// newInvalidPresetFieldsResponse reports the invalid fields of the preset
+// along with the error message.
+func newInvalidPresetFieldsResponse(err *db.ValidationError) *invalidPresetResponse {
+	resp := newInvalidPresetResponse(fmt.Errorf("invalid preset: %s", err))
+	resp.fields = err.Fields
+	return resp
+}
+
 func (r *invalidPresetResponse) Result() (int, interface{}, error) {
-	return r.Error.Result()
+	if len(r.fields) == 0 {
+		return r.Error.Result()
+	}
+	status, _, _ := r.Error.Result()
+	return status, nil, &presetFieldsError{Message: r.Error.Message, Fields: r.fields}
+}
+
+// presetFieldsError is the body of invalidPreset responses caused by invalid
+// preset fields.
+type presetFieldsError struct {
+	Message string          `json:"error"`
+	Fields  []db.FieldError `json:"fields"`
+}
+
+func (e *presetFieldsError) Error() string {
+	return e.Message
 }
@@ -13,6 +13,7 @@ import (
 	"github.com/video-dev/video-transcoding-api/v2/config"
 	"github.com/video-dev/video-transcoding-api/v2/db"
 	"github.com/video-dev/video-transcoding-api/v2/db/dbtest"
+	"github.com/video-dev/video-transcoding-api/v2/internal/provider"
 )
 
 func TestNewPreset(t *testing.T) {
@@ -113,6 +114,198 @@ func TestNewPreset(t *testing.T) {
 			},
 			http.StatusInternalServerError,
 		},
+		{
+			"Color settings on a provider without hdr support",
+			map[string]interface{}{
+				"providers": []string{"fake"},
+				"outputOptions": map[string]interface{}{
+					"extension": "mp4",
+				},
+				"preset": map[string]interface{}{
+					"name":      "nyt_test_here_hdr",
+					"container": "mp4",
+					"video": map[string]string{
+						"height":  "2160",
+						"codec":   "hevc",
+						"profile": "main10",
+						"bitrate": "16000000",
+						"gopSize": "90",
+						"hdr":     "hdr10",
+					},
+					"audio": map[string]string{
+						"codec":   "aac",
+						"bitrate": "64000",
+					},
+				},
+			},
+			db.OutputOptions{},
+			map[string]interface{}{
+				"Results": map[string]interface{}{
+					"fake": map[string]interface{}{
+						"PresetID": "",
+						"Error":    "creating preset: bit depth, color space and hdr settings are not supported by the provider",
+					},
+				},
+				"PresetMap": "",
+			},
+			http.StatusInternalServerError,
+		},
+		{
+			"Invalid audio settings",
+			map[string]interface{}{
+				"providers": []string{"fake"},
+				"outputOptions": map[string]interface{}{
+					"extension": "mp4",
+				},
+				"preset": map[string]interface{}{
+					"name":      "nyt_test_here_4wq",
+					"container": "mp4",
+					"video": map[string]string{
+						"height":  "720",
+						"codec":   "h264",
+						"bitrate": "1000",
+						"gopSize": "90",
+					},
+					"audio": map[string]string{
+						"codec":         "aac",
+						"bitrate":       "64000",
+						"channelLayout": "7.1",
+					},
+				},
+			},
+			db.OutputOptions{},
+			map[string]interface{}{
+				"error": `invalid audio preset: channel layout "7.1" is not supported`,
+			},
+			http.StatusBadRequest,
+		},
+		{
+			"Invalid hevc settings",
+			map[string]interface{}{
+				"providers": []string{"fake"},
+				"outputOptions": map[string]interface{}{
+					"extension": "mp4",
+				},
+				"preset": map[string]interface{}{
+					"name":      "nyt_test_here_5wq",
+					"container": "mp4",
+					"video": map[string]string{
+						"height":  "720",
+						"codec":   "hevc",
+						"profile": "main12",
+						"bitrate": "1000",
+						"gopSize": "90",
+					},
+					"audio": map[string]string{
+						"codec":   "aac",
+						"bitrate": "64000",
+					},
+				},
+			},
+			db.OutputOptions{},
+			map[string]interface{}{
+				"error": `invalid video preset: hevc profile "main12" is not supported`,
+			},
+			http.StatusBadRequest,
+		},
+		{
+			"Codecs unsupported by the container",
+			map[string]interface{}{
+				"providers": []string{"fake"},
+				"outputOptions": map[string]interface{}{
+					"extension": "webm",
+				},
+				"preset": map[string]interface{}{
+					"name":      "nyt_test_here_6wq",
+					"container": "webm",
+					"video": map[string]string{
+						"height":  "720",
+						"codec":   "vp9",
+						"bitrate": "1000",
+						"gopSize": "90",
+					},
+					"audio": map[string]string{
+						"codec":   "aac",
+						"bitrate": "64000",
+					},
+				},
+			},
+			db.OutputOptions{},
+			map[string]interface{}{
+				"error": `invalid preset: audio codec "aac" is not supported with the webm container`,
+			},
+			http.StatusBadRequest,
+		},
+		{
+			"Invalid numeric fields",
+			map[string]interface{}{
+				"providers": []string{"fake"},
+				"outputOptions": map[string]interface{}{
+					"extension": "mp4",
+				},
+				"preset": map[string]interface{}{
+					"name":      "nyt_test_here_7wq",
+					"container": "mp4",
+					"video": map[string]string{
+						"height":  "721",
+						"codec":   "h264",
+						"bitrate": "2.5M",
+						"gopSize": "2s",
+					},
+					"audio": map[string]string{
+						"codec":   "aac",
+						"bitrate": "64k",
+					},
+				},
+			},
+			db.OutputOptions{},
+			map[string]interface{}{
+				"error": `invalid preset: video.gopSize: gop size "2s" in seconds requires a frame rate; video.height: "721" must be an even number`,
+				"fields": []interface{}{
+					map[string]interface{}{
+						"field":   "video.gopSize",
+						"message": `gop size "2s" in seconds requires a frame rate`,
+					},
+					map[string]interface{}{
+						"field":   "video.height",
+						"message": `"721" must be an even number`,
+					},
+				},
+			},
+			http.StatusBadRequest,
+		},
+		{
+			"Overrides for a provider that isn't in the list",
+			map[string]interface{}{
+				"providers": []string{"fake"},
+				"outputOptions": map[string]interface{}{
+					"extension": "mp4",
+				},
+				"preset": map[string]interface{}{
+					"name":      "nyt_test_here_8wq",
+					"container": "mp4",
+					"video": map[string]string{
+						"height":  "720",
+						"codec":   "h264",
+						"bitrate": "1000",
+						"gopSize": "90",
+					},
+					"audio": map[string]string{
+						"codec":   "aac",
+						"bitrate": "64000",
+					},
+					"providerOverrides": map[string]interface{}{
+						"fake":     map[string]interface{}{"speed": 2},
+						"zencoder": map[string]interface{}{"speed": 2},
+					},
+				},
+			},
+			db.OutputOptions{},
+			map[string]interface{}{
+				"error": `invalid preset: providerOverrides: unexpected provider "zencoder"`,
+			},
+			http.StatusBadRequest,
+		},
 	}
 
 	for _, test := range tests {
@@ -149,6 +342,9 @@ func TestNewPreset(t *testing.T) {
 			if !reflect.DeepEqual(presetMap.OutputOpts, test.wantOutputOpts) {
 				t.Errorf("%s: wrong output options saved.\nWant %#v\nGot  %#v", test.givenTestCase, test.wantOutputOpts, presetMap.OutputOpts)
 			}
+			if presetMap.Preset == nil || presetMap.Preset.Name != name {
+				t.Errorf("%s: canonical preset not saved in the presetmap: %#v", test.givenTestCase, presetMap.Preset)
+			}
 		}
 	}
 }
@@ -229,6 +425,475 @@ func TestNewPresetWithExistentPresetMap(t *testing.T) {
 	}
 }
 
+func TestGetPreset(t *testing.T) {
+	tests := []struct {
+		givenTestCase   string
+		givenPresetName string
+		wantBody        map[string]interface{}
+		wantCode        int
+	}{
+		{
+			"Get a preset from its providers",
+			"abc-321",
+			map[string]interface{}{
+				"name": "abc-321",
+				"preset": map[string]interface{}{
+					"name":        "abc-321",
+					"description": "my nice preset",
+					"container":   "mp4",
+					"rateControl": "VBR",
+					"video": map[string]interface{}{
+						"profile":       "main",
+						"profileLevel":  "3.1",
+						"width":         "1920",
+						"height":        "1080",
+						"codec":         "h264",
+						"bitrate":       "3500000",
+						"gopSize":       "90",
+						"gopMode":       "fixed",
+						"interlaceMode": "progressive",
+						"crop":          map[string]interface{}{},
+					},
+					"audio": map[string]interface{}{
+						"codec":   "aac",
+						"bitrate": "64000",
+					},
+				},
+				"output":  map[string]interface{}{"extension": "mp4"},
+				"version": float64(1),
+				"results": map[string]interface{}{
+					"fake": map[string]interface{}{
+						"presetId": "presetID_here",
+						"preset": map[string]interface{}{
+							"id":        "presetID_here",
+							"container": "mp4",
+						},
+					},
+					"unknown": map[string]interface{}{
+						"presetId": "unknown-preset",
+						"error":    "getting factory: provider not found",
+					},
+				},
+			},
+			http.StatusOK,
+		},
+		{
+			"Get a preset that doesn't exist",
+			"some-unknown-preset",
+			map[string]interface{}{"error": "presetmap not found"},
+			http.StatusNotFound,
+		},
+	}
+
+	for _, test := range tests {
+		srvr := server.NewSimpleServer(&server.Config{})
+		fakeDB := dbtest.NewFakeRepository(false)
+		fakeDB.CreatePresetMap(&db.PresetMap{
+			Name:            "abc-321",
+			ProviderMapping: map[string]string{"fake": "presetID_here", "unknown": "unknown-preset"},
+			OutputOpts:      db.OutputOptions{Extension: "mp4"},
+		})
+		fakeDB.CreateLocalPreset(&db.LocalPreset{
+			Name: "abc-321",
+			Preset: db.Preset{
+				Name:        "abc-321",
+				Description: "my nice preset",
+				Container:   "mp4",
+				RateControl: "VBR",
+				Video: db.VideoPreset{
+					Profile:       "main",
+					ProfileLevel:  "3.1",
+					Width:         "1920",
+					Height:        "1080",
+					Codec:         "h264",
+					Bitrate:       "3500000",
+					GopSize:       "90",
+					GopMode:       "fixed",
+					InterlaceMode: "progressive",
+				},
+				Audio: db.AudioPreset{Codec: "aac", Bitrate: "64000"},
+			},
+		})
+		service, err := NewTranscodingService(&config.Config{Server: &server.Config{}}, logrus.New())
+		if err != nil {
+			t.Fatal(err)
+		}
+		service.db = fakeDB
+		srvr.Register(service)
+		r, _ := http.NewRequest("GET", "/presets/"+test.givenPresetName, nil)
+		w := httptest.NewRecorder()
+		srvr.ServeHTTP(w, r)
+		if w.Code != test.wantCode {
+			t.Errorf("%s: wrong response code. Want %d. Got %d", test.givenTestCase, test.wantCode, w.Code)
+		}
+		var got map[string]interface{}
+		err = json.NewDecoder(w.Body).Decode(&got)
+		if err != nil {
+			t.Errorf("%s: unable to JSON decode response body: %s", test.givenTestCase, err)
+		}
+		if !reflect.DeepEqual(got, test.wantBody) {
+			t.Errorf("%s: expected response body of\n%#v;\ngot\n%#v", test.givenTestCase, test.wantBody, got)
+		}
+	}
+}
+
+func TestUpdatePreset(t *testing.T) {
+	preset := map[string]interface{}{
+		"description": "updated from api",
+		"container":   "mp4",
+		"rateControl": "VBR",
+		"video": map[string]string{
+			"height":        "720",
+			"codec":         "h264",
+			"bitrate":       "1000",
+			"gopSize":       "90",
+			"gopMode":       "fixed",
+			"interlaceMode": "progressive",
+		},
+		"audio": map[string]string{
+			"codec":   "aac",
+			"bitrate": "64000",
+		},
+	}
+	tests := []struct {
+		givenTestCase    string
+		givenPresetName  string
+		givenMapping     map[string]string
+		givenRequestData map[string]interface{}
+
+		wantBody       map[string]interface{}
+		wantCode       int
+		wantMapping    map[string]string
+		wantDeleted    []string
+		wantDBDesc     string
+		wantOutputOpts db.OutputOptions
+	}{
+		{
+			"Update a preset",
+			"abc-321",
+			map[string]string{"fake": "old-preset-id"},
+			map[string]interface{}{"preset": preset},
+			map[string]interface{}{
+				"results": map[string]interface{}{
+					"fake": map[string]interface{}{
+						"presetId":         "presetID_here",
+						"previousPresetId": "old-preset-id",
+					},
+				},
+				"presetMap": "abc-321",
+			},
+			http.StatusOK,
+			map[string]string{"fake": "presetID_here"},
+			[]string{"old-preset-id"},
+			"updated from api",
+			db.OutputOptions{Extension: "mp4"},
+		},
+		{
+			"Roll back when a provider fails",
+			"abc-321",
+			map[string]string{"fake": "old-preset-id", "encodingcom": "old-encodingcom-id"},
+			map[string]interface{}{"preset": preset},
+			map[string]interface{}{
+				"results": map[string]interface{}{
+					"fake": map[string]interface{}{
+						"presetId":         "",
+						"previousPresetId": "old-preset-id",
+						"error":            "rolled back after failures on other providers",
+					},
+					"encodingcom": map[string]interface{}{
+						"presetId":         "",
+						"previousPresetId": "old-encodingcom-id",
+						"error":            "getting factory: provider not found",
+					},
+				},
+				"presetMap": "",
+			},
+			http.StatusInternalServerError,
+			map[string]string{"fake": "old-preset-id", "encodingcom": "old-encodingcom-id"},
+			[]string{"presetID_here"},
+			"",
+			db.OutputOptions{Extension: "webm"},
+		},
+		{
+			"Update a preset with a different name",
+			"abc-321",
+			map[string]string{"fake": "old-preset-id"},
+			map[string]interface{}{"preset": map[string]interface{}{"name": "other-preset"}},
+			map[string]interface{}{"error": `invalid preset: name "other-preset" doesn't match "abc-321"`},
+			http.StatusBadRequest,
+			map[string]string{"fake": "old-preset-id"},
+			nil,
+			"",
+			db.OutputOptions{Extension: "webm"},
+		},
+		{
+			"Update a preset that doesn't exist",
+			"some-unknown-preset",
+			map[string]string{"fake": "old-preset-id"},
+			map[string]interface{}{"preset": preset},
+			map[string]interface{}{"error": "presetmap not found"},
+			http.StatusNotFound,
+			map[string]string{"fake": "old-preset-id"},
+			nil,
+			"",
+			db.OutputOptions{Extension: "webm"},
+		},
+	}
+
+	for _, test := range tests {
+		fprovider.deletedPresets = nil
+		srvr := server.NewSimpleServer(&server.Config{})
+		fakeDB := dbtest.NewFakeRepository(false)
+		fakeDB.CreatePresetMap(&db.PresetMap{
+			Name:            "abc-321",
+			ProviderMapping: test.givenMapping,
+			OutputOpts:      db.OutputOptions{Extension: "webm"},
+		})
+		service, err := NewTranscodingService(&config.Config{Server: &server.Config{}}, logrus.New())
+		if err != nil {
+			t.Fatal(err)
+		}
+		service.db = fakeDB
+		srvr.Register(service)
+		body, _ := json.Marshal(test.givenRequestData)
+		r, _ := http.NewRequest("PUT", "/presets/"+test.givenPresetName, bytes.NewReader(body))
+		r.Header.Set("Content-Type", "application/json")
+		w := httptest.NewRecorder()
+		srvr.ServeHTTP(w, r)
+		if w.Code != test.wantCode {
+			t.Errorf("%s: wrong response code. Want %d. Got %d", test.givenTestCase, test.wantCode, w.Code)
+		}
+		var got map[string]interface{}
+		err = json.NewDecoder(w.Body).Decode(&got)
+		if err != nil {
+			t.Errorf("%s: unable to JSON decode response body: %s", test.givenTestCase, err)
+		}
+		if !reflect.DeepEqual(got, test.wantBody) {
+			t.Errorf("%s: expected response body of\n%#v;\ngot\n%#v", test.givenTestCase, test.wantBody, got)
+		}
+		if !reflect.DeepEqual(fprovider.deletedPresets, test.wantDeleted) {
+			t.Errorf("%s: wrong deleted presets. Want %#v. Got %#v", test.givenTestCase, test.wantDeleted, fprovider.deletedPresets)
+		}
+		presetMap, err := fakeDB.GetPresetMap("abc-321")
+		if err != nil {
+			t.Fatal(err)
+		}
+		if !reflect.DeepEqual(presetMap.ProviderMapping, test.wantMapping) {
+			t.Errorf("%s: wrong provider mapping. Want %#v. Got %#v", test.givenTestCase, test.wantMapping, presetMap.ProviderMapping)
+		}
+		if presetMap.OutputOpts != test.wantOutputOpts {
+			t.Errorf("%s: wrong output options. Want %#v. Got %#v", test.givenTestCase, test.wantOutputOpts, presetMap.OutputOpts)
+		}
+		var gotDesc string
+		if presetMap.Preset != nil {
+			gotDesc = presetMap.Preset.Description
+		}
+		if gotDesc != test.wantDBDesc {
+			t.Errorf("%s: wrong stored preset description. Want %q. Got %q", test.givenTestCase, test.wantDBDesc, gotDesc)
+		}
+	}
+	fprovider.deletedPresets = nil
+}
+
+func TestUpdatePresetLocalPresets(t *testing.T) {
+	previous := db.Preset{
+		Name:        "abc-321",
+		Description: "my nice preset",
+		Container:   "mp4",
+		Video:       db.VideoPreset{Codec: "h264", Bitrate: "1000000", GopSize: "90", Height: "720"},
+		Audio:       db.AudioPreset{Codec: "aac", Bitrate: "64000"},
+	}
+	updated := previous
+	updated.Description = "updated from api"
+	updated.Video.Bitrate = "2000000"
+	tests := []struct {
+		givenTestCase string
+		givenMapping  map[string]string
+
+		wantCode        int
+		wantResults     map[string]interface{}
+		wantLocalPreset db.Preset
+	}{
+		{
+			"Update a preset in place",
+			map[string]string{"local": "abc-321"},
+			http.StatusOK,
+			map[string]interface{}{
+				"local": map[string]interface{}{"presetId": "abc-321", "previousPresetId": "abc-321"},
+			},
+			updated,
+		},
+		{
+			"Restore the previous preset when a provider fails",
+			map[string]string{"local": "abc-321", "encodingcom": "old-encodingcom-id"},
+			http.StatusInternalServerError,
+			map[string]interface{}{
+				"local": map[string]interface{}{
+					"presetId":         "",
+					"previousPresetId": "abc-321",
+					"error":            "rolled back after failures on other providers",
+				},
+				"encodingcom": map[string]interface{}{
+					"presetId":         "",
+					"previousPresetId": "old-encodingcom-id",
+					"error":            "getting factory: provider not found",
+				},
+			},
+			previous,
+		},
+	}
+	for _, test := range tests {
+		srvr := server.NewSimpleServer(&server.Config{})
+		fakeDB := dbtest.NewFakeRepository(false)
+		fakeDB.CreatePresetMap(&db.PresetMap{
+			Name:            "abc-321",
+			ProviderMapping: test.givenMapping,
+			OutputOpts:      db.OutputOptions{Extension: "mp4"},
+			Preset:          &previous,
+		})
+		fakeDB.CreateLocalPreset(&db.LocalPreset{Name: "abc-321", Preset: previous})
+		localPresets = fakeDB
+		defer func() { localPresets = nil }()
+		service, err := NewTranscodingService(&config.Config{Server: &server.Config{}}, logrus.New())
+		if err != nil {
+			t.Fatal(err)
+		}
+		service.db = fakeDB
+		srvr.Register(service)
+		body, _ := json.Marshal(map[string]interface{}{"preset": updated})
+		r, _ := http.NewRequest("PUT", "/presets/abc-321", bytes.NewReader(body))
+		r.Header.Set("Content-Type", "application/json")
+		w := httptest.NewRecorder()
+		srvr.ServeHTTP(w, r)
+		if w.Code != test.wantCode {
+			t.Errorf("%s: wrong response code. Want %d. Got %d", test.givenTestCase, test.wantCode, w.Code)
+		}
+		var got map[string]interface{}
+		err = json.Unmarshal(w.Body.Bytes(), &got)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if !reflect.DeepEqual(got["results"], test.wantResults) {
+			t.Errorf("%s: wrong results\nwant %#v\ngot  %#v", test.givenTestCase, test.wantResults, got["results"])
+		}
+		localPreset, err := fakeDB.GetLocalPreset("abc-321")
+		if err != nil {
+			t.Fatalf("%s: %s", test.givenTestCase, err)
+		}
+		if !reflect.DeepEqual(localPreset.Preset, test.wantLocalPreset) {
+			t.Errorf("%s: wrong local preset\nwant %#v\ngot  %#v", test.givenTestCase, test.wantLocalPreset, localPreset.Preset)
+		}
+	}
+}
+
+func TestUpdatePresetMediaConvert(t *testing.T) {
+	previous := db.Preset{
+		Name:        "abc-321",
+		Description: "my nice preset",
+		Container:   "mp4",
+		RateControl: "VBR",
+		Video:       db.VideoPreset{Codec: "h264", Bitrate: "1000000", GopSize: "90", Height: "720"},
+		Audio:       db.AudioPreset{Codec: "aac", Bitrate: "64000"},
+	}
+	updated := previous
+	updated.Description = "updated from api"
+	updated.Video.Bitrate = "2000000"
+	tests := []struct {
+		givenTestCase string
+		givenMapping  map[string]string
+
+		wantCode        int
+		wantResults     map[string]interface{}
+		wantBitrate     float64
+		wantUpdateCalls []string
+	}{
+		{
+			"Update the preset in place",
+			map[string]string{"mediaconvert": "abc-321"},
+			http.StatusOK,
+			map[string]interface{}{
+				"mediaconvert": map[string]interface{}{"presetId": "abc-321", "previousPresetId": "abc-321"},
+			},
+			2000000,
+			[]string{"abc-321"},
+		},
+		{
+			"Restore the previous settings when a provider fails",
+			map[string]string{"mediaconvert": "abc-321", "encodingcom": "old-encodingcom-id"},
+			http.StatusInternalServerError,
+			map[string]interface{}{
+				"mediaconvert": map[string]interface{}{
+					"presetId":         "",
+					"previousPresetId": "abc-321",
+					"error":            "rolled back after failures on other providers",
+				},
+				"encodingcom": map[string]interface{}{
+					"presetId":         "",
+					"previousPresetId": "old-encodingcom-id",
+					"error":            "getting factory: provider not found",
+				},
+			},
+			1000000,
+			[]string{"abc-321", "abc-321"},
+		},
+	}
+	for _, test := range tests {
+		mc := newFakeMediaConvert()
+		defer mc.Close()
+		cfg := &config.Config{Server: &server.Config{}, MediaConvert: mc.config()}
+		providerFactory, err := provider.GetProviderFactory("mediaconvert")
+		if err != nil {
+			t.Fatal(err)
+		}
+		mcProvider, err := providerFactory(cfg)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if _, err = mcProvider.CreatePreset(previous); err != nil {
+			t.Fatal(err)
+		}
+		srvr := server.NewSimpleServer(&server.Config{})
+		fakeDB := dbtest.NewFakeRepository(false)
+		fakeDB.CreatePresetMap(&db.PresetMap{
+			Name:            "abc-321",
+			ProviderMapping: test.givenMapping,
+			OutputOpts:      db.OutputOptions{Extension: "mp4"},
+			Preset:          &previous,
+		})
+		service, err := NewTranscodingService(cfg, logrus.New())
+		if err != nil {
+			t.Fatal(err)
+		}
+		service.db = fakeDB
+		srvr.Register(service)
+		body, _ := json.Marshal(map[string]interface{}{"preset": updated})
+		r, _ := http.NewRequest("PUT", "/presets/abc-321", bytes.NewReader(body))
+		r.Header.Set("Content-Type", "application/json")
+		w := httptest.NewRecorder()
+		srvr.ServeHTTP(w, r)
+		if w.Code != test.wantCode {
+			t.Errorf("%s: wrong response code. Want %d. Got %d", test.givenTestCase, test.wantCode, w.Code)
+		}
+		var got map[string]interface{}
+		err = json.Unmarshal(w.Body.Bytes(), &got)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if !reflect.DeepEqual(got["results"], test.wantResults) {
+			t.Errorf("%s: wrong results\nwant %#v\ngot  %#v", test.givenTestCase, test.wantResults, got["results"])
+		}
+		if bitrate := mc.bitrate("abc-321"); bitrate != test.wantBitrate {
+			t.Errorf("%s: wrong bitrate on the stored preset. Want %v. Got %v", test.givenTestCase, test.wantBitrate, bitrate)
+		}
+		if !reflect.DeepEqual(mc.updates, test.wantUpdateCalls) {
+			t.Errorf("%s: wrong preset updates\nwant %#v\ngot  %#v", test.givenTestCase, test.wantUpdateCalls, mc.updates)
+		}
+		if len(mc.presets) != 1 {
+			t.Errorf("%s: wrong number of stored presets. Want 1. Got %d", test.givenTestCase, len(mc.presets))
+		}
+	}
+}
+
 func TestDeletePreset(t *testing.T) {
 	tests := []struct {
 		givenTestCase string
@@ -0,0 +1,478 @@
+package service
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io/ioutil"
+	"net/http"
+	"reflect"
+	"sort"
+
+	"github.com/video-dev/video-transcoding-api/v2/db"
+	"github.com/video-dev/video-transcoding-api/v2/internal/provider"
+	"github.com/video-dev/video-transcoding-api/v2/swagger"
+	"sigs.k8s.io/yaml"
+)
+
+// Actions of presetmaps and provider presets in apply plans.
+const (
+	applyActionCreate    = "create"
+	applyActionUpdate    = "update"
+	applyActionReplace   = "replace"
+	applyActionDelete    = "delete"
+	applyActionUnchanged = "unchanged"
+)
+
+// swagger:route GET /presets/export presets exportPresets
+//
+// Exports every presetmap along with its canonical preset, as JSON or YAML.
+// The exported file can be applied through POST /presets/apply.
+//
+//     Responses:
+//       200: presetManifest
+//       400: invalidPreset
+//       500: genericError
+func (s *TranscodingService) exportPresets(w http.ResponseWriter, r *http.Request) {
+	var params exportPresetsInput
+	params.loadParams(r.URL.Query())
+
+	presetMaps, err := s.db.ListPresetMaps()
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+	sort.Slice(presetMaps, func(i, j int) bool {
+		return presetMaps[i].Name < presetMaps[j].Name
+	})
+	manifest := presetManifest{PresetMaps: presetMaps}
+
+	var data []byte
+	switch params.Format {
+	case "", "json":
+		w.Header().Set("Content-Type", "application/json")
+		data, err = json.MarshalIndent(manifest, "", "  ")
+	case "yaml":
+		w.Header().Set("Content-Type", "application/x-yaml")
+		data, err = yaml.Marshal(manifest)
+	default:
+		http.Error(w, fmt.Sprintf("invalid format %q: must be json or yaml", params.Format), http.StatusBadRequest)
+		return
+	}
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+	w.Write(data)
+}
+
+// swagger:route POST /presets/apply presets applyPresets
+//
+// Converges the stored presetmaps to the ones in the given file, as returned
+// by GET /presets/export. Missing presetmaps are created on the providers
+// they're mapped to, and presetmaps whose preset, output options or
+// providers changed are updated, replacing their presets on the providers.
+// With prune, stored presetmaps that aren't in the file are deleted, unless
+// they're used by jobs that haven't finished yet and force isn't set.
+//
+// With dryRun, the plan is returned without applying any change.
+//
+//     Responses:
+//       200: applyPresetsOutputs
+//       400: invalidPreset
+//       409: presetInUse
+//       500: applyPresetsOutputs
+//       503: presetUsageUnknown
+func (s *TranscodingService) applyPresets(r *http.Request) swagger.GizmoJSONResponse {
+	defer r.Body.Close()
+	var input applyPresetsInput
+	if err := input.loadParams(r.URL.Query()); err != nil {
+		return newInvalidPresetResponse(err)
+	}
+	data, err := ioutil.ReadAll(r.Body)
+	if err != nil {
+		return swagger.NewErrorResponse(err)
+	}
+	// JSON documents are valid YAML documents, so both formats are
+	// decoded the same way.
+	if err = yaml.Unmarshal(data, &input.Payload); err != nil {
+		return newInvalidPresetResponse(fmt.Errorf("invalid preset file: %s", err))
+	}
+
+	changes, errResp := s.planPresetChanges(input.Payload, input.Prune)
+	if errResp != nil {
+		return errResp
+	}
+	if !input.Force {
+		for _, change := range changes {
+			if change.action != applyActionDelete {
+				continue
+			}
+			if errResp = s.checkPresetNotInUse(change.name); errResp != nil {
+				return errResp
+			}
+		}
+	}
+
+	output := applyPresetsOutputs{
+		DryRun:  input.DryRun,
+		Prune:   input.Prune,
+		Results: make(map[string]applyPresetOutput, len(changes)),
+	}
+	status := http.StatusOK
+	for _, change := range changes {
+		result := change.plan()
+		if !input.DryRun {
+			result = s.applyPresetChange(change)
+		}
+		if result.Error != "" {
+			status = http.StatusInternalServerError
+		}
+		output.Results[change.name] = result
+	}
+	return &applyPresetsResponse{
+		baseResponse: baseResponse{
+			payload: output,
+			status:  status,
+		},
+	}
+}
+
+// presetMapChange is the change required to converge a stored presetmap to
+// the one in an applied file. desired is nil when the presetmap is deleted,
+// and current is nil when it's created.
+type presetMapChange struct {
+	name      string
+	action    string
+	changes   []string
+	desired   *db.PresetMap
+	current   *db.PresetMap
+	providers map[string]string
+	affected  []string
+}
+
+// plan returns the output describing the change before applying it.
+func (c presetMapChange) plan() applyPresetOutput {
+	output := applyPresetOutput{
+		Action:          c.action,
+		Changes:         c.changes,
+		Providers:       make(map[string]applyProviderPresetOutput, len(c.providers)),
+		AffectedPresets: c.affected,
+	}
+	for p, action := range c.providers {
+		result := applyProviderPresetOutput{Action: action}
+		if c.current != nil {
+			switch action {
+			case applyActionUnchanged:
+				result.PresetID = c.current.ProviderMapping[p]
+			case applyActionReplace, applyActionDelete:
+				result.PreviousPresetID = c.current.ProviderMapping[p]
+			}
+		}
+		output.Providers[p] = result
+	}
+	return output
+}
+
+// planPresetChanges validates the presetmaps in the manifest and compares
+// them with the stored presetmaps, returning the changes sorted by presetmap
+// name.
+func (s *TranscodingService) planPresetChanges(manifest presetManifest, prune bool) ([]presetMapChange, swagger.GizmoJSONResponse) {
+	changes := make([]presetMapChange, 0, len(manifest.PresetMaps))
+	listed := make(map[string]bool, len(manifest.PresetMaps))
+
+	// base presets are looked up in the file first, so changes to a base
+	// preset are also applied to the presets inheriting from it
+	declared := make(map[string]*db.Preset, len(manifest.PresetMaps))
+	for _, presetMap := range manifest.PresetMaps {
+		if presetMap.Preset != nil {
+			preset := *presetMap.Preset
+			preset.Name = presetMap.Name
+			declared[presetMap.Name] = &preset
+		}
+	}
+	lookup := func(name string) (*db.Preset, error) {
+		if preset, ok := declared[name]; ok {
+			return preset, nil
+		}
+		return s.lookupPreset(name)
+	}
+
+	for _, presetMap := range manifest.PresetMaps {
+		if presetMap.Name == "" {
+			return nil, newInvalidPresetResponse(errors.New("invalid preset file: presetmap name is required"))
+		}
+		if listed[presetMap.Name] {
+			return nil, newInvalidPresetResponse(fmt.Errorf("invalid preset file: presetmap %q is listed more than once", presetMap.Name))
+		}
+		listed[presetMap.Name] = true
+		desired, err := desiredPresetMap(presetMap, lookup)
+		if err != nil {
+			return nil, newInvalidPresetResponse(fmt.Errorf("invalid presetmap %q: %s", presetMap.Name, err))
+		}
+
+		current, err := s.db.GetPresetMap(presetMap.Name)
+		switch err {
+		case nil:
+			change := planPresetMapUpdate(current, desired)
+			if change.action == applyActionUpdate {
+				change.affected, err = s.descendantPresets(change.name)
+				if err != nil {
+					return nil, swagger.NewErrorResponse(err)
+				}
+			}
+			changes = append(changes, change)
+		case db.ErrPresetMapNotFound:
+			change := presetMapChange{
+				name:      desired.Name,
+				action:    applyActionCreate,
+				desired:   desired,
+				providers: make(map[string]string, len(desired.ProviderMapping)),
+			}
+			for p := range desired.ProviderMapping {
+				change.providers[p] = applyActionCreate
+			}
+			changes = append(changes, change)
+		default:
+			return nil, swagger.NewErrorResponse(err)
+		}
+	}
+
+	if prune {
+		presetMaps, err := s.db.ListPresetMaps()
+		if err != nil {
+			return nil, swagger.NewErrorResponse(err)
+		}
+		for i := range presetMaps {
+			current := &presetMaps[i]
+			if listed[current.Name] {
+				continue
+			}
+			change := presetMapChange{
+				name:      current.Name,
+				action:    applyActionDelete,
+				current:   current,
+				providers: make(map[string]string, len(current.ProviderMapping)),
+			}
+			for p := range current.ProviderMapping {
+				change.providers[p] = applyActionDelete
+			}
+			changes = append(changes, change)
+		}
+	}
+	sort.Slice(changes, func(i, j int) bool {
+		return changes[i].name < changes[j].name
+	})
+	return changes, nil
+}
+
+// desiredPresetMap validates the given presetmap from an applied file,
+// returning it with the preset merged with its base presets and normalized,
+// and without the provider preset IDs.
+func desiredPresetMap(presetMap db.PresetMap, lookup func(string) (*db.Preset, error)) (*db.PresetMap, error) {
+	if presetMap.Preset == nil {
+		return nil, errors.New("preset is required")
+	}
+	if len(presetMap.ProviderMapping) == 0 {
+		return nil, errors.New("providerMapping must list at least one provider")
+	}
+	providers := make([]string, 0, len(presetMap.ProviderMapping))
+	mapping := make(map[string]string, len(presetMap.ProviderMapping))
+	for p := range presetMap.ProviderMapping {
+		if _, err := provider.GetProviderFactory(p); err != nil {
+			return nil, fmt.Errorf("%s: %s", p, err)
+		}
+		providers = append(providers, p)
+		mapping[p] = ""
+	}
+
+	preset := *presetMap.Preset
+	if preset.Name == "" {
+		preset.Name = presetMap.Name
+	}
+	if preset.Name != presetMap.Name {
+		return nil, fmt.Errorf("preset name %q doesn't match %q", preset.Name, presetMap.Name)
+	}
+	preset, err := db.ResolvePreset(preset, lookup)
+	if err != nil {
+		return nil, err
+	}
+	preset, err = normalizePreset(preset, providers)
+	if err != nil {
+		return nil, err
+	}
+
+	outputOpts := presetMap.OutputOpts
+	outputOpts.Extension = preset.Container
+	if err = outputOpts.Validate(); err != nil {
+		return nil, fmt.Errorf("invalid output: %s", err)
+	}
+	return &db.PresetMap{
+		Name:            presetMap.Name,
+		ProviderMapping: mapping,
+		OutputOpts:      outputOpts,
+		Preset:          &preset,
+	}, nil
+}
+
+// planPresetMapUpdate compares the stored presetmap with the desired one.
+// Provider presets are replaced only when the preset changes, as the output
+// options are only stored in the presetmap.
+func planPresetMapUpdate(current, desired *db.PresetMap) presetMapChange {
+	change := presetMapChange{
+		name:      desired.Name,
+		action:    applyActionUnchanged,
+		desired:   desired,
+		current:   current,
+		providers: make(map[string]string, len(desired.ProviderMapping)),
+	}
+	presetChanged := current.Preset == nil || !samePreset(*current.Preset, *desired.Preset)
+	if presetChanged {
+		change.changes = append(change.changes, "preset")
+	}
+	if !reflect.DeepEqual(current.OutputOpts, desired.OutputOpts) {
+		change.changes = append(change.changes, "output")
+	}
+
+	var providersChanged bool
+	for p := range desired.ProviderMapping {
+		switch _, ok := current.ProviderMapping[p]; {
+		case !ok:
+			change.providers[p] = applyActionCreate
+			providersChanged = true
+		case presetChanged:
+			change.providers[p] = applyActionReplace
+		default:
+			change.providers[p] = applyActionUnchanged
+		}
+	}
+	for p := range current.ProviderMapping {
+		if _, ok := desired.ProviderMapping[p]; !ok {
+			change.providers[p] = applyActionDelete
+			providersChanged = true
+		}
+	}
+	if providersChanged {
+		change.changes = append(change.changes, "providers")
+	}
+	if len(change.changes) > 0 {
+		change.action = applyActionUpdate
+	}
+	return change
+}
+
+// samePreset compares presets by their JSON representation, so unset and
+// empty provider overrides are considered equal.
+func samePreset(a, b db.Preset) bool {
+	dataA, errA := json.Marshal(a)
+	dataB, errB := json.Marshal(b)
+	return errA == nil && errB == nil && string(dataA) == string(dataB)
+}
+
+// applyPresetChange applies the given change. Presets are created on the
+// providers before the presetmap is stored, and previous presets are
+// deleted only after that. If any of the presets can't be created, the
+// created presets are deleted and the presetmap is left untouched.
+func (s *TranscodingService) applyPresetChange(change presetMapChange) applyPresetOutput {
+	output := change.plan()
+	switch change.action {
+	case applyActionUnchanged:
+		return output
+	case applyActionDelete:
+		for p, result := range output.Providers {
+			if err := s.deleteProviderPreset(p, result.PreviousPresetID); err != nil {
+				result.Error = err.Error()
+				output.Providers[p] = result
+			}
+		}
+		if err := s.db.DeletePresetMap(change.current); err != nil {
+			output.Error = "deleting presetmap: " + err.Error()
+		}
+		return output
+	}
+
+	var previousPreset *db.Preset
+	if change.current != nil {
+		previousPreset = s.canonicalPreset(*change.current)
+	}
+	presetMap := *change.desired
+	providerObjs := make(map[string]provider.TranscodingProvider, len(output.Providers))
+	var failed bool
+	for p, result := range output.Providers {
+		switch result.Action {
+		case applyActionCreate, applyActionReplace:
+			providerObj, presetID, err := s.replaceProviderPreset(p, result.PreviousPresetID, *presetMap.Preset)
+			if err != nil {
+				result.Error = err.Error()
+				failed = true
+			} else {
+				providerObjs[p] = providerObj
+				result.PresetID = presetID
+				presetMap.ProviderMapping[p] = presetID
+			}
+		case applyActionUnchanged:
+			presetMap.ProviderMapping[p] = result.PresetID
+		}
+		output.Providers[p] = result
+	}
+
+	if !failed {
+		var err error
+		if change.action == applyActionCreate {
+			err = s.db.CreatePresetMap(&presetMap)
+		} else {
+			err = s.db.UpdatePresetMap(&presetMap)
+		}
+		if err != nil {
+			output.Error = "storing presetmap: " + err.Error()
+			failed = true
+		}
+	}
+
+	if failed {
+		if output.Error == "" {
+			output.Error = "failed to create the preset on every provider, changes were rolled back"
+		}
+		for p, result := range output.Providers {
+			if result.PresetID == "" || result.Action == applyActionUnchanged {
+				continue
+			}
+			if result.Error == "" {
+				result.Error = "rolled back after failures on other providers"
+			}
+			if err := rollbackProviderPreset(providerObjs[p], result.PresetID, result.PreviousPresetID, previousPreset); err != nil {
+				result.Error += ", " + err.Error()
+			}
+			result.PresetID = ""
+			output.Providers[p] = result
+		}
+		return output
+	}
+
+	for p, result := range output.Providers {
+		if result.PreviousPresetID == "" || result.PreviousPresetID == result.PresetID {
+			continue
+		}
+		if err := s.deleteProviderPreset(p, result.PreviousPresetID); err != nil {
+			result.Error = err.Error()
+			output.Providers[p] = result
+		}
+	}
+	return output
+}
+
+// deleteProviderPreset deletes the given preset from the provider.
+func (s *TranscodingService) deleteProviderPreset(p, presetID string) error {
+	providerFactory, err := provider.GetProviderFactory(p)
+	if err != nil {
+		return fmt.Errorf("getting factory: %s", err)
+	}
+	providerObj, err := providerFactory(s.config)
+	if err != nil {
+		return fmt.Errorf("initializing provider: %s", err)
+	}
+	if err = providerObj.DeletePreset(presetID); err != nil {
+		return fmt.Errorf("deleting preset: %s", err)
+	}
+	return nil
+}
@@ -0,0 +1,492 @@
+package service
+
+import (
+	"bytes"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"reflect"
+	"sort"
+	"strings"
+	"testing"
+
+	"github.com/NYTimes/gizmo/server"
+	"github.com/sirupsen/logrus"
+	"github.com/video-dev/video-transcoding-api/v2/config"
+	"github.com/video-dev/video-transcoding-api/v2/db"
+	"github.com/video-dev/video-transcoding-api/v2/db/dbtest"
+	"github.com/video-dev/video-transcoding-api/v2/internal/provider"
+	"sigs.k8s.io/yaml"
+)
+
+func TestExportPresets(t *testing.T) {
+	tests := []struct {
+		givenTestCase   string
+		givenFormat     string
+		wantCode        int
+		wantContentType string
+	}{
+		{"JSON export", "", http.StatusOK, "application/json"},
+		{"YAML export", "yaml", http.StatusOK, "application/x-yaml"},
+		{"Invalid format", "xml", http.StatusBadRequest, "text/plain; charset=utf-8"},
+	}
+	for _, test := range tests {
+		srvr, fakeDB := newPresetApplyServer(t)
+		r, _ := http.NewRequest("GET", "/presets/export?format="+test.givenFormat, nil)
+		w := httptest.NewRecorder()
+		srvr.ServeHTTP(w, r)
+		if w.Code != test.wantCode {
+			t.Errorf("%s: wrong response code. Want %d. Got %d", test.givenTestCase, test.wantCode, w.Code)
+		}
+		if contentType := w.Header().Get("Content-Type"); contentType != test.wantContentType {
+			t.Errorf("%s: wrong content type. Want %q. Got %q", test.givenTestCase, test.wantContentType, contentType)
+		}
+		if w.Code != http.StatusOK {
+			continue
+		}
+		var got presetManifest
+		if err := yaml.Unmarshal(w.Body.Bytes(), &got); err != nil {
+			t.Fatalf("%s: unable to decode the exported file: %s", test.givenTestCase, err)
+		}
+		mp4, _ := fakeDB.GetPresetMap("mp4_720p")
+		webm, _ := fakeDB.GetPresetMap("webm_480p")
+		want := presetManifest{PresetMaps: []db.PresetMap{*mp4, *webm}}
+		if !reflect.DeepEqual(got, want) {
+			t.Errorf("%s: wrong exported file\nwant %#v\ngot  %#v", test.givenTestCase, want, got)
+		}
+	}
+}
+
+func TestApplyExportedPresets(t *testing.T) {
+	srvr, _ := newPresetApplyServer(t)
+	r, _ := http.NewRequest("GET", "/presets/export?format=yaml", nil)
+	w := httptest.NewRecorder()
+	srvr.ServeHTTP(w, r)
+	exported := w.Body.Bytes()
+
+	r, _ = http.NewRequest("POST", "/presets/apply?prune=true", bytes.NewReader(exported))
+	w = httptest.NewRecorder()
+	srvr.ServeHTTP(w, r)
+	if w.Code != http.StatusOK {
+		t.Fatalf("wrong response code. Want %d. Got %d: %s", http.StatusOK, w.Code, w.Body.String())
+	}
+	var got applyPresetsOutputs
+	if err := json.NewDecoder(w.Body).Decode(&got); err != nil {
+		t.Fatal(err)
+	}
+	for name, result := range got.Results {
+		if result.Action != applyActionUnchanged {
+			t.Errorf("%s: wrong action. Want %q. Got %q", name, applyActionUnchanged, result.Action)
+		}
+	}
+	if len(got.Results) != 2 {
+		t.Errorf("wrong number of results. Want 2. Got %d", len(got.Results))
+	}
+	if len(fprovider.deletedPresets) > 0 {
+		t.Errorf("unexpected deleted presets: %#v", fprovider.deletedPresets)
+	}
+}
+
+func TestApplyPresets(t *testing.T) {
+	const presetFile = `
+presetMaps:
+  - name: mp4_720p
+    providerMapping:
+      fake: mp4_720p-id
+    output:
+      extension: mp4
+    preset:
+      container: mp4
+      video: {codec: h264, width: "1280", height: "720", bitrate: "2500000"}
+      audio: {codec: aac, bitrate: "128000"}
+  - name: webm_480p
+    providerMapping:
+      fake: ""
+    preset:
+      container: webm
+      video: {codec: vp9, width: "854", height: "480", bitrate: "1200000"}
+      audio: {codec: opus, bitrate: "96000"}
+  - name: hls_1080p
+    providerMapping:
+      fake: ""
+      zencoder: ""
+    preset:
+      container: m3u8
+      video: {codec: h264, width: "1920", height: "1080", bitrate: "5000000"}
+      audio: {codec: aac, bitrate: "128000"}
+`
+	plan := map[string]applyPresetOutput{
+		"mp4_720p": {
+			Action:    "unchanged",
+			Providers: map[string]applyProviderPresetOutput{"fake": {Action: "unchanged", PresetID: "mp4_720p-id"}},
+		},
+		"webm_480p": {
+			Action:  "update",
+			Changes: []string{"preset", "providers"},
+			Providers: map[string]applyProviderPresetOutput{
+				"fake":     {Action: "replace", PreviousPresetID: "webm_480p-id"},
+				"zencoder": {Action: "delete", PreviousPresetID: "webm_480p-zencoder-id"},
+			},
+		},
+		"hls_1080p": {
+			Action: "create",
+			Providers: map[string]applyProviderPresetOutput{
+				"fake":     {Action: "create"},
+				"zencoder": {Action: "create"},
+			},
+		},
+	}
+	tests := []struct {
+		givenTestCase     string
+		givenQuery        string
+		givenRequestData  string
+		wantCode          int
+		wantBody          interface{}
+		wantPresetMaps    map[string]map[string]string
+		wantDeletedPreset []string
+	}{
+		{
+			"Dry-run",
+			"?dryRun=true",
+			presetFile,
+			http.StatusOK,
+			applyPresetsOutputs{DryRun: true, Results: plan},
+			map[string]map[string]string{
+				"mp4_720p":  {"fake": "mp4_720p-id"},
+				"webm_480p": {"fake": "webm_480p-id", "zencoder": "webm_480p-zencoder-id"},
+			},
+			nil,
+		},
+		{
+			"Apply",
+			"",
+			presetFile,
+			http.StatusOK,
+			applyPresetsOutputs{Results: map[string]applyPresetOutput{
+				"mp4_720p": plan["mp4_720p"],
+				"webm_480p": {
+					Action:  "update",
+					Changes: []string{"preset", "providers"},
+					Providers: map[string]applyProviderPresetOutput{
+						"fake":     {Action: "replace", PresetID: "presetID_here", PreviousPresetID: "webm_480p-id"},
+						"zencoder": {Action: "delete", PreviousPresetID: "webm_480p-zencoder-id"},
+					},
+				},
+				"hls_1080p": {
+					Action: "create",
+					Providers: map[string]applyProviderPresetOutput{
+						"fake":     {Action: "create", PresetID: "presetID_here"},
+						"zencoder": {Action: "create", PresetID: "presetID_here"},
+					},
+				},
+			}},
+			map[string]map[string]string{
+				"mp4_720p":  {"fake": "mp4_720p-id"},
+				"webm_480p": {"fake": "presetID_here"},
+				"hls_1080p": {"fake": "presetID_here", "zencoder": "presetID_here"},
+			},
+			[]string{"webm_480p-id", "webm_480p-zencoder-id"},
+		},
+		{
+			"Dry-run with prune",
+			"?dryRun=1&prune=true",
+			`{"presetMaps":[{"name":"mp4_720p","providerMapping":{"fake":""},"output":{"extension":"mp4"},"preset":{"container":"mp4","video":{"codec":"h264","width":"1280","height":"720","bitrate":"2500000"},"audio":{"codec":"aac","bitrate":"128000"}}}]}`,
+			http.StatusOK,
+			applyPresetsOutputs{DryRun: true, Prune: true, Results: map[string]applyPresetOutput{
+				"mp4_720p": plan["mp4_720p"],
+				"webm_480p": {
+					Action: "delete",
+					Providers: map[string]applyProviderPresetOutput{
+						"fake":     {Action: "delete", PreviousPresetID: "webm_480p-id"},
+						"zencoder": {Action: "delete", PreviousPresetID: "webm_480p-zencoder-id"},
+					},
+				},
+			}},
+			map[string]map[string]string{
+				"mp4_720p":  {"fake": "mp4_720p-id"},
+				"webm_480p": {"fake": "webm_480p-id", "zencoder": "webm_480p-zencoder-id"},
+			},
+			nil,
+		},
+		{
+			"Apply with prune",
+			"?prune=true",
+			"presetMaps: []",
+			http.StatusOK,
+			applyPresetsOutputs{Prune: true, Results: map[string]applyPresetOutput{
+				"mp4_720p": {
+					Action:    "delete",
+					Providers: map[string]applyProviderPresetOutput{"fake": {Action: "delete", PreviousPresetID: "mp4_720p-id"}},
+				},
+				"webm_480p": {
+					Action: "delete",
+					Providers: map[string]applyProviderPresetOutput{
+						"fake":     {Action: "delete", PreviousPresetID: "webm_480p-id"},
+						"zencoder": {Action: "delete", PreviousPresetID: "webm_480p-zencoder-id"},
+					},
+				},
+			}},
+			map[string]map[string]string{},
+			[]string{"mp4_720p-id", "webm_480p-id", "webm_480p-zencoder-id"},
+		},
+		{
+			"Unknown provider",
+			"",
+			`{"presetMaps":[{"name":"mp4_720p","providerMapping":{"unknown":""},"preset":{"container":"mp4"}}]}`,
+			http.StatusBadRequest,
+			map[string]interface{}{"error": `invalid presetmap "mp4_720p": unknown: provider not found`},
+			map[string]map[string]string{
+				"mp4_720p":  {"fake": "mp4_720p-id"},
+				"webm_480p": {"fake": "webm_480p-id", "zencoder": "webm_480p-zencoder-id"},
+			},
+			nil,
+		},
+		{
+			"Missing preset",
+			"",
+			`{"presetMaps":[{"name":"mp4_720p","providerMapping":{"fake":""}}]}`,
+			http.StatusBadRequest,
+			map[string]interface{}{"error": `invalid presetmap "mp4_720p": preset is required`},
+			map[string]map[string]string{
+				"mp4_720p":  {"fake": "mp4_720p-id"},
+				"webm_480p": {"fake": "webm_480p-id", "zencoder": "webm_480p-zencoder-id"},
+			},
+			nil,
+		},
+		{
+			"Invalid file",
+			"",
+			"presetMaps: {",
+			http.StatusBadRequest,
+			map[string]interface{}{"error": "invalid preset file: error converting YAML to JSON: yaml: line 1: did not find expected node content"},
+			map[string]map[string]string{
+				"mp4_720p":  {"fake": "mp4_720p-id"},
+				"webm_480p": {"fake": "webm_480p-id", "zencoder": "webm_480p-zencoder-id"},
+			},
+			nil,
+		},
+		{
+			"Invalid dryRun",
+			"?dryRun=maybe",
+			presetFile,
+			http.StatusBadRequest,
+			map[string]interface{}{"error": `invalid dryRun: "maybe" is not a boolean`},
+			map[string]map[string]string{
+				"mp4_720p":  {"fake": "mp4_720p-id"},
+				"webm_480p": {"fake": "webm_480p-id", "zencoder": "webm_480p-zencoder-id"},
+			},
+			nil,
+		},
+	}
+	for _, test := range tests {
+		srvr, fakeDB := newPresetApplyServer(t)
+		r, _ := http.NewRequest("POST", "/presets/apply"+test.givenQuery, strings.NewReader(test.givenRequestData))
+		w := httptest.NewRecorder()
+		srvr.ServeHTTP(w, r)
+		if w.Code != test.wantCode {
+			t.Errorf("%s: wrong response code. Want %d. Got %d", test.givenTestCase, test.wantCode, w.Code)
+		}
+		got := reflect.New(reflect.TypeOf(test.wantBody))
+		if err := json.NewDecoder(w.Body).Decode(got.Interface()); err != nil {
+			t.Errorf("%s: unable to JSON decode response body: %s", test.givenTestCase, err)
+		}
+		if !reflect.DeepEqual(got.Elem().Interface(), test.wantBody) {
+			t.Errorf("%s: wrong response body\nwant %#v\ngot  %#v", test.givenTestCase, test.wantBody, got.Elem().Interface())
+		}
+		presetMaps, err := fakeDB.ListPresetMaps()
+		if err != nil {
+			t.Fatal(err)
+		}
+		mappings := make(map[string]map[string]string, len(presetMaps))
+		for _, presetMap := range presetMaps {
+			mappings[presetMap.Name] = presetMap.ProviderMapping
+		}
+		if !reflect.DeepEqual(mappings, test.wantPresetMaps) {
+			t.Errorf("%s: wrong presetmaps\nwant %#v\ngot  %#v", test.givenTestCase, test.wantPresetMaps, mappings)
+		}
+		deleted := append([]string(nil), fprovider.deletedPresets...)
+		sort.Strings(deleted)
+		if !reflect.DeepEqual(deleted, test.wantDeletedPreset) {
+			t.Errorf("%s: wrong deleted presets. Want %#v. Got %#v", test.givenTestCase, test.wantDeletedPreset, deleted)
+		}
+	}
+}
+
+func TestApplyPresetsLocalPresets(t *testing.T) {
+	srvr, fakeDB := newPresetApplyServer(t)
+	previous, err := normalizePreset(db.Preset{
+		Name:      "mp4_360p",
+		Container: "mp4",
+		Video:     db.VideoPreset{Codec: "h264", Height: "360", Bitrate: "800000"},
+		Audio:     db.AudioPreset{Codec: "aac", Bitrate: "96000"},
+	}, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	fakeDB.CreatePresetMap(&db.PresetMap{
+		Name:            "mp4_360p",
+		ProviderMapping: map[string]string{"local": "mp4_360p"},
+		OutputOpts:      db.OutputOptions{Extension: "mp4"},
+		Preset:          &previous,
+	})
+	fakeDB.CreateLocalPreset(&db.LocalPreset{Name: "mp4_360p", Preset: previous})
+	localPresets = fakeDB
+	defer func() { localPresets = nil }()
+	const presetFile = `
+presetMaps:
+  - name: mp4_360p
+    providerMapping:
+      local: mp4_360p
+    preset:
+      container: mp4
+      video: {codec: h264, height: "360", bitrate: "900000"}
+      audio: {codec: aac, bitrate: "96000"}
+`
+	r, _ := http.NewRequest("POST", "/presets/apply", strings.NewReader(presetFile))
+	w := httptest.NewRecorder()
+	srvr.ServeHTTP(w, r)
+	if w.Code != http.StatusOK {
+		t.Fatalf("wrong response code. Want %d. Got %d: %s", http.StatusOK, w.Code, w.Body.String())
+	}
+	var got applyPresetsOutputs
+	if err = json.NewDecoder(w.Body).Decode(&got); err != nil {
+		t.Fatal(err)
+	}
+	want := applyProviderPresetOutput{Action: "replace", PresetID: "mp4_360p", PreviousPresetID: "mp4_360p"}
+	if result := got.Results["mp4_360p"].Providers["local"]; result != want {
+		t.Errorf("wrong provider result\nwant %#v\ngot  %#v", want, result)
+	}
+	localPreset, err := fakeDB.GetLocalPreset("mp4_360p")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if localPreset.Preset.Video.Bitrate != "900000" {
+		t.Errorf("local preset wasn't updated: %#v", localPreset.Preset)
+	}
+}
+
+func TestApplyPresetsMediaConvert(t *testing.T) {
+	mc := newFakeMediaConvert()
+	defer mc.Close()
+	cfg := &config.Config{Server: &server.Config{}, MediaConvert: mc.config()}
+	previous, err := normalizePreset(db.Preset{
+		Name:      "mp4_360p",
+		Container: "mp4",
+		Video:     db.VideoPreset{Codec: "h264", Height: "360", Bitrate: "800000", GopSize: "90"},
+		Audio:     db.AudioPreset{Codec: "aac", Bitrate: "96000"},
+	}, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	providerFactory, err := provider.GetProviderFactory("mediaconvert")
+	if err != nil {
+		t.Fatal(err)
+	}
+	mcProvider, err := providerFactory(cfg)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err = mcProvider.CreatePreset(previous); err != nil {
+		t.Fatal(err)
+	}
+	fakeDB := dbtest.NewFakeRepository(false)
+	fakeDB.CreatePresetMap(&db.PresetMap{
+		Name:            "mp4_360p",
+		ProviderMapping: map[string]string{"mediaconvert": "mp4_360p"},
+		OutputOpts:      db.OutputOptions{Extension: "mp4"},
+		Preset:          &previous,
+	})
+	srvr := server.NewSimpleServer(&server.Config{})
+	service, err := NewTranscodingService(cfg, logrus.New())
+	if err != nil {
+		t.Fatal(err)
+	}
+	service.db = fakeDB
+	srvr.Register(service)
+	const presetFile = `
+presetMaps:
+  - name: mp4_360p
+    providerMapping:
+      mediaconvert: mp4_360p
+    preset:
+      container: mp4
+      video: {codec: h264, height: "360", bitrate: "900000", gopSize: "90"}
+      audio: {codec: aac, bitrate: "96000"}
+`
+	r, _ := http.NewRequest("POST", "/presets/apply", strings.NewReader(presetFile))
+	w := httptest.NewRecorder()
+	srvr.ServeHTTP(w, r)
+	if w.Code != http.StatusOK {
+		t.Fatalf("wrong response code. Want %d. Got %d: %s", http.StatusOK, w.Code, w.Body.String())
+	}
+	var got applyPresetsOutputs
+	if err = json.NewDecoder(w.Body).Decode(&got); err != nil {
+		t.Fatal(err)
+	}
+	want := applyProviderPresetOutput{Action: "replace", PresetID: "mp4_360p", PreviousPresetID: "mp4_360p"}
+	if result := got.Results["mp4_360p"].Providers["mediaconvert"]; result != want {
+		t.Errorf("wrong provider result\nwant %#v\ngot  %#v", want, result)
+	}
+	if bitrate := mc.bitrate("mp4_360p"); bitrate != 900000 {
+		t.Errorf("mediaconvert preset wasn't updated. Want bitrate 900000. Got %v", bitrate)
+	}
+	if len(mc.presets) != 1 {
+		t.Errorf("wrong number of stored presets. Want 1. Got %d", len(mc.presets))
+	}
+	presetMap, err := fakeDB.GetPresetMap("mp4_360p")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if presetMap.Preset.Video.Bitrate != "900000" {
+		t.Errorf("presetmap wasn't updated: %#v", presetMap.Preset)
+	}
+}
+
+// newPresetApplyServer returns a server backed by a fake repository holding
+// two presetmaps with normalized presets.
+func newPresetApplyServer(t *testing.T) (*server.SimpleServer, db.Repository) {
+	fprovider = fakeProvider{}
+	fakeDB := dbtest.NewFakeRepository(false)
+	presetMaps := []db.PresetMap{
+		{
+			Name:            "mp4_720p",
+			ProviderMapping: map[string]string{"fake": "mp4_720p-id"},
+			OutputOpts:      db.OutputOptions{Extension: "mp4"},
+			Preset: &db.Preset{
+				Name:      "mp4_720p",
+				Container: "mp4",
+				Video:     db.VideoPreset{Codec: "h264", Width: "1280", Height: "720", Bitrate: "2500000"},
+				Audio:     db.AudioPreset{Codec: "aac", Bitrate: "128000"},
+			},
+		},
+		{
+			Name:            "webm_480p",
+			ProviderMapping: map[string]string{"fake": "webm_480p-id", "zencoder": "webm_480p-zencoder-id"},
+			OutputOpts:      db.OutputOptions{Extension: "webm"},
+			Preset: &db.Preset{
+				Name:      "webm_480p",
+				Container: "webm",
+				Video:     db.VideoPreset{Codec: "vp9", Width: "854", Height: "480", Bitrate: "1000000"},
+				Audio:     db.AudioPreset{Codec: "opus", Bitrate: "96000"},
+			},
+		},
+	}
+	for i := range presetMaps {
+		preset, err := normalizePreset(*presetMaps[i].Preset, nil)
+		if err != nil {
+			t.Fatal(err)
+		}
+		presetMaps[i].Preset = &preset
+		if err = fakeDB.CreatePresetMap(&presetMaps[i]); err != nil {
+			t.Fatal(err)
+		}
+	}
+	srvr := server.NewSimpleServer(&server.Config{})
+	service, err := NewTranscodingService(&config.Config{Server: &server.Config{}}, logrus.New())
+	if err != nil {
+		t.Fatal(err)
+	}
+	service.db = fakeDB
+	srvr.Register(service)
+	return srvr, fakeDB
+}
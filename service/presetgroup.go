@@ -0,0 +1,155 @@
+package service
+
+import (
+	"fmt"
+	"net/http"
+
+	"github.com/NYTimes/gizmo/server"
+	"github.com/video-dev/video-transcoding-api/v2/db"
+	"github.com/video-dev/video-transcoding-api/v2/swagger"
+)
+
+// swagger:route POST /presetgroups presetGroups newPresetGroup
+//
+// Creates a named list of presets that jobs can reference instead of listing
+// their outputs. Ladders are created through the ladders API instead.
+//
+//     Responses:
+//       200: presetGroup
+//       400: invalidPresetGroup
+//       409: presetGroupAlreadyExists
+//       500: genericError
+func (s *TranscodingService) newPresetGroup(r *http.Request) swagger.GizmoJSONResponse {
+	defer r.Body.Close()
+	var input newPresetGroupInput
+	group, err := input.PresetGroup(r.Body)
+	if err != nil {
+		return newInvalidPresetGroupResponse(err)
+	}
+	if errResp := s.checkPresetGroupPresets(group); errResp != nil {
+		return errResp
+	}
+	err = s.db.CreatePresetGroup(&group)
+	switch err {
+	case nil:
+		return newPresetGroupResponse(&group)
+	case db.ErrPresetGroupAlreadyExists:
+		return newPresetGroupAlreadyExistsResponse(err)
+	default:
+		return swagger.NewErrorResponse(err)
+	}
+}
+
+// swagger:route GET /presetgroups/{name} presetGroups getPresetGroup
+//
+// Finds a preset group using its name.
+//
+//     Responses:
+//       200: presetGroup
+//       404: presetGroupNotFound
+//       500: genericError
+func (s *TranscodingService) getPresetGroup(r *http.Request) swagger.GizmoJSONResponse {
+	var params getPresetGroupInput
+	params.loadParams(server.Vars(r))
+	group, err := s.db.GetPresetGroup(params.Name)
+	switch err {
+	case nil:
+		return newPresetGroupResponse(group)
+	case db.ErrPresetGroupNotFound:
+		return newPresetGroupNotFoundResponse(err)
+	default:
+		return swagger.NewErrorResponse(err)
+	}
+}
+
+// swagger:route PUT /presetgroups/{name} presetGroups updatePresetGroup
+//
+// Replaces a preset group using its name. Jobs already submitted aren't
+// affected. Ladders stay ladders, and other groups can't be turned into
+// ladders.
+//
+//     Responses:
+//       200: presetGroup
+//       400: invalidPresetGroup
+//       404: presetGroupNotFound
+//       500: genericError
+func (s *TranscodingService) updatePresetGroup(r *http.Request) swagger.GizmoJSONResponse {
+	defer r.Body.Close()
+	var input updatePresetGroupInput
+	group, err := input.PresetGroup(server.Vars(r), r.Body)
+	if err != nil {
+		return newInvalidPresetGroupResponse(err)
+	}
+	if errResp := s.checkPresetGroupPresets(group); errResp != nil {
+		return errResp
+	}
+	existing, err := s.db.GetPresetGroup(group.Name)
+	if err == db.ErrPresetGroupNotFound {
+		return newPresetGroupNotFoundResponse(err)
+	} else if err != nil {
+		return swagger.NewErrorResponse(err)
+	}
+	// whether the group is a ladder is kept from the stored group
+	group.Ladder = existing.Ladder
+	err = s.db.UpdatePresetGroup(&group)
+	switch err {
+	case nil:
+		return newPresetGroupResponse(&group)
+	case db.ErrPresetGroupNotFound:
+		return newPresetGroupNotFoundResponse(err)
+	default:
+		return swagger.NewErrorResponse(err)
+	}
+}
+
+// swagger:route DELETE /presetgroups/{name} presetGroups deletePresetGroup
+//
+// Deletes a preset group by name. The presets of the group are kept.
+//
+//     Responses:
+//       200: emptyResponse
+//       404: presetGroupNotFound
+//       500: genericError
+func (s *TranscodingService) deletePresetGroup(r *http.Request) swagger.GizmoJSONResponse {
+	var params getPresetGroupInput
+	params.loadParams(server.Vars(r))
+	err := s.db.DeletePresetGroup(&db.PresetGroup{Name: params.Name})
+	switch err {
+	case nil:
+		return emptyResponse(http.StatusOK)
+	case db.ErrPresetGroupNotFound:
+		return newPresetGroupNotFoundResponse(err)
+	default:
+		return swagger.NewErrorResponse(err)
+	}
+}
+
+// swagger:route GET /presetgroups presetGroups listPresetGroups
+//
+// List available preset groups on the API.
+//
+//     Responses:
+//       200: listPresetGroups
+//       500: genericError
+func (s *TranscodingService) listPresetGroups(*http.Request) swagger.GizmoJSONResponse {
+	groups, err := s.db.ListPresetGroups()
+	if err != nil {
+		return swagger.NewErrorResponse(err)
+	}
+	return newListPresetGroupsResponse(groups)
+}
+
+// checkPresetGroupPresets returns an invalidPresetGroup response when any of
+// the presets of the group doesn't exist, or nil when all of them do.
+func (s *TranscodingService) checkPresetGroupPresets(group db.PresetGroup) swagger.GizmoJSONResponse {
+	for _, name := range group.Presets {
+		_, err := s.db.GetPresetMap(name)
+		if err == db.ErrPresetMapNotFound {
+			return newInvalidPresetGroupResponse(fmt.Errorf("preset %q: %s", name, err))
+		}
+		if err != nil {
+			return swagger.NewErrorResponse(err)
+		}
+	}
+	return nil
+}
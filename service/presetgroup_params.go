@@ -0,0 +1,157 @@
+package service
+
+import (
+	"encoding/json"
+	"io"
+	"net/http"
+
+	"github.com/video-dev/video-transcoding-api/v2/db"
+	"github.com/video-dev/video-transcoding-api/v2/swagger"
+)
+
+// swagger:parameters newPresetGroup
+type newPresetGroupInput struct {
+	// in: body
+	// required: true
+	Payload db.PresetGroup
+}
+
+// swagger:parameters getPresetGroup deletePresetGroup
+type getPresetGroupInput struct {
+	// in: path
+	// required: true
+	Name string `json:"name"`
+}
+
+// swagger:parameters updatePresetGroup
+type updatePresetGroupInput struct {
+	// in: path
+	// required: true
+	Name string `json:"name"`
+
+	// in: body
+	// required: true
+	Payload db.PresetGroup
+}
+
+// PresetGroup loads the preset group from the request body and validates
+// it.
+func (p *newPresetGroupInput) PresetGroup(body io.Reader) (db.PresetGroup, error) {
+	err := json.NewDecoder(body).Decode(&p.Payload)
+	if err != nil {
+		return p.Payload, err
+	}
+	// ladders are only created through newLadder
+	p.Payload.Ladder = false
+	return p.Payload, p.Payload.Validate()
+}
+
+func (p *getPresetGroupInput) loadParams(paramsMap map[string]string) {
+	p.Name = paramsMap["name"]
+}
+
+// PresetGroup loads the preset group from the request body, named after the
+// path parameter, and validates it.
+func (p *updatePresetGroupInput) PresetGroup(paramsMap map[string]string, body io.Reader) (db.PresetGroup, error) {
+	p.Name = paramsMap["name"]
+	err := json.NewDecoder(body).Decode(&p.Payload)
+	if err != nil {
+		return p.Payload, err
+	}
+	p.Payload.Name = p.Name
+	return p.Payload, p.Payload.Validate()
+}
+
+// JSON-encoded preset group returned on the newPresetGroup, getPresetGroup
+// and updatePresetGroup operations.
+//
+// swagger:response presetGroup
+type presetGroupResponse struct {
+	// in: body
+	Payload *db.PresetGroup
+
+	baseResponse
+}
+
+// response for the listPresetGroups operation. It's a JSON-encoded object in
+// the format `groupName: groupObject`
+//
+// swagger:response listPresetGroups
+type listPresetGroupsResponse struct {
+	// in: body
+	PresetGroups map[string]db.PresetGroup
+
+	baseResponse
+}
+
+// error returned when the given preset group is not valid or references
+// presets that don't exist.
+//
+// swagger:response invalidPresetGroup
+type invalidPresetGroupResponse struct {
+	// in: body
+	Error *swagger.ErrorResponse
+}
+
+// error returned when the given preset group name is not found on the API.
+//
+// swagger:response presetGroupNotFound
+type presetGroupNotFoundResponse struct {
+	// in: body
+	Error *swagger.ErrorResponse
+}
+
+// error returned when trying to create a new preset group using a name that
+// is already in-use.
+//
+// swagger:response presetGroupAlreadyExists
+type presetGroupAlreadyExistsResponse struct {
+	// in: body
+	Error *swagger.ErrorResponse
+}
+
+func newPresetGroupResponse(group *db.PresetGroup) *presetGroupResponse {
+	return &presetGroupResponse{
+		baseResponse: baseResponse{
+			payload: group,
+			status:  http.StatusOK,
+		},
+	}
+}
+
+func newListPresetGroupsResponse(groups []db.PresetGroup) *listPresetGroupsResponse {
+	groupMap := make(map[string]db.PresetGroup, len(groups))
+	for _, group := range groups {
+		groupMap[group.Name] = group
+	}
+	return &listPresetGroupsResponse{
+		baseResponse: baseResponse{
+			payload: groupMap,
+			status:  http.StatusOK,
+		},
+	}
+}
+
+func newInvalidPresetGroupResponse(err error) *invalidPresetGroupResponse {
+	return &invalidPresetGroupResponse{Error: swagger.NewErrorResponse(err).WithStatus(http.StatusBadRequest)}
+}
+
+func (r *invalidPresetGroupResponse) Result() (int, interface{}, error) {
+	return r.Error.Result()
+}
+
+func newPresetGroupNotFoundResponse(err error) *presetGroupNotFoundResponse {
+	return &presetGroupNotFoundResponse{Error: swagger.NewErrorResponse(err).WithStatus(http.StatusNotFound)}
+}
+
+func (r *presetGroupNotFoundResponse) Result() (int, interface{}, error) {
+	return r.Error.Result()
+}
+
+func newPresetGroupAlreadyExistsResponse(err error) *presetGroupAlreadyExistsResponse {
+	return &presetGroupAlreadyExistsResponse{Error: swagger.NewErrorResponse(err).WithStatus(http.StatusConflict)}
+}
+
+func (r *presetGroupAlreadyExistsResponse) Result() (int, interface{}, error) {
+	return r.Error.Result()
+}
@@ -0,0 +1,247 @@
+package service
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"reflect"
+	"strings"
+	"testing"
+
+	"github.com/NYTimes/gizmo/server"
+	"github.com/sirupsen/logrus"
+	"github.com/video-dev/video-transcoding-api/v2/config"
+	"github.com/video-dev/video-transcoding-api/v2/db"
+	"github.com/video-dev/video-transcoding-api/v2/db/dbtest"
+)
+
+func TestPresetGroups(t *testing.T) {
+	srvr := server.NewSimpleServer(&server.Config{})
+	fakeDB := dbtest.NewFakeRepository(false)
+	for _, name := range []string{"hls_360p", "hls_720p", "hls_1080p"} {
+		err := fakeDB.CreatePresetMap(&db.PresetMap{
+			Name:            name,
+			ProviderMapping: map[string]string{"fake": name + "-id"},
+			OutputOpts:      db.OutputOptions{Extension: "m3u8"},
+		})
+		if err != nil {
+			t.Fatal(err)
+		}
+	}
+	service, err := NewTranscodingService(&config.Config{Server: &server.Config{}}, logrus.New())
+	if err != nil {
+		t.Fatal(err)
+	}
+	service.db = fakeDB
+	srvr.Register(service)
+
+	standard := map[string]interface{}{
+		"name":            "standard",
+		"presets":         []interface{}{"hls_360p", "hls_720p"},
+		"fileNames":       map[string]interface{}{"hls_720p": "hls/720p.m3u8"},
+		"streamingParams": map[string]interface{}{"protocol": "hls", "segmentDuration": float64(6)},
+	}
+	steps := []struct {
+		givenTestCase    string
+		givenMethod      string
+		givenPath        string
+		givenRequestData string
+		wantCode         int
+		wantBody         interface{}
+	}{
+		{
+			"Create a preset group",
+			"POST",
+			"/presetgroups",
+			`{"name":"standard","presets":["hls_360p","hls_720p"],"fileNames":{"hls_720p":"hls/720p.m3u8"},"streamingParams":{"protocol":"hls","segmentDuration":6}}`,
+			http.StatusOK,
+			standard,
+		},
+		{
+			"Create a preset group that already exists",
+			"POST",
+			"/presetgroups",
+			`{"name":"standard","presets":["hls_360p"]}`,
+			http.StatusConflict,
+			map[string]interface{}{"error": "preset group already exists"},
+		},
+		{
+			"Create a preset group with an unknown preset",
+			"POST",
+			"/presetgroups",
+			`{"name":"premium","presets":["hls_360p","hls_4k"]}`,
+			http.StatusBadRequest,
+			map[string]interface{}{"error": `preset "hls_4k": presetmap not found`},
+		},
+		{
+			"Create an invalid preset group",
+			"POST",
+			"/presetgroups",
+			`{"name":"premium","presets":["hls_360p"],"fileNames":{"hls_720p":"720p.m3u8"}}`,
+			http.StatusBadRequest,
+			map[string]interface{}{"error": `file name given for preset "hls_720p", which isn't part of the group`},
+		},
+		{
+			"Get a preset group",
+			"GET",
+			"/presetgroups/standard",
+			"",
+			http.StatusOK,
+			standard,
+		},
+		{
+			"Get a preset group that doesn't exist",
+			"GET",
+			"/presetgroups/premium",
+			"",
+			http.StatusNotFound,
+			map[string]interface{}{"error": "preset group not found"},
+		},
+		{
+			"Update a preset group",
+			"PUT",
+			"/presetgroups/standard",
+			`{"presets":["hls_720p","hls_1080p"]}`,
+			http.StatusOK,
+			map[string]interface{}{
+				"name":            "standard",
+				"presets":         []interface{}{"hls_720p", "hls_1080p"},
+				"streamingParams": map[string]interface{}{"protocol": "", "segmentDuration": float64(0)},
+			},
+		},
+		{
+			"Update a preset group that doesn't exist",
+			"PUT",
+			"/presetgroups/premium",
+			`{"presets":["hls_1080p"]}`,
+			http.StatusNotFound,
+			map[string]interface{}{"error": "preset group not found"},
+		},
+		{
+			"List preset groups",
+			"GET",
+			"/presetgroups",
+			"",
+			http.StatusOK,
+			map[string]interface{}{
+				"standard": map[string]interface{}{
+					"name":            "standard",
+					"presets":         []interface{}{"hls_720p", "hls_1080p"},
+					"streamingParams": map[string]interface{}{"protocol": "", "segmentDuration": float64(0)},
+				},
+			},
+		},
+		{
+			"Delete a preset group",
+			"DELETE",
+			"/presetgroups/standard",
+			"",
+			http.StatusOK,
+			nil,
+		},
+		{
+			"Delete a preset group that doesn't exist",
+			"DELETE",
+			"/presetgroups/standard",
+			"",
+			http.StatusNotFound,
+			map[string]interface{}{"error": "preset group not found"},
+		},
+	}
+	for _, step := range steps {
+		r, _ := http.NewRequest(step.givenMethod, step.givenPath, strings.NewReader(step.givenRequestData))
+		w := httptest.NewRecorder()
+		srvr.ServeHTTP(w, r)
+		if w.Code != step.wantCode {
+			t.Errorf("%s: wrong response code. Want %d. Got %d", step.givenTestCase, step.wantCode, w.Code)
+		}
+		var got interface{}
+		if w.Body.Len() > 0 {
+			if err = json.NewDecoder(w.Body).Decode(&got); err != nil {
+				t.Errorf("%s: unable to JSON decode response body: %s", step.givenTestCase, err)
+			}
+		}
+		if !reflect.DeepEqual(got, step.wantBody) {
+			t.Errorf("%s: expected response body of\n%#v;\ngot\n%#v", step.givenTestCase, step.wantBody, got)
+		}
+	}
+	if _, err = fakeDB.GetPresetMap("hls_720p"); err != nil {
+		t.Errorf("presets of the deleted group should be kept, got %v", err)
+	}
+}
+
+func TestPresetGroupsLadderFlag(t *testing.T) {
+	srvr := server.NewSimpleServer(&server.Config{})
+	fakeDB := dbtest.NewFakeRepository(false)
+	err := fakeDB.CreatePresetMap(&db.PresetMap{
+		Name:            "hls_360p",
+		ProviderMapping: map[string]string{"fake": "hls_360p-id"},
+		OutputOpts:      db.OutputOptions{Extension: "m3u8"},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	fakeDB.CreatePresetGroup(&db.PresetGroup{Name: "myladder", Presets: []string{"hls_360p"}, Ladder: true})
+	service, err := NewTranscodingService(&config.Config{Server: &server.Config{}}, logrus.New())
+	if err != nil {
+		t.Fatal(err)
+	}
+	service.db = fakeDB
+	srvr.Register(service)
+
+	tests := []struct {
+		givenTestCase    string
+		givenMethod      string
+		givenPath        string
+		givenRequestData string
+		wantGroup        string
+		wantLadder       bool
+	}{
+		{
+			"Create a preset group flagged as a ladder",
+			"POST",
+			"/presetgroups",
+			`{"name":"standard","presets":["hls_360p"],"ladder":true}`,
+			"standard",
+			false,
+		},
+		{
+			"Turn a preset group into a ladder",
+			"PUT",
+			"/presetgroups/standard",
+			`{"presets":["hls_360p"],"ladder":true}`,
+			"standard",
+			false,
+		},
+		{
+			"Update a ladder without the flag",
+			"PUT",
+			"/presetgroups/myladder",
+			`{"presets":["hls_360p"]}`,
+			"myladder",
+			true,
+		},
+	}
+	for _, test := range tests {
+		r, _ := http.NewRequest(test.givenMethod, test.givenPath, strings.NewReader(test.givenRequestData))
+		w := httptest.NewRecorder()
+		srvr.ServeHTTP(w, r)
+		if w.Code != http.StatusOK {
+			t.Errorf("%s: wrong response code. Want %d. Got %d", test.givenTestCase, http.StatusOK, w.Code)
+		}
+		var got db.PresetGroup
+		if err = json.NewDecoder(w.Body).Decode(&got); err != nil {
+			t.Errorf("%s: unable to JSON decode response body: %s", test.givenTestCase, err)
+		}
+		if got.Ladder != test.wantLadder {
+			t.Errorf("%s: wrong ladder flag returned. Want %v. Got %v", test.givenTestCase, test.wantLadder, got.Ladder)
+		}
+		stored, err := fakeDB.GetPresetGroup(test.wantGroup)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if stored.Ladder != test.wantLadder {
+			t.Errorf("%s: wrong ladder flag stored. Want %v. Got %v", test.givenTestCase, test.wantLadder, stored.Ladder)
+		}
+	}
+}
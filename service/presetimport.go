@@ -0,0 +1,147 @@
+package service
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"path"
+
+	"github.com/NYTimes/gizmo/server"
+	"github.com/video-dev/video-transcoding-api/v2/db"
+	"github.com/video-dev/video-transcoding-api/v2/internal/provider"
+	"github.com/video-dev/video-transcoding-api/v2/swagger"
+)
+
+// Statuses of provider presets in import reports.
+const (
+	presetImportImported = "imported"
+	presetImportReady    = "ready"
+	presetImportSkipped  = "skipped"
+	presetImportError    = "error"
+)
+
+// swagger:route POST /providers/{name}/presets/import presets importPresets
+//
+// Imports the presets stored in the provider, creating a presetmap for each
+// of them. Presets are converted to canonical presets and the extension of
+// the outputs is inferred from their containers. Presets whose names are
+// already in use are skipped.
+//
+//     Responses:
+//       200: importPresetsOutputs
+//       400: invalidPreset
+//       404: providerNotFound
+//       500: genericError
+func (s *TranscodingService) importPresets(r *http.Request) swagger.GizmoJSONResponse {
+	defer r.Body.Close()
+	var params importPresetsInput
+	params.loadParams(server.Vars(r))
+	if err := json.NewDecoder(r.Body).Decode(&params.Payload); err != nil && err != io.EOF {
+		return newInvalidPresetResponse(err)
+	}
+	for _, pattern := range params.Payload.Names {
+		if _, err := path.Match(pattern, ""); err != nil {
+			return newInvalidPresetResponse(fmt.Errorf("invalid name filter %q: %s", pattern, err))
+		}
+	}
+
+	providerFactory, err := provider.GetProviderFactory(params.Name)
+	if err != nil {
+		return newProviderNotFoundResponse(err)
+	}
+	providerObj, err := providerFactory(s.config)
+	if err != nil {
+		return swagger.NewErrorResponse(fmt.Errorf("initializing provider: %s", err))
+	}
+	lister, ok := providerObj.(provider.PresetLister)
+	normalizer, canNormalize := providerObj.(provider.PresetNormalizer)
+	if !ok || !canNormalize {
+		return newInvalidPresetResponse(fmt.Errorf("provider %q doesn't support importing presets", params.Name))
+	}
+	presets, err := lister.ListPresets()
+	if err != nil {
+		return swagger.NewErrorResponse(fmt.Errorf("listing presets: %s", err))
+	}
+
+	output := importPresetsOutputs{
+		Provider: params.Name,
+		DryRun:   params.Payload.DryRun,
+		Results:  make(map[string]importPresetOutput, len(presets)),
+	}
+	for _, stored := range presets {
+		if !matchesAny(params.Payload.Names, stored.ID) {
+			continue
+		}
+		output.Results[stored.ID] = s.importPreset(params.Name, stored, normalizer, params.Payload.DryRun)
+	}
+	return &importPresetsResponse{
+		baseResponse: baseResponse{
+			payload: output,
+			status:  http.StatusOK,
+		},
+	}
+}
+
+// importPreset creates a presetmap named after the ID of the given provider
+// preset, unless dryRun is true.
+func (s *TranscodingService) importPreset(providerName string, stored provider.StoredPreset, normalizer provider.PresetNormalizer, dryRun bool) importPresetOutput {
+	result := importPresetOutput{PresetMap: stored.ID, Status: presetImportError}
+	preset, err := normalizer.NormalizePreset(stored.Preset)
+	if err != nil {
+		result.Error = "normalizing preset: " + err.Error()
+		return result
+	}
+	preset.Name = stored.ID
+	result.Preset = &preset
+	if preset.Container == "" {
+		result.Error = "unable to infer the extension: the preset has no container"
+		return result
+	}
+	presetMap := db.PresetMap{
+		Name:            stored.ID,
+		ProviderMapping: map[string]string{providerName: stored.ID},
+		OutputOpts:      db.OutputOptions{Extension: preset.Container},
+		Preset:          &preset,
+	}
+	if err = presetMap.OutputOpts.Validate(); err != nil {
+		result.Error = "invalid outputOptions: " + err.Error()
+		return result
+	}
+
+	if dryRun {
+		_, err = s.db.GetPresetMap(stored.ID)
+		switch err {
+		case nil:
+			err = db.ErrPresetMapAlreadyExists
+		case db.ErrPresetMapNotFound:
+			result.Status = presetImportReady
+			return result
+		}
+	} else {
+		err = s.db.CreatePresetMap(&presetMap)
+		if err == nil {
+			result.Status = presetImportImported
+			return result
+		}
+	}
+	if err == db.ErrPresetMapAlreadyExists {
+		result.Status = presetImportSkipped
+	}
+	result.Error = err.Error()
+	return result
+}
+
+// matchesAny returns whether the name matches any of the given shell
+// patterns. Every name matches an empty list of patterns.
+func matchesAny(patterns []string, name string) bool {
+	if len(patterns) == 0 {
+		return true
+	}
+	for _, pattern := range patterns {
+		if ok, _ := path.Match(pattern, name); ok {
+			return true
+		}
+	}
+	return false
+}
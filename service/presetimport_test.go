@@ -0,0 +1,164 @@
+package service
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"reflect"
+	"sort"
+	"strings"
+	"testing"
+
+	"github.com/NYTimes/gizmo/server"
+	"github.com/sirupsen/logrus"
+	"github.com/video-dev/video-transcoding-api/v2/config"
+	"github.com/video-dev/video-transcoding-api/v2/db"
+	"github.com/video-dev/video-transcoding-api/v2/db/dbtest"
+)
+
+func TestImportPresets(t *testing.T) {
+	storedPresets := map[string]string{"mp4_1080p": "mp4", "webm_720p": "webm", "hls_720p": "m3u8", "broken": ""}
+	tests := []struct {
+		givenTestCase    string
+		givenProvider    string
+		givenRequestData string
+		wantCode         int
+		wantBody         map[string]interface{}
+		wantPresetMaps   []string
+	}{
+		{
+			"Import every preset",
+			"fake",
+			"",
+			http.StatusOK,
+			map[string]interface{}{
+				"provider": "fake",
+				"dryRun":   false,
+				"results": map[string]interface{}{
+					"mp4_1080p": map[string]interface{}{
+						"presetMap": "mp4_1080p",
+						"status":    "imported",
+						"preset":    importedPreset("mp4_1080p", "mp4"),
+					},
+					"webm_720p": map[string]interface{}{
+						"presetMap": "webm_720p",
+						"status":    "imported",
+						"preset":    importedPreset("webm_720p", "webm"),
+					},
+					"hls_720p": map[string]interface{}{
+						"presetMap": "hls_720p",
+						"status":    "skipped",
+						"preset":    importedPreset("hls_720p", "m3u8"),
+						"error":     "presetmap already exists",
+					},
+					"broken": map[string]interface{}{
+						"presetMap": "broken",
+						"status":    "error",
+						"preset":    importedPreset("broken", ""),
+						"error":     "unable to infer the extension: the preset has no container",
+					},
+				},
+			},
+			[]string{"hls_720p", "mp4_1080p", "webm_720p"},
+		},
+		{
+			"Dry-run import of filtered presets",
+			"fake",
+			`{"dryRun":true,"names":["mp4_*","hls_*"]}`,
+			http.StatusOK,
+			map[string]interface{}{
+				"provider": "fake",
+				"dryRun":   true,
+				"results": map[string]interface{}{
+					"mp4_1080p": map[string]interface{}{
+						"presetMap": "mp4_1080p",
+						"status":    "ready",
+						"preset":    importedPreset("mp4_1080p", "mp4"),
+					},
+					"hls_720p": map[string]interface{}{
+						"presetMap": "hls_720p",
+						"status":    "skipped",
+						"preset":    importedPreset("hls_720p", "m3u8"),
+						"error":     "presetmap already exists",
+					},
+				},
+			},
+			[]string{"hls_720p"},
+		},
+		{
+			"Invalid name filter",
+			"fake",
+			`{"names":["mp4_["]}`,
+			http.StatusBadRequest,
+			map[string]interface{}{"error": `invalid name filter "mp4_[": syntax error in pattern`},
+			[]string{"hls_720p"},
+		},
+		{
+			"Provider that doesn't exist",
+			"unknown",
+			"",
+			http.StatusNotFound,
+			map[string]interface{}{"error": "provider not found"},
+			[]string{"hls_720p"},
+		},
+	}
+	for _, test := range tests {
+		fprovider = fakeProvider{storedPresets: storedPresets}
+		srvr := server.NewSimpleServer(&server.Config{})
+		fakeDB := dbtest.NewFakeRepository(false)
+		err := fakeDB.CreatePresetMap(&db.PresetMap{
+			Name:            "hls_720p",
+			ProviderMapping: map[string]string{"fake": "hls_720p"},
+			OutputOpts:      db.OutputOptions{Extension: "m3u8"},
+		})
+		if err != nil {
+			t.Fatal(err)
+		}
+		service, err := NewTranscodingService(&config.Config{Server: &server.Config{}}, logrus.New())
+		if err != nil {
+			t.Fatal(err)
+		}
+		service.db = fakeDB
+		srvr.Register(service)
+		r, _ := http.NewRequest("POST", "/providers/"+test.givenProvider+"/presets/import", strings.NewReader(test.givenRequestData))
+		w := httptest.NewRecorder()
+		srvr.ServeHTTP(w, r)
+		if w.Code != test.wantCode {
+			t.Errorf("%s: wrong response code. Want %d. Got %d", test.givenTestCase, test.wantCode, w.Code)
+		}
+		var got map[string]interface{}
+		err = json.NewDecoder(w.Body).Decode(&got)
+		if err != nil {
+			t.Errorf("%s: unable to JSON decode response body: %s", test.givenTestCase, err)
+		}
+		if !reflect.DeepEqual(got, test.wantBody) {
+			t.Errorf("%s: expected response body of\n%#v;\ngot\n%#v", test.givenTestCase, test.wantBody, got)
+		}
+		presetMaps, err := fakeDB.ListPresetMaps()
+		if err != nil {
+			t.Fatal(err)
+		}
+		var names []string
+		for _, presetMap := range presetMaps {
+			names = append(names, presetMap.Name)
+		}
+		sort.Strings(names)
+		if !reflect.DeepEqual(names, test.wantPresetMaps) {
+			t.Errorf("%s: wrong presetmaps. Want %#v. Got %#v", test.givenTestCase, test.wantPresetMaps, names)
+		}
+	}
+}
+
+// importedPreset is the JSON representation of the canonical preset imported
+// from the fake provider.
+func importedPreset(name, container string) map[string]interface{} {
+	preset := map[string]interface{}{
+		"name":  name,
+		"video": map[string]interface{}{"crop": map[string]interface{}{}},
+		"audio": map[string]interface{}{},
+	}
+	if container != "" {
+		preset["container"] = container
+	}
+	return preset
+}
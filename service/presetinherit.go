@@ -0,0 +1,56 @@
+package service
+
+import (
+	"errors"
+	"sort"
+
+	"github.com/video-dev/video-transcoding-api/v2/db"
+)
+
+// resolvePreset merges the given preset with its base presets, looked up in
+// the stored presetmaps.
+func (s *TranscodingService) resolvePreset(preset db.Preset) (db.Preset, error) {
+	return db.ResolvePreset(preset, s.lookupPreset)
+}
+
+// lookupPreset returns the canonical preset of the given presetmap, which is
+// already merged with its own base presets.
+func (s *TranscodingService) lookupPreset(name string) (*db.Preset, error) {
+	presetMap, err := s.db.GetPresetMap(name)
+	if err != nil {
+		return nil, err
+	}
+	if presetMap.Preset == nil {
+		return nil, errors.New("presetmap has no canonical preset")
+	}
+	return presetMap.Preset, nil
+}
+
+// descendantPresets returns the names of the stored presets that inherit,
+// directly or through other presets, from the given preset, alphabetically
+// ordered.
+func (s *TranscodingService) descendantPresets(name string) ([]string, error) {
+	presetMaps, err := s.db.ListPresetMaps()
+	if err != nil {
+		return nil, err
+	}
+	children := make(map[string][]string)
+	for _, presetMap := range presetMaps {
+		if presetMap.Preset != nil && presetMap.Preset.Base != "" {
+			children[presetMap.Preset.Base] = append(children[presetMap.Preset.Base], presetMap.Name)
+		}
+	}
+	var descendants []string
+	visited := map[string]bool{name: true}
+	for queue := children[name]; len(queue) > 0; queue = queue[1:] {
+		child := queue[0]
+		if visited[child] {
+			continue
+		}
+		visited[child] = true
+		descendants = append(descendants, child)
+		queue = append(queue, children[child]...)
+	}
+	sort.Strings(descendants)
+	return descendants, nil
+}
@@ -0,0 +1,138 @@
+package service
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"reflect"
+	"strings"
+	"testing"
+
+	"github.com/NYTimes/gizmo/server"
+	"github.com/sirupsen/logrus"
+	"github.com/video-dev/video-transcoding-api/v2/config"
+	"github.com/video-dev/video-transcoding-api/v2/db"
+	"github.com/video-dev/video-transcoding-api/v2/db/dbtest"
+)
+
+func TestPresetInheritance(t *testing.T) {
+	fprovider = fakeProvider{}
+	srvr := server.NewSimpleServer(&server.Config{})
+	fakeDB := dbtest.NewFakeRepository(false)
+	service, err := NewTranscodingService(&config.Config{Server: &server.Config{}}, logrus.New())
+	if err != nil {
+		t.Fatal(err)
+	}
+	service.db = fakeDB
+	srvr.Register(service)
+
+	steps := []struct {
+		givenTestCase    string
+		givenMethod      string
+		givenPath        string
+		givenRequestData string
+		wantCode         int
+		wantBody         map[string]interface{}
+	}{
+		{
+			"Create the base preset",
+			"POST",
+			"/presets",
+			`{"providers":["fake"],"preset":{"name":"mp4_base","container":"mp4","video":{"codec":"h264","profile":"high","width":"1920","height":"1080","bitrate":"5000000","gopSize":"60"},"audio":{"codec":"aac","bitrate":"128000"}}}`,
+			http.StatusOK,
+			map[string]interface{}{
+				"Results":   map[string]interface{}{"fake": map[string]interface{}{"PresetID": "presetID_here", "Error": ""}},
+				"PresetMap": "mp4_base",
+			},
+		},
+		{
+			"Create a preset overriding the dimensions",
+			"POST",
+			"/presets",
+			`{"providers":["fake"],"preset":{"name":"mp4_720p","base":"mp4_base","video":{"width":"1280","height":"720","bitrate":"2500000"}}}`,
+			http.StatusOK,
+			map[string]interface{}{
+				"Results":   map[string]interface{}{"fake": map[string]interface{}{"PresetID": "presetID_here", "Error": ""}},
+				"PresetMap": "mp4_720p",
+			},
+		},
+		{
+			"Create a preset inheriting from a derived preset",
+			"POST",
+			"/presets",
+			`{"providers":["fake"],"preset":{"name":"mp4_720p_low","base":"mp4_720p","video":{"bitrate":"1500000"},"audio":{"bitrate":"96000"}}}`,
+			http.StatusOK,
+			map[string]interface{}{
+				"Results":   map[string]interface{}{"fake": map[string]interface{}{"PresetID": "presetID_here", "Error": ""}},
+				"PresetMap": "mp4_720p_low",
+			},
+		},
+		{
+			"Base preset that doesn't exist",
+			"POST",
+			"/presets",
+			`{"providers":["fake"],"preset":{"name":"orphan","base":"unknown","video":{"width":"640"}}}`,
+			http.StatusBadRequest,
+			map[string]interface{}{"error": `invalid preset: base preset "unknown": presetmap not found`},
+		},
+		{
+			"Inheritance cycle",
+			"PUT",
+			"/presets/mp4_base",
+			`{"preset":{"base":"mp4_720p_low"}}`,
+			http.StatusBadRequest,
+			map[string]interface{}{"error": "invalid preset: preset inheritance cycle: mp4_base -> mp4_720p_low -> mp4_720p -> mp4_base"},
+		},
+		{
+			"Update the base preset",
+			"PUT",
+			"/presets/mp4_base",
+			`{"preset":{"container":"mp4","video":{"codec":"h264","profile":"main","width":"1920","height":"1080","bitrate":"5000000","gopSize":"60"},"audio":{"codec":"aac","bitrate":"128000"}}}`,
+			http.StatusOK,
+			map[string]interface{}{
+				"results":         map[string]interface{}{"fake": map[string]interface{}{"presetId": "presetID_here", "previousPresetId": "presetID_here"}},
+				"presetMap":       "mp4_base",
+				"affectedPresets": []interface{}{"mp4_720p", "mp4_720p_low"},
+			},
+		},
+	}
+	for _, step := range steps {
+		r, _ := http.NewRequest(step.givenMethod, step.givenPath, strings.NewReader(step.givenRequestData))
+		w := httptest.NewRecorder()
+		srvr.ServeHTTP(w, r)
+		if w.Code != step.wantCode {
+			t.Errorf("%s: wrong response code. Want %d. Got %d", step.givenTestCase, step.wantCode, w.Code)
+		}
+		var got map[string]interface{}
+		if err = json.NewDecoder(w.Body).Decode(&got); err != nil {
+			t.Errorf("%s: unable to JSON decode response body: %s", step.givenTestCase, err)
+		}
+		if !reflect.DeepEqual(got, step.wantBody) {
+			t.Errorf("%s: expected response body of\n%#v;\ngot\n%#v", step.givenTestCase, step.wantBody, got)
+		}
+	}
+
+	presetMap, err := fakeDB.GetPresetMap("mp4_720p_low")
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := db.Preset{
+		Name:      "mp4_720p_low",
+		Base:      "mp4_720p",
+		Container: "mp4",
+		Video: db.VideoPreset{
+			Codec: "h264", Profile: "high", Width: "1280", Height: "720", Bitrate: "1500000", GopSize: "60",
+		},
+		Audio: db.AudioPreset{Codec: "aac", Bitrate: "96000"},
+	}
+	want, err = normalizePreset(want, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !reflect.DeepEqual(*presetMap.Preset, want) {
+		t.Errorf("wrong merged preset\nwant %#v\ngot  %#v", want, *presetMap.Preset)
+	}
+	if presetMap.OutputOpts.Extension != "mp4" {
+		t.Errorf("wrong extension. Want %q. Got %q", "mp4", presetMap.OutputOpts.Extension)
+	}
+}
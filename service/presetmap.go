@@ -89,15 +89,28 @@ func (s *TranscodingService) updatePresetMap(r *http.Request) swagger.GizmoJSONR
 
 // swagger:route DELETE /presetmaps/{name} presets deletePresetMap
 //
-// Deletes a presetmap by name.
+// Deletes a presetmap by name. Presetmaps used by jobs that haven't finished
+// yet are only deleted when force is set.
 //
 //     Responses:
 //       200: emptyResponse
+//       400: invalidPreset
 //       404: presetNotFound
+//       409: presetInUse
 //       500: genericError
+//       503: presetUsageUnknown
 func (s *TranscodingService) deletePresetMap(r *http.Request) swagger.GizmoJSONResponse {
 	var params getPresetMapInput
 	params.loadParams(server.Vars(r))
+	var input deletePresetInput
+	if err := input.loadParams(r.URL.Query()); err != nil {
+		return newInvalidPresetMapResponse(err)
+	}
+	if !input.Force {
+		if errResp := s.checkPresetNotInUse(params.Name); errResp != nil {
+			return errResp
+		}
+	}
 	err := s.db.DeletePresetMap(&db.PresetMap{Name: params.Name})
 
 	switch err {
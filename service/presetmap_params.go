@@ -6,6 +6,7 @@ import (
 	"fmt"
 	"io"
 	"net/http"
+	"net/url"
 
 	"github.com/video-dev/video-transcoding-api/v2/db"
 	"github.com/video-dev/video-transcoding-api/v2/swagger"
@@ -21,13 +22,22 @@ type presetMapResponse struct {
 	baseResponse
 }
 
-// swagger:parameters getPreset deletePreset deletePresetMap
+// swagger:parameters getPreset getProviderPresets updateProviderPresets reconcilePreset listPresetVersions deletePreset deletePresetMap
 type getPresetMapInput struct {
 	// in: path
 	// required: true
 	Name string `json:"name"`
 }
 
+// swagger:parameters deletePreset deletePresetMap
+type deletePresetInput struct {
+	// deletes the preset even when it's used by jobs that haven't
+	// finished yet
+	//
+	// in: query
+	Force bool `json:"force"`
+}
+
 // swagger:parameters updatePreset
 type updatePresetMapInput struct {
 	// in: path
@@ -65,6 +75,28 @@ type invalidPresetMapResponse struct {
 	Error *swagger.ErrorResponse
 }
 
+// error returned when trying to delete a preset used by jobs that haven't
+// finished yet, listing the IDs of the jobs.
+//
+// swagger:response presetInUse
+type presetInUseResponse struct {
+	// in: body
+	Error *swagger.ErrorResponse
+
+	jobs []string
+}
+
+// error returned when trying to delete a preset used by jobs whose status
+// couldn't be retrieved from their providers, listing the IDs of the jobs.
+//
+// swagger:response presetUsageUnknown
+type presetUsageUnknownResponse struct {
+	// in: body
+	Error *swagger.ErrorResponse
+
+	jobs []string
+}
+
 // error returned when trying to create a new preset using a name that is
 // already in-use.
 //
@@ -94,6 +126,32 @@ func newPresetMapResponse(preset *db.PresetMap) *presetMapResponse {
 	}
 }
 
+// every version of a preset, from the oldest to the latest one.
+//
+// swagger:response presetVersions
+type presetVersionsResponse struct {
+	// in: body
+	Payload presetVersions
+
+	baseResponse
+}
+
+type presetVersions struct {
+	Name     string         `json:"name"`
+	Versions []db.PresetMap `json:"versions"`
+}
+
+func newPresetVersionsResponse(name string, versions []db.PresetMap) *presetVersionsResponse {
+	payload := presetVersions{Name: name, Versions: versions}
+	return &presetVersionsResponse{
+		Payload: payload,
+		baseResponse: baseResponse{
+			payload: payload,
+			status:  http.StatusOK,
+		},
+	}
+}
+
 func newPresetMapNotFoundResponse(err error) *presetMapNotFoundResponse {
 	return &presetMapNotFoundResponse{Error: swagger.NewErrorResponse(err).WithStatus(http.StatusNotFound)}
 }
@@ -118,6 +176,37 @@ func (r *presetMapAlreadyExistsResponse) Result() (int, interface{}, error) {
 	return r.Error.Result()
 }
 
+func newPresetInUseResponse(name string, jobs []string) *presetInUseResponse {
+	err := fmt.Errorf("preset %q is used by %d active job(s), use force=true to delete it anyway", name, len(jobs))
+	return &presetInUseResponse{Error: swagger.NewErrorResponse(err).WithStatus(http.StatusConflict), jobs: jobs}
+}
+
+func (r *presetInUseResponse) Result() (int, interface{}, error) {
+	status, _, _ := r.Error.Result()
+	return status, nil, &presetInUseError{Message: r.Error.Message, Jobs: r.jobs}
+}
+
+func newPresetUsageUnknownResponse(name string, jobs []string) *presetUsageUnknownResponse {
+	err := fmt.Errorf("couldn't check whether %d job(s) using preset %q are active, use force=true to delete it anyway", len(jobs), name)
+	return &presetUsageUnknownResponse{Error: swagger.NewErrorResponse(err).WithStatus(http.StatusServiceUnavailable), jobs: jobs}
+}
+
+func (r *presetUsageUnknownResponse) Result() (int, interface{}, error) {
+	status, _, _ := r.Error.Result()
+	return status, nil, &presetInUseError{Message: r.Error.Message, Jobs: r.jobs}
+}
+
+// presetInUseError is the body of presetInUse and presetUsageUnknown
+// responses.
+type presetInUseError struct {
+	Message string   `json:"error"`
+	Jobs    []string `json:"jobs"`
+}
+
+func (e *presetInUseError) Error() string {
+	return e.Message
+}
+
 func newListPresetMapsResponse(presetsMap []db.PresetMap) *listPresetMapsResponse {
 	Map := make(map[string]db.PresetMap, len(presetsMap))
 	for _, presetMap := range presetsMap {
@@ -153,6 +242,12 @@ func (p *getPresetMapInput) loadParams(paramsMap map[string]string) {
 	p.Name = paramsMap["name"]
 }
 
+func (p *deletePresetInput) loadParams(query url.Values) error {
+	var err error
+	p.Force, err = boolParam(query, "force")
+	return err
+}
+
 func (p *updatePresetMapInput) PresetMap(paramsMap map[string]string, body io.Reader) (db.PresetMap, error) {
 	p.Name = paramsMap["name"]
 	err := json.NewDecoder(body).Decode(&p.Payload)
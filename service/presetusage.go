@@ -0,0 +1,144 @@
+package service
+
+import (
+	"sort"
+	"sync"
+	"time"
+
+	"github.com/video-dev/video-transcoding-api/v2/db"
+	"github.com/video-dev/video-transcoding-api/v2/internal/provider"
+	"github.com/video-dev/video-transcoding-api/v2/swagger"
+)
+
+// presetSnapshotsOf returns the canonical presets of the given outputs,
+// keyed by preset name. Presetmaps that only have a provider mapping are
+// snapshotted from their local presets, and left out when they don't have
+// one either.
+func (s *TranscodingService) presetSnapshotsOf(outputs []db.TranscodeOutput) db.PresetSnapshots {
+	snapshots := make(db.PresetSnapshots, len(outputs))
+	for _, output := range outputs {
+		if preset := s.canonicalPreset(output.Preset); preset != nil {
+			snapshots[output.Preset.Name] = *preset
+		}
+	}
+	return snapshots
+}
+
+// checkPresetNotInUse returns a presetInUse response when the given preset
+// is used by active jobs, a presetUsageUnknown response when the status of
+// some of the jobs using it can't be retrieved, or nil when it can be
+// deleted.
+func (s *TranscodingService) checkPresetNotInUse(name string) swagger.GizmoJSONResponse {
+	usage, err := s.presetUsage(name)
+	if err != nil {
+		return swagger.NewErrorResponse(err)
+	}
+	if len(usage.active) > 0 {
+		return newPresetInUseResponse(name, usage.active)
+	}
+	if len(usage.unknown) > 0 {
+		return newPresetUsageUnknownResponse(name, usage.unknown)
+	}
+	return nil
+}
+
+// activeJobsWindow is how far back presetUsage looks for jobs. Older jobs
+// are considered to have reached a terminal status.
+const activeJobsWindow = 7 * 24 * time.Hour
+
+// jobStatusConcurrency is the maximum number of job status lookups
+// presetUsage runs at the same time.
+const jobStatusConcurrency = 8
+
+// presetJobs lists the IDs of the jobs using a preset, alphabetically
+// ordered. The status of unknown jobs couldn't be retrieved from their
+// providers.
+type presetJobs struct {
+	active  []string
+	unknown []string
+}
+
+// presetUsage returns the jobs created within activeJobsWindow that use the
+// given preset and haven't reached a terminal status yet.
+func (s *TranscodingService) presetUsage(name string) (presetJobs, error) {
+	var usage presetJobs
+	jobs, err := s.db.ListJobs(db.JobFilter{Since: time.Now().Add(-activeJobsWindow)})
+	if err != nil {
+		return usage, err
+	}
+	var using []*db.Job
+	for i := range jobs {
+		if usesPreset(&jobs[i], name) {
+			using = append(using, &jobs[i])
+		}
+	}
+
+	type jobResult struct {
+		id     string
+		active bool
+		err    error
+	}
+	resultCh := make(chan jobResult, len(using))
+	sem := make(chan struct{}, jobStatusConcurrency)
+	var wg sync.WaitGroup
+	for _, job := range using {
+		wg.Add(1)
+		go func(job *db.Job) {
+			defer wg.Done()
+			sem <- struct{}{}
+			defer func() { <-sem }()
+			active, err := s.jobActive(job)
+			resultCh <- jobResult{id: job.ID, active: active, err: err}
+		}(job)
+	}
+	wg.Wait()
+	close(resultCh)
+
+	for res := range resultCh {
+		switch {
+		case res.err != nil:
+			usage.unknown = append(usage.unknown, res.id)
+		case res.active:
+			usage.active = append(usage.active, res.id)
+		}
+	}
+	sort.Strings(usage.active)
+	sort.Strings(usage.unknown)
+	return usage, nil
+}
+
+func usesPreset(job *db.Job, name string) bool {
+	for _, presetName := range job.PresetNames() {
+		if presetName == name {
+			return true
+		}
+	}
+	return false
+}
+
+// jobActive queries the provider for the status of the job, returning an
+// error when it can't be retrieved. Jobs the provider doesn't know about
+// anymore, or whose provider isn't configured, can't be running there.
+func (s *TranscodingService) jobActive(job *db.Job) (bool, error) {
+	providerFactory, err := provider.GetProviderFactory(job.ProviderName)
+	if err != nil {
+		return false, nil
+	}
+	providerObj, err := providerFactory(s.config)
+	if err != nil {
+		return false, nil
+	}
+	status, err := providerObj.JobStatus(job)
+	if err != nil {
+		if _, notFound := err.(provider.JobNotFoundError); notFound {
+			return false, nil
+		}
+		return false, err
+	}
+	switch status.Status {
+	case provider.StatusFinished, provider.StatusFailed, provider.StatusCanceled:
+		return false, nil
+	default:
+		return true, nil
+	}
+}
@@ -0,0 +1,225 @@
+package service
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/NYTimes/gizmo/server"
+	"github.com/sirupsen/logrus"
+	"github.com/video-dev/video-transcoding-api/v2/config"
+	"github.com/video-dev/video-transcoding-api/v2/db"
+	"github.com/video-dev/video-transcoding-api/v2/db/dbtest"
+)
+
+func TestDeletePresetInUse(t *testing.T) {
+	inUse := map[string]interface{}{
+		"error": `preset "mp4_720p" is used by 1 active job(s), use force=true to delete it anyway`,
+		"jobs":  []interface{}{"active-job"},
+	}
+	tests := []struct {
+		givenTestCase  string
+		givenMethod    string
+		givenPath      string
+		wantCode       int
+		wantBody       map[string]interface{}
+		wantPresetMaps int
+	}{
+		{
+			"Presetmap used by an active job",
+			"DELETE",
+			"/presetmaps/mp4_720p",
+			http.StatusConflict,
+			inUse,
+			2,
+		},
+		{
+			"Preset used by an active job",
+			"DELETE",
+			"/presets/mp4_720p",
+			http.StatusConflict,
+			inUse,
+			2,
+		},
+		{
+			"Forced deletion of a presetmap used by an active job",
+			"DELETE",
+			"/presetmaps/mp4_720p?force=true",
+			http.StatusOK,
+			nil,
+			1,
+		},
+		{
+			"Forced deletion of a preset used by an active job",
+			"DELETE",
+			"/presets/mp4_720p?force=true",
+			http.StatusOK,
+			map[string]interface{}{
+				"results":   map[string]interface{}{"fake": map[string]interface{}{"presetId": "mp4_720p-id"}},
+				"presetMap": "removed successfully",
+			},
+			1,
+		},
+		{
+			"Preset used only by finished jobs",
+			"DELETE",
+			"/presets/webm_480p",
+			http.StatusOK,
+			map[string]interface{}{
+				"results":   map[string]interface{}{"fake": map[string]interface{}{"presetId": "webm_480p-id"}},
+				"presetMap": "removed successfully",
+			},
+			1,
+		},
+		{
+			"Invalid force parameter",
+			"DELETE",
+			"/presets/mp4_720p?force=please",
+			http.StatusBadRequest,
+			map[string]interface{}{"error": `invalid force: "please" is not a boolean`},
+			2,
+		},
+		{
+			"Pruning a preset used by an active job",
+			"POST",
+			"/presets/apply?prune=true",
+			http.StatusConflict,
+			inUse,
+			2,
+		},
+	}
+	for _, test := range tests {
+		fprovider = fakeProvider{}
+		fakeDB := dbtest.NewFakeRepository(false)
+		for _, name := range []string{"mp4_720p", "webm_480p"} {
+			err := fakeDB.CreatePresetMap(&db.PresetMap{
+				Name:            name,
+				ProviderMapping: map[string]string{"fake": name + "-id"},
+				OutputOpts:      db.OutputOptions{Extension: "mp4"},
+			})
+			if err != nil {
+				t.Fatal(err)
+			}
+		}
+		jobs := []db.Job{
+			{ID: "active-job", ProviderName: "fake", ProviderJobID: "provider-job-running", PresetVersions: db.PresetVersions{"mp4_720p": 1}},
+			{ID: "finished-job", ProviderName: "fake", ProviderJobID: "provider-job-123", PresetVersions: db.PresetVersions{"mp4_720p": 1, "webm_480p": 1}},
+			{ID: "unknown-job", ProviderName: "fake", ProviderJobID: "provider-job-gone", PresetSnapshots: db.PresetSnapshots{"webm_480p": {Name: "webm_480p"}}},
+			{ID: "other-job", ProviderName: "fake", ProviderJobID: "provider-job-running", PresetVersions: db.PresetVersions{"hls_360p": 1}},
+			{ID: "stale-job", ProviderName: "fake", ProviderJobID: "provider-job-running", PresetVersions: db.PresetVersions{"webm_480p": 1}, CreationTime: time.Now().Add(-30 * 24 * time.Hour)},
+			{ID: "unconfigured-job", ProviderName: "unconfigured", ProviderJobID: "provider-job-running", PresetVersions: db.PresetVersions{"webm_480p": 1}},
+		}
+		for i := range jobs {
+			if err := fakeDB.CreateJob(&jobs[i]); err != nil {
+				t.Fatal(err)
+			}
+		}
+		srvr := server.NewSimpleServer(&server.Config{})
+		service, err := NewTranscodingService(&config.Config{Server: &server.Config{}}, logrus.New())
+		if err != nil {
+			t.Fatal(err)
+		}
+		service.db = fakeDB
+		srvr.Register(service)
+		r, _ := http.NewRequest(test.givenMethod, test.givenPath, strings.NewReader(`{"presetMaps":[]}`))
+		w := httptest.NewRecorder()
+		srvr.ServeHTTP(w, r)
+		if w.Code != test.wantCode {
+			t.Errorf("%s: wrong response code. Want %d. Got %d", test.givenTestCase, test.wantCode, w.Code)
+		}
+		var got map[string]interface{}
+		if w.Body.Len() > 0 {
+			if err = json.NewDecoder(w.Body).Decode(&got); err != nil {
+				t.Errorf("%s: unable to JSON decode response body: %s", test.givenTestCase, err)
+			}
+		}
+		if !reflect.DeepEqual(got, test.wantBody) {
+			t.Errorf("%s: expected response body of\n%#v;\ngot\n%#v", test.givenTestCase, test.wantBody, got)
+		}
+		presetMaps, err := fakeDB.ListPresetMaps()
+		if err != nil {
+			t.Fatal(err)
+		}
+		if len(presetMaps) != test.wantPresetMaps {
+			t.Errorf("%s: wrong number of presetmaps. Want %d. Got %d", test.givenTestCase, test.wantPresetMaps, len(presetMaps))
+		}
+	}
+}
+
+func TestDeletePresetUsageUnknown(t *testing.T) {
+	fprovider = fakeProvider{}
+	fakeDB := dbtest.NewFakeRepository(false)
+	err := fakeDB.CreatePresetMap(&db.PresetMap{
+		Name:            "mp4_720p",
+		ProviderMapping: map[string]string{"fake": "mp4_720p-id"},
+		OutputOpts:      db.OutputOptions{Extension: "mp4"},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	jobs := []db.Job{{ID: "unreachable-job", ProviderName: "fake", ProviderJobID: "provider-job-unavailable", PresetVersions: db.PresetVersions{"mp4_720p": 1}}}
+	// more finished jobs than concurrent status lookups
+	for i := 0; i < 2*jobStatusConcurrency; i++ {
+		jobs = append(jobs, db.Job{ID: fmt.Sprintf("finished-job-%d", i), ProviderName: "fake", ProviderJobID: "provider-job-123", PresetVersions: db.PresetVersions{"mp4_720p": 1}})
+	}
+	for i := range jobs {
+		if err = fakeDB.CreateJob(&jobs[i]); err != nil {
+			t.Fatal(err)
+		}
+	}
+	srvr := server.NewSimpleServer(&server.Config{})
+	service, err := NewTranscodingService(&config.Config{Server: &server.Config{}}, logrus.New())
+	if err != nil {
+		t.Fatal(err)
+	}
+	service.db = fakeDB
+	srvr.Register(service)
+	r, _ := http.NewRequest("DELETE", "/presets/mp4_720p", nil)
+	w := httptest.NewRecorder()
+	srvr.ServeHTTP(w, r)
+	if w.Code != http.StatusServiceUnavailable {
+		t.Errorf("wrong response code. Want %d. Got %d", http.StatusServiceUnavailable, w.Code)
+	}
+	var got map[string]interface{}
+	if err = json.NewDecoder(w.Body).Decode(&got); err != nil {
+		t.Fatal(err)
+	}
+	want := map[string]interface{}{
+		"error": `couldn't check whether 1 job(s) using preset "mp4_720p" are active, use force=true to delete it anyway`,
+		"jobs":  []interface{}{"unreachable-job"},
+	}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("wrong response body\nwant %#v\ngot  %#v", want, got)
+	}
+	if _, err = fakeDB.GetPresetMap("mp4_720p"); err != nil {
+		t.Errorf("presetmap shouldn't be deleted, got %v", err)
+	}
+}
+
+func TestPresetSnapshotsOf(t *testing.T) {
+	fakeDB := dbtest.NewFakeRepository(false)
+	localPreset := db.Preset{Name: "local_720p", Container: "mp4"}
+	if err := fakeDB.CreateLocalPreset(&db.LocalPreset{Name: "local_720p", Preset: localPreset}); err != nil {
+		t.Fatal(err)
+	}
+	service, err := NewTranscodingService(&config.Config{Server: &server.Config{}}, logrus.New())
+	if err != nil {
+		t.Fatal(err)
+	}
+	service.db = fakeDB
+	preset := db.Preset{Name: "mp4_720p", Container: "mp4"}
+	outputs := []db.TranscodeOutput{
+		{Preset: db.PresetMap{Name: "mp4_720p", Preset: &preset}},
+		{Preset: db.PresetMap{Name: "local_720p", ProviderMapping: map[string]string{"zencoder": "local_720p"}}},
+		{Preset: db.PresetMap{Name: "legacy", ProviderMapping: map[string]string{"fake": "legacy-id"}}},
+	}
+	want := db.PresetSnapshots{"mp4_720p": preset, "local_720p": localPreset}
+	if got := service.presetSnapshotsOf(outputs); !reflect.DeepEqual(got, want) {
+		t.Errorf("wrong snapshots\nwant %#v\ngot  %#v", want, got)
+	}
+}
@@ -0,0 +1,86 @@
+package service
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+
+	"github.com/video-dev/video-transcoding-api/v2/db"
+	"github.com/video-dev/video-transcoding-api/v2/internal/provider"
+	"github.com/video-dev/video-transcoding-api/v2/swagger"
+)
+
+const (
+	presetValidationValid     = "valid"
+	presetValidationInvalid   = "invalid"
+	presetValidationUnchecked = "unchecked"
+	presetValidationError     = "error"
+)
+
+// swagger:route POST /presets/validate presets validatePreset
+//
+// Checks whether a preset can be created on each of the given providers,
+// without creating it. Providers default to the configured ones, and
+// providers that can't check presets without creating them are reported as
+// unchecked.
+//
+//     Responses:
+//       200: validatePresetOutputs
+//       400: invalidPreset
+//       500: genericError
+func (s *TranscodingService) validatePreset(r *http.Request) swagger.GizmoJSONResponse {
+	defer r.Body.Close()
+	var input validatePresetInput
+	if err := json.NewDecoder(r.Body).Decode(&input); err != nil {
+		return newInvalidPresetResponse(err)
+	}
+	providers := input.Providers
+	if len(providers) == 0 {
+		providers = provider.ListProviders(s.config)
+	}
+	preset, err := s.resolvePreset(input.Preset)
+	if err != nil {
+		return newInvalidPresetResponse(fmt.Errorf("invalid preset: %s", err))
+	}
+	preset, errResp := validateNewPreset(preset, providers)
+	if errResp != nil {
+		return errResp
+	}
+	output := validatePresetOutputs{Valid: true, Results: make(map[string]validatePresetOutput, len(providers))}
+	for _, p := range providers {
+		result := s.validateProviderPreset(p, preset)
+		output.Results[p] = result
+		output.Valid = output.Valid && result.Status == presetValidationValid
+	}
+	return &validatePresetResponse{
+		baseResponse: baseResponse{
+			payload: output,
+			status:  http.StatusOK,
+		},
+	}
+}
+
+// validateProviderPreset checks whether the given provider is able to create
+// the preset, through its capabilities and its PresetValidator
+// implementation.
+func (s *TranscodingService) validateProviderPreset(p string, preset db.Preset) validatePresetOutput {
+	providerFactory, err := provider.GetProviderFactory(p)
+	if err != nil {
+		return validatePresetOutput{Status: presetValidationError, Error: "getting factory: " + err.Error()}
+	}
+	providerObj, err := providerFactory(s.config)
+	if err != nil {
+		return validatePresetOutput{Status: presetValidationError, Error: "initializing provider: " + err.Error()}
+	}
+	if err = checkPresetCapabilities(providerObj, preset); err != nil {
+		return validatePresetOutput{Status: presetValidationInvalid, Error: err.Error()}
+	}
+	validator, ok := providerObj.(provider.PresetValidator)
+	if !ok {
+		return validatePresetOutput{Status: presetValidationUnchecked}
+	}
+	if err = validator.ValidatePreset(preset); err != nil {
+		return validatePresetOutput{Status: presetValidationInvalid, Error: err.Error()}
+	}
+	return validatePresetOutput{Status: presetValidationValid}
+}
@@ -0,0 +1,146 @@
+package service
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"reflect"
+	"strings"
+	"testing"
+
+	"github.com/NYTimes/gizmo/server"
+	"github.com/sirupsen/logrus"
+	"github.com/video-dev/video-transcoding-api/v2/config"
+	"github.com/video-dev/video-transcoding-api/v2/db/dbtest"
+)
+
+func TestValidatePreset(t *testing.T) {
+	const mp4Preset = `{"name":"mp4_1080p","container":"mp4","video":{"codec":"h264","profile":"high","width":"1920","height":"1080","bitrate":"5000000","gopSize":"60"},"audio":{"codec":"aac","bitrate":"128000"}}`
+	const webmPreset = `{"name":"webm_720p","container":"webm","video":{"codec":"vp8","width":"1280","height":"720","bitrate":"2500000","gopSize":"60"},"audio":{"codec":"vorbis","bitrate":"128000"}}`
+	tests := []struct {
+		givenTestCase    string
+		givenRequestData string
+		wantCode         int
+		wantBody         map[string]interface{}
+	}{
+		{
+			"Preset valid on all the configured providers",
+			`{"preset":` + mp4Preset + `}`,
+			http.StatusOK,
+			map[string]interface{}{
+				"valid": true,
+				"results": map[string]interface{}{
+					"fake":     map[string]interface{}{"status": "valid"},
+					"zencoder": map[string]interface{}{"status": "valid"},
+				},
+			},
+		},
+		{
+			"Preset rejected by a provider",
+			`{"providers":["fake"],"preset":` + webmPreset + `}`,
+			http.StatusOK,
+			map[string]interface{}{
+				"valid": false,
+				"results": map[string]interface{}{
+					"fake": map[string]interface{}{"status": "invalid", "error": "unsupported container: webm"},
+				},
+			},
+		},
+		{
+			"Color settings on a provider without hdr support",
+			`{"providers":["fake"],"preset":{"name":"hevc_2160p","container":"mp4","video":{"codec":"hevc","profile":"main10","height":"2160","bitrate":"16000000","gopSize":"90","hdr":"hdr10"},"audio":{"codec":"aac","bitrate":"128000"}}}`,
+			http.StatusOK,
+			map[string]interface{}{
+				"valid": false,
+				"results": map[string]interface{}{
+					"fake": map[string]interface{}{"status": "invalid", "error": "bit depth, color space and hdr settings are not supported by the provider"},
+				},
+			},
+		},
+		{
+			"Unknown provider",
+			`{"providers":["fake","unknown"],"preset":` + mp4Preset + `}`,
+			http.StatusOK,
+			map[string]interface{}{
+				"valid": false,
+				"results": map[string]interface{}{
+					"fake":    map[string]interface{}{"status": "valid"},
+					"unknown": map[string]interface{}{"status": "error", "error": "getting factory: provider not found"},
+				},
+			},
+		},
+		{
+			"Preset with an unknown base",
+			`{"providers":["fake"],"preset":{"name":"orphan","base":"unknown"}}`,
+			http.StatusBadRequest,
+			map[string]interface{}{"error": `invalid preset: base preset "unknown": presetmap not found`},
+		},
+		{
+			"Invalid request body",
+			`{"providers":"fake"}`,
+			http.StatusBadRequest,
+			map[string]interface{}{"error": "json: cannot unmarshal string into Go struct field validatePresetInput.providers of type []string"},
+		},
+	}
+	for _, test := range tests {
+		fprovider = fakeProvider{}
+		srvr := server.NewSimpleServer(&server.Config{})
+		service, err := NewTranscodingService(&config.Config{Server: &server.Config{}}, logrus.New())
+		if err != nil {
+			t.Fatal(err)
+		}
+		service.db = dbtest.NewFakeRepository(false)
+		srvr.Register(service)
+		r, _ := http.NewRequest("POST", "/presets/validate", strings.NewReader(test.givenRequestData))
+		w := httptest.NewRecorder()
+		srvr.ServeHTTP(w, r)
+		if w.Code != test.wantCode {
+			t.Errorf("%s: wrong response code. Want %d. Got %d", test.givenTestCase, test.wantCode, w.Code)
+		}
+		var got map[string]interface{}
+		if err = json.NewDecoder(w.Body).Decode(&got); err != nil {
+			t.Errorf("%s: unable to JSON decode response body: %s", test.givenTestCase, err)
+		}
+		if !reflect.DeepEqual(got, test.wantBody) {
+			t.Errorf("%s: expected response body of\n%#v;\ngot\n%#v", test.givenTestCase, test.wantBody, got)
+		}
+		if len(fprovider.jobs) > 0 || len(fprovider.deletedPresets) > 0 {
+			t.Errorf("%s: validating a preset shouldn't change the provider", test.givenTestCase)
+		}
+	}
+}
+
+func TestValidatePresetUnsupportedCodec(t *testing.T) {
+	mc := newFakeMediaConvert()
+	defer mc.Close()
+	srvr := server.NewSimpleServer(&server.Config{})
+	service, err := NewTranscodingService(&config.Config{Server: &server.Config{}, MediaConvert: mc.config()}, logrus.New())
+	if err != nil {
+		t.Fatal(err)
+	}
+	service.db = dbtest.NewFakeRepository(false)
+	srvr.Register(service)
+	body := `{"providers":["mediaconvert"],"preset":{"name":"webm_720p","container":"webm","video":{"codec":"vp8","height":"720","bitrate":"2500000","gopSize":"60"},"audio":{"codec":"vorbis","bitrate":"128000"}}}`
+	r, _ := http.NewRequest("POST", "/presets/validate", strings.NewReader(body))
+	w := httptest.NewRecorder()
+	srvr.ServeHTTP(w, r)
+	if w.Code != http.StatusOK {
+		t.Errorf("wrong response code. Want %d. Got %d", http.StatusOK, w.Code)
+	}
+	var got map[string]interface{}
+	if err = json.NewDecoder(w.Body).Decode(&got); err != nil {
+		t.Fatal(err)
+	}
+	want := map[string]interface{}{
+		"valid": false,
+		"results": map[string]interface{}{
+			"mediaconvert": map[string]interface{}{"status": "invalid", "error": `video codec "vp8" is not supported by the provider`},
+		},
+	}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("wrong response body\nwant %#v\ngot  %#v", want, got)
+	}
+	if len(mc.presets) > 0 {
+		t.Errorf("validating a preset shouldn't create presets, got %v", mc.presets)
+	}
+}
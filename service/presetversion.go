@@ -0,0 +1,126 @@
+package service
+
+import (
+	"fmt"
+	"net/http"
+
+	"github.com/NYTimes/gizmo/server"
+	"github.com/video-dev/video-transcoding-api/v2/db"
+	"github.com/video-dev/video-transcoding-api/v2/internal/provider"
+	"github.com/video-dev/video-transcoding-api/v2/swagger"
+)
+
+// presetVersionUnavailableError is returned when a job output is pinned to a
+// version of a preset that can no longer be used on the provider of the job.
+type presetVersionUnavailableError struct {
+	name     string
+	version  uint
+	provider string
+}
+
+func (e presetVersionUnavailableError) Error() string {
+	return fmt.Sprintf("version %d of preset %q is not available on provider %q", e.version, e.name, e.provider)
+}
+
+// swagger:route GET /presets/{name}/versions presets listPresetVersions
+//
+// Lists every version of a preset, from the oldest to the latest one.
+//
+//     Responses:
+//       200: presetVersions
+//       404: presetNotFound
+//       500: genericError
+func (s *TranscodingService) listPresetVersions(r *http.Request) swagger.GizmoJSONResponse {
+	var params getPresetMapInput
+	params.loadParams(server.Vars(r))
+	versions, err := s.db.ListPresetMapVersions(params.Name)
+	switch err {
+	case nil:
+		return newPresetVersionsResponse(params.Name, versions)
+	case db.ErrPresetMapNotFound:
+		return newPresetMapNotFoundResponse(err)
+	default:
+		return swagger.NewErrorResponse(err)
+	}
+}
+
+// presetMapVersion returns the given version of the presetmap, to be used in
+// a job submitted to the given provider.
+//
+// Provider presets of previous versions are deleted when a preset is
+// replaced, so they're created again from the canonical preset of the
+// version whenever they're gone, and stored in the version for further jobs.
+// Providers that key presets by name update them in place, so previous
+// versions get their own presets there, named after the version. A preset
+// left with that name by an earlier attempt gets the settings of the
+// version, as the name can't be taken again.
+func (s *TranscodingService) presetMapVersion(current *db.PresetMap, version uint, providerName string, providerObj provider.TranscodingProvider) (*db.PresetMap, error) {
+	if version == current.Version {
+		return current, nil
+	}
+	presetMap, err := s.db.GetPresetMapVersion(current.Name, version)
+	if err != nil {
+		return nil, err
+	}
+	presetID, ok := presetMap.ProviderMapping[providerName]
+	_, nameKeyed := providerObj.(provider.PresetUpdater)
+	if nameKeyed && presetID == current.ProviderMapping[providerName] {
+		// the preset has the settings of the latest version
+		ok = false
+	}
+	if ok {
+		_, err = providerObj.GetPreset(presetID)
+		switch err.(type) {
+		case nil:
+			return presetMap, nil
+		case provider.PresetNotFoundError:
+		default:
+			return nil, fmt.Errorf("error getting version %d of preset %q: %s", version, presetMap.Name, err)
+		}
+	}
+	if presetMap.Preset == nil {
+		return nil, presetVersionUnavailableError{name: presetMap.Name, version: version, provider: providerName}
+	}
+	preset := *presetMap.Preset
+	var existingID string
+	if nameKeyed {
+		preset.Name = fmt.Sprintf("%s_v%d", presetMap.Name, version)
+		_, err = providerObj.GetPreset(preset.Name)
+		switch err.(type) {
+		case nil:
+			existingID = preset.Name
+		case provider.PresetNotFoundError:
+		default:
+			return nil, fmt.Errorf("error getting version %d of preset %q: %s", version, presetMap.Name, err)
+		}
+	}
+	if existingID != "" {
+		_, presetID, err = s.replaceProviderPreset(providerName, existingID, preset)
+	} else {
+		_, presetID, err = s.createProviderPreset(providerName, preset)
+	}
+	if err != nil {
+		return nil, fmt.Errorf("error creating version %d of preset %q: %s", version, presetMap.Name, err)
+	}
+	if presetMap.ProviderMapping == nil {
+		presetMap.ProviderMapping = make(map[string]string)
+	}
+	presetMap.ProviderMapping[providerName] = presetID
+	if err = s.db.UpdatePresetMapVersion(presetMap); err != nil {
+		return nil, err
+	}
+	return presetMap, nil
+}
+
+// presetVersionsOf returns the version of each preset used in the given
+// outputs. Presets stored before versioning was introduced have no version
+// and are left out.
+func presetVersionsOf(outputs []db.TranscodeOutput) db.PresetVersions {
+	versions := make(db.PresetVersions, len(outputs))
+	for _, output := range outputs {
+		if output.Preset.Version > 0 {
+			versions[output.Preset.Name] = output.Preset.Version
+		}
+	}
+	return versions
+}
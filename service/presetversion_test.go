@@ -0,0 +1,378 @@
+package service
+
+import (
+	"bytes"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"reflect"
+	"testing"
+
+	"github.com/NYTimes/gizmo/server"
+	"github.com/sirupsen/logrus"
+	"github.com/video-dev/video-transcoding-api/v2/config"
+	"github.com/video-dev/video-transcoding-api/v2/db"
+	"github.com/video-dev/video-transcoding-api/v2/db/dbtest"
+)
+
+func newVersionedPresetDB(t *testing.T, canonical *db.Preset) db.Repository {
+	fakeDB := dbtest.NewFakeRepository(false)
+	err := fakeDB.CreatePresetMap(&db.PresetMap{
+		Name:            "mp4_1080p",
+		ProviderMapping: map[string]string{"fake": "preset-v1"},
+		OutputOpts:      db.OutputOptions{Extension: "mp4"},
+		Preset:          canonical,
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	err = fakeDB.UpdatePresetMap(&db.PresetMap{
+		Name:            "mp4_1080p",
+		ProviderMapping: map[string]string{"fake": "preset-v2"},
+		OutputOpts:      db.OutputOptions{Extension: "mp4"},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	return fakeDB
+}
+
+func TestListPresetVersions(t *testing.T) {
+	tests := []struct {
+		givenTestCase   string
+		givenPresetName string
+		wantCode        int
+		wantBody        map[string]interface{}
+	}{
+		{
+			"List versions of a preset",
+			"mp4_1080p",
+			http.StatusOK,
+			map[string]interface{}{
+				"name": "mp4_1080p",
+				"versions": []interface{}{
+					map[string]interface{}{
+						"name":            "mp4_1080p",
+						"providerMapping": map[string]interface{}{"fake": "preset-v1"},
+						"output":          map[string]interface{}{"extension": "mp4"},
+						"version":         float64(1),
+					},
+					map[string]interface{}{
+						"name":            "mp4_1080p",
+						"providerMapping": map[string]interface{}{"fake": "preset-v2"},
+						"output":          map[string]interface{}{"extension": "mp4"},
+						"version":         float64(2),
+					},
+				},
+			},
+		},
+		{
+			"List versions of a preset that doesn't exist",
+			"mp4_720p",
+			http.StatusNotFound,
+			map[string]interface{}{"error": "presetmap not found"},
+		},
+	}
+	for _, test := range tests {
+		srvr := server.NewSimpleServer(&server.Config{})
+		service, err := NewTranscodingService(&config.Config{Server: &server.Config{}}, logrus.New())
+		if err != nil {
+			t.Fatal(err)
+		}
+		service.db = newVersionedPresetDB(t, nil)
+		srvr.Register(service)
+		r, _ := http.NewRequest("GET", "/presets/"+test.givenPresetName+"/versions", nil)
+		w := httptest.NewRecorder()
+		srvr.ServeHTTP(w, r)
+		if w.Code != test.wantCode {
+			t.Errorf("%s: wrong response code. Want %d. Got %d", test.givenTestCase, test.wantCode, w.Code)
+		}
+		var got map[string]interface{}
+		err = json.NewDecoder(w.Body).Decode(&got)
+		if err != nil {
+			t.Errorf("%s: unable to JSON decode response body: %s", test.givenTestCase, err)
+		}
+		if !reflect.DeepEqual(got, test.wantBody) {
+			t.Errorf("%s: expected response body of\n%#v;\ngot\n%#v", test.givenTestCase, test.wantBody, got)
+		}
+	}
+}
+
+func TestTranscodeWithPresetVersion(t *testing.T) {
+	canonical := &db.Preset{Name: "mp4_1080p", Container: "mp4"}
+	tests := []struct {
+		givenTestCase   string
+		givenVersion    uint
+		givenCanonical  *db.Preset
+		givenDeleted    []string
+		givenFailed     []string
+		wantCode        int
+		wantError       string
+		wantPresetID    string
+		wantVersionedID string
+	}{
+		{
+			"Pin an output to the latest version",
+			2,
+			canonical,
+			nil,
+			nil,
+			http.StatusOK,
+			"",
+			"preset-v2",
+			"preset-v1",
+		},
+		{
+			"Pin an output to a previous version",
+			1,
+			canonical,
+			nil,
+			nil,
+			http.StatusOK,
+			"",
+			"preset-v1",
+			"preset-v1",
+		},
+		{
+			"Pin an output to a previous version deleted from the provider",
+			1,
+			canonical,
+			[]string{"preset-v1"},
+			nil,
+			http.StatusOK,
+			"",
+			"presetID_here",
+			"presetID_here",
+		},
+		{
+			"Pin an output to a deleted version without canonical preset",
+			1,
+			nil,
+			[]string{"preset-v1"},
+			nil,
+			http.StatusBadRequest,
+			`version 1 of preset "mp4_1080p" is not available on provider "fake"`,
+			"",
+			"preset-v1",
+		},
+		{
+			"Pin an output to a previous version the provider fails to get",
+			1,
+			canonical,
+			nil,
+			[]string{"preset-v1"},
+			http.StatusInternalServerError,
+			`error getting version 1 of preset "mp4_1080p": service unavailable`,
+			"",
+			"preset-v1",
+		},
+		{
+			"Pin an output to a version that doesn't exist",
+			3,
+			canonical,
+			nil,
+			nil,
+			http.StatusBadRequest,
+			"presetmap version not found",
+			"",
+			"preset-v1",
+		},
+	}
+	for _, test := range tests {
+		fprovider.jobs = nil
+		fprovider.deletedPresets = test.givenDeleted
+		fprovider.failedPresets = test.givenFailed
+		srvr := server.NewSimpleServer(&server.Config{})
+		service, err := NewTranscodingService(&config.Config{Server: &server.Config{}}, logrus.New())
+		if err != nil {
+			t.Fatal(err)
+		}
+		fakeDB := newVersionedPresetDB(t, test.givenCanonical)
+		service.db = fakeDB
+		srvr.Register(service)
+		body, _ := json.Marshal(map[string]interface{}{
+			"source":   "http://some.nice/video.mov",
+			"provider": "fake",
+			"outputs": []map[string]interface{}{
+				{"preset": "mp4_1080p", "presetVersion": test.givenVersion},
+			},
+		})
+		r, _ := http.NewRequest("POST", "/jobs", bytes.NewReader(body))
+		w := httptest.NewRecorder()
+		srvr.ServeHTTP(w, r)
+		if w.Code != test.wantCode {
+			t.Errorf("%s: wrong response code. Want %d. Got %d", test.givenTestCase, test.wantCode, w.Code)
+		}
+		if test.wantError != "" {
+			var got map[string]interface{}
+			json.NewDecoder(w.Body).Decode(&got)
+			if got["error"] != test.wantError {
+				t.Errorf("%s: wrong error. Want %q. Got %q", test.givenTestCase, test.wantError, got["error"])
+			}
+		} else if len(fprovider.jobs) != 1 {
+			t.Errorf("%s: wrong number of jobs sent to the provider. Want 1. Got %d", test.givenTestCase, len(fprovider.jobs))
+		} else {
+			job := fprovider.jobs[0]
+			if presetID := job.Outputs[0].Preset.ProviderMapping["fake"]; presetID != test.wantPresetID {
+				t.Errorf("%s: wrong provider preset. Want %q. Got %q", test.givenTestCase, test.wantPresetID, presetID)
+			}
+			wantVersions := db.PresetVersions{"mp4_1080p": test.givenVersion}
+			if !reflect.DeepEqual(job.PresetVersions, wantVersions) {
+				t.Errorf("%s: wrong preset versions. Want %#v. Got %#v", test.givenTestCase, wantVersions, job.PresetVersions)
+			}
+		}
+		version, err := fakeDB.GetPresetMapVersion("mp4_1080p", 1)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if presetID := version.ProviderMapping["fake"]; presetID != test.wantVersionedID {
+			t.Errorf("%s: wrong provider preset stored in the version. Want %q. Got %q", test.givenTestCase, test.wantVersionedID, presetID)
+		}
+	}
+	fprovider.jobs = nil
+	fprovider.deletedPresets = nil
+	fprovider.failedPresets = nil
+}
+
+func TestPresetMapVersionLocalPresets(t *testing.T) {
+	previous := db.Preset{Name: "mp4_1080p", Container: "mp4", Video: db.VideoPreset{Bitrate: "3000000"}}
+	latest := db.Preset{Name: "mp4_1080p", Container: "mp4", Video: db.VideoPreset{Bitrate: "4000000"}}
+	fakeDB := dbtest.NewFakeRepository(false)
+	err := fakeDB.CreatePresetMap(&db.PresetMap{
+		Name:            "mp4_1080p",
+		ProviderMapping: map[string]string{"local": "mp4_1080p"},
+		Preset:          &previous,
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	current := db.PresetMap{
+		Name:            "mp4_1080p",
+		ProviderMapping: map[string]string{"local": "mp4_1080p"},
+		Preset:          &latest,
+	}
+	if err = fakeDB.UpdatePresetMap(&current); err != nil {
+		t.Fatal(err)
+	}
+	fakeDB.CreateLocalPreset(&db.LocalPreset{Name: "mp4_1080p", Preset: latest})
+	localPresets = fakeDB
+	defer func() { localPresets = nil }()
+	service, err := NewTranscodingService(&config.Config{Server: &server.Config{}}, logrus.New())
+	if err != nil {
+		t.Fatal(err)
+	}
+	service.db = fakeDB
+	providerObj, err := localPresetProviderFactory(nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	for i := 0; i < 2; i++ {
+		presetMap, err := service.presetMapVersion(&current, 1, "local", providerObj)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if presetID := presetMap.ProviderMapping["local"]; presetID != "mp4_1080p_v1" {
+			t.Errorf("wrong provider preset. Want %q. Got %q", "mp4_1080p_v1", presetID)
+		}
+	}
+	localPreset, err := fakeDB.GetLocalPreset("mp4_1080p_v1")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if localPreset.Preset.Video.Bitrate != previous.Video.Bitrate {
+		t.Errorf("wrong bitrate in the local preset of the version. Want %q. Got %q", previous.Video.Bitrate, localPreset.Preset.Video.Bitrate)
+	}
+	version, err := fakeDB.GetPresetMapVersion("mp4_1080p", 1)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if presetID := version.ProviderMapping["local"]; presetID != "mp4_1080p_v1" {
+		t.Errorf("wrong provider preset stored in the version. Want %q. Got %q", "mp4_1080p_v1", presetID)
+	}
+}
+
+func TestPresetMapVersionMediaConvert(t *testing.T) {
+	previous, err := normalizePreset(db.Preset{
+		Name:        "mp4_1080p",
+		Container:   "mp4",
+		RateControl: "VBR",
+		Video:       db.VideoPreset{Codec: "h264", Height: "1080", Bitrate: "3000000", GopSize: "90"},
+		Audio:       db.AudioPreset{Codec: "aac", Bitrate: "128000"},
+	}, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	latest := previous
+	latest.Video.Bitrate = "4000000"
+	tests := []struct {
+		givenTestCase   string
+		givenLeftover   bool
+		wantUpdateCalls []string
+	}{
+		{"version preset created", false, nil},
+		{"leftover version preset updated in place", true, []string{"mp4_1080p_v1"}},
+	}
+	for _, test := range tests {
+		mc := newFakeMediaConvert()
+		defer mc.Close()
+		cfg := &config.Config{Server: &server.Config{}, MediaConvert: mc.config()}
+		service, err := NewTranscodingService(cfg, logrus.New())
+		if err != nil {
+			t.Fatal(err)
+		}
+		providerObj, presetID, err := service.createProviderPreset("mediaconvert", latest)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if test.givenLeftover {
+			leftover := latest
+			leftover.Name = "mp4_1080p_v1"
+			if _, _, err = service.createProviderPreset("mediaconvert", leftover); err != nil {
+				t.Fatal(err)
+			}
+		}
+		fakeDB := dbtest.NewFakeRepository(false)
+		err = fakeDB.CreatePresetMap(&db.PresetMap{
+			Name:            "mp4_1080p",
+			ProviderMapping: map[string]string{"mediaconvert": presetID},
+			Preset:          &previous,
+		})
+		if err != nil {
+			t.Fatal(err)
+		}
+		current := db.PresetMap{
+			Name:            "mp4_1080p",
+			ProviderMapping: map[string]string{"mediaconvert": presetID},
+			Preset:          &latest,
+		}
+		if err = fakeDB.UpdatePresetMap(&current); err != nil {
+			t.Fatal(err)
+		}
+		service.db = fakeDB
+		for i := 0; i < 2; i++ {
+			presetMap, err := service.presetMapVersion(&current, 1, "mediaconvert", providerObj)
+			if err != nil {
+				t.Fatalf("%s: %s", test.givenTestCase, err)
+			}
+			if presetID := presetMap.ProviderMapping["mediaconvert"]; presetID != "mp4_1080p_v1" {
+				t.Errorf("%s: wrong provider preset. Want %q. Got %q", test.givenTestCase, "mp4_1080p_v1", presetID)
+			}
+		}
+		if bitrate := mc.bitrate("mp4_1080p_v1"); bitrate != 3000000 {
+			t.Errorf("%s: wrong bitrate in the preset of the version. Want 3000000. Got %v", test.givenTestCase, bitrate)
+		}
+		if bitrate := mc.bitrate("mp4_1080p"); bitrate != 4000000 {
+			t.Errorf("%s: latest preset changed. Want bitrate 4000000. Got %v", test.givenTestCase, bitrate)
+		}
+		if !reflect.DeepEqual(mc.updates, test.wantUpdateCalls) {
+			t.Errorf("%s: wrong preset updates\nwant %#v\ngot  %#v", test.givenTestCase, test.wantUpdateCalls, mc.updates)
+		}
+		version, err := fakeDB.GetPresetMapVersion("mp4_1080p", 1)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if presetID := version.ProviderMapping["mediaconvert"]; presetID != "mp4_1080p_v1" {
+			t.Errorf("%s: wrong provider preset stored in the version. Want %q. Got %q", test.givenTestCase, "mp4_1080p_v1", presetID)
+		}
+	}
+}
@@ -55,6 +55,8 @@ func TestGetProvider(t *testing.T) {
 					"input":        []interface{}{"prores", "h264"},
 					"output":       []interface{}{"mp4", "webm", "hls"},
 					"destinations": []interface{}{"akamai", "s3"},
+					"hdr":          false,
+					"contentAware": false,
 				},
 				"enabled": true,
 			},
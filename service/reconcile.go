@@ -0,0 +1,219 @@
+package service
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"sort"
+	"sync"
+
+	"github.com/NYTimes/gizmo/server"
+	"github.com/video-dev/video-transcoding-api/v2/db"
+	"github.com/video-dev/video-transcoding-api/v2/internal/provider"
+	"github.com/video-dev/video-transcoding-api/v2/swagger"
+)
+
+// Statuses of provider presets in reconciliation reports.
+const (
+	presetStatusInSync    = "in-sync"
+	presetStatusDrifted   = "drifted"
+	presetStatusMissing   = "missing"
+	presetStatusUnchecked = "unchecked"
+	presetStatusError     = "error"
+)
+
+// swagger:route POST /presets/{name}/reconcile presets reconcilePreset
+//
+// Compares the preset stored in each provider with the canonical preset,
+// reporting drifted and missing presets. When recreate is set in the body,
+// drifted and missing presets are created again from the canonical preset,
+// and drifted presets are deleted after the preset points to their
+// replacements. Providers that key presets by name, like Zencoder, update
+// drifted presets in place.
+//
+//     Responses:
+//       200: presetReconciliation
+//       400: invalidPreset
+//       404: presetNotFound
+//       500: genericError
+func (s *TranscodingService) reconcilePreset(r *http.Request) swagger.GizmoJSONResponse {
+	var params getPresetMapInput
+	params.loadParams(server.Vars(r))
+
+	var input reconcilePresetInput
+	if err := json.NewDecoder(r.Body).Decode(&input); err != nil && err != io.EOF {
+		return newInvalidPresetResponse(err)
+	}
+
+	presetMap, err := s.db.GetPresetMap(params.Name)
+	switch err {
+	case nil:
+	case db.ErrPresetMapNotFound:
+		return newPresetMapNotFoundResponse(err)
+	default:
+		return swagger.NewErrorResponse(err)
+	}
+
+	output, err := s.reconcilePresetMap(presetMap, input.Recreate)
+	if err != nil {
+		return swagger.NewErrorResponse(err)
+	}
+	return &reconcilePresetResponse{
+		baseResponse: baseResponse{
+			payload: output,
+			status:  http.StatusOK,
+		},
+	}
+}
+
+// ReconcilePresets compares the given presets, or every preset when no name
+// is given, with the presets stored in their providers, writing the
+// JSON-encoded reports to w. When recreate is true, drifted and missing
+// presets are created again from the canonical presets.
+func (s *TranscodingService) ReconcilePresets(w io.Writer, recreate bool, names ...string) error {
+	if len(names) == 0 {
+		presetMaps, err := s.db.ListPresetMaps()
+		if err != nil {
+			return err
+		}
+		for _, presetMap := range presetMaps {
+			names = append(names, presetMap.Name)
+		}
+		sort.Strings(names)
+	}
+	reports := make([]presetReconciliation, 0, len(names))
+	for _, name := range names {
+		presetMap, err := s.db.GetPresetMap(name)
+		if err != nil {
+			return fmt.Errorf("getting preset %q: %s", name, err)
+		}
+		report, err := s.reconcilePresetMap(presetMap, recreate)
+		if err != nil {
+			return fmt.Errorf("reconciling preset %q: %s", name, err)
+		}
+		reports = append(reports, report)
+	}
+	encoder := json.NewEncoder(w)
+	encoder.SetIndent("", "  ")
+	return encoder.Encode(reports)
+}
+
+// reconcilePresetMap checks the preset of each provider in the presetmap,
+// storing the IDs of the recreated presets in the presetmap.
+func (s *TranscodingService) reconcilePresetMap(presetMap *db.PresetMap, recreate bool) (presetReconciliation, error) {
+	output := presetReconciliation{
+		Name:    presetMap.Name,
+		Results: make(map[string]providerPresetReconciliation, len(presetMap.ProviderMapping)),
+	}
+
+	type providerResult struct {
+		provider string
+		output   providerPresetReconciliation
+	}
+	resultCh := make(chan providerResult, len(presetMap.ProviderMapping))
+	var wg sync.WaitGroup
+	for p, presetID := range presetMap.ProviderMapping {
+		wg.Add(1)
+		go func(p, presetID string) {
+			defer wg.Done()
+			resultCh <- providerResult{provider: p, output: s.reconcileProviderPreset(p, presetID, presetMap.Preset, recreate)}
+		}(p, presetID)
+	}
+	wg.Wait()
+	close(resultCh)
+
+	var recreated bool
+	for res := range resultCh {
+		output.Results[res.provider] = res.output
+		if res.output.RecreatedPresetID != "" && res.output.RecreatedPresetID != res.output.PresetID {
+			presetMap.ProviderMapping[res.provider] = res.output.RecreatedPresetID
+			recreated = true
+		}
+	}
+	if !recreated {
+		return output, nil
+	}
+	if err := s.db.UpdatePresetMap(presetMap); err != nil {
+		for p, res := range output.Results {
+			if res.RecreatedPresetID != "" && res.RecreatedPresetID != res.PresetID {
+				presetMap.ProviderMapping[p] = res.PresetID
+				s.deleteProviderPreset(p, res.RecreatedPresetID)
+			}
+		}
+		return output, err
+	}
+	// drifted presets are only deleted once the presetmap points to their
+	// replacements
+	for p, res := range output.Results {
+		if res.Status != presetStatusDrifted || res.RecreatedPresetID == "" || res.RecreatedPresetID == res.PresetID {
+			continue
+		}
+		if err := s.deleteProviderPreset(p, res.PresetID); err != nil {
+			res.Error = "deleting drifted preset: " + err.Error()
+			output.Results[p] = res
+		}
+	}
+	return output, nil
+}
+
+// reconcileProviderPreset compares the given provider preset with the
+// canonical preset. Presets can't be compared when the presetmap has no
+// canonical preset or when the provider can't normalize its presets.
+func (s *TranscodingService) reconcileProviderPreset(p, presetID string, canonical *db.Preset, recreate bool) providerPresetReconciliation {
+	result := providerPresetReconciliation{PresetID: presetID, Status: presetStatusError}
+	providerFactory, err := provider.GetProviderFactory(p)
+	if err != nil {
+		result.Error = "getting factory: " + err.Error()
+		return result
+	}
+	providerObj, err := providerFactory(s.config)
+	if err != nil {
+		result.Error = "initializing provider: " + err.Error()
+		return result
+	}
+
+	native, err := providerObj.GetPreset(presetID)
+	switch err.(type) {
+	case nil:
+		normalizer, ok := providerObj.(provider.PresetNormalizer)
+		if canonical == nil || !ok {
+			result.Status = presetStatusUnchecked
+			return result
+		}
+		normalized, err := normalizer.NormalizePreset(native)
+		if err != nil {
+			result.Error = "normalizing preset: " + err.Error()
+			return result
+		}
+		result.Differences = canonical.Drift(normalized)
+		result.Status = presetStatusInSync
+		if len(result.Differences) > 0 {
+			result.Status = presetStatusDrifted
+		}
+	case provider.PresetNotFoundError:
+		result.Status = presetStatusMissing
+		result.Error = "getting preset: " + err.Error()
+	default:
+		result.Error = "getting preset: " + err.Error()
+		return result
+	}
+
+	if !recreate || canonical == nil || result.Status == presetStatusInSync {
+		return result
+	}
+	var newPresetID string
+	if result.Status == presetStatusDrifted {
+		// the drifted preset is kept until the presetmap points to its
+		// replacement, except on providers that update presets in place
+		_, newPresetID, err = s.replaceProviderPreset(p, presetID, *canonical)
+	} else {
+		_, newPresetID, err = s.createProviderPreset(p, *canonical)
+	}
+	if err != nil {
+		result.Error = err.Error()
+		return result
+	}
+	result.RecreatedPresetID = newPresetID
+	return result
+}
@@ -0,0 +1,453 @@
+package service
+
+import (
+	"bytes"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"reflect"
+	"strings"
+	"testing"
+
+	"github.com/NYTimes/gizmo/server"
+	"github.com/sirupsen/logrus"
+	"github.com/video-dev/video-transcoding-api/v2/config"
+	"github.com/video-dev/video-transcoding-api/v2/db"
+	"github.com/video-dev/video-transcoding-api/v2/db/dbtest"
+)
+
+func TestReconcilePreset(t *testing.T) {
+	tests := []struct {
+		givenTestCase    string
+		givenPresetName  string
+		givenCanonical   *db.Preset
+		givenDeleted     []string
+		givenRequestData string
+		wantCode         int
+		wantBody         map[string]interface{}
+		wantMapping      map[string]string
+	}{
+		{
+			"Preset in sync",
+			"mp4_1080p",
+			&db.Preset{Name: "mp4_1080p", Container: "mp4"},
+			nil,
+			"",
+			http.StatusOK,
+			map[string]interface{}{
+				"name": "mp4_1080p",
+				"results": map[string]interface{}{
+					"fake": map[string]interface{}{"presetId": "preset-1", "status": "in-sync"},
+				},
+			},
+			map[string]string{"fake": "preset-1"},
+		},
+		{
+			"Drifted preset",
+			"mp4_1080p",
+			&db.Preset{Name: "mp4_1080p", Container: "webm"},
+			nil,
+			`{"recreate":false}`,
+			http.StatusOK,
+			map[string]interface{}{
+				"name": "mp4_1080p",
+				"results": map[string]interface{}{
+					"fake": map[string]interface{}{
+						"presetId": "preset-1",
+						"status":   "drifted",
+						"differences": []interface{}{
+							map[string]interface{}{"field": "container", "expected": "webm", "actual": "mp4"},
+						},
+					},
+				},
+			},
+			map[string]string{"fake": "preset-1"},
+		},
+		{
+			"Drifted preset recreated",
+			"mp4_1080p",
+			&db.Preset{Name: "mp4_1080p", Container: "webm"},
+			nil,
+			`{"recreate":true}`,
+			http.StatusOK,
+			map[string]interface{}{
+				"name": "mp4_1080p",
+				"results": map[string]interface{}{
+					"fake": map[string]interface{}{
+						"presetId": "preset-1",
+						"status":   "drifted",
+						"differences": []interface{}{
+							map[string]interface{}{"field": "container", "expected": "webm", "actual": "mp4"},
+						},
+						"recreatedPresetId": "presetID_here",
+					},
+				},
+			},
+			map[string]string{"fake": "presetID_here"},
+		},
+		{
+			"Missing preset",
+			"mp4_1080p",
+			&db.Preset{Name: "mp4_1080p", Container: "mp4"},
+			[]string{"preset-1"},
+			"",
+			http.StatusOK,
+			map[string]interface{}{
+				"name": "mp4_1080p",
+				"results": map[string]interface{}{
+					"fake": map[string]interface{}{
+						"presetId": "preset-1",
+						"status":   "missing",
+						"error":    "getting preset: could not find preset with id: preset-1",
+					},
+				},
+			},
+			map[string]string{"fake": "preset-1"},
+		},
+		{
+			"Missing preset recreated",
+			"mp4_1080p",
+			&db.Preset{Name: "mp4_1080p", Container: "mp4"},
+			[]string{"preset-1"},
+			`{"recreate":true}`,
+			http.StatusOK,
+			map[string]interface{}{
+				"name": "mp4_1080p",
+				"results": map[string]interface{}{
+					"fake": map[string]interface{}{
+						"presetId":          "preset-1",
+						"status":            "missing",
+						"error":             "getting preset: could not find preset with id: preset-1",
+						"recreatedPresetId": "presetID_here",
+					},
+				},
+			},
+			map[string]string{"fake": "presetID_here"},
+		},
+		{
+			"Preset without canonical preset",
+			"mp4_1080p",
+			nil,
+			nil,
+			`{"recreate":true}`,
+			http.StatusOK,
+			map[string]interface{}{
+				"name": "mp4_1080p",
+				"results": map[string]interface{}{
+					"fake": map[string]interface{}{"presetId": "preset-1", "status": "unchecked"},
+				},
+			},
+			map[string]string{"fake": "preset-1"},
+		},
+		{
+			"Invalid request body",
+			"mp4_1080p",
+			nil,
+			nil,
+			`{"recreate":`,
+			http.StatusBadRequest,
+			map[string]interface{}{"error": "unexpected EOF"},
+			map[string]string{"fake": "preset-1"},
+		},
+		{
+			"Preset that doesn't exist",
+			"mp4_720p",
+			nil,
+			nil,
+			"",
+			http.StatusNotFound,
+			map[string]interface{}{"error": "presetmap not found"},
+			map[string]string{"fake": "preset-1"},
+		},
+	}
+	for _, test := range tests {
+		fprovider = fakeProvider{deletedPresets: test.givenDeleted}
+		srvr := server.NewSimpleServer(&server.Config{})
+		fakeDB := dbtest.NewFakeRepository(false)
+		err := fakeDB.CreatePresetMap(&db.PresetMap{
+			Name:            "mp4_1080p",
+			ProviderMapping: map[string]string{"fake": "preset-1"},
+			OutputOpts:      db.OutputOptions{Extension: "mp4"},
+			Preset:          test.givenCanonical,
+		})
+		if err != nil {
+			t.Fatal(err)
+		}
+		service, err := NewTranscodingService(&config.Config{Server: &server.Config{}}, logrus.New())
+		if err != nil {
+			t.Fatal(err)
+		}
+		service.db = fakeDB
+		srvr.Register(service)
+		r, _ := http.NewRequest("POST", "/presets/"+test.givenPresetName+"/reconcile", strings.NewReader(test.givenRequestData))
+		w := httptest.NewRecorder()
+		srvr.ServeHTTP(w, r)
+		if w.Code != test.wantCode {
+			t.Errorf("%s: wrong response code. Want %d. Got %d", test.givenTestCase, test.wantCode, w.Code)
+		}
+		var got map[string]interface{}
+		err = json.NewDecoder(w.Body).Decode(&got)
+		if err != nil {
+			t.Errorf("%s: unable to JSON decode response body: %s", test.givenTestCase, err)
+		}
+		if !reflect.DeepEqual(got, test.wantBody) {
+			t.Errorf("%s: expected response body of\n%#v;\ngot\n%#v", test.givenTestCase, test.wantBody, got)
+		}
+		presetMap, err := fakeDB.GetPresetMap("mp4_1080p")
+		if err != nil {
+			t.Fatal(err)
+		}
+		if !reflect.DeepEqual(presetMap.ProviderMapping, test.wantMapping) {
+			t.Errorf("%s: wrong provider mapping. Want %#v. Got %#v", test.givenTestCase, test.wantMapping, presetMap.ProviderMapping)
+		}
+	}
+}
+
+func TestReconcilePresets(t *testing.T) {
+	fprovider = fakeProvider{deletedPresets: []string{"preset-2"}}
+	fakeDB := dbtest.NewFakeRepository(false)
+	presetMaps := []db.PresetMap{
+		{Name: "webm_720p", ProviderMapping: map[string]string{"fake": "preset-2"}, Preset: &db.Preset{Container: "webm"}},
+		{Name: "mp4_1080p", ProviderMapping: map[string]string{"fake": "preset-1"}, Preset: &db.Preset{Container: "mp4"}},
+	}
+	for i := range presetMaps {
+		if err := fakeDB.CreatePresetMap(&presetMaps[i]); err != nil {
+			t.Fatal(err)
+		}
+	}
+	service, err := NewTranscodingService(&config.Config{Server: &server.Config{}}, logrus.New())
+	if err != nil {
+		t.Fatal(err)
+	}
+	service.db = fakeDB
+	var buf bytes.Buffer
+	err = service.ReconcilePresets(&buf, false)
+	if err != nil {
+		t.Fatal(err)
+	}
+	var got []interface{}
+	err = json.Unmarshal(buf.Bytes(), &got)
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := []interface{}{
+		map[string]interface{}{
+			"name": "mp4_1080p",
+			"results": map[string]interface{}{
+				"fake": map[string]interface{}{"presetId": "preset-1", "status": "in-sync"},
+			},
+		},
+		map[string]interface{}{
+			"name": "webm_720p",
+			"results": map[string]interface{}{
+				"fake": map[string]interface{}{
+					"presetId": "preset-2",
+					"status":   "missing",
+					"error":    "getting preset: could not find preset with id: preset-2",
+				},
+			},
+		},
+	}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("wrong reports\nwant %#v\ngot  %#v", want, got)
+	}
+	if err = service.ReconcilePresets(&buf, false, "mp4_720p"); err == nil {
+		t.Error("unexpected <nil> error for a preset that doesn't exist")
+	}
+}
+
+func TestReconcilePresetRecreate(t *testing.T) {
+	canonical := db.Preset{Name: "mp4_1080p", Container: "webm"}
+	tests := []struct {
+		givenTestCase   string
+		givenMapping    map[string]string
+		givenFailed     []string
+		givenDBError    bool
+		wantResults     map[string]providerPresetReconciliation
+		wantMapping     map[string]string
+		wantDeleted     []string
+		wantLocalPreset *db.Preset
+	}{
+		{
+			"drifted preset deleted after the presetmap is updated",
+			map[string]string{"fake": "preset-1"},
+			nil,
+			false,
+			map[string]providerPresetReconciliation{
+				"fake": {
+					PresetID:          "preset-1",
+					Status:            presetStatusDrifted,
+					Differences:       []db.PresetDifference{{Field: "container", Expected: "webm", Actual: "mp4"}},
+					RecreatedPresetID: "presetID_here",
+				},
+			},
+			map[string]string{"fake": "presetID_here"},
+			[]string{"preset-1"},
+			nil,
+		},
+		{
+			"drifted preset updated in place",
+			map[string]string{"local": "mp4_1080p"},
+			nil,
+			false,
+			map[string]providerPresetReconciliation{
+				"local": {
+					PresetID:          "mp4_1080p",
+					Status:            presetStatusDrifted,
+					Differences:       []db.PresetDifference{{Field: "container", Expected: "webm", Actual: "mp4"}},
+					RecreatedPresetID: "mp4_1080p",
+				},
+			},
+			map[string]string{"local": "mp4_1080p"},
+			nil,
+			&canonical,
+		},
+		{
+			"preset that can't be fetched",
+			map[string]string{"fake": "preset-1"},
+			[]string{"preset-1"},
+			false,
+			map[string]providerPresetReconciliation{
+				"fake": {
+					PresetID: "preset-1",
+					Status:   presetStatusError,
+					Error:    "getting preset: service unavailable",
+				},
+			},
+			map[string]string{"fake": "preset-1"},
+			nil,
+			nil,
+		},
+		{
+			"replacement deleted when the presetmap can't be updated",
+			map[string]string{"fake": "preset-1"},
+			nil,
+			true,
+			map[string]providerPresetReconciliation{
+				"fake": {
+					PresetID:          "preset-1",
+					Status:            presetStatusDrifted,
+					Differences:       []db.PresetDifference{{Field: "container", Expected: "webm", Actual: "mp4"}},
+					RecreatedPresetID: "presetID_here",
+				},
+			},
+			map[string]string{"fake": "preset-1"},
+			[]string{"presetID_here"},
+			nil,
+		},
+	}
+	for _, test := range tests {
+		fprovider = fakeProvider{failedPresets: test.givenFailed}
+		fakeDB := dbtest.NewFakeRepository(test.givenDBError)
+		localDB := dbtest.NewFakeRepository(false)
+		localDB.CreateLocalPreset(&db.LocalPreset{Name: "mp4_1080p", Preset: db.Preset{Name: "mp4_1080p", Container: "mp4"}})
+		localPresets = localDB
+		service, err := NewTranscodingService(&config.Config{Server: &server.Config{}}, logrus.New())
+		if err != nil {
+			t.Fatal(err)
+		}
+		service.db = fakeDB
+		preset := canonical
+		presetMap := db.PresetMap{Name: "mp4_1080p", ProviderMapping: test.givenMapping, Preset: &preset}
+		fakeDB.CreatePresetMap(&presetMap)
+		output, err := service.reconcilePresetMap(&presetMap, true)
+		if test.givenDBError && err == nil {
+			t.Errorf("%s: unexpected <nil> error", test.givenTestCase)
+		}
+		if !test.givenDBError && err != nil {
+			t.Errorf("%s: unexpected error: %s", test.givenTestCase, err)
+		}
+		if !reflect.DeepEqual(output.Results, test.wantResults) {
+			t.Errorf("%s: wrong results\nwant %#v\ngot  %#v", test.givenTestCase, test.wantResults, output.Results)
+		}
+		if !reflect.DeepEqual(presetMap.ProviderMapping, test.wantMapping) {
+			t.Errorf("%s: wrong provider mapping. Want %#v. Got %#v", test.givenTestCase, test.wantMapping, presetMap.ProviderMapping)
+		}
+		if !reflect.DeepEqual(fprovider.deletedPresets, test.wantDeleted) {
+			t.Errorf("%s: wrong deleted presets. Want %#v. Got %#v", test.givenTestCase, test.wantDeleted, fprovider.deletedPresets)
+		}
+		if test.wantLocalPreset != nil {
+			localPreset, err := localDB.GetLocalPreset("mp4_1080p")
+			if err != nil {
+				t.Fatal(err)
+			}
+			if !reflect.DeepEqual(localPreset.Preset, *test.wantLocalPreset) {
+				t.Errorf("%s: wrong local preset\nwant %#v\ngot  %#v", test.givenTestCase, *test.wantLocalPreset, localPreset.Preset)
+			}
+		}
+	}
+	localPresets = nil
+}
+
+func TestReconcilePresetRecreateMediaConvert(t *testing.T) {
+	canonical, err := normalizePreset(db.Preset{
+		Name:        "mp4_720p",
+		Container:   "mp4",
+		RateControl: "VBR",
+		Video:       db.VideoPreset{Codec: "h264", Height: "720", Bitrate: "2000000", GopSize: "90"},
+		Audio:       db.AudioPreset{Codec: "aac", Bitrate: "128000"},
+	}, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	drifted := canonical
+	drifted.Video.Bitrate = "1000000"
+	tests := []struct {
+		givenTestCase   string
+		givenPresets    []db.Preset
+		wantStatus      string
+		wantUpdateCalls []string
+	}{
+		{
+			"drifted preset updated in place",
+			[]db.Preset{drifted},
+			presetStatusDrifted,
+			[]string{"mp4_720p"},
+		},
+		{
+			"missing preset created",
+			nil,
+			presetStatusMissing,
+			nil,
+		},
+	}
+	for _, test := range tests {
+		mc := newFakeMediaConvert()
+		defer mc.Close()
+		cfg := &config.Config{Server: &server.Config{}, MediaConvert: mc.config()}
+		service, err := NewTranscodingService(cfg, logrus.New())
+		if err != nil {
+			t.Fatal(err)
+		}
+		for _, preset := range test.givenPresets {
+			if _, _, err = service.createProviderPreset("mediaconvert", preset); err != nil {
+				t.Fatal(err)
+			}
+		}
+		fakeDB := dbtest.NewFakeRepository(false)
+		service.db = fakeDB
+		preset := canonical
+		presetMap := db.PresetMap{Name: "mp4_720p", ProviderMapping: map[string]string{"mediaconvert": "mp4_720p"}, Preset: &preset}
+		fakeDB.CreatePresetMap(&presetMap)
+		output, err := service.reconcilePresetMap(&presetMap, true)
+		if err != nil {
+			t.Fatalf("%s: %s", test.givenTestCase, err)
+		}
+		result := output.Results["mediaconvert"]
+		if result.Status != test.wantStatus {
+			t.Errorf("%s: wrong status. Want %q. Got %q (%s)", test.givenTestCase, test.wantStatus, result.Status, result.Error)
+		}
+		if result.RecreatedPresetID != "mp4_720p" {
+			t.Errorf("%s: wrong recreated preset id. Want %q. Got %q (%s)", test.givenTestCase, "mp4_720p", result.RecreatedPresetID, result.Error)
+		}
+		if !reflect.DeepEqual(mc.updates, test.wantUpdateCalls) {
+			t.Errorf("%s: wrong preset updates\nwant %#v\ngot  %#v", test.givenTestCase, test.wantUpdateCalls, mc.updates)
+		}
+		if bitrate := mc.bitrate("mp4_720p"); bitrate != 2000000 {
+			t.Errorf("%s: wrong bitrate on the stored preset. Want 2000000. Got %v", test.givenTestCase, bitrate)
+		}
+		if presetMap.ProviderMapping["mediaconvert"] != "mp4_720p" {
+			t.Errorf("%s: wrong provider mapping: %#v", test.givenTestCase, presetMap.ProviderMapping)
+		}
+	}
+}
@@ -3,6 +3,7 @@ package service
 import (
 	"fmt"
 	"net/http"
+	"time"
 
 	"github.com/NYTimes/gizmo/server"
 	"github.com/NYTimes/gziphandler"
@@ -12,15 +13,27 @@ import (
 	"github.com/video-dev/video-transcoding-api/v2/config"
 	"github.com/video-dev/video-transcoding-api/v2/db"
 	"github.com/video-dev/video-transcoding-api/v2/db/redis"
+	"github.com/video-dev/video-transcoding-api/v2/internal/probe"
+	"github.com/video-dev/video-transcoding-api/v2/internal/verify"
 	"github.com/video-dev/video-transcoding-api/v2/swagger"
 )
 
+// sourceProbeTimeout is the timeout of each request made while probing the
+// source of a job.
+const sourceProbeTimeout = 30 * time.Second
+
+// outputVerificationTimeout is the timeout of each request made while
+// verifying the outputs of a job.
+const outputVerificationTimeout = 30 * time.Second
+
 // TranscodingService will implement server.JSONService and handle all requests
 // to the server.
 type TranscodingService struct {
-	config *config.Config
-	db     db.Repository
-	logger *logrus.Logger
+	config   *config.Config
+	db       db.Repository
+	logger   *logrus.Logger
+	prober   sourceProber
+	verifier outputVerifier
 }
 
 // NewTranscodingService will instantiate a JSONService
@@ -30,7 +43,12 @@ func NewTranscodingService(cfg *config.Config, logger *logrus.Logger) (*Transcod
 	if err != nil {
 		return nil, fmt.Errorf("error initializing Redis client: %s", err)
 	}
-	return &TranscodingService{config: cfg, db: dbRepo, logger: logger}, nil
+	prober := &probe.Prober{Client: &http.Client{Timeout: sourceProbeTimeout}}
+	verifier, err := verify.NewVerifier(cfg.Verification, &http.Client{Timeout: outputVerificationTimeout})
+	if err != nil {
+		return nil, fmt.Errorf("error initializing output verifier: %s", err)
+	}
+	return &TranscodingService{config: cfg, db: dbRepo, logger: logger, prober: prober, verifier: verifier}, nil
 }
 
 // Prefix returns the string prefix used for all endpoints within
@@ -55,10 +73,15 @@ func (s *TranscodingService) Middleware(h http.Handler) http.Handler {
 func (s *TranscodingService) JSONMiddleware(j server.JSONEndpoint) server.JSONEndpoint {
 	return func(r *http.Request) (int, interface{}, error) {
 		status, res, err := j(r)
-		if err != nil {
+		switch err.(type) {
+		case nil:
+			return status, res, nil
+		case *presetFieldsError, *presetInUseError:
+			// these errors carry their own body
+			return status, nil, err
+		default:
 			return swagger.NewErrorResponse(err).WithStatus(status).Result()
 		}
-		return status, res, nil
 	}
 }
 
@@ -74,12 +97,45 @@ func (s *TranscodingService) JSONEndpoints() map[string]map[string]server.JSONEn
 		"/jobs/{jobId}/cancel": {
 			"POST": swagger.HandlerToJSONEndpoint(s.cancelTranscodeJob),
 		},
+		"/jobs/{jobId}/verification": {
+			"GET": swagger.HandlerToJSONEndpoint(s.getJobVerification),
+		},
 		"/presets": {
 			"POST": swagger.HandlerToJSONEndpoint(s.newPreset),
 		},
 		"/presets/{name}": {
+			"GET":    swagger.HandlerToJSONEndpoint(s.getPreset),
+			"PUT":    swagger.HandlerToJSONEndpoint(s.updatePreset),
 			"DELETE": swagger.HandlerToJSONEndpoint(s.deletePreset),
 		},
+		"/presets/apply": {
+			"POST": swagger.HandlerToJSONEndpoint(s.applyPresets),
+		},
+		"/presets/validate": {
+			"POST": swagger.HandlerToJSONEndpoint(s.validatePreset),
+		},
+		"/presets/{name}/reconcile": {
+			"POST": swagger.HandlerToJSONEndpoint(s.reconcilePreset),
+		},
+		"/presets/{name}/versions": {
+			"GET": swagger.HandlerToJSONEndpoint(s.listPresetVersions),
+		},
+		"/ladders": {
+			"POST": swagger.HandlerToJSONEndpoint(s.newLadder),
+			"GET":  swagger.HandlerToJSONEndpoint(s.listLadders),
+		},
+		"/ladders/{name}": {
+			"GET": swagger.HandlerToJSONEndpoint(s.getLadder),
+		},
+		"/presetgroups": {
+			"POST": swagger.HandlerToJSONEndpoint(s.newPresetGroup),
+			"GET":  swagger.HandlerToJSONEndpoint(s.listPresetGroups),
+		},
+		"/presetgroups/{name}": {
+			"GET":    swagger.HandlerToJSONEndpoint(s.getPresetGroup),
+			"PUT":    swagger.HandlerToJSONEndpoint(s.updatePresetGroup),
+			"DELETE": swagger.HandlerToJSONEndpoint(s.deletePresetGroup),
+		},
 		"/presetmaps": {
 			"POST": swagger.HandlerToJSONEndpoint(s.newPresetMap),
 			"GET":  swagger.HandlerToJSONEndpoint(s.listPresetMaps),
@@ -95,6 +151,9 @@ func (s *TranscodingService) JSONEndpoints() map[string]map[string]server.JSONEn
 		"/providers/{name}": {
 			"GET": swagger.HandlerToJSONEndpoint(s.getProvider),
 		},
+		"/providers/{name}/presets/import": {
+			"POST": swagger.HandlerToJSONEndpoint(s.importPresets),
+		},
 	}
 }
 
@@ -104,5 +163,10 @@ func (s *TranscodingService) Endpoints() map[string]map[string]http.HandlerFunc
 		"/swagger.json": {
 			"GET": s.swaggerManifest,
 		},
+		// plain endpoints are registered before JSON endpoints, so this
+		// route takes precedence over GET /presets/{name}.
+		"/presets/export": {
+			"GET": s.exportPresets,
+		},
 	}
 }
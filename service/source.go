@@ -0,0 +1,64 @@
+package service
+
+import (
+	"fmt"
+
+	"github.com/video-dev/video-transcoding-api/v2/db"
+)
+
+// sourceProber is the interface used for probing sources before submitting
+// jobs, implemented by probe.Prober.
+type sourceProber interface {
+	Probe(sourceURL string) (db.SourceInfo, error)
+}
+
+// compatibleOutputs checks that the outputs and the audio tracks of the job
+// can be produced from the given source, returning the outputs that should
+// be transcoded. Presets are the canonical presets of the outputs, keyed by
+// preset name, and outputs whose preset is unknown are always kept. Outputs
+// that would upscale the source make the job invalid, except for ladder jobs,
+// where the rungs taller or wider than the source are left out of the job.
+func compatibleOutputs(job *db.Job, presets map[string]*db.Preset, source db.SourceInfo, dropUpscales bool) ([]db.TranscodeOutput, error) {
+	for i, track := range job.AudioTracks {
+		if track.SourceMedia == "" && int(track.TrackIndex) > len(source.AudioTracks) {
+			return nil, fmt.Errorf("invalid audio track %d: the source has %d audio track(s)", i, len(source.AudioTracks))
+		}
+	}
+	outputs := make([]db.TranscodeOutput, 0, len(job.Outputs))
+	for _, output := range job.Outputs {
+		preset := presets[output.Preset.Name]
+		if preset == nil {
+			outputs = append(outputs, output)
+			continue
+		}
+		if !preset.AudioOnly() && !source.HasVideo() {
+			return nil, fmt.Errorf("preset %q requires video, but the source has no video track", output.Preset.Name)
+		}
+		if preset.Audio.Codec != "" && !source.HasAudio() {
+			return nil, fmt.Errorf("preset %q requires audio, but the source has no audio track", output.Preset.Name)
+		}
+		if upscales(preset.Video, source) {
+			if dropUpscales {
+				continue
+			}
+			return nil, fmt.Errorf("preset %q would upscale the %dx%d source", output.Preset.Name, source.Width, source.Height)
+		}
+		outputs = append(outputs, output)
+	}
+	if len(outputs) == 0 {
+		return nil, fmt.Errorf("all outputs would upscale the %dx%d source", source.Width, source.Height)
+	}
+	return outputs, nil
+}
+
+// upscales returns whether transcoding the source with the given video
+// settings requires upscaling it.
+func upscales(video db.VideoPreset, source db.SourceInfo) bool {
+	if width, err := video.WidthPixels(); err == nil && source.Width > 0 && int64(width) > source.Width {
+		return true
+	}
+	if height, err := video.HeightPixels(); err == nil && source.Height > 0 && int64(height) > source.Height {
+		return true
+	}
+	return false
+}
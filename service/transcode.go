@@ -2,11 +2,13 @@ package service
 
 import (
 	"crypto/rand"
+	"errors"
 	"fmt"
 	"io"
 	"net/http"
 	"path"
 	"path/filepath"
+	"reflect"
 
 	"github.com/NYTimes/gizmo/server"
 	"github.com/video-dev/video-transcoding-api/v2/db"
@@ -38,39 +40,116 @@ func (s *TranscodingService) newTranscodeJob(r *http.Request) swagger.GizmoJSONR
 		return swagger.NewErrorResponse(formattedErr)
 	}
 	job := db.Job{
-		SourceMedia:     input.Payload.Source,
-		StreamingParams: input.Payload.StreamingParams,
+		SourceMedia:       input.Payload.Source,
+		StreamingParams:   input.Payload.StreamingParams,
+		AudioTracks:       input.Payload.AudioTracks,
+		ProviderOverrides: input.Payload.ProviderOverrides,
+		ContentAware:      input.Payload.ContentAware,
+		VerifyOutputs:     input.Payload.VerifyOutputs,
 	}
-	outputs := make([]db.TranscodeOutput, len(input.Payload.Outputs))
+	presetNames := make([]string, len(input.Payload.Outputs))
+	presetVersions := make([]uint, len(input.Payload.Outputs))
+	fileNames := make([]string, len(input.Payload.Outputs))
 	for i, output := range input.Payload.Outputs {
-		presetMap, presetErr := s.db.GetPresetMap(output.Preset)
+		presetNames[i] = output.Preset
+		presetVersions[i] = output.PresetVersion
+		fileNames[i] = output.FileName
+	}
+	// ladders are preset groups whose rungs can be dropped on small sources
+	groupName := input.Payload.PresetGroup
+	if input.Payload.Ladder != "" {
+		groupName = input.Payload.Ladder
+	}
+	var group *db.PresetGroup
+	if groupName != "" {
+		var groupErr error
+		group, groupErr = s.db.GetPresetGroup(groupName)
+		if groupErr != nil && groupErr != db.ErrPresetGroupNotFound {
+			return swagger.NewErrorResponse(groupErr)
+		}
+		if input.Payload.Ladder != "" && (groupErr != nil || !group.Ladder) {
+			return newInvalidJobResponse(errLadderNotFound)
+		}
+		if groupErr != nil {
+			return newInvalidJobResponse(groupErr)
+		}
+		presetNames = group.Presets
+		presetVersions = make([]uint, len(group.Presets))
+		fileNames = make([]string, len(group.Presets))
+		for i, presetName := range group.Presets {
+			fileNames[i] = group.FileNames[presetName]
+		}
+		if job.StreamingParams == (db.StreamingParams{}) {
+			job.StreamingParams = group.StreamingParams
+		}
+	}
+	outputs := make([]db.TranscodeOutput, len(presetNames))
+	versions := make(map[string]uint, len(presetNames))
+	for i, presetName := range presetNames {
+		presetMap, presetErr := s.db.GetPresetMap(presetName)
+		if presetErr == nil && presetVersions[i] != 0 {
+			presetMap, presetErr = s.presetMapVersion(presetMap, presetVersions[i], input.Payload.Provider, providerObj)
+		}
 		if presetErr != nil {
-			if presetErr == db.ErrPresetMapNotFound {
+			if presetErr == db.ErrPresetMapNotFound || presetErr == db.ErrPresetMapVersionNotFound {
+				return newInvalidJobResponse(presetErr)
+			}
+			if _, ok := presetErr.(presetVersionUnavailableError); ok {
 				return newInvalidJobResponse(presetErr)
 			}
 			return swagger.NewErrorResponse(presetErr)
 		}
-		fileName := output.FileName
+		if version, ok := versions[presetName]; ok && version != presetMap.Version {
+			return newInvalidJobResponse(fmt.Errorf("preset %q is used with different versions in the same job", presetName))
+		}
+		versions[presetName] = presetMap.Version
+		fileName := fileNames[i]
 		if fileName == "" {
 			fileName = s.defaultFileName(input.Payload.Source, presetMap)
 		}
 		outputs[i] = db.TranscodeOutput{FileName: fileName, Preset: *presetMap}
 	}
 	job.Outputs = outputs
+	if input.Payload.ProbeSource {
+		sourceInfo, probeErr := s.prober.Probe(job.SourceMedia)
+		if probeErr != nil {
+			return newInvalidJobResponse(fmt.Errorf("error probing source: %s", probeErr))
+		}
+		presets := make(map[string]*db.Preset, len(job.Outputs))
+		for _, output := range job.Outputs {
+			presets[output.Preset.Name] = s.canonicalPreset(output.Preset)
+		}
+		job.Outputs, err = compatibleOutputs(&job, presets, sourceInfo, group != nil && group.Ladder)
+		if err != nil {
+			return newInvalidJobResponse(fmt.Errorf("incompatible source: %s", err))
+		}
+		job.SourceInfo = &sourceInfo
+	}
+	if err = checkStreamingProtocol(&job); err != nil {
+		return newInvalidJobResponse(err)
+	}
+	job.PresetVersions = presetVersionsOf(job.Outputs)
+	job.PresetSnapshots = s.presetSnapshotsOf(job.Outputs)
 	job.ID, err = s.genID()
 	if err != nil {
 		return swagger.NewErrorResponse(err)
 	}
-	if job.StreamingParams.Protocol == "hls" {
+	if protocol := job.StreamingParams.Protocol; protocol == "hls" || protocol == "cmaf" {
 		if job.StreamingParams.PlaylistFileName == "" {
-			job.StreamingParams.PlaylistFileName = "hls/index.m3u8"
+			job.StreamingParams.PlaylistFileName = protocol + "/index.m3u8"
 		}
 		if job.StreamingParams.SegmentDuration == 0 {
 			job.StreamingParams.SegmentDuration = s.config.DefaultSegmentDuration
 		}
 	}
 	jobStatus, err := providerObj.Transcode(&job)
-	if err == provider.ErrPresetMapNotFound {
+	if err == provider.ErrPresetMapNotFound || err == provider.ErrAudioTracksNotSupported || err == provider.ErrProviderOverridesNotSupported {
+		return newInvalidJobResponse(err)
+	}
+	if _, ok := err.(provider.HLSOptionNotSupportedError); ok {
+		return newInvalidJobResponse(err)
+	}
+	if _, ok := err.(provider.InvalidAudioTracksError); ok {
 		return newInvalidJobResponse(err)
 	}
 	if err != nil {
@@ -87,6 +166,32 @@ func (s *TranscodingService) newTranscodeJob(r *http.Request) swagger.GizmoJSONR
 	return newJobResponse(job.ID)
 }
 
+// checkStreamingProtocol makes sure the streaming protocol of the job matches
+// the containers of its presets. Providers pick the packaging of each output
+// from its preset, so a cmaf preset on an hls job (or the other way around)
+// would produce manifests other than the ones requested.
+func checkStreamingProtocol(job *db.Job) error {
+	protocol := job.StreamingParams.Protocol
+	var cmafOutputs int
+	for _, output := range job.Outputs {
+		switch output.Preset.OutputOpts.Extension {
+		case "cmaf":
+			if protocol != "cmaf" {
+				return fmt.Errorf("preset %q outputs cmaf, but the streaming protocol is %q", output.Preset.Name, protocol)
+			}
+			cmafOutputs++
+		case "m3u8":
+			if protocol == "cmaf" {
+				return fmt.Errorf("preset %q outputs m3u8, which can't be used with the cmaf streaming protocol", output.Preset.Name)
+			}
+		}
+	}
+	if protocol == "cmaf" && cmafOutputs == 0 {
+		return errors.New("the cmaf streaming protocol requires at least one cmaf preset")
+	}
+	return nil
+}
+
 func (s *TranscodingService) genID() (string, error) {
 	var data [8]byte
 	n, err := rand.Read(data[:])
@@ -104,8 +209,11 @@ func (s *TranscodingService) defaultFileName(source string, preset *db.PresetMap
 	_, source = path.Split(source)
 	source = source[:len(source)-len(sourceExtension)]
 	pattern := "%s_%s.%s"
-	if preset.OutputOpts.Extension == "m3u8" {
+	switch preset.OutputOpts.Extension {
+	case "m3u8":
 		pattern = "hls/" + pattern
+	case "cmaf":
+		pattern = "cmaf/" + pattern
 	}
 	return fmt.Sprintf(pattern, source, preset.Name, preset.OutputOpts.Extension)
 }
@@ -164,6 +272,9 @@ func (s *TranscodingService) getTranscodeJobByID(jobID string) (*db.Job, *provid
 		return job, nil, providerObj, err
 	}
 	jobStatus.ProviderName = job.ProviderName
+	if job.SourceInfo != nil && reflect.DeepEqual(jobStatus.SourceInfo, provider.SourceInfo{}) {
+		jobStatus.SourceInfo = *job.SourceInfo
+	}
 	return job, jobStatus, providerObj, nil
 }
 
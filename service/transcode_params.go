@@ -3,7 +3,9 @@ package service
 import (
 	"encoding/json"
 	"errors"
+	"fmt"
 	"io"
+	"net/url"
 
 	"github.com/video-dev/video-transcoding-api/v2/db"
 	"github.com/video-dev/video-transcoding-api/v2/internal/provider"
@@ -19,13 +21,44 @@ type NewTranscodeJobInputPayload struct {
 	Outputs []struct {
 		FileName string `json:"fileName"`
 		Preset   string `json:"preset"`
+
+		// version of the preset to use, defaults to the latest one
+		PresetVersion uint `json:"presetVersion,omitempty"`
 	} `json:"outputs"`
 
+	// name of a ladder whose presets are used as the outputs of the job,
+	// as an alternative to the list of outputs
+	Ladder string `json:"ladder,omitempty"`
+
+	// name of a preset group whose presets are used as the outputs of the
+	// job, as an alternative to the list of outputs
+	PresetGroup string `json:"presetGroup,omitempty"`
+
 	// provider to use in this job
 	Provider string `json:"provider"`
 
 	// provider Adaptive Streaming parameters
 	StreamingParams db.StreamingParams `json:"streamingParams,omitempty"`
+
+	// alternate audio tracks, added to the adaptive streaming outputs
+	AudioTracks []db.AudioTrack `json:"audioTracks,omitempty"`
+
+	// provider-specific settings, keyed by provider name and merged into
+	// the native job payload of the provider
+	ProviderOverrides db.ProviderOverrides `json:"providerOverrides,omitempty"`
+
+	// adapt the renditions to the complexity of the source. Providers
+	// without native support transcode the outputs as a fixed ladder
+	ContentAware bool `json:"contentAware,omitempty"`
+
+	// probe the source before submitting the job, rejecting jobs whose
+	// outputs can't be produced from it. Only HTTP sources can be probed
+	ProbeSource bool `json:"probeSource,omitempty"`
+
+	// fetch the outputs once the job finishes and check them against
+	// their presets. The report is available at
+	// /jobs/{jobId}/verification
+	VerifyOutputs bool `json:"verifyOutputs,omitempty"`
 }
 
 // swagger:parameters newJob
@@ -60,10 +93,29 @@ func (p *newTranscodeJobInput) validate() error {
 	if p.Payload.Source == "" {
 		return errors.New("missing source media from request")
 	}
-	if len(p.Payload.Outputs) == 0 {
+	if len(p.Payload.Outputs) == 0 && p.Payload.Ladder == "" && p.Payload.PresetGroup == "" {
 		return errors.New("missing output list from request")
 	}
-	return nil
+	if len(p.Payload.Outputs) > 0 && p.Payload.Ladder != "" {
+		return errors.New("outputs and ladder are mutually exclusive")
+	}
+	if p.Payload.PresetGroup != "" && (len(p.Payload.Outputs) > 0 || p.Payload.Ladder != "") {
+		return errors.New("presetGroup is mutually exclusive with outputs and ladder")
+	}
+	if err := p.Payload.StreamingParams.Validate(); err != nil {
+		return err
+	}
+	if p.Payload.ProbeSource {
+		if u, err := url.Parse(p.Payload.Source); err != nil || (u.Scheme != "http" && u.Scheme != "https") {
+			return errors.New("source probing is only supported for HTTP sources")
+		}
+	}
+	for i, track := range p.Payload.AudioTracks {
+		if err := track.Validate(); err != nil {
+			return fmt.Errorf("invalid audio track %d: %s", i, err)
+		}
+	}
+	return p.Payload.ProviderOverrides.Validate(provider.Names())
 }
 
 // swagger:parameters getJob
@@ -81,3 +133,8 @@ func (p *getTranscodeJobInput) loadParams(paramsMap map[string]string) {
 type cancelTranscodeJobInput struct {
 	getTranscodeJobInput
 }
+
+// swagger:parameters getJobVerification
+type getJobVerificationInput struct {
+	getTranscodeJobInput
+}
@@ -3,6 +3,7 @@ package service
 import (
 	"net/http"
 
+	"github.com/video-dev/video-transcoding-api/v2/db"
 	"github.com/video-dev/video-transcoding-api/v2/internal/provider"
 	"github.com/video-dev/video-transcoding-api/v2/swagger"
 )
@@ -105,3 +106,40 @@ func newJobNotFoundProviderResponse(err error) *jobNotFoundProviderResponse {
 func (r *jobNotFoundProviderResponse) Result() (int, interface{}, error) {
 	return r.Error.Result()
 }
+
+// JSON-encoded report of the verification of the outputs of a job.
+//
+// swagger:response verificationReport
+type verificationReportResponse struct {
+	// in: body
+	Payload *db.VerificationReport
+
+	baseResponse
+}
+
+func newVerificationReportResponse(report *db.VerificationReport) *verificationReportResponse {
+	return &verificationReportResponse{
+		baseResponse: baseResponse{
+			payload: report,
+			status:  http.StatusOK,
+		},
+	}
+}
+
+// error returned when the outputs of the job can't be verified, either
+// because the verification wasn't requested or because the job didn't
+// finish.
+//
+// swagger:response verificationUnavailable
+type verificationUnavailableResponse struct {
+	// in: body
+	Error *swagger.ErrorResponse
+}
+
+func newVerificationUnavailableResponse(err error) *verificationUnavailableResponse {
+	return &verificationUnavailableResponse{Error: swagger.NewErrorResponse(err).WithStatus(http.StatusConflict)}
+}
+
+func (r *verificationUnavailableResponse) Result() (int, interface{}, error) {
+	return r.Error.Result()
+}
@@ -3,11 +3,13 @@ package service
 import (
 	"bytes"
 	"encoding/json"
+	"errors"
 	"net/http"
 	"net/http/httptest"
 	"reflect"
 	"strings"
 	"testing"
+	"time"
 
 	"github.com/NYTimes/gizmo/server"
 	"github.com/sirupsen/logrus"
@@ -80,6 +82,74 @@ func TestTranscode(t *testing.T) {
 			"hls/index.m3u8",
 			5,
 		},
+		{
+			"New job - cmaf default playlist file name, segment duration & regular file name",
+			`{
+  "source": "http://another.non.existent/video.mp4",
+  "destination": "s3://some.bucket.s3.amazonaws.com/some_path",
+  "outputs": [{"preset":"cmaf_1080p","fileName":""}],
+  "streamingParams": {"protocol":"cmaf"},
+  "provider": "fake"
+}`,
+			false,
+
+			http.StatusOK,
+			map[string]interface{}{"jobId": "fill me"},
+			[]string{"cmaf/video_cmaf_1080p.cmaf"},
+			"cmaf/index.m3u8",
+			5,
+		},
+		{
+			"New job - cmaf preset with the hls protocol",
+			`{
+  "source": "http://another.non.existent/video.mp4",
+  "destination": "s3://some.bucket.s3.amazonaws.com/some_path",
+  "outputs": [{"preset":"cmaf_1080p"}],
+  "streamingParams": {"protocol":"hls"},
+  "provider": "fake"
+}`,
+			false,
+
+			http.StatusBadRequest,
+			map[string]interface{}{"error": `preset "cmaf_1080p" outputs cmaf, but the streaming protocol is "hls"`},
+			nil,
+			"",
+			0,
+		},
+		{
+			"New job - hls preset with the cmaf protocol",
+			`{
+  "source": "http://another.non.existent/video.mp4",
+  "destination": "s3://some.bucket.s3.amazonaws.com/some_path",
+  "outputs": [{"preset":"cmaf_1080p"},{"preset":"hls_1080p"}],
+  "streamingParams": {"protocol":"cmaf"},
+  "provider": "fake"
+}`,
+			false,
+
+			http.StatusBadRequest,
+			map[string]interface{}{"error": `preset "hls_1080p" outputs m3u8, which can't be used with the cmaf streaming protocol`},
+			nil,
+			"",
+			0,
+		},
+		{
+			"New job - cmaf protocol without cmaf presets",
+			`{
+  "source": "http://another.non.existent/video.mp4",
+  "destination": "s3://some.bucket.s3.amazonaws.com/some_path",
+  "outputs": [{"preset":"mp4_1080p"}],
+  "streamingParams": {"protocol":"cmaf"},
+  "provider": "fake"
+}`,
+			false,
+
+			http.StatusBadRequest,
+			map[string]interface{}{"error": "the cmaf streaming protocol requires at least one cmaf preset"},
+			nil,
+			"",
+			0,
+		},
 		{
 			"New job - no playlist file name",
 			`{
@@ -177,6 +247,271 @@ func TestTranscode(t *testing.T) {
 			"",
 			0,
 		},
+		{
+			"New job with alternate audio tracks",
+			`{
+  "source": "http://another.non.existent/video.mp4",
+  "outputs": [{"preset":"hls_1080p"}],
+  "streamingParams": {"protocol":"hls"},
+  "audioTracks": [{"trackIndex":2,"language":"es","name":"Español"},{"source":"http://another.non.existent/fr.wav","language":"fr"}],
+  "provider": "fake"
+}`,
+			false,
+
+			http.StatusOK,
+			map[string]interface{}{"jobId": "fill me"},
+			[]string{"hls/video_hls_1080p.m3u8"},
+			"hls/index.m3u8",
+			5,
+		},
+		{
+			"New job with invalid audio track",
+			`{
+  "source": "http://another.non.existent/video.mp4",
+  "outputs": [{"preset":"hls_1080p"}],
+  "audioTracks": [{"trackIndex":2}],
+  "provider": "fake"
+}`,
+			false,
+
+			http.StatusBadRequest,
+			map[string]interface{}{"error": "invalid audio track 0: language is required"},
+			nil,
+			"",
+			0,
+		},
+		{
+			"New job with audio tracks the provider can't transcode",
+			`{
+  "source": "http://another.non.existent/video.mp4",
+  "outputs": [{"preset":"hls_1080p"}],
+  "streamingParams": {"protocol":"hls"},
+  "audioTracks": [{"source":"http://another.non.existent/audio.wav","language":"unsupported"}],
+  "provider": "fake"
+}`,
+			false,
+
+			http.StatusBadRequest,
+			map[string]interface{}{"error": `audio tracks in language "unsupported" are not supported`},
+			nil,
+			"",
+			0,
+		},
+		{
+			"New job with provider overrides",
+			`{
+  "source": "http://another.non.existent/video.mp4",
+  "outputs": [{"preset":"mp4_1080p","fileName":"video.mp4"}],
+  "providerOverrides": {"fake": {"priority":10}, "zencoder": {"speed":4}},
+  "provider": "fake"
+}`,
+			false,
+
+			http.StatusOK,
+			map[string]interface{}{"jobId": "fill me"},
+			[]string{"video.mp4"},
+			"",
+			0,
+		},
+		{
+			"New job with overrides for an unknown provider",
+			`{
+  "source": "http://another.non.existent/video.mp4",
+  "outputs": [{"preset":"mp4_1080p","fileName":"video.mp4"}],
+  "providerOverrides": {"unknown": {"priority":10}},
+  "provider": "fake"
+}`,
+			false,
+
+			http.StatusBadRequest,
+			map[string]interface{}{"error": `providerOverrides: unexpected provider "unknown"`},
+			nil,
+			"",
+			0,
+		},
+		{
+			"New job with overrides that aren't an object",
+			`{
+  "source": "http://another.non.existent/video.mp4",
+  "outputs": [{"preset":"mp4_1080p","fileName":"video.mp4"}],
+  "providerOverrides": {"fake": [1, 2]},
+  "provider": "fake"
+}`,
+			false,
+
+			http.StatusBadRequest,
+			map[string]interface{}{"error": `providerOverrides: overrides for "fake" must be a JSON object`},
+			nil,
+			"",
+			0,
+		},
+		{
+			"New job with content-aware encoding",
+			`{
+  "source": "http://another.non.existent/video.mp4",
+  "destination": "s3://some.bucket.s3.amazonaws.com/some_path",
+  "outputs": [{"preset":"hls_1080p"}],
+  "streamingParams": {"protocol":"hls"},
+  "contentAware": true,
+  "verifyOutputs": true,
+  "provider": "fake"
+}`,
+			false,
+
+			http.StatusOK,
+			map[string]interface{}{"jobId": "fill me"},
+			[]string{"hls/video_hls_1080p.m3u8"},
+			"hls/index.m3u8",
+			5,
+		},
+		{
+			"New job using a ladder",
+			`{
+  "source": "http://another.non.existent/video.mp4",
+  "destination": "s3://some.bucket.s3.amazonaws.com/some_path",
+  "ladder": "hls_ladder",
+  "provider": "fake"
+}`,
+			false,
+
+			http.StatusOK,
+			map[string]interface{}{"jobId": "fill me"},
+			[]string{"hls/video_hls_1080p.m3u8", "hls/video_hls_720p.m3u8"},
+			"hls/index.m3u8",
+			4,
+		},
+		{
+			"New job using a ladder with streaming params",
+			`{
+  "source": "http://another.non.existent/video.mp4",
+  "destination": "s3://some.bucket.s3.amazonaws.com/some_path",
+  "ladder": "hls_ladder",
+  "streamingParams": {"protocol":"hls","segmentDuration":6},
+  "provider": "fake"
+}`,
+			false,
+
+			http.StatusOK,
+			map[string]interface{}{"jobId": "fill me"},
+			[]string{"hls/video_hls_1080p.m3u8", "hls/video_hls_720p.m3u8"},
+			"hls/index.m3u8",
+			6,
+		},
+		{
+			"New job with ladder not found",
+			`{
+  "source": "http://another.non.existent/video.mp4",
+  "destination": "s3://some.bucket.s3.amazonaws.com/some_path",
+  "ladder": "dash_ladder",
+  "provider": "fake"
+}`,
+			false,
+
+			http.StatusBadRequest,
+			map[string]interface{}{"error": "ladder not found"},
+			nil,
+			"",
+			0,
+		},
+		{
+			"New job with ladder that is a plain preset group",
+			`{
+  "source": "http://another.non.existent/video.mp4",
+  "destination": "s3://some.bucket.s3.amazonaws.com/some_path",
+  "ladder": "standard",
+  "provider": "fake"
+}`,
+			false,
+
+			http.StatusBadRequest,
+			map[string]interface{}{"error": "ladder not found"},
+			nil,
+			"",
+			0,
+		},
+		{
+			"New job with both outputs and ladder",
+			`{
+  "source": "http://another.non.existent/video.mp4",
+  "destination": "s3://some.bucket.s3.amazonaws.com/some_path",
+  "outputs": [{"preset":"mp4_1080p"}],
+  "ladder": "hls_ladder",
+  "provider": "fake"
+}`,
+			false,
+
+			http.StatusBadRequest,
+			map[string]interface{}{"error": "outputs and ladder are mutually exclusive"},
+			nil,
+			"",
+			0,
+		},
+		{
+			"New job using a preset group",
+			`{
+  "source": "http://another.non.existent/video.mp4",
+  "destination": "s3://some.bucket.s3.amazonaws.com/some_path",
+  "presetGroup": "standard",
+  "provider": "fake"
+}`,
+			false,
+
+			http.StatusOK,
+			map[string]interface{}{"jobId": "fill me"},
+			[]string{"hls/video_hls_1080p.m3u8", "hls/720p.m3u8", "video.mp4"},
+			"hls/master.m3u8",
+			6,
+		},
+		{
+			"New job using a preset group with streaming params",
+			`{
+  "source": "http://another.non.existent/video.mp4",
+  "destination": "s3://some.bucket.s3.amazonaws.com/some_path",
+  "presetGroup": "standard",
+  "streamingParams": {"protocol":"hls"},
+  "provider": "fake"
+}`,
+			false,
+
+			http.StatusOK,
+			map[string]interface{}{"jobId": "fill me"},
+			[]string{"hls/video_hls_1080p.m3u8", "hls/720p.m3u8", "video.mp4"},
+			"hls/index.m3u8",
+			5,
+		},
+		{
+			"New job with preset group not found",
+			`{
+  "source": "http://another.non.existent/video.mp4",
+  "destination": "s3://some.bucket.s3.amazonaws.com/some_path",
+  "presetGroup": "premium",
+  "provider": "fake"
+}`,
+			false,
+
+			http.StatusBadRequest,
+			map[string]interface{}{"error": "preset group not found"},
+			nil,
+			"",
+			0,
+		},
+		{
+			"New job with both outputs and preset group",
+			`{
+  "source": "http://another.non.existent/video.mp4",
+  "destination": "s3://some.bucket.s3.amazonaws.com/some_path",
+  "outputs": [{"preset":"mp4_1080p"}],
+  "presetGroup": "standard",
+  "provider": "fake"
+}`,
+			false,
+
+			http.StatusBadRequest,
+			map[string]interface{}{"error": "presetGroup is mutually exclusive with outputs and ladder"},
+			nil,
+			"",
+			0,
+		},
 		{
 			"New job missing outputs",
 			`{
@@ -208,10 +543,32 @@ func TestTranscode(t *testing.T) {
 			ProviderMapping: map[string]string{"fake": "19928"},
 			OutputOpts:      db.OutputOptions{Extension: "m3u8"},
 		})
+		fakeDBObj.CreatePresetMap(&db.PresetMap{
+			Name:            "cmaf_1080p",
+			ProviderMapping: map[string]string{"fake": "20028"},
+			OutputOpts:      db.OutputOptions{Extension: "cmaf"},
+		})
 		fakeDBObj.CreatePresetMap(&db.PresetMap{
 			Name:            "mp4_360p",
 			ProviderMapping: map[string]string{"elementalconductor": "172712"},
 		})
+		fakeDBObj.CreatePresetMap(&db.PresetMap{
+			Name:            "hls_720p",
+			ProviderMapping: map[string]string{"fake": "19927"},
+			OutputOpts:      db.OutputOptions{Extension: "m3u8"},
+		})
+		fakeDBObj.CreatePresetGroup(&db.PresetGroup{
+			Name:            "hls_ladder",
+			Presets:         []string{"hls_1080p", "hls_720p"},
+			StreamingParams: db.StreamingParams{Protocol: "hls", SegmentDuration: 4},
+			Ladder:          true,
+		})
+		fakeDBObj.CreatePresetGroup(&db.PresetGroup{
+			Name:            "standard",
+			Presets:         []string{"hls_1080p", "hls_720p", "mp4_1080p"},
+			FileNames:       db.PresetFileNames{"hls_720p": "hls/720p.m3u8", "mp4_1080p": "video.mp4"},
+			StreamingParams: db.StreamingParams{Protocol: "hls", PlaylistFileName: "hls/master.m3u8", SegmentDuration: 6},
+		})
 		service, err := NewTranscodingService(&config.Config{
 			DefaultSegmentDuration: 5,
 			Server:                 &server.Config{},
@@ -264,6 +621,245 @@ func TestTranscode(t *testing.T) {
 			if segmentDuration != test.wantSegmentDuration {
 				t.Errorf("%s: wrong segment duration\nwant %d\ngot  %d", test.givenTestCase, test.wantSegmentDuration, segmentDuration)
 			}
+			var payload NewTranscodeJobInputPayload
+			json.Unmarshal([]byte(test.givenRequestBody), &payload)
+			audioTracks := fprovider.jobs[0].AudioTracks
+			if !reflect.DeepEqual(audioTracks, payload.AudioTracks) {
+				t.Errorf("%s: wrong audio tracks\nwant %#v\ngot  %#v", test.givenTestCase, payload.AudioTracks, audioTracks)
+			}
+			overrides := fprovider.jobs[0].ProviderOverrides
+			if !reflect.DeepEqual(overrides, payload.ProviderOverrides) {
+				t.Errorf("%s: wrong provider overrides\nwant %#v\ngot  %#v", test.givenTestCase, payload.ProviderOverrides, overrides)
+			}
+			if contentAware := fprovider.jobs[0].ContentAware; contentAware != payload.ContentAware {
+				t.Errorf("%s: wrong content-aware flag\nwant %t\ngot  %t", test.givenTestCase, payload.ContentAware, contentAware)
+			}
+			if verifyOutputs := fprovider.jobs[0].VerifyOutputs; verifyOutputs != payload.VerifyOutputs {
+				t.Errorf("%s: wrong verify outputs flag\nwant %t\ngot  %t", test.givenTestCase, payload.VerifyOutputs, verifyOutputs)
+			}
+		}
+	}
+}
+
+type fakeProber struct {
+	info    db.SourceInfo
+	err     error
+	sources []string
+}
+
+func (p *fakeProber) Probe(sourceURL string) (db.SourceInfo, error) {
+	p.sources = append(p.sources, sourceURL)
+	return p.info, p.err
+}
+
+func TestTranscodeWithSourceProbing(t *testing.T) {
+	source1080p := db.SourceInfo{
+		Duration:    time.Minute,
+		Width:       1920,
+		Height:      1080,
+		VideoCodec:  "h264",
+		Container:   "mp4",
+		AudioTracks: []db.SourceAudioTrack{{Codec: "aac", Channels: 2, Language: "eng"}},
+	}
+	source720p := source1080p
+	source720p.Width = 1280
+	source720p.Height = 720
+	source480p := source1080p
+	source480p.Width = 854
+	source480p.Height = 480
+	sourceNoAudio := source1080p
+	sourceNoAudio.AudioTracks = nil
+	sourceNoVideo := db.SourceInfo{Duration: time.Minute, AudioTracks: source1080p.AudioTracks}
+	tests := []struct {
+		givenTestCase    string
+		givenRequestBody string
+		givenSourceInfo  db.SourceInfo
+		givenProbeError  error
+
+		wantCode            int
+		wantBody            map[string]interface{}
+		wantOutputFileNames []string
+	}{
+		{
+			"Compatible source",
+			`{"source":"http://example.com/video.mp4","outputs":[{"preset":"hls_1080p"},{"preset":"aac_128k"}],"provider":"fake","probeSource":true}`,
+			source1080p,
+			nil,
+
+			http.StatusOK,
+			map[string]interface{}{"jobId": "fill me"},
+			[]string{"hls/video_hls_1080p.m3u8", "video_aac_128k.m4a"},
+		},
+		{
+			"Ladder rungs taller than the source are left out",
+			`{"source":"https://example.com/video.mp4","ladder":"hls_ladder","provider":"fake","probeSource":true}`,
+			source720p,
+			nil,
+
+			http.StatusOK,
+			map[string]interface{}{"jobId": "fill me"},
+			[]string{"hls/video_hls_720p.m3u8"},
+		},
+		{
+			"Preset group rungs taller than the source are left out",
+			`{"source":"https://example.com/video.mp4","presetGroup":"hls_ladder","provider":"fake","probeSource":true}`,
+			source720p,
+			nil,
+
+			http.StatusOK,
+			map[string]interface{}{"jobId": "fill me"},
+			[]string{"hls/video_hls_720p.m3u8"},
+		},
+		{
+			"Audio-only output from a source without video",
+			`{"source":"http://example.com/audio.mp4","outputs":[{"preset":"aac_128k"}],"provider":"fake","probeSource":true}`,
+			sourceNoVideo,
+			nil,
+
+			http.StatusOK,
+			map[string]interface{}{"jobId": "fill me"},
+			[]string{"audio_aac_128k.m4a"},
+		},
+		{
+			"Output that would upscale the source",
+			`{"source":"http://example.com/video.mp4","outputs":[{"preset":"hls_1080p"}],"provider":"fake","probeSource":true}`,
+			source720p,
+			nil,
+
+			http.StatusBadRequest,
+			map[string]interface{}{"error": `incompatible source: preset "hls_1080p" would upscale the 1280x720 source`},
+			nil,
+		},
+		{
+			"All ladder rungs would upscale the source",
+			`{"source":"http://example.com/video.mp4","ladder":"hls_ladder","provider":"fake","probeSource":true}`,
+			source480p,
+			nil,
+
+			http.StatusBadRequest,
+			map[string]interface{}{"error": "incompatible source: all outputs would upscale the 854x480 source"},
+			nil,
+		},
+		{
+			"Source without audio",
+			`{"source":"http://example.com/video.mp4","outputs":[{"preset":"hls_1080p"}],"provider":"fake","probeSource":true}`,
+			sourceNoAudio,
+			nil,
+
+			http.StatusBadRequest,
+			map[string]interface{}{"error": `incompatible source: preset "hls_1080p" requires audio, but the source has no audio track`},
+			nil,
+		},
+		{
+			"Source without video",
+			`{"source":"http://example.com/audio.mp4","outputs":[{"preset":"hls_720p"}],"provider":"fake","probeSource":true}`,
+			sourceNoVideo,
+			nil,
+
+			http.StatusBadRequest,
+			map[string]interface{}{"error": `incompatible source: preset "hls_720p" requires video, but the source has no video track`},
+			nil,
+		},
+		{
+			"Audio track missing from the source",
+			`{"source":"http://example.com/video.mp4","outputs":[{"preset":"hls_1080p"}],"audioTracks":[{"trackIndex":2,"language":"spa"}],"provider":"fake","probeSource":true}`,
+			source1080p,
+			nil,
+
+			http.StatusBadRequest,
+			map[string]interface{}{"error": "incompatible source: invalid audio track 0: the source has 1 audio track(s)"},
+			nil,
+		},
+		{
+			"Probe error",
+			`{"source":"http://example.com/video.ts","outputs":[{"preset":"hls_1080p"}],"provider":"fake","probeSource":true}`,
+			db.SourceInfo{},
+			errors.New("unsupported container"),
+
+			http.StatusBadRequest,
+			map[string]interface{}{"error": "error probing source: unsupported container"},
+			nil,
+		},
+		{
+			"Non-HTTP source",
+			`{"source":"s3://bucket/video.mp4","outputs":[{"preset":"hls_1080p"}],"provider":"fake","probeSource":true}`,
+			source1080p,
+			nil,
+
+			http.StatusBadRequest,
+			map[string]interface{}{"error": "source probing is only supported for HTTP sources"},
+			nil,
+		},
+	}
+	for _, test := range tests {
+		fprovider.jobs = nil
+		srvr := server.NewSimpleServer(&server.Config{})
+		fakeDBObj := dbtest.NewFakeRepository(false)
+		audio := db.AudioPreset{Codec: "aac", Bitrate: "128000"}
+		presets := []db.Preset{
+			{Name: "hls_1080p", Container: "m3u8", Video: db.VideoPreset{Codec: "h264", Height: "1080"}, Audio: audio},
+			{Name: "hls_720p", Container: "m3u8", Video: db.VideoPreset{Codec: "h264", Width: "1280", Height: "720"}, Audio: audio},
+			{Name: "aac_128k", Container: "m4a", Audio: audio},
+		}
+		for _, preset := range presets {
+			fakeDBObj.CreatePresetMap(&db.PresetMap{
+				Name:            preset.Name,
+				ProviderMapping: map[string]string{"fake": preset.Name},
+				OutputOpts:      db.OutputOptions{Extension: preset.Container},
+			})
+			fakeDBObj.CreateLocalPreset(&db.LocalPreset{Name: preset.Name, Preset: preset})
+		}
+		fakeDBObj.CreatePresetGroup(&db.PresetGroup{
+			Name:            "hls_ladder",
+			Presets:         []string{"hls_720p", "hls_1080p"},
+			StreamingParams: db.StreamingParams{Protocol: "hls", SegmentDuration: 4},
+			Ladder:          true,
+		})
+		service, err := NewTranscodingService(&config.Config{Server: &server.Config{}}, logrus.New())
+		if err != nil {
+			t.Fatal(err)
+		}
+		prober := &fakeProber{info: test.givenSourceInfo, err: test.givenProbeError}
+		service.db = fakeDBObj
+		service.prober = prober
+		srvr.Register(service)
+		r, _ := http.NewRequest("POST", "/jobs", strings.NewReader(test.givenRequestBody))
+		r.Header.Set("Content-Type", "application/json")
+		w := httptest.NewRecorder()
+		srvr.ServeHTTP(w, r)
+		if w.Code != test.wantCode {
+			t.Errorf("%s: expected response code of %d. got %d", test.givenTestCase, test.wantCode, w.Code)
+		}
+		var got map[string]interface{}
+		err = json.Unmarshal(w.Body.Bytes(), &got)
+		if err != nil {
+			t.Errorf("%s: unable to JSON decode response body: %s", test.givenTestCase, err)
+		}
+		if _, ok := test.wantBody["jobId"]; ok {
+			test.wantBody["jobId"] = got["jobId"]
+		}
+		if !reflect.DeepEqual(got, test.wantBody) {
+			t.Errorf("%s: expected response body of\n%#v;\ngot\n%#v", test.givenTestCase, test.wantBody, got)
+		}
+		if test.wantCode != http.StatusOK {
+			continue
+		}
+		job, err := fakeDBObj.GetJob(got["jobId"].(string))
+		if err != nil {
+			t.Fatal(err)
+		}
+		if job.SourceInfo == nil || !reflect.DeepEqual(*job.SourceInfo, test.givenSourceInfo) {
+			t.Errorf("%s: wrong source info stored in the job\nwant %#v\ngot  %#v", test.givenTestCase, test.givenSourceInfo, job.SourceInfo)
+		}
+		if len(prober.sources) != 1 || prober.sources[0] != job.SourceMedia {
+			t.Errorf("%s: wrong sources probed: %#v", test.givenTestCase, prober.sources)
+		}
+		fileNames := make([]string, len(fprovider.jobs[0].Outputs))
+		for i, output := range fprovider.jobs[0].Outputs {
+			fileNames[i] = output.FileName
+		}
+		if !reflect.DeepEqual(fileNames, test.wantOutputFileNames) {
+			t.Errorf("%s: wrong file names for output files\nwant %#v\ngot  %#v", test.givenTestCase, test.wantOutputFileNames, fileNames)
 		}
 	}
 }
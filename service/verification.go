@@ -0,0 +1,65 @@
+package service
+
+import (
+	"errors"
+	"fmt"
+	"net/http"
+
+	"github.com/NYTimes/gizmo/server"
+	"github.com/video-dev/video-transcoding-api/v2/db"
+	"github.com/video-dev/video-transcoding-api/v2/internal/provider"
+	"github.com/video-dev/video-transcoding-api/v2/swagger"
+)
+
+// outputVerifier is the interface used for verifying the outputs of finished
+// jobs, implemented by verify.Verifier.
+type outputVerifier interface {
+	Verify(job *db.Job, presets map[string]*db.Preset, status *provider.JobStatus) db.VerificationReport
+}
+
+// swagger:route GET /jobs/{jobId}/verification jobs getJobVerification
+//
+// Returns the verification report of the outputs of a job. The outputs are
+// verified the first time the report is requested after the job finishes,
+// and the report is stored with the job.
+//
+//     Responses:
+//       200: verificationReport
+//       404: jobNotFound
+//       409: verificationUnavailable
+//       410: jobNotFoundInTheProvider
+//       500: genericError
+func (s *TranscodingService) getJobVerification(r *http.Request) swagger.GizmoJSONResponse {
+	var params getJobVerificationInput
+	params.loadParams(server.Vars(r))
+	job, err := s.db.GetJob(params.JobID)
+	if err != nil {
+		if err == db.ErrJobNotFound {
+			return newJobNotFoundResponse(err)
+		}
+		return swagger.NewErrorResponse(err)
+	}
+	if !job.VerifyOutputs {
+		return newVerificationUnavailableResponse(errors.New("output verification was not requested for the job"))
+	}
+	if job.Verification != nil {
+		return newVerificationReportResponse(job.Verification)
+	}
+	job, status, prov, err := s.getTranscodeJobByID(params.JobID)
+	if err != nil {
+		return s.getJobStatusResponse(job, status, prov, err)
+	}
+	if status.Status != provider.StatusFinished {
+		return newVerificationUnavailableResponse(fmt.Errorf("job is %s, outputs can only be verified once it finishes", status.Status))
+	}
+	presets := make(map[string]*db.Preset, len(job.Outputs))
+	for _, output := range job.Outputs {
+		presets[output.Preset.Name] = s.canonicalPreset(output.Preset)
+	}
+	report := s.verifier.Verify(job, presets, status)
+	job.Verification = &report
+	if err = s.db.UpdateJob(job); err != nil {
+		return swagger.NewErrorResponse(err)
+	}
+	return newVerificationReportResponse(&report)
+}
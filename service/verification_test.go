@@ -0,0 +1,203 @@
+package service
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"reflect"
+	"testing"
+	"time"
+
+	"github.com/NYTimes/gizmo/server"
+	"github.com/sirupsen/logrus"
+	"github.com/video-dev/video-transcoding-api/v2/config"
+	"github.com/video-dev/video-transcoding-api/v2/db"
+	"github.com/video-dev/video-transcoding-api/v2/db/dbtest"
+	"github.com/video-dev/video-transcoding-api/v2/internal/provider"
+)
+
+type fakeVerifier struct {
+	report  db.VerificationReport
+	jobs    []string
+	outputs []string
+	presets map[string]*db.Preset
+}
+
+func (v *fakeVerifier) Verify(job *db.Job, presets map[string]*db.Preset, status *provider.JobStatus) db.VerificationReport {
+	v.jobs = append(v.jobs, job.ID)
+	for _, output := range job.Outputs {
+		v.outputs = append(v.outputs, output.FileName)
+	}
+	v.presets = presets
+	return v.report
+}
+
+// storedJobsRepository keeps only the job fields that are stored in Redis,
+// like jobs loaded from the Redis repository.
+type storedJobsRepository struct {
+	db.Repository
+}
+
+func (r storedJobsRepository) GetJob(id string) (*db.Job, error) {
+	job, err := r.Repository.GetJob(id)
+	if err != nil {
+		return nil, err
+	}
+	stored := *job
+	value := reflect.ValueOf(&stored).Elem()
+	for i := 0; i < value.NumField(); i++ {
+		if value.Type().Field(i).Tag.Get("redis-hash") == "-" {
+			value.Field(i).Set(reflect.Zero(value.Field(i).Type()))
+		}
+	}
+	return &stored, nil
+}
+
+func TestGetJobVerification(t *testing.T) {
+	verifiedAt := time.Date(2026, 10, 19, 10, 0, 0, 0, time.UTC)
+	storedReport := db.VerificationReport{Status: db.VerificationPassed, VerifiedAt: verifiedAt}
+	newReport := db.VerificationReport{
+		Status:     db.VerificationFailed,
+		VerifiedAt: verifiedAt,
+		Outputs: []db.OutputVerification{{
+			Path:   "s3://mybucket/some/dir/job-123/output.mp4",
+			Errors: []string{"error fetching the output: unexpected status: 404 Not Found"},
+		}},
+	}
+	tests := []struct {
+		givenTestCase       string
+		givenJob            db.Job
+		givenCanceled       bool
+		givenTriggerDBError bool
+
+		wantCode     int
+		wantBody     map[string]interface{}
+		wantVerified bool
+	}{
+		{
+			givenTestCase: "finished job",
+			givenJob: db.Job{
+				ID:            "job-123",
+				ProviderName:  "fake",
+				ProviderJobID: "provider-job-123",
+				VerifyOutputs: true,
+				Outputs: []db.TranscodeOutput{{
+					Preset:   db.PresetMap{Name: "mp4_1080p", Preset: &db.Preset{Name: "mp4_1080p", Container: "mp4"}},
+					FileName: "output.mp4",
+				}},
+			},
+			wantCode: http.StatusOK,
+			wantBody: map[string]interface{}{
+				"status":     "failed",
+				"verifiedAt": "2026-10-19T10:00:00Z",
+				"outputs": []interface{}{map[string]interface{}{
+					"path":   "s3://mybucket/some/dir/job-123/output.mp4",
+					"errors": []interface{}{"error fetching the output: unexpected status: 404 Not Found"},
+				}},
+			},
+			wantVerified: true,
+		},
+		{
+			givenTestCase: "already verified job",
+			givenJob:      db.Job{ID: "job-123", ProviderName: "fake", ProviderJobID: "provider-job-123", VerifyOutputs: true, Verification: &storedReport},
+			wantCode:      http.StatusOK,
+			wantBody:      map[string]interface{}{"status": "passed", "verifiedAt": "2026-10-19T10:00:00Z"},
+		},
+		{
+			givenTestCase: "verification not requested",
+			givenJob:      db.Job{ID: "job-123", ProviderName: "fake", ProviderJobID: "provider-job-123"},
+			wantCode:      http.StatusConflict,
+			wantBody:      map[string]interface{}{"error": "output verification was not requested for the job"},
+		},
+		{
+			givenTestCase: "unfinished job",
+			givenJob:      db.Job{ID: "job-123", ProviderName: "fake", ProviderJobID: "provider-job-123", VerifyOutputs: true},
+			givenCanceled: true,
+			wantCode:      http.StatusConflict,
+			wantBody:      map[string]interface{}{"error": "job is canceled, outputs can only be verified once it finishes"},
+		},
+		{
+			givenTestCase: "job not found in the provider",
+			givenJob:      db.Job{ID: "job-123", ProviderName: "fake", ProviderJobID: "provider-job-456", VerifyOutputs: true},
+			wantCode:      http.StatusGone,
+			wantBody: map[string]interface{}{
+				"error": `error with provider "fake" when trying to retrieve job id "job-123": could not found job with id: provider-job-456`,
+			},
+		},
+		{
+			givenTestCase: "job not found",
+			givenJob:      db.Job{ID: "job-456", ProviderName: "fake", ProviderJobID: "provider-job-123", VerifyOutputs: true},
+			wantCode:      http.StatusNotFound,
+			wantBody:      map[string]interface{}{"error": "job not found"},
+		},
+		{
+			givenTestCase:       "database error",
+			givenJob:            db.Job{ID: "job-123", ProviderName: "fake", ProviderJobID: "provider-job-123", VerifyOutputs: true},
+			givenTriggerDBError: true,
+			wantCode:            http.StatusInternalServerError,
+			wantBody:            map[string]interface{}{"error": "database error"},
+		},
+	}
+	for _, test := range tests {
+		test := test
+		t.Run(test.givenTestCase, func(t *testing.T) {
+			fprovider.canceledJobs = nil
+			if test.givenCanceled {
+				fprovider.canceledJobs = []string{test.givenJob.ProviderJobID}
+			}
+			defer func() { fprovider.canceledJobs = nil }()
+			srvr := server.NewSimpleServer(&server.Config{})
+			fakeDBObj := dbtest.NewFakeRepository(false)
+			job := test.givenJob
+			fakeDBObj.CreateJob(&job)
+			if test.givenTriggerDBError {
+				fakeDBObj = dbtest.NewFakeRepository(true)
+			}
+			service, err := NewTranscodingService(&config.Config{Server: &server.Config{}}, logrus.New())
+			if err != nil {
+				t.Fatal(err)
+			}
+			verifier := &fakeVerifier{report: newReport}
+			service.db = storedJobsRepository{fakeDBObj}
+			service.verifier = verifier
+			srvr.Register(service)
+			r, _ := http.NewRequest("GET", "/jobs/job-123/verification", nil)
+			w := httptest.NewRecorder()
+			srvr.ServeHTTP(w, r)
+			if w.Code != test.wantCode {
+				t.Errorf("wrong response code. Want %d. Got %d", test.wantCode, w.Code)
+			}
+			var got map[string]interface{}
+			err = json.NewDecoder(w.Body).Decode(&got)
+			if err != nil {
+				t.Fatalf("unable to JSON decode response body: %s", err)
+			}
+			if !reflect.DeepEqual(got, test.wantBody) {
+				t.Errorf("wrong response body\nwant %#v\ngot  %#v", test.wantBody, got)
+			}
+			if !test.wantVerified {
+				if len(verifier.jobs) > 0 {
+					t.Errorf("unexpected verification of jobs %#v", verifier.jobs)
+				}
+				return
+			}
+			if !reflect.DeepEqual(verifier.jobs, []string{"job-123"}) {
+				t.Errorf("wrong jobs verified: %#v", verifier.jobs)
+			}
+			if !reflect.DeepEqual(verifier.outputs, []string{"output.mp4"}) {
+				t.Errorf("wrong outputs verified: %#v", verifier.outputs)
+			}
+			wantPresets := map[string]*db.Preset{"mp4_1080p": {Name: "mp4_1080p", Container: "mp4"}}
+			if !reflect.DeepEqual(verifier.presets, wantPresets) {
+				t.Errorf("wrong presets used in the verification\nwant %#v\ngot  %#v", wantPresets, verifier.presets)
+			}
+			storedJob, err := fakeDBObj.GetJob("job-123")
+			if err != nil {
+				t.Fatal(err)
+			}
+			if storedJob.Verification == nil || !reflect.DeepEqual(*storedJob.Verification, newReport) {
+				t.Errorf("wrong verification stored in the job\nwant %#v\ngot  %#v", newReport, storedJob.Verification)
+			}
+		})
+	}
+}